@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+)
+
+func runCreateAdmin(ctx context.Context, e *env, args []string) error {
+	fs := flags("create-admin")
+	email := fs.String("email", "", "admin email")
+	password := fs.String("password", "", "admin password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "email", "password"); err != nil {
+		return err
+	}
+
+	svc, err := e.services(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.pool.Close()
+
+	u, err := svc.users.SeedAdmin(ctx, *email, *password)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "admin %d created: %s\n", u.ID, u.Email)
+	return nil
+}
+
+func runCreatePharmacy(ctx context.Context, e *env, args []string) error {
+	fs := flags("create-pharmacy")
+	var p pharmacy.CreateParams
+	fs.StringVar(&p.Name, "name", "", "pharmacy name")
+	fs.StringVar(&p.Address, "address", "", "pharmacy address")
+	fs.StringVar(&p.Phone, "phone", "", "pharmacy phone")
+	fs.StringVar(&p.Email, "email", "", "pharmacy email")
+	fs.StringVar(&p.OwnerName, "owner-name", "", "owner's full name")
+	fs.StringVar(&p.OwnerEmail, "owner-email", "", "owner's login email")
+	fs.StringVar(&p.OwnerPassword, "owner-password", "", "owner's password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "name", "address", "owner-name", "owner-email", "owner-password"); err != nil {
+		return err
+	}
+
+	svc, err := e.services(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.pool.Close()
+
+	ph, err := svc.pharmacies.CreateWithOwner(ctx, p)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "pharmacy %d created: %s, owner %s\n", ph.ID, ph.Name, p.OwnerEmail)
+	return nil
+}
+
+func runCreateOwner(ctx context.Context, e *env, args []string) error {
+	fs := flags("create-owner")
+	p := pharmacy.CreatePersonnelParams{Role: permission.RoleOwner}
+	fs.Int64Var(&p.PharmacyID, "pharmacy", 0, "pharmacy ID")
+	fs.StringVar(&p.Name, "name", "", "owner's full name")
+	fs.StringVar(&p.Email, "email", "", "owner's login email")
+	fs.StringVar(&p.Password, "password", "", "owner's password")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "pharmacy", "name", "email", "password"); err != nil {
+		return err
+	}
+
+	svc, err := e.services(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.pool.Close()
+
+	if _, err := svc.pharmacies.Get(ctx, p.PharmacyID); err != nil {
+		return fmt.Errorf("pharmacy %d: %w", p.PharmacyID, err)
+	}
+	m, err := svc.pharmacies.CreatePersonnel(ctx, p)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "owner %d created: %s\n", m.ID, m.Email)
+	return nil
+}
+
+func runResetPassword(ctx context.Context, e *env, args []string) error {
+	fs := flags("reset-password")
+	email := fs.String("email", "", "user's login email")
+	password := fs.String("password", "", "temporary password (generated if empty)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "email"); err != nil {
+		return err
+	}
+	generated := *password == ""
+	if generated {
+		*password = rand.Text()
+	}
+
+	svc, err := e.services(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.pool.Close()
+
+	u, err := svc.users.FindByEmail(ctx, *email)
+	if err != nil {
+		return fmt.Errorf("user %s: %w", *email, err)
+	}
+	if err := svc.users.SetTemporaryPassword(ctx, u.ID, *password); err != nil {
+		if errors.Is(err, user.ErrFederated) {
+			return fmt.Errorf("%s signs in through their pharmacy's identity provider and has no password to reset", u.Email)
+		}
+		return err
+	}
+	fmt.Fprintf(e.out, "password of %s reset; they must change it at the next login and were logged out everywhere\n", u.Email)
+	if generated {
+		fmt.Fprintf(e.out, "temporary password: %s\n", *password)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giorgiovilardo/pharmarecall/db/migrations"
+)
+
+// runCheckConfig loads and validates the configuration the way the server
+// does at startup; with --connect it also reaches the database and checks
+// its migrations are current.
+func runCheckConfig(ctx context.Context, e *env, args []string) error {
+	fs := flags("check-config")
+	connect := fs.Bool("connect", false, "also connect to the database and check migrations")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if _, err := e.config(); err != nil {
+		return err
+	}
+	if *connect {
+		conn, err := e.sqlDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if err := conn.PingContext(ctx); err != nil {
+			return fmt.Errorf("connecting to database: %w", err)
+		}
+		if err := migrations.CheckVersion(ctx, conn); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(e.out, "configuration OK")
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/config"
+	"github.com/giorgiovilardo/pharmarecall/internal/demo"
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+)
+
+// runGenerateDemo fills the database with demo pharmacies, staff, patients,
+// prescriptions and orders. The same flags give the same data on an empty
+// database, and only an empty database is filled: demo data mixed into real
+// pharmacies could not be told apart. A production configuration needs
+// --force.
+func runGenerateDemo(ctx context.Context, e *env, args []string) error {
+	fs := flags("generate-demo")
+	var opts demo.Options
+	fs.Uint64Var(&opts.Seed, "seed", 1, "random seed")
+	fs.IntVar(&opts.Pharmacies, "pharmacies", 3, "number of pharmacies")
+	fs.IntVar(&opts.Staff, "staff", 4, "staff per pharmacy, besides the owner")
+	fs.IntVar(&opts.Patients, "patients", 40, "patients per pharmacy")
+	fs.IntVar(&opts.HistoryDays, "history", 180, "days of refills and orders before the date")
+	fs.StringVar(&opts.Password, "password", "", "password of every staff account")
+	date := fs.String("date", "", "generate as of this day, YYYY-MM-DD (default today)")
+	force := fs.Bool("force", false, "generate even with a production configuration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "password"); err != nil {
+		return err
+	}
+	var err error
+	if opts.Today, err = parseDate(*date); err != nil {
+		return err
+	}
+
+	cfg, err := e.config()
+	if err != nil {
+		return err
+	}
+	if cfg.Server.Env == config.EnvProduction && !*force {
+		return errors.New("generate-demo refuses a production configuration; pass --force if this database really is for demos")
+	}
+
+	svc, err := e.services(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.pool.Close()
+
+	existing, err := svc.pharmacies.List(ctx)
+	if err != nil {
+		return fmt.Errorf("checking the database is empty: %w", err)
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("generate-demo needs an empty database, this one has %d pharmacies", len(existing))
+	}
+
+	opts.LookaheadDays = svc.cfg.Lookahead.Days
+	summaries, err := demo.Generate(ctx, demo.Deps{
+		Pharmacies:    svc.pharmacies,
+		Patients:      svc.patients,
+		Prescriptions: svc.prescriptions,
+		Orders:        svc.orders,
+		Notifications: svc.notifications,
+	}, opts)
+	if err != nil {
+		return err
+	}
+	for _, s := range summaries {
+		fmt.Fprintf(e.out, "pharmacy %d %s: owner %s, %d staff, %d patients, %d prescriptions, orders %d pending, %d prepared, %d fulfilled\n",
+			s.PharmacyID, s.Name, s.OwnerEmail, s.Staff, s.Patients, s.Prescriptions,
+			s.Orders[order.StatusPending], s.Orders[order.StatusPrepared], s.Orders[order.StatusFulfilled])
+	}
+	return nil
+}
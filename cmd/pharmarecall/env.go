@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/auth"
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+	"github.com/giorgiovilardo/pharmarecall/internal/config"
+	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/giorgiovilardo/pharmarecall/internal/gateway"
+	"github.com/giorgiovilardo/pharmarecall/internal/notification"
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+)
+
+// env is what commands share: where the configuration is and where their
+// output goes.
+type env struct {
+	configPath string
+	out        io.Writer
+}
+
+// config loads and validates the configuration.
+func (e *env) config() (config.Config, error) {
+	cfg, err := config.Load(e.configPath)
+	if err != nil {
+		return config.Config{}, fmt.Errorf("loading config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return config.Config{}, err
+	}
+	for _, name := range cfg.UnknownEnv {
+		slog.Warn("ignoring environment variable that names no config key", "name", name)
+	}
+	for _, name := range cfg.ServiceLinkEnv {
+		slog.Warn("ignoring service address in a numeric config key", "name", name)
+	}
+	return cfg, nil
+}
+
+// sqlDB opens the database/sql connection goose migrates through.
+func (e *env) sqlDB() (*sql.DB, error) {
+	cfg, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sql.Open("pgx", cfg.DB.URL)
+	if err != nil {
+		return nil, fmt.Errorf("opening sql db: %w", err)
+	}
+	return conn, nil
+}
+
+// services are the domain services commands work through, wired as in the
+// server.
+type services struct {
+	cfg           config.Config
+	pool          *pgxpool.Pool
+	users         *user.Service
+	pharmacies    *pharmacy.Service
+	patients      *patient.Service
+	prescriptions *prescription.Service
+	orders        *order.Service
+	notifications *notification.Service
+}
+
+// services connects to the database and builds the domain services. Close
+// the pool when done.
+func (e *env) services(ctx context.Context) (*services, error) {
+	cfg, err := e.config()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.New(ctx, cfg.DB.URL)
+	if err != nil {
+		return nil, fmt.Errorf("creating connection pool: %w", err)
+	}
+	queries := db.New(pool)
+
+	patientSvc := patient.NewService(patient.NewPgxRepository(pool, queries))
+	prescriptionSvc := prescription.NewService(prescription.NewPgxRepository(pool, queries), patientSvc)
+	calendarSvc := calendar.NewService(calendar.NewPgxRepository(pool, queries))
+	pickupSvc := pickup.NewService(pickup.NewPgxRepository(pool, queries), gateway.PickupNotifier(cfg), calendarSvc)
+
+	return &services{
+		cfg:           cfg,
+		pool:          pool,
+		users:         user.NewService(user.NewPgxRepository(pool, queries), gateway.Mailer(cfg), auth.HashPassword, auth.VerifyPassword),
+		pharmacies:    pharmacy.NewService(pharmacy.NewPgxRepository(pool, queries), auth.HashPassword),
+		patients:      patientSvc,
+		prescriptions: prescriptionSvc,
+		orders:        order.NewService(order.NewPgxRepository(pool, queries), prescriptionSvc, pickupSvc, calendarSvc),
+		notifications: notification.NewService(notification.NewPgxRepository(pool, queries)),
+	}, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/notification"
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+// export is the document runExport writes: everything the staff of one
+// pharmacy can see, as the domain services return it.
+type export struct {
+	ExportedAt    time.Time
+	Pharmacy      pharmacy.Pharmacy
+	Settings      pharmacy.Settings
+	Personnel     []pharmacy.PersonnelMember
+	Patients      []exportedPatient
+	Orders        []order.DashboardEntry
+	Notifications []notification.Notification
+}
+
+type exportedPatient struct {
+	patient.Patient
+	Prescriptions []prescription.Prescription
+}
+
+// runExport writes a pharmacy's data as indented JSON to a file or stdout.
+func runExport(ctx context.Context, e *env, args []string) error {
+	fs := flags("export")
+	pharmacyID := fs.Int64("pharmacy", 0, "pharmacy ID")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := required(fs, "pharmacy"); err != nil {
+		return err
+	}
+
+	svc, err := e.services(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.pool.Close()
+
+	doc, err := collectExport(ctx, svc, *pharmacyID)
+	if err != nil {
+		return fmt.Errorf("pharmacy %d: %w", *pharmacyID, err)
+	}
+
+	var w io.Writer = e.out
+	if *out != "" {
+		f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("writing export: %w", err)
+	}
+	if *out != "" {
+		fmt.Fprintf(e.out, "pharmacy %d exported to %s: %d patients, %d orders\n", *pharmacyID, *out, len(doc.Patients), len(doc.Orders))
+	}
+	return nil
+}
+
+func collectExport(ctx context.Context, svc *services, pharmacyID int64) (export, error) {
+	doc := export{ExportedAt: time.Now()}
+	var err error
+	if doc.Pharmacy, err = svc.pharmacies.Get(ctx, pharmacyID); err != nil {
+		return export{}, err
+	}
+	if doc.Settings, err = svc.pharmacies.Settings(ctx, pharmacyID); err != nil {
+		return export{}, err
+	}
+	if doc.Personnel, err = svc.pharmacies.ListPersonnel(ctx, pharmacyID); err != nil {
+		return export{}, err
+	}
+	summaries, err := svc.patients.List(ctx, pharmacyID)
+	if err != nil {
+		return export{}, err
+	}
+	for _, s := range summaries {
+		p, err := svc.patients.Get(ctx, s.ID)
+		if err != nil {
+			return export{}, err
+		}
+		rxs, err := svc.prescriptions.ListByPatient(ctx, s.ID)
+		if err != nil {
+			return export{}, err
+		}
+		doc.Patients = append(doc.Patients, exportedPatient{Patient: p, Prescriptions: rxs})
+	}
+	if doc.Orders, err = svc.orders.ListDashboard(ctx, pharmacyID); err != nil {
+		return export{}, err
+	}
+	if doc.Notifications, err = svc.notifications.List(ctx, pharmacyID); err != nil {
+		return export{}, err
+	}
+	return doc, nil
+}
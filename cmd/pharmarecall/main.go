@@ -0,0 +1,133 @@
+// Command pharmarecall manages a PharmaRecall installation: migrations,
+// pharmacies and accounts, sessions, order generation and data exports. It
+// reads the same configuration as the server and goes through the domain
+// services, so no operation needs raw SQL.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/config"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+)
+
+// command is one subcommand. run receives the arguments after its name.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, env *env, args []string) error
+}
+
+func commands() []command {
+	return []command{
+		{"migrate", "up | down | to <version> | version", "apply, roll back or inspect database migrations", runMigrate},
+		{"create-admin", "--email <email> --password <password>", "create a platform admin", runCreateAdmin},
+		{"create-pharmacy", "--name <name> --address <address> --owner-name <name> --owner-email <email> --owner-password <password> [--phone] [--email]", "create a pharmacy with its owner", runCreatePharmacy},
+		{"create-owner", "--pharmacy <id> --name <name> --email <email> --password <password>", "add an owner to a pharmacy", runCreateOwner},
+		{"reset-password", "--email <email> [--password <password>]", "set a temporary password, generated if not given", runResetPassword},
+		{"sessions", "list --email <email> | revoke (--email <email> [--id <id>] | --pharmacy <id>)", "list or revoke staff sessions", runSessions},
+		{"generate-orders", "[--date YYYY-MM-DD] [--pharmacy <id>]", "create due orders and notifications as of a date", runGenerateOrders},
+		{"export", "--pharmacy <id> [--out <file>]", "export a pharmacy's data as JSON", runExport},
+		{"generate-demo", "--password <password> [--force] [--seed <n>] [--pharmacies <n>] [--staff <n>] [--patients <n>] [--history <days>] [--date YYYY-MM-DD]", "fill an empty database with realistic demo data", runGenerateDemo},
+		{"check-config", "[--connect]", "validate the configuration, optionally reaching the database", runCheckConfig},
+	}
+}
+
+func main() {
+	configPath := flag.String("config", config.DefaultPath, "path to config file, skipped when the default is missing (empty to read only PHARMARECALL_* variables)")
+	flag.Usage = func() { usage(os.Stderr) }
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	path := *configPath
+	if !flagSet("config") {
+		var err error
+		if path, err = config.DefaultFile(); err != nil {
+			fmt.Fprintln(os.Stderr, "pharmarecall:", err)
+			os.Exit(1)
+		}
+	}
+	err := run(ctx, &env{configPath: path, out: os.Stdout}, flag.Args())
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pharmarecall:", err)
+		os.Exit(1)
+	}
+}
+
+// flagSet reports whether the flag name was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
+
+// run dispatches args to their command. Commands act as the system, so
+// services do not check staff permissions for them.
+func run(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return flag.ErrHelp
+	}
+	for _, c := range commands() {
+		if c.name == args[0] {
+			return c.run(permission.WithSystem(ctx), e, args[1:])
+		}
+	}
+	usage(os.Stderr)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: pharmarecall [--config <file>] <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %-16s %s\n  %-16s   %s\n", c.name, c.summary, "", c.usage)
+	}
+}
+
+// flags returns a flag set for the command that reports errors instead of
+// exiting.
+func flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("pharmarecall "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parseDate parses a --date flag, YYYY-MM-DD in local time; empty is now.
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	d, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --date %q: want YYYY-MM-DD", value)
+	}
+	return d, nil
+}
+
+// required returns an error naming the first of the flags left empty.
+func required(fs *flag.FlagSet, names ...string) error {
+	for _, name := range names {
+		if v := fs.Lookup(name).Value.String(); v == "" || v == "0" {
+			return fmt.Errorf("--%s is required", name)
+		}
+	}
+	return nil
+}
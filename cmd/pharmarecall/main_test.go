@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const validConfig = `
+[db]
+url = "postgres://localhost/pharmarecall"
+
+[session]
+secret = "s"
+`
+
+func TestRunRefusesBadArguments(t *testing.T) {
+	e := &env{configPath: writeConfig(t, validConfig), out: &bytes.Buffer{}}
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown command", []string{"frobnicate"}, `unknown command "frobnicate"`},
+		{"missing required flag", []string{"create-admin", "--email", "a@example.it"}, "--password is required"},
+		{"missing pharmacy", []string{"create-owner", "--name", "Anna", "--email", "a@example.it", "--password", "x"}, "--pharmacy is required"},
+		{"migrate without action", []string{"migrate"}, "migrate needs"},
+		{"sessions without action", []string{"sessions"}, "sessions needs list or revoke"},
+		{"sessions revoke with both targets", []string{"sessions", "revoke", "--email", "a@example.it", "--pharmacy", "1"}, "either --email or --pharmacy"},
+		{"session ID without email", []string{"sessions", "revoke", "--pharmacy", "1", "--id", "3"}, "--id needs --email"},
+		{"malformed date", []string{"generate-orders", "--date", "18/10/2026"}, "invalid --date"},
+		{"demo without password", []string{"generate-demo", "--seed", "7"}, "--password is required"},
+		{"undefined flag", []string{"export", "--pharmacy", "1", "--format", "csv"}, "flag provided but not defined"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := run(context.Background(), e, tc.args)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("run(%q) = %v, want error containing %q", tc.args, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestGenerateDemoRefusesProduction(t *testing.T) {
+	e := &env{configPath: writeConfig(t, `
+[server]
+env = "production"
+
+[db]
+url = "postgres://localhost/pharmarecall"
+
+[session]
+secret = "0123456789abcdef0123456789abcdef"
+
+[mail]
+host = "smtp.example.it"
+from = "farmacia@example.it"
+
+[sms]
+url = "https://sms.example.it/send"
+`), out: &bytes.Buffer{}}
+	err := run(context.Background(), e, []string{"generate-demo", "--password", "demo"})
+	if err == nil || !strings.Contains(err.Error(), "refuses a production configuration") {
+		t.Errorf("err = %v, want a refusal of the production configuration", err)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	t.Run("accepts a valid configuration", func(t *testing.T) {
+		var out bytes.Buffer
+		e := &env{configPath: writeConfig(t, validConfig), out: &out}
+		if err := run(context.Background(), e, []string{"check-config"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := out.String(); got != "configuration OK\n" {
+			t.Errorf("output = %q, want configuration OK", got)
+		}
+	})
+
+	t.Run("reports validation problems", func(t *testing.T) {
+		e := &env{configPath: writeConfig(t, "[server]\nport = 8080\n"), out: &bytes.Buffer{}}
+		err := run(context.Background(), e, []string{"check-config"})
+		if err == nil || !strings.Contains(err.Error(), "db.url") {
+			t.Errorf("err = %v, want a db.url problem", err)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/giorgiovilardo/pharmarecall/db/migrations"
+)
+
+// runMigrate applies all migrations, rolls back the last one, migrates to a
+// version or prints the current one.
+func runMigrate(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate needs up, down, to <version> or version")
+	}
+	conn, err := e.sqlDB()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	switch args[0] {
+	case "up":
+		err = migrations.Run(conn)
+	case "down":
+		err = migrations.Down(conn)
+	case "to":
+		if len(args) != 2 {
+			return errors.New("migrate to needs a version")
+		}
+		version, perr := strconv.ParseInt(args[1], 10, 64)
+		if perr != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		err = migrations.To(ctx, conn, version)
+	case "version":
+	default:
+		return fmt.Errorf("unknown migrate action %q", args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := migrations.Version(ctx, conn)
+	if err != nil {
+		return err
+	}
+	latest, err := migrations.Latest()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "database at migration %d (latest %d)\n", current, latest)
+	return nil
+}
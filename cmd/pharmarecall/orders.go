@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+// runGenerateOrders does what opening the dashboard does, for every
+// pharmacy or one: it creates the orders due within each pharmacy's
+// lookahead and the notifications of approaching prescriptions, as of date.
+func runGenerateOrders(ctx context.Context, e *env, args []string) error {
+	fs := flags("generate-orders")
+	date := fs.String("date", "", "generate as of this day, YYYY-MM-DD (default today)")
+	pharmacyID := fs.Int64("pharmacy", 0, "only this pharmacy (default all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	now, err := parseDate(*date)
+	if err != nil {
+		return err
+	}
+
+	svc, err := e.services(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.pool.Close()
+
+	ids := []int64{*pharmacyID}
+	if *pharmacyID == 0 {
+		all, err := svc.pharmacies.List(ctx)
+		if err != nil {
+			return err
+		}
+		ids = ids[:0]
+		for _, ph := range all {
+			ids = append(ids, ph.ID)
+		}
+	}
+
+	for _, id := range ids {
+		days, err := svc.pharmacies.LookaheadDays(ctx, id, svc.cfg.Lookahead.Days)
+		if err != nil {
+			return fmt.Errorf("pharmacy %d: %w", id, err)
+		}
+		if err := svc.orders.EnsureOrders(ctx, id, now, days); err != nil {
+			return fmt.Errorf("pharmacy %d: %w", id, err)
+		}
+		entries, err := svc.orders.ListDashboard(ctx, id)
+		if err != nil {
+			return fmt.Errorf("pharmacy %d: %w", id, err)
+		}
+		var approaching []int64
+		for _, entry := range entries {
+			if entry.PrescriptionStatus(now) == prescription.StatusApproaching {
+				approaching = append(approaching, entry.PrescriptionID)
+			}
+		}
+		if err := svc.notifications.GenerateApproaching(ctx, id, approaching); err != nil {
+			return fmt.Errorf("pharmacy %d: %w", id, err)
+		}
+		fmt.Fprintf(e.out, "pharmacy %d: %d open orders, %d approaching, lookahead %d days\n", id, len(entries), len(approaching), days)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+// runSessions lists a user's live sessions, or revokes one of them, all of
+// them, or those of everyone at a pharmacy.
+func runSessions(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "revoke") {
+		return errors.New("sessions needs list or revoke")
+	}
+	action := args[0]
+	fs := flags("sessions " + action)
+	email := fs.String("email", "", "user's login email")
+	id := fs.Int64("id", 0, "session ID, from sessions list (revoke only)")
+	pharmacyID := fs.Int64("pharmacy", 0, "pharmacy ID: revoke the sessions of all its staff (revoke only)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	switch {
+	case action == "list" && *email == "":
+		return errors.New("--email is required")
+	case action == "revoke" && (*email == "") == (*pharmacyID == 0):
+		return errors.New("give either --email or --pharmacy")
+	case *id != 0 && *email == "":
+		return errors.New("--id needs --email")
+	}
+
+	svc, err := e.services(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.pool.Close()
+
+	if *pharmacyID != 0 {
+		n, err := svc.pharmacies.RevokeSessions(ctx, *pharmacyID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.out, "%d sessions revoked at pharmacy %d\n", n, *pharmacyID)
+		return nil
+	}
+
+	u, err := svc.users.FindByEmail(ctx, *email)
+	if err != nil {
+		return fmt.Errorf("user %s: %w", *email, err)
+	}
+	switch {
+	case action == "list":
+		sessions, err := svc.users.Sessions(ctx, u.ID, "")
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSTARTED\tLAST SEEN\tIP\tDEVICE")
+		for _, s := range sessions {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", s.ID, s.CreatedAt.Format(time.DateTime), s.LastSeenAt.Format(time.DateTime), s.Device.IP, s.Device.UserAgent)
+		}
+		return tw.Flush()
+	case *id != 0:
+		if err := svc.users.RevokeSession(ctx, u.ID, *id); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.out, "session %d of %s revoked\n", *id, u.Email)
+	default:
+		// No session is kept: an empty token matches none.
+		n, err := svc.users.RevokeOtherSessions(ctx, u.ID, "")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.out, "%d sessions of %s revoked\n", n, u.Email)
+	}
+	return nil
+}
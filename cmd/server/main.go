@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -14,14 +15,25 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 
 	"github.com/giorgiovilardo/pharmarecall/db/migrations"
+	"github.com/giorgiovilardo/pharmarecall/internal/analytics"
 	"github.com/giorgiovilardo/pharmarecall/internal/auth"
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
 	"github.com/giorgiovilardo/pharmarecall/internal/config"
 	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/giorgiovilardo/pharmarecall/internal/gateway"
+	"github.com/giorgiovilardo/pharmarecall/internal/logging"
+	"github.com/giorgiovilardo/pharmarecall/internal/metrics"
 	"github.com/giorgiovilardo/pharmarecall/internal/notification"
 	"github.com/giorgiovilardo/pharmarecall/internal/order"
 	"github.com/giorgiovilardo/pharmarecall/internal/patient"
 	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/portal"
 	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+	"github.com/giorgiovilardo/pharmarecall/internal/role"
+	"github.com/giorgiovilardo/pharmarecall/internal/shipping"
+	"github.com/giorgiovilardo/pharmarecall/internal/sso"
+	"github.com/giorgiovilardo/pharmarecall/internal/tracing"
 	"github.com/giorgiovilardo/pharmarecall/internal/user"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
@@ -29,20 +41,59 @@ import (
 )
 
 func main() {
-	if err := run(); err != nil {
+	configPath := flag.String("config", config.DefaultPath, "path to config file, skipped when the default is missing (empty to read only PHARMARECALL_* variables)")
+	flag.Parse()
+
+	if err := run(*configPath, flagSet("config")); err != nil {
 		slog.Error("fatal", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run() error {
+// flagSet reports whether the flag name was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
+
+func run(configPath string, explicitConfig bool) error {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	cfg, err := config.Load("config.toml")
+	if !explicitConfig {
+		var err error
+		if configPath, err = config.DefaultFile(); err != nil {
+			return err
+		}
+	}
+	cfg, err := config.Load(configPath)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	slog.SetDefault(logging.New(os.Stderr, cfg.Log.Format))
+	for _, name := range cfg.UnknownEnv {
+		slog.Warn("ignoring environment variable that names no config key", "name", name)
+	}
+	for _, name := range cfg.ServiceLinkEnv {
+		slog.Warn("ignoring service address in a numeric config key", "name", name)
+	}
+	if gateway.LogsMessages(cfg) {
+		slog.Warn("no mail server or SMS gateway configured: password resets, patient logins and pickup notices are written to the log")
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Endpoint)
+	if err != nil {
+		return fmt.Errorf("setting up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("flushing traces", "error", err)
+		}
+	}()
 
 	// database/sql connection for goose migrations
 	sqlDB, err := sql.Open("pgx", cfg.DB.URL)
@@ -56,18 +107,26 @@ func run() error {
 	}
 
 	// pgxpool for application use
-	pool, err := pgxpool.New(ctx, cfg.DB.URL)
+	poolCfg, err := pgxpool.ParseConfig(cfg.DB.URL)
+	if err != nil {
+		return fmt.Errorf("parsing database URL: %w", err)
+	}
+	if cfg.Tracing.Endpoint != "" {
+		poolCfg.ConnConfig.Tracer = tracing.QueryTracer{}
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return fmt.Errorf("creating connection pool: %w", err)
 	}
 	defer pool.Close()
 
 	queries := db.New(pool)
-	sm := auth.NewSessionManager(pool)
+	sm := auth.NewSessionManager(pool, cfg.Session.IdleTimeout)
+	patientSM := auth.NewPatientSessionManager(pool)
 
 	// Domain services
 	userRepo := user.NewPgxRepository(pool, queries)
-	userSvc := user.NewService(userRepo, auth.HashPassword, auth.VerifyPassword)
+	userSvc := user.NewService(userRepo, gateway.Mailer(cfg), auth.HashPassword, auth.VerifyPassword)
 
 	pharmacyRepo := pharmacy.NewPgxRepository(pool, queries)
 	pharmacySvc := pharmacy.NewService(pharmacyRepo, auth.HashPassword)
@@ -78,31 +137,95 @@ func run() error {
 	prescriptionRepo := prescription.NewPgxRepository(pool, queries)
 	prescriptionSvc := prescription.NewService(prescriptionRepo, patientSvc)
 
+	calendarRepo := calendar.NewPgxRepository(pool, queries)
+	calendarSvc := calendar.NewService(calendarRepo)
+
+	pickupRepo := pickup.NewPgxRepository(pool, queries)
+	pickupSvc := pickup.NewService(pickupRepo, gateway.PickupNotifier(cfg), calendarSvc)
+
 	orderRepo := order.NewPgxRepository(pool, queries)
-	orderSvc := order.NewService(orderRepo, prescriptionSvc)
+	orderSvc := order.NewService(orderRepo, prescriptionSvc, pickupSvc, calendarSvc)
+	orderRefs := order.NewReferenceSigner(cfg.Session.Secret)
+
+	shippingRepo := shipping.NewPgxRepository(pool, queries)
+	shippingSvc := shipping.NewService(shippingRepo)
+
+	portalRepo := portal.NewPgxRepository(pool, queries)
+	portalSvc := portal.NewService(portalRepo, gateway.LoginSender(cfg), pickupSvc, orderSvc)
+
+	analyticsRepo := analytics.NewPgxRepository(pool, queries)
+	analyticsSvc := analytics.NewService(analyticsRepo, calendarSvc)
 
 	notificationRepo := notification.NewPgxRepository(pool, queries)
 	notificationSvc := notification.NewService(notificationRepo)
 
+	roleRepo := role.NewPgxRepository(pool, queries)
+	roleSvc := role.NewService(roleRepo)
+
+	ssoRepo := sso.NewPgxRepository(pool, queries)
+	ssoSvc := sso.NewService(ssoRepo, sso.NewOIDCProvider(&http.Client{Timeout: 10 * time.Second}))
+
 	// Build handlers
 	mux := web.NewRouter(web.Handlers{
 		LoginPage:      handler.HandleLoginPage(),
-		LoginPost:      handler.HandleLoginPost(sm, userSvc, pharmacySvc),
+		LoginPost:      handler.HandleLoginPost(sm, userSvc, userSvc, pharmacySvc),
 		Logout:         handler.HandleLogout(sm),
 		ChangePassPage: handler.HandleChangePasswordPage(),
 		ChangePassPost: handler.HandleChangePasswordPost(sm, userSvc),
+		ForgotPassPage: handler.HandleForgotPasswordPage(),
+		ForgotPassPost: handler.HandleForgotPasswordPost(userSvc, cfg.Server.BaseURL),
+		ResetPassPage:  handler.HandleResetPasswordPage(),
+		ResetPassPost:  handler.HandleResetPasswordPost(userSvc),
+		LoginCodePage:  handler.HandleTwoFactorLoginPage(sm),
+		LoginCodePost:  handler.HandleTwoFactorLoginPost(sm, userSvc, userSvc, pharmacySvc),
+		SSOLogin:       handler.HandleSSOLogin(sm, ssoSvc, cfg.Server.BaseURL),
+		SSOCallback:    handler.HandleSSOCallback(sm, ssoSvc, userSvc, userSvc, pharmacySvc, cfg.Server.BaseURL),
+		Account:        handler.HandleAccountPage(userSvc, userSvc),
+		SetLocale:      handler.HandleSetLocale(sm, userSvc),
+		TwoFactor: web.TwoFactorHandlers{
+			Settings:      handler.HandleTwoFactorSettings(sm, userSvc, userSvc),
+			Enable:        handler.HandleEnableTwoFactor(sm, userSvc, userSvc),
+			Disable:       handler.HandleDisableTwoFactor(sm, userSvc, userSvc),
+			RecoveryCodes: handler.HandleRegenerateRecoveryCodes(sm, userSvc, userSvc),
+		},
+		Sessions: web.SessionHandlers{
+			List:         handler.HandleSessionsPage(sm, userSvc),
+			Revoke:       handler.HandleRevokeSession(sm, userSvc),
+			RevokeOthers: handler.HandleRevokeOtherSessions(sm, userSvc),
+		},
 		Owner: web.OwnerHandlers{
 			PersonnelList:   handler.HandleOwnerPersonnelList(pharmacySvc),
-			AddPersonnel:    handler.HandleOwnerAddPersonnelPage(),
-			CreatePersonnel: handler.HandleOwnerCreatePersonnel(pharmacySvc),
+			AddPersonnel:    handler.HandleOwnerAddPersonnelPage(roleSvc),
+			CreatePersonnel: handler.HandleOwnerCreatePersonnel(pharmacySvc, roleSvc),
+			Analytics:       handler.HandleAnalyticsPage(analyticsSvc),
+			Group:           handler.HandleGroupDashboard(pharmacySvc),
+			SwitchBranch:    handler.HandleSwitchBranch(sm, pharmacySvc),
+			Personnel:       personnelHandlers(handler.OwnerPersonnelScope, pharmacySvc, roleSvc),
+			Roles: web.RoleHandlers{
+				List:   handler.HandleRoles(roleSvc),
+				New:    handler.HandleNewRole(),
+				Create: handler.HandleCreateRole(roleSvc),
+				Edit:   handler.HandleEditRole(roleSvc),
+				Update: handler.HandleUpdateRole(roleSvc),
+				Delete: handler.HandleDeleteRole(roleSvc, roleSvc),
+			},
+			Settings: web.SettingsHandlers{
+				Page:       handler.HandleSettingsPage(pharmacySvc),
+				Update:     handler.HandleUpdateSettings(pharmacySvc, pharmacySvc),
+				Logo:       handler.HandleLogo(pharmacySvc),
+				UploadLogo: handler.HandleUploadLogo(pharmacySvc, pharmacySvc),
+				RemoveLogo: handler.HandleRemoveLogo(pharmacySvc),
+			},
 		},
 		Patient: web.PatientHandlers{
 			List:         handler.HandlePatientList(patientSvc),
-			New:          handler.HandleNewPatientPage(),
+			New:          handler.HandleNewPatientPage(pharmacySvc),
 			Create:       handler.HandleCreatePatient(patientSvc),
 			Detail:       handler.HandlePatientDetail(patientSvc, prescriptionSvc),
 			Update:       handler.HandleUpdatePatient(patientSvc, patientSvc, prescriptionSvc),
 			SetConsensus: handler.HandleSetConsensus(patientSvc),
+			TransferPage: handler.HandlePatientTransferPage(patientSvc, pharmacySvc),
+			Transfer:     handler.HandleTransferPatient(patientSvc, pharmacySvc, patientSvc),
 		},
 		Prescription: web.PrescriptionHandlers{
 			New:          handler.HandleNewPrescriptionPage(patientSvc),
@@ -110,13 +233,39 @@ func run() error {
 			Edit:         handler.HandlePrescriptionEditPage(prescriptionSvc, patientSvc),
 			Update:       handler.HandleUpdatePrescription(prescriptionSvc, prescriptionSvc, patientSvc),
 			RecordRefill: handler.HandleRecordRefill(prescriptionSvc),
+			ReportStock:  handler.HandleReportStock(orderSvc, patientSvc, prescriptionSvc, pharmacySvc, cfg.Lookahead.Days),
 		},
 		Order: web.OrderHandlers{
-			Dashboard:        handler.HandleDashboard(orderSvc, orderSvc, notificationSvc, cfg.Lookahead.Days),
+			Dashboard:        handler.HandleDashboard(orderSvc, orderSvc, notificationSvc, pharmacySvc, cfg.Lookahead.Days),
 			AdvanceStatus:    handler.HandleAdvanceOrderStatus(orderSvc),
 			PrintDashboard:   handler.HandlePrintDashboard(orderSvc),
-			PrintLabel:       handler.HandlePrintLabel(orderSvc),
-			PrintBatchLabels: handler.HandlePrintBatchLabels(orderSvc),
+			PrintLabel:       handler.HandlePrintLabel(orderSvc, orderRefs, pharmacySvc),
+			PrintBatchLabels: handler.HandlePrintBatchLabels(orderSvc, orderRefs, pharmacySvc),
+			ScanPage:         handler.HandleScanPage(),
+			ScanPost:         handler.HandleScanPost(orderRefs, orderSvc, orderSvc),
+		},
+		Shipping: web.ShippingHandlers{
+			List:          handler.HandleShippingPage(shippingSvc, shippingSvc),
+			CreateBatch:   handler.HandleCreateShippingBatch(shippingSvc, shippingSvc, shippingSvc),
+			Batch:         handler.HandleShippingBatchPage(shippingSvc),
+			Manifest:      handler.HandleShippingManifest(shippingSvc),
+			Export:        handler.HandleShippingExport(shippingSvc),
+			SetTracking:   handler.HandleSetShipmentTracking(shippingSvc, shippingSvc),
+			Ship:          handler.HandleShipBatch(shippingSvc),
+			MarkDelivered: handler.HandleMarkShipmentDelivered(shippingSvc),
+		},
+		Pickup: web.PickupHandlers{
+			Day:          handler.HandlePickupDayPage(pickupSvc),
+			Settings:     handler.HandlePickupSettingsPage(pickupSvc),
+			SaveSettings: handler.HandleSavePickupSettings(pickupSvc),
+			AssignPage:   handler.HandlePickupAssignPage(pickupSvc),
+			Assign:       handler.HandleAssignPickupSlot(pickupSvc, pickupSvc),
+		},
+		Calendar: web.CalendarHandlers{
+			Page:          handler.HandleCalendarPage(calendarSvc),
+			SaveSettings:  handler.HandleSaveCalendarSettings(calendarSvc, calendarSvc),
+			AddClosure:    handler.HandleAddClosure(calendarSvc, calendarSvc),
+			DeleteClosure: handler.HandleDeleteClosure(calendarSvc),
 		},
 		Notification: web.NotificationHandlers{
 			List:        handler.HandleNotificationList(notificationSvc),
@@ -131,12 +280,52 @@ func run() error {
 			UpdatePharmacy:  handler.HandleUpdatePharmacy(pharmacySvc, pharmacySvc, pharmacySvc),
 			AddPersonnel:    handler.HandleAddPersonnelPage(),
 			CreatePersonnel: handler.HandleCreatePersonnel(pharmacySvc),
+			RevokeSessions:  handler.HandleRevokePharmacySessions(pharmacySvc),
+			SSOSettings:     handler.HandleSSOSettingsPage(ssoSvc, cfg.Server.BaseURL),
+			SaveSSOSettings: handler.HandleSaveSSOSettings(ssoSvc, cfg.Server.BaseURL),
+			Personnel:       personnelHandlers(handler.AdminPersonnelScope, pharmacySvc, roleSvc),
 		},
 	})
 
-	// Compose middleware: CORS → sessions → load user → notification count → router
+	portalMux := web.NewPortalRouter(web.PortalHandlers{
+		LoginPage:    handler.HandlePortalLoginPage(),
+		LoginPost:    handler.HandlePortalLoginPost(portalSvc, cfg.Portal.BaseURL),
+		CodePost:     handler.HandlePortalCodePost(patientSM, portalSvc),
+		LinkPage:     handler.HandlePortalLinkPage(),
+		LinkPost:     handler.HandlePortalLinkPost(patientSM, portalSvc),
+		Logout:       handler.HandlePortalLogout(patientSM),
+		Home:         handler.HandlePortalHome(portalSvc),
+		Confirm:      handler.HandlePortalConfirmPickup(portalSvc, portalSvc),
+		PostponePage: handler.HandlePortalPostponePage(portalSvc, portalSvc),
+		Postpone:     handler.HandlePortalPostpone(portalSvc, portalSvc, portalSvc),
+		ReportStock:  handler.HandlePortalReportStock(portalSvc, portalSvc, pharmacySvc, cfg.Lookahead.Days),
+	})
+
+	registerPoolMetrics(metrics.Default, pool)
+	probes := web.ProbeHandlers{
+		Healthz: handler.HandleHealthz(),
+		Readyz: handler.HandleReadyz(
+			handler.ReadinessCheck{Name: "database", Check: pool.Ping},
+			handler.ReadinessCheck{Name: "migrations", Check: func(ctx context.Context) error {
+				return migrations.CheckVersion(ctx, sqlDB)
+			}},
+		),
+	}
+	if cfg.Metrics.Token != "" {
+		probes.Metrics = web.RequireBearerToken(cfg.Metrics.Token)(metrics.Handler(metrics.Default))
+	}
+	if cfg.SMS.InboundToken != "" {
+		probes.Replies = web.RequireBearerToken(cfg.SMS.InboundToken)(handler.HandleInboundReply(portalSvc, pharmacySvc, cfg.Lookahead.Days))
+	}
+
+	// Compose middleware: request metrics → tracing → request log → CORS → then either
+	//   staff:  sessions → branch recheck → load user → session activity → forced password change → 2FA enrolment → notification count → router
+	//   portal: patient sessions → load patient → portal router
+	//   probes: no session
 	cop := http.NewCrossOriginProtection()
-	h := cop.Handler(sm.LoadAndSave(web.LoadUser(sm)(web.LoadNotificationCount(notificationSvc)(mux))))
+	staff := sm.LoadAndSave(web.RecheckBranch(sm, pharmacySvc)(web.LoadUser(sm)(web.TouchSession(sm, userSvc)(web.RequirePasswordChanged(web.RequireTwoFactorEnrolled(web.LoadNotificationCount(notificationSvc)(metrics.Route(mux))))))))
+	patients := patientSM.LoadAndSave(web.LoadPatient(patientSM)(metrics.Route(portalMux)))
+	h := metrics.Instrument(web.TraceRequests(web.LogRequests(cop.Handler(metrics.Route(web.Mount(staff, patients, probes))))))
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
@@ -163,3 +352,43 @@ func run() error {
 	slog.Info("server stopped")
 	return nil
 }
+
+// registerPoolMetrics exposes the connection pool's statistics, read at
+// each scrape.
+func registerPoolMetrics(reg *metrics.Registry, pool *pgxpool.Pool) {
+	reg.NewGaugeFunc("pharmarecall_db_pool_total_conns", "Connections open in the pool.", func() float64 {
+		return float64(pool.Stat().TotalConns())
+	})
+	reg.NewGaugeFunc("pharmarecall_db_pool_acquired_conns", "Connections in use.", func() float64 {
+		return float64(pool.Stat().AcquiredConns())
+	})
+	reg.NewGaugeFunc("pharmarecall_db_pool_idle_conns", "Idle connections.", func() float64 {
+		return float64(pool.Stat().IdleConns())
+	})
+	reg.NewGaugeFunc("pharmarecall_db_pool_max_conns", "Largest size of the pool.", func() float64 {
+		return float64(pool.Stat().MaxConns())
+	})
+	reg.NewCounterFunc("pharmarecall_db_pool_acquires_total", "Connections acquired from the pool.", func() float64 {
+		return float64(pool.Stat().AcquireCount())
+	})
+	reg.NewCounterFunc("pharmarecall_db_pool_empty_acquires_total", "Acquires that waited because the pool was empty.", func() float64 {
+		return float64(pool.Stat().EmptyAcquireCount())
+	})
+	reg.NewCounterFunc("pharmarecall_db_pool_acquire_seconds_total", "Time spent acquiring connections.", func() float64 {
+		return pool.Stat().AcquireDuration().Seconds()
+	})
+}
+
+// personnelHandlers builds the personnel lifecycle handlers for one scope.
+func personnelHandlers(scope handler.PersonnelScoper, svc *pharmacy.Service, roles *role.Service) web.PersonnelHandlers {
+	return web.PersonnelHandlers{
+		Member:         handler.HandlePersonnelMember(scope, svc, roles),
+		Deactivate:     handler.HandleDeactivatePersonnel(scope, svc, roles, svc),
+		Reactivate:     handler.HandleReactivatePersonnel(scope, svc, roles, svc),
+		Unlock:         handler.HandleUnlockPersonnel(scope, svc, roles, svc),
+		ChangeRole:     handler.HandleChangePersonnelRole(scope, svc, roles, svc),
+		ResetPassword:  handler.HandleResetPersonnelPassword(scope, svc, roles, svc),
+		ResetTwoFactor: handler.HandleResetPersonnelTwoFactor(scope, svc, roles, svc),
+		Remove:         handler.HandleRemovePersonnel(scope, svc, roles, svc),
+	}
+}
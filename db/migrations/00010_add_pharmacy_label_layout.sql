@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE pharmacies
+    ADD COLUMN label_layout VARCHAR(20) NOT NULL DEFAULT 'a4-2x7'
+        CHECK (label_layout IN ('a4-2x7', 'roll-62mm'));
+
+-- +goose Down
+ALTER TABLE pharmacies DROP COLUMN label_layout;
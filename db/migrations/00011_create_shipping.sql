@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE shipping_batches (
+    id          BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    pharmacy_id BIGINT NOT NULL,
+    status      VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'shipped')),
+    shipped_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_shipping_batches_pharmacy_id ON shipping_batches (pharmacy_id);
+
+ALTER TABLE shipping_batches
+    ADD CONSTRAINT fk_shipping_batches_pharmacy
+    FOREIGN KEY (pharmacy_id) REFERENCES pharmacies (id);
+
+CREATE TABLE shipments (
+    id              BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    batch_id        BIGINT NOT NULL,
+    order_id        BIGINT NOT NULL,
+    tracking_number VARCHAR(100) NOT NULL DEFAULT '',
+    status          VARCHAR(20) NOT NULL DEFAULT 'ready' CHECK (status IN ('ready', 'shipped', 'delivered')),
+    shipped_at      TIMESTAMPTZ,
+    delivered_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_shipments_batch_id ON shipments (batch_id);
+CREATE UNIQUE INDEX idx_shipments_order_id ON shipments (order_id);
+
+ALTER TABLE shipments
+    ADD CONSTRAINT fk_shipments_batch
+    FOREIGN KEY (batch_id) REFERENCES shipping_batches (id);
+
+ALTER TABLE shipments
+    ADD CONSTRAINT fk_shipments_order
+    FOREIGN KEY (order_id) REFERENCES orders (id);
+
+-- +goose Down
+ALTER TABLE shipments DROP CONSTRAINT fk_shipments_order;
+ALTER TABLE shipments DROP CONSTRAINT fk_shipments_batch;
+DROP TABLE shipments;
+ALTER TABLE shipping_batches DROP CONSTRAINT fk_shipping_batches_pharmacy;
+DROP TABLE shipping_batches;
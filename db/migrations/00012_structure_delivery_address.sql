@@ -0,0 +1,32 @@
+-- +goose Up
+-- The free-text address is kept as delivery_address_legacy; the application
+-- parses it into the structured fields for staff to confirm, and clears it
+-- the first time the patient is saved with a structured address.
+ALTER TABLE patients RENAME COLUMN delivery_address TO delivery_address_legacy;
+
+ALTER TABLE patients
+    ADD COLUMN delivery_street       VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN delivery_house_number VARCHAR(20)  NOT NULL DEFAULT '',
+    ADD COLUMN delivery_cap          VARCHAR(10)  NOT NULL DEFAULT '',
+    ADD COLUMN delivery_city         VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN delivery_province     VARCHAR(2)   NOT NULL DEFAULT '',
+    ADD COLUMN delivery_country      VARCHAR(2)   NOT NULL DEFAULT 'IT',
+    ADD COLUMN delivery_notes        TEXT         NOT NULL DEFAULT '';
+
+-- +goose Down
+UPDATE patients
+SET delivery_address_legacy = trim(both ', ' FROM concat_ws(', ',
+        nullif(trim(delivery_street || ' ' || delivery_house_number), ''),
+        nullif(trim(delivery_cap || ' ' || delivery_city || CASE WHEN delivery_province <> '' THEN ' (' || delivery_province || ')' ELSE '' END), '')))
+WHERE delivery_street <> '';
+
+ALTER TABLE patients
+    DROP COLUMN delivery_street,
+    DROP COLUMN delivery_house_number,
+    DROP COLUMN delivery_cap,
+    DROP COLUMN delivery_city,
+    DROP COLUMN delivery_province,
+    DROP COLUMN delivery_country,
+    DROP COLUMN delivery_notes;
+
+ALTER TABLE patients RENAME COLUMN delivery_address_legacy TO delivery_address;
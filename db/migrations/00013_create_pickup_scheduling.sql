@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE opening_hours (
+    id          BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    pharmacy_id BIGINT NOT NULL,
+    weekday     SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens_at    TIME NOT NULL,
+    closes_at   TIME NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (closes_at > opens_at)
+);
+
+CREATE INDEX idx_opening_hours_pharmacy_id ON opening_hours (pharmacy_id);
+
+ALTER TABLE opening_hours
+    ADD CONSTRAINT fk_opening_hours_pharmacy
+    FOREIGN KEY (pharmacy_id) REFERENCES pharmacies (id);
+
+CREATE TABLE pickup_settings (
+    pharmacy_id    BIGINT PRIMARY KEY,
+    slot_minutes   INT NOT NULL DEFAULT 30 CHECK (slot_minutes BETWEEN 5 AND 240),
+    slot_capacity  INT NOT NULL DEFAULT 4 CHECK (slot_capacity > 0),
+    daily_capacity INT NOT NULL DEFAULT 0 CHECK (daily_capacity >= 0),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE pickup_settings
+    ADD CONSTRAINT fk_pickup_settings_pharmacy
+    FOREIGN KEY (pharmacy_id) REFERENCES pharmacies (id);
+
+-- pickup_at is the local wall-clock start of the pickup slot. It is first set
+-- as a suggestion when the order is generated and confirmed by staff.
+ALTER TABLE orders
+    ADD COLUMN pickup_at          TIMESTAMP,
+    ADD COLUMN pickup_confirmed   BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN pickup_notified_at TIMESTAMPTZ;
+
+CREATE INDEX idx_orders_pickup_at ON orders (pickup_at) WHERE pickup_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX idx_orders_pickup_at;
+ALTER TABLE orders
+    DROP COLUMN pickup_at,
+    DROP COLUMN pickup_confirmed,
+    DROP COLUMN pickup_notified_at;
+ALTER TABLE pickup_settings DROP CONSTRAINT fk_pickup_settings_pharmacy;
+DROP TABLE pickup_settings;
+ALTER TABLE opening_hours DROP CONSTRAINT fk_opening_hours_pharmacy;
+DROP TABLE opening_hours;
@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE pharmacy_calendars (
+    pharmacy_id        BIGINT PRIMARY KEY,
+    closed_weekdays    SMALLINT[] NOT NULL DEFAULT '{0}',
+    national_holidays  BOOLEAN NOT NULL DEFAULT true,
+    patron_saint_name  VARCHAR(100) NOT NULL DEFAULT '',
+    patron_saint_month SMALLINT CHECK (patron_saint_month BETWEEN 1 AND 12),
+    patron_saint_day   SMALLINT CHECK (patron_saint_day BETWEEN 1 AND 31),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE pharmacy_calendars
+    ADD CONSTRAINT fk_pharmacy_calendars_pharmacy
+    FOREIGN KEY (pharmacy_id) REFERENCES pharmacies (id);
+
+CREATE TABLE pharmacy_closures (
+    id          BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    pharmacy_id BIGINT NOT NULL,
+    start_date  DATE NOT NULL,
+    end_date    DATE NOT NULL,
+    reason      VARCHAR(200) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_pharmacy_closures_pharmacy_id ON pharmacy_closures (pharmacy_id, start_date);
+
+ALTER TABLE pharmacy_closures
+    ADD CONSTRAINT fk_pharmacy_closures_pharmacy
+    FOREIGN KEY (pharmacy_id) REFERENCES pharmacies (id);
+
+-- +goose Down
+ALTER TABLE pharmacy_closures DROP CONSTRAINT fk_pharmacy_closures_pharmacy;
+DROP TABLE pharmacy_closures;
+ALTER TABLE pharmacy_calendars DROP CONSTRAINT fk_pharmacy_calendars_pharmacy;
+DROP TABLE pharmacy_calendars;
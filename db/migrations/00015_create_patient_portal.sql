@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE patient_sessions (
+    token  TEXT PRIMARY KEY,
+    data   BYTEA NOT NULL,
+    expiry TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX patient_sessions_expiry_idx ON patient_sessions (expiry);
+
+CREATE TABLE patient_login_tokens (
+    id          BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    patient_id  BIGINT NOT NULL,
+    channel     VARCHAR(10) NOT NULL CHECK (channel IN ('email', 'phone')),
+    token_hash  BYTEA NOT NULL,
+    attempts    INTEGER NOT NULL DEFAULT 0,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_patient_login_tokens_hash ON patient_login_tokens (token_hash);
+CREATE INDEX idx_patient_login_tokens_patient_id ON patient_login_tokens (patient_id, created_at);
+
+ALTER TABLE patient_login_tokens
+    ADD CONSTRAINT fk_patient_login_tokens_patient
+    FOREIGN KEY (patient_id) REFERENCES patients (id) ON DELETE CASCADE;
+
+CREATE TABLE stock_reports (
+    id              BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    prescription_id BIGINT NOT NULL,
+    units           INTEGER NOT NULL CHECK (units >= 0),
+    reported_on     DATE NOT NULL,
+    source          VARCHAR(20) NOT NULL CHECK (source IN ('portal')),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_stock_reports_prescription_id ON stock_reports (prescription_id, reported_on);
+
+ALTER TABLE stock_reports
+    ADD CONSTRAINT fk_stock_reports_prescription
+    FOREIGN KEY (prescription_id) REFERENCES prescriptions (id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE stock_reports DROP CONSTRAINT fk_stock_reports_prescription;
+DROP TABLE stock_reports;
+ALTER TABLE patient_login_tokens DROP CONSTRAINT fk_patient_login_tokens_patient;
+DROP TABLE patient_login_tokens;
+DROP TABLE patient_sessions;
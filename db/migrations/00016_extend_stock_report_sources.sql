@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE stock_reports DROP CONSTRAINT stock_reports_source_check;
+ALTER TABLE stock_reports
+    ADD CONSTRAINT stock_reports_source_check CHECK (source IN ('portal', 'staff', 'reply'));
+
+-- +goose Down
+DELETE FROM stock_reports WHERE source <> 'portal';
+ALTER TABLE stock_reports DROP CONSTRAINT stock_reports_source_check;
+ALTER TABLE stock_reports
+    ADD CONSTRAINT stock_reports_source_check CHECK (source IN ('portal'));
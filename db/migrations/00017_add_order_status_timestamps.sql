@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE orders ADD COLUMN prepared_at TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN fulfilled_at TIMESTAMPTZ;
+
+-- Best estimate for orders that changed status before the columns existed:
+-- updated_at is the time of their last transition.
+UPDATE orders SET prepared_at = updated_at WHERE status = 'prepared';
+UPDATE orders SET fulfilled_at = updated_at WHERE status = 'fulfilled';
+
+CREATE INDEX idx_orders_created_at ON orders (created_at);
+
+-- +goose Down
+DROP INDEX idx_orders_created_at;
+ALTER TABLE orders DROP COLUMN fulfilled_at;
+ALTER TABLE orders DROP COLUMN prepared_at;
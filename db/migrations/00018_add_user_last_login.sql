@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN last_login_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN last_login_at;
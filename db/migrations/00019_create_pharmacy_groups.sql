@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE pharmacy_groups (
+    id         BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_pharmacy_groups_name ON pharmacy_groups (lower(name));
+
+-- A pharmacy belongs to at most one group. Users stay bound to their home
+-- pharmacy; owners reach the other branches of its group.
+ALTER TABLE pharmacies ADD COLUMN group_id BIGINT;
+
+CREATE INDEX idx_pharmacies_group_id ON pharmacies (group_id);
+
+ALTER TABLE pharmacies
+    ADD CONSTRAINT fk_pharmacies_group
+    FOREIGN KEY (group_id) REFERENCES pharmacy_groups (id);
+
+-- +goose Down
+ALTER TABLE pharmacies DROP CONSTRAINT fk_pharmacies_group;
+ALTER TABLE pharmacies DROP COLUMN group_id;
+DROP TABLE pharmacy_groups;
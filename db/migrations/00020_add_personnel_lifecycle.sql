@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE users ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT false;
+
+-- scs keeps session data opaque, so the sessions of a user cannot be found
+-- from the sessions table alone. Each login records its token here.
+CREATE TABLE user_sessions (
+    token      TEXT PRIMARY KEY,
+    user_id    BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_user_sessions_user_id ON user_sessions (user_id);
+
+ALTER TABLE user_sessions
+    ADD CONSTRAINT fk_user_sessions_user
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+-- Sessions opened before this migration have no user_sessions row and could
+-- not be revoked: log everyone out once.
+DELETE FROM sessions;
+
+-- +goose Down
+ALTER TABLE user_sessions DROP CONSTRAINT fk_user_sessions_user;
+DROP TABLE user_sessions;
+ALTER TABLE users DROP COLUMN must_change_password;
+ALTER TABLE users DROP COLUMN active;
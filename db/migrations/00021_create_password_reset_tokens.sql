@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE password_reset_tokens (
+    id          BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id     BIGINT NOT NULL,
+    token_hash  BYTEA NOT NULL,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_password_reset_tokens_hash ON password_reset_tokens (token_hash);
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id, created_at);
+
+ALTER TABLE password_reset_tokens
+    ADD CONSTRAINT fk_password_reset_tokens_user
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE password_reset_tokens DROP CONSTRAINT fk_password_reset_tokens_user;
+DROP TABLE password_reset_tokens;
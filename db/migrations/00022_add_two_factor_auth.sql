@@ -0,0 +1,31 @@
+-- +goose Up
+-- totp_secret is NULL until the user confirms enrolment with a valid code.
+-- totp_last_step is the time step of the last accepted code, so a code
+-- cannot be replayed within its validity window.
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE pharmacies ADD COLUMN require_2fa BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE user_recovery_codes (
+    id         BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    BIGINT NOT NULL,
+    code_hash  BYTEA NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
+
+ALTER TABLE user_recovery_codes
+    ADD CONSTRAINT fk_user_recovery_codes_user
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE user_recovery_codes DROP CONSTRAINT fk_user_recovery_codes_user;
+DROP TABLE user_recovery_codes;
+ALTER TABLE pharmacies DROP COLUMN require_2fa;
+ALTER TABLE users DROP COLUMN totp_last_step;
+ALTER TABLE users DROP COLUMN totp_enabled_at;
+ALTER TABLE users DROP COLUMN totp_secret;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- failed_logins counts consecutive wrong passwords and resets on success.
+-- Past the threshold the account is locked until locked_until, for longer
+-- after each further failure.
+ALTER TABLE users ADD COLUMN failed_logins INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMPTZ;
+
+-- login_events records every login attempt. user_id is NULL when the email
+-- matched no account; failures per IP are counted from here, so the limit
+-- holds across replicas.
+CREATE TABLE login_events (
+    id         BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    BIGINT,
+    email      TEXT NOT NULL,
+    ip         TEXT NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    success    BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_login_events_user_id ON login_events (user_id, created_at DESC);
+CREATE INDEX idx_login_events_failed_ip ON login_events (ip, created_at) WHERE NOT success;
+
+ALTER TABLE login_events
+    ADD CONSTRAINT fk_login_events_user
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE login_events DROP CONSTRAINT fk_login_events_user;
+DROP TABLE login_events;
+ALTER TABLE users DROP COLUMN locked_until;
+ALTER TABLE users DROP COLUMN failed_logins;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- id names a session in URLs without exposing its token. ip and user_agent
+-- describe the device that logged in; last_seen_at is refreshed every few
+-- minutes while the session is used.
+ALTER TABLE user_sessions ADD COLUMN id BIGINT NOT NULL GENERATED ALWAYS AS IDENTITY UNIQUE;
+ALTER TABLE user_sessions ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_sessions ADD COLUMN last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- +goose Down
+ALTER TABLE user_sessions DROP COLUMN last_seen_at;
+ALTER TABLE user_sessions DROP COLUMN user_agent;
+ALTER TABLE user_sessions DROP COLUMN ip;
+ALTER TABLE user_sessions DROP COLUMN id;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- pharmacy_sso holds a pharmacy's OpenID Connect provider. Users signing in
+-- through it get the role whose value appears in role_claim: owner_value
+-- makes an owner, personnel_value (or any value when empty) makes personnel.
+CREATE TABLE pharmacy_sso (
+    pharmacy_id     BIGINT PRIMARY KEY,
+    enabled         BOOLEAN NOT NULL DEFAULT false,
+    issuer          TEXT NOT NULL,
+    client_id       TEXT NOT NULL,
+    client_secret   TEXT NOT NULL,
+    role_claim      TEXT NOT NULL DEFAULT 'groups',
+    owner_value     TEXT NOT NULL DEFAULT '',
+    personnel_value TEXT NOT NULL DEFAULT '',
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE pharmacy_sso
+    ADD CONSTRAINT fk_pharmacy_sso_pharmacy
+    FOREIGN KEY (pharmacy_id) REFERENCES pharmacies (id) ON DELETE CASCADE;
+
+-- A federated user is identified by the provider's issuer and subject, not
+-- by email. Their password_hash is empty, so password logins never match.
+ALTER TABLE users ADD COLUMN oidc_issuer TEXT;
+ALTER TABLE users ADD COLUMN oidc_subject TEXT;
+
+CREATE UNIQUE INDEX idx_users_oidc_identity ON users (oidc_issuer, oidc_subject)
+    WHERE oidc_subject IS NOT NULL;
+
+-- +goose Down
+DROP INDEX idx_users_oidc_identity;
+ALTER TABLE users DROP COLUMN oidc_subject;
+ALTER TABLE users DROP COLUMN oidc_issuer;
+ALTER TABLE pharmacy_sso DROP CONSTRAINT fk_pharmacy_sso_pharmacy;
+DROP TABLE pharmacy_sso;
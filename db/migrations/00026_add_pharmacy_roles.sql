@@ -0,0 +1,48 @@
+-- +goose Up
+-- pharmacy_roles are the custom roles an owner defines on top of the
+-- built-in ones, each with its own list of permission names.
+CREATE TABLE pharmacy_roles (
+    id          BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    pharmacy_id BIGINT NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_pharmacy_roles_name ON pharmacy_roles (pharmacy_id, lower(name));
+
+ALTER TABLE pharmacy_roles
+    ADD CONSTRAINT fk_pharmacy_roles_pharmacy
+    FOREIGN KEY (pharmacy_id) REFERENCES pharmacies (id) ON DELETE CASCADE;
+
+-- Built-in roles gain pharmacist (in charge), trainee and driver. A user
+-- with role 'custom' holds the pharmacy role in custom_role_id; a role still
+-- held by someone cannot be deleted.
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('admin', 'owner', 'pharmacist', 'personnel', 'trainee', 'driver', 'custom'));
+
+ALTER TABLE users ADD COLUMN custom_role_id BIGINT;
+
+CREATE INDEX idx_users_custom_role_id ON users (custom_role_id);
+
+ALTER TABLE users
+    ADD CONSTRAINT fk_users_custom_role
+    FOREIGN KEY (custom_role_id) REFERENCES pharmacy_roles (id);
+
+ALTER TABLE users ADD CONSTRAINT users_custom_role_check
+    CHECK ((role = 'custom') = (custom_role_id IS NOT NULL));
+
+-- +goose Down
+UPDATE users SET role = 'personnel', custom_role_id = NULL
+WHERE role IN ('pharmacist', 'trainee', 'driver', 'custom');
+ALTER TABLE users DROP CONSTRAINT users_custom_role_check;
+ALTER TABLE users DROP CONSTRAINT fk_users_custom_role;
+DROP INDEX idx_users_custom_role_id;
+ALTER TABLE users DROP COLUMN custom_role_id;
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('admin', 'owner', 'personnel'));
+ALTER TABLE pharmacy_roles DROP CONSTRAINT fk_pharmacy_roles_pharmacy;
+DROP TABLE pharmacy_roles;
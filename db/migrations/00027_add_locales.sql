@@ -0,0 +1,12 @@
+-- +goose Up
+-- The interface language: each pharmacy has a default, and a user may pick
+-- their own. An empty user locale follows the pharmacy.
+ALTER TABLE pharmacies ADD COLUMN locale TEXT NOT NULL DEFAULT 'it'
+    CHECK (locale IN ('it', 'de'));
+
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT ''
+    CHECK (locale IN ('', 'it', 'de'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN locale;
+ALTER TABLE pharmacies DROP COLUMN locale;
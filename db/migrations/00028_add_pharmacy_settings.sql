@@ -0,0 +1,20 @@
+-- +goose Up
+-- Settings owners manage for their own pharmacy. lookahead_days 0 follows
+-- the server's default; an empty pickup_reminder uses the built-in text.
+-- The logo is stored as a JPEG, normalised on upload.
+ALTER TABLE pharmacies
+    ADD COLUMN vat_number TEXT NOT NULL DEFAULT '',
+    ADD COLUMN default_fulfillment TEXT NOT NULL DEFAULT 'pickup'
+        CHECK (default_fulfillment IN ('pickup', 'shipping')),
+    ADD COLUMN lookahead_days INTEGER NOT NULL DEFAULT 0
+        CHECK (lookahead_days BETWEEN 0 AND 60),
+    ADD COLUMN pickup_reminder TEXT NOT NULL DEFAULT '',
+    ADD COLUMN logo BYTEA;
+
+-- +goose Down
+ALTER TABLE pharmacies
+    DROP COLUMN logo,
+    DROP COLUMN pickup_reminder,
+    DROP COLUMN lookahead_days,
+    DROP COLUMN default_fulfillment,
+    DROP COLUMN vat_number;
@@ -1,22 +1,110 @@
 package migrations
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
 
 	"github.com/pressly/goose/v3"
 )
 
 func Run(db *sql.DB) error {
-	goose.SetBaseFS(Files)
+	if err := prepare(); err != nil {
+		return err
+	}
+
+	if err := goose.Up(db, "."); err != nil {
+		return fmt.Errorf("running migrations: %w", err)
+	}
+
+	return nil
+}
+
+// Down rolls back the most recent migration.
+func Down(db *sql.DB) error {
+	if err := prepare(); err != nil {
+		return err
+	}
+	if err := goose.Down(db, "."); err != nil {
+		return fmt.Errorf("rolling back migration: %w", err)
+	}
+	return nil
+}
+
+// To migrates up or down until the database is at version.
+func To(ctx context.Context, db *sql.DB, version int64) error {
+	current, err := Version(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version >= current {
+		err = goose.UpTo(db, ".", version)
+	} else {
+		err = goose.DownTo(db, ".", version)
+	}
+	if err != nil {
+		return fmt.Errorf("migrating to %d: %w", version, err)
+	}
+	return nil
+}
 
+// Version returns the version of the last migration applied to db.
+func Version(ctx context.Context, db *sql.DB) (int64, error) {
+	if err := prepare(); err != nil {
+		return 0, err
+	}
+	v, err := goose.GetDBVersionContext(ctx, db)
+	if err != nil {
+		return 0, fmt.Errorf("reading migration version: %w", err)
+	}
+	return v, nil
+}
+
+// prepare points goose at the embedded migrations.
+func prepare() error {
+	goose.SetBaseFS(Files)
 	if err := goose.SetDialect("postgres"); err != nil {
 		return fmt.Errorf("setting goose dialect: %w", err)
 	}
+	return nil
+}
 
-	if err := goose.Up(db, "."); err != nil {
-		return fmt.Errorf("running migrations: %w", err)
+// Latest returns the version of the newest embedded migration, the number
+// its file name starts with.
+func Latest() (int64, error) {
+	names, err := fs.Glob(Files, "*.sql")
+	if err != nil {
+		return 0, fmt.Errorf("listing migrations: %w", err)
+	}
+	var latest int64
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		v, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s has no version: %w", name, err)
+		}
+		latest = max(latest, v)
 	}
+	return latest, nil
+}
 
+// CheckVersion returns an error while db lacks migrations this binary
+// embeds. A database migrated further by a newer release passes, so older
+// replicas keep serving during a rolling deploy.
+func CheckVersion(ctx context.Context, db *sql.DB) error {
+	latest, err := Latest()
+	if err != nil {
+		return err
+	}
+	current, err := Version(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current < latest {
+		return fmt.Errorf("database at migration %d, want %d", current, latest)
+	}
 	return nil
 }
@@ -0,0 +1,28 @@
+-- name: ListOrderEventsSince :many
+SELECT o.created_at, o.prepared_at, o.fulfilled_at
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+  AND (o.created_at >= sqlc.arg(since)::TIMESTAMPTZ
+       OR o.prepared_at >= sqlc.arg(since)::TIMESTAMPTZ
+       OR o.fulfilled_at >= sqlc.arg(since)::TIMESTAMPTZ);
+
+-- name: ListRefillsSince :many
+SELECT rh.box_end_date, rh.created_at
+FROM refill_history rh
+JOIN prescriptions p ON rh.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+  AND rh.created_at >= sqlc.arg(since)::TIMESTAMPTZ;
+
+-- name: ListTopMedications :many
+SELECT p.medication_name, count(*)::INTEGER AS orders
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+  AND o.created_at >= sqlc.arg(since)::TIMESTAMPTZ
+GROUP BY p.medication_name
+ORDER BY orders DESC, p.medication_name
+LIMIT sqlc.arg(max_rows)::INTEGER;
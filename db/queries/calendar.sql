@@ -0,0 +1,30 @@
+-- name: GetPharmacyCalendar :one
+SELECT closed_weekdays, national_holidays, patron_saint_name, patron_saint_month, patron_saint_day
+FROM pharmacy_calendars
+WHERE pharmacy_id = $1;
+
+-- name: UpsertPharmacyCalendar :exec
+INSERT INTO pharmacy_calendars (pharmacy_id, closed_weekdays, national_holidays, patron_saint_name, patron_saint_month, patron_saint_day)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (pharmacy_id) DO UPDATE
+SET closed_weekdays = EXCLUDED.closed_weekdays,
+    national_holidays = EXCLUDED.national_holidays,
+    patron_saint_name = EXCLUDED.patron_saint_name,
+    patron_saint_month = EXCLUDED.patron_saint_month,
+    patron_saint_day = EXCLUDED.patron_saint_day,
+    updated_at = now();
+
+-- name: ListPharmacyClosures :many
+SELECT id, start_date, end_date, reason
+FROM pharmacy_closures
+WHERE pharmacy_id = $1
+ORDER BY start_date, id;
+
+-- name: CreatePharmacyClosure :one
+INSERT INTO pharmacy_closures (pharmacy_id, start_date, end_date, reason)
+VALUES ($1, $2, $3, $4)
+RETURNING id;
+
+-- name: DeletePharmacyClosure :execrows
+DELETE FROM pharmacy_closures
+WHERE id = $1 AND pharmacy_id = $2;
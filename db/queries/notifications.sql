@@ -1,4 +1,4 @@
--- name: CreateNotification :exec
+-- name: CreateNotification :execrows
 INSERT INTO notifications (pharmacy_id, prescription_id, transition_type)
 VALUES ($1, $2, $3)
 ON CONFLICT (prescription_id, transition_type) DO NOTHING;
@@ -17,10 +17,19 @@ SELECT
     p.box_start_date,
     pat.id AS patient_id,
     pat.first_name,
-    pat.last_name
+    pat.last_name,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on
 FROM notifications n
 JOIN prescriptions p ON n.prescription_id = p.id
 JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN LATERAL (
+    SELECT units, reported_on
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
 WHERE n.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
 ORDER BY n.created_at DESC;
 
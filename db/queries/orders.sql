@@ -1,10 +1,12 @@
 -- name: CreateOrder :one
 INSERT INTO orders (prescription_id, cycle_start_date, estimated_depletion_date, status)
 VALUES ($1, $2, $3, $4)
-RETURNING id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at;
+RETURNING id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at,
+    pickup_at, pickup_confirmed, pickup_notified_at, prepared_at, fulfilled_at;
 
 -- name: GetActiveOrderByPrescription :one
-SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at
+SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at,
+    pickup_at, pickup_confirmed, pickup_notified_at, prepared_at, fulfilled_at
 FROM orders
 WHERE prescription_id = sqlc.arg(prescription_id)::BIGINT
   AND status IN ('pending', 'prepared')
@@ -12,13 +14,17 @@ WHERE prescription_id = sqlc.arg(prescription_id)::BIGINT
 LIMIT 1;
 
 -- name: GetOrderByID :one
-SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at
+SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at,
+    pickup_at, pickup_confirmed, pickup_notified_at, prepared_at, fulfilled_at
 FROM orders
 WHERE id = $1;
 
 -- name: UpdateOrderStatus :exec
 UPDATE orders
-SET status = $2, updated_at = now()
+SET status = $2,
+    prepared_at = CASE WHEN $2 = 'prepared' THEN now() ELSE prepared_at END,
+    fulfilled_at = CASE WHEN $2 = 'fulfilled' THEN now() ELSE fulfilled_at END,
+    updated_at = now()
 WHERE id = $1;
 
 -- name: ListDashboardOrders :many
@@ -36,18 +42,30 @@ SELECT
     pat.first_name,
     pat.last_name,
     pat.fulfillment,
-    pat.delivery_address,
+    pat.delivery_street,
+    pat.delivery_house_number,
+    pat.delivery_cap,
+    pat.delivery_city,
+    pat.delivery_province,
+    pat.delivery_country,
+    pat.delivery_notes,
+    pat.delivery_address_legacy,
     pat.phone,
-    pat.email
+    pat.email,
+    COALESCE(s.status, '')::TEXT AS shipping_status,
+    COALESCE(s.tracking_number, '')::TEXT AS tracking_number,
+    o.pickup_at,
+    o.pickup_confirmed
 FROM orders o
 JOIN prescriptions p ON o.prescription_id = p.id
 JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN shipments s ON s.order_id = o.id
 WHERE pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
 ORDER BY o.estimated_depletion_date ASC;
 
 -- name: FulfillActiveOrderByPrescription :exec
 UPDATE orders
-SET status = 'fulfilled', updated_at = now()
+SET status = 'fulfilled', fulfilled_at = now(), updated_at = now()
 WHERE prescription_id = $1
   AND status IN ('pending', 'prepared');
 
@@ -57,9 +75,60 @@ SELECT
     p.units_per_box,
     p.daily_consumption,
     p.box_start_date,
-    pat.id AS patient_id
+    pat.id AS patient_id,
+    pat.fulfillment,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on
 FROM prescriptions p
 JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN LATERAL (
+    SELECT units, reported_on
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
 WHERE pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
   AND pat.consensus = true
 ORDER BY p.id;
+
+-- name: GetPrescriptionSummary :one
+SELECT
+    p.id AS prescription_id,
+    p.units_per_box,
+    p.daily_consumption,
+    p.box_start_date,
+    pat.id AS patient_id,
+    pat.fulfillment,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on
+FROM prescriptions p
+JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN LATERAL (
+    SELECT units, reported_on
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
+WHERE p.id = sqlc.arg(prescription_id)::BIGINT
+  AND pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT;
+
+-- name: ListPendingOrdersByPrescription :many
+SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at,
+    pickup_at, pickup_confirmed, pickup_notified_at, prepared_at, fulfilled_at
+FROM orders
+WHERE prescription_id = $1
+  AND status = 'pending'
+ORDER BY id;
+
+-- name: UpdateOrderDepletionDate :exec
+UPDATE orders
+SET estimated_depletion_date = $2, updated_at = now()
+WHERE id = $1 AND status = 'pending';
+
+-- name: DeletePendingOrder :execrows
+DELETE FROM orders o
+WHERE o.id = $1
+  AND o.status = 'pending'
+  AND NOT EXISTS (SELECT 1 FROM shipments s WHERE s.order_id = o.id);
@@ -1,7 +1,13 @@
 -- name: CreatePatient :one
-INSERT INTO patients (pharmacy_id, first_name, last_name, phone, email, delivery_address, fulfillment, notes)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, pharmacy_id, first_name, last_name, phone, email, delivery_address, fulfillment, notes, consensus, consensus_date, created_at, updated_at;
+INSERT INTO patients (
+    pharmacy_id, first_name, last_name, phone, email,
+    delivery_street, delivery_house_number, delivery_cap, delivery_city, delivery_province, delivery_country, delivery_notes,
+    fulfillment, notes
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, pharmacy_id, first_name, last_name, phone, email, delivery_address_legacy,
+    fulfillment, notes, consensus, consensus_date, created_at, updated_at,
+    delivery_street, delivery_house_number, delivery_cap, delivery_city, delivery_province, delivery_country, delivery_notes;
 
 -- name: ListPatientsByPharmacy :many
 SELECT id, first_name, last_name, phone, email, consensus
@@ -10,16 +16,63 @@ WHERE pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
 ORDER BY last_name, first_name;
 
 -- name: GetPatientByID :one
-SELECT id, pharmacy_id, first_name, last_name, phone, email, delivery_address, fulfillment, notes, consensus, consensus_date, created_at, updated_at
+SELECT id, pharmacy_id, first_name, last_name, phone, email, delivery_address_legacy,
+    fulfillment, notes, consensus, consensus_date, created_at, updated_at,
+    delivery_street, delivery_house_number, delivery_cap, delivery_city, delivery_province, delivery_country, delivery_notes
 FROM patients
 WHERE id = $1;
 
 -- name: UpdatePatient :exec
+-- Saving a structured address retires the legacy free-text value.
 UPDATE patients
-SET first_name = $2, last_name = $3, phone = $4, email = $5, delivery_address = $6, fulfillment = $7, notes = $8, updated_at = now()
+SET first_name = $2, last_name = $3, phone = $4, email = $5,
+    delivery_street = $6, delivery_house_number = $7, delivery_cap = $8, delivery_city = $9,
+    delivery_province = $10, delivery_country = $11, delivery_notes = $12,
+    delivery_address_legacy = CASE WHEN $6 <> '' THEN '' ELSE delivery_address_legacy END,
+    fulfillment = $13, notes = $14, updated_at = now()
 WHERE id = $1;
 
 -- name: SetPatientConsensus :exec
 UPDATE patients
 SET consensus = true, consensus_date = now(), updated_at = now()
 WHERE id = $1;
+
+-- name: LockPatientInPharmacy :one
+SELECT id
+FROM patients
+WHERE id = $1 AND pharmacy_id = $2
+FOR UPDATE;
+
+-- name: PharmaciesShareGroup :one
+SELECT EXISTS (
+    SELECT 1
+    FROM pharmacies a
+    JOIN pharmacies b ON b.group_id = a.group_id
+    WHERE a.id = sqlc.arg(from_pharmacy_id)::BIGINT AND b.id = sqlc.arg(to_pharmacy_id)::BIGINT
+)::BOOLEAN AS share_group;
+
+-- name: CountPatientOrdersInTransit :one
+-- Orders sitting in a shipping batch of the current branch that have not been
+-- delivered yet. They block a transfer: the batch belongs to the old branch.
+SELECT COUNT(*)::BIGINT
+FROM shipments s
+JOIN orders o ON o.id = s.order_id
+JOIN prescriptions rx ON rx.id = o.prescription_id
+WHERE rx.patient_id = $1 AND s.status <> 'delivered';
+
+-- name: MovePatientToPharmacy :exec
+UPDATE patients
+SET pharmacy_id = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: ClearPatientOrderPickups :exec
+-- Pickup slots belong to the old branch's opening hours.
+UPDATE orders
+SET pickup_at = NULL, pickup_confirmed = false, pickup_notified_at = NULL, updated_at = now()
+WHERE status <> 'fulfilled'
+  AND prescription_id IN (SELECT id FROM prescriptions WHERE patient_id = $1);
+
+-- name: MovePatientNotifications :exec
+UPDATE notifications
+SET pharmacy_id = $2
+WHERE prescription_id IN (SELECT id FROM prescriptions WHERE patient_id = $1);
@@ -1,29 +1,116 @@
 -- name: CreatePharmacy :one
 INSERT INTO pharmacies (name, address, phone, email)
 VALUES ($1, $2, $3, $4)
-RETURNING id, name, address, phone, email, created_at, updated_at;
+RETURNING id, name, address, phone, email, label_layout, created_at, updated_at;
 
 -- name: ListPharmacies :many
+-- Per-pharmacy aggregates for the admin overview and, filtered to the
+-- branches an owner can reach, the group dashboard. Counts only: no patient
+-- data leaves this query.
 SELECT
     p.id,
     p.name,
     p.address,
-    p.phone,
-    p.email,
     p.created_at,
-    p.updated_at,
-    COUNT(DISTINCT u.id)::BIGINT AS personnel_count
+    COALESCE(g.name, '')::TEXT AS group_name,
+    (SELECT COUNT(*) FROM users u WHERE u.pharmacy_id = p.id)::BIGINT AS personnel_count,
+    (SELECT COUNT(DISTINCT pa.id)
+     FROM patients pa
+     JOIN prescriptions rx ON rx.patient_id = pa.id
+     WHERE pa.pharmacy_id = p.id)::BIGINT AS active_patient_count,
+    (SELECT COUNT(*)
+     FROM prescriptions rx
+     JOIN patients pa ON pa.id = rx.patient_id
+     WHERE pa.pharmacy_id = p.id)::BIGINT AS prescription_count,
+    (SELECT COUNT(*)
+     FROM orders o
+     JOIN prescriptions rx ON rx.id = o.prescription_id
+     JOIN patients pa ON pa.id = rx.patient_id
+     WHERE pa.pharmacy_id = p.id
+       AND o.status <> 'fulfilled'
+       AND o.estimated_depletion_date < CURRENT_DATE)::BIGINT AS overdue_order_count,
+    (SELECT COUNT(*)
+     FROM notifications n
+     WHERE n.pharmacy_id = p.id AND NOT n.read)::BIGINT AS unread_notification_count,
+    (SELECT MAX(u.last_login_at) FROM users u WHERE u.pharmacy_id = p.id)::TIMESTAMPTZ AS last_login_at
 FROM pharmacies p
-LEFT JOIN users u ON u.pharmacy_id = p.id
-GROUP BY p.id
+LEFT JOIN pharmacy_groups g ON g.id = p.group_id
+WHERE sqlc.narg(owner_id)::BIGINT IS NULL
+   OR p.id IN (
+       SELECT b.id
+       FROM users u
+       JOIN pharmacies home ON home.id = u.pharmacy_id
+       JOIN pharmacies b ON b.id = home.id OR b.group_id = home.group_id
+       WHERE u.id = sqlc.narg(owner_id)::BIGINT AND u.role = 'owner'
+   )
 ORDER BY p.name;
 
 -- name: GetPharmacyByID :one
-SELECT id, name, address, phone, email, created_at, updated_at
+SELECT p.id, p.name, p.address, p.phone, p.email, p.label_layout, p.group_id, p.require_2fa, p.locale,
+    COALESCE(g.name, '')::TEXT AS group_name,
+    (p.logo IS NOT NULL)::BOOLEAN AS has_logo
+FROM pharmacies p
+LEFT JOIN pharmacy_groups g ON g.id = p.group_id
+WHERE p.id = $1;
+
+-- name: UpdatePharmacy :exec
+UPDATE pharmacies
+SET name = $2, address = $3, phone = $4, email = $5, label_layout = $6, require_2fa = $7, locale = $8, updated_at = now()
+WHERE id = $1;
+
+-- name: UpsertPharmacyGroup :one
+INSERT INTO pharmacy_groups (name)
+VALUES ($1)
+ON CONFLICT (lower(name)) DO UPDATE SET name = pharmacy_groups.name
+RETURNING id;
+
+-- name: SetPharmacyGroup :exec
+UPDATE pharmacies
+SET group_id = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: DeleteEmptyPharmacyGroups :exec
+DELETE FROM pharmacy_groups g
+WHERE NOT EXISTS (SELECT 1 FROM pharmacies p WHERE p.group_id = g.id);
+
+-- name: ListUserBranches :many
+-- The pharmacies an owner can switch to: their home pharmacy and, if it
+-- belongs to a group, every other branch of that group.
+SELECT b.id, b.name
+FROM users u
+JOIN pharmacies home ON home.id = u.pharmacy_id
+JOIN pharmacies b ON b.id = home.id OR b.group_id = home.group_id
+WHERE u.id = $1 AND u.role = 'owner'
+ORDER BY b.name;
+
+-- name: DeletePharmacySessions :execrows
+-- Logs out every member of the pharmacy.
+WITH revoked AS (
+    DELETE FROM user_sessions us
+    USING users u
+    WHERE u.id = us.user_id AND u.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+    RETURNING us.token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked);
+
+-- name: GetPharmacySettings :one
+SELECT id, address, phone, email, vat_number, default_fulfillment, lookahead_days, pickup_reminder,
+    (logo IS NOT NULL)::BOOLEAN AS has_logo
 FROM pharmacies
 WHERE id = $1;
 
--- name: UpdatePharmacy :exec
+-- name: UpdatePharmacySettings :exec
+UPDATE pharmacies
+SET address = $2, phone = $3, email = $4, vat_number = $5, default_fulfillment = $6,
+    lookahead_days = $7, pickup_reminder = $8, updated_at = now()
+WHERE id = $1;
+
+-- name: GetPharmacyLogo :one
+SELECT logo FROM pharmacies WHERE id = $1;
+
+-- name: SetPharmacyLogo :exec
+-- A NULL logo removes it.
 UPDATE pharmacies
-SET name = $2, address = $3, phone = $4, email = $5, updated_at = now()
+SET logo = $2, updated_at = now()
 WHERE id = $1;
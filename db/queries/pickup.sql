@@ -0,0 +1,101 @@
+-- name: ListOpeningHours :many
+SELECT weekday, opens_at, closes_at
+FROM opening_hours
+WHERE pharmacy_id = $1
+ORDER BY weekday, opens_at;
+
+-- name: DeleteOpeningHours :exec
+DELETE FROM opening_hours WHERE pharmacy_id = $1;
+
+-- name: InsertOpeningHours :exec
+INSERT INTO opening_hours (pharmacy_id, weekday, opens_at, closes_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetPickupSettings :one
+SELECT slot_minutes, slot_capacity, daily_capacity
+FROM pickup_settings
+WHERE pharmacy_id = $1;
+
+-- name: UpsertPickupSettings :exec
+INSERT INTO pickup_settings (pharmacy_id, slot_minutes, slot_capacity, daily_capacity)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (pharmacy_id) DO UPDATE
+SET slot_minutes = EXCLUDED.slot_minutes,
+    slot_capacity = EXCLUDED.slot_capacity,
+    daily_capacity = EXCLUDED.daily_capacity,
+    updated_at = now();
+
+-- name: CountPickupBookings :many
+SELECT o.pickup_at::TIMESTAMP AS pickup_at, COUNT(*)::BIGINT AS booked
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+  AND o.status <> 'fulfilled'
+  AND o.pickup_at >= sqlc.arg(from_at)::TIMESTAMP
+  AND o.pickup_at < sqlc.arg(to_at)::TIMESTAMP
+  AND o.id <> sqlc.arg(exclude_order_id)::BIGINT
+GROUP BY o.pickup_at;
+
+-- name: ListPickupAppointments :many
+SELECT
+    o.id AS order_id,
+    o.pickup_at::TIMESTAMP AS pickup_at,
+    o.pickup_confirmed,
+    o.pickup_notified_at,
+    o.status AS order_status,
+    p.medication_name,
+    pat.id AS patient_id,
+    pat.first_name,
+    pat.last_name,
+    pat.phone,
+    pat.email
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+  AND o.pickup_at >= sqlc.arg(from_at)::TIMESTAMP
+  AND o.pickup_at < sqlc.arg(to_at)::TIMESTAMP
+ORDER BY o.pickup_at, pat.last_name, pat.first_name;
+
+-- name: GetPickupOrder :one
+SELECT
+    o.id AS order_id,
+    o.status AS order_status,
+    o.estimated_depletion_date,
+    o.pickup_at,
+    o.pickup_confirmed,
+    p.medication_name,
+    pat.fulfillment,
+    pat.first_name,
+    pat.last_name,
+    pat.phone,
+    pat.email,
+    ph.name AS pharmacy_name,
+    ph.pickup_reminder
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+JOIN pharmacies ph ON ph.id = pat.pharmacy_id
+WHERE o.id = sqlc.arg(order_id)::BIGINT
+  AND pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT;
+
+-- name: LockPickupBookings :exec
+-- Serialises bookings at one pharmacy until the transaction ends, so a slot's
+-- count cannot change between checking it and booking.
+SELECT pg_advisory_xact_lock(hashtext('pickup_bookings'), (sqlc.arg(pharmacy_id)::BIGINT % 2147483647)::INTEGER);
+
+-- name: AssignPickupSlot :exec
+UPDATE orders
+SET pickup_at = sqlc.arg(pickup_at), pickup_confirmed = sqlc.arg(pickup_confirmed), pickup_notified_at = NULL, updated_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: MarkPickupNotified :exec
+UPDATE orders
+SET pickup_notified_at = now(), updated_at = now()
+WHERE id = $1;
+
+-- name: ConfirmPickupSlot :exec
+UPDATE orders
+SET pickup_confirmed = true, updated_at = now()
+WHERE id = $1;
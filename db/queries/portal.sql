@@ -0,0 +1,89 @@
+-- name: FindPortalPatientsByEmail :many
+SELECT pat.id, pat.pharmacy_id, pat.first_name, pat.last_name, pat.phone, pat.email, ph.name AS pharmacy_name
+FROM patients pat
+JOIN pharmacies ph ON ph.id = pat.pharmacy_id
+WHERE pat.consensus AND lower(pat.email) = lower(sqlc.arg(email)::TEXT)
+ORDER BY pat.id;
+
+-- name: FindPortalPatientsByPhoneSuffix :many
+SELECT pat.id, pat.pharmacy_id, pat.first_name, pat.last_name, pat.phone, pat.email, ph.name AS pharmacy_name
+FROM patients pat
+JOIN pharmacies ph ON ph.id = pat.pharmacy_id
+WHERE pat.consensus AND regexp_replace(pat.phone, '[^0-9]', '', 'g') LIKE '%' || sqlc.arg(suffix)::TEXT
+ORDER BY pat.id;
+
+-- name: GetPortalPatient :one
+SELECT pat.id, pat.pharmacy_id, pat.first_name, pat.last_name, pat.phone, pat.email, ph.name AS pharmacy_name
+FROM patients pat
+JOIN pharmacies ph ON ph.id = pat.pharmacy_id
+WHERE pat.id = $1;
+
+-- name: CreatePatientLoginToken :exec
+INSERT INTO patient_login_tokens (patient_id, channel, token_hash, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: CountRecentPatientLoginTokens :one
+SELECT count(*) FROM patient_login_tokens
+WHERE patient_id = $1 AND created_at >= sqlc.arg(since)::TIMESTAMPTZ;
+
+-- name: ConsumePatientLoginLink :one
+UPDATE patient_login_tokens
+SET used_at = sqlc.arg(now)::TIMESTAMPTZ
+WHERE token_hash = sqlc.arg(token_hash)
+  AND channel = 'email'
+  AND used_at IS NULL
+  AND expires_at > sqlc.arg(now)::TIMESTAMPTZ
+RETURNING patient_id;
+
+-- name: ListActivePatientLoginCodes :many
+SELECT id, patient_id, token_hash
+FROM patient_login_tokens
+WHERE patient_id = ANY(sqlc.arg(patient_ids)::BIGINT[])
+  AND channel = 'phone'
+  AND used_at IS NULL
+  AND expires_at > sqlc.arg(now)::TIMESTAMPTZ
+  AND attempts < sqlc.arg(max_attempts)::INTEGER
+ORDER BY id;
+
+-- name: UsePatientLoginToken :execrows
+UPDATE patient_login_tokens
+SET used_at = sqlc.arg(now)::TIMESTAMPTZ
+WHERE id = $1 AND used_at IS NULL;
+
+-- name: IncrementPatientLoginAttempts :exec
+UPDATE patient_login_tokens
+SET attempts = attempts + 1
+WHERE id = ANY(sqlc.arg(ids)::BIGINT[]);
+
+-- name: ListPortalItems :many
+SELECT
+    p.id AS prescription_id,
+    p.medication_name,
+    p.units_per_box,
+    p.daily_consumption,
+    p.box_start_date,
+    pat.fulfillment,
+    COALESCE(o.id, 0)::BIGINT AS order_id,
+    COALESCE(o.status, '')::TEXT AS order_status,
+    o.pickup_at,
+    COALESCE(o.pickup_confirmed, false)::BOOLEAN AS pickup_confirmed,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on
+FROM prescriptions p
+JOIN patients pat ON pat.id = p.patient_id
+LEFT JOIN LATERAL (
+    SELECT id, status, pickup_at, pickup_confirmed
+    FROM orders
+    WHERE prescription_id = p.id AND status <> 'fulfilled'
+    ORDER BY cycle_start_date DESC
+    LIMIT 1
+) o ON true
+LEFT JOIN LATERAL (
+    SELECT units, reported_on
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
+WHERE p.patient_id = $1
+ORDER BY p.medication_name;
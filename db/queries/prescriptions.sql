@@ -4,15 +4,37 @@ VALUES ($1, $2, $3, $4, $5)
 RETURNING id, patient_id, medication_name, units_per_box, daily_consumption, box_start_date, created_at, updated_at;
 
 -- name: ListPrescriptionsByPatient :many
-SELECT id, patient_id, medication_name, units_per_box, daily_consumption, box_start_date, created_at, updated_at
-FROM prescriptions
-WHERE patient_id = $1
-ORDER BY medication_name;
+SELECT p.id, p.patient_id, p.medication_name, p.units_per_box, p.daily_consumption, p.box_start_date,
+    p.created_at, p.updated_at,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on,
+    COALESCE(sr.source, '')::TEXT AS report_source
+FROM prescriptions p
+LEFT JOIN LATERAL (
+    SELECT units, reported_on, source
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
+WHERE p.patient_id = $1
+ORDER BY p.medication_name;
 
 -- name: GetPrescriptionByID :one
-SELECT id, patient_id, medication_name, units_per_box, daily_consumption, box_start_date, created_at, updated_at
-FROM prescriptions
-WHERE id = $1;
+SELECT p.id, p.patient_id, p.medication_name, p.units_per_box, p.daily_consumption, p.box_start_date,
+    p.created_at, p.updated_at,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on,
+    COALESCE(sr.source, '')::TEXT AS report_source
+FROM prescriptions p
+LEFT JOIN LATERAL (
+    SELECT units, reported_on, source
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
+WHERE p.id = $1;
 
 -- name: UpdatePrescription :exec
 UPDATE prescriptions
@@ -22,3 +44,7 @@ WHERE id = $1;
 -- name: InsertRefillHistory :exec
 INSERT INTO refill_history (prescription_id, box_start_date, box_end_date)
 VALUES ($1, $2, $3);
+
+-- name: CreateStockReport :exec
+INSERT INTO stock_reports (prescription_id, units, reported_on, source)
+VALUES ($1, $2, $3, $4);
@@ -0,0 +1,44 @@
+-- name: ListPharmacyRoles :many
+SELECT r.id, r.name, r.permissions, COUNT(u.id) AS members
+FROM pharmacy_roles r
+LEFT JOIN users u ON u.custom_role_id = r.id
+WHERE r.pharmacy_id = $1
+GROUP BY r.id
+ORDER BY lower(r.name);
+
+-- name: GetPharmacyRole :one
+SELECT id, name, permissions
+FROM pharmacy_roles
+WHERE id = $1 AND pharmacy_id = $2;
+
+-- name: LockPharmacyRole :one
+SELECT id, name, permissions
+FROM pharmacy_roles
+WHERE id = $1 AND pharmacy_id = $2
+FOR UPDATE;
+
+-- name: CreatePharmacyRole :one
+INSERT INTO pharmacy_roles (pharmacy_id, name, permissions)
+VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: UpdatePharmacyRole :exec
+UPDATE pharmacy_roles
+SET name = $2, permissions = $3, updated_at = now()
+WHERE id = $1;
+
+-- name: DeletePharmacyRole :execrows
+DELETE FROM pharmacy_roles
+WHERE id = $1 AND pharmacy_id = $2;
+
+-- name: DeleteRoleHolderSessions :exec
+-- Logs out everyone holding the custom role: sessions carry the permissions
+-- granted at login, so a change must not wait for them to expire.
+WITH revoked AS (
+    DELETE FROM user_sessions us
+    USING users u
+    WHERE u.id = us.user_id AND u.custom_role_id = sqlc.arg(role_id)::BIGINT
+    RETURNING us.token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked);
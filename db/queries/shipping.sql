@@ -0,0 +1,130 @@
+-- name: ListShippableOrders :many
+SELECT
+    o.id AS order_id,
+    o.estimated_depletion_date,
+    p.medication_name,
+    pat.first_name,
+    pat.last_name,
+    pat.delivery_street,
+    pat.delivery_house_number,
+    pat.delivery_cap,
+    pat.delivery_city,
+    pat.delivery_province,
+    pat.delivery_country,
+    pat.delivery_notes,
+    pat.delivery_address_legacy
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN shipments s ON s.order_id = o.id
+WHERE pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+  AND pat.fulfillment = 'shipping'
+  AND o.status = 'prepared'
+  AND s.id IS NULL
+ORDER BY pat.last_name, pat.first_name, o.estimated_depletion_date;
+
+-- name: CreateShippingBatch :one
+INSERT INTO shipping_batches (pharmacy_id)
+VALUES ($1)
+RETURNING id, pharmacy_id, status, shipped_at, created_at, updated_at;
+
+-- name: AddShipmentsToBatch :execrows
+INSERT INTO shipments (batch_id, order_id)
+SELECT sqlc.arg(batch_id)::BIGINT, o.id
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN shipments s ON s.order_id = o.id
+WHERE o.id = ANY(sqlc.arg(order_ids)::BIGINT[])
+  AND pat.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+  AND pat.fulfillment = 'shipping'
+  AND o.status = 'prepared'
+  AND s.id IS NULL;
+
+-- name: ListShippingBatches :many
+SELECT
+    b.id,
+    b.pharmacy_id,
+    b.status,
+    b.shipped_at,
+    b.created_at,
+    COUNT(s.id)::BIGINT AS parcel_count,
+    COUNT(s.id) FILTER (WHERE s.status = 'delivered')::BIGINT AS delivered_count
+FROM shipping_batches b
+LEFT JOIN shipments s ON s.batch_id = b.id
+WHERE b.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+GROUP BY b.id
+ORDER BY b.created_at DESC;
+
+-- name: GetShippingBatch :one
+SELECT
+    b.id,
+    b.pharmacy_id,
+    b.status,
+    b.shipped_at,
+    b.created_at,
+    COUNT(s.id)::BIGINT AS parcel_count,
+    COUNT(s.id) FILTER (WHERE s.status = 'delivered')::BIGINT AS delivered_count
+FROM shipping_batches b
+LEFT JOIN shipments s ON s.batch_id = b.id
+WHERE b.id = sqlc.arg(id)::BIGINT
+  AND b.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+GROUP BY b.id;
+
+-- name: ListBatchShipments :many
+SELECT
+    s.order_id,
+    s.batch_id,
+    s.tracking_number,
+    s.status,
+    p.medication_name,
+    pat.first_name,
+    pat.last_name,
+    pat.phone,
+    pat.email,
+    pat.delivery_street,
+    pat.delivery_house_number,
+    pat.delivery_cap,
+    pat.delivery_city,
+    pat.delivery_province,
+    pat.delivery_country,
+    pat.delivery_notes,
+    pat.delivery_address_legacy
+FROM shipments s
+JOIN shipping_batches b ON s.batch_id = b.id
+JOIN orders o ON s.order_id = o.id
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE s.batch_id = sqlc.arg(batch_id)::BIGINT
+  AND b.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+ORDER BY pat.last_name, pat.first_name, s.order_id;
+
+-- name: UpdateShipmentTracking :exec
+UPDATE shipments s
+SET tracking_number = sqlc.arg(tracking_number), updated_at = now()
+FROM shipping_batches b
+WHERE s.batch_id = b.id
+  AND s.batch_id = sqlc.arg(batch_id)::BIGINT
+  AND s.order_id = sqlc.arg(order_id)::BIGINT
+  AND b.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT;
+
+-- name: MarkShippingBatchShipped :execrows
+UPDATE shipping_batches
+SET status = 'shipped', shipped_at = now(), updated_at = now()
+WHERE id = sqlc.arg(id)::BIGINT
+  AND pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+  AND status = 'open';
+
+-- name: MarkBatchShipmentsShipped :exec
+UPDATE shipments
+SET status = 'shipped', shipped_at = now(), updated_at = now()
+WHERE batch_id = $1 AND status = 'ready';
+
+-- name: MarkShipmentDelivered :execrows
+UPDATE shipments s
+SET status = 'delivered', delivered_at = now(), updated_at = now()
+FROM shipping_batches b
+WHERE s.batch_id = b.id
+  AND s.order_id = sqlc.arg(order_id)::BIGINT
+  AND b.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+  AND s.status = 'shipped';
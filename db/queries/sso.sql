@@ -0,0 +1,17 @@
+-- name: GetPharmacySSO :one
+SELECT pharmacy_id, enabled, issuer, client_id, client_secret, role_claim, owner_value, personnel_value
+FROM pharmacy_sso
+WHERE pharmacy_id = $1;
+
+-- name: UpsertPharmacySSO :exec
+INSERT INTO pharmacy_sso (pharmacy_id, enabled, issuer, client_id, client_secret, role_claim, owner_value, personnel_value)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (pharmacy_id) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    issuer = EXCLUDED.issuer,
+    client_id = EXCLUDED.client_id,
+    client_secret = EXCLUDED.client_secret,
+    role_claim = EXCLUDED.role_claim,
+    owner_value = EXCLUDED.owner_value,
+    personnel_value = EXCLUDED.personnel_value,
+    updated_at = now();
@@ -1,25 +1,254 @@
 -- name: GetUserByEmail :one
-SELECT id, email, password_hash, name, role, pharmacy_id, created_at, updated_at
-FROM users
-WHERE email = $1;
+SELECT u.id, u.email, u.password_hash, u.name, u.role, u.pharmacy_id, u.active, u.must_change_password,
+    u.totp_enabled_at, u.locked_until, COALESCE(p.require_2fa, false)::BOOLEAN AS pharmacy_requires_2fa,
+    COALESCE(r.permissions, '{}')::TEXT[] AS custom_permissions,
+    u.locale, COALESCE(p.locale, '')::TEXT AS pharmacy_locale,
+    (u.oidc_issuer IS NOT NULL)::BOOLEAN AS federated
+FROM users u
+LEFT JOIN pharmacies p ON p.id = u.pharmacy_id
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.email = $1;
 
 -- name: GetUserByID :one
-SELECT id, email, password_hash, name, role, pharmacy_id, created_at, updated_at
-FROM users
-WHERE id = $1;
+SELECT u.id, u.email, u.password_hash, u.name, u.role, u.pharmacy_id, u.active, u.must_change_password,
+    u.totp_enabled_at, u.locked_until, COALESCE(p.require_2fa, false)::BOOLEAN AS pharmacy_requires_2fa,
+    COALESCE(r.permissions, '{}')::TEXT[] AS custom_permissions,
+    u.locale, COALESCE(p.locale, '')::TEXT AS pharmacy_locale,
+    (u.oidc_issuer IS NOT NULL)::BOOLEAN AS federated
+FROM users u
+LEFT JOIN pharmacies p ON p.id = u.pharmacy_id
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.id = $1;
+
+-- name: GetUserByIdentity :one
+SELECT u.id, u.email, u.password_hash, u.name, u.role, u.pharmacy_id, u.active, u.must_change_password,
+    u.totp_enabled_at, u.locked_until, COALESCE(p.require_2fa, false)::BOOLEAN AS pharmacy_requires_2fa,
+    COALESCE(r.permissions, '{}')::TEXT[] AS custom_permissions,
+    u.locale, COALESCE(p.locale, '')::TEXT AS pharmacy_locale,
+    (u.oidc_issuer IS NOT NULL)::BOOLEAN AS federated
+FROM users u
+LEFT JOIN pharmacies p ON p.id = u.pharmacy_id
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.oidc_issuer = sqlc.arg(issuer)::TEXT AND u.oidc_subject = sqlc.arg(subject)::TEXT;
+
+-- name: CreateFederatedUser :one
+-- Provisions a user signing in through their pharmacy's identity provider.
+-- The empty password hash never matches a password login.
+INSERT INTO users (email, password_hash, name, role, pharmacy_id, oidc_issuer, oidc_subject)
+VALUES (sqlc.arg(email), '', sqlc.arg(name), sqlc.arg(role), sqlc.arg(pharmacy_id)::BIGINT, sqlc.arg(issuer)::TEXT, sqlc.arg(subject)::TEXT)
+RETURNING id;
 
 -- name: CreateUser :one
-INSERT INTO users (email, password_hash, name, role, pharmacy_id)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, email, password_hash, name, role, pharmacy_id, created_at, updated_at;
+INSERT INTO users (email, password_hash, name, role, pharmacy_id, custom_role_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, email, password_hash, name, role, pharmacy_id, custom_role_id, created_at, updated_at;
 
 -- name: ListUsersByPharmacy :many
-SELECT id, email, password_hash, name, role, pharmacy_id, created_at, updated_at
+SELECT u.id, u.email, u.name, u.role, u.custom_role_id, COALESCE(r.name, '')::TEXT AS custom_role_name,
+    u.active, u.must_change_password, (u.totp_enabled_at IS NOT NULL)::BOOLEAN AS two_factor_enabled, u.locked_until
+FROM users u
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+ORDER BY u.name;
+
+-- name: GetPharmacyUser :one
+SELECT u.id, u.email, u.name, u.role, u.custom_role_id, COALESCE(r.name, '')::TEXT AS custom_role_name,
+    u.active, u.must_change_password, (u.totp_enabled_at IS NOT NULL)::BOOLEAN AS two_factor_enabled, u.locked_until,
+    (u.oidc_issuer IS NOT NULL)::BOOLEAN AS federated
+FROM users u
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.id = sqlc.arg(id) AND u.pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT;
+
+-- name: LockPharmacyUser :one
+SELECT id, role, custom_role_id, active, (oidc_issuer IS NOT NULL)::BOOLEAN AS federated
 FROM users
-WHERE pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
-ORDER BY name;
+WHERE id = sqlc.arg(id) AND pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT
+FOR UPDATE;
+
+-- name: LockActivePharmacyOwners :many
+-- Locks the active owners so two concurrent changes cannot both remove "the
+-- other" owner.
+SELECT id
+FROM users
+WHERE pharmacy_id = sqlc.arg(pharmacy_id)::BIGINT AND role = 'owner' AND active
+FOR UPDATE;
 
 -- name: UpdateUserPassword :exec
 UPDATE users
-SET password_hash = $2, updated_at = now()
+SET password_hash = $2, must_change_password = false, updated_at = now()
+WHERE id = $1;
+
+-- name: SetUserLocale :exec
+UPDATE users
+SET locale = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: ResetUserPassword :exec
+UPDATE users
+SET password_hash = $2, must_change_password = true, updated_at = now()
+WHERE id = $1;
+
+-- name: SetUserActive :exec
+UPDATE users
+SET active = $2, updated_at = now()
+WHERE id = $1;
+
+-- name: SetUserRole :exec
+UPDATE users
+SET role = $2, custom_role_id = $3, updated_at = now()
+WHERE id = $1;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
+
+-- name: RecordUserLogin :exec
+UPDATE users
+SET last_login_at = now(), failed_logins = 0, locked_until = NULL
+WHERE id = $1;
+
+-- name: IncrementFailedLogins :one
+UPDATE users
+SET failed_logins = failed_logins + 1
+WHERE id = $1
+RETURNING failed_logins;
+
+-- name: LockUser :exec
+UPDATE users
+SET locked_until = sqlc.arg(locked_until)::TIMESTAMPTZ
+WHERE id = sqlc.arg(id);
+
+-- name: UnlockUser :exec
+UPDATE users
+SET failed_logins = 0, locked_until = NULL
+WHERE id = $1;
+
+-- name: CreateLoginEvent :exec
+INSERT INTO login_events (user_id, email, ip, user_agent, success, created_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: CountRecentFailedLoginsByIP :one
+SELECT count(*)::INT
+FROM login_events
+WHERE ip = sqlc.arg(ip) AND NOT success AND created_at > sqlc.arg(since)::TIMESTAMPTZ;
+
+-- name: ListLoginEventsByUser :many
+SELECT id, ip, user_agent, success, created_at
+FROM login_events
+WHERE user_id = sqlc.arg(user_id)::BIGINT
+ORDER BY created_at DESC
+LIMIT sqlc.arg(max_events)::INT;
+
+-- name: TrackUserSession :exec
+INSERT INTO user_sessions (token, user_id, ip, user_agent)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (token) DO NOTHING;
+
+-- name: TouchUserSession :exec
+UPDATE user_sessions
+SET last_seen_at = $2
+WHERE token = $1;
+
+-- name: ListUserSessions :many
+-- Only sessions still alive in the scs store; current marks the caller's.
+SELECT us.id, us.ip, us.user_agent, us.created_at, us.last_seen_at,
+    (us.token = sqlc.arg(current_token)::TEXT)::BOOLEAN AS current
+FROM user_sessions us
+JOIN sessions s ON s.token = us.token
+WHERE us.user_id = sqlc.arg(user_id)::BIGINT AND s.expiry > now()
+ORDER BY us.last_seen_at DESC;
+
+-- name: DeleteUserSession :execrows
+WITH revoked AS (
+    DELETE FROM user_sessions
+    WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)::BIGINT
+    RETURNING token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked);
+
+-- name: DeleteOtherUserSessions :execrows
+-- Logs the user out everywhere except the session holding keep_token.
+WITH revoked AS (
+    DELETE FROM user_sessions
+    WHERE user_id = sqlc.arg(user_id)::BIGINT AND token <> sqlc.arg(keep_token)::TEXT
+    RETURNING token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked);
+
+-- name: PruneUserSessions :exec
+-- Drops the records of sessions that have expired or been logged out.
+DELETE FROM user_sessions us
+WHERE us.user_id = $1
+  AND NOT EXISTS (SELECT 1 FROM sessions s WHERE s.token = us.token);
+
+-- name: DeleteUserSessions :exec
+-- Logs the user out everywhere: removes their rows from the scs store.
+WITH revoked AS (
+    DELETE FROM user_sessions
+    WHERE user_id = $1
+    RETURNING token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked);
+
+-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: CountRecentPasswordResetTokens :one
+SELECT count(*) FROM password_reset_tokens
+WHERE user_id = $1 AND created_at >= sqlc.arg(since)::TIMESTAMPTZ;
+
+-- name: ConsumePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = sqlc.arg(now)::TIMESTAMPTZ
+WHERE token_hash = sqlc.arg(token_hash)
+  AND used_at IS NULL
+  AND expires_at > sqlc.arg(now)::TIMESTAMPTZ
+RETURNING user_id;
+
+-- name: ExpirePasswordResetTokens :exec
+-- Once the password is reset, the other links still in the mailbox stop working.
+UPDATE password_reset_tokens
+SET used_at = sqlc.arg(now)::TIMESTAMPTZ
+WHERE user_id = $1 AND used_at IS NULL;
+
+-- name: GetUserTOTP :one
+SELECT totp_secret::TEXT AS totp_secret, totp_last_step
+FROM users
+WHERE id = $1 AND totp_secret IS NOT NULL;
+
+-- name: UseUserTOTPStep :execrows
+-- Accepts a code's time step only if it is newer than the last one used.
+UPDATE users
+SET totp_last_step = sqlc.arg(step)
+WHERE id = sqlc.arg(id) AND totp_last_step < sqlc.arg(step);
+
+-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_secret = sqlc.arg(totp_secret)::TEXT, totp_enabled_at = now(), totp_last_step = sqlc.arg(step), updated_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = now()
 WHERE id = $1;
+
+-- name: DeleteUserRecoveryCodes :exec
+DELETE FROM user_recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateUserRecoveryCode :exec
+INSERT INTO user_recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: UseUserRecoveryCode :execrows
+UPDATE user_recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT count(*) FROM user_recovery_codes
+WHERE user_id = $1 AND used_at IS NULL;
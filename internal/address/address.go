@@ -0,0 +1,175 @@
+// Package address models structured delivery addresses and validates Italian
+// CAP/province pairs against an embedded dataset of per-province CAP ranges.
+package address
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrStreetRequired      = errors.New("address.street_required")
+	ErrCityRequired        = errors.New("address.city_required")
+	ErrInvalidCAP          = errors.New("address.invalid_cap")
+	ErrCAPRequired         = errors.New("address.cap_required")
+	ErrUnknownProvince     = errors.New("address.unknown_province")
+	ErrCAPProvinceMismatch = errors.New("address.cap_province_mismatch")
+	ErrInvalidCountry      = errors.New("address.invalid_country")
+)
+
+// CountryItaly is the default country code.
+const CountryItaly = "IT"
+
+// Address is a structured postal address.
+//
+// Legacy holds the free-text address recorded before addresses were
+// structured. It is kept for display until staff save the structured fields.
+type Address struct {
+	Street      string
+	HouseNumber string
+	CAP         string
+	City        string
+	Province    string
+	Country     string
+	Notes       string
+	Legacy      string
+}
+
+// IsZero reports whether none of the structured location fields are set.
+func (a Address) IsZero() bool {
+	return a.Street == "" && a.HouseNumber == "" && a.CAP == "" && a.City == "" && a.Province == ""
+}
+
+// Empty reports whether there is nothing to show, structured or legacy.
+func (a Address) Empty() bool {
+	return a.IsZero() && a.Legacy == ""
+}
+
+// Normalize trims all fields, upper-cases province and country codes and
+// defaults the country to Italy.
+func Normalize(a Address) Address {
+	a.Street = strings.TrimSpace(a.Street)
+	a.HouseNumber = strings.TrimSpace(a.HouseNumber)
+	a.CAP = strings.TrimSpace(a.CAP)
+	a.City = strings.TrimSpace(a.City)
+	a.Province = strings.ToUpper(strings.TrimSpace(a.Province))
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.Notes = strings.TrimSpace(a.Notes)
+	a.Legacy = strings.TrimSpace(a.Legacy)
+	if a.Country == "" {
+		a.Country = CountryItaly
+	}
+	return a
+}
+
+var capPattern = regexp.MustCompile(`^\d{5}$`)
+
+// Validate checks a normalized address. Italian addresses need a 5-digit CAP
+// inside one of the ranges of the given province; foreign addresses only need
+// a postal code and city.
+func Validate(a Address) error {
+	if a.Street == "" || a.HouseNumber == "" {
+		return ErrStreetRequired
+	}
+	if a.City == "" {
+		return ErrCityRequired
+	}
+	if len(a.Country) != 2 {
+		return ErrInvalidCountry
+	}
+	if a.Country != CountryItaly {
+		if a.CAP == "" {
+			return ErrCAPRequired
+		}
+		return nil
+	}
+	if !capPattern.MatchString(a.CAP) {
+		return ErrInvalidCAP
+	}
+	if _, ok := ProvinceName(a.Province); !ok {
+		return ErrUnknownProvince
+	}
+	if !CAPInProvince(a.CAP, a.Province) {
+		return ErrCAPProvinceMismatch
+	}
+	return nil
+}
+
+// Line1 returns street and house number, or the legacy text if unstructured.
+func (a Address) Line1() string {
+	if a.IsZero() {
+		return a.Legacy
+	}
+	return strings.TrimSpace(a.Street + " " + a.HouseNumber)
+}
+
+// Line2 returns "CAP City (PR)", followed by the country for foreign addresses.
+func (a Address) Line2() string {
+	if a.IsZero() {
+		return ""
+	}
+	s := strings.TrimSpace(a.CAP + " " + a.City)
+	if a.Province != "" {
+		s += " (" + a.Province + ")"
+	}
+	if a.Country != "" && a.Country != CountryItaly {
+		s += " – " + a.Country
+	}
+	return s
+}
+
+// String returns the address on one line.
+func (a Address) String() string {
+	if l2 := a.Line2(); l2 != "" {
+		return a.Line1() + ", " + l2
+	}
+	return a.Line1()
+}
+
+// legacyPattern matches the common free-text shape
+// "Via Roma 1, 20121 Milano (MI)" with optional commas, dashes and province.
+var legacyPattern = regexp.MustCompile(
+	`^(.+?)[\s,]+(\d+(?:\s?[A-Za-z]|/[A-Za-z0-9]+)?)[\s,–-]+(\d{5})\s+(.+?)(?:\s*\(([A-Za-z]{2})\)|\s+([A-Za-z]{2}))?$`)
+
+// FromLegacy builds an address from a free-text value, filling the
+// structured fields when the text follows the usual Italian layout. The
+// original text is always kept in Legacy so staff can verify the result.
+func FromLegacy(text string) Address {
+	a := Address{Legacy: strings.TrimSpace(text)}
+	m := legacyPattern.FindStringSubmatch(strings.Join(strings.Fields(a.Legacy), " "))
+	if m == nil {
+		return a
+	}
+
+	province := strings.ToUpper(m[5] + m[6])
+	city := m[4]
+	if province != "" {
+		if _, ok := ProvinceName(province); !ok {
+			city += " " + m[6]
+			province = ""
+		}
+	}
+	if province == "" {
+		province, _ = ProvinceForCAP(m[3])
+	}
+
+	a.Street = m[1]
+	a.HouseNumber = m[2]
+	a.CAP = m[3]
+	a.City = strings.TrimSpace(city)
+	a.Province = province
+	a.Country = CountryItaly
+	return a
+}
+
+// WithLegacy returns a if it has structured fields, otherwise an address
+// parsed from the legacy free text. Notes are preserved either way.
+func WithLegacy(a Address, legacy string) Address {
+	if !a.IsZero() || legacy == "" {
+		return a
+	}
+	parsed := FromLegacy(legacy)
+	parsed.Notes = a.Notes
+	return parsed
+}
@@ -0,0 +1,155 @@
+package address_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
+)
+
+func valid() address.Address {
+	return address.Address{
+		Street: "Via Roma", HouseNumber: "1", CAP: "20121", City: "Milano", Province: "MI", Country: "IT",
+	}
+}
+
+func TestValidateAcceptsItalianAddress(t *testing.T) {
+	if err := address.Validate(valid()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*address.Address)
+		want   error
+	}{
+		{"missing street", func(a *address.Address) { a.Street = "" }, address.ErrStreetRequired},
+		{"missing house number", func(a *address.Address) { a.HouseNumber = "" }, address.ErrStreetRequired},
+		{"missing city", func(a *address.Address) { a.City = "" }, address.ErrCityRequired},
+		{"short CAP", func(a *address.Address) { a.CAP = "2012" }, address.ErrInvalidCAP},
+		{"letters in CAP", func(a *address.Address) { a.CAP = "2012A" }, address.ErrInvalidCAP},
+		{"unknown province", func(a *address.Address) { a.Province = "XX" }, address.ErrUnknownProvince},
+		{"CAP of another province", func(a *address.Address) { a.CAP = "00184" }, address.ErrCAPProvinceMismatch},
+		{"invalid country", func(a *address.Address) { a.Country = "ITA" }, address.ErrInvalidCountry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid()
+			tt.modify(&a)
+			if err := address.Validate(a); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateForeignAddressSkipsCAPRanges(t *testing.T) {
+	a := address.Address{Street: "Rue de Rivoli", HouseNumber: "10", CAP: "75001", City: "Paris", Country: "FR"}
+	if err := address.Validate(a); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	a.CAP = ""
+	if err := address.Validate(a); !errors.Is(err, address.ErrCAPRequired) {
+		t.Errorf("got %v, want ErrCAPRequired", err)
+	}
+}
+
+func TestCAPInProvinceUsesAllRanges(t *testing.T) {
+	tests := []struct {
+		cap, province string
+		want          bool
+	}{
+		{"00184", "RM", true},
+		{"20900", "MB", true},
+		{"20900", "MI", false},
+		{"33170", "PN", true},
+		{"33080", "PN", true},
+		{"33100", "UD", true},
+		{"33170", "UD", false},
+		{"39100", "BZ", true},
+	}
+	for _, tt := range tests {
+		if got := address.CAPInProvince(tt.cap, tt.province); got != tt.want {
+			t.Errorf("CAPInProvince(%q, %q) = %v, want %v", tt.cap, tt.province, got, tt.want)
+		}
+	}
+}
+
+func TestProvinceForCAP(t *testing.T) {
+	if p, ok := address.ProvinceForCAP("50122"); !ok || p != "FI" {
+		t.Errorf("ProvinceForCAP(50122) = %q, %v; want FI", p, ok)
+	}
+	// Sardinian ranges are shared between provinces: no unique answer.
+	if p, ok := address.ProvinceForCAP("09020"); ok {
+		t.Errorf("ProvinceForCAP(09020) = %q, want ambiguous", p)
+	}
+}
+
+func TestProvincesHas107Entries(t *testing.T) {
+	if n := len(address.Provinces()); n != 107 {
+		t.Errorf("len(Provinces()) = %d, want 107", n)
+	}
+}
+
+func TestNormalizeDefaultsCountry(t *testing.T) {
+	a := address.Normalize(address.Address{Street: " Via Roma ", Province: "mi"})
+	if a.Street != "Via Roma" || a.Province != "MI" || a.Country != "IT" {
+		t.Errorf("Normalize = %+v", a)
+	}
+}
+
+func TestLines(t *testing.T) {
+	a := valid()
+	if a.Line1() != "Via Roma 1" {
+		t.Errorf("Line1 = %q", a.Line1())
+	}
+	if a.Line2() != "20121 Milano (MI)" {
+		t.Errorf("Line2 = %q", a.Line2())
+	}
+	legacy := address.Address{Legacy: "Cascina Bianca, Lodi"}
+	if legacy.String() != "Cascina Bianca, Lodi" {
+		t.Errorf("legacy String = %q", legacy.String())
+	}
+}
+
+func TestFromLegacy(t *testing.T) {
+	tests := []struct {
+		in   string
+		want address.Address
+	}{
+		{"Via Roma 1, 20121 Milano (MI)", address.Address{Street: "Via Roma", HouseNumber: "1", CAP: "20121", City: "Milano", Province: "MI"}},
+		{"Corso Italia, 12/B - 50123 Firenze FI", address.Address{Street: "Corso Italia", HouseNumber: "12/B", CAP: "50123", City: "Firenze", Province: "FI"}},
+		{"Via Emilia 40 42121 Reggio Emilia", address.Address{Street: "Via Emilia", HouseNumber: "40", CAP: "42121", City: "Reggio Emilia", Province: "RE"}},
+		{"Via Sparano 3, 70121 Bari", address.Address{Street: "Via Sparano", HouseNumber: "3", CAP: "70121", City: "Bari", Province: "BA"}},
+	}
+	for _, tt := range tests {
+		got := address.FromLegacy(tt.in)
+		if got.Street != tt.want.Street || got.HouseNumber != tt.want.HouseNumber || got.CAP != tt.want.CAP ||
+			got.City != tt.want.City || got.Province != tt.want.Province {
+			t.Errorf("FromLegacy(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if got.Legacy != tt.in {
+			t.Errorf("FromLegacy(%q).Legacy = %q", tt.in, got.Legacy)
+		}
+	}
+}
+
+func TestFromLegacyUnparseableKeepsText(t *testing.T) {
+	got := address.FromLegacy("Cascina Bianca, dopo il ponte")
+	if !got.IsZero() || got.Legacy != "Cascina Bianca, dopo il ponte" {
+		t.Errorf("FromLegacy = %+v", got)
+	}
+}
+
+func TestWithLegacyPrefersStructured(t *testing.T) {
+	a := address.WithLegacy(valid(), "Via Vecchia 9, 20121 Milano")
+	if a.Street != "Via Roma" {
+		t.Errorf("structured address overwritten: %+v", a)
+	}
+	b := address.WithLegacy(address.Address{Notes: "citofono 3"}, "Via Vecchia 9, 20121 Milano")
+	if b.Street != "Via Vecchia" || b.Notes != "citofono 3" {
+		t.Errorf("WithLegacy = %+v", b)
+	}
+}
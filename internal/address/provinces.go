@@ -0,0 +1,97 @@
+package address
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed provinces.csv
+var provincesCSV string
+
+// Province is an Italian province with its two-letter code.
+type Province struct {
+	Code string
+	Name string
+}
+
+type capRange struct {
+	province string
+	from, to int
+}
+
+var (
+	provinces     []Province
+	provinceNames = map[string]string{}
+	capRanges     []capRange
+)
+
+func init() {
+	r := csv.NewReader(strings.NewReader(provincesCSV))
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("address: parsing provinces.csv: %v", err))
+	}
+	for _, rec := range records[1:] {
+		from, errFrom := strconv.Atoi(rec[2])
+		to, errTo := strconv.Atoi(rec[3])
+		if errFrom != nil || errTo != nil {
+			panic(fmt.Sprintf("address: invalid CAP range %v", rec))
+		}
+		if _, seen := provinceNames[rec[0]]; !seen {
+			provinceNames[rec[0]] = rec[1]
+			provinces = append(provinces, Province{Code: rec[0], Name: rec[1]})
+		}
+		capRanges = append(capRanges, capRange{province: rec[0], from: from, to: to})
+	}
+	sort.Slice(provinces, func(i, j int) bool { return provinces[i].Name < provinces[j].Name })
+}
+
+// Provinces returns all Italian provinces sorted by name.
+func Provinces() []Province {
+	return provinces
+}
+
+// ProvinceName returns the name of the province with the given code.
+func ProvinceName(code string) (string, bool) {
+	name, ok := provinceNames[code]
+	return name, ok
+}
+
+// CAPInProvince reports whether postcode falls in one of the province's ranges.
+func CAPInProvince(postcode, province string) bool {
+	n, err := strconv.Atoi(postcode)
+	if err != nil {
+		return false
+	}
+	for _, r := range capRanges {
+		if r.province == province && n >= r.from && n <= r.to {
+			return true
+		}
+	}
+	return false
+}
+
+// ProvinceForCAP returns the province a CAP belongs to when exactly one
+// province claims it.
+func ProvinceForCAP(postcode string) (string, bool) {
+	n, err := strconv.Atoi(postcode)
+	if err != nil {
+		return "", false
+	}
+	found := ""
+	for _, r := range capRanges {
+		if n < r.from || n > r.to {
+			continue
+		}
+		if found != "" && found != r.province {
+			return "", false
+		}
+		found = r.province
+	}
+	return found, found != ""
+}
@@ -0,0 +1,225 @@
+// Package analytics computes the owner's reporting figures: weekly order
+// volume, time to fulfilment, late refills, top medications and a forecast
+// of the orders coming due.
+package analytics
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+	"github.com/giorgiovilardo/pharmarecall/internal/depletion"
+)
+
+const (
+	Weeks               = 12 // weeks covered by the report
+	ForecastDays        = 30 // days covered by the forecast, today included
+	TopMedicationsLimit = 5
+)
+
+// OrderEvent holds when an order was created, prepared and fulfilled.
+// PreparedAt and FulfilledAt are nil until the order gets there.
+type OrderEvent struct {
+	CreatedAt   time.Time
+	PreparedAt  *time.Time
+	FulfilledAt *time.Time
+}
+
+// Refill is a recorded refill with the estimated end of the box it replaced.
+type Refill struct {
+	BoxEndDate time.Time
+	RefilledAt time.Time
+}
+
+// Late reports whether the refill came after the previous box ran out.
+func (r Refill) Late() bool {
+	return Day(r.RefilledAt).After(r.BoxEndDate)
+}
+
+// Prescription is what the forecast needs of a prescription.
+type Prescription struct {
+	UnitsPerBox      int
+	DailyConsumption float64
+	BoxStartDate     time.Time
+	ReportedUnits    int       // latest stock report, meaningful when ReportedOn is set
+	ReportedOn       time.Time // zero when no stock was reported
+}
+
+// EstimatedDepletionDate returns when the current box runs out, re-anchored
+// on the latest stock report.
+func (p Prescription) EstimatedDepletionDate() time.Time {
+	return depletion.EstimatedDateFromReport(p.UnitsPerBox, p.DailyConsumption, p.BoxStartDate, p.ReportedUnits, p.ReportedOn)
+}
+
+// WeekCount is the number of orders that reached each status in a week.
+type WeekCount struct {
+	Start     time.Time // Monday
+	Created   int
+	Prepared  int
+	Fulfilled int
+}
+
+// MedicationCount is the number of orders of a medication.
+type MedicationCount struct {
+	Name   string
+	Orders int
+}
+
+// DayCount is the number of orders due on a day.
+type DayCount struct {
+	Date   time.Time
+	Orders int
+}
+
+// Report is everything the analytics page shows.
+type Report struct {
+	Since             time.Time // first day covered by the figures
+	Weeks             []WeekCount
+	MedianFulfillment time.Duration // from creation (pending) to fulfilment
+	Fulfilled         int           // orders behind MedianFulfillment
+	Refills           int
+	LateRefills       int
+	TopMedications    []MedicationCount
+	Forecast          []DayCount
+}
+
+// LateRefillShare returns the share of refills made after depletion, 0 to 1.
+func (r Report) LateRefillShare() float64 {
+	if r.Refills == 0 {
+		return 0
+	}
+	return float64(r.LateRefills) / float64(r.Refills)
+}
+
+// ForecastTotal returns the number of orders due over the forecast.
+func (r Report) ForecastTotal() int {
+	total := 0
+	for _, d := range r.Forecast {
+		total += d.Orders
+	}
+	return total
+}
+
+// Day returns the local calendar day of t as midnight UTC, the
+// representation used for dates throughout the app.
+func Day(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// WeekStart returns the Monday of t's week.
+func WeekStart(t time.Time) time.Time {
+	d := Day(t)
+	offset := (int(d.Weekday()) + 6) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// PeriodStart returns the Monday that opens the last weeks weeks up to now.
+func PeriodStart(now time.Time, weeks int) time.Time {
+	return WeekStart(now).AddDate(0, 0, -7*(weeks-1))
+}
+
+// WeeklyCounts buckets order events into the last weeks weeks up to now,
+// oldest first. Events outside the period are ignored.
+func WeeklyCounts(events []OrderEvent, now time.Time, weeks int) []WeekCount {
+	start := PeriodStart(now, weeks)
+	result := make([]WeekCount, weeks)
+	for i := range result {
+		result[i].Start = start.AddDate(0, 0, 7*i)
+	}
+	index := func(t time.Time) int {
+		i := int(WeekStart(t).Sub(start).Hours() / (24 * 7))
+		if i < 0 || i >= weeks {
+			return -1
+		}
+		return i
+	}
+	for _, e := range events {
+		if i := index(e.CreatedAt); i >= 0 {
+			result[i].Created++
+		}
+		if e.PreparedAt != nil {
+			if i := index(*e.PreparedAt); i >= 0 {
+				result[i].Prepared++
+			}
+		}
+		if e.FulfilledAt != nil {
+			if i := index(*e.FulfilledAt); i >= 0 {
+				result[i].Fulfilled++
+			}
+		}
+	}
+	return result
+}
+
+// MedianFulfillment returns the median time from creation to fulfilment of
+// the orders fulfilled since the given time, and how many there were.
+func MedianFulfillment(events []OrderEvent, since time.Time) (time.Duration, int) {
+	var durations []time.Duration
+	for _, e := range events {
+		if e.FulfilledAt == nil || e.FulfilledAt.Before(since) {
+			continue
+		}
+		durations = append(durations, e.FulfilledAt.Sub(e.CreatedAt))
+	}
+	if len(durations) == 0 {
+		return 0, 0
+	}
+	slices.Sort(durations)
+	mid := len(durations) / 2
+	if len(durations)%2 == 1 {
+		return durations[mid], len(durations)
+	}
+	return (durations[mid-1] + durations[mid]) / 2, len(durations)
+}
+
+// CountLate returns how many refills came after depletion.
+func CountLate(refills []Refill) int {
+	late := 0
+	for _, r := range refills {
+		if r.Late() {
+			late++
+		}
+	}
+	return late
+}
+
+// Forecast projects how many orders must be ready on each of the next days
+// days from today. Every prescription is assumed to be refilled when it
+// runs out, so its orders fall one box apart from the current estimate;
+// each order is counted on its prepare-by date in the pharmacy calendar.
+func Forecast(prescriptions []Prescription, cal calendar.Calendar, today time.Time, days int) []DayCount {
+	from := Day(today)
+	result := make([]DayCount, days)
+	for i := range result {
+		result[i].Date = from.AddDate(0, 0, i)
+	}
+	until := from.AddDate(0, 0, days)
+
+	for _, rx := range prescriptions {
+		if rx.DailyConsumption <= 0 {
+			continue
+		}
+		cycle := max(int(math.Floor(float64(rx.UnitsPerBox)/rx.DailyConsumption)), 1)
+		d := rx.EstimatedDepletionDate()
+		if d.Before(from) {
+			// Skip whole boxes that are already over.
+			behind := int(from.Sub(d).Hours() / 24)
+			d = d.AddDate(0, 0, (behind+cycle-1)/cycle*cycle)
+		}
+		// Prepare-by dates only move forward with d; a closure can pull one
+		// from a box depleting after the forecast into range.
+		for ; ; d = d.AddDate(0, 0, cycle) {
+			due := cal.PrepareBy(d)
+			if !due.Before(until) {
+				break
+			}
+			if due.Before(from) {
+				continue
+			}
+			result[int(due.Sub(from).Hours()/24)].Orders++
+		}
+	}
+	return result
+}
@@ -0,0 +1,153 @@
+package analytics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/analytics"
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// noon keeps the local calendar day stable whatever the test machine's zone.
+func noon(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 12, 0, 0, 0, time.Local)
+}
+
+func ptr(t time.Time) *time.Time { return &t }
+
+func TestWeekStart(t *testing.T) {
+	// Jan 28, 2026 is a Wednesday; Feb 1 a Sunday.
+	if got := analytics.WeekStart(noon(2026, 1, 28)); !got.Equal(date(2026, 1, 26)) {
+		t.Errorf("WeekStart(Wed) = %s, want 2026-01-26", got.Format("2006-01-02"))
+	}
+	if got := analytics.WeekStart(noon(2026, 2, 1)); !got.Equal(date(2026, 1, 26)) {
+		t.Errorf("WeekStart(Sun) = %s, want 2026-01-26", got.Format("2006-01-02"))
+	}
+}
+
+func TestWeeklyCounts(t *testing.T) {
+	now := noon(2026, 1, 28)
+	events := []analytics.OrderEvent{
+		{CreatedAt: noon(2026, 1, 19), PreparedAt: ptr(noon(2026, 1, 20)), FulfilledAt: ptr(noon(2026, 1, 27))},
+		{CreatedAt: noon(2026, 1, 26)},
+		{CreatedAt: noon(2025, 6, 1), FulfilledAt: ptr(noon(2026, 1, 26))}, // created before the period
+	}
+
+	weeks := analytics.WeeklyCounts(events, now, 2)
+
+	if len(weeks) != 2 || !weeks[0].Start.Equal(date(2026, 1, 19)) || !weeks[1].Start.Equal(date(2026, 1, 26)) {
+		t.Fatalf("weeks = %+v, want the weeks of Jan 19 and Jan 26", weeks)
+	}
+	want := []analytics.WeekCount{
+		{Start: date(2026, 1, 19), Created: 1, Prepared: 1},
+		{Start: date(2026, 1, 26), Created: 1, Fulfilled: 2},
+	}
+	for i := range want {
+		if weeks[i] != want[i] {
+			t.Errorf("week %d = %+v, want %+v", i, weeks[i], want[i])
+		}
+	}
+}
+
+func TestMedianFulfillment(t *testing.T) {
+	since := noon(2026, 1, 1)
+	created := noon(2026, 1, 10)
+	events := []analytics.OrderEvent{
+		{CreatedAt: created, FulfilledAt: ptr(created.Add(24 * time.Hour))},
+		{CreatedAt: created, FulfilledAt: ptr(created.Add(72 * time.Hour))},
+		{CreatedAt: created, FulfilledAt: ptr(created.Add(48 * time.Hour))},
+		{CreatedAt: created}, // still open
+		{CreatedAt: noon(2025, 12, 1), FulfilledAt: ptr(noon(2025, 12, 20))}, // before the period
+	}
+
+	median, n := analytics.MedianFulfillment(events, since)
+	if n != 3 || median != 48*time.Hour {
+		t.Errorf("median = %s over %d, want 48h over 3", median, n)
+	}
+
+	events = append(events, analytics.OrderEvent{CreatedAt: created, FulfilledAt: ptr(created.Add(96 * time.Hour))})
+	median, n = analytics.MedianFulfillment(events, since)
+	if n != 4 || median != 60*time.Hour {
+		t.Errorf("even median = %s over %d, want 60h over 4", median, n)
+	}
+
+	if median, n := analytics.MedianFulfillment(nil, since); median != 0 || n != 0 {
+		t.Errorf("empty median = %s over %d, want 0", median, n)
+	}
+}
+
+func TestRefillLate(t *testing.T) {
+	refills := []analytics.Refill{
+		{BoxEndDate: date(2026, 1, 31), RefilledAt: noon(2026, 1, 29)},
+		{BoxEndDate: date(2026, 1, 31), RefilledAt: noon(2026, 1, 31)}, // on the day: on time
+		{BoxEndDate: date(2026, 1, 31), RefilledAt: noon(2026, 2, 2)},
+	}
+	if got := analytics.CountLate(refills); got != 1 {
+		t.Errorf("CountLate = %d, want 1", got)
+	}
+
+	r := analytics.Report{Refills: 4, LateRefills: 1}
+	if got := r.LateRefillShare(); got != 0.25 {
+		t.Errorf("LateRefillShare = %v, want 0.25", got)
+	}
+	if got := (analytics.Report{}).LateRefillShare(); got != 0 {
+		t.Errorf("LateRefillShare without refills = %v, want 0", got)
+	}
+}
+
+func TestForecastRepeatsEveryBox(t *testing.T) {
+	// 10 units at 1/day from Jan 1 → depletes Jan 11, then every 10 days.
+	rxs := []analytics.Prescription{{UnitsPerBox: 10, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)}}
+
+	days := analytics.Forecast(rxs, calendar.Calendar{}, noon(2026, 1, 5), 30)
+
+	if len(days) != 30 || !days[0].Date.Equal(date(2026, 1, 5)) {
+		t.Fatalf("forecast starts %s with %d days, want Jan 5 and 30", days[0].Date.Format("2006-01-02"), len(days))
+	}
+	due := map[time.Time]bool{date(2026, 1, 11): true, date(2026, 1, 21): true, date(2026, 1, 31): true}
+	for _, d := range days {
+		want := 0
+		if due[d.Date] {
+			want = 1
+		}
+		if d.Orders != want {
+			t.Errorf("%s: %d orders, want %d", d.Date.Format("2006-01-02"), d.Orders, want)
+		}
+	}
+}
+
+func TestForecastSkipsBoxesAlreadyOver(t *testing.T) {
+	// Depleted Jan 11, never refilled: the next boxes would end Jan 21, Jan 31...
+	rxs := []analytics.Prescription{{UnitsPerBox: 10, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)}}
+
+	days := analytics.Forecast(rxs, calendar.Calendar{}, noon(2026, 1, 25), 10)
+
+	r := analytics.Report{Forecast: days}
+	if r.ForecastTotal() != 1 || days[6].Orders != 1 {
+		t.Errorf("forecast = %+v, want one order on Jan 31", days)
+	}
+}
+
+func TestForecastUsesPrepareByAndStockReports(t *testing.T) {
+	cal := calendar.Calendar{Closures: []calendar.Closure{{From: date(2026, 2, 10), To: date(2026, 2, 20)}}}
+	rxs := []analytics.Prescription{
+		// Would deplete Jan 31 but 20 units were left on Jan 25 → Feb 14, in the closure → Feb 9.
+		{UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1), ReportedUnits: 20, ReportedOn: date(2026, 1, 25)},
+	}
+
+	days := analytics.Forecast(rxs, cal, noon(2026, 1, 27), 30)
+
+	for _, d := range days {
+		want := 0
+		if d.Date.Equal(date(2026, 2, 9)) {
+			want = 1
+		}
+		if d.Orders != want {
+			t.Errorf("%s: %d orders, want %d", d.Date.Format("2006-01-02"), d.Orders, want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/giorgiovilardo/pharmarecall/internal/dbutil"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Ensure PgxRepository satisfies Repository at compile time.
+var _ Repository = (*PgxRepository)(nil)
+
+// PgxRepository implements all analytics port interfaces using pgx/sqlc.
+type PgxRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewPgxRepository creates a new PgxRepository.
+func NewPgxRepository(pool *pgxpool.Pool, queries *db.Queries) *PgxRepository {
+	return &PgxRepository{pool: pool, queries: queries}
+}
+
+func (r *PgxRepository) ListOrderEvents(ctx context.Context, pharmacyID int64, since time.Time) ([]OrderEvent, error) {
+	rows, err := r.queries.ListOrderEventsSince(ctx, db.ListOrderEventsSinceParams{
+		PharmacyID: pharmacyID,
+		Since:      dbutil.TimeToTimestamptz(since),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing order events: %w", err)
+	}
+	result := make([]OrderEvent, len(rows))
+	for i, row := range rows {
+		result[i] = OrderEvent{
+			CreatedAt:   row.CreatedAt.Time,
+			PreparedAt:  timePtr(row.PreparedAt),
+			FulfilledAt: timePtr(row.FulfilledAt),
+		}
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) ListRefills(ctx context.Context, pharmacyID int64, since time.Time) ([]Refill, error) {
+	rows, err := r.queries.ListRefillsSince(ctx, db.ListRefillsSinceParams{
+		PharmacyID: pharmacyID,
+		Since:      dbutil.TimeToTimestamptz(since),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing refills: %w", err)
+	}
+	result := make([]Refill, len(rows))
+	for i, row := range rows {
+		result[i] = Refill{BoxEndDate: row.BoxEndDate.Time, RefilledAt: row.CreatedAt.Time}
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) ListTopMedications(ctx context.Context, pharmacyID int64, since time.Time, limit int) ([]MedicationCount, error) {
+	rows, err := r.queries.ListTopMedications(ctx, db.ListTopMedicationsParams{
+		PharmacyID: pharmacyID,
+		Since:      dbutil.TimeToTimestamptz(since),
+		MaxRows:    int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing top medications: %w", err)
+	}
+	result := make([]MedicationCount, len(rows))
+	for i, row := range rows {
+		result[i] = MedicationCount{Name: row.MedicationName, Orders: int(row.Orders)}
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) ListPrescriptions(ctx context.Context, pharmacyID int64) ([]Prescription, error) {
+	rows, err := r.queries.ListPrescriptionsInLookahead(ctx, pharmacyID)
+	if err != nil {
+		return nil, fmt.Errorf("listing prescriptions: %w", err)
+	}
+	result := make([]Prescription, len(rows))
+	for i, row := range rows {
+		result[i] = Prescription{
+			UnitsPerBox:      int(row.UnitsPerBox),
+			DailyConsumption: dbutil.NumericToFloat64(row.DailyConsumption),
+			BoxStartDate:     row.BoxStartDate.Time,
+			ReportedUnits:    int(row.ReportedUnits),
+			ReportedOn:       row.ReportedOn.Time,
+		}
+	}
+	return result, nil
+}
+
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
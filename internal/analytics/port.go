@@ -0,0 +1,41 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+)
+
+// OrderEventLister lists the events of a pharmacy's orders touched since a time.
+type OrderEventLister interface {
+	ListOrderEvents(ctx context.Context, pharmacyID int64, since time.Time) ([]OrderEvent, error)
+}
+
+// RefillLister lists a pharmacy's refills recorded since a time.
+type RefillLister interface {
+	ListRefills(ctx context.Context, pharmacyID int64, since time.Time) ([]Refill, error)
+}
+
+// TopMedicationLister lists the medications with most orders created since a time.
+type TopMedicationLister interface {
+	ListTopMedications(ctx context.Context, pharmacyID int64, since time.Time, limit int) ([]MedicationCount, error)
+}
+
+// PrescriptionLister lists the prescriptions of consenting patients of a pharmacy.
+type PrescriptionLister interface {
+	ListPrescriptions(ctx context.Context, pharmacyID int64) ([]Prescription, error)
+}
+
+// CalendarGetter returns a pharmacy's closure calendar.
+type CalendarGetter interface {
+	Calendar(ctx context.Context, pharmacyID int64) (calendar.Calendar, error)
+}
+
+// Repository composes all ports — used only by NewService for convenient wiring.
+type Repository interface {
+	OrderEventLister
+	RefillLister
+	TopMedicationLister
+	PrescriptionLister
+}
@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+)
+
+// ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
+type ServiceDeps struct {
+	Events        OrderEventLister
+	Refills       RefillLister
+	Medications   TopMedicationLister
+	Prescriptions PrescriptionLister
+	Calendar      CalendarGetter
+}
+
+// Service contains analytics business logic.
+type Service struct {
+	deps ServiceDeps
+}
+
+// NewService is the production constructor — takes a Repository (satisfies all ports)
+// and the pharmacy closure calendar used by the forecast.
+func NewService(repo Repository, cal CalendarGetter) *Service {
+	return &Service{deps: ServiceDeps{
+		Events:        repo,
+		Refills:       repo,
+		Medications:   repo,
+		Prescriptions: repo,
+		Calendar:      cal,
+	}}
+}
+
+// NewServiceWith is the test constructor — inject only what you need, rest stays nil.
+func NewServiceWith(d ServiceDeps) *Service {
+	return &Service{deps: d}
+}
+
+// Report computes a pharmacy's figures over the last Weeks weeks up to now
+// and the forecast for the next ForecastDays days.
+func (s *Service) Report(ctx context.Context, pharmacyID int64, now time.Time) (Report, error) {
+	since := PeriodStart(now, Weeks)
+
+	events, err := s.deps.Events.ListOrderEvents(ctx, pharmacyID, since)
+	if err != nil {
+		return Report{}, fmt.Errorf("listing order events: %w", err)
+	}
+	refills, err := s.deps.Refills.ListRefills(ctx, pharmacyID, since)
+	if err != nil {
+		return Report{}, fmt.Errorf("listing refills: %w", err)
+	}
+	top, err := s.deps.Medications.ListTopMedications(ctx, pharmacyID, since, TopMedicationsLimit)
+	if err != nil {
+		return Report{}, fmt.Errorf("listing top medications: %w", err)
+	}
+	prescriptions, err := s.deps.Prescriptions.ListPrescriptions(ctx, pharmacyID)
+	if err != nil {
+		return Report{}, fmt.Errorf("listing prescriptions: %w", err)
+	}
+	cal := calendar.Calendar{}
+	if s.deps.Calendar != nil {
+		if cal, err = s.deps.Calendar.Calendar(ctx, pharmacyID); err != nil {
+			return Report{}, fmt.Errorf("getting closure calendar: %w", err)
+		}
+	}
+
+	median, fulfilled := MedianFulfillment(events, since)
+	return Report{
+		Since:             since,
+		Weeks:             WeeklyCounts(events, now, Weeks),
+		MedianFulfillment: median,
+		Fulfilled:         fulfilled,
+		Refills:           len(refills),
+		LateRefills:       CountLate(refills),
+		TopMedications:    top,
+		Forecast:          Forecast(prescriptions, cal, now, ForecastDays),
+	}, nil
+}
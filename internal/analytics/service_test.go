@@ -0,0 +1,112 @@
+package analytics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/analytics"
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+)
+
+// --- Mocks ---
+
+type mockEvents struct {
+	result []analytics.OrderEvent
+	since  time.Time
+	err    error
+}
+
+func (m *mockEvents) ListOrderEvents(_ context.Context, _ int64, since time.Time) ([]analytics.OrderEvent, error) {
+	m.since = since
+	return m.result, m.err
+}
+
+type mockRefills struct {
+	result []analytics.Refill
+}
+
+func (m *mockRefills) ListRefills(_ context.Context, _ int64, _ time.Time) ([]analytics.Refill, error) {
+	return m.result, nil
+}
+
+type mockMedications struct {
+	result []analytics.MedicationCount
+	limit  int
+}
+
+func (m *mockMedications) ListTopMedications(_ context.Context, _ int64, _ time.Time, limit int) ([]analytics.MedicationCount, error) {
+	m.limit = limit
+	return m.result, nil
+}
+
+type mockPrescriptions struct {
+	result []analytics.Prescription
+}
+
+func (m *mockPrescriptions) ListPrescriptions(_ context.Context, _ int64) ([]analytics.Prescription, error) {
+	return m.result, nil
+}
+
+type mockCalendar struct {
+	cal calendar.Calendar
+}
+
+func (m *mockCalendar) Calendar(_ context.Context, _ int64) (calendar.Calendar, error) {
+	return m.cal, nil
+}
+
+// --- Report tests ---
+
+func TestReport(t *testing.T) {
+	now := noon(2026, 1, 28)
+	created := noon(2026, 1, 20)
+	events := &mockEvents{result: []analytics.OrderEvent{
+		{CreatedAt: created, PreparedAt: ptr(created.Add(time.Hour)), FulfilledAt: ptr(created.Add(30 * time.Hour))},
+	}}
+	medications := &mockMedications{result: []analytics.MedicationCount{{Name: "Tachipirina", Orders: 4}}}
+	svc := analytics.NewServiceWith(analytics.ServiceDeps{
+		Events: events,
+		Refills: &mockRefills{result: []analytics.Refill{
+			{BoxEndDate: date(2026, 1, 10), RefilledAt: noon(2026, 1, 12)},
+			{BoxEndDate: date(2026, 1, 10), RefilledAt: noon(2026, 1, 9)},
+		}},
+		Medications:   medications,
+		Prescriptions: &mockPrescriptions{result: []analytics.Prescription{{UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)}}},
+		Calendar:      &mockCalendar{},
+	})
+
+	r, err := svc.Report(context.Background(), 7, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantSince := date(2026, 1, 26).AddDate(0, 0, -7*(analytics.Weeks-1))
+	if !events.since.Equal(wantSince) || !r.Since.Equal(wantSince) {
+		t.Errorf("since = %s, want %s", events.since.Format("2006-01-02"), wantSince.Format("2006-01-02"))
+	}
+	if len(r.Weeks) != analytics.Weeks || r.Weeks[analytics.Weeks-2].Created != 1 || r.Weeks[analytics.Weeks-1].Fulfilled != 0 {
+		t.Errorf("weeks = %+v", r.Weeks)
+	}
+	if r.MedianFulfillment != 30*time.Hour || r.Fulfilled != 1 {
+		t.Errorf("median = %s over %d, want 30h over 1", r.MedianFulfillment, r.Fulfilled)
+	}
+	if r.Refills != 2 || r.LateRefills != 1 {
+		t.Errorf("refills = %d late of %d, want 1 of 2", r.LateRefills, r.Refills)
+	}
+	if medications.limit != analytics.TopMedicationsLimit || len(r.TopMedications) != 1 {
+		t.Errorf("top medications = %+v with limit %d", r.TopMedications, medications.limit)
+	}
+	if len(r.Forecast) != analytics.ForecastDays || r.ForecastTotal() != 1 {
+		t.Errorf("forecast total = %d over %d days, want 1 over %d", r.ForecastTotal(), len(r.Forecast), analytics.ForecastDays)
+	}
+}
+
+func TestReportErrorPropagates(t *testing.T) {
+	svc := analytics.NewServiceWith(analytics.ServiceDeps{Events: &mockEvents{err: errors.New("db down")}})
+
+	if _, err := svc.Report(context.Background(), 7, noon(2026, 1, 28)); err == nil {
+		t.Fatal("expected error")
+	}
+}
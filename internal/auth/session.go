@@ -9,10 +9,34 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewSessionManager(pool *pgxpool.Pool) *scs.SessionManager {
+// NewSessionManager returns the session manager for staff. Sessions last at
+// most a day, and end earlier after idleTimeout without requests unless
+// idleTimeout is zero.
+func NewSessionManager(pool *pgxpool.Pool, idleTimeout time.Duration) *scs.SessionManager {
 	sm := scs.New()
 	sm.Store = pgxstore.New(pool)
 	sm.Lifetime = 24 * time.Hour
+	sm.IdleTimeout = idleTimeout
+	sm.Cookie.HttpOnly = true
+	sm.Cookie.Secure = true
+	sm.Cookie.SameSite = http.SameSiteLaxMode
+	return sm
+}
+
+// NewPatientSessionManager returns the session manager for the patient
+// portal. It keeps its own table and cookie, scoped to /portal, so a patient
+// session is never read as a staff session or vice versa. Sessions are
+// shorter-lived than staff ones and expire after half an hour of inactivity.
+func NewPatientSessionManager(pool *pgxpool.Pool) *scs.SessionManager {
+	sm := scs.New()
+	sm.Store = pgxstore.NewWithConfig(pool, pgxstore.Config{
+		TableName:       "patient_sessions",
+		CleanUpInterval: 5 * time.Minute,
+	})
+	sm.Lifetime = 12 * time.Hour
+	sm.IdleTimeout = 30 * time.Minute
+	sm.Cookie.Name = "pharmarecall_patient"
+	sm.Cookie.Path = "/portal"
 	sm.Cookie.HttpOnly = true
 	sm.Cookie.Secure = true
 	sm.Cookie.SameSite = http.SameSiteLaxMode
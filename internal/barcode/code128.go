@@ -0,0 +1,100 @@
+// Package barcode renders Code 128 barcodes and QR codes as inline SVG.
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnsupportedChar = errors.New("character not encodable in Code 128 set B")
+
+// patterns holds the bar/space module widths for each Code 128 symbol value (0-106).
+// Each pattern starts with a bar and alternates bar/space; all sum to 11 modules
+// except the stop pattern, which is 13.
+var patterns = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	startB = 104
+	stop   = 106
+)
+
+// quietZone is the number of white modules on each side of an SVG barcode.
+const quietZone = 10
+
+// Code128B returns the symbol values for data encoded in Code 128 set B,
+// including the start symbol, checksum and stop symbol.
+func Code128B(data string) ([]int, error) {
+	values := make([]int, 0, len(data)+3)
+	values = append(values, startB)
+	checksum := startB
+	for i, c := range []byte(data) {
+		if c < 32 || c > 127 {
+			return nil, ErrUnsupportedChar
+		}
+		v := int(c) - 32
+		values = append(values, v)
+		checksum += v * (i + 1)
+	}
+	values = append(values, checksum%103, stop)
+	return values, nil
+}
+
+// Code128Modules returns data encoded in Code 128 set B as a sequence of
+// modules, true for black. Quiet zones are not included.
+func Code128Modules(data string) ([]bool, error) {
+	values, err := Code128B(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []bool
+	for _, v := range values {
+		for i, w := range patterns[v] {
+			for range int(w - '0') {
+				modules = append(modules, i%2 == 0)
+			}
+		}
+	}
+	return modules, nil
+}
+
+// Code128SVG renders data as a Code 128 barcode SVG. moduleWidth and height are
+// in SVG user units; the human-readable text is not included.
+func Code128SVG(data string, moduleWidth, height int) (string, error) {
+	modules, err := Code128Modules(data)
+	if err != nil {
+		return "", err
+	}
+
+	var bars strings.Builder
+	offset := quietZone * moduleWidth
+	for start := 0; start < len(modules); {
+		end := start
+		for end < len(modules) && modules[end] == modules[start] {
+			end++
+		}
+		if modules[start] {
+			fmt.Fprintf(&bars, `<rect x="%d" y="0" width="%d" height="%d"/>`, offset+start*moduleWidth, (end-start)*moduleWidth, height)
+		}
+		start = end
+	}
+	total := (len(modules) + 2*quietZone) * moduleWidth
+
+	return fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges"><rect width="100%%" height="100%%" fill="#fff"/><g fill="#000">%s</g></svg>`,
+		total, height, total, height, bars.String(),
+	), nil
+}
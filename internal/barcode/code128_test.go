@@ -0,0 +1,70 @@
+package barcode_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/barcode"
+)
+
+func TestCode128BValues(t *testing.T) {
+	// Start B (104), P J J 1 2 3 C, checksum (879 % 103 = 55), stop (106).
+	got, err := barcode.Code128B("PJJ123C")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []int{104, 48, 42, 42, 17, 18, 19, 35, 55, 106}
+	if !slices.Equal(got, want) {
+		t.Errorf("Code128B() = %v, want %v", got, want)
+	}
+}
+
+func TestCode128BRejectsNonASCII(t *testing.T) {
+	_, err := barcode.Code128B("città")
+	if !errors.Is(err, barcode.ErrUnsupportedChar) {
+		t.Errorf("err = %v, want ErrUnsupportedChar", err)
+	}
+}
+
+func TestCode128SVG(t *testing.T) {
+	svg, err := barcode.Code128SVG("PR-1-ABC", 2, 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") {
+		t.Errorf("output is not an svg element: %q", svg)
+	}
+	// Every symbol has 3 bars except stop (4): start + 8 chars + checksum = 10 symbols.
+	if got := strings.Count(svg, `<rect x=`); got != 10*3+4 {
+		t.Errorf("bar count = %d, want %d", got, 10*3+4)
+	}
+}
+
+func TestCode128Modules(t *testing.T) {
+	modules, err := barcode.Code128Modules("AB")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// start + 2 chars + checksum = 4×11 modules, stop = 13.
+	if len(modules) != 4*11+13 {
+		t.Errorf("len = %d, want %d", len(modules), 4*11+13)
+	}
+	if !modules[0] || !modules[len(modules)-1] {
+		t.Error("barcode must start and end with a bar")
+	}
+}
+
+func TestQRSVG(t *testing.T) {
+	svg, err := barcode.QRSVG("otpauth://totp/PharmaRecall:anna%40example.com?secret=JBSWY3DPEHPK3PXP&issuer=PharmaRecall", 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") {
+		t.Errorf("output is not an svg element: %q", svg)
+	}
+	if !strings.Contains(svg, `<rect x="`) {
+		t.Error("expected dark modules")
+	}
+}
@@ -0,0 +1,43 @@
+package barcode
+
+import (
+	"fmt"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// qrQuietZone is the number of white modules around an SVG QR code.
+const qrQuietZone = 4
+
+// QRSVG renders data as a QR code SVG at error correction level M. Each
+// module is moduleSize SVG user units wide.
+func QRSVG(data string, moduleSize int) (string, error) {
+	code, err := qr.Encode(data, qr.M)
+	if err != nil {
+		return "", fmt.Errorf("encoding qr code: %w", err)
+	}
+
+	var cells strings.Builder
+	offset := qrQuietZone * moduleSize
+	for y := range code.Size {
+		for x := 0; x < code.Size; {
+			if !code.Black(x, y) {
+				x++
+				continue
+			}
+			end := x
+			for end < code.Size && code.Black(end, y) {
+				end++
+			}
+			fmt.Fprintf(&cells, `<rect x="%d" y="%d" width="%d" height="%d"/>`, offset+x*moduleSize, offset+y*moduleSize, (end-x)*moduleSize, moduleSize)
+			x = end
+		}
+	}
+	total := (code.Size + 2*qrQuietZone) * moduleSize
+
+	return fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges"><rect width="100%%" height="100%%" fill="#fff"/><g fill="#000">%s</g></svg>`,
+		total, total, total, total, cells.String(),
+	), nil
+}
@@ -0,0 +1,157 @@
+// Package calendar holds each pharmacy's closure calendar — weekly closing
+// days, Italian national holidays, the local patron-saint day and ad-hoc
+// closures such as summer holidays — and derives from it the date by which
+// an order must be prepared.
+package calendar
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrNotFound           = errors.New("calendar.not_found")
+	ErrInvalidPatronDate  = errors.New("calendar.invalid_patron_date")
+	ErrPatronNameTooLong  = errors.New("calendar.patron_name_too_long")
+	ErrInvalidClosure     = errors.New("calendar.invalid_closure")
+	ErrClosureTooLong     = errors.New("calendar.closure_too_long")
+	ErrReasonTooLong      = errors.New("calendar.reason_too_long")
+	ErrAlwaysClosed       = errors.New("calendar.always_closed")
+	ErrInvalidWeekday     = errors.New("calendar.invalid_weekday")
+	ErrClosureDateMissing = errors.New("calendar.closure_date_missing")
+)
+
+// Limits on free-text fields and closure length.
+const (
+	MaxPatronNameLength = 100
+	MaxReasonLength     = 200
+	MaxClosureDays      = 366
+)
+
+// WeeklyClosureReason is the ClosedReason of a weekly closing day.
+const WeeklyClosureReason = "Chiusura settimanale"
+
+// PatronSaint is the local patron-saint holiday, recurring every year.
+// A zero Month means none is configured.
+type PatronSaint struct {
+	Name  string
+	Month time.Month
+	Day   int
+}
+
+// IsZero reports whether no patron-saint day is configured.
+func (p PatronSaint) IsZero() bool {
+	return p.Month == 0
+}
+
+// Closure is an ad-hoc closing period, both ends inclusive.
+type Closure struct {
+	ID     int64
+	From   time.Time
+	To     time.Time
+	Reason string
+}
+
+// Settings are the recurring rules of a pharmacy's calendar.
+type Settings struct {
+	ClosedWeekdays   []time.Weekday
+	NationalHolidays bool
+	Patron           PatronSaint
+}
+
+// DefaultSettings returns the rules used until an owner saves their own:
+// closed on Sundays and on national holidays.
+func DefaultSettings() Settings {
+	return Settings{ClosedWeekdays: []time.Weekday{time.Sunday}, NationalHolidays: true}
+}
+
+// Calendar is a pharmacy's full closure calendar. The zero Calendar is
+// never closed, so PrepareBy returns the depletion date unchanged.
+type Calendar struct {
+	Settings
+	Closures []Closure
+}
+
+// ClosedDay is a day the pharmacy is closed and why.
+type ClosedDay struct {
+	Date   time.Time
+	Reason string
+}
+
+// dayOf truncates t to its calendar date in UTC, the representation used for dates.
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ClosedReason returns why the pharmacy is closed on d, or "" when it is open.
+// Ad-hoc closures take precedence over holidays, which take precedence over
+// weekly closing days.
+func (c Calendar) ClosedReason(d time.Time) string {
+	d = dayOf(d)
+	for _, cl := range c.Closures {
+		if !d.Before(dayOf(cl.From)) && !d.After(dayOf(cl.To)) {
+			if cl.Reason == "" {
+				return "Chiusura"
+			}
+			return cl.Reason
+		}
+	}
+	if !c.Patron.IsZero() && d.Month() == c.Patron.Month && d.Day() == c.Patron.Day {
+		if c.Patron.Name == "" {
+			return "Santo patrono"
+		}
+		return c.Patron.Name
+	}
+	if c.NationalHolidays {
+		if name := NationalHoliday(d); name != "" {
+			return name
+		}
+	}
+	if slices.Contains(c.ClosedWeekdays, d.Weekday()) {
+		return WeeklyClosureReason
+	}
+	return ""
+}
+
+// IsClosed reports whether the pharmacy is closed on d.
+func (c Calendar) IsClosed(d time.Time) bool {
+	return c.ClosedReason(d) != ""
+}
+
+// PrepareBy returns the last day the pharmacy is open on or before depletion:
+// an order running out on a closed day must be ready before the closure.
+// If no open day is found within MaxClosureDays the depletion date is returned.
+func (c Calendar) PrepareBy(depletion time.Time) time.Time {
+	d := dayOf(depletion)
+	for range MaxClosureDays + 7 {
+		if !c.IsClosed(d) {
+			return d
+		}
+		d = d.AddDate(0, 0, -1)
+	}
+	return dayOf(depletion)
+}
+
+// ClosedDays lists the closed days in [from, to], both inclusive.
+func (c Calendar) ClosedDays(from, to time.Time) []ClosedDay {
+	var days []ClosedDay
+	for d := dayOf(from); !d.After(dayOf(to)); d = d.AddDate(0, 0, 1) {
+		if reason := c.ClosedReason(d); reason != "" {
+			days = append(days, ClosedDay{Date: d, Reason: reason})
+		}
+	}
+	return days
+}
+
+// SpecialClosures lists the closed days in [from, to] other than plain
+// weekly closing days: holidays, the patron-saint day and ad-hoc closures.
+func (c Calendar) SpecialClosures(from, to time.Time) []ClosedDay {
+	var days []ClosedDay
+	for _, d := range c.ClosedDays(from, to) {
+		if d.Reason != WeeklyClosureReason {
+			days = append(days, d)
+		}
+	}
+	return days
+}
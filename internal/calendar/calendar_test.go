@@ -0,0 +1,141 @@
+package calendar_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestEaster(t *testing.T) {
+	tests := []struct {
+		year int
+		want time.Time
+	}{
+		{2024, date(2024, 3, 31)},
+		{2025, date(2025, 4, 20)},
+		{2026, date(2026, 4, 5)},
+		{2027, date(2027, 3, 28)},
+		{2038, date(2038, 4, 25)},
+	}
+	for _, tt := range tests {
+		if got := calendar.Easter(tt.year); !got.Equal(tt.want) {
+			t.Errorf("Easter(%d) = %s, want %s", tt.year, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestNationalHoliday(t *testing.T) {
+	tests := []struct {
+		day  time.Time
+		want string
+	}{
+		{date(2026, 1, 1), "Capodanno"},
+		{date(2026, 4, 6), "Lunedì dell'Angelo"},
+		{date(2026, 4, 25), "Festa della Liberazione"},
+		{date(2026, 8, 15), "Ferragosto"},
+		{date(2026, 12, 26), "Santo Stefano"},
+		{date(2026, 10, 4), "San Francesco d'Assisi"},
+		{date(2025, 10, 4), ""},
+		{date(2026, 3, 19), ""},
+	}
+	for _, tt := range tests {
+		if got := calendar.NationalHoliday(tt.day); got != tt.want {
+			t.Errorf("NationalHoliday(%s) = %q, want %q", tt.day.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func milan() calendar.Calendar {
+	return calendar.Calendar{
+		Settings: calendar.Settings{
+			ClosedWeekdays:   []time.Weekday{time.Sunday},
+			NationalHolidays: true,
+			Patron:           calendar.PatronSaint{Name: "Sant'Ambrogio", Month: time.December, Day: 7},
+		},
+		Closures: []calendar.Closure{
+			{From: date(2026, 8, 10), To: date(2026, 8, 23), Reason: "Ferie estive"},
+		},
+	}
+}
+
+func TestClosedReason(t *testing.T) {
+	cal := milan()
+	tests := []struct {
+		day  time.Time
+		want string
+	}{
+		{date(2026, 1, 27), ""},
+		{date(2026, 2, 1), calendar.WeeklyClosureReason},
+		{date(2026, 12, 7), "Sant'Ambrogio"},
+		{date(2026, 12, 8), "Immacolata Concezione"},
+		{date(2026, 8, 15), "Ferie estive"}, // closure takes precedence over Ferragosto
+		{date(2026, 8, 23), "Ferie estive"}, // end is inclusive
+		{date(2026, 8, 24), ""},
+	}
+	for _, tt := range tests {
+		if got := cal.ClosedReason(tt.day); got != tt.want {
+			t.Errorf("ClosedReason(%s) = %q, want %q", tt.day.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestClosedReasonWithoutNationalHolidays(t *testing.T) {
+	cal := milan()
+	cal.NationalHolidays = false
+	if got := cal.ClosedReason(date(2026, 6, 2)); got != "" {
+		t.Errorf("ClosedReason(2 June) = %q, want open", got)
+	}
+}
+
+func TestPrepareBy(t *testing.T) {
+	cal := milan()
+	tests := []struct {
+		name      string
+		depletion time.Time
+		want      time.Time
+	}{
+		{"open day is unchanged", date(2026, 1, 27), date(2026, 1, 27)},
+		{"sunday moves to saturday", date(2026, 2, 1), date(2026, 1, 31)},
+		{"summer closure", date(2026, 8, 20), date(2026, 8, 8)},
+		{"christmas to sunday", date(2026, 12, 27), date(2026, 12, 24)},
+		{"patron saint and immacolata", date(2026, 12, 8), date(2026, 12, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.PrepareBy(tt.depletion); !got.Equal(tt.want) {
+				t.Errorf("PrepareBy(%s) = %s, want %s", tt.depletion.Format("2006-01-02"), got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestPrepareByZeroCalendar(t *testing.T) {
+	var cal calendar.Calendar
+	d := date(2026, 8, 15)
+	if got := cal.PrepareBy(d); !got.Equal(d) {
+		t.Errorf("PrepareBy = %s, want depletion date unchanged", got.Format("2006-01-02"))
+	}
+}
+
+func TestSpecialClosuresSkipsWeeklyClosures(t *testing.T) {
+	cal := milan()
+	days := cal.SpecialClosures(date(2026, 12, 1), date(2026, 12, 31))
+	var reasons []string
+	for _, d := range days {
+		reasons = append(reasons, d.Reason)
+	}
+	want := []string{"Sant'Ambrogio", "Immacolata Concezione", "Natale", "Santo Stefano"}
+	if len(reasons) != len(want) {
+		t.Fatalf("special closures = %v, want %v", reasons, want)
+	}
+	for i := range want {
+		if reasons[i] != want[i] {
+			t.Errorf("closure %d = %q, want %q", i, reasons[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package calendar
+
+import "time"
+
+// fixedHolidays are the Italian national holidays on a fixed date.
+var fixedHolidays = []struct {
+	month time.Month
+	day   int
+	name  string
+	since int // first year the holiday applies, 0 for always
+}{
+	{time.January, 1, "Capodanno", 0},
+	{time.January, 6, "Epifania", 0},
+	{time.April, 25, "Festa della Liberazione", 0},
+	{time.May, 1, "Festa del Lavoro", 0},
+	{time.June, 2, "Festa della Repubblica", 0},
+	{time.August, 15, "Ferragosto", 0},
+	{time.October, 4, "San Francesco d'Assisi", 2026},
+	{time.November, 1, "Ognissanti", 0},
+	{time.December, 8, "Immacolata Concezione", 0},
+	{time.December, 25, "Natale", 0},
+	{time.December, 26, "Santo Stefano", 0},
+}
+
+// NationalHoliday returns the name of the Italian national holiday on d, or "".
+func NationalHoliday(d time.Time) string {
+	d = dayOf(d)
+	for _, h := range fixedHolidays {
+		if d.Month() == h.month && d.Day() == h.day && d.Year() >= h.since {
+			return h.name
+		}
+	}
+	easter := Easter(d.Year())
+	switch {
+	case d.Equal(easter):
+		return "Pasqua"
+	case d.Equal(easter.AddDate(0, 0, 1)):
+		return "Lunedì dell'Angelo"
+	}
+	return ""
+}
+
+// Easter returns the date of Easter Sunday in the Gregorian calendar
+// (anonymous Gregorian algorithm).
+func Easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,116 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/giorgiovilardo/pharmarecall/internal/dbutil"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Ensure PgxRepository satisfies Repository at compile time.
+var _ Repository = (*PgxRepository)(nil)
+
+// PgxRepository implements all calendar port interfaces using pgx/sqlc.
+type PgxRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewPgxRepository creates a new PgxRepository.
+func NewPgxRepository(pool *pgxpool.Pool, queries *db.Queries) *PgxRepository {
+	return &PgxRepository{pool: pool, queries: queries}
+}
+
+func (r *PgxRepository) GetSettings(ctx context.Context, pharmacyID int64) (Settings, error) {
+	row, err := r.queries.GetPharmacyCalendar(ctx, pharmacyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return DefaultSettings(), nil
+		}
+		return Settings{}, fmt.Errorf("querying pharmacy calendar: %w", err)
+	}
+	s := Settings{
+		NationalHolidays: row.NationalHolidays,
+		Patron:           PatronSaint{Name: row.PatronSaintName},
+	}
+	for _, wd := range row.ClosedWeekdays {
+		s.ClosedWeekdays = append(s.ClosedWeekdays, time.Weekday(wd))
+	}
+	if row.PatronSaintMonth.Valid && row.PatronSaintDay.Valid {
+		s.Patron.Month = time.Month(row.PatronSaintMonth.Int16)
+		s.Patron.Day = int(row.PatronSaintDay.Int16)
+	}
+	return s, nil
+}
+
+func (r *PgxRepository) SaveSettings(ctx context.Context, pharmacyID int64, s Settings) error {
+	weekdays := make([]int16, len(s.ClosedWeekdays))
+	for i, wd := range s.ClosedWeekdays {
+		weekdays[i] = int16(wd)
+	}
+	params := db.UpsertPharmacyCalendarParams{
+		PharmacyID:       pharmacyID,
+		ClosedWeekdays:   weekdays,
+		NationalHolidays: s.NationalHolidays,
+		PatronSaintName:  s.Patron.Name,
+	}
+	if !s.Patron.IsZero() {
+		params.PatronSaintMonth = pgtype.Int2{Int16: int16(s.Patron.Month), Valid: true}
+		params.PatronSaintDay = pgtype.Int2{Int16: int16(s.Patron.Day), Valid: true}
+	}
+	if err := r.queries.UpsertPharmacyCalendar(ctx, params); err != nil {
+		return fmt.Errorf("saving pharmacy calendar: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) ListClosures(ctx context.Context, pharmacyID int64) ([]Closure, error) {
+	rows, err := r.queries.ListPharmacyClosures(ctx, pharmacyID)
+	if err != nil {
+		return nil, fmt.Errorf("listing pharmacy closures: %w", err)
+	}
+	result := make([]Closure, len(rows))
+	for i, row := range rows {
+		result[i] = Closure{
+			ID:     row.ID,
+			From:   row.StartDate.Time,
+			To:     row.EndDate.Time,
+			Reason: row.Reason,
+		}
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) CreateClosure(ctx context.Context, pharmacyID int64, c Closure) (Closure, error) {
+	id, err := r.queries.CreatePharmacyClosure(ctx, db.CreatePharmacyClosureParams{
+		PharmacyID: pharmacyID,
+		StartDate:  dbutil.TimeToDate(c.From),
+		EndDate:    dbutil.TimeToDate(c.To),
+		Reason:     c.Reason,
+	})
+	if err != nil {
+		return Closure{}, fmt.Errorf("creating pharmacy closure: %w", err)
+	}
+	c.ID = id
+	return c, nil
+}
+
+func (r *PgxRepository) DeleteClosure(ctx context.Context, pharmacyID, id int64) error {
+	n, err := r.queries.DeletePharmacyClosure(ctx, db.DeletePharmacyClosureParams{
+		ID:         id,
+		PharmacyID: pharmacyID,
+	})
+	if err != nil {
+		return fmt.Errorf("deleting pharmacy closure: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package calendar
+
+import "context"
+
+// SettingsGetter loads a pharmacy's calendar rules, falling back to
+// DefaultSettings when none were saved.
+type SettingsGetter interface {
+	GetSettings(ctx context.Context, pharmacyID int64) (Settings, error)
+}
+
+// SettingsSaver saves a pharmacy's calendar rules.
+type SettingsSaver interface {
+	SaveSettings(ctx context.Context, pharmacyID int64, s Settings) error
+}
+
+// ClosureLister lists a pharmacy's ad-hoc closures.
+type ClosureLister interface {
+	ListClosures(ctx context.Context, pharmacyID int64) ([]Closure, error)
+}
+
+// ClosureCreator stores a new ad-hoc closure.
+type ClosureCreator interface {
+	CreateClosure(ctx context.Context, pharmacyID int64, c Closure) (Closure, error)
+}
+
+// ClosureDeleter removes an ad-hoc closure of the pharmacy.
+type ClosureDeleter interface {
+	DeleteClosure(ctx context.Context, pharmacyID, id int64) error
+}
+
+// Repository composes all ports — used only by NewService for convenient wiring.
+type Repository interface {
+	SettingsGetter
+	SettingsSaver
+	ClosureLister
+	ClosureCreator
+	ClosureDeleter
+}
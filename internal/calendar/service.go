@@ -0,0 +1,126 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
+type ServiceDeps struct {
+	Getter  SettingsGetter
+	Saver   SettingsSaver
+	Lister  ClosureLister
+	Creator ClosureCreator
+	Deleter ClosureDeleter
+}
+
+// Service contains closure calendar business logic.
+type Service struct {
+	deps ServiceDeps
+}
+
+// NewService is the production constructor — takes a Repository (satisfies all ports).
+func NewService(repo Repository) *Service {
+	return &Service{deps: ServiceDeps{
+		Getter:  repo,
+		Saver:   repo,
+		Lister:  repo,
+		Creator: repo,
+		Deleter: repo,
+	}}
+}
+
+// NewServiceWith is the test constructor — inject only what you need, rest stays nil.
+func NewServiceWith(d ServiceDeps) *Service {
+	return &Service{deps: d}
+}
+
+// Calendar returns the pharmacy's full closure calendar.
+func (s *Service) Calendar(ctx context.Context, pharmacyID int64) (Calendar, error) {
+	settings, err := s.deps.Getter.GetSettings(ctx, pharmacyID)
+	if err != nil {
+		return Calendar{}, fmt.Errorf("getting calendar settings: %w", err)
+	}
+	closures, err := s.deps.Lister.ListClosures(ctx, pharmacyID)
+	if err != nil {
+		return Calendar{}, fmt.Errorf("listing closures: %w", err)
+	}
+	return Calendar{Settings: settings, Closures: closures}, nil
+}
+
+// SaveSettings validates and saves the pharmacy's recurring closure rules.
+func (s *Service) SaveSettings(ctx context.Context, pharmacyID int64, settings Settings) error {
+	settings.Patron.Name = strings.TrimSpace(settings.Patron.Name)
+	if err := validateSettings(settings); err != nil {
+		return err
+	}
+	slices.Sort(settings.ClosedWeekdays)
+	settings.ClosedWeekdays = slices.Compact(settings.ClosedWeekdays)
+	if err := s.deps.Saver.SaveSettings(ctx, pharmacyID, settings); err != nil {
+		return fmt.Errorf("saving calendar settings: %w", err)
+	}
+	return nil
+}
+
+func validateSettings(s Settings) error {
+	seen := map[time.Weekday]bool{}
+	for _, wd := range s.ClosedWeekdays {
+		if wd < time.Sunday || wd > time.Saturday {
+			return ErrInvalidWeekday
+		}
+		seen[wd] = true
+	}
+	if len(seen) == 7 {
+		return ErrAlwaysClosed
+	}
+	if utf8.RuneCountInString(s.Patron.Name) > MaxPatronNameLength {
+		return ErrPatronNameTooLong
+	}
+	if s.Patron.IsZero() {
+		if s.Patron.Day != 0 || s.Patron.Name != "" {
+			return ErrInvalidPatronDate
+		}
+		return nil
+	}
+	// 2024 is a leap year, so 29 February is accepted.
+	if s.Patron.Month < time.January || s.Patron.Month > time.December || s.Patron.Day < 1 ||
+		time.Date(2024, s.Patron.Month, s.Patron.Day, 0, 0, 0, 0, time.UTC).Month() != s.Patron.Month {
+		return ErrInvalidPatronDate
+	}
+	return nil
+}
+
+// AddClosure validates and stores an ad-hoc closure.
+func (s *Service) AddClosure(ctx context.Context, pharmacyID int64, c Closure) (Closure, error) {
+	c.Reason = strings.TrimSpace(c.Reason)
+	if c.From.IsZero() || c.To.IsZero() {
+		return Closure{}, ErrClosureDateMissing
+	}
+	c.From, c.To = dayOf(c.From), dayOf(c.To)
+	if c.To.Before(c.From) {
+		return Closure{}, ErrInvalidClosure
+	}
+	if c.To.Sub(c.From) >= MaxClosureDays*24*time.Hour {
+		return Closure{}, ErrClosureTooLong
+	}
+	if utf8.RuneCountInString(c.Reason) > MaxReasonLength {
+		return Closure{}, ErrReasonTooLong
+	}
+	created, err := s.deps.Creator.CreateClosure(ctx, pharmacyID, c)
+	if err != nil {
+		return Closure{}, fmt.Errorf("creating closure: %w", err)
+	}
+	return created, nil
+}
+
+// DeleteClosure removes an ad-hoc closure of the pharmacy.
+func (s *Service) DeleteClosure(ctx context.Context, pharmacyID, id int64) error {
+	if err := s.deps.Deleter.DeleteClosure(ctx, pharmacyID, id); err != nil {
+		return fmt.Errorf("deleting closure: %w", err)
+	}
+	return nil
+}
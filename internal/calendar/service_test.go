@@ -0,0 +1,108 @@
+package calendar_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+)
+
+// --- Mocks ---
+
+type mockSettingsSaver struct {
+	saved *calendar.Settings
+}
+
+func (m *mockSettingsSaver) SaveSettings(_ context.Context, _ int64, s calendar.Settings) error {
+	m.saved = &s
+	return nil
+}
+
+type mockClosureCreator struct {
+	created *calendar.Closure
+}
+
+func (m *mockClosureCreator) CreateClosure(_ context.Context, _ int64, c calendar.Closure) (calendar.Closure, error) {
+	m.created = &c
+	c.ID = 1
+	return c, nil
+}
+
+// --- SaveSettings tests ---
+
+func TestSaveSettingsValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		s    calendar.Settings
+		want error
+	}{
+		{"defaults", calendar.DefaultSettings(), nil},
+		{"patron saint", calendar.Settings{Patron: calendar.PatronSaint{Name: "San Giovanni", Month: time.June, Day: 24}}, nil},
+		{"leap day", calendar.Settings{Patron: calendar.PatronSaint{Month: time.February, Day: 29}}, nil},
+		{"impossible date", calendar.Settings{Patron: calendar.PatronSaint{Month: time.April, Day: 31}}, calendar.ErrInvalidPatronDate},
+		{"day without month", calendar.Settings{Patron: calendar.PatronSaint{Day: 7}}, calendar.ErrInvalidPatronDate},
+		{"name without date", calendar.Settings{Patron: calendar.PatronSaint{Name: "Sant'Ambrogio"}}, calendar.ErrInvalidPatronDate},
+		{"long name", calendar.Settings{Patron: calendar.PatronSaint{Name: strings.Repeat("a", 101), Month: 1, Day: 1}}, calendar.ErrPatronNameTooLong},
+		{"bad weekday", calendar.Settings{ClosedWeekdays: []time.Weekday{7}}, calendar.ErrInvalidWeekday},
+		{"always closed", calendar.Settings{ClosedWeekdays: []time.Weekday{0, 1, 2, 3, 4, 5, 6}}, calendar.ErrAlwaysClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saver := &mockSettingsSaver{}
+			svc := calendar.NewServiceWith(calendar.ServiceDeps{Saver: saver})
+			err := svc.SaveSettings(context.Background(), 1, tt.s)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("SaveSettings() = %v, want %v", err, tt.want)
+			}
+			if (saver.saved != nil) != (tt.want == nil) {
+				t.Errorf("saved = %v, want saved only when valid", saver.saved != nil)
+			}
+		})
+	}
+}
+
+func TestSaveSettingsDeduplicatesWeekdays(t *testing.T) {
+	saver := &mockSettingsSaver{}
+	svc := calendar.NewServiceWith(calendar.ServiceDeps{Saver: saver})
+
+	err := svc.SaveSettings(context.Background(), 1, calendar.Settings{ClosedWeekdays: []time.Weekday{6, 0, 6}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := saver.saved.ClosedWeekdays
+	if len(got) != 2 || got[0] != time.Sunday || got[1] != time.Saturday {
+		t.Errorf("ClosedWeekdays = %v, want [Sunday Saturday]", got)
+	}
+}
+
+// --- AddClosure tests ---
+
+func TestAddClosureValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		c    calendar.Closure
+		want error
+	}{
+		{"single day", calendar.Closure{From: date(2026, 8, 14), To: date(2026, 8, 14)}, nil},
+		{"missing end", calendar.Closure{From: date(2026, 8, 14)}, calendar.ErrClosureDateMissing},
+		{"reversed", calendar.Closure{From: date(2026, 8, 14), To: date(2026, 8, 1)}, calendar.ErrInvalidClosure},
+		{"too long", calendar.Closure{From: date(2026, 1, 1), To: date(2027, 1, 2)}, calendar.ErrClosureTooLong},
+		{"long reason", calendar.Closure{From: date(2026, 8, 14), To: date(2026, 8, 14), Reason: strings.Repeat("a", 201)}, calendar.ErrReasonTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creator := &mockClosureCreator{}
+			svc := calendar.NewServiceWith(calendar.ServiceDeps{Creator: creator})
+			_, err := svc.AddClosure(context.Background(), 1, tt.c)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("AddClosure() = %v, want %v", err, tt.want)
+			}
+			if (creator.created != nil) != (tt.want == nil) {
+				t.Errorf("created = %v, want created only when valid", creator.created != nil)
+			}
+		})
+	}
+}
@@ -1,54 +1,395 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/knadh/koanf/parsers/toml"
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/v2"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/logging"
 )
 
+// EnvPrefix starts the environment variables that override the config file:
+// PHARMARECALL_DB_URL sets db.url. Appending _FILE reads the value from a
+// file instead, as with Docker and Kubernetes secrets.
+const EnvPrefix = "PHARMARECALL_"
+
+// Environments of the server. Production refuses development defaults such
+// as the sample session secret.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// DevSessionSecret is the session secret of config.toml.dist, refused in
+// production.
+const DevSessionSecret = "dev-secret-change-in-production"
+
+// minSecretLength is the shortest session secret accepted in production.
+const minSecretLength = 32
+
 type Config struct {
 	Server    ServerConfig    `koanf:"server"`
 	DB        DBConfig        `koanf:"db"`
 	Session   SessionConfig   `koanf:"session"`
 	Lookahead LookaheadConfig `koanf:"lookahead"`
+	Portal    PortalConfig    `koanf:"portal"`
+	Mail      MailConfig      `koanf:"mail"`
+	SMS       SMSConfig       `koanf:"sms"`
+	Metrics   MetricsConfig   `koanf:"metrics"`
+	Log       LogConfig       `koanf:"log"`
+	Tracing   TracingConfig   `koanf:"tracing"`
+
+	// UnknownEnv lists the PHARMARECALL_* variables that name no key. They
+	// are ignored rather than refused, since platforms such as Kubernetes
+	// set variables of their own under the same prefix; callers log them.
+	UnknownEnv []string `koanf:"-"`
+	// ServiceLinkEnv lists the variables naming a numeric key whose value
+	// is a service address such as tcp://10.0.0.1:8080, as Kubernetes sets
+	// PHARMARECALL_SERVER_PORT for a service named pharmarecall-server. They
+	// are ignored too; callers log them.
+	ServiceLinkEnv []string `koanf:"-"`
 }
 
+// ServerConfig configures the HTTP server. BaseURL is the public address
+// used in links sent by email, such as password resets. Env is development
+// or production.
 type ServerConfig struct {
-	Port int `koanf:"port"`
+	Port    int    `koanf:"port"`
+	BaseURL string `koanf:"base_url"`
+	Env     string `koanf:"env"`
 }
 
 type DBConfig struct {
 	URL string `koanf:"url"`
 }
 
+// SessionConfig configures staff sessions. IdleTimeout logs a user out after
+// that long without requests, within the fixed 24h session lifetime; zero
+// disables it.
 type SessionConfig struct {
-	Secret string `koanf:"secret"`
+	Secret      string        `koanf:"secret"`
+	IdleTimeout time.Duration `koanf:"idle_timeout"`
 }
 
 type LookaheadConfig struct {
 	Days int `koanf:"days"`
 }
 
+// PortalConfig configures the patient portal. BaseURL is the public address
+// used in login links sent to patients.
+type PortalConfig struct {
+	BaseURL string `koanf:"base_url"`
+}
+
+// MailConfig configures the SMTP server that sends password resets and
+// patient login links. Without a Host, messages are written to the log,
+// which production refuses. Password is best set with PHARMARECALL_MAIL_PASSWORD_FILE.
+type MailConfig struct {
+	Host     string `koanf:"host"`
+	Port     int    `koanf:"port"`
+	Username string `koanf:"username"`
+	Password string `koanf:"password"`
+	From     string `koanf:"from"`
+}
+
+// SMSConfig configures the HTTP gateway that texts patient login codes;
+// see sms.Gateway for the request it receives. Without a URL, codes are
+// written to the log, which production refuses. The gateway forwards
+// patients' replies to POST /inbound/sms with InboundToken as a bearer
+// token; without one the endpoint is not served.
+type SMSConfig struct {
+	URL          string `koanf:"url"`
+	Token        string `koanf:"token"`
+	From         string `koanf:"from"`
+	InboundToken string `koanf:"inbound_token"`
+}
+
+// MetricsConfig configures /metrics. Scrapers send Token as a bearer token;
+// without one the endpoint is not served.
+type MetricsConfig struct {
+	Token string `koanf:"token"`
+}
+
+// LogConfig configures the server's logs. Format is "text" or "json".
+type LogConfig struct {
+	Format string `koanf:"format"`
+}
+
+// TracingConfig configures OpenTelemetry tracing. Endpoint is the base URL
+// of an OTLP/HTTP collector, such as http://otel-collector:4318; without
+// one, tracing is off.
+type TracingConfig struct {
+	Endpoint string `koanf:"endpoint"`
+}
+
+// DefaultPath is the config file the binaries read without --config.
+const DefaultPath = "config.toml"
+
+// DefaultFile returns DefaultPath when that file exists and "" otherwise,
+// so a deployment configured by environment variables alone, as in
+// Kubernetes, needs no file and no flag. A path given with --config must
+// exist.
+func DefaultFile() (string, error) {
+	_, err := os.Stat(DefaultPath)
+	switch {
+	case err == nil:
+		return DefaultPath, nil
+	case errors.Is(err, os.ErrNotExist):
+		return "", nil
+	default:
+		return "", fmt.Errorf("checking for %s: %w", DefaultPath, err)
+	}
+}
+
+// Load reads the TOML file at path, then applies the PHARMARECALL_*
+// environment variables over it. An empty path reads the environment only.
+// Defaults are filled in; call Validate before using the result.
 func Load(path string) (Config, error) {
 	k := koanf.New(".")
 
-	if err := k.Load(file.Provider(path), toml.Parser()); err != nil {
-		return Config{}, fmt.Errorf("loading config from %s: %w", path, err)
+	if path != "" {
+		if err := k.Load(file.Provider(path), toml.Parser()); err != nil {
+			return Config{}, fmt.Errorf("loading config from %s: %w", path, err)
+		}
+	}
+	unknown, links, err := loadEnv(k, os.Environ())
+	if err != nil {
+		return Config{}, err
 	}
 
 	var cfg Config
 	if err := k.Unmarshal("", &cfg); err != nil {
 		return Config{}, fmt.Errorf("unmarshalling config: %w", err)
 	}
+	cfg.UnknownEnv = unknown
+	cfg.ServiceLinkEnv = links
 
 	if cfg.Server.Port == 0 {
 		cfg.Server.Port = 8080
 	}
+	if cfg.Server.Env == "" {
+		cfg.Server.Env = EnvDevelopment
+	}
+	if cfg.Mail.Port == 0 {
+		cfg.Mail.Port = 587
+	}
+	if cfg.Log.Format == "" {
+		cfg.Log.Format = logging.FormatText
+	}
+	if !k.Exists("session.idle_timeout") {
+		cfg.Session.IdleTimeout = 2 * time.Hour
+	}
 	if cfg.Lookahead.Days == 0 {
 		cfg.Lookahead.Days = 7
 	}
+	if cfg.Server.BaseURL == "" {
+		cfg.Server.BaseURL = fmt.Sprintf("http://localhost:%d", cfg.Server.Port)
+	}
+	if cfg.Portal.BaseURL == "" {
+		cfg.Portal.BaseURL = cfg.Server.BaseURL
+	}
 
 	return cfg, nil
 }
+
+// loadEnv sets every key named by a PHARMARECALL_* variable in environ and
+// returns the names of those that match no key, then of those that hold a
+// service address for a numeric key. Kubernetes, for one, adds
+// PHARMARECALL_SERVICE_HOST and the like for a service of that name, so
+// neither must stop the server.
+func loadEnv(k *koanf.Koanf, environ []string) (unknown, links []string, err error) {
+	known := Keys()
+	numeric := numericKeys()
+	seen := map[string]string{}
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		rest, ok := strings.CutPrefix(name, EnvPrefix)
+		if !ok {
+			continue
+		}
+
+		fromFile := false
+		if base, ok := strings.CutSuffix(rest, "_FILE"); ok {
+			rest, fromFile = base, true
+		}
+		key := envKey(rest, known)
+		if key == "" {
+			unknown = append(unknown, name)
+			continue
+		}
+		if numeric[key] && isServiceLink(value) {
+			links = append(links, name)
+			continue
+		}
+		if other, ok := seen[key]; ok {
+			return nil, nil, fmt.Errorf("both %s and %s set %s", other, name, key)
+		}
+		seen[key] = name
+
+		if fromFile {
+			data, err := os.ReadFile(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("reading %s: %w", name, err)
+			}
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+		if err := k.Set(key, value); err != nil {
+			return nil, nil, fmt.Errorf("setting %s from %s: %w", key, name, err)
+		}
+	}
+	slices.Sort(unknown)
+	slices.Sort(links)
+	return unknown, links, nil
+}
+
+// isServiceLink reports whether value is an address in the form Kubernetes
+// gives service links, such as tcp://10.0.0.1:8080.
+func isServiceLink(value string) bool {
+	scheme, _, ok := strings.Cut(value, "://")
+	return ok && slices.Contains([]string{"tcp", "udp", "sctp"}, scheme)
+}
+
+// envKey maps the part of a variable name after the prefix, such as
+// SERVER_BASE_URL, to its key, server.base_url, or "" when none matches.
+func envKey(name string, known []string) string {
+	name = strings.ToLower(name)
+	for _, key := range known {
+		if strings.ReplaceAll(key, ".", "_") == name {
+			return key
+		}
+	}
+	return ""
+}
+
+// Keys lists every configuration key, such as "db.url".
+func Keys() []string {
+	var keys []string
+	t := reflect.TypeFor[Config]()
+	for section := range t.Fields() {
+		if section.Tag.Get("koanf") == "-" {
+			continue
+		}
+		for field := range section.Type.Fields() {
+			keys = append(keys, section.Tag.Get("koanf")+"."+field.Tag.Get("koanf"))
+		}
+	}
+	return keys
+}
+
+// numericKeys returns the keys that hold numbers or durations, such as
+// server.port.
+func numericKeys() map[string]bool {
+	keys := map[string]bool{}
+	for section := range reflect.TypeFor[Config]().Fields() {
+		if section.Tag.Get("koanf") == "-" {
+			continue
+		}
+		for field := range section.Type.Fields() {
+			if field.Type.Kind() == reflect.Int || field.Type.Kind() == reflect.Int64 {
+				keys[section.Tag.Get("koanf")+"."+field.Tag.Get("koanf")] = true
+			}
+		}
+	}
+	return keys
+}
+
+// EnvName is the environment variable that sets key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Validate reports every problem of the configuration at once, naming the
+// keys to fix and the variables that set them.
+func (c Config) Validate() error {
+	var problems []string
+	fail := func(key, format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("%s (%s): %s", key, EnvName(key), fmt.Sprintf(format, args...)))
+	}
+
+	if !slices.Contains([]string{EnvDevelopment, EnvProduction}, c.Server.Env) {
+		fail("server.env", "must be %q or %q, got %q", EnvDevelopment, EnvProduction, c.Server.Env)
+	}
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		fail("server.port", "must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if err := checkBaseURL(c.Server.BaseURL); err != nil {
+		fail("server.base_url", "%v", err)
+	}
+	if err := checkBaseURL(c.Portal.BaseURL); err != nil {
+		fail("portal.base_url", "%v", err)
+	}
+	if c.DB.URL == "" {
+		fail("db.url", "is required")
+	}
+	switch {
+	case c.Session.Secret == "":
+		fail("session.secret", "is required")
+	case c.Server.Env == EnvProduction && c.Session.Secret == DevSessionSecret:
+		fail("session.secret", "is the development default; generate a new one for production")
+	case c.Server.Env == EnvProduction && len(c.Session.Secret) < minSecretLength:
+		fail("session.secret", "must be at least %d characters in production", minSecretLength)
+	}
+	if c.Session.IdleTimeout < 0 {
+		fail("session.idle_timeout", "cannot be negative")
+	}
+	switch {
+	case c.Mail.Host == "" && c.Server.Env == EnvProduction:
+		fail("mail.host", "is required in production, where reset and login links must not go to the log")
+	case c.Mail.Host != "":
+		if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+			fail("mail.from", "must be an email address, got %q", c.Mail.From)
+		}
+		if c.Mail.Port < 1 || c.Mail.Port > 65535 {
+			fail("mail.port", "must be between 1 and 65535, got %d", c.Mail.Port)
+		}
+	}
+	switch {
+	case c.SMS.URL == "" && c.Server.Env == EnvProduction:
+		fail("sms.url", "is required in production, where login codes must not go to the log")
+	case c.SMS.URL != "":
+		if err := checkBaseURL(c.SMS.URL); err != nil {
+			fail("sms.url", "%v", err)
+		}
+	}
+	if c.SMS.InboundToken != "" && len(c.SMS.InboundToken) < minSecretLength {
+		fail("sms.inbound_token", "must be at least %d characters", minSecretLength)
+	}
+	if c.Metrics.Token != "" && len(c.Metrics.Token) < minSecretLength {
+		fail("metrics.token", "must be at least %d characters", minSecretLength)
+	}
+	if c.Log.Format != logging.FormatText && c.Log.Format != logging.FormatJSON {
+		fail("log.format", "must be %q or %q, got %q", logging.FormatText, logging.FormatJSON, c.Log.Format)
+	}
+	if c.Tracing.Endpoint != "" {
+		if err := checkBaseURL(c.Tracing.Endpoint); err != nil {
+			fail("tracing.endpoint", "%v", err)
+		}
+	}
+	if c.Lookahead.Days < 1 || c.Lookahead.Days > 60 {
+		fail("lookahead.days", "must be between 1 and 60, got %d", c.Lookahead.Days)
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkBaseURL accepts absolute http and https URLs.
+func checkBaseURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http(s) URL, got %q", s)
+	}
+	return nil
+}
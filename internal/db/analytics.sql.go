@@ -0,0 +1,136 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: analytics.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const listOrderEventsSince = `-- name: ListOrderEventsSince :many
+SELECT o.created_at, o.prepared_at, o.fulfilled_at
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = $1::BIGINT
+  AND (o.created_at >= $2::TIMESTAMPTZ
+       OR o.prepared_at >= $2::TIMESTAMPTZ
+       OR o.fulfilled_at >= $2::TIMESTAMPTZ)
+`
+
+type ListOrderEventsSinceParams struct {
+	PharmacyID int64
+	Since      pgtype.Timestamptz
+}
+
+type ListOrderEventsSinceRow struct {
+	CreatedAt   pgtype.Timestamptz
+	PreparedAt  pgtype.Timestamptz
+	FulfilledAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListOrderEventsSince(ctx context.Context, arg ListOrderEventsSinceParams) ([]ListOrderEventsSinceRow, error) {
+	rows, err := q.db.Query(ctx, listOrderEventsSince, arg.PharmacyID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOrderEventsSinceRow
+	for rows.Next() {
+		var i ListOrderEventsSinceRow
+		if err := rows.Scan(&i.CreatedAt, &i.PreparedAt, &i.FulfilledAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRefillsSince = `-- name: ListRefillsSince :many
+SELECT rh.box_end_date, rh.created_at
+FROM refill_history rh
+JOIN prescriptions p ON rh.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = $1::BIGINT
+  AND rh.created_at >= $2::TIMESTAMPTZ
+`
+
+type ListRefillsSinceParams struct {
+	PharmacyID int64
+	Since      pgtype.Timestamptz
+}
+
+type ListRefillsSinceRow struct {
+	BoxEndDate pgtype.Date
+	CreatedAt  pgtype.Timestamptz
+}
+
+func (q *Queries) ListRefillsSince(ctx context.Context, arg ListRefillsSinceParams) ([]ListRefillsSinceRow, error) {
+	rows, err := q.db.Query(ctx, listRefillsSince, arg.PharmacyID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRefillsSinceRow
+	for rows.Next() {
+		var i ListRefillsSinceRow
+		if err := rows.Scan(&i.BoxEndDate, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopMedications = `-- name: ListTopMedications :many
+SELECT p.medication_name, count(*)::INTEGER AS orders
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = $1::BIGINT
+  AND o.created_at >= $2::TIMESTAMPTZ
+GROUP BY p.medication_name
+ORDER BY orders DESC, p.medication_name
+LIMIT $3::INTEGER
+`
+
+type ListTopMedicationsParams struct {
+	PharmacyID int64
+	Since      pgtype.Timestamptz
+	MaxRows    int32
+}
+
+type ListTopMedicationsRow struct {
+	MedicationName string
+	Orders         int32
+}
+
+func (q *Queries) ListTopMedications(ctx context.Context, arg ListTopMedicationsParams) ([]ListTopMedicationsRow, error) {
+	rows, err := q.db.Query(ctx, listTopMedications, arg.PharmacyID, arg.Since, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopMedicationsRow
+	for rows.Next() {
+		var i ListTopMedicationsRow
+		if err := rows.Scan(&i.MedicationName, &i.Orders); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
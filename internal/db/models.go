@@ -8,6 +8,16 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type LoginEvent struct {
+	ID        int64
+	UserID    pgtype.Int8
+	Email     string
+	Ip        string
+	UserAgent string
+	Success   bool
+	CreatedAt pgtype.Timestamptz
+}
+
 type Notification struct {
 	ID             int64
 	PharmacyID     int64
@@ -17,6 +27,15 @@ type Notification struct {
 	CreatedAt      pgtype.Timestamptz
 }
 
+type OpeningHour struct {
+	ID         int64
+	PharmacyID int64
+	Weekday    int16
+	OpensAt    pgtype.Time
+	ClosesAt   pgtype.Time
+	CreatedAt  pgtype.Timestamptz
+}
+
 type Order struct {
 	ID                     int64
 	PrescriptionID         int64
@@ -25,32 +44,133 @@ type Order struct {
 	Status                 string
 	CreatedAt              pgtype.Timestamptz
 	UpdatedAt              pgtype.Timestamptz
+	PickupAt               pgtype.Timestamp
+	PickupConfirmed        bool
+	PickupNotifiedAt       pgtype.Timestamptz
+	PreparedAt             pgtype.Timestamptz
+	FulfilledAt            pgtype.Timestamptz
+}
+
+type PasswordResetToken struct {
+	ID        int64
+	UserID    int64
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
 }
 
 type Patient struct {
-	ID              int64
-	PharmacyID      int64
-	FirstName       string
-	LastName        string
-	Phone           string
-	Email           string
-	DeliveryAddress string
-	Fulfillment     string
-	Notes           string
-	Consensus       bool
-	ConsensusDate   pgtype.Timestamptz
-	CreatedAt       pgtype.Timestamptz
-	UpdatedAt       pgtype.Timestamptz
+	ID                    int64
+	PharmacyID            int64
+	FirstName             string
+	LastName              string
+	Phone                 string
+	Email                 string
+	DeliveryAddressLegacy string
+	Fulfillment           string
+	Notes                 string
+	Consensus             bool
+	ConsensusDate         pgtype.Timestamptz
+	CreatedAt             pgtype.Timestamptz
+	UpdatedAt             pgtype.Timestamptz
+	DeliveryStreet        string
+	DeliveryHouseNumber   string
+	DeliveryCap           string
+	DeliveryCity          string
+	DeliveryProvince      string
+	DeliveryCountry       string
+	DeliveryNotes         string
+}
+
+type PatientLoginToken struct {
+	ID        int64
+	PatientID int64
+	Channel   string
+	TokenHash []byte
+	Attempts  int32
+	ExpiresAt pgtype.Timestamptz
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type PatientSession struct {
+	Token  string
+	Data   []byte
+	Expiry pgtype.Timestamptz
 }
 
 type Pharmacy struct {
+	ID                 int64
+	Name               string
+	Address            string
+	Phone              string
+	Email              string
+	CreatedAt          pgtype.Timestamptz
+	UpdatedAt          pgtype.Timestamptz
+	LabelLayout        string
+	GroupID            pgtype.Int8
+	Require2fa         bool
+	Locale             string
+	VatNumber          string
+	DefaultFulfillment string
+	LookaheadDays      int32
+	PickupReminder     string
+	Logo               []byte
+}
+
+type PharmacyCalendar struct {
+	PharmacyID       int64
+	ClosedWeekdays   []int16
+	NationalHolidays bool
+	PatronSaintName  string
+	PatronSaintMonth pgtype.Int2
+	PatronSaintDay   pgtype.Int2
+	UpdatedAt        pgtype.Timestamptz
+}
+
+type PharmacyClosure struct {
+	ID         int64
+	PharmacyID int64
+	StartDate  pgtype.Date
+	EndDate    pgtype.Date
+	Reason     string
+	CreatedAt  pgtype.Timestamptz
+}
+
+type PharmacyGroup struct {
 	ID        int64
 	Name      string
-	Address   string
-	Phone     string
-	Email     string
 	CreatedAt pgtype.Timestamptz
-	UpdatedAt pgtype.Timestamptz
+}
+
+type PharmacyRole struct {
+	ID          int64
+	PharmacyID  int64
+	Name        string
+	Permissions []string
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type PharmacySso struct {
+	PharmacyID     int64
+	Enabled        bool
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RoleClaim      string
+	OwnerValue     string
+	PersonnelValue string
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type PickupSetting struct {
+	PharmacyID    int64
+	SlotMinutes   int32
+	SlotCapacity  int32
+	DailyCapacity int32
+	UpdatedAt     pgtype.Timestamptz
 }
 
 type Prescription struct {
@@ -78,13 +198,73 @@ type Session struct {
 	Expiry pgtype.Timestamptz
 }
 
+type Shipment struct {
+	ID             int64
+	BatchID        int64
+	OrderID        int64
+	TrackingNumber string
+	Status         string
+	ShippedAt      pgtype.Timestamptz
+	DeliveredAt    pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type ShippingBatch struct {
+	ID         int64
+	PharmacyID int64
+	Status     string
+	ShippedAt  pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type StockReport struct {
+	ID             int64
+	PrescriptionID int64
+	Units          int32
+	ReportedOn     pgtype.Date
+	Source         string
+	CreatedAt      pgtype.Timestamptz
+}
+
 type User struct {
-	ID           int64
-	Email        string
-	PasswordHash string
-	Name         string
-	Role         string
-	PharmacyID   pgtype.Int8
-	CreatedAt    pgtype.Timestamptz
-	UpdatedAt    pgtype.Timestamptz
+	ID                 int64
+	Email              string
+	PasswordHash       string
+	Name               string
+	Role               string
+	PharmacyID         pgtype.Int8
+	CreatedAt          pgtype.Timestamptz
+	UpdatedAt          pgtype.Timestamptz
+	LastLoginAt        pgtype.Timestamptz
+	Active             bool
+	MustChangePassword bool
+	TotpSecret         pgtype.Text
+	TotpEnabledAt      pgtype.Timestamptz
+	TotpLastStep       int64
+	FailedLogins       int32
+	LockedUntil        pgtype.Timestamptz
+	OidcIssuer         pgtype.Text
+	OidcSubject        pgtype.Text
+	CustomRoleID       pgtype.Int8
+	Locale             string
+}
+
+type UserRecoveryCode struct {
+	ID        int64
+	UserID    int64
+	CodeHash  []byte
+	UsedAt    pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type UserSession struct {
+	Token      string
+	UserID     int64
+	CreatedAt  pgtype.Timestamptz
+	ID         int64
+	Ip         string
+	UserAgent  string
+	LastSeenAt pgtype.Timestamptz
 }
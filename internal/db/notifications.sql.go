@@ -23,7 +23,7 @@ func (q *Queries) CountUnreadNotifications(ctx context.Context, pharmacyID int64
 	return count, err
 }
 
-const createNotification = `-- name: CreateNotification :exec
+const createNotification = `-- name: CreateNotification :execrows
 INSERT INTO notifications (pharmacy_id, prescription_id, transition_type)
 VALUES ($1, $2, $3)
 ON CONFLICT (prescription_id, transition_type) DO NOTHING
@@ -35,9 +35,12 @@ type CreateNotificationParams struct {
 	TransitionType string
 }
 
-func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
-	_, err := q.db.Exec(ctx, createNotification, arg.PharmacyID, arg.PrescriptionID, arg.TransitionType)
-	return err
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createNotification, arg.PharmacyID, arg.PrescriptionID, arg.TransitionType)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const listNotificationsByPharmacy = `-- name: ListNotificationsByPharmacy :many
@@ -54,10 +57,19 @@ SELECT
     p.box_start_date,
     pat.id AS patient_id,
     pat.first_name,
-    pat.last_name
+    pat.last_name,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on
 FROM notifications n
 JOIN prescriptions p ON n.prescription_id = p.id
 JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN LATERAL (
+    SELECT units, reported_on
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
 WHERE n.pharmacy_id = $1::BIGINT
 ORDER BY n.created_at DESC
 `
@@ -76,6 +88,8 @@ type ListNotificationsByPharmacyRow struct {
 	PatientID        int64
 	FirstName        string
 	LastName         string
+	ReportedUnits    int32
+	ReportedOn       pgtype.Date
 }
 
 func (q *Queries) ListNotificationsByPharmacy(ctx context.Context, pharmacyID int64) ([]ListNotificationsByPharmacyRow, error) {
@@ -101,6 +115,8 @@ func (q *Queries) ListNotificationsByPharmacy(ctx context.Context, pharmacyID in
 			&i.PatientID,
 			&i.FirstName,
 			&i.LastName,
+			&i.ReportedUnits,
+			&i.ReportedOn,
 		); err != nil {
 			return nil, err
 		}
@@ -14,7 +14,8 @@ import (
 const createOrder = `-- name: CreateOrder :one
 INSERT INTO orders (prescription_id, cycle_start_date, estimated_depletion_date, status)
 VALUES ($1, $2, $3, $4)
-RETURNING id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at
+RETURNING id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at,
+    pickup_at, pickup_confirmed, pickup_notified_at, prepared_at, fulfilled_at
 `
 
 type CreateOrderParams struct {
@@ -40,13 +41,33 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PickupAt,
+		&i.PickupConfirmed,
+		&i.PickupNotifiedAt,
+		&i.PreparedAt,
+		&i.FulfilledAt,
 	)
 	return i, err
 }
 
+const deletePendingOrder = `-- name: DeletePendingOrder :execrows
+DELETE FROM orders o
+WHERE o.id = $1
+  AND o.status = 'pending'
+  AND NOT EXISTS (SELECT 1 FROM shipments s WHERE s.order_id = o.id)
+`
+
+func (q *Queries) DeletePendingOrder(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePendingOrder, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const fulfillActiveOrderByPrescription = `-- name: FulfillActiveOrderByPrescription :exec
 UPDATE orders
-SET status = 'fulfilled', updated_at = now()
+SET status = 'fulfilled', fulfilled_at = now(), updated_at = now()
 WHERE prescription_id = $1
   AND status IN ('pending', 'prepared')
 `
@@ -57,7 +78,8 @@ func (q *Queries) FulfillActiveOrderByPrescription(ctx context.Context, prescrip
 }
 
 const getActiveOrderByPrescription = `-- name: GetActiveOrderByPrescription :one
-SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at
+SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at,
+    pickup_at, pickup_confirmed, pickup_notified_at, prepared_at, fulfilled_at
 FROM orders
 WHERE prescription_id = $1::BIGINT
   AND status IN ('pending', 'prepared')
@@ -81,12 +103,18 @@ func (q *Queries) GetActiveOrderByPrescription(ctx context.Context, arg GetActiv
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PickupAt,
+		&i.PickupConfirmed,
+		&i.PickupNotifiedAt,
+		&i.PreparedAt,
+		&i.FulfilledAt,
 	)
 	return i, err
 }
 
 const getOrderByID = `-- name: GetOrderByID :one
-SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at
+SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at,
+    pickup_at, pickup_confirmed, pickup_notified_at, prepared_at, fulfilled_at
 FROM orders
 WHERE id = $1
 `
@@ -102,6 +130,66 @@ func (q *Queries) GetOrderByID(ctx context.Context, id int64) (Order, error) {
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PickupAt,
+		&i.PickupConfirmed,
+		&i.PickupNotifiedAt,
+		&i.PreparedAt,
+		&i.FulfilledAt,
+	)
+	return i, err
+}
+
+const getPrescriptionSummary = `-- name: GetPrescriptionSummary :one
+SELECT
+    p.id AS prescription_id,
+    p.units_per_box,
+    p.daily_consumption,
+    p.box_start_date,
+    pat.id AS patient_id,
+    pat.fulfillment,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on
+FROM prescriptions p
+JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN LATERAL (
+    SELECT units, reported_on
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
+WHERE p.id = $1::BIGINT
+  AND pat.pharmacy_id = $2::BIGINT
+`
+
+type GetPrescriptionSummaryParams struct {
+	PrescriptionID int64
+	PharmacyID     int64
+}
+
+type GetPrescriptionSummaryRow struct {
+	PrescriptionID   int64
+	UnitsPerBox      int32
+	DailyConsumption pgtype.Numeric
+	BoxStartDate     pgtype.Date
+	PatientID        int64
+	Fulfillment      string
+	ReportedUnits    int32
+	ReportedOn       pgtype.Date
+}
+
+func (q *Queries) GetPrescriptionSummary(ctx context.Context, arg GetPrescriptionSummaryParams) (GetPrescriptionSummaryRow, error) {
+	row := q.db.QueryRow(ctx, getPrescriptionSummary, arg.PrescriptionID, arg.PharmacyID)
+	var i GetPrescriptionSummaryRow
+	err := row.Scan(
+		&i.PrescriptionID,
+		&i.UnitsPerBox,
+		&i.DailyConsumption,
+		&i.BoxStartDate,
+		&i.PatientID,
+		&i.Fulfillment,
+		&i.ReportedUnits,
+		&i.ReportedOn,
 	)
 	return i, err
 }
@@ -121,12 +209,24 @@ SELECT
     pat.first_name,
     pat.last_name,
     pat.fulfillment,
-    pat.delivery_address,
+    pat.delivery_street,
+    pat.delivery_house_number,
+    pat.delivery_cap,
+    pat.delivery_city,
+    pat.delivery_province,
+    pat.delivery_country,
+    pat.delivery_notes,
+    pat.delivery_address_legacy,
     pat.phone,
-    pat.email
+    pat.email,
+    COALESCE(s.status, '')::TEXT AS shipping_status,
+    COALESCE(s.tracking_number, '')::TEXT AS tracking_number,
+    o.pickup_at,
+    o.pickup_confirmed
 FROM orders o
 JOIN prescriptions p ON o.prescription_id = p.id
 JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN shipments s ON s.order_id = o.id
 WHERE pat.pharmacy_id = $1::BIGINT
 ORDER BY o.estimated_depletion_date ASC
 `
@@ -145,9 +245,20 @@ type ListDashboardOrdersRow struct {
 	FirstName              string
 	LastName               string
 	Fulfillment            string
-	DeliveryAddress        string
+	DeliveryStreet         string
+	DeliveryHouseNumber    string
+	DeliveryCap            string
+	DeliveryCity           string
+	DeliveryProvince       string
+	DeliveryCountry        string
+	DeliveryNotes          string
+	DeliveryAddressLegacy  string
 	Phone                  string
 	Email                  string
+	ShippingStatus         string
+	TrackingNumber         string
+	PickupAt               pgtype.Timestamp
+	PickupConfirmed        bool
 }
 
 func (q *Queries) ListDashboardOrders(ctx context.Context, pharmacyID int64) ([]ListDashboardOrdersRow, error) {
@@ -173,9 +284,62 @@ func (q *Queries) ListDashboardOrders(ctx context.Context, pharmacyID int64) ([]
 			&i.FirstName,
 			&i.LastName,
 			&i.Fulfillment,
-			&i.DeliveryAddress,
+			&i.DeliveryStreet,
+			&i.DeliveryHouseNumber,
+			&i.DeliveryCap,
+			&i.DeliveryCity,
+			&i.DeliveryProvince,
+			&i.DeliveryCountry,
+			&i.DeliveryNotes,
+			&i.DeliveryAddressLegacy,
 			&i.Phone,
 			&i.Email,
+			&i.ShippingStatus,
+			&i.TrackingNumber,
+			&i.PickupAt,
+			&i.PickupConfirmed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingOrdersByPrescription = `-- name: ListPendingOrdersByPrescription :many
+SELECT id, prescription_id, cycle_start_date, estimated_depletion_date, status, created_at, updated_at,
+    pickup_at, pickup_confirmed, pickup_notified_at, prepared_at, fulfilled_at
+FROM orders
+WHERE prescription_id = $1
+  AND status = 'pending'
+ORDER BY id
+`
+
+func (q *Queries) ListPendingOrdersByPrescription(ctx context.Context, prescriptionID int64) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listPendingOrdersByPrescription, prescriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.PrescriptionID,
+			&i.CycleStartDate,
+			&i.EstimatedDepletionDate,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PickupAt,
+			&i.PickupConfirmed,
+			&i.PickupNotifiedAt,
+			&i.PreparedAt,
+			&i.FulfilledAt,
 		); err != nil {
 			return nil, err
 		}
@@ -193,9 +357,19 @@ SELECT
     p.units_per_box,
     p.daily_consumption,
     p.box_start_date,
-    pat.id AS patient_id
+    pat.id AS patient_id,
+    pat.fulfillment,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on
 FROM prescriptions p
 JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN LATERAL (
+    SELECT units, reported_on
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
 WHERE pat.pharmacy_id = $1::BIGINT
   AND pat.consensus = true
 ORDER BY p.id
@@ -207,6 +381,9 @@ type ListPrescriptionsInLookaheadRow struct {
 	DailyConsumption pgtype.Numeric
 	BoxStartDate     pgtype.Date
 	PatientID        int64
+	Fulfillment      string
+	ReportedUnits    int32
+	ReportedOn       pgtype.Date
 }
 
 func (q *Queries) ListPrescriptionsInLookahead(ctx context.Context, pharmacyID int64) ([]ListPrescriptionsInLookaheadRow, error) {
@@ -224,6 +401,9 @@ func (q *Queries) ListPrescriptionsInLookahead(ctx context.Context, pharmacyID i
 			&i.DailyConsumption,
 			&i.BoxStartDate,
 			&i.PatientID,
+			&i.Fulfillment,
+			&i.ReportedUnits,
+			&i.ReportedOn,
 		); err != nil {
 			return nil, err
 		}
@@ -235,9 +415,28 @@ func (q *Queries) ListPrescriptionsInLookahead(ctx context.Context, pharmacyID i
 	return items, nil
 }
 
+const updateOrderDepletionDate = `-- name: UpdateOrderDepletionDate :exec
+UPDATE orders
+SET estimated_depletion_date = $2, updated_at = now()
+WHERE id = $1 AND status = 'pending'
+`
+
+type UpdateOrderDepletionDateParams struct {
+	ID                     int64
+	EstimatedDepletionDate pgtype.Date
+}
+
+func (q *Queries) UpdateOrderDepletionDate(ctx context.Context, arg UpdateOrderDepletionDateParams) error {
+	_, err := q.db.Exec(ctx, updateOrderDepletionDate, arg.ID, arg.EstimatedDepletionDate)
+	return err
+}
+
 const updateOrderStatus = `-- name: UpdateOrderStatus :exec
 UPDATE orders
-SET status = $2, updated_at = now()
+SET status = $2,
+    prepared_at = CASE WHEN $2 = 'prepared' THEN now() ELSE prepared_at END,
+    fulfilled_at = CASE WHEN $2 = 'fulfilled' THEN now() ELSE fulfilled_at END,
+    updated_at = now()
 WHERE id = $1
 `
 
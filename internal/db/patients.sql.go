@@ -9,21 +9,63 @@ import (
 	"context"
 )
 
+const clearPatientOrderPickups = `-- name: ClearPatientOrderPickups :exec
+UPDATE orders
+SET pickup_at = NULL, pickup_confirmed = false, pickup_notified_at = NULL, updated_at = now()
+WHERE status <> 'fulfilled'
+  AND prescription_id IN (SELECT id FROM prescriptions WHERE patient_id = $1)
+`
+
+// Pickup slots belong to the old branch's opening hours.
+func (q *Queries) ClearPatientOrderPickups(ctx context.Context, patientID int64) error {
+	_, err := q.db.Exec(ctx, clearPatientOrderPickups, patientID)
+	return err
+}
+
+const countPatientOrdersInTransit = `-- name: CountPatientOrdersInTransit :one
+SELECT COUNT(*)::BIGINT
+FROM shipments s
+JOIN orders o ON o.id = s.order_id
+JOIN prescriptions rx ON rx.id = o.prescription_id
+WHERE rx.patient_id = $1 AND s.status <> 'delivered'
+`
+
+// Orders sitting in a shipping batch of the current branch that have not been
+// delivered yet. They block a transfer: the batch belongs to the old branch.
+func (q *Queries) CountPatientOrdersInTransit(ctx context.Context, patientID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countPatientOrdersInTransit, patientID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
 const createPatient = `-- name: CreatePatient :one
-INSERT INTO patients (pharmacy_id, first_name, last_name, phone, email, delivery_address, fulfillment, notes)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, pharmacy_id, first_name, last_name, phone, email, delivery_address, fulfillment, notes, consensus, consensus_date, created_at, updated_at
+INSERT INTO patients (
+    pharmacy_id, first_name, last_name, phone, email,
+    delivery_street, delivery_house_number, delivery_cap, delivery_city, delivery_province, delivery_country, delivery_notes,
+    fulfillment, notes
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, pharmacy_id, first_name, last_name, phone, email, delivery_address_legacy,
+    fulfillment, notes, consensus, consensus_date, created_at, updated_at,
+    delivery_street, delivery_house_number, delivery_cap, delivery_city, delivery_province, delivery_country, delivery_notes
 `
 
 type CreatePatientParams struct {
-	PharmacyID      int64
-	FirstName       string
-	LastName        string
-	Phone           string
-	Email           string
-	DeliveryAddress string
-	Fulfillment     string
-	Notes           string
+	PharmacyID          int64
+	FirstName           string
+	LastName            string
+	Phone               string
+	Email               string
+	DeliveryStreet      string
+	DeliveryHouseNumber string
+	DeliveryCap         string
+	DeliveryCity        string
+	DeliveryProvince    string
+	DeliveryCountry     string
+	DeliveryNotes       string
+	Fulfillment         string
+	Notes               string
 }
 
 func (q *Queries) CreatePatient(ctx context.Context, arg CreatePatientParams) (Patient, error) {
@@ -33,7 +75,13 @@ func (q *Queries) CreatePatient(ctx context.Context, arg CreatePatientParams) (P
 		arg.LastName,
 		arg.Phone,
 		arg.Email,
-		arg.DeliveryAddress,
+		arg.DeliveryStreet,
+		arg.DeliveryHouseNumber,
+		arg.DeliveryCap,
+		arg.DeliveryCity,
+		arg.DeliveryProvince,
+		arg.DeliveryCountry,
+		arg.DeliveryNotes,
 		arg.Fulfillment,
 		arg.Notes,
 	)
@@ -45,19 +93,28 @@ func (q *Queries) CreatePatient(ctx context.Context, arg CreatePatientParams) (P
 		&i.LastName,
 		&i.Phone,
 		&i.Email,
-		&i.DeliveryAddress,
+		&i.DeliveryAddressLegacy,
 		&i.Fulfillment,
 		&i.Notes,
 		&i.Consensus,
 		&i.ConsensusDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeliveryStreet,
+		&i.DeliveryHouseNumber,
+		&i.DeliveryCap,
+		&i.DeliveryCity,
+		&i.DeliveryProvince,
+		&i.DeliveryCountry,
+		&i.DeliveryNotes,
 	)
 	return i, err
 }
 
 const getPatientByID = `-- name: GetPatientByID :one
-SELECT id, pharmacy_id, first_name, last_name, phone, email, delivery_address, fulfillment, notes, consensus, consensus_date, created_at, updated_at
+SELECT id, pharmacy_id, first_name, last_name, phone, email, delivery_address_legacy,
+    fulfillment, notes, consensus, consensus_date, created_at, updated_at,
+    delivery_street, delivery_house_number, delivery_cap, delivery_city, delivery_province, delivery_country, delivery_notes
 FROM patients
 WHERE id = $1
 `
@@ -72,13 +129,20 @@ func (q *Queries) GetPatientByID(ctx context.Context, id int64) (Patient, error)
 		&i.LastName,
 		&i.Phone,
 		&i.Email,
-		&i.DeliveryAddress,
+		&i.DeliveryAddressLegacy,
 		&i.Fulfillment,
 		&i.Notes,
 		&i.Consensus,
 		&i.ConsensusDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeliveryStreet,
+		&i.DeliveryHouseNumber,
+		&i.DeliveryCap,
+		&i.DeliveryCity,
+		&i.DeliveryProvince,
+		&i.DeliveryCountry,
+		&i.DeliveryNotes,
 	)
 	return i, err
 }
@@ -126,6 +190,78 @@ func (q *Queries) ListPatientsByPharmacy(ctx context.Context, pharmacyID int64)
 	return items, nil
 }
 
+const lockPatientInPharmacy = `-- name: LockPatientInPharmacy :one
+SELECT id
+FROM patients
+WHERE id = $1 AND pharmacy_id = $2
+FOR UPDATE
+`
+
+type LockPatientInPharmacyParams struct {
+	ID         int64
+	PharmacyID int64
+}
+
+func (q *Queries) LockPatientInPharmacy(ctx context.Context, arg LockPatientInPharmacyParams) (int64, error) {
+	row := q.db.QueryRow(ctx, lockPatientInPharmacy, arg.ID, arg.PharmacyID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const movePatientNotifications = `-- name: MovePatientNotifications :exec
+UPDATE notifications
+SET pharmacy_id = $2
+WHERE prescription_id IN (SELECT id FROM prescriptions WHERE patient_id = $1)
+`
+
+type MovePatientNotificationsParams struct {
+	PatientID  int64
+	PharmacyID int64
+}
+
+func (q *Queries) MovePatientNotifications(ctx context.Context, arg MovePatientNotificationsParams) error {
+	_, err := q.db.Exec(ctx, movePatientNotifications, arg.PatientID, arg.PharmacyID)
+	return err
+}
+
+const movePatientToPharmacy = `-- name: MovePatientToPharmacy :exec
+UPDATE patients
+SET pharmacy_id = $2, updated_at = now()
+WHERE id = $1
+`
+
+type MovePatientToPharmacyParams struct {
+	ID         int64
+	PharmacyID int64
+}
+
+func (q *Queries) MovePatientToPharmacy(ctx context.Context, arg MovePatientToPharmacyParams) error {
+	_, err := q.db.Exec(ctx, movePatientToPharmacy, arg.ID, arg.PharmacyID)
+	return err
+}
+
+const pharmaciesShareGroup = `-- name: PharmaciesShareGroup :one
+SELECT EXISTS (
+    SELECT 1
+    FROM pharmacies a
+    JOIN pharmacies b ON b.group_id = a.group_id
+    WHERE a.id = $1::BIGINT AND b.id = $2::BIGINT
+)::BOOLEAN AS share_group
+`
+
+type PharmaciesShareGroupParams struct {
+	FromPharmacyID int64
+	ToPharmacyID   int64
+}
+
+func (q *Queries) PharmaciesShareGroup(ctx context.Context, arg PharmaciesShareGroupParams) (bool, error) {
+	row := q.db.QueryRow(ctx, pharmaciesShareGroup, arg.FromPharmacyID, arg.ToPharmacyID)
+	var share_group bool
+	err := row.Scan(&share_group)
+	return share_group, err
+}
+
 const setPatientConsensus = `-- name: SetPatientConsensus :exec
 UPDATE patients
 SET consensus = true, consensus_date = now(), updated_at = now()
@@ -139,21 +275,32 @@ func (q *Queries) SetPatientConsensus(ctx context.Context, id int64) error {
 
 const updatePatient = `-- name: UpdatePatient :exec
 UPDATE patients
-SET first_name = $2, last_name = $3, phone = $4, email = $5, delivery_address = $6, fulfillment = $7, notes = $8, updated_at = now()
+SET first_name = $2, last_name = $3, phone = $4, email = $5,
+    delivery_street = $6, delivery_house_number = $7, delivery_cap = $8, delivery_city = $9,
+    delivery_province = $10, delivery_country = $11, delivery_notes = $12,
+    delivery_address_legacy = CASE WHEN $6 <> '' THEN '' ELSE delivery_address_legacy END,
+    fulfillment = $13, notes = $14, updated_at = now()
 WHERE id = $1
 `
 
 type UpdatePatientParams struct {
-	ID              int64
-	FirstName       string
-	LastName        string
-	Phone           string
-	Email           string
-	DeliveryAddress string
-	Fulfillment     string
-	Notes           string
+	ID                  int64
+	FirstName           string
+	LastName            string
+	Phone               string
+	Email               string
+	DeliveryStreet      string
+	DeliveryHouseNumber string
+	DeliveryCap         string
+	DeliveryCity        string
+	DeliveryProvince    string
+	DeliveryCountry     string
+	DeliveryNotes       string
+	Fulfillment         string
+	Notes               string
 }
 
+// Saving a structured address retires the legacy free-text value.
 func (q *Queries) UpdatePatient(ctx context.Context, arg UpdatePatientParams) error {
 	_, err := q.db.Exec(ctx, updatePatient,
 		arg.ID,
@@ -161,7 +308,13 @@ func (q *Queries) UpdatePatient(ctx context.Context, arg UpdatePatientParams) er
 		arg.LastName,
 		arg.Phone,
 		arg.Email,
-		arg.DeliveryAddress,
+		arg.DeliveryStreet,
+		arg.DeliveryHouseNumber,
+		arg.DeliveryCap,
+		arg.DeliveryCity,
+		arg.DeliveryProvince,
+		arg.DeliveryCountry,
+		arg.DeliveryNotes,
 		arg.Fulfillment,
 		arg.Notes,
 	)
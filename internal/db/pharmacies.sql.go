@@ -14,7 +14,7 @@ import (
 const createPharmacy = `-- name: CreatePharmacy :one
 INSERT INTO pharmacies (name, address, phone, email)
 VALUES ($1, $2, $3, $4)
-RETURNING id, name, address, phone, email, created_at, updated_at
+RETURNING id, name, address, phone, email, label_layout, created_at, updated_at
 `
 
 type CreatePharmacyParams struct {
@@ -24,43 +24,153 @@ type CreatePharmacyParams struct {
 	Email   string
 }
 
-func (q *Queries) CreatePharmacy(ctx context.Context, arg CreatePharmacyParams) (Pharmacy, error) {
+type CreatePharmacyRow struct {
+	ID          int64
+	Name        string
+	Address     string
+	Phone       string
+	Email       string
+	LabelLayout string
+	CreatedAt   pgtype.Timestamptz
+	UpdatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) CreatePharmacy(ctx context.Context, arg CreatePharmacyParams) (CreatePharmacyRow, error) {
 	row := q.db.QueryRow(ctx, createPharmacy,
 		arg.Name,
 		arg.Address,
 		arg.Phone,
 		arg.Email,
 	)
-	var i Pharmacy
+	var i CreatePharmacyRow
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.Address,
 		&i.Phone,
 		&i.Email,
+		&i.LabelLayout,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+const deleteEmptyPharmacyGroups = `-- name: DeleteEmptyPharmacyGroups :exec
+DELETE FROM pharmacy_groups g
+WHERE NOT EXISTS (SELECT 1 FROM pharmacies p WHERE p.group_id = g.id)
+`
+
+func (q *Queries) DeleteEmptyPharmacyGroups(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteEmptyPharmacyGroups)
+	return err
+}
+
+const deletePharmacySessions = `-- name: DeletePharmacySessions :execrows
+WITH revoked AS (
+    DELETE FROM user_sessions us
+    USING users u
+    WHERE u.id = us.user_id AND u.pharmacy_id = $1::BIGINT
+    RETURNING us.token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked)
+`
+
+// Logs out every member of the pharmacy.
+func (q *Queries) DeletePharmacySessions(ctx context.Context, pharmacyID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePharmacySessions, pharmacyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getPharmacyByID = `-- name: GetPharmacyByID :one
-SELECT id, name, address, phone, email, created_at, updated_at
-FROM pharmacies
-WHERE id = $1
+SELECT p.id, p.name, p.address, p.phone, p.email, p.label_layout, p.group_id, p.require_2fa, p.locale,
+    COALESCE(g.name, '')::TEXT AS group_name,
+    (p.logo IS NOT NULL)::BOOLEAN AS has_logo
+FROM pharmacies p
+LEFT JOIN pharmacy_groups g ON g.id = p.group_id
+WHERE p.id = $1
 `
 
-func (q *Queries) GetPharmacyByID(ctx context.Context, id int64) (Pharmacy, error) {
+type GetPharmacyByIDRow struct {
+	ID          int64
+	Name        string
+	Address     string
+	Phone       string
+	Email       string
+	LabelLayout string
+	GroupID     pgtype.Int8
+	Require2fa  bool
+	Locale      string
+	GroupName   string
+	HasLogo     bool
+}
+
+func (q *Queries) GetPharmacyByID(ctx context.Context, id int64) (GetPharmacyByIDRow, error) {
 	row := q.db.QueryRow(ctx, getPharmacyByID, id)
-	var i Pharmacy
+	var i GetPharmacyByIDRow
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.Address,
 		&i.Phone,
 		&i.Email,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.LabelLayout,
+		&i.GroupID,
+		&i.Require2fa,
+		&i.Locale,
+		&i.GroupName,
+		&i.HasLogo,
+	)
+	return i, err
+}
+
+const getPharmacyLogo = `-- name: GetPharmacyLogo :one
+SELECT logo FROM pharmacies WHERE id = $1
+`
+
+func (q *Queries) GetPharmacyLogo(ctx context.Context, id int64) ([]byte, error) {
+	row := q.db.QueryRow(ctx, getPharmacyLogo, id)
+	var logo []byte
+	err := row.Scan(&logo)
+	return logo, err
+}
+
+const getPharmacySettings = `-- name: GetPharmacySettings :one
+SELECT id, address, phone, email, vat_number, default_fulfillment, lookahead_days, pickup_reminder,
+    (logo IS NOT NULL)::BOOLEAN AS has_logo
+FROM pharmacies
+WHERE id = $1
+`
+
+type GetPharmacySettingsRow struct {
+	ID                 int64
+	Address            string
+	Phone              string
+	Email              string
+	VatNumber          string
+	DefaultFulfillment string
+	LookaheadDays      int32
+	PickupReminder     string
+	HasLogo            bool
+}
+
+func (q *Queries) GetPharmacySettings(ctx context.Context, id int64) (GetPharmacySettingsRow, error) {
+	row := q.db.QueryRow(ctx, getPharmacySettings, id)
+	var i GetPharmacySettingsRow
+	err := row.Scan(
+		&i.ID,
+		&i.Address,
+		&i.Phone,
+		&i.Email,
+		&i.VatNumber,
+		&i.DefaultFulfillment,
+		&i.LookaheadDays,
+		&i.PickupReminder,
+		&i.HasLogo,
 	)
 	return i, err
 }
@@ -70,30 +180,60 @@ SELECT
     p.id,
     p.name,
     p.address,
-    p.phone,
-    p.email,
     p.created_at,
-    p.updated_at,
-    COUNT(DISTINCT u.id)::BIGINT AS personnel_count
+    COALESCE(g.name, '')::TEXT AS group_name,
+    (SELECT COUNT(*) FROM users u WHERE u.pharmacy_id = p.id)::BIGINT AS personnel_count,
+    (SELECT COUNT(DISTINCT pa.id)
+     FROM patients pa
+     JOIN prescriptions rx ON rx.patient_id = pa.id
+     WHERE pa.pharmacy_id = p.id)::BIGINT AS active_patient_count,
+    (SELECT COUNT(*)
+     FROM prescriptions rx
+     JOIN patients pa ON pa.id = rx.patient_id
+     WHERE pa.pharmacy_id = p.id)::BIGINT AS prescription_count,
+    (SELECT COUNT(*)
+     FROM orders o
+     JOIN prescriptions rx ON rx.id = o.prescription_id
+     JOIN patients pa ON pa.id = rx.patient_id
+     WHERE pa.pharmacy_id = p.id
+       AND o.status <> 'fulfilled'
+       AND o.estimated_depletion_date < CURRENT_DATE)::BIGINT AS overdue_order_count,
+    (SELECT COUNT(*)
+     FROM notifications n
+     WHERE n.pharmacy_id = p.id AND NOT n.read)::BIGINT AS unread_notification_count,
+    (SELECT MAX(u.last_login_at) FROM users u WHERE u.pharmacy_id = p.id)::TIMESTAMPTZ AS last_login_at
 FROM pharmacies p
-LEFT JOIN users u ON u.pharmacy_id = p.id
-GROUP BY p.id
+LEFT JOIN pharmacy_groups g ON g.id = p.group_id
+WHERE $1::BIGINT IS NULL
+   OR p.id IN (
+       SELECT b.id
+       FROM users u
+       JOIN pharmacies home ON home.id = u.pharmacy_id
+       JOIN pharmacies b ON b.id = home.id OR b.group_id = home.group_id
+       WHERE u.id = $1::BIGINT AND u.role = 'owner'
+   )
 ORDER BY p.name
 `
 
 type ListPharmaciesRow struct {
-	ID             int64
-	Name           string
-	Address        string
-	Phone          string
-	Email          string
-	CreatedAt      pgtype.Timestamptz
-	UpdatedAt      pgtype.Timestamptz
-	PersonnelCount int64
-}
-
-func (q *Queries) ListPharmacies(ctx context.Context) ([]ListPharmaciesRow, error) {
-	rows, err := q.db.Query(ctx, listPharmacies)
+	ID                      int64
+	Name                    string
+	Address                 string
+	CreatedAt               pgtype.Timestamptz
+	GroupName               string
+	PersonnelCount          int64
+	ActivePatientCount      int64
+	PrescriptionCount       int64
+	OverdueOrderCount       int64
+	UnreadNotificationCount int64
+	LastLoginAt             pgtype.Timestamptz
+}
+
+// Per-pharmacy aggregates for the admin overview and, filtered to the
+// branches an owner can reach, the group dashboard. Counts only: no patient
+// data leaves this query.
+func (q *Queries) ListPharmacies(ctx context.Context, ownerID pgtype.Int8) ([]ListPharmaciesRow, error) {
+	rows, err := q.db.Query(ctx, listPharmacies, ownerID)
 	if err != nil {
 		return nil, err
 	}
@@ -105,11 +245,14 @@ func (q *Queries) ListPharmacies(ctx context.Context) ([]ListPharmaciesRow, erro
 			&i.ID,
 			&i.Name,
 			&i.Address,
-			&i.Phone,
-			&i.Email,
 			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.GroupName,
 			&i.PersonnelCount,
+			&i.ActivePatientCount,
+			&i.PrescriptionCount,
+			&i.OverdueOrderCount,
+			&i.UnreadNotificationCount,
+			&i.LastLoginAt,
 		); err != nil {
 			return nil, err
 		}
@@ -121,18 +264,90 @@ func (q *Queries) ListPharmacies(ctx context.Context) ([]ListPharmaciesRow, erro
 	return items, nil
 }
 
+const listUserBranches = `-- name: ListUserBranches :many
+SELECT b.id, b.name
+FROM users u
+JOIN pharmacies home ON home.id = u.pharmacy_id
+JOIN pharmacies b ON b.id = home.id OR b.group_id = home.group_id
+WHERE u.id = $1 AND u.role = 'owner'
+ORDER BY b.name
+`
+
+type ListUserBranchesRow struct {
+	ID   int64
+	Name string
+}
+
+// The pharmacies an owner can switch to: their home pharmacy and, if it
+// belongs to a group, every other branch of that group.
+func (q *Queries) ListUserBranches(ctx context.Context, id int64) ([]ListUserBranchesRow, error) {
+	rows, err := q.db.Query(ctx, listUserBranches, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserBranchesRow
+	for rows.Next() {
+		var i ListUserBranchesRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPharmacyGroup = `-- name: SetPharmacyGroup :exec
+UPDATE pharmacies
+SET group_id = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetPharmacyGroupParams struct {
+	ID      int64
+	GroupID pgtype.Int8
+}
+
+func (q *Queries) SetPharmacyGroup(ctx context.Context, arg SetPharmacyGroupParams) error {
+	_, err := q.db.Exec(ctx, setPharmacyGroup, arg.ID, arg.GroupID)
+	return err
+}
+
+const setPharmacyLogo = `-- name: SetPharmacyLogo :exec
+UPDATE pharmacies
+SET logo = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetPharmacyLogoParams struct {
+	ID   int64
+	Logo []byte
+}
+
+// A NULL logo removes it.
+func (q *Queries) SetPharmacyLogo(ctx context.Context, arg SetPharmacyLogoParams) error {
+	_, err := q.db.Exec(ctx, setPharmacyLogo, arg.ID, arg.Logo)
+	return err
+}
+
 const updatePharmacy = `-- name: UpdatePharmacy :exec
 UPDATE pharmacies
-SET name = $2, address = $3, phone = $4, email = $5, updated_at = now()
+SET name = $2, address = $3, phone = $4, email = $5, label_layout = $6, require_2fa = $7, locale = $8, updated_at = now()
 WHERE id = $1
 `
 
 type UpdatePharmacyParams struct {
-	ID      int64
-	Name    string
-	Address string
-	Phone   string
-	Email   string
+	ID          int64
+	Name        string
+	Address     string
+	Phone       string
+	Email       string
+	LabelLayout string
+	Require2fa  bool
+	Locale      string
 }
 
 func (q *Queries) UpdatePharmacy(ctx context.Context, arg UpdatePharmacyParams) error {
@@ -142,6 +357,55 @@ func (q *Queries) UpdatePharmacy(ctx context.Context, arg UpdatePharmacyParams)
 		arg.Address,
 		arg.Phone,
 		arg.Email,
+		arg.LabelLayout,
+		arg.Require2fa,
+		arg.Locale,
 	)
 	return err
 }
+
+const updatePharmacySettings = `-- name: UpdatePharmacySettings :exec
+UPDATE pharmacies
+SET address = $2, phone = $3, email = $4, vat_number = $5, default_fulfillment = $6,
+    lookahead_days = $7, pickup_reminder = $8, updated_at = now()
+WHERE id = $1
+`
+
+type UpdatePharmacySettingsParams struct {
+	ID                 int64
+	Address            string
+	Phone              string
+	Email              string
+	VatNumber          string
+	DefaultFulfillment string
+	LookaheadDays      int32
+	PickupReminder     string
+}
+
+func (q *Queries) UpdatePharmacySettings(ctx context.Context, arg UpdatePharmacySettingsParams) error {
+	_, err := q.db.Exec(ctx, updatePharmacySettings,
+		arg.ID,
+		arg.Address,
+		arg.Phone,
+		arg.Email,
+		arg.VatNumber,
+		arg.DefaultFulfillment,
+		arg.LookaheadDays,
+		arg.PickupReminder,
+	)
+	return err
+}
+
+const upsertPharmacyGroup = `-- name: UpsertPharmacyGroup :one
+INSERT INTO pharmacy_groups (name)
+VALUES ($1)
+ON CONFLICT (lower(name)) DO UPDATE SET name = pharmacy_groups.name
+RETURNING id
+`
+
+func (q *Queries) UpsertPharmacyGroup(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRow(ctx, upsertPharmacyGroup, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
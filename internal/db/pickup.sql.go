@@ -0,0 +1,361 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: pickup.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const assignPickupSlot = `-- name: AssignPickupSlot :exec
+UPDATE orders
+SET pickup_at = $1, pickup_confirmed = $2, pickup_notified_at = NULL, updated_at = now()
+WHERE id = $3
+`
+
+type AssignPickupSlotParams struct {
+	PickupAt        pgtype.Timestamp
+	PickupConfirmed bool
+	ID              int64
+}
+
+func (q *Queries) AssignPickupSlot(ctx context.Context, arg AssignPickupSlotParams) error {
+	_, err := q.db.Exec(ctx, assignPickupSlot, arg.PickupAt, arg.PickupConfirmed, arg.ID)
+	return err
+}
+
+const confirmPickupSlot = `-- name: ConfirmPickupSlot :exec
+UPDATE orders
+SET pickup_confirmed = true, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) ConfirmPickupSlot(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, confirmPickupSlot, id)
+	return err
+}
+
+const countPickupBookings = `-- name: CountPickupBookings :many
+SELECT o.pickup_at::TIMESTAMP AS pickup_at, COUNT(*)::BIGINT AS booked
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = $1::BIGINT
+  AND o.status <> 'fulfilled'
+  AND o.pickup_at >= $2::TIMESTAMP
+  AND o.pickup_at < $3::TIMESTAMP
+  AND o.id <> $4::BIGINT
+GROUP BY o.pickup_at
+`
+
+type CountPickupBookingsParams struct {
+	PharmacyID     int64
+	FromAt         pgtype.Timestamp
+	ToAt           pgtype.Timestamp
+	ExcludeOrderID int64
+}
+
+type CountPickupBookingsRow struct {
+	PickupAt pgtype.Timestamp
+	Booked   int64
+}
+
+func (q *Queries) CountPickupBookings(ctx context.Context, arg CountPickupBookingsParams) ([]CountPickupBookingsRow, error) {
+	rows, err := q.db.Query(ctx, countPickupBookings,
+		arg.PharmacyID,
+		arg.FromAt,
+		arg.ToAt,
+		arg.ExcludeOrderID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountPickupBookingsRow
+	for rows.Next() {
+		var i CountPickupBookingsRow
+		if err := rows.Scan(&i.PickupAt, &i.Booked); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteOpeningHours = `-- name: DeleteOpeningHours :exec
+DELETE FROM opening_hours WHERE pharmacy_id = $1
+`
+
+func (q *Queries) DeleteOpeningHours(ctx context.Context, pharmacyID int64) error {
+	_, err := q.db.Exec(ctx, deleteOpeningHours, pharmacyID)
+	return err
+}
+
+const getPickupOrder = `-- name: GetPickupOrder :one
+SELECT
+    o.id AS order_id,
+    o.status AS order_status,
+    o.estimated_depletion_date,
+    o.pickup_at,
+    o.pickup_confirmed,
+    p.medication_name,
+    pat.fulfillment,
+    pat.first_name,
+    pat.last_name,
+    pat.phone,
+    pat.email,
+    ph.name AS pharmacy_name,
+    ph.pickup_reminder
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+JOIN pharmacies ph ON ph.id = pat.pharmacy_id
+WHERE o.id = $1::BIGINT
+  AND pat.pharmacy_id = $2::BIGINT
+`
+
+type GetPickupOrderParams struct {
+	OrderID    int64
+	PharmacyID int64
+}
+
+type GetPickupOrderRow struct {
+	OrderID                int64
+	OrderStatus            string
+	EstimatedDepletionDate pgtype.Date
+	PickupAt               pgtype.Timestamp
+	PickupConfirmed        bool
+	MedicationName         string
+	Fulfillment            string
+	FirstName              string
+	LastName               string
+	Phone                  string
+	Email                  string
+	PharmacyName           string
+	PickupReminder         string
+}
+
+func (q *Queries) GetPickupOrder(ctx context.Context, arg GetPickupOrderParams) (GetPickupOrderRow, error) {
+	row := q.db.QueryRow(ctx, getPickupOrder, arg.OrderID, arg.PharmacyID)
+	var i GetPickupOrderRow
+	err := row.Scan(
+		&i.OrderID,
+		&i.OrderStatus,
+		&i.EstimatedDepletionDate,
+		&i.PickupAt,
+		&i.PickupConfirmed,
+		&i.MedicationName,
+		&i.Fulfillment,
+		&i.FirstName,
+		&i.LastName,
+		&i.Phone,
+		&i.Email,
+		&i.PharmacyName,
+		&i.PickupReminder,
+	)
+	return i, err
+}
+
+const getPickupSettings = `-- name: GetPickupSettings :one
+SELECT slot_minutes, slot_capacity, daily_capacity
+FROM pickup_settings
+WHERE pharmacy_id = $1
+`
+
+type GetPickupSettingsRow struct {
+	SlotMinutes   int32
+	SlotCapacity  int32
+	DailyCapacity int32
+}
+
+func (q *Queries) GetPickupSettings(ctx context.Context, pharmacyID int64) (GetPickupSettingsRow, error) {
+	row := q.db.QueryRow(ctx, getPickupSettings, pharmacyID)
+	var i GetPickupSettingsRow
+	err := row.Scan(&i.SlotMinutes, &i.SlotCapacity, &i.DailyCapacity)
+	return i, err
+}
+
+const insertOpeningHours = `-- name: InsertOpeningHours :exec
+INSERT INTO opening_hours (pharmacy_id, weekday, opens_at, closes_at)
+VALUES ($1, $2, $3, $4)
+`
+
+type InsertOpeningHoursParams struct {
+	PharmacyID int64
+	Weekday    int16
+	OpensAt    pgtype.Time
+	ClosesAt   pgtype.Time
+}
+
+func (q *Queries) InsertOpeningHours(ctx context.Context, arg InsertOpeningHoursParams) error {
+	_, err := q.db.Exec(ctx, insertOpeningHours,
+		arg.PharmacyID,
+		arg.Weekday,
+		arg.OpensAt,
+		arg.ClosesAt,
+	)
+	return err
+}
+
+const listOpeningHours = `-- name: ListOpeningHours :many
+SELECT weekday, opens_at, closes_at
+FROM opening_hours
+WHERE pharmacy_id = $1
+ORDER BY weekday, opens_at
+`
+
+type ListOpeningHoursRow struct {
+	Weekday  int16
+	OpensAt  pgtype.Time
+	ClosesAt pgtype.Time
+}
+
+func (q *Queries) ListOpeningHours(ctx context.Context, pharmacyID int64) ([]ListOpeningHoursRow, error) {
+	rows, err := q.db.Query(ctx, listOpeningHours, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpeningHoursRow
+	for rows.Next() {
+		var i ListOpeningHoursRow
+		if err := rows.Scan(&i.Weekday, &i.OpensAt, &i.ClosesAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPickupAppointments = `-- name: ListPickupAppointments :many
+SELECT
+    o.id AS order_id,
+    o.pickup_at::TIMESTAMP AS pickup_at,
+    o.pickup_confirmed,
+    o.pickup_notified_at,
+    o.status AS order_status,
+    p.medication_name,
+    pat.id AS patient_id,
+    pat.first_name,
+    pat.last_name,
+    pat.phone,
+    pat.email
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE pat.pharmacy_id = $1::BIGINT
+  AND o.pickup_at >= $2::TIMESTAMP
+  AND o.pickup_at < $3::TIMESTAMP
+ORDER BY o.pickup_at, pat.last_name, pat.first_name
+`
+
+type ListPickupAppointmentsParams struct {
+	PharmacyID int64
+	FromAt     pgtype.Timestamp
+	ToAt       pgtype.Timestamp
+}
+
+type ListPickupAppointmentsRow struct {
+	OrderID          int64
+	PickupAt         pgtype.Timestamp
+	PickupConfirmed  bool
+	PickupNotifiedAt pgtype.Timestamptz
+	OrderStatus      string
+	MedicationName   string
+	PatientID        int64
+	FirstName        string
+	LastName         string
+	Phone            string
+	Email            string
+}
+
+func (q *Queries) ListPickupAppointments(ctx context.Context, arg ListPickupAppointmentsParams) ([]ListPickupAppointmentsRow, error) {
+	rows, err := q.db.Query(ctx, listPickupAppointments, arg.PharmacyID, arg.FromAt, arg.ToAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPickupAppointmentsRow
+	for rows.Next() {
+		var i ListPickupAppointmentsRow
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.PickupAt,
+			&i.PickupConfirmed,
+			&i.PickupNotifiedAt,
+			&i.OrderStatus,
+			&i.MedicationName,
+			&i.PatientID,
+			&i.FirstName,
+			&i.LastName,
+			&i.Phone,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockPickupBookings = `-- name: LockPickupBookings :exec
+SELECT pg_advisory_xact_lock(hashtext('pickup_bookings'), ($1::BIGINT % 2147483647)::INTEGER)
+`
+
+// Serialises bookings at one pharmacy until the transaction ends, so a slot's
+// count cannot change between checking it and booking.
+func (q *Queries) LockPickupBookings(ctx context.Context, pharmacyID int64) error {
+	_, err := q.db.Exec(ctx, lockPickupBookings, pharmacyID)
+	return err
+}
+
+const markPickupNotified = `-- name: MarkPickupNotified :exec
+UPDATE orders
+SET pickup_notified_at = now(), updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkPickupNotified(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markPickupNotified, id)
+	return err
+}
+
+const upsertPickupSettings = `-- name: UpsertPickupSettings :exec
+INSERT INTO pickup_settings (pharmacy_id, slot_minutes, slot_capacity, daily_capacity)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (pharmacy_id) DO UPDATE
+SET slot_minutes = EXCLUDED.slot_minutes,
+    slot_capacity = EXCLUDED.slot_capacity,
+    daily_capacity = EXCLUDED.daily_capacity,
+    updated_at = now()
+`
+
+type UpsertPickupSettingsParams struct {
+	PharmacyID    int64
+	SlotMinutes   int32
+	SlotCapacity  int32
+	DailyCapacity int32
+}
+
+func (q *Queries) UpsertPickupSettings(ctx context.Context, arg UpsertPickupSettingsParams) error {
+	_, err := q.db.Exec(ctx, upsertPickupSettings,
+		arg.PharmacyID,
+		arg.SlotMinutes,
+		arg.SlotCapacity,
+		arg.DailyCapacity,
+	)
+	return err
+}
@@ -47,15 +47,62 @@ func (q *Queries) CreatePrescription(ctx context.Context, arg CreatePrescription
 	return i, err
 }
 
+const createStockReport = `-- name: CreateStockReport :exec
+INSERT INTO stock_reports (prescription_id, units, reported_on, source)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateStockReportParams struct {
+	PrescriptionID int64
+	Units          int32
+	ReportedOn     pgtype.Date
+	Source         string
+}
+
+func (q *Queries) CreateStockReport(ctx context.Context, arg CreateStockReportParams) error {
+	_, err := q.db.Exec(ctx, createStockReport,
+		arg.PrescriptionID,
+		arg.Units,
+		arg.ReportedOn,
+		arg.Source,
+	)
+	return err
+}
+
 const getPrescriptionByID = `-- name: GetPrescriptionByID :one
-SELECT id, patient_id, medication_name, units_per_box, daily_consumption, box_start_date, created_at, updated_at
-FROM prescriptions
-WHERE id = $1
+SELECT p.id, p.patient_id, p.medication_name, p.units_per_box, p.daily_consumption, p.box_start_date,
+    p.created_at, p.updated_at,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on,
+    COALESCE(sr.source, '')::TEXT AS report_source
+FROM prescriptions p
+LEFT JOIN LATERAL (
+    SELECT units, reported_on, source
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
+WHERE p.id = $1
 `
 
-func (q *Queries) GetPrescriptionByID(ctx context.Context, id int64) (Prescription, error) {
+type GetPrescriptionByIDRow struct {
+	ID               int64
+	PatientID        int64
+	MedicationName   string
+	UnitsPerBox      int32
+	DailyConsumption pgtype.Numeric
+	BoxStartDate     pgtype.Date
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	ReportedUnits    int32
+	ReportedOn       pgtype.Date
+	ReportSource     string
+}
+
+func (q *Queries) GetPrescriptionByID(ctx context.Context, id int64) (GetPrescriptionByIDRow, error) {
 	row := q.db.QueryRow(ctx, getPrescriptionByID, id)
-	var i Prescription
+	var i GetPrescriptionByIDRow
 	err := row.Scan(
 		&i.ID,
 		&i.PatientID,
@@ -65,6 +112,9 @@ func (q *Queries) GetPrescriptionByID(ctx context.Context, id int64) (Prescripti
 		&i.BoxStartDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ReportedUnits,
+		&i.ReportedOn,
+		&i.ReportSource,
 	)
 	return i, err
 }
@@ -86,21 +136,46 @@ func (q *Queries) InsertRefillHistory(ctx context.Context, arg InsertRefillHisto
 }
 
 const listPrescriptionsByPatient = `-- name: ListPrescriptionsByPatient :many
-SELECT id, patient_id, medication_name, units_per_box, daily_consumption, box_start_date, created_at, updated_at
-FROM prescriptions
-WHERE patient_id = $1
-ORDER BY medication_name
+SELECT p.id, p.patient_id, p.medication_name, p.units_per_box, p.daily_consumption, p.box_start_date,
+    p.created_at, p.updated_at,
+    COALESCE(sr.units, -1)::INTEGER AS reported_units,
+    sr.reported_on,
+    COALESCE(sr.source, '')::TEXT AS report_source
+FROM prescriptions p
+LEFT JOIN LATERAL (
+    SELECT units, reported_on, source
+    FROM stock_reports
+    WHERE prescription_id = p.id
+    ORDER BY reported_on DESC, id DESC
+    LIMIT 1
+) sr ON true
+WHERE p.patient_id = $1
+ORDER BY p.medication_name
 `
 
-func (q *Queries) ListPrescriptionsByPatient(ctx context.Context, patientID int64) ([]Prescription, error) {
+type ListPrescriptionsByPatientRow struct {
+	ID               int64
+	PatientID        int64
+	MedicationName   string
+	UnitsPerBox      int32
+	DailyConsumption pgtype.Numeric
+	BoxStartDate     pgtype.Date
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	ReportedUnits    int32
+	ReportedOn       pgtype.Date
+	ReportSource     string
+}
+
+func (q *Queries) ListPrescriptionsByPatient(ctx context.Context, patientID int64) ([]ListPrescriptionsByPatientRow, error) {
 	rows, err := q.db.Query(ctx, listPrescriptionsByPatient, patientID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Prescription
+	var items []ListPrescriptionsByPatientRow
 	for rows.Next() {
-		var i Prescription
+		var i ListPrescriptionsByPatientRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.PatientID,
@@ -110,6 +185,9 @@ func (q *Queries) ListPrescriptionsByPatient(ctx context.Context, patientID int6
 			&i.BoxStartDate,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ReportedUnits,
+			&i.ReportedOn,
+			&i.ReportSource,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,172 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: roles.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createPharmacyRole = `-- name: CreatePharmacyRole :one
+INSERT INTO pharmacy_roles (pharmacy_id, name, permissions)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+
+type CreatePharmacyRoleParams struct {
+	PharmacyID  int64
+	Name        string
+	Permissions []string
+}
+
+func (q *Queries) CreatePharmacyRole(ctx context.Context, arg CreatePharmacyRoleParams) (int64, error) {
+	row := q.db.QueryRow(ctx, createPharmacyRole, arg.PharmacyID, arg.Name, arg.Permissions)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deletePharmacyRole = `-- name: DeletePharmacyRole :execrows
+DELETE FROM pharmacy_roles
+WHERE id = $1 AND pharmacy_id = $2
+`
+
+type DeletePharmacyRoleParams struct {
+	ID         int64
+	PharmacyID int64
+}
+
+func (q *Queries) DeletePharmacyRole(ctx context.Context, arg DeletePharmacyRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePharmacyRole, arg.ID, arg.PharmacyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRoleHolderSessions = `-- name: DeleteRoleHolderSessions :exec
+WITH revoked AS (
+    DELETE FROM user_sessions us
+    USING users u
+    WHERE u.id = us.user_id AND u.custom_role_id = $1::BIGINT
+    RETURNING us.token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked)
+`
+
+// Logs out everyone holding the custom role: sessions carry the permissions
+// granted at login, so a change must not wait for them to expire.
+func (q *Queries) DeleteRoleHolderSessions(ctx context.Context, roleID int64) error {
+	_, err := q.db.Exec(ctx, deleteRoleHolderSessions, roleID)
+	return err
+}
+
+const getPharmacyRole = `-- name: GetPharmacyRole :one
+SELECT id, name, permissions
+FROM pharmacy_roles
+WHERE id = $1 AND pharmacy_id = $2
+`
+
+type GetPharmacyRoleParams struct {
+	ID         int64
+	PharmacyID int64
+}
+
+type GetPharmacyRoleRow struct {
+	ID          int64
+	Name        string
+	Permissions []string
+}
+
+func (q *Queries) GetPharmacyRole(ctx context.Context, arg GetPharmacyRoleParams) (GetPharmacyRoleRow, error) {
+	row := q.db.QueryRow(ctx, getPharmacyRole, arg.ID, arg.PharmacyID)
+	var i GetPharmacyRoleRow
+	err := row.Scan(&i.ID, &i.Name, &i.Permissions)
+	return i, err
+}
+
+const listPharmacyRoles = `-- name: ListPharmacyRoles :many
+SELECT r.id, r.name, r.permissions, COUNT(u.id) AS members
+FROM pharmacy_roles r
+LEFT JOIN users u ON u.custom_role_id = r.id
+WHERE r.pharmacy_id = $1
+GROUP BY r.id
+ORDER BY lower(r.name)
+`
+
+type ListPharmacyRolesRow struct {
+	ID          int64
+	Name        string
+	Permissions []string
+	Members     int64
+}
+
+func (q *Queries) ListPharmacyRoles(ctx context.Context, pharmacyID int64) ([]ListPharmacyRolesRow, error) {
+	rows, err := q.db.Query(ctx, listPharmacyRoles, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPharmacyRolesRow
+	for rows.Next() {
+		var i ListPharmacyRolesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Permissions,
+			&i.Members,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockPharmacyRole = `-- name: LockPharmacyRole :one
+SELECT id, name, permissions
+FROM pharmacy_roles
+WHERE id = $1 AND pharmacy_id = $2
+FOR UPDATE
+`
+
+type LockPharmacyRoleParams struct {
+	ID         int64
+	PharmacyID int64
+}
+
+type LockPharmacyRoleRow struct {
+	ID          int64
+	Name        string
+	Permissions []string
+}
+
+func (q *Queries) LockPharmacyRole(ctx context.Context, arg LockPharmacyRoleParams) (LockPharmacyRoleRow, error) {
+	row := q.db.QueryRow(ctx, lockPharmacyRole, arg.ID, arg.PharmacyID)
+	var i LockPharmacyRoleRow
+	err := row.Scan(&i.ID, &i.Name, &i.Permissions)
+	return i, err
+}
+
+const updatePharmacyRole = `-- name: UpdatePharmacyRole :exec
+UPDATE pharmacy_roles
+SET name = $2, permissions = $3, updated_at = now()
+WHERE id = $1
+`
+
+type UpdatePharmacyRoleParams struct {
+	ID          int64
+	Name        string
+	Permissions []string
+}
+
+func (q *Queries) UpdatePharmacyRole(ctx context.Context, arg UpdatePharmacyRoleParams) error {
+	_, err := q.db.Exec(ctx, updatePharmacyRole, arg.ID, arg.Name, arg.Permissions)
+	return err
+}
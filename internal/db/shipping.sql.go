@@ -0,0 +1,410 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: shipping.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addShipmentsToBatch = `-- name: AddShipmentsToBatch :execrows
+INSERT INTO shipments (batch_id, order_id)
+SELECT $1::BIGINT, o.id
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN shipments s ON s.order_id = o.id
+WHERE o.id = ANY($2::BIGINT[])
+  AND pat.pharmacy_id = $3::BIGINT
+  AND pat.fulfillment = 'shipping'
+  AND o.status = 'prepared'
+  AND s.id IS NULL
+`
+
+type AddShipmentsToBatchParams struct {
+	BatchID    int64
+	OrderIds   []int64
+	PharmacyID int64
+}
+
+func (q *Queries) AddShipmentsToBatch(ctx context.Context, arg AddShipmentsToBatchParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addShipmentsToBatch, arg.BatchID, arg.OrderIds, arg.PharmacyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createShippingBatch = `-- name: CreateShippingBatch :one
+INSERT INTO shipping_batches (pharmacy_id)
+VALUES ($1)
+RETURNING id, pharmacy_id, status, shipped_at, created_at, updated_at
+`
+
+func (q *Queries) CreateShippingBatch(ctx context.Context, pharmacyID int64) (ShippingBatch, error) {
+	row := q.db.QueryRow(ctx, createShippingBatch, pharmacyID)
+	var i ShippingBatch
+	err := row.Scan(
+		&i.ID,
+		&i.PharmacyID,
+		&i.Status,
+		&i.ShippedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getShippingBatch = `-- name: GetShippingBatch :one
+SELECT
+    b.id,
+    b.pharmacy_id,
+    b.status,
+    b.shipped_at,
+    b.created_at,
+    COUNT(s.id)::BIGINT AS parcel_count,
+    COUNT(s.id) FILTER (WHERE s.status = 'delivered')::BIGINT AS delivered_count
+FROM shipping_batches b
+LEFT JOIN shipments s ON s.batch_id = b.id
+WHERE b.id = $1::BIGINT
+  AND b.pharmacy_id = $2::BIGINT
+GROUP BY b.id
+`
+
+type GetShippingBatchParams struct {
+	ID         int64
+	PharmacyID int64
+}
+
+type GetShippingBatchRow struct {
+	ID             int64
+	PharmacyID     int64
+	Status         string
+	ShippedAt      pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+	ParcelCount    int64
+	DeliveredCount int64
+}
+
+func (q *Queries) GetShippingBatch(ctx context.Context, arg GetShippingBatchParams) (GetShippingBatchRow, error) {
+	row := q.db.QueryRow(ctx, getShippingBatch, arg.ID, arg.PharmacyID)
+	var i GetShippingBatchRow
+	err := row.Scan(
+		&i.ID,
+		&i.PharmacyID,
+		&i.Status,
+		&i.ShippedAt,
+		&i.CreatedAt,
+		&i.ParcelCount,
+		&i.DeliveredCount,
+	)
+	return i, err
+}
+
+const listBatchShipments = `-- name: ListBatchShipments :many
+SELECT
+    s.order_id,
+    s.batch_id,
+    s.tracking_number,
+    s.status,
+    p.medication_name,
+    pat.first_name,
+    pat.last_name,
+    pat.phone,
+    pat.email,
+    pat.delivery_street,
+    pat.delivery_house_number,
+    pat.delivery_cap,
+    pat.delivery_city,
+    pat.delivery_province,
+    pat.delivery_country,
+    pat.delivery_notes,
+    pat.delivery_address_legacy
+FROM shipments s
+JOIN shipping_batches b ON s.batch_id = b.id
+JOIN orders o ON s.order_id = o.id
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+WHERE s.batch_id = $1::BIGINT
+  AND b.pharmacy_id = $2::BIGINT
+ORDER BY pat.last_name, pat.first_name, s.order_id
+`
+
+type ListBatchShipmentsParams struct {
+	BatchID    int64
+	PharmacyID int64
+}
+
+type ListBatchShipmentsRow struct {
+	OrderID               int64
+	BatchID               int64
+	TrackingNumber        string
+	Status                string
+	MedicationName        string
+	FirstName             string
+	LastName              string
+	Phone                 string
+	Email                 string
+	DeliveryStreet        string
+	DeliveryHouseNumber   string
+	DeliveryCap           string
+	DeliveryCity          string
+	DeliveryProvince      string
+	DeliveryCountry       string
+	DeliveryNotes         string
+	DeliveryAddressLegacy string
+}
+
+func (q *Queries) ListBatchShipments(ctx context.Context, arg ListBatchShipmentsParams) ([]ListBatchShipmentsRow, error) {
+	rows, err := q.db.Query(ctx, listBatchShipments, arg.BatchID, arg.PharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBatchShipmentsRow
+	for rows.Next() {
+		var i ListBatchShipmentsRow
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.BatchID,
+			&i.TrackingNumber,
+			&i.Status,
+			&i.MedicationName,
+			&i.FirstName,
+			&i.LastName,
+			&i.Phone,
+			&i.Email,
+			&i.DeliveryStreet,
+			&i.DeliveryHouseNumber,
+			&i.DeliveryCap,
+			&i.DeliveryCity,
+			&i.DeliveryProvince,
+			&i.DeliveryCountry,
+			&i.DeliveryNotes,
+			&i.DeliveryAddressLegacy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShippableOrders = `-- name: ListShippableOrders :many
+SELECT
+    o.id AS order_id,
+    o.estimated_depletion_date,
+    p.medication_name,
+    pat.first_name,
+    pat.last_name,
+    pat.delivery_street,
+    pat.delivery_house_number,
+    pat.delivery_cap,
+    pat.delivery_city,
+    pat.delivery_province,
+    pat.delivery_country,
+    pat.delivery_notes,
+    pat.delivery_address_legacy
+FROM orders o
+JOIN prescriptions p ON o.prescription_id = p.id
+JOIN patients pat ON p.patient_id = pat.id
+LEFT JOIN shipments s ON s.order_id = o.id
+WHERE pat.pharmacy_id = $1::BIGINT
+  AND pat.fulfillment = 'shipping'
+  AND o.status = 'prepared'
+  AND s.id IS NULL
+ORDER BY pat.last_name, pat.first_name, o.estimated_depletion_date
+`
+
+type ListShippableOrdersRow struct {
+	OrderID                int64
+	EstimatedDepletionDate pgtype.Date
+	MedicationName         string
+	FirstName              string
+	LastName               string
+	DeliveryStreet         string
+	DeliveryHouseNumber    string
+	DeliveryCap            string
+	DeliveryCity           string
+	DeliveryProvince       string
+	DeliveryCountry        string
+	DeliveryNotes          string
+	DeliveryAddressLegacy  string
+}
+
+func (q *Queries) ListShippableOrders(ctx context.Context, pharmacyID int64) ([]ListShippableOrdersRow, error) {
+	rows, err := q.db.Query(ctx, listShippableOrders, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListShippableOrdersRow
+	for rows.Next() {
+		var i ListShippableOrdersRow
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.EstimatedDepletionDate,
+			&i.MedicationName,
+			&i.FirstName,
+			&i.LastName,
+			&i.DeliveryStreet,
+			&i.DeliveryHouseNumber,
+			&i.DeliveryCap,
+			&i.DeliveryCity,
+			&i.DeliveryProvince,
+			&i.DeliveryCountry,
+			&i.DeliveryNotes,
+			&i.DeliveryAddressLegacy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShippingBatches = `-- name: ListShippingBatches :many
+SELECT
+    b.id,
+    b.pharmacy_id,
+    b.status,
+    b.shipped_at,
+    b.created_at,
+    COUNT(s.id)::BIGINT AS parcel_count,
+    COUNT(s.id) FILTER (WHERE s.status = 'delivered')::BIGINT AS delivered_count
+FROM shipping_batches b
+LEFT JOIN shipments s ON s.batch_id = b.id
+WHERE b.pharmacy_id = $1::BIGINT
+GROUP BY b.id
+ORDER BY b.created_at DESC
+`
+
+type ListShippingBatchesRow struct {
+	ID             int64
+	PharmacyID     int64
+	Status         string
+	ShippedAt      pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+	ParcelCount    int64
+	DeliveredCount int64
+}
+
+func (q *Queries) ListShippingBatches(ctx context.Context, pharmacyID int64) ([]ListShippingBatchesRow, error) {
+	rows, err := q.db.Query(ctx, listShippingBatches, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListShippingBatchesRow
+	for rows.Next() {
+		var i ListShippingBatchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PharmacyID,
+			&i.Status,
+			&i.ShippedAt,
+			&i.CreatedAt,
+			&i.ParcelCount,
+			&i.DeliveredCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markBatchShipmentsShipped = `-- name: MarkBatchShipmentsShipped :exec
+UPDATE shipments
+SET status = 'shipped', shipped_at = now(), updated_at = now()
+WHERE batch_id = $1 AND status = 'ready'
+`
+
+func (q *Queries) MarkBatchShipmentsShipped(ctx context.Context, batchID int64) error {
+	_, err := q.db.Exec(ctx, markBatchShipmentsShipped, batchID)
+	return err
+}
+
+const markShipmentDelivered = `-- name: MarkShipmentDelivered :execrows
+UPDATE shipments s
+SET status = 'delivered', delivered_at = now(), updated_at = now()
+FROM shipping_batches b
+WHERE s.batch_id = b.id
+  AND s.order_id = $1::BIGINT
+  AND b.pharmacy_id = $2::BIGINT
+  AND s.status = 'shipped'
+`
+
+type MarkShipmentDeliveredParams struct {
+	OrderID    int64
+	PharmacyID int64
+}
+
+func (q *Queries) MarkShipmentDelivered(ctx context.Context, arg MarkShipmentDeliveredParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markShipmentDelivered, arg.OrderID, arg.PharmacyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markShippingBatchShipped = `-- name: MarkShippingBatchShipped :execrows
+UPDATE shipping_batches
+SET status = 'shipped', shipped_at = now(), updated_at = now()
+WHERE id = $1::BIGINT
+  AND pharmacy_id = $2::BIGINT
+  AND status = 'open'
+`
+
+type MarkShippingBatchShippedParams struct {
+	ID         int64
+	PharmacyID int64
+}
+
+func (q *Queries) MarkShippingBatchShipped(ctx context.Context, arg MarkShippingBatchShippedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markShippingBatchShipped, arg.ID, arg.PharmacyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateShipmentTracking = `-- name: UpdateShipmentTracking :exec
+UPDATE shipments s
+SET tracking_number = $1, updated_at = now()
+FROM shipping_batches b
+WHERE s.batch_id = b.id
+  AND s.batch_id = $2::BIGINT
+  AND s.order_id = $3::BIGINT
+  AND b.pharmacy_id = $4::BIGINT
+`
+
+type UpdateShipmentTrackingParams struct {
+	TrackingNumber string
+	BatchID        int64
+	OrderID        int64
+	PharmacyID     int64
+}
+
+func (q *Queries) UpdateShipmentTracking(ctx context.Context, arg UpdateShipmentTrackingParams) error {
+	_, err := q.db.Exec(ctx, updateShipmentTracking,
+		arg.TrackingNumber,
+		arg.BatchID,
+		arg.OrderID,
+		arg.PharmacyID,
+	)
+	return err
+}
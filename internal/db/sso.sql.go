@@ -0,0 +1,82 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: sso.sql
+
+package db
+
+import (
+	"context"
+)
+
+const getPharmacySSO = `-- name: GetPharmacySSO :one
+SELECT pharmacy_id, enabled, issuer, client_id, client_secret, role_claim, owner_value, personnel_value
+FROM pharmacy_sso
+WHERE pharmacy_id = $1
+`
+
+type GetPharmacySSORow struct {
+	PharmacyID     int64
+	Enabled        bool
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RoleClaim      string
+	OwnerValue     string
+	PersonnelValue string
+}
+
+func (q *Queries) GetPharmacySSO(ctx context.Context, pharmacyID int64) (GetPharmacySSORow, error) {
+	row := q.db.QueryRow(ctx, getPharmacySSO, pharmacyID)
+	var i GetPharmacySSORow
+	err := row.Scan(
+		&i.PharmacyID,
+		&i.Enabled,
+		&i.Issuer,
+		&i.ClientID,
+		&i.ClientSecret,
+		&i.RoleClaim,
+		&i.OwnerValue,
+		&i.PersonnelValue,
+	)
+	return i, err
+}
+
+const upsertPharmacySSO = `-- name: UpsertPharmacySSO :exec
+INSERT INTO pharmacy_sso (pharmacy_id, enabled, issuer, client_id, client_secret, role_claim, owner_value, personnel_value)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (pharmacy_id) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    issuer = EXCLUDED.issuer,
+    client_id = EXCLUDED.client_id,
+    client_secret = EXCLUDED.client_secret,
+    role_claim = EXCLUDED.role_claim,
+    owner_value = EXCLUDED.owner_value,
+    personnel_value = EXCLUDED.personnel_value,
+    updated_at = now()
+`
+
+type UpsertPharmacySSOParams struct {
+	PharmacyID     int64
+	Enabled        bool
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RoleClaim      string
+	OwnerValue     string
+	PersonnelValue string
+}
+
+func (q *Queries) UpsertPharmacySSO(ctx context.Context, arg UpsertPharmacySSOParams) error {
+	_, err := q.db.Exec(ctx, upsertPharmacySSO,
+		arg.PharmacyID,
+		arg.Enabled,
+		arg.Issuer,
+		arg.ClientID,
+		arg.ClientSecret,
+		arg.RoleClaim,
+		arg.OwnerValue,
+		arg.PersonnelValue,
+	)
+	return err
+}
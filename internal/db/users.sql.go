@@ -11,10 +11,151 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const consumePasswordResetToken = `-- name: ConsumePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = $1::TIMESTAMPTZ
+WHERE token_hash = $2
+  AND used_at IS NULL
+  AND expires_at > $1::TIMESTAMPTZ
+RETURNING user_id
+`
+
+type ConsumePasswordResetTokenParams struct {
+	Now       pgtype.Timestamptz
+	TokenHash []byte
+}
+
+func (q *Queries) ConsumePasswordResetToken(ctx context.Context, arg ConsumePasswordResetTokenParams) (int64, error) {
+	row := q.db.QueryRow(ctx, consumePasswordResetToken, arg.Now, arg.TokenHash)
+	var user_id int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const countRecentFailedLoginsByIP = `-- name: CountRecentFailedLoginsByIP :one
+SELECT count(*)::INT
+FROM login_events
+WHERE ip = $1 AND NOT success AND created_at > $2::TIMESTAMPTZ
+`
+
+type CountRecentFailedLoginsByIPParams struct {
+	Ip    string
+	Since pgtype.Timestamptz
+}
+
+func (q *Queries) CountRecentFailedLoginsByIP(ctx context.Context, arg CountRecentFailedLoginsByIPParams) (int32, error) {
+	row := q.db.QueryRow(ctx, countRecentFailedLoginsByIP, arg.Ip, arg.Since)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const countRecentPasswordResetTokens = `-- name: CountRecentPasswordResetTokens :one
+SELECT count(*) FROM password_reset_tokens
+WHERE user_id = $1 AND created_at >= $2::TIMESTAMPTZ
+`
+
+type CountRecentPasswordResetTokensParams struct {
+	UserID int64
+	Since  pgtype.Timestamptz
+}
+
+func (q *Queries) CountRecentPasswordResetTokens(ctx context.Context, arg CountRecentPasswordResetTokensParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecentPasswordResetTokens, arg.UserID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnusedRecoveryCodes = `-- name: CountUnusedRecoveryCodes :one
+SELECT count(*) FROM user_recovery_codes
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, countUnusedRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createFederatedUser = `-- name: CreateFederatedUser :one
+INSERT INTO users (email, password_hash, name, role, pharmacy_id, oidc_issuer, oidc_subject)
+VALUES ($1, '', $2, $3, $4::BIGINT, $5::TEXT, $6::TEXT)
+RETURNING id
+`
+
+type CreateFederatedUserParams struct {
+	Email      string
+	Name       string
+	Role       string
+	PharmacyID int64
+	Issuer     string
+	Subject    string
+}
+
+// Provisions a user signing in through their pharmacy's identity provider.
+// The empty password hash never matches a password login.
+func (q *Queries) CreateFederatedUser(ctx context.Context, arg CreateFederatedUserParams) (int64, error) {
+	row := q.db.QueryRow(ctx, createFederatedUser,
+		arg.Email,
+		arg.Name,
+		arg.Role,
+		arg.PharmacyID,
+		arg.Issuer,
+		arg.Subject,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createLoginEvent = `-- name: CreateLoginEvent :exec
+INSERT INTO login_events (user_id, email, ip, user_agent, success, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateLoginEventParams struct {
+	UserID    pgtype.Int8
+	Email     string
+	Ip        string
+	UserAgent string
+	Success   bool
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreateLoginEvent(ctx context.Context, arg CreateLoginEventParams) error {
+	_, err := q.db.Exec(ctx, createLoginEvent,
+		arg.UserID,
+		arg.Email,
+		arg.Ip,
+		arg.UserAgent,
+		arg.Success,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :exec
+INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+`
+
+type CreatePasswordResetTokenParams struct {
+	UserID    int64
+	TokenHash []byte
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error {
+	_, err := q.db.Exec(ctx, createPasswordResetToken, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (email, password_hash, name, role, pharmacy_id)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, email, password_hash, name, role, pharmacy_id, created_at, updated_at
+INSERT INTO users (email, password_hash, name, role, pharmacy_id, custom_role_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, email, password_hash, name, role, pharmacy_id, custom_role_id, created_at, updated_at
 `
 
 type CreateUserParams struct {
@@ -23,17 +164,31 @@ type CreateUserParams struct {
 	Name         string
 	Role         string
 	PharmacyID   pgtype.Int8
+	CustomRoleID pgtype.Int8
+}
+
+type CreateUserRow struct {
+	ID           int64
+	Email        string
+	PasswordHash string
+	Name         string
+	Role         string
+	PharmacyID   pgtype.Int8
+	CustomRoleID pgtype.Int8
+	CreatedAt    pgtype.Timestamptz
+	UpdatedAt    pgtype.Timestamptz
 }
 
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
 	row := q.db.QueryRow(ctx, createUser,
 		arg.Email,
 		arg.PasswordHash,
 		arg.Name,
 		arg.Role,
 		arg.PharmacyID,
+		arg.CustomRoleID,
 	)
-	var i User
+	var i CreateUserRow
 	err := row.Scan(
 		&i.ID,
 		&i.Email,
@@ -41,21 +196,236 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Name,
 		&i.Role,
 		&i.PharmacyID,
+		&i.CustomRoleID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+const createUserRecoveryCode = `-- name: CreateUserRecoveryCode :exec
+INSERT INTO user_recovery_codes (user_id, code_hash)
+VALUES ($1, $2)
+`
+
+type CreateUserRecoveryCodeParams struct {
+	UserID   int64
+	CodeHash []byte
+}
+
+func (q *Queries) CreateUserRecoveryCode(ctx context.Context, arg CreateUserRecoveryCodeParams) error {
+	_, err := q.db.Exec(ctx, createUserRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
+const deleteOtherUserSessions = `-- name: DeleteOtherUserSessions :execrows
+WITH revoked AS (
+    DELETE FROM user_sessions
+    WHERE user_id = $1::BIGINT AND token <> $2::TEXT
+    RETURNING token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked)
+`
+
+type DeleteOtherUserSessionsParams struct {
+	UserID    int64
+	KeepToken string
+}
+
+// Logs the user out everywhere except the session holding keep_token.
+func (q *Queries) DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOtherUserSessions, arg.UserID, arg.KeepToken)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteUser, id)
+	return err
+}
+
+const deleteUserRecoveryCodes = `-- name: DeleteUserRecoveryCodes :exec
+DELETE FROM user_recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserRecoveryCodes(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteUserRecoveryCodes, userID)
+	return err
+}
+
+const deleteUserSession = `-- name: DeleteUserSession :execrows
+WITH revoked AS (
+    DELETE FROM user_sessions
+    WHERE id = $1 AND user_id = $2::BIGINT
+    RETURNING token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked)
+`
+
+type DeleteUserSessionParams struct {
+	ID     int64
+	UserID int64
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUserSessions = `-- name: DeleteUserSessions :exec
+WITH revoked AS (
+    DELETE FROM user_sessions
+    WHERE user_id = $1
+    RETURNING token
+)
+DELETE FROM sessions
+WHERE token IN (SELECT token FROM revoked)
+`
+
+// Logs the user out everywhere: removes their rows from the scs store.
+func (q *Queries) DeleteUserSessions(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteUserSessions, userID)
+	return err
+}
+
+const disableUserTOTP = `-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, disableUserTOTP, id)
+	return err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_secret = $1::TEXT, totp_enabled_at = now(), totp_last_step = $2, updated_at = now()
+WHERE id = $3
+`
+
+type EnableUserTOTPParams struct {
+	TotpSecret string
+	Step       int64
+	ID         int64
+}
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) error {
+	_, err := q.db.Exec(ctx, enableUserTOTP, arg.TotpSecret, arg.Step, arg.ID)
+	return err
+}
+
+const expirePasswordResetTokens = `-- name: ExpirePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = $2::TIMESTAMPTZ
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+type ExpirePasswordResetTokensParams struct {
+	UserID int64
+	Now    pgtype.Timestamptz
+}
+
+// Once the password is reset, the other links still in the mailbox stop working.
+func (q *Queries) ExpirePasswordResetTokens(ctx context.Context, arg ExpirePasswordResetTokensParams) error {
+	_, err := q.db.Exec(ctx, expirePasswordResetTokens, arg.UserID, arg.Now)
+	return err
+}
+
+const getPharmacyUser = `-- name: GetPharmacyUser :one
+SELECT u.id, u.email, u.name, u.role, u.custom_role_id, COALESCE(r.name, '')::TEXT AS custom_role_name,
+    u.active, u.must_change_password, (u.totp_enabled_at IS NOT NULL)::BOOLEAN AS two_factor_enabled, u.locked_until,
+    (u.oidc_issuer IS NOT NULL)::BOOLEAN AS federated
+FROM users u
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.id = $1 AND u.pharmacy_id = $2::BIGINT
+`
+
+type GetPharmacyUserParams struct {
+	ID         int64
+	PharmacyID int64
+}
+
+type GetPharmacyUserRow struct {
+	ID                 int64
+	Email              string
+	Name               string
+	Role               string
+	CustomRoleID       pgtype.Int8
+	CustomRoleName     string
+	Active             bool
+	MustChangePassword bool
+	TwoFactorEnabled   bool
+	LockedUntil        pgtype.Timestamptz
+	Federated          bool
+}
+
+func (q *Queries) GetPharmacyUser(ctx context.Context, arg GetPharmacyUserParams) (GetPharmacyUserRow, error) {
+	row := q.db.QueryRow(ctx, getPharmacyUser, arg.ID, arg.PharmacyID)
+	var i GetPharmacyUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.Role,
+		&i.CustomRoleID,
+		&i.CustomRoleName,
+		&i.Active,
+		&i.MustChangePassword,
+		&i.TwoFactorEnabled,
+		&i.LockedUntil,
+		&i.Federated,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, name, role, pharmacy_id, created_at, updated_at
-FROM users
-WHERE email = $1
+SELECT u.id, u.email, u.password_hash, u.name, u.role, u.pharmacy_id, u.active, u.must_change_password,
+    u.totp_enabled_at, u.locked_until, COALESCE(p.require_2fa, false)::BOOLEAN AS pharmacy_requires_2fa,
+    COALESCE(r.permissions, '{}')::TEXT[] AS custom_permissions,
+    u.locale, COALESCE(p.locale, '')::TEXT AS pharmacy_locale,
+    (u.oidc_issuer IS NOT NULL)::BOOLEAN AS federated
+FROM users u
+LEFT JOIN pharmacies p ON p.id = u.pharmacy_id
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.email = $1
 `
 
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+type GetUserByEmailRow struct {
+	ID                  int64
+	Email               string
+	PasswordHash        string
+	Name                string
+	Role                string
+	PharmacyID          pgtype.Int8
+	Active              bool
+	MustChangePassword  bool
+	TotpEnabledAt       pgtype.Timestamptz
+	LockedUntil         pgtype.Timestamptz
+	PharmacyRequires2fa bool
+	CustomPermissions   []string
+	Locale              string
+	PharmacyLocale      string
+	Federated           bool
+}
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
 	row := q.db.QueryRow(ctx, getUserByEmail, email)
-	var i User
+	var i GetUserByEmailRow
 	err := row.Scan(
 		&i.ID,
 		&i.Email,
@@ -63,21 +433,52 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Name,
 		&i.Role,
 		&i.PharmacyID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.Active,
+		&i.MustChangePassword,
+		&i.TotpEnabledAt,
+		&i.LockedUntil,
+		&i.PharmacyRequires2fa,
+		&i.CustomPermissions,
+		&i.Locale,
+		&i.PharmacyLocale,
+		&i.Federated,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password_hash, name, role, pharmacy_id, created_at, updated_at
-FROM users
-WHERE id = $1
+SELECT u.id, u.email, u.password_hash, u.name, u.role, u.pharmacy_id, u.active, u.must_change_password,
+    u.totp_enabled_at, u.locked_until, COALESCE(p.require_2fa, false)::BOOLEAN AS pharmacy_requires_2fa,
+    COALESCE(r.permissions, '{}')::TEXT[] AS custom_permissions,
+    u.locale, COALESCE(p.locale, '')::TEXT AS pharmacy_locale,
+    (u.oidc_issuer IS NOT NULL)::BOOLEAN AS federated
+FROM users u
+LEFT JOIN pharmacies p ON p.id = u.pharmacy_id
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.id = $1
 `
 
-func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
+type GetUserByIDRow struct {
+	ID                  int64
+	Email               string
+	PasswordHash        string
+	Name                string
+	Role                string
+	PharmacyID          pgtype.Int8
+	Active              bool
+	MustChangePassword  bool
+	TotpEnabledAt       pgtype.Timestamptz
+	LockedUntil         pgtype.Timestamptz
+	PharmacyRequires2fa bool
+	CustomPermissions   []string
+	Locale              string
+	PharmacyLocale      string
+	Federated           bool
+}
+
+func (q *Queries) GetUserByID(ctx context.Context, id int64) (GetUserByIDRow, error) {
 	row := q.db.QueryRow(ctx, getUserByID, id)
-	var i User
+	var i GetUserByIDRow
 	err := row.Scan(
 		&i.ID,
 		&i.Email,
@@ -85,37 +486,249 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.Name,
 		&i.Role,
 		&i.PharmacyID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.Active,
+		&i.MustChangePassword,
+		&i.TotpEnabledAt,
+		&i.LockedUntil,
+		&i.PharmacyRequires2fa,
+		&i.CustomPermissions,
+		&i.Locale,
+		&i.PharmacyLocale,
+		&i.Federated,
 	)
 	return i, err
 }
 
-const listUsersByPharmacy = `-- name: ListUsersByPharmacy :many
-SELECT id, email, password_hash, name, role, pharmacy_id, created_at, updated_at
+const getUserByIdentity = `-- name: GetUserByIdentity :one
+SELECT u.id, u.email, u.password_hash, u.name, u.role, u.pharmacy_id, u.active, u.must_change_password,
+    u.totp_enabled_at, u.locked_until, COALESCE(p.require_2fa, false)::BOOLEAN AS pharmacy_requires_2fa,
+    COALESCE(r.permissions, '{}')::TEXT[] AS custom_permissions,
+    u.locale, COALESCE(p.locale, '')::TEXT AS pharmacy_locale,
+    (u.oidc_issuer IS NOT NULL)::BOOLEAN AS federated
+FROM users u
+LEFT JOIN pharmacies p ON p.id = u.pharmacy_id
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.oidc_issuer = $1::TEXT AND u.oidc_subject = $2::TEXT
+`
+
+type GetUserByIdentityParams struct {
+	Issuer  string
+	Subject string
+}
+
+type GetUserByIdentityRow struct {
+	ID                  int64
+	Email               string
+	PasswordHash        string
+	Name                string
+	Role                string
+	PharmacyID          pgtype.Int8
+	Active              bool
+	MustChangePassword  bool
+	TotpEnabledAt       pgtype.Timestamptz
+	LockedUntil         pgtype.Timestamptz
+	PharmacyRequires2fa bool
+	CustomPermissions   []string
+	Locale              string
+	PharmacyLocale      string
+	Federated           bool
+}
+
+func (q *Queries) GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (GetUserByIdentityRow, error) {
+	row := q.db.QueryRow(ctx, getUserByIdentity, arg.Issuer, arg.Subject)
+	var i GetUserByIdentityRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.Role,
+		&i.PharmacyID,
+		&i.Active,
+		&i.MustChangePassword,
+		&i.TotpEnabledAt,
+		&i.LockedUntil,
+		&i.PharmacyRequires2fa,
+		&i.CustomPermissions,
+		&i.Locale,
+		&i.PharmacyLocale,
+		&i.Federated,
+	)
+	return i, err
+}
+
+const getUserTOTP = `-- name: GetUserTOTP :one
+SELECT totp_secret::TEXT AS totp_secret, totp_last_step
 FROM users
-WHERE pharmacy_id = $1::BIGINT
-ORDER BY name
+WHERE id = $1 AND totp_secret IS NOT NULL
+`
+
+type GetUserTOTPRow struct {
+	TotpSecret   string
+	TotpLastStep int64
+}
+
+func (q *Queries) GetUserTOTP(ctx context.Context, id int64) (GetUserTOTPRow, error) {
+	row := q.db.QueryRow(ctx, getUserTOTP, id)
+	var i GetUserTOTPRow
+	err := row.Scan(&i.TotpSecret, &i.TotpLastStep)
+	return i, err
+}
+
+const incrementFailedLogins = `-- name: IncrementFailedLogins :one
+UPDATE users
+SET failed_logins = failed_logins + 1
+WHERE id = $1
+RETURNING failed_logins
+`
+
+func (q *Queries) IncrementFailedLogins(ctx context.Context, id int64) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementFailedLogins, id)
+	var failed_logins int32
+	err := row.Scan(&failed_logins)
+	return failed_logins, err
+}
+
+const listLoginEventsByUser = `-- name: ListLoginEventsByUser :many
+SELECT id, ip, user_agent, success, created_at
+FROM login_events
+WHERE user_id = $1::BIGINT
+ORDER BY created_at DESC
+LIMIT $2::INT
+`
+
+type ListLoginEventsByUserParams struct {
+	UserID    int64
+	MaxEvents int32
+}
+
+type ListLoginEventsByUserRow struct {
+	ID        int64
+	Ip        string
+	UserAgent string
+	Success   bool
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) ListLoginEventsByUser(ctx context.Context, arg ListLoginEventsByUserParams) ([]ListLoginEventsByUserRow, error) {
+	rows, err := q.db.Query(ctx, listLoginEventsByUser, arg.UserID, arg.MaxEvents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLoginEventsByUserRow
+	for rows.Next() {
+		var i ListLoginEventsByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Ip,
+			&i.UserAgent,
+			&i.Success,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT us.id, us.ip, us.user_agent, us.created_at, us.last_seen_at,
+    (us.token = $1::TEXT)::BOOLEAN AS current
+FROM user_sessions us
+JOIN sessions s ON s.token = us.token
+WHERE us.user_id = $2::BIGINT AND s.expiry > now()
+ORDER BY us.last_seen_at DESC
 `
 
-func (q *Queries) ListUsersByPharmacy(ctx context.Context, pharmacyID int64) ([]User, error) {
+type ListUserSessionsParams struct {
+	CurrentToken string
+	UserID       int64
+}
+
+type ListUserSessionsRow struct {
+	ID         int64
+	Ip         string
+	UserAgent  string
+	CreatedAt  pgtype.Timestamptz
+	LastSeenAt pgtype.Timestamptz
+	Current    bool
+}
+
+// Only sessions still alive in the scs store; current marks the caller's.
+func (q *Queries) ListUserSessions(ctx context.Context, arg ListUserSessionsParams) ([]ListUserSessionsRow, error) {
+	rows, err := q.db.Query(ctx, listUserSessions, arg.CurrentToken, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserSessionsRow
+	for rows.Next() {
+		var i ListUserSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Ip,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.Current,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByPharmacy = `-- name: ListUsersByPharmacy :many
+SELECT u.id, u.email, u.name, u.role, u.custom_role_id, COALESCE(r.name, '')::TEXT AS custom_role_name,
+    u.active, u.must_change_password, (u.totp_enabled_at IS NOT NULL)::BOOLEAN AS two_factor_enabled, u.locked_until
+FROM users u
+LEFT JOIN pharmacy_roles r ON r.id = u.custom_role_id
+WHERE u.pharmacy_id = $1::BIGINT
+ORDER BY u.name
+`
+
+type ListUsersByPharmacyRow struct {
+	ID                 int64
+	Email              string
+	Name               string
+	Role               string
+	CustomRoleID       pgtype.Int8
+	CustomRoleName     string
+	Active             bool
+	MustChangePassword bool
+	TwoFactorEnabled   bool
+	LockedUntil        pgtype.Timestamptz
+}
+
+func (q *Queries) ListUsersByPharmacy(ctx context.Context, pharmacyID int64) ([]ListUsersByPharmacyRow, error) {
 	rows, err := q.db.Query(ctx, listUsersByPharmacy, pharmacyID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []User
+	var items []ListUsersByPharmacyRow
 	for rows.Next() {
-		var i User
+		var i ListUsersByPharmacyRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Email,
-			&i.PasswordHash,
 			&i.Name,
 			&i.Role,
-			&i.PharmacyID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
+			&i.CustomRoleID,
+			&i.CustomRoleName,
+			&i.Active,
+			&i.MustChangePassword,
+			&i.TwoFactorEnabled,
+			&i.LockedUntil,
 		); err != nil {
 			return nil, err
 		}
@@ -127,9 +740,225 @@ func (q *Queries) ListUsersByPharmacy(ctx context.Context, pharmacyID int64) ([]
 	return items, nil
 }
 
+const lockActivePharmacyOwners = `-- name: LockActivePharmacyOwners :many
+SELECT id
+FROM users
+WHERE pharmacy_id = $1::BIGINT AND role = 'owner' AND active
+FOR UPDATE
+`
+
+// Locks the active owners so two concurrent changes cannot both remove "the
+// other" owner.
+func (q *Queries) LockActivePharmacyOwners(ctx context.Context, pharmacyID int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, lockActivePharmacyOwners, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockPharmacyUser = `-- name: LockPharmacyUser :one
+SELECT id, role, custom_role_id, active, (oidc_issuer IS NOT NULL)::BOOLEAN AS federated
+FROM users
+WHERE id = $1 AND pharmacy_id = $2::BIGINT
+FOR UPDATE
+`
+
+type LockPharmacyUserParams struct {
+	ID         int64
+	PharmacyID int64
+}
+
+type LockPharmacyUserRow struct {
+	ID           int64
+	Role         string
+	CustomRoleID pgtype.Int8
+	Active       bool
+	Federated    bool
+}
+
+func (q *Queries) LockPharmacyUser(ctx context.Context, arg LockPharmacyUserParams) (LockPharmacyUserRow, error) {
+	row := q.db.QueryRow(ctx, lockPharmacyUser, arg.ID, arg.PharmacyID)
+	var i LockPharmacyUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Role,
+		&i.CustomRoleID,
+		&i.Active,
+		&i.Federated,
+	)
+	return i, err
+}
+
+const lockUser = `-- name: LockUser :exec
+UPDATE users
+SET locked_until = $1::TIMESTAMPTZ
+WHERE id = $2
+`
+
+type LockUserParams struct {
+	LockedUntil pgtype.Timestamptz
+	ID          int64
+}
+
+func (q *Queries) LockUser(ctx context.Context, arg LockUserParams) error {
+	_, err := q.db.Exec(ctx, lockUser, arg.LockedUntil, arg.ID)
+	return err
+}
+
+const pruneUserSessions = `-- name: PruneUserSessions :exec
+DELETE FROM user_sessions us
+WHERE us.user_id = $1
+  AND NOT EXISTS (SELECT 1 FROM sessions s WHERE s.token = us.token)
+`
+
+// Drops the records of sessions that have expired or been logged out.
+func (q *Queries) PruneUserSessions(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, pruneUserSessions, userID)
+	return err
+}
+
+const recordUserLogin = `-- name: RecordUserLogin :exec
+UPDATE users
+SET last_login_at = now(), failed_logins = 0, locked_until = NULL
+WHERE id = $1
+`
+
+func (q *Queries) RecordUserLogin(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, recordUserLogin, id)
+	return err
+}
+
+const resetUserPassword = `-- name: ResetUserPassword :exec
+UPDATE users
+SET password_hash = $2, must_change_password = true, updated_at = now()
+WHERE id = $1
+`
+
+type ResetUserPasswordParams struct {
+	ID           int64
+	PasswordHash string
+}
+
+func (q *Queries) ResetUserPassword(ctx context.Context, arg ResetUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, resetUserPassword, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const setUserActive = `-- name: SetUserActive :exec
+UPDATE users
+SET active = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetUserActiveParams struct {
+	ID     int64
+	Active bool
+}
+
+func (q *Queries) SetUserActive(ctx context.Context, arg SetUserActiveParams) error {
+	_, err := q.db.Exec(ctx, setUserActive, arg.ID, arg.Active)
+	return err
+}
+
+const setUserLocale = `-- name: SetUserLocale :exec
+UPDATE users
+SET locale = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetUserLocaleParams struct {
+	ID     int64
+	Locale string
+}
+
+func (q *Queries) SetUserLocale(ctx context.Context, arg SetUserLocaleParams) error {
+	_, err := q.db.Exec(ctx, setUserLocale, arg.ID, arg.Locale)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users
+SET role = $2, custom_role_id = $3, updated_at = now()
+WHERE id = $1
+`
+
+type SetUserRoleParams struct {
+	ID           int64
+	Role         string
+	CustomRoleID pgtype.Int8
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.Exec(ctx, setUserRole, arg.ID, arg.Role, arg.CustomRoleID)
+	return err
+}
+
+const touchUserSession = `-- name: TouchUserSession :exec
+UPDATE user_sessions
+SET last_seen_at = $2
+WHERE token = $1
+`
+
+type TouchUserSessionParams struct {
+	Token      string
+	LastSeenAt pgtype.Timestamptz
+}
+
+func (q *Queries) TouchUserSession(ctx context.Context, arg TouchUserSessionParams) error {
+	_, err := q.db.Exec(ctx, touchUserSession, arg.Token, arg.LastSeenAt)
+	return err
+}
+
+const trackUserSession = `-- name: TrackUserSession :exec
+INSERT INTO user_sessions (token, user_id, ip, user_agent)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (token) DO NOTHING
+`
+
+type TrackUserSessionParams struct {
+	Token     string
+	UserID    int64
+	Ip        string
+	UserAgent string
+}
+
+func (q *Queries) TrackUserSession(ctx context.Context, arg TrackUserSessionParams) error {
+	_, err := q.db.Exec(ctx, trackUserSession,
+		arg.Token,
+		arg.UserID,
+		arg.Ip,
+		arg.UserAgent,
+	)
+	return err
+}
+
+const unlockUser = `-- name: UnlockUser :exec
+UPDATE users
+SET failed_logins = 0, locked_until = NULL
+WHERE id = $1
+`
+
+func (q *Queries) UnlockUser(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, unlockUser, id)
+	return err
+}
+
 const updateUserPassword = `-- name: UpdateUserPassword :exec
 UPDATE users
-SET password_hash = $2, updated_at = now()
+SET password_hash = $2, must_change_password = false, updated_at = now()
 WHERE id = $1
 `
 
@@ -142,3 +971,42 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	_, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
 	return err
 }
+
+const useUserRecoveryCode = `-- name: UseUserRecoveryCode :execrows
+UPDATE user_recovery_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseUserRecoveryCodeParams struct {
+	UserID   int64
+	CodeHash []byte
+}
+
+func (q *Queries) UseUserRecoveryCode(ctx context.Context, arg UseUserRecoveryCodeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useUserRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const useUserTOTPStep = `-- name: UseUserTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $1
+WHERE id = $2 AND totp_last_step < $1
+`
+
+type UseUserTOTPStepParams struct {
+	Step int64
+	ID   int64
+}
+
+// Accepts a code's time step only if it is newer than the last one used.
+func (q *Queries) UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useUserTOTPStep, arg.Step, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
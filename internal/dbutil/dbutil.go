@@ -26,3 +26,13 @@ func Float64ToNumeric(f float64) pgtype.Numeric {
 func TimeToDate(t time.Time) pgtype.Date {
 	return pgtype.Date{Time: t, Valid: true}
 }
+
+// TimeToTimestamp converts a time.Time to pgtype.Timestamp (no time zone).
+func TimeToTimestamp(t time.Time) pgtype.Timestamp {
+	return pgtype.Timestamp{Time: t, Valid: true}
+}
+
+// TimeToTimestamptz converts a time.Time to pgtype.Timestamptz.
+func TimeToTimestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
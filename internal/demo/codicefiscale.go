@@ -0,0 +1,77 @@
+package demo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// monthCodes are the letters the codice fiscale uses for January to December.
+const monthCodes = "ABCDEHLMPRST"
+
+// oddValues are the check-character values of the characters in odd
+// positions (1st, 3rd, ...), indexed by digit or by letter.
+var oddValues = [26]int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21, 2, 4, 18, 20, 11, 3, 6, 8, 12, 14, 16, 10, 22, 25, 24, 23}
+
+// FiscalCode returns the Italian codice fiscale of a person: three letters
+// from the surname and three from the name, the birth date with 40 added to
+// the day for women, the cadastral code of the birth place and the check
+// character. Homocodes, which the tax office resolves by hand, are ignored.
+func FiscalCode(lastName, firstName string, birth time.Time, female bool, placeCode string) string {
+	day := birth.Day()
+	if female {
+		day += 40
+	}
+	code := surnameCode(lastName) + nameCode(firstName) +
+		fmt.Sprintf("%02d%c%02d", birth.Year()%100, monthCodes[birth.Month()-1], day) +
+		placeCode
+	return code + string(checkCharacter(code))
+}
+
+// surnameCode takes the consonants then the vowels, padded with X.
+func surnameCode(s string) string {
+	consonants, vowels := splitLetters(s)
+	return (consonants + vowels + "XXX")[:3]
+}
+
+// nameCode is like surnameCode, but a name with four or more consonants
+// gives the 1st, 3rd and 4th of them.
+func nameCode(s string) string {
+	consonants, vowels := splitLetters(s)
+	if len(consonants) >= 4 {
+		return consonants[:1] + consonants[2:4]
+	}
+	return (consonants + vowels + "XXX")[:3]
+}
+
+// splitLetters upper-cases s and splits its letters into consonants and
+// vowels, dropping spaces and apostrophes.
+func splitLetters(s string) (consonants, vowels string) {
+	var c, v strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case strings.ContainsRune("AEIOU", r):
+			v.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			c.WriteRune(r)
+		}
+	}
+	return c.String(), v.String()
+}
+
+// checkCharacter computes the 16th character from the first 15.
+func checkCharacter(code string) byte {
+	sum := 0
+	for i := range len(code) {
+		n := int(code[i] - 'A')
+		if code[i] >= '0' && code[i] <= '9' {
+			n = int(code[i] - '0')
+		}
+		if i%2 == 0 {
+			sum += oddValues[n]
+		} else {
+			sum += n
+		}
+	}
+	return byte('A' + sum%26)
+}
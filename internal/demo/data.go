@@ -0,0 +1,111 @@
+package demo
+
+// city is where pharmacies and patients' homes are, and where patients were
+// born. Code is the cadastral (Belfiore) code the codice fiscale encodes.
+type city struct {
+	Name     string
+	Province string
+	CAP      string
+	Code     string
+	AreaCode string
+}
+
+var cities = []city{
+	{"Roma", "RM", "00185", "H501", "06"},
+	{"Milano", "MI", "20121", "F205", "02"},
+	{"Napoli", "NA", "80133", "F839", "081"},
+	{"Torino", "TO", "10123", "L219", "011"},
+	{"Bologna", "BO", "40126", "A944", "051"},
+	{"Firenze", "FI", "50122", "D612", "055"},
+	{"Genova", "GE", "16124", "D969", "010"},
+	{"Palermo", "PA", "90133", "G273", "091"},
+	{"Bari", "BA", "70122", "A662", "080"},
+	{"Verona", "VR", "37121", "L781", "045"},
+	{"Padova", "PD", "35122", "G224", "049"},
+	{"Pisa", "PI", "56126", "G702", "050"},
+	{"Parma", "PR", "43121", "G337", "0521"},
+	{"Brescia", "BS", "25122", "B157", "030"},
+	{"Catania", "CT", "95124", "C351", "095"},
+	{"Cagliari", "CA", "09124", "B354", "070"},
+	{"Perugia", "PG", "06121", "G478", "075"},
+	{"Trieste", "TS", "34121", "L424", "040"},
+	{"Lecce", "LE", "73100", "E506", "0832"},
+	{"Bergamo", "BG", "24122", "A794", "035"},
+}
+
+var maleNames = []string{
+	"Giuseppe", "Giovanni", "Antonio", "Mario", "Luigi", "Francesco", "Angelo", "Vincenzo",
+	"Pietro", "Salvatore", "Carlo", "Franco", "Domenico", "Bruno", "Paolo", "Michele",
+	"Giorgio", "Aldo", "Sergio", "Luciano", "Roberto", "Stefano", "Andrea", "Marco",
+	"Alessandro", "Massimo", "Claudio", "Enzo", "Renato", "Gianni",
+}
+
+var femaleNames = []string{
+	"Maria", "Anna", "Giuseppina", "Rosa", "Angela", "Giovanna", "Teresa", "Lucia",
+	"Carmela", "Caterina", "Francesca", "Anna Maria", "Antonietta", "Carla", "Elena", "Concetta",
+	"Rita", "Margherita", "Franca", "Paola", "Laura", "Giulia", "Silvia", "Patrizia",
+	"Daniela", "Cristina", "Luisa", "Graziella", "Assunta", "Marisa",
+}
+
+var surnames = []string{
+	"Rossi", "Russo", "Ferrari", "Esposito", "Bianchi", "Romano", "Colombo", "Ricci",
+	"Marino", "Greco", "Bruno", "Gallo", "Conti", "De Luca", "Mancini", "Costa",
+	"Giordano", "Rizzo", "Lombardi", "Moretti", "Barbieri", "Fontana", "Santoro", "Mariani",
+	"Rinaldi", "Caruso", "Ferrara", "Galli", "Martini", "Leone", "Longo", "Gentile",
+	"Martinelli", "Vitale", "Lombardo", "Serra", "Coppola", "De Santis", "D'Angelo", "Marchetti",
+	"Parisi", "Villa", "Conte", "Ferraro", "Ferri", "Fabbri", "Bianco", "Marini",
+	"Grasso", "Valentini", "Messina", "Sala", "De Angelis", "Gatti", "Pellegrini", "Palumbo",
+	"Sanna", "Farina", "Rizzi", "Monti",
+}
+
+var streets = []string{
+	"Via Roma", "Via Garibaldi", "Via Mazzini", "Corso Vittorio Emanuele II", "Via Cavour",
+	"Via Dante Alighieri", "Via Verdi", "Via Marconi", "Viale della Repubblica", "Via XX Settembre",
+	"Via Matteotti", "Piazza della Libertà", "Via San Francesco", "Via dei Mille", "Corso Italia",
+	"Via Manzoni", "Via Leopardi", "Via Nazionale", "Via Gramsci", "Viale Europa",
+}
+
+var deliveryNotes = []string{
+	"", "", "", "Citofono %s", "Scala B, interno %d", "Lasciare in portineria", "Suonare due volte",
+}
+
+var pharmacyNames = []string{
+	"Farmacia Centrale", "Farmacia San Marco", "Farmacia Comunale", "Farmacia del Corso",
+	"Farmacia Moderna", "Farmacia Sant'Anna", "Farmacia alla Stazione", "Farmacia Internazionale",
+	"Farmacia San Giorgio", "Farmacia del Duomo", "Farmacia Santa Lucia", "Farmacia Nuova",
+}
+
+var mobilePrefixes = []string{
+	"320", "328", "329", "333", "338", "339", "340", "347", "348", "349", "366", "380", "388", "392",
+}
+
+// medication is a chronic therapy as dispensed in Italy: a box of
+// UnitsPerBox and the daily doses prescribers commonly give.
+type medication struct {
+	Name        string
+	UnitsPerBox int
+	Doses       []float64
+}
+
+var medications = []medication{
+	{"Cardioaspirin 100 mg", 30, []float64{1}},
+	{"Eutirox 50 mcg", 50, []float64{1}},
+	{"Eutirox 75 mcg", 50, []float64{1}},
+	{"Metformina 500 mg", 60, []float64{1, 2, 3}},
+	{"Metformina 1000 mg", 60, []float64{1, 2}},
+	{"Ramipril 5 mg", 28, []float64{1}},
+	{"Atorvastatina 20 mg", 30, []float64{1}},
+	{"Rosuvastatina 10 mg", 28, []float64{1}},
+	{"Bisoprololo 2,5 mg", 28, []float64{0.5, 1}},
+	{"Amlodipina 5 mg", 28, []float64{1}},
+	{"Pantoprazolo 20 mg", 28, []float64{1}},
+	{"Lansoprazolo 30 mg", 14, []float64{1}},
+	{"Furosemide 25 mg", 30, []float64{0.5, 1}},
+	{"Coumadin 5 mg", 30, []float64{0.5, 0.75, 1}},
+	{"Allopurinolo 300 mg", 30, []float64{1}},
+	{"Tamsulosina 0,4 mg", 30, []float64{1}},
+	{"Losartan 50 mg", 28, []float64{1}},
+	{"Brilique 90 mg", 56, []float64{2}},
+	{"Olmesartan 20 mg", 28, []float64{1}},
+	{"Levetiracetam 500 mg", 60, []float64{2}},
+}
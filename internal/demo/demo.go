@@ -0,0 +1,274 @@
+// Package demo generates realistic Italian demo data: pharmacies with their
+// staff, patients with valid codici fiscali, prescriptions with refill
+// histories, and orders in every status. Everything goes through the domain
+// services, and the same Options always give the same data, so integration
+// tests can build on it.
+package demo
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+var (
+	ErrPasswordRequired  = errors.New("demo.password_required")
+	ErrTooManyPharmacies = errors.New("demo.too_many_pharmacies")
+)
+
+// Options are what the generated data depends on.
+type Options struct {
+	Seed        uint64
+	Pharmacies  int
+	Staff       int // per pharmacy, besides the owner
+	Patients    int // per pharmacy
+	HistoryDays int // how far back refills and orders go
+	// Today is the day the data is generated as of; box start dates, refills
+	// and orders are placed relative to it.
+	Today time.Time
+	// Password is given to every staff account.
+	Password string
+	// LookaheadDays is used for pharmacies without their own lookahead.
+	LookaheadDays int
+}
+
+func (o Options) withDefaults() Options {
+	if o.Pharmacies == 0 {
+		o.Pharmacies = 3
+	}
+	if o.Staff == 0 {
+		o.Staff = 4
+	}
+	if o.Patients == 0 {
+		o.Patients = 40
+	}
+	if o.HistoryDays == 0 {
+		o.HistoryDays = 180
+	}
+	if o.LookaheadDays == 0 {
+		o.LookaheadDays = 7
+	}
+	o.Today = day(o.Today)
+	return o
+}
+
+// Random streams: the plan and the simulation draw from separate streams,
+// so changing how orders are handled does not change who the patients are.
+const (
+	planStream       = 1
+	simulationStream = 2
+)
+
+// Plan is the data Generate creates, before any of it exists. IDs linking
+// the records are filled in as they are created.
+type Plan struct {
+	Pharmacies []PharmacyPlan
+}
+
+type PharmacyPlan struct {
+	pharmacy.CreateParams
+	Staff    []pharmacy.CreatePersonnelParams
+	Patients []PatientPlan
+}
+
+type PatientPlan struct {
+	patient.CreateParams
+	FiscalCode string
+	BirthDate  time.Time
+	Female     bool
+	BirthPlace string // cadastral code
+	Consensus  bool
+	// Lateness is how many days after running out the patient usually
+	// collects, negative when they come early.
+	Lateness int
+	// Forgetful patients stop collecting in the last month, leaving prepared
+	// orders and depleted prescriptions.
+	Forgetful     bool
+	Prescriptions []prescription.CreateParams
+}
+
+// staffRoles are given in turn to the staff after the owner.
+var staffRoles = []string{
+	permission.RolePharmacist, permission.RolePersonnel, permission.RoleTrainee,
+	permission.RoleDriver, permission.RolePharmacist, permission.RolePersonnel,
+}
+
+// NewPlan draws the pharmacies, staff, patients and prescriptions for opts.
+func NewPlan(opts Options) (Plan, error) {
+	opts = opts.withDefaults()
+	if opts.Pharmacies > len(pharmacyNames) {
+		return Plan{}, ErrTooManyPharmacies
+	}
+	p := planner{r: rand.New(rand.NewPCG(opts.Seed, planStream)), opts: opts, emails: map[string]bool{}}
+
+	var plan Plan
+	names := p.r.Perm(len(pharmacyNames))
+	places := p.r.Perm(len(cities))
+	for i := range opts.Pharmacies {
+		plan.Pharmacies = append(plan.Pharmacies, p.pharmacy(pharmacyNames[names[i]], cities[places[i]]))
+	}
+	return plan, nil
+}
+
+type planner struct {
+	r      *rand.Rand
+	opts   Options
+	emails map[string]bool
+}
+
+func (p *planner) pharmacy(name string, c city) PharmacyPlan {
+	domain := slug(name+" "+c.Name) + ".example.com"
+	ownerFirst, ownerLast, _ := p.name()
+	pp := PharmacyPlan{CreateParams: pharmacy.CreateParams{
+		Name:          name,
+		Address:       fmt.Sprintf("%s %d, %s %s %s", pick(p.r, streets), 1+p.r.IntN(120), c.CAP, c.Name, c.Province),
+		Phone:         fmt.Sprintf("%s %d", c.AreaCode, 1000000+p.r.IntN(9000000)),
+		Email:         "info@" + domain,
+		OwnerName:     ownerFirst + " " + ownerLast,
+		OwnerEmail:    p.staffEmail(ownerFirst, ownerLast, domain),
+		OwnerPassword: p.opts.Password,
+	}}
+	for i := range p.opts.Staff {
+		first, last, _ := p.name()
+		pp.Staff = append(pp.Staff, pharmacy.CreatePersonnelParams{
+			Name:     first + " " + last,
+			Email:    p.staffEmail(first, last, domain),
+			Password: p.opts.Password,
+			Role:     staffRoles[i%len(staffRoles)],
+		})
+	}
+	for range p.opts.Patients {
+		pp.Patients = append(pp.Patients, p.patient(c))
+	}
+	return pp
+}
+
+func (p *planner) patient(home city) PatientPlan {
+	first, last, female := p.name()
+	birthPlace := home
+	if p.r.IntN(10) < 3 {
+		birthPlace = pick(p.r, cities)
+	}
+	birth := p.opts.Today.AddDate(-45-p.r.IntN(48), 0, -p.r.IntN(365))
+	pt := PatientPlan{
+		FiscalCode: FiscalCode(last, first, birth, female, birthPlace.Code),
+		BirthDate:  birth,
+		Female:     female,
+		BirthPlace: birthPlace.Code,
+		Consensus:  p.r.IntN(10) > 0,
+		Lateness:   p.r.IntN(9) - 3,
+		Forgetful:  p.r.IntN(12) == 0,
+	}
+	pt.CreateParams = patient.CreateParams{
+		FirstName:       first,
+		LastName:        last,
+		DeliveryAddress: p.address(home, last),
+		Fulfillment:     patient.FulfillmentPickup,
+	}
+	pt.Notes = "Codice fiscale " + pt.FiscalCode
+	if p.r.IntN(4) == 0 {
+		pt.Fulfillment = patient.FulfillmentShipping
+	}
+	if p.r.IntN(10) < 9 {
+		pt.Phone = fmt.Sprintf("%s %d", pick(p.r, mobilePrefixes), 1000000+p.r.IntN(9000000))
+	}
+	if pt.Phone == "" || p.r.IntN(2) == 0 {
+		pt.Email = fmt.Sprintf("%s.%s%02d@example.com", slug(first), slug(last), birth.Year()%100)
+	}
+	if !pt.Consensus {
+		return pt
+	}
+
+	for _, m := range p.r.Perm(len(medications))[:1+p.r.IntN(3)] {
+		med := medications[m]
+		dose := pick(p.r, med.Doses)
+		cycle := int(float64(med.UnitsPerBox) / dose)
+		// Most therapies predate the history and go through several
+		// refills; some were started recently and have none yet.
+		start := p.opts.Today.AddDate(0, 0, -p.opts.HistoryDays-p.r.IntN(cycle))
+		if p.r.IntN(5) == 0 {
+			start = p.opts.Today.AddDate(0, 0, -p.r.IntN(cycle))
+		}
+		pt.Prescriptions = append(pt.Prescriptions, prescription.CreateParams{
+			MedicationName:   med.Name,
+			UnitsPerBox:      med.UnitsPerBox,
+			DailyConsumption: dose,
+			BoxStartDate:     start,
+		})
+	}
+	return pt
+}
+
+func (p *planner) name() (first, last string, female bool) {
+	female = p.r.IntN(2) == 0
+	if female {
+		return pick(p.r, femaleNames), pick(p.r, surnames), true
+	}
+	return pick(p.r, maleNames), pick(p.r, surnames), false
+}
+
+func (p *planner) address(c city, lastName string) address.Address {
+	a := address.Address{
+		Street:      pick(p.r, streets),
+		HouseNumber: fmt.Sprint(1 + p.r.IntN(200)),
+		CAP:         c.CAP,
+		City:        c.Name,
+		Province:    c.Province,
+		Country:     address.CountryItaly,
+	}
+	switch note := pick(p.r, deliveryNotes); {
+	case strings.Contains(note, "%s"):
+		a.Notes = fmt.Sprintf(note, lastName)
+	case strings.Contains(note, "%d"):
+		a.Notes = fmt.Sprintf(note, 1+p.r.IntN(30))
+	default:
+		a.Notes = note
+	}
+	return a
+}
+
+// staffEmail returns name.surname at the pharmacy's domain, numbered when
+// two staff share a name.
+func (p *planner) staffEmail(first, last, domain string) string {
+	local := slug(first) + "." + slug(last)
+	email := local + "@" + domain
+	for n := 2; p.emails[email]; n++ {
+		email = fmt.Sprintf("%s%d@%s", local, n, domain)
+	}
+	p.emails[email] = true
+	return email
+}
+
+// slug lower-cases s, joins words with dashes and drops anything but
+// letters and digits: "Farmacia Sant'Anna Roma" is "farmacia-santanna-roma".
+func slug(s string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(strings.ToLower(s)) {
+		if b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		for _, r := range word {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+func pick[T any](r *rand.Rand, xs []T) T {
+	return xs[r.IntN(len(xs))]
+}
+
+// day returns t's date at midnight UTC, the form dates take in the database.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
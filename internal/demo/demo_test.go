@@ -0,0 +1,281 @@
+package demo_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
+	"github.com/giorgiovilardo/pharmarecall/internal/demo"
+	"github.com/giorgiovilardo/pharmarecall/internal/depletion"
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestFiscalCode(t *testing.T) {
+	cases := []struct {
+		name        string
+		last, first string
+		birth       time.Time
+		female      bool
+		place       string
+		want        string
+	}{
+		{"reference example", "Rossi", "Mario", date(1985, 12, 10), false, "A562", "RSSMRA85T10A562S"},
+		{"women add 40 to the day", "Rossi", "Maria", date(1985, 12, 10), true, "A562", "RSSMRA85T50A562"},
+		{"name with four consonants skips the second", "Bianchi", "Gianfranco", date(1950, 1, 3), false, "H501", "BNCGFR50A03H501"},
+		{"short surname is padded", "Fo", "Dario", date(1926, 3, 24), false, "E734", "FOXDRA26C24E734"},
+		{"apostrophes and spaces are dropped", "D'Angelo", "Anna Maria", date(1941, 7, 1), true, "F839", "DNGNMR41L41F839"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := demo.FiscalCode(tc.last, tc.first, tc.birth, tc.female, tc.place)
+			if len(got) != 16 || !strings.HasPrefix(got, tc.want) {
+				t.Errorf("FiscalCode = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+var opts = demo.Options{Seed: 42, Pharmacies: 2, Staff: 3, Patients: 25, HistoryDays: 120, Today: date(2026, 10, 18), Password: "demo-password"}
+
+func TestNewPlanIsDeterministic(t *testing.T) {
+	a, err := demo.NewPlan(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := demo.NewPlan(opts)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("same options gave different plans")
+	}
+
+	other := opts
+	other.Seed = 43
+	c, _ := demo.NewPlan(other)
+	if reflect.DeepEqual(a, c) {
+		t.Error("different seeds gave the same plan")
+	}
+}
+
+func TestNewPlanIsValid(t *testing.T) {
+	plan, err := demo.NewPlan(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Pharmacies) != 2 {
+		t.Fatalf("pharmacies = %d, want 2", len(plan.Pharmacies))
+	}
+
+	emails := map[string]bool{}
+	for _, ph := range plan.Pharmacies {
+		if len(ph.Staff) != 3 || len(ph.Patients) != 25 {
+			t.Errorf("%s: %d staff and %d patients, want 3 and 25", ph.Name, len(ph.Staff), len(ph.Patients))
+		}
+		staff := []string{ph.OwnerEmail}
+		for _, m := range ph.Staff {
+			staff = append(staff, m.Email)
+		}
+		for _, e := range staff {
+			if emails[e] {
+				t.Errorf("staff email %s used twice", e)
+			}
+			emails[e] = true
+		}
+
+		for _, pt := range ph.Patients {
+			if err := address.Validate(pt.DeliveryAddress); err != nil {
+				t.Errorf("%s %s: invalid address %+v: %v", pt.FirstName, pt.LastName, pt.DeliveryAddress, err)
+			}
+			if pt.Phone == "" && pt.Email == "" {
+				t.Errorf("%s %s has no contact", pt.FirstName, pt.LastName)
+			}
+			if want := demo.FiscalCode(pt.LastName, pt.FirstName, pt.BirthDate, pt.Female, pt.BirthPlace); pt.FiscalCode != want {
+				t.Errorf("fiscal code = %q, want %q", pt.FiscalCode, want)
+			}
+			if !strings.Contains(pt.Notes, pt.FiscalCode) {
+				t.Errorf("notes %q do not carry the fiscal code", pt.Notes)
+			}
+			if !pt.Consensus && len(pt.Prescriptions) > 0 {
+				t.Errorf("%s %s has prescriptions without consensus", pt.FirstName, pt.LastName)
+			}
+			for _, rx := range pt.Prescriptions {
+				if rx.BoxStartDate.After(opts.Today) || rx.DailyConsumption >= float64(rx.UnitsPerBox) {
+					t.Errorf("invalid prescription %+v", rx)
+				}
+			}
+		}
+	}
+}
+
+func TestNewPlanRefusesTooManyPharmacies(t *testing.T) {
+	o := opts
+	o.Pharmacies = 100
+	if _, err := demo.NewPlan(o); err != demo.ErrTooManyPharmacies {
+		t.Errorf("err = %v, want ErrTooManyPharmacies", err)
+	}
+}
+
+func TestGenerateRequiresPassword(t *testing.T) {
+	o := opts
+	o.Password = ""
+	if _, err := demo.Generate(context.Background(), newFakeServices().deps(), o); err != demo.ErrPasswordRequired {
+		t.Errorf("err = %v, want ErrPasswordRequired", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	run := func() ([]demo.Summary, *fakeServices) {
+		f := newFakeServices()
+		summaries, err := demo.Generate(context.Background(), f.deps(), opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return summaries, f
+	}
+	summaries, f := run()
+
+	if len(summaries) != 2 || len(f.pharmacies) != 2 {
+		t.Fatalf("%d summaries for %d pharmacies, want 2", len(summaries), len(f.pharmacies))
+	}
+	for _, s := range summaries {
+		if s.Staff != 3 || s.Patients != 25 || s.Prescriptions == 0 {
+			t.Errorf("%s: %d staff, %d patients, %d prescriptions", s.Name, s.Staff, s.Patients, s.Prescriptions)
+		}
+		for _, status := range []string{order.StatusPending, order.StatusPrepared, order.StatusFulfilled} {
+			if s.Orders[status] == 0 {
+				t.Errorf("%s: no %s orders in %v", s.Name, status, s.Orders)
+			}
+		}
+	}
+	if f.refills == 0 {
+		t.Error("no refills recorded")
+	}
+	if len(f.notified) == 0 {
+		t.Error("no approaching notifications")
+	}
+
+	again, g := run()
+	if !reflect.DeepEqual(summaries, again) || !reflect.DeepEqual(f.orders, g.orders) {
+		t.Error("same options gave different data")
+	}
+}
+
+// fakeServices keeps the generated data in memory and creates, advances and
+// fulfils orders as the order service does.
+type fakeServices struct {
+	pharmacies    []pharmacy.CreateParams
+	patients      map[int64]patient.CreateParams
+	consensus     map[int64]bool
+	prescriptions []prescription.Prescription
+	orders        []order.DashboardEntry
+	refills       int
+	notified      []int64
+}
+
+func newFakeServices() *fakeServices {
+	return &fakeServices{patients: map[int64]patient.CreateParams{}, consensus: map[int64]bool{}}
+}
+
+func (f *fakeServices) deps() demo.Deps {
+	return demo.Deps{Pharmacies: f, Patients: patientFake{f}, Prescriptions: prescriptionFake{f}, Orders: f, Notifications: f}
+}
+
+func (f *fakeServices) CreateWithOwner(_ context.Context, p pharmacy.CreateParams) (pharmacy.Pharmacy, error) {
+	f.pharmacies = append(f.pharmacies, p)
+	return pharmacy.Pharmacy{ID: int64(len(f.pharmacies)), Name: p.Name}, nil
+}
+
+func (f *fakeServices) CreatePersonnel(_ context.Context, p pharmacy.CreatePersonnelParams) (pharmacy.PersonnelMember, error) {
+	return pharmacy.PersonnelMember{Email: p.Email, Role: p.Role}, nil
+}
+
+func (f *fakeServices) LookaheadDays(context.Context, int64, int) (int, error) {
+	return 7, nil
+}
+
+type patientFake struct{ *fakeServices }
+
+func (f patientFake) Create(_ context.Context, p patient.CreateParams) (patient.Patient, error) {
+	id := int64(len(f.patients) + 1)
+	f.patients[id] = p
+	return patient.Patient{ID: id, PharmacyID: p.PharmacyID}, nil
+}
+
+func (f patientFake) SetConsensus(_ context.Context, id int64) error {
+	f.consensus[id] = true
+	return nil
+}
+
+type prescriptionFake struct{ *fakeServices }
+
+func (f prescriptionFake) Create(_ context.Context, p prescription.CreateParams) (prescription.Prescription, error) {
+	if !f.consensus[p.PatientID] {
+		return prescription.Prescription{}, prescription.ErrNoConsensus
+	}
+	rx := prescription.Prescription{ID: int64(len(f.prescriptions) + 1), PatientID: p.PatientID, MedicationName: p.MedicationName,
+		UnitsPerBox: p.UnitsPerBox, DailyConsumption: p.DailyConsumption, BoxStartDate: p.BoxStartDate}
+	f.prescriptions = append(f.prescriptions, rx)
+	return rx, nil
+}
+
+func (f *fakeServices) EnsureOrders(_ context.Context, pharmacyID int64, now time.Time, lookaheadDays int) error {
+	for _, rx := range f.prescriptions {
+		if f.patients[rx.PatientID].PharmacyID != pharmacyID {
+			continue
+		}
+		depletes := depletion.EstimatedDate(rx.UnitsPerBox, rx.DailyConsumption, rx.BoxStartDate)
+		if depletion.DaysRemaining(depletes, now) > lookaheadDays || f.hasActiveOrder(rx) {
+			continue
+		}
+		f.orders = append(f.orders, order.DashboardEntry{
+			OrderID: int64(len(f.orders) + 1), PrescriptionID: rx.ID, PatientID: rx.PatientID,
+			CycleStartDate: rx.BoxStartDate, EstimatedDepletionDate: depletes, PrepareBy: depletes,
+			OrderStatus: order.StatusPending,
+		})
+	}
+	return nil
+}
+
+func (f *fakeServices) hasActiveOrder(rx prescription.Prescription) bool {
+	for _, o := range f.orders {
+		if o.PrescriptionID == rx.ID && o.CycleStartDate.Equal(rx.BoxStartDate) && o.OrderStatus != order.StatusFulfilled {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeServices) ListDashboard(_ context.Context, pharmacyID int64) ([]order.DashboardEntry, error) {
+	var entries []order.DashboardEntry
+	for _, o := range f.orders {
+		if f.patients[o.PatientID].PharmacyID == pharmacyID {
+			rx := f.prescriptions[o.PrescriptionID-1]
+			o.UnitsPerBox, o.DailyConsumption, o.BoxStartDate = rx.UnitsPerBox, rx.DailyConsumption, rx.BoxStartDate
+			entries = append(entries, o)
+		}
+	}
+	return entries, nil
+}
+
+func (f *fakeServices) AdvanceStatus(_ context.Context, orderID int64, now time.Time) error {
+	o := &f.orders[orderID-1]
+	o.OrderStatus = order.NextStatus(o.OrderStatus)
+	if o.OrderStatus == order.StatusFulfilled {
+		f.prescriptions[o.PrescriptionID-1].BoxStartDate = now
+		f.refills++
+	}
+	return nil
+}
+
+func (f *fakeServices) GenerateApproaching(_ context.Context, _ int64, prescriptionIDs []int64) error {
+	f.notified = append(f.notified, prescriptionIDs...)
+	return nil
+}
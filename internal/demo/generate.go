@@ -0,0 +1,184 @@
+package demo
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+// Summary is what Generate created at one pharmacy.
+type Summary struct {
+	PharmacyID    int64
+	Name          string
+	OwnerEmail    string
+	Staff         int
+	Patients      int
+	Prescriptions int
+	// Orders counts the pharmacy's orders by status on the last day.
+	Orders map[string]int
+}
+
+// Generate creates the data of NewPlan(opts), then replays HistoryDays days
+// of pharmacy work up to opts.Today: each day due orders are created, then
+// prepared shortly before their prepare-by date and fulfilled when the
+// patient comes, which records a refill. It leaves fulfilled orders and
+// refill histories behind, and prepared and pending orders for the days
+// ahead. On an empty database the same opts give the same data.
+func Generate(ctx context.Context, d Deps, opts Options) ([]Summary, error) {
+	opts = opts.withDefaults()
+	if opts.Password == "" {
+		return nil, ErrPasswordRequired
+	}
+	plan, err := NewPlan(opts)
+	if err != nil {
+		return nil, err
+	}
+	r := rand.New(rand.NewPCG(opts.Seed, simulationStream))
+
+	var summaries []Summary
+	for _, pp := range plan.Pharmacies {
+		s, err := generatePharmacy(ctx, d, opts, pp, r)
+		if err != nil {
+			return nil, fmt.Errorf("generating %s: %w", pp.Name, err)
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+func generatePharmacy(ctx context.Context, d Deps, opts Options, pp PharmacyPlan, r *rand.Rand) (Summary, error) {
+	ph, err := d.Pharmacies.CreateWithOwner(ctx, pp.CreateParams)
+	if err != nil {
+		return Summary{}, err
+	}
+	s := Summary{PharmacyID: ph.ID, Name: ph.Name, OwnerEmail: pp.OwnerEmail}
+	for _, m := range pp.Staff {
+		m.PharmacyID = ph.ID
+		if _, err := d.Pharmacies.CreatePersonnel(ctx, m); err != nil {
+			return Summary{}, fmt.Errorf("creating %s: %w", m.Email, err)
+		}
+		s.Staff++
+	}
+
+	patients := map[int64]PatientPlan{}
+	for _, pt := range pp.Patients {
+		pt.PharmacyID = ph.ID
+		created, err := d.Patients.Create(ctx, pt.CreateParams)
+		if err != nil {
+			return Summary{}, fmt.Errorf("creating patient %s %s: %w", pt.FirstName, pt.LastName, err)
+		}
+		patients[created.ID] = pt
+		s.Patients++
+		if !pt.Consensus {
+			continue
+		}
+		if err := d.Patients.SetConsensus(ctx, created.ID); err != nil {
+			return Summary{}, err
+		}
+		for _, rx := range pt.Prescriptions {
+			rx.PatientID = created.ID
+			if _, err := d.Prescriptions.Create(ctx, rx); err != nil {
+				return Summary{}, fmt.Errorf("creating prescription of %s for patient %d: %w", rx.MedicationName, created.ID, err)
+			}
+			s.Prescriptions++
+		}
+	}
+
+	lookahead, err := d.Pharmacies.LookaheadDays(ctx, ph.ID, opts.LookaheadDays)
+	if err != nil {
+		return Summary{}, err
+	}
+	sim := simulation{d: d, pharmacyID: ph.ID, today: opts.Today, lookahead: lookahead, patients: patients, r: r, due: map[int64]schedule{}}
+	for day := opts.Today.AddDate(0, 0, -opts.HistoryDays); !day.After(opts.Today); day = day.AddDate(0, 0, 1) {
+		if err := sim.run(ctx, day); err != nil {
+			return Summary{}, fmt.Errorf("simulating %s: %w", day.Format(time.DateOnly), err)
+		}
+	}
+
+	entries, err := d.Orders.ListDashboard(ctx, ph.ID)
+	if err != nil {
+		return Summary{}, err
+	}
+	s.Orders = map[string]int{}
+	var approaching []int64
+	for _, e := range entries {
+		s.Orders[e.OrderStatus]++
+		if e.OrderStatus != order.StatusFulfilled && e.PrescriptionStatus(opts.Today) == prescription.StatusApproaching {
+			approaching = append(approaching, e.PrescriptionID)
+		}
+	}
+	if err := d.Notifications.GenerateApproaching(ctx, ph.ID, approaching); err != nil {
+		return Summary{}, err
+	}
+	return s, nil
+}
+
+// schedule is when an order is prepared and when its patient collects it.
+type schedule struct {
+	prepareOn, collectOn time.Time
+}
+
+type simulation struct {
+	d          Deps
+	pharmacyID int64
+	today      time.Time
+	lookahead  int
+	patients   map[int64]PatientPlan
+	r          *rand.Rand
+	due        map[int64]schedule // by order ID
+}
+
+// run is one working day: create the due orders, then prepare and hand over
+// those whose day has come.
+func (s *simulation) run(ctx context.Context, day time.Time) error {
+	if err := s.d.Orders.EnsureOrders(ctx, s.pharmacyID, day, s.lookahead); err != nil {
+		return err
+	}
+	entries, err := s.d.Orders.ListDashboard(ctx, s.pharmacyID)
+	if err != nil {
+		return err
+	}
+	// The dashboard is sorted by date; order IDs keep the draws below in
+	// the same order on every run.
+	slices.SortFunc(entries, func(a, b order.DashboardEntry) int { return cmp.Compare(a.OrderID, b.OrderID) })
+	for _, e := range entries {
+		if e.OrderStatus == order.StatusFulfilled {
+			continue
+		}
+		sch, ok := s.due[e.OrderID]
+		if !ok {
+			sch = s.plan(e)
+			s.due[e.OrderID] = sch
+		}
+		if (e.OrderStatus == order.StatusPending && !day.Before(sch.prepareOn)) ||
+			(e.OrderStatus == order.StatusPrepared && !day.Before(sch.collectOn)) {
+			if err := s.d.Orders.AdvanceStatus(ctx, e.OrderID, day); err != nil {
+				return fmt.Errorf("advancing order %d: %w", e.OrderID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// plan schedules a new order: it is prepared up to three days before its
+// prepare-by date and collected around the day the patient runs out, as
+// early or late as they usually are. Forgetful patients leave this month's
+// orders on the shelf.
+func (s *simulation) plan(e order.DashboardEntry) schedule {
+	pt := s.patients[e.PatientID]
+	prepareOn := day(e.PrepareBy).AddDate(0, 0, -s.r.IntN(4))
+	collectOn := day(e.EstimatedDepletionDate).AddDate(0, 0, pt.Lateness+s.r.IntN(5)-2)
+	if !collectOn.After(prepareOn) {
+		collectOn = prepareOn.AddDate(0, 0, 1)
+	}
+	if pt.Forgetful && collectOn.After(s.today.AddDate(0, 0, -30)) {
+		collectOn = s.today.AddDate(1, 0, 0)
+	}
+	return schedule{prepareOn: prepareOn, collectOn: collectOn}
+}
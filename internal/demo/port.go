@@ -0,0 +1,51 @@
+package demo
+
+import (
+	"context"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+// PharmacyCreator creates pharmacies and their staff.
+type PharmacyCreator interface {
+	CreateWithOwner(ctx context.Context, p pharmacy.CreateParams) (pharmacy.Pharmacy, error)
+	CreatePersonnel(ctx context.Context, p pharmacy.CreatePersonnelParams) (pharmacy.PersonnelMember, error)
+	LookaheadDays(ctx context.Context, pharmacyID int64, fallback int) (int, error)
+}
+
+// PatientCreator creates patients and records their consensus.
+type PatientCreator interface {
+	Create(ctx context.Context, p patient.CreateParams) (patient.Patient, error)
+	SetConsensus(ctx context.Context, id int64) error
+}
+
+// PrescriptionCreator creates prescriptions.
+type PrescriptionCreator interface {
+	Create(ctx context.Context, p prescription.CreateParams) (prescription.Prescription, error)
+}
+
+// OrderProcessor creates due orders and moves them through their lifecycle,
+// recording refills as they are fulfilled.
+type OrderProcessor interface {
+	EnsureOrders(ctx context.Context, pharmacyID int64, now time.Time, lookaheadDays int) error
+	ListDashboard(ctx context.Context, pharmacyID int64) ([]order.DashboardEntry, error)
+	AdvanceStatus(ctx context.Context, orderID int64, now time.Time) error
+}
+
+// ApproachingNotifier creates notifications for approaching prescriptions.
+type ApproachingNotifier interface {
+	GenerateApproaching(ctx context.Context, pharmacyID int64, prescriptionIDs []int64) error
+}
+
+// Deps are the domain services Generate goes through.
+type Deps struct {
+	Pharmacies    PharmacyCreator
+	Patients      PatientCreator
+	Prescriptions PrescriptionCreator
+	Orders        OrderProcessor
+	Notifications ApproachingNotifier
+}
@@ -20,6 +20,17 @@ func EstimatedDate(unitsPerBox int, dailyConsumption float64, boxStartDate time.
 	return boxStartDate.AddDate(0, 0, int(days))
 }
 
+// EstimatedDateFromReport re-anchors the estimate on a reported count of
+// units still on hand: they run out reportedOn + floor(units / dailyConsumption)
+// days. A report made on or before boxStartDate belongs to an earlier box and
+// is ignored, as is a zero reportedOn.
+func EstimatedDateFromReport(unitsPerBox int, dailyConsumption float64, boxStartDate time.Time, units int, reportedOn time.Time) time.Time {
+	if reportedOn.IsZero() || !reportedOn.After(boxStartDate) {
+		return EstimatedDate(unitsPerBox, dailyConsumption, boxStartDate)
+	}
+	return EstimatedDate(units, dailyConsumption, reportedOn)
+}
+
 // DaysRemaining returns the number of days until depletionDate relative to now.
 // Negative values mean the prescription is past depletion.
 func DaysRemaining(depletionDate, now time.Time) int {
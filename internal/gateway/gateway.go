@@ -0,0 +1,56 @@
+// Package gateway builds the senders that reach people outside the
+// pharmacy, by email and SMS, from the configuration. Only in development,
+// and only without a mail server and an SMS gateway, do they fall back to
+// writing messages to the log; see LogsMessages.
+package gateway
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/config"
+	"github.com/giorgiovilardo/pharmarecall/internal/mail"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/portal"
+	"github.com/giorgiovilardo/pharmarecall/internal/sms"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+)
+
+// Mailer emails password resets through the configured SMTP server.
+func Mailer(cfg config.Config) user.Mailer {
+	if cfg.Server.Env != config.EnvProduction && cfg.Mail.Host == "" {
+		return user.LogMailer{}
+	}
+	return user.EmailMailer{Email: smtpClient(cfg.Mail)}
+}
+
+// LoginSender emails and texts patient logins.
+func LoginSender(cfg config.Config) portal.Sender {
+	if LogsMessages(cfg) {
+		return portal.LogSender{}
+	}
+	return portal.GatewaySender{Email: smtpClient(cfg.Mail), SMS: smsGateway(cfg.SMS)}
+}
+
+// PickupNotifier texts or emails patients their pickup time.
+func PickupNotifier(cfg config.Config) pickup.Notifier {
+	if LogsMessages(cfg) {
+		return pickup.LogNotifier{}
+	}
+	return pickup.GatewayNotifier{Email: smtpClient(cfg.Mail), SMS: smsGateway(cfg.SMS)}
+}
+
+// LogsMessages reports whether messages to patients go to the log instead
+// of being sent: never in production, where Validate requires both
+// gateways.
+func LogsMessages(cfg config.Config) bool {
+	return cfg.Server.Env != config.EnvProduction && (cfg.Mail.Host == "" || cfg.SMS.URL == "")
+}
+
+func smtpClient(c config.MailConfig) mail.SMTP {
+	return mail.SMTP{Host: c.Host, Port: c.Port, Username: c.Username, Password: c.Password, From: c.From}
+}
+
+func smsGateway(c config.SMSConfig) sms.Gateway {
+	return sms.Gateway{URL: c.URL, Token: c.Token, From: c.From, Client: &http.Client{Timeout: 10 * time.Second}}
+}
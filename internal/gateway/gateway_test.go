@@ -0,0 +1,43 @@
+package gateway_test
+
+import (
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/config"
+	"github.com/giorgiovilardo/pharmarecall/internal/gateway"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/portal"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+)
+
+func TestProductionNeverLogsMessages(t *testing.T) {
+	for name, cfg := range map[string]config.Config{
+		"configured": {
+			Server: config.ServerConfig{Env: config.EnvProduction},
+			Mail:   config.MailConfig{Host: "smtp.example.it", Port: 587, From: "farmacia@example.it"},
+			SMS:    config.SMSConfig{URL: "https://sms.example.it/send"},
+		},
+		// Validate refuses this, but the wiring must not fall back anyway.
+		"unconfigured": {Server: config.ServerConfig{Env: config.EnvProduction}},
+	} {
+		if gateway.LogsMessages(cfg) {
+			t.Errorf("%s: LogsMessages = true in production", name)
+		}
+		if _, ok := gateway.PickupNotifier(cfg).(pickup.LogNotifier); ok {
+			t.Errorf("%s: pickup notices go to the log in production", name)
+		}
+		if _, ok := gateway.LoginSender(cfg).(portal.LogSender); ok {
+			t.Errorf("%s: patient logins go to the log in production", name)
+		}
+		if _, ok := gateway.Mailer(cfg).(user.LogMailer); ok {
+			t.Errorf("%s: password resets go to the log in production", name)
+		}
+	}
+}
+
+func TestDevelopmentWithoutGatewaysLogsMessages(t *testing.T) {
+	cfg := config.Config{Server: config.ServerConfig{Env: config.EnvDevelopment}}
+	if _, ok := gateway.PickupNotifier(cfg).(pickup.LogNotifier); !ok {
+		t.Error("want pickup notices in the log without gateways in development")
+	}
+}
@@ -0,0 +1,219 @@
+// Package i18n holds the message catalogue of the web interface, the
+// supported locales and locale-aware formatting of dates and numbers.
+//
+// Messages are looked up by key. Domain errors carry language-neutral codes
+// (e.g. "patient.name_required") that double as catalogue keys, so the web
+// layer translates them with Error.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is a supported interface language, as an ISO 639-1 code.
+type Locale string
+
+const (
+	Italian Locale = "it"
+	German  Locale = "de"
+)
+
+// Default is the locale used when neither the user nor the pharmacy chose
+// one, and for keys a catalogue lacks.
+const Default = Italian
+
+// Locales lists the supported locales in the order they are offered.
+var Locales = []Locale{Italian, German}
+
+var catalogues = map[Locale]map[string]string{
+	Italian: italian,
+	German:  german,
+}
+
+// Supported reports whether s names a supported locale.
+func Supported(s string) bool {
+	return slices.Contains(Locales, Locale(s))
+}
+
+// Parse returns the locale named by s, or Default when s is not supported.
+func Parse(s string) Locale {
+	if Supported(s) {
+		return Locale(s)
+	}
+	return Default
+}
+
+// Negotiate picks the supported locale an Accept-Language header prefers,
+// or Default when it names none of them.
+func Negotiate(header string) Locale {
+	best, bestQ := Default, 0.0
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if Supported(lang) && q > bestQ {
+			best, bestQ = Locale(lang), q
+		}
+	}
+	return best
+}
+
+// Name is the locale's name in its own language, for language pickers.
+func (l Locale) Name() string {
+	switch l {
+	case Italian:
+		return "Italiano"
+	case German:
+		return "Deutsch"
+	default:
+		return string(l)
+	}
+}
+
+// T returns the message for key, formatted with args when there are any.
+// A key missing from the locale's catalogue falls back to Default, then to
+// the key itself so a gap shows up on the page rather than as blank text.
+func (l Locale) T(key string, args ...any) string {
+	msg, ok := l.lookup(key)
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Error translates the code of a domain error, looking through wrapped
+// errors. It returns "" when no error in the chain has a message: callers
+// treat such errors as internal.
+func (l Locale) Error(err error) string {
+	if err == nil {
+		return ""
+	}
+	if msg, ok := l.lookup(err.Error()); ok {
+		return msg
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return l.Error(e.Unwrap())
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if msg := l.Error(inner); msg != "" {
+				return msg
+			}
+		}
+	}
+	return ""
+}
+
+func (l Locale) lookup(key string) (string, bool) {
+	if msg, ok := catalogues[l][key]; ok {
+		return msg, true
+	}
+	msg, ok := catalogues[Default][key]
+	return msg, ok
+}
+
+type ctxKey struct{}
+
+// NewContext returns a context carrying the locale of the current request.
+func NewContext(ctx context.Context, l Locale) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the locale carried by ctx, or Default.
+func FromContext(ctx context.Context) Locale {
+	if l, ok := ctx.Value(ctxKey{}).(Locale); ok {
+		return l
+	}
+	return Default
+}
+
+// Date formats a calendar date the way the locale writes it.
+func (l Locale) Date(t time.Time) string {
+	return t.Format(l.format().date)
+}
+
+// DayMonth formats the day and month of a date, for dates near enough that
+// the year goes without saying.
+func (l Locale) DayMonth(t time.Time) string {
+	return t.Format(l.format().dayMonth)
+}
+
+// Weekday names a day of the week.
+func (l Locale) Weekday(d time.Weekday) string {
+	return l.T("weekday." + strconv.Itoa(int(d)))
+}
+
+// ShortWeekday abbreviates the name of a day of the week.
+func (l Locale) ShortWeekday(d time.Weekday) string {
+	return l.T("weekday_short." + strconv.Itoa(int(d)))
+}
+
+// DateTime formats a date with the time of day, in local time.
+func (l Locale) DateTime(t time.Time) string {
+	return t.Local().Format(l.format().date + " 15:04")
+}
+
+// Number formats f with the locale's decimal and grouping separators.
+// decimals is the number of digits after the separator; -1 uses as many as
+// needed.
+func (l Locale) Number(f float64, decimals int) string {
+	s := strconv.FormatFloat(f, 'f', decimals, 64)
+	sign := ""
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		sign, s = "-", rest
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	nf := l.format()
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(nf.group)
+		}
+		b.WriteRune(d)
+	}
+	if hasFrac {
+		b.WriteString(nf.decimal)
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// Int formats a whole number with the locale's grouping separator.
+func (l Locale) Int(n int64) string {
+	return l.Number(float64(n), 0)
+}
+
+type format struct {
+	date     string
+	dayMonth string
+	decimal  string
+	group    string
+}
+
+var formats = map[Locale]format{
+	Italian: {date: "02/01/2006", dayMonth: "02/01", decimal: ",", group: "."},
+	German:  {date: "02.01.2006", dayMonth: "02.01.", decimal: ",", group: "."},
+}
+
+func (l Locale) format() format {
+	if f, ok := formats[l]; ok {
+		return f
+	}
+	return formats[Default]
+}
@@ -0,0 +1,101 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var verb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCataloguesHaveTheSameKeysAndVerbs(t *testing.T) {
+	for _, l := range Locales {
+		if l == Default {
+			continue
+		}
+		for key, msg := range catalogues[Default] {
+			translated, ok := catalogues[l][key]
+			if !ok {
+				t.Errorf("%s: missing %q", l, key)
+				continue
+			}
+			if got, want := fmt.Sprint(verb.FindAllString(translated, -1)), fmt.Sprint(verb.FindAllString(msg, -1)); got != want {
+				t.Errorf("%s: %q has verbs %s, want %s", l, key, got, want)
+			}
+		}
+		for key := range catalogues[l] {
+			if _, ok := catalogues[Default][key]; !ok {
+				t.Errorf("%s: %q is not in the default catalogue", l, key)
+			}
+		}
+	}
+}
+
+func TestTFallsBackToTheKey(t *testing.T) {
+	if got := German.T("no.such_key"); got != "no.such_key" {
+		t.Errorf("T = %q, want the key", got)
+	}
+	if got := German.T("sessions.revoked_others", 3); got != "Beendete Sitzungen: 3." {
+		t.Errorf("T = %q", got)
+	}
+}
+
+func TestErrorTranslatesWrappedCodes(t *testing.T) {
+	errNameRequired := errors.New("patient.name_required")
+
+	if got := Italian.Error(fmt.Errorf("creating patient: %w", errNameRequired)); got != italian["patient.name_required"] {
+		t.Errorf("Error = %q", got)
+	}
+	if got := German.Error(errors.Join(errors.New("boom"), errNameRequired)); got != german["patient.name_required"] {
+		t.Errorf("Error = %q", got)
+	}
+	if got := Italian.Error(errors.New("connection refused")); got != "" {
+		t.Errorf("Error = %q, want empty for an internal error", got)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Locale
+	}{
+		{"", Italian},
+		{"de-DE,de;q=0.9,en;q=0.8", German},
+		{"en-US,it;q=0.5,de;q=0.7", German},
+		{"fr-FR,fr;q=0.9", Italian},
+		{"de;q=bad,it", Italian},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	d := time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)
+	if got := German.Date(d); got != "07.03.2026" {
+		t.Errorf("German date = %q", got)
+	}
+	if got := Italian.Date(d); got != "07/03/2026" {
+		t.Errorf("Italian date = %q", got)
+	}
+
+	tests := []struct {
+		f        float64
+		decimals int
+		want     string
+	}{
+		{1.5, -1, "1,5"},
+		{1234567.25, 2, "1.234.567,25"},
+		{-1000, 0, "-1.000"},
+		{999, 0, "999"},
+	}
+	for _, tt := range tests {
+		if got := Italian.Number(tt.f, tt.decimals); got != tt.want {
+			t.Errorf("Number(%v, %d) = %q, want %q", tt.f, tt.decimals, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,666 @@
+package i18n
+
+// german translates the interface for the pharmacies of Alto Adige.
+var german = map[string]string{
+	// Navigation
+	"nav.pharmacies":      "Apotheken",
+	"nav.orders":          "Bestellungen",
+	"nav.patients":        "Patienten",
+	"nav.scan":            "Scannen",
+	"nav.shipping":        "Versand",
+	"nav.pickups":         "Abholungen",
+	"nav.notifications":   "Benachrichtigungen",
+	"nav.personnel":       "Personal",
+	"nav.roles":           "Rollen",
+	"nav.calendar":        "Kalender",
+	"nav.analytics":       "Statistiken",
+	"nav.branches":        "Filialen",
+	"nav.settings":        "Einstellungen",
+	"nav.change_password": "Passwort ändern",
+	"nav.account":         "Konto",
+	"nav.logout":          "Abmelden",
+
+	// Shared
+	"common.email":          "E-Mail",
+	"common.password":       "Passwort",
+	"common.back_to_login":  "Zurück zur Anmeldung",
+	"common.bad_request":    "Ungültige Anfrage.",
+	"common.internal_error": "Interner Fehler.",
+	"common.forbidden":      "Zugriff verweigert.",
+	"common.cancel":         "Abbrechen",
+	"common.save":           "Speichern",
+	"common.save_changes":   "Änderungen speichern",
+	"common.date":           "Datum",
+	"common.name":           "Name",
+	"common.phone":          "Telefon",
+	"common.notes":          "Notizen",
+	"common.patient":        "Patient",
+	"common.medication":     "Medikament",
+	"common.status":         "Status",
+	"common.all":            "Alle",
+	"common.yes":            "Ja",
+	"common.no":             "Nein",
+
+	// Login and passwords
+	"login.title":                 "Anmeldung",
+	"login.heading":               "Anmelden",
+	"login.submit":                "Anmelden",
+	"login.email_placeholder":     "name@apotheke.it",
+	"login.forgot_password":       "Passwort vergessen?",
+	"login.session_expired":       "Sitzung abgelaufen. Bitte melden Sie sich erneut an.",
+	"login.too_many_codes":        "Zu viele falsche Codes. Bitte melden Sie sich erneut an.",
+	"login.sso_unavailable":       "Der Anmeldeprovider antwortet nicht. Bitte versuchen Sie es später erneut.",
+	"login.sso_cancelled":         "Unternehmensanmeldung abgebrochen.",
+	"password.change_title":       "Passwort ändern",
+	"password.must_change":        "Ihr Passwort wurde zurückgesetzt. Wählen Sie ein neues, um fortzufahren.",
+	"password.temporary":          "Temporäres Passwort",
+	"password.current":            "Aktuelles Passwort",
+	"password.new":                "Neues Passwort",
+	"password.current_wrong":      "Das aktuelle Passwort ist falsch.",
+	"password.changed":            "Passwort aktualisiert.",
+	"password.forgot_title":       "Passwort vergessen",
+	"password.forgot_intro":       "Geben Sie die E-Mail-Adresse Ihres Kontos ein: Wir senden Ihnen einen Link, um ein neues Passwort zu wählen.",
+	"password.reset_link_sent":    "Wenn die E-Mail-Adresse zu einem aktiven Konto gehört, erhalten Sie in Kürze einen Link, um ein neues Passwort zu wählen. Der Link ist eine Stunde gültig.",
+	"password.send_link":          "Link senden",
+	"password.email_required":     "Geben Sie die E-Mail-Adresse Ihres Kontos ein.",
+	"password.reset_done":         "Passwort aktualisiert. Melden Sie sich mit dem neuen Passwort an.",
+	"password.reset_link_invalid": "Der Link ist ungültig oder abgelaufen. Fordern Sie einen neuen an.",
+	"password.new_required":       "Geben Sie das neue Passwort ein.",
+	"password.save":               "Passwort speichern",
+	"password.request_new_link":   "Neuen Link anfordern",
+
+	// Two-factor authentication
+	"two_factor.verify_title":         "Bestätigung in zwei Schritten",
+	"two_factor.verify_intro":         "Geben Sie den 6-stelligen Code Ihrer Authenticator-App oder einen Ihrer Wiederherstellungscodes ein.",
+	"two_factor.code":                 "Code",
+	"two_factor.verify":               "Bestätigen",
+	"two_factor.current_code":         "Aktueller Code",
+	"two_factor.title":                "Zwei-Faktor-Authentifizierung",
+	"two_factor.enabled_badge":        "aktiv",
+	"two_factor.must_enrol":           "Ihr Konto erfordert die Zwei-Faktor-Authentifizierung. Richten Sie sie ein, um fortzufahren.",
+	"two_factor.recovery_codes":       "Wiederherstellungscodes",
+	"two_factor.recovery_codes_intro": "Bewahren Sie sie sicher auf: Jeder Code gilt für eine Anmeldung ohne Ihr Telefon. Sie werden nicht erneut angezeigt.",
+	"two_factor.recovery_codes_left":  "Verbleibende Wiederherstellungscodes:",
+	"two_factor.new_recovery_codes":   "Neue Wiederherstellungscodes",
+	"two_factor.generate_codes":       "Neue Codes erzeugen",
+	"two_factor.disable":              "Deaktivieren",
+	"two_factor.disable_submit":       "2FA deaktivieren",
+	"two_factor.enrol_intro":          "Scannen Sie den QR-Code mit einer Authenticator-App (zum Beispiel Google Authenticator oder Aegis) und geben Sie dann den angezeigten 6-stelligen Code ein.",
+	"two_factor.manual_key":           "Manueller Schlüssel:",
+	"two_factor.enable":               "Aktivieren",
+	"two_factor.enabled":              "Zwei-Faktor-Authentifizierung aktiviert.",
+	"two_factor.disabled":             "Zwei-Faktor-Authentifizierung deaktiviert.",
+	"two_factor.codes_regenerated":    "Neue Wiederherstellungscodes erzeugt. Die bisherigen sind nicht mehr gültig.",
+
+	// Sessions and account
+	"sessions.title":              "Aktive Sitzungen",
+	"sessions.intro":              "Die Geräte, auf denen Sie angemeldet sind. Wenn Sie eines nicht erkennen, beenden Sie die Sitzung und ändern Sie Ihr Passwort.",
+	"sessions.device":             "Gerät",
+	"sessions.ip":                 "IP-Adresse",
+	"sessions.signed_in":          "Anmeldung",
+	"sessions.last_seen":          "Letzte Aktivität",
+	"sessions.current":            "diese Sitzung",
+	"sessions.revoke":             "Beenden",
+	"sessions.revoke_others":      "Alle anderen Sitzungen abmelden",
+	"sessions.back_to_account":    "Zurück zum Profil",
+	"sessions.not_found":          "Sitzung nicht gefunden oder bereits beendet.",
+	"sessions.revoked":            "Sitzung beendet.",
+	"sessions.revoked_others":     "Beendete Sitzungen: %d.",
+	"account.title":               "Mein Konto",
+	"account.language":            "Sprache",
+	"account.language_pharmacy":   "Sprache der Apotheke",
+	"account.language_saved":      "Sprache gespeichert.",
+	"account.recent_logins":       "Letzte Anmeldungen",
+	"account.recent_logins_intro": "Wenn Sie eine Anmeldung nicht erkennen, ändern Sie sofort Ihr Passwort und informieren Sie den Apothekeninhaber.",
+	"account.no_logins":           "Keine Anmeldungen erfasst.",
+	"account.outcome":             "Ergebnis",
+	"account.login_succeeded":     "erfolgreich",
+	"account.login_failed":        "fehlgeschlagen",
+
+	// Orders
+	"supply.ok":                      "ok",
+	"supply.approaching":             "geht zur Neige",
+	"supply.depleted":                "aufgebraucht",
+	"order_status.pending":           "Offen",
+	"order_status.prepared":          "Vorbereitet",
+	"order_status.fulfilled":         "Abgeschlossen",
+	"fulfillment.pickup":             "Abholung",
+	"fulfillment.shipping":           "Versand",
+	"fulfillment.pickup_in_pharmacy": "Abholung in der Apotheke",
+	"orders.title":                   "Bestellübersicht",
+	"orders.mark_prepared":           "Als vorbereitet markieren",
+	"orders.mark_fulfilled":          "Als abgeschlossen markieren",
+	"orders.filter_supply":           "Verordnungsstatus",
+	"orders.filter_depleted":         "Aufgebraucht",
+	"orders.filter_approaching":      "Gehen zur Neige",
+	"orders.order_status":            "Bestellstatus",
+	"orders.filter_active":           "Aktiv",
+	"orders.prepare_from":            "Vorbereiten ab",
+	"orders.prepare_to":              "bis",
+	"orders.filter":                  "Filtern",
+	"orders.print":                   "Bestellungen drucken",
+	"orders.print_labels":            "Etiketten drucken",
+	"orders.print_pdf":               "Bestellungen als PDF",
+	"orders.labels_pdf":              "Etiketten als PDF",
+	"orders.empty":                   "Keine aktiven Bestellungen. Legen Sie Patienten und Verordnungen an, um zu beginnen.",
+	"orders.depletion":               "Aufgebraucht am",
+	"orders.prepare_by":              "Vorbereiten bis",
+	"orders.days_left":               "Tage übrig",
+	"orders.supply":                  "Verordnung",
+	"orders.fulfillment":             "Übergabe",
+	"orders.brought_forward":         "Wegen Schließung der Apotheke vorgezogen",
+	"orders.pickup":                  "Abholung",
+	"orders.label":                   "Etikett",
+
+	// Days of the week, indexed like time.Weekday
+	"weekday.0":       "Sonntag",
+	"weekday.1":       "Montag",
+	"weekday.2":       "Dienstag",
+	"weekday.3":       "Mittwoch",
+	"weekday.4":       "Donnerstag",
+	"weekday.5":       "Freitag",
+	"weekday.6":       "Samstag",
+	"weekday_short.0": "So",
+	"weekday_short.1": "Mo",
+	"weekday_short.2": "Di",
+	"weekday_short.3": "Mi",
+	"weekday_short.4": "Do",
+	"weekday_short.5": "Fr",
+	"weekday_short.6": "Sa",
+
+	// Pickups
+	"pickup.confirmed":           "Bestätigt",
+	"pickup.suggested":           "Vorgeschlagen",
+	"pickup.notified":            "Benachrichtigt",
+	"pickup.move":                "Verschieben",
+	"pickup.day_title":           "Abholungen am %s",
+	"pickup.notify_failed":       "Abholung festgelegt, aber der Patient konnte nicht benachrichtigt werden. Bitte kontaktieren Sie ihn direkt.",
+	"pickup.previous_day":        "Vorheriger Tag",
+	"pickup.go":                  "Los",
+	"pickup.next_day":            "Nächster Tag",
+	"pickup.hours_and_slots":     "Öffnungszeiten und Zeitfenster",
+	"pickup.closed":              "Die Apotheke ist an diesem Tag geschlossen.",
+	"pickup.booked":              "Geplante Abholungen: %d von %d Plätzen.",
+	"pickup.slot":                "Zeitfenster",
+	"pickup.occupancy":           "Belegung",
+	"pickup.outside_slots":       "Außerhalb der Zeitfenster",
+	"pickup.outside_slots_intro": "Abholungen zu Zeiten, die keinem Zeitfenster mehr entsprechen.",
+	"pickup.settings_title":      "Abholzeiten",
+	"pickup.slots":               "Zeitfenster",
+	"pickup.slot_minutes":        "Dauer eines Zeitfensters (Minuten)",
+	"pickup.slot_capacity":       "Patienten pro Zeitfenster",
+	"pickup.daily_capacity":      "Tageshöchstzahl (0 = unbegrenzt)",
+	"pickup.opening_hours":       "Öffnungszeiten",
+	"pickup.opening_hours_intro": "Lassen Sie die Zeiten an Schließtagen leer.",
+	"pickup.day":                 "Tag",
+	"pickup.morning":             "Vormittag",
+	"pickup.afternoon":           "Nachmittag",
+	"pickup.opens_at":            "%s Öffnung",
+	"pickup.closes_at":           "%s Schließung",
+	"pickup.assign_title":        "Abholung festlegen",
+	"pickup.expected_depletion":  "Voraussichtlich aufgebraucht am: %s",
+	"pickup.confirmed_at":        "Abholung festgelegt: %s",
+	"pickup.suggested_at":        "Vorgeschlagene Abholung: %s",
+	"pickup.no_free_slots":       "Keine freien Zeitfenster in den nächsten Tagen. Prüfen Sie die Abholzeiten.",
+	"pickup.slot_option":         "%s (%d frei)",
+	"pickup.notify_patient":      "Patienten benachrichtigen",
+	"pickup.confirm":             "Abholung bestätigen",
+	"pickup.choose_slot":         "Wählen Sie ein Zeitfenster.",
+
+	// Calendar
+	"month.1":                     "Januar",
+	"month.2":                     "Februar",
+	"month.3":                     "März",
+	"month.4":                     "April",
+	"month.5":                     "Mai",
+	"month.6":                     "Juni",
+	"month.7":                     "Juli",
+	"month.8":                     "August",
+	"month.9":                     "September",
+	"month.10":                    "Oktober",
+	"month.11":                    "November",
+	"month.12":                    "Dezember",
+	"calendar.title":              "Schließtage",
+	"calendar.intro":              "Bestellungen, die an einem Schließtag aufgebraucht sind, müssen bis zum letzten Öffnungstag davor vorbereitet werden.",
+	"calendar.recurring":          "Regelmäßige Schließtage",
+	"calendar.weekly":             "Wöchentlicher Ruhetag",
+	"calendar.national_holidays":  "An gesetzlichen Feiertagen geschlossen",
+	"calendar.patron":             "Patronatsfest",
+	"calendar.patron_placeholder": "Sankt Ambrosius",
+	"calendar.month":              "Monat",
+	"calendar.closures":           "Außerordentliche Schließungen",
+	"calendar.no_closures":        "Keine außerordentlichen Schließungen.",
+	"calendar.period":             "Zeitraum",
+	"calendar.reason":             "Grund",
+	"calendar.from":               "Von",
+	"calendar.to":                 "Bis",
+	"calendar.reason_placeholder": "Sommerurlaub",
+	"calendar.add_closure":        "Schließung hinzufügen",
+	"calendar.upcoming":           "Nächste Schließtage",
+	"calendar.upcoming_intro":     "Feiertage und außerordentliche Schließungen der nächsten zwölf Monate, ohne den wöchentlichen Ruhetag.",
+	"calendar.no_upcoming":        "Keine Schließungen geplant.",
+	"common.delete":               "Löschen",
+	"common.add":                  "Hinzufügen",
+
+	// Scanning
+	"scan.title":             "Etiketten scannen",
+	"scan.intro":             "Scannen Sie den Barcode eines Etiketts, um die Bestellung weiterzuführen: von offen zu vorbereitet, von vorbereitet zu abgeschlossen.",
+	"scan.code":              "Etikettencode",
+	"scan.submit":            "Bestellung weiterführen",
+	"scan.order_not_found":   "Bestellung nicht gefunden.",
+	"scan.already_fulfilled": "Die Bestellung von %s %s (%s) ist bereits ausgegeben.",
+	"scan.forbidden":         "Sie haben keine Berechtigung, Bestellungen auszugeben.",
+	"scan.advanced":          "%s %s — %s: %s.",
+
+	// Notifications
+	"notifications.mark_all_read": "Alle als gelesen markieren",
+	"notifications.empty":         "Keine Benachrichtigungen.",
+	"notifications.read":          "Gelesen",
+	"notifications.unread":        "Ungelesen",
+	"notifications.mark_read":     "Als gelesen markieren",
+
+	// Patients
+	"patients.empty":             "Keine Patienten. Legen Sie den ersten Patienten an.",
+	"patients.consensus":         "Einwilligung",
+	"patients.consensus_active":  "Erteilt",
+	"patients.consensus_missing": "Ausstehend",
+	"patients.new_title":         "Neuer Patient",
+	"patients.first_name":        "Vorname",
+	"patients.last_name":         "Nachname",
+	"patients.fulfillment":       "Übergabeart",
+	"patients.create":            "Patient anlegen",
+
+	// Delivery addresses
+	"address.title":                    "Lieferadresse",
+	"address.legacy":                   "Aus Freitext übernommene Adresse: „%s“. Prüfen Sie die Felder und speichern Sie, um sie zu bestätigen.",
+	"address.street":                   "Straße",
+	"address.house_number":             "Hausnummer",
+	"address.cap":                      "PLZ",
+	"address.city":                     "Gemeinde",
+	"address.province":                 "Provinz",
+	"address.country":                  "Land",
+	"address.notes":                    "Hinweise zur Lieferung",
+	"address.notes_placeholder":        "Klingel, Stockwerk, Uhrzeiten",
+	"common.edit":                      "Bearbeiten",
+	"patients.consensus_missing_alert": "Einwilligung nicht erfasst. Erfassen Sie die Einwilligung, um den Patienten zu aktivieren.",
+	"patients.record_consensus":        "Einwilligung erfassen",
+	"patients.consensus_active_badge":  "Einwilligung erteilt",
+	"patients.back":                    "Zurück zu den Patienten",
+	"patients.transfer":                "In andere Filiale verlegen",
+
+	// Prescriptions
+	"stock_source.portal":                   "Patientenbereich",
+	"stock_source.staff":                    "in der Apotheke",
+	"stock_source.reply":                    "Antwort auf die Erinnerung",
+	"prescriptions.title":                   "Verordnungen",
+	"prescriptions.add":                     "Verordnung hinzufügen",
+	"prescriptions.empty":                   "Keine Verordnungen erfasst.",
+	"prescriptions.units_per_box_short":     "Einh./Pack.",
+	"prescriptions.daily_consumption_short": "Verbrauch/Tag",
+	"prescriptions.box_start_short":         "Packungsbeginn",
+	"prescriptions.estimated_depletion":     "Voraussichtlich aufgebraucht",
+	"prescriptions.last_report":             "%d Einheiten am %s (%s)",
+	"prescriptions.refill":                  "Nachfüllung",
+	"prescriptions.units_left":              "Verbleibende Einheiten",
+	"prescriptions.record":                  "Erfassen",
+	"prescriptions.new_title":               "Neue Verordnung",
+	"prescriptions.edit_title":              "Verordnung bearbeiten",
+	"prescriptions.medication_name":         "Name des Medikaments",
+	"prescriptions.units_per_box":           "Einheiten pro Packung",
+	"prescriptions.daily_consumption":       "Tagesverbrauch (Einheiten/Tag)",
+	"prescriptions.box_start":               "Beginn der Packung",
+	"prescriptions.create":                  "Verordnung anlegen",
+
+	// Shipping
+	"shipping_status.ready":     "Zu versenden",
+	"shipping_status.shipped":   "Versandt",
+	"shipping_status.delivered": "Zugestellt",
+	"batch_status.open":         "In Vorbereitung",
+	"batch_status.shipped":      "Dem Kurier übergeben",
+	"shipping.shippable":        "Vorbereitete Bestellungen zum Versand",
+	"shipping.no_shippable":     "Keine vorbereiteten Bestellungen warten auf den Versand.",
+	"shipping.waiting":          "%d vorbereitete Bestellungen warten auf eine Sendung.",
+	"shipping.address":          "Adresse",
+	"shipping.create_batch":     "Sendung erstellen",
+	"shipping.batches":          "Sendungen",
+	"shipping.no_batches":       "Keine Sendungen.",
+	"shipping.batch":            "Sendung",
+	"shipping.batch_title":      "Sendung #%d",
+	"shipping.created_on":       "Erstellt am",
+	"shipping.created_at":       "Erstellt am %s",
+	"shipping.shipped_at":       "dem Kurier übergeben am %s",
+	"shipping.parcels":          "Pakete",
+	"shipping.delivered":        "Zugestellt",
+	"shipping.print_manifest":   "Versandliste drucken",
+	"shipping.export_csv":       "CSV für den Kurier exportieren",
+	"shipping.mark_shipped":     "Als dem Kurier übergeben markieren",
+	"shipping.tracking":         "Sendungsnummer",
+	"shipping.mark_delivered":   "Als zugestellt markieren",
+	"shipping.save_tracking":    "Sendungsnummern speichern",
+
+	// Analytics
+	"analytics.one_day":               "1 Tag",
+	"analytics.days":                  "%s Tage",
+	"analytics.created":               "Erstellt",
+	"analytics.prepared":              "Vorbereitet",
+	"analytics.fulfilled":             "Abgeschlossen",
+	"analytics.orders":                "Bestellungen",
+	"analytics.period":                "Letzte %d Wochen, seit %s.",
+	"analytics.median_fulfillment":    "Mittlere Zeit von Bestellung bis Abholung",
+	"analytics.no_fulfilled":          "Keine abgeschlossenen Bestellungen im Zeitraum.",
+	"analytics.fulfilled_count":       "bei %d abgeschlossenen Bestellungen",
+	"analytics.late_refills":          "Nachfüllungen nach dem Aufbrauchen",
+	"analytics.no_refills":            "Keine Nachfüllungen im Zeitraum.",
+	"analytics.late_refills_count":    "%d von %d Nachfüllungen",
+	"analytics.forecast_total":        "Erwartete Bestellungen",
+	"analytics.next_days":             "in den nächsten %d Tagen",
+	"analytics.weekly":                "Bestellungen pro Woche",
+	"analytics.weekly_chart":          "Erstellte, vorbereitete und abgeschlossene Bestellungen pro Woche",
+	"analytics.forecast":              "Bestellprognose",
+	"analytics.forecast_intro":        "Täglich vorzubereitende Bestellungen nach dem voraussichtlichen Aufbrauchen der aktiven Verordnungen und dem Schließkalender.",
+	"analytics.forecast_chart":        "Vorzubereitende Bestellungen pro Tag",
+	"analytics.top_medications":       "Meistbestellte Medikamente",
+	"analytics.no_orders":             "Keine Bestellungen im Zeitraum.",
+	"analytics.top_medications_chart": "Medikamente mit den meisten Bestellungen",
+	"common.back":                     "Zurück",
+
+	// Branches and transfers
+	"group.name":                 "Gruppe %s",
+	"group.none":                 "Die Apotheke gehört zu keiner Gruppe. Der Administrator kann sie mit den anderen Filialen verbinden.",
+	"group.active_patients":      "Aktive Patienten",
+	"group.prescriptions_count":  "%d Verordnungen",
+	"group.overdue_orders":       "Überfällige Bestellungen",
+	"group.branches_count":       "in %d Filialen",
+	"group.unread_notifications": "Ungelesene Benachrichtigungen",
+	"group.branch":               "Filiale",
+	"group.active":               "aktiv",
+	"group.switch":               "Zu dieser Filiale wechseln",
+	"transfer.title":             "Patienten verlegen",
+	"transfer.heading":           "%s verlegen",
+	"transfer.intro":             "Verordnungen, Nachfüllungen, Bestandsmeldungen und Bestellungen gehen an die neue Filiale über. Bereits vorgeschlagene Abholzeiten werden storniert.",
+	"transfer.no_targets":        "Keine andere Filiale verfügbar.",
+	"transfer.target":            "Zielfiliale",
+	"transfer.submit":            "Verlegen",
+	"transfer.target_required":   "Wählen Sie die Zielfiliale.",
+
+	// Roles and permissions
+	"role_name.admin":                      "Administrator",
+	"role_name.owner":                      "Inhaber",
+	"role_name.pharmacist":                 "Verantwortlicher Apotheker",
+	"role_name.personnel":                  "Personal",
+	"role_name.trainee":                    "Praktikant",
+	"role_name.driver":                     "Lieferfahrer",
+	"permission_name.patients.view":        "Patienten und Verordnungen ansehen",
+	"permission_name.patients.edit":        "Patienten anlegen und bearbeiten",
+	"permission_name.prescriptions.edit":   "Verordnungen anlegen und bearbeiten",
+	"permission_name.prescriptions.refill": "Nachfüllungen und Bestände erfassen",
+	"permission_name.orders.view":          "Bestellungen und Benachrichtigungen ansehen",
+	"permission_name.orders.advance":       "Bestellungen weiterführen",
+	"permission_name.pickups.assign":       "Abholungen festlegen",
+	"permission_name.shipping.view":        "Versand ansehen",
+	"permission_name.shipping.manage":      "Sendungen vorbereiten und versenden",
+	"permission_name.shipping.deliver":     "Zustellungen erfassen",
+	"permission_name.schedule.manage":      "Öffnungszeiten und Kalender verwalten",
+	"permission_name.analytics.view":       "Statistiken ansehen",
+	"permission_name.patients.transfer":    "Patienten zwischen Filialen verlegen",
+	"permission_name.personnel.manage":     "Personal verwalten",
+	"permission_name.roles.manage":         "Rollen verwalten",
+	"permission_name.branches.switch":      "Zu anderen Filialen wechseln",
+	"permission_name.settings.manage":      "Apothekeneinstellungen verwalten",
+	"permission_name.pharmacies.manage":    "Apotheken verwalten",
+	"roles.new":                            "Neue Rolle",
+	"roles.role":                           "Rolle",
+	"roles.custom":                         "Eigene Rollen",
+	"roles.no_custom":                      "Keine eigenen Rollen. Legen Sie eine an, um dem Personal nur die nötigen Berechtigungen zu geben.",
+	"roles.permissions":                    "Berechtigungen",
+	"roles.holders":                        "Zugewiesen an",
+	"roles.builtin":                        "Vordefinierte Rollen",
+	"roles.change_logs_out":                "Wer diese Rolle hat, wird abgemeldet, wenn Sie die Berechtigungen ändern, und erhält sie bei der nächsten Anmeldung.",
+	"roles.delete":                         "Rolle löschen",
+
+	// Personnel
+	"personnel.empty":                 "Kein Personal.",
+	"personnel.role":                  "Rolle",
+	"personnel.new_title":             "Neues Personal",
+	"personnel.create":                "Personal anlegen",
+	"personnel.deactivated":           "deaktiviert",
+	"personnel.temporary_password":    "temporäres Passwort",
+	"personnel.locked":                "gesperrt",
+	"personnel.self":                  "Das ist Ihr Konto. Um das Passwort zu ändern, verwenden Sie",
+	"personnel.access":                "Zugang",
+	"personnel.locked_until":          "Nach zu vielen fehlgeschlagenen Anmeldeversuchen gesperrt bis %s.",
+	"personnel.unlock":                "Entsperren",
+	"personnel.deactivate_intro":      "Beim Deaktivieren des Kontos wird der Benutzer sofort auf allen Geräten abgemeldet.",
+	"personnel.deactivate":            "Deaktivieren",
+	"personnel.deactivated_intro":     "Das Konto ist deaktiviert und kann sich nicht anmelden.",
+	"personnel.reactivate":            "Reaktivieren",
+	"personnel.change_role":           "Rolle ändern",
+	"personnel.reset_password":        "Passwort zurücksetzen",
+	"personnel.reset":                 "Zurücksetzen",
+	"personnel.reset_2fa_intro":       "Setzen Sie das Gerät zurück, wenn der Benutzer es verloren hat. Er wird auf allen Geräten abgemeldet.",
+	"personnel.reset_2fa":             "2FA zurücksetzen",
+	"personnel.delete":                "Endgültig entfernen",
+	"personnel.fields_required":       "Alle Felder sind erforderlich.",
+	"personnel.deactivated_done":      "Konto deaktiviert.",
+	"personnel.reactivated_done":      "Konto reaktiviert.",
+	"personnel.unlocked_done":         "Konto entsperrt.",
+	"personnel.role_changed":          "Rolle aktualisiert.",
+	"personnel.password_reset_done":   "Temporäres Passwort gesetzt. Bei der nächsten Anmeldung muss es geändert werden.",
+	"personnel.two_factor_reset_done": "Zwei-Faktor-Authentifizierung zurückgesetzt. Bei der nächsten Anmeldung kann ein neues Gerät eingerichtet werden.",
+
+	// Administration
+	"admin.never":                 "nie",
+	"admin.new_pharmacy":          "Neue Apotheke",
+	"admin.new_pharmacy_title":    "Neue Apotheke",
+	"admin.no_pharmacies":         "Keine Apotheken registriert.",
+	"admin.inactive_count":        "%d inaktiv",
+	"admin.all_active":            "alle aktiv",
+	"admin.unread_count":          "%d ungelesene Benachrichtigungen",
+	"admin.last_login":            "Letzte Anmeldung",
+	"admin.inactive":              "inaktiv",
+	"admin.details":               "Details",
+	"admin.inactive_after":        "Eine Apotheke ist inaktiv, wenn sich seit mehr als %d Tagen niemand vom Personal angemeldet hat.",
+	"admin.pharmacy":              "Apotheke",
+	"admin.create_pharmacy":       "Apotheke anlegen",
+	"admin.group":                 "Gruppe",
+	"admin.no_group":              "Keine Gruppe",
+	"admin.group_intro":           "Apotheken derselben Gruppe sind Filialen desselben Inhabers: Inhaber können zwischen ihnen wechseln und Patienten verlegen.",
+	"admin.require_2fa":           "Zwei-Faktor-Authentifizierung für das Personal verpflichtend",
+	"admin.label_layout":          "Etikettenformat (PDF)",
+	"admin.label_layout_a4":       "A4, 2×7 Etiketten",
+	"admin.label_layout_roll":     "Brother-Rolle 62 mm",
+	"admin.locale":                "Standardsprache",
+	"admin.locale_intro":          "Die Sprache der Oberfläche für Personal, das keine eigene gewählt hat.",
+	"admin.sso_intro":             "Das Personal kann sich über den Identity Provider der Apotheke anmelden (OpenID Connect).",
+	"admin.configure_sso":         "SSO einrichten",
+	"admin.sessions":              "Sitzungen",
+	"admin.revoke_sessions_intro": "Meldet das gesamte Personal der Apotheke auf allen Geräten ab, zum Beispiel nach dem Verlust eines Computers. Alle müssen sich erneut anmelden.",
+	"admin.revoke_sessions":       "Gesamtes Personal abmelden",
+	"admin.back":                  "Zurück zu den Apotheken",
+	"admin.name_address_required": "Name und Adresse sind erforderlich.",
+	"admin.fields_required":       "Alle Pflichtfelder müssen ausgefüllt werden.",
+	"admin.owner_email_in_use":    "Die E-Mail-Adresse des Inhabers wird bereits verwendet.",
+
+	// Single sign-on
+	"sso.title":                 "Unternehmensanmeldung (SSO)",
+	"sso.title_short":           "Unternehmensanmeldung",
+	"sso.enabled_badge":         "aktiv",
+	"sso.intro":                 "Das Personal der Apotheke meldet sich über den Identity Provider an (OpenID Connect). Bei der ersten Anmeldung wird das Konto mit der Rolle aus den Claims angelegt.",
+	"sso.share":                 "Weiterzugeben",
+	"sso.redirect_uri":          "Beim Provider zu registrierende Redirect-URI:",
+	"sso.login_link":            "Anmeldelink für das Personal:",
+	"sso.enabled":               "Unternehmensanmeldung aktiv",
+	"sso.keep_secret":           "Leer lassen, um das gespeicherte beizubehalten",
+	"sso.role_claim":            "Rollen-Claim",
+	"sso.role_claim_intro":      "Claim des ID-Tokens mit den Gruppen oder Rollen des Benutzers, zum Beispiel groups oder roles.",
+	"sso.owner_value":           "Wert für Inhaber",
+	"sso.owner_value_intro":     "Wer diesen Wert hat, wird Inhaber. Leer: keine Inhaber über SSO.",
+	"sso.personnel_value":       "Wert für das Personal",
+	"sso.personnel_value_intro": "Wer diesen Wert hat, wird Personal. Leer: jeder, der sich beim Provider anmeldet.",
+	"sso.back":                  "Zurück zur Apotheke",
+
+	// Pharmacy settings
+	"settings.title":                     "Apothekeneinstellungen",
+	"settings.saved":                     "Einstellungen gespeichert.",
+	"settings.contacts":                  "Kontakt",
+	"settings.vat_number":                "Umsatzsteuer-Identifikationsnummer (Partita IVA)",
+	"settings.orders":                    "Bestellungen",
+	"settings.default_fulfillment":       "Standard-Zustellung für neue Patienten",
+	"settings.default_fulfillment_intro": "Wird beim Anlegen eines Patienten vorgeschlagen und kann jederzeit geändert werden.",
+	"settings.lookahead_days":            "Vorlauf der Bestellungen in Tagen",
+	"settings.lookahead_intro":           "Wie viele Tage vor dem Aufbrauchen des Medikaments die Bestellung erstellt wird. 0 verwendet die Servereinstellung.",
+	"settings.reminder":                  "Patientenbenachrichtigung",
+	"settings.pickup_reminder":           "Nachricht bei festgelegter Abholung",
+	"settings.reminder_fields":           "Verfügbare Platzhalter: %s",
+	"settings.opening_hours_intro":       "Öffnungszeiten und Abholzeitfenster werden festgelegt unter",
+	"settings.logo":                      "Logo auf den Etiketten",
+	"settings.logo_intro":                "Wird auf die Bestelletiketten gedruckt. PNG oder JPEG, höchstens 2 MB.",
+	"settings.upload_logo":               "Neues Logo",
+	"settings.upload":                    "Hochladen",
+	"settings.remove_logo":               "Logo entfernen",
+
+	// Printing
+	"print.printed_at":   "Gedruckt am: %s",
+	"print.no_orders":    "Keine Bestellungen zu drucken.",
+	"manifest.title":     "Versandliste Sendung #%d",
+	"manifest.heading":   "Versandliste #%d",
+	"manifest.ref":       "Ref.",
+	"manifest.recipient": "Empfänger",
+	"manifest.contents":  "Inhalt",
+	"manifest.signature": "Unterschrift Kurier:",
+
+	// Domain errors, keyed by their codes
+	"user.invalid_credentials":              "Ungültige Anmeldedaten.",
+	"user.deactivated":                      "Konto deaktiviert. Wenden Sie sich an den Apothekeninhaber.",
+	"user.account_locked":                   "Zu viele fehlgeschlagene Versuche: Das Konto ist vorübergehend gesperrt. Versuchen Sie es später erneut oder bitten Sie den Apothekeninhaber um Entsperrung.",
+	"user.too_many_attempts":                "Zu viele fehlgeschlagene Versuche aus diesem Netzwerk. Versuchen Sie es in einigen Minuten erneut.",
+	"user.invalid_reset_token":              "Der Link ist ungültig oder abgelaufen.",
+	"user.password_required":                "Geben Sie ein Passwort ein.",
+	"user.invalid_second_factor":            "Ungültiger Code.",
+	"user.two_factor_required":              "Die Zwei-Faktor-Authentifizierung ist für Ihr Konto verpflichtend und kann nicht deaktiviert werden.",
+	"user.email_taken":                      "Es gibt bereits ein Konto mit dieser E-Mail-Adresse: Melden Sie sich mit E-Mail und Passwort an.",
+	"user.other_pharmacy":                   "Ihr Konto gehört zu einer anderen Apotheke: Verwenden Sie den Anmeldelink dieser Apotheke.",
+	"user.invalid_locale":                   "Sprache nicht unterstützt.",
+	"user.federated":                        "Dieses Konto meldet sich über die Unternehmensanmeldung der Apotheke an: Verwenden Sie den Anmeldelink der Apotheke.",
+	"permission.forbidden":                  "Sie haben keine Berechtigung für diesen Vorgang.",
+	"permission.not_assignable":             "Eine der gewählten Berechtigungen kann keiner eigenen Rolle gegeben werden.",
+	"role.name_required":                    "Der Name der Rolle ist erforderlich.",
+	"role.name_too_long":                    "Der Name der Rolle ist zu lang.",
+	"role.name_taken":                       "Es gibt bereits eine Rolle mit diesem Namen.",
+	"role.no_permissions":                   "Wählen Sie mindestens eine Berechtigung.",
+	"role.in_use":                           "Die Rolle ist noch jemandem vom Personal zugewiesen: Ändern Sie zuerst dessen Rolle.",
+	"pharmacy.duplicate_email":              "Die E-Mail-Adresse wird bereits verwendet.",
+	"pharmacy.invalid_label_layout":         "Ungültiges Etikettenformat.",
+	"pharmacy.invalid_locale":               "Sprache nicht unterstützt.",
+	"pharmacy.last_owner":                   "Die Apotheke muss mindestens einen aktiven Inhaber haben.",
+	"pharmacy.self_change":                  "Sie können Ihr eigenes Konto hier nicht ändern.",
+	"pharmacy.federated_member":             "Dieses Mitglied meldet sich über die Unternehmensanmeldung an: Das Passwort wird dort verwaltet.",
+	"pharmacy.invalid_role":                 "Ungültige Rolle.",
+	"pharmacy.address_required":             "Die Adresse ist erforderlich.",
+	"pharmacy.invalid_email":                "Ungültige E-Mail-Adresse.",
+	"pharmacy.invalid_vat_number":           "Ungültige Partita IVA: Es werden 11 Ziffern mit korrekter Prüfziffer benötigt.",
+	"pharmacy.invalid_fulfillment":          "Ungültige Standard-Zustellung.",
+	"pharmacy.invalid_lookahead":            "Der Vorlauf muss zwischen 0 und 60 Tagen liegen.",
+	"pharmacy.invalid_reminder":             "Die Nachricht enthält einen unbekannten oder nicht geschlossenen Platzhalter.",
+	"pharmacy.reminder_too_long":            "Die Nachricht ist zu lang (höchstens 459 Zeichen).",
+	"pharmacy.invalid_logo":                 "Das Logo muss ein PNG- oder JPEG-Bild sein.",
+	"pharmacy.logo_too_large":               "Das Logo ist zu groß.",
+	"personnel.temporary_password_required": "Geben Sie ein temporäres Passwort ein.",
+	"patient.name_required":                 "Vor- und Nachname sind erforderlich.",
+	"patient.contact_required":              "Mindestens ein Kontakt ist erforderlich (Telefon oder E-Mail).",
+	"patient.delivery_address_required":     "Für den Versand ist eine Lieferadresse erforderlich.",
+	"patient.transfer_same_branch":          "Der Patient ist bereits in dieser Filiale.",
+	"patient.transfer_outside_group":        "Die Zielfiliale gehört nicht zur Gruppe.",
+	"patient.transfer_in_transit":           "Der Patient hat Bestellungen in einer noch nicht zugestellten Sendung.",
+	"address.street_required":               "Straße und Hausnummer sind erforderlich.",
+	"address.city_required":                 "Die Gemeinde ist erforderlich.",
+	"address.invalid_cap":                   "Die PLZ muss aus 5 Ziffern bestehen.",
+	"address.cap_required":                  "Die Postleitzahl ist erforderlich.",
+	"address.unknown_province":              "Wählen Sie eine gültige Provinz.",
+	"address.cap_province_mismatch":         "Die PLZ gehört nicht zur angegebenen Provinz.",
+	"address.invalid_country":               "Das Land muss ein Code aus 2 Buchstaben sein (z. B. IT).",
+	"prescription.no_consensus":             "Der Patient muss einwilligen, bevor Verordnungen hinzugefügt werden.",
+	"prescription.medication_required":      "Der Name des Medikaments ist erforderlich.",
+	"prescription.invalid_units_per_box":    "Die Einheiten pro Packung müssen größer als null sein.",
+	"prescription.invalid_consumption":      "Der Tagesverbrauch muss größer als null sein.",
+	"prescription.start_date_required":      "Der Beginn der Packung ist erforderlich.",
+	"prescription.consumption_exceeds_box":  "Der Tagesverbrauch muss kleiner als die Einheiten pro Packung sein.",
+	"prescription.invalid_stock_units":      "Die Zahl der verbleibenden Einheiten darf nicht negativ sein.",
+	"prescription.report_date_required":     "Das Datum der Meldung ist erforderlich.",
+	"prescription.invalid_stock_source":     "Ungültige Herkunft der Meldung.",
+	"order.invalid_transition":              "Ungültiger Statuswechsel.",
+	"order.invalid_reference":               "Code nicht erkannt.",
+	"pickup.invalid_hours":                  "Jede Öffnungszeit braucht Beginn und Ende, mit dem Ende nach dem Beginn.",
+	"pickup.overlapping_hours":              "Die Vormittags- und Nachmittagszeiten desselben Tages überschneiden sich.",
+	"pickup.invalid_slot_length":            "Die Dauer der Zeitfenster muss zwischen 5 und 240 Minuten liegen.",
+	"pickup.invalid_capacity":               "Geben Sie mindestens 1 Patienten pro Zeitfenster und eine nicht negative Tageshöchstzahl an.",
+	"pickup.not_pickup_order":               "Die Bestellung wird nach Hause geliefert, nicht in der Apotheke abgeholt.",
+	"pickup.order_not_prepared":             "Die Bestellung muss vorbereitet sein, bevor die Abholung festgelegt wird.",
+	"pickup.slot_unavailable":               "Die gewählte Zeit ist nicht mehr verfügbar. Wählen Sie eine andere.",
+	"pickup.no_pickup_slot":                 "Es wurde noch keine Abholzeit festgelegt.",
+	"pickup.order_collected":                "Die Bestellung wurde bereits abgeholt.",
+	"pickup.not_later":                      "Die neue Zeit muss nach der aktuellen liegen.",
+	"shipping.no_shippable_orders":          "Wählen Sie mindestens eine vorbereitete Bestellung zum Versand.",
+	"shipping.batch_already_shipped":        "Die Sendung wurde bereits dem Kurier übergeben.",
+	"shipping.invalid_transition":           "Ungültiger Statuswechsel.",
+	"shipping.tracking_too_long":            "Die Sendungsnummer ist zu lang.",
+	"calendar.invalid_patron_date":          "Geben Sie einen gültigen Tag und Monat für das Patronatsfest an oder lassen Sie alle Felder leer.",
+	"calendar.patron_name_too_long":         "Der Name des Patronatsfests ist zu lang.",
+	"calendar.always_closed":                "Die Apotheke muss mindestens einen Tag pro Woche geöffnet sein.",
+	"calendar.invalid_weekday":              "Ungültiger Wochentag.",
+	"calendar.closure_date_missing":         "Geben Sie Beginn und Ende der Schließung an.",
+	"calendar.invalid_closure":              "Das Enddatum muss gleich dem Beginn oder danach sein.",
+	"calendar.closure_too_long":             "Eine Schließung darf nicht länger als ein Jahr dauern.",
+	"calendar.reason_too_long":              "Der Grund der Schließung ist zu lang.",
+	"sso.not_configured":                    "Die Unternehmensanmeldung ist für diese Apotheke nicht verfügbar.",
+	"sso.invalid_issuer":                    "Der Issuer muss eine https-Adresse sein.",
+	"sso.client_id_required":                "Die Client-ID ist erforderlich.",
+	"sso.secret_required":                   "Das Client-Secret ist erforderlich.",
+	"sso.role_claim_required":               "Geben Sie den Claim an, aus dem die Rolle gelesen wird.",
+	"sso.no_access":                         "Ihr Unternehmenskonto ist für diese Apotheke nicht freigeschaltet.",
+	"sso.email_missing":                     "Der Anmeldeprovider hat keine bestätigte E-Mail-Adresse geliefert.",
+	"sso.invalid_response":                  "Unternehmensanmeldung fehlgeschlagen. Bitte erneut versuchen.",
+	"portal.invalid_contact":                "Geben Sie eine gültige E-Mail-Adresse oder Handynummer ein.",
+	"portal.invalid_link":                   "Der Link ist ungültig oder abgelaufen.",
+	"portal.invalid_code":                   "Ungültiger oder abgelaufener Code.",
+	"portal.confirmed":                      "Danke, die Abholung ist bestätigt.",
+	"portal.postponed":                      "Die Abholung wurde verschoben.",
+	"portal.reported":                       "Danke, die Apotheke berücksichtigt die Einheiten, die Sie noch haben.",
+	"portal.choose_time":                    "Wählen Sie eine Uhrzeit.",
+	"portal.units_required":                 "Geben Sie an, wie viele Einheiten Sie noch haben.",
+
+	// Patient portal pages
+	"portal.area":               "Patientenbereich",
+	"portal.login_title":        "Anmeldung für Patienten",
+	"portal.login_intro":        "Geben Sie die E-Mail-Adresse oder Handynummer ein, die Sie in der Apotheke hinterlassen haben. Wir senden Ihnen einen Anmeldelink oder -code.",
+	"portal.contact":            "E-Mail oder Handynummer",
+	"portal.send":               "Senden",
+	"portal.check_mail":         "Prüfen Sie Ihre E-Mails",
+	"portal.link_sent":          "Wenn die Adresse bei einer Apotheke registriert ist, erhalten Sie in Kürze einen Anmeldelink, der %d Minuten gültig ist.",
+	"portal.enter_code":         "Code eingeben",
+	"portal.code_sent":          "Wenn die Nummer bei einer Apotheke registriert ist, erhalten Sie in Kürze eine SMS mit einem Code, der %d Minuten gültig ist.",
+	"portal.code":               "Code",
+	"portal.sign_in":            "Anmelden",
+	"portal.new_code":           "Neuen Code anfordern",
+	"portal.new_link":           "Neuen Link anfordern",
+	"portal.enter":              "Zum Patientenbereich",
+	"portal.expected_depletion": "Voraussichtlich aufgebraucht am",
+	"portal.order":              "Bestellung",
+	"portal.home_delivery":      "Lieferung nach Hause",
+	"portal.pickup_confirmed":   "Abholung vereinbart",
+	"portal.pickup_proposed":    "Vorgeschlagene Abholung",
+	"portal.confirm_pickup":     "Abholung bestätigen",
+	"portal.postpone":           "Verschieben",
+	"portal.choose_slot":        "Uhrzeit wählen",
+	"portal.no_order":           "Keine laufende Bestellung.",
+	"portal.stock_left":         "Ich habe noch Tabletten",
+	"portal.last_report":        "Letzte Meldung: %d Einheiten am %s.",
+	"portal.units_left":         "Heute verbleibende Einheiten",
+	"portal.home_title":         "Ihre Therapien",
+	"portal.no_therapies":       "Keine Therapien erfasst.",
+	"portal.postpone_title":     "Abholung verschieben",
+	"portal.current_pickup":     "Aktuelle Abholung",
+	"portal.no_slots":           "In den nächsten Tagen ist keine Uhrzeit frei. Wenden Sie sich an die Apotheke.",
+	"portal.new_slot":           "Neue Uhrzeit",
+	"portal.confirm":            "Bestätigen",
+}
@@ -0,0 +1,666 @@
+package i18n
+
+// italian is the reference catalogue: every key the interface uses is here.
+var italian = map[string]string{
+	// Navigation
+	"nav.pharmacies":      "Farmacie",
+	"nav.orders":          "Ordini",
+	"nav.patients":        "Pazienti",
+	"nav.scan":            "Scansione",
+	"nav.shipping":        "Spedizioni",
+	"nav.pickups":         "Ritiri",
+	"nav.notifications":   "Notifiche",
+	"nav.personnel":       "Personale",
+	"nav.roles":           "Ruoli",
+	"nav.calendar":        "Calendario",
+	"nav.analytics":       "Statistiche",
+	"nav.branches":        "Sedi",
+	"nav.settings":        "Impostazioni",
+	"nav.change_password": "Cambia password",
+	"nav.account":         "Account",
+	"nav.logout":          "Esci",
+
+	// Shared
+	"common.email":          "Email",
+	"common.password":       "Password",
+	"common.back_to_login":  "Torna all'accesso",
+	"common.bad_request":    "Richiesta non valida.",
+	"common.internal_error": "Errore interno.",
+	"common.forbidden":      "Accesso negato.",
+	"common.cancel":         "Annulla",
+	"common.save":           "Salva",
+	"common.save_changes":   "Salva modifiche",
+	"common.date":           "Data",
+	"common.name":           "Nome",
+	"common.phone":          "Telefono",
+	"common.notes":          "Note",
+	"common.patient":        "Paziente",
+	"common.medication":     "Farmaco",
+	"common.status":         "Stato",
+	"common.all":            "Tutti",
+	"common.yes":            "Sì",
+	"common.no":             "No",
+
+	// Login and passwords
+	"login.title":                 "Login",
+	"login.heading":               "Accedi",
+	"login.submit":                "Accedi",
+	"login.email_placeholder":     "nome@farmacia.it",
+	"login.forgot_password":       "Password dimenticata?",
+	"login.session_expired":       "Sessione scaduta. Accedi di nuovo.",
+	"login.too_many_codes":        "Troppi codici errati. Accedi di nuovo.",
+	"login.sso_unavailable":       "Il provider di accesso aziendale non risponde. Riprova più tardi.",
+	"login.sso_cancelled":         "Accesso aziendale annullato.",
+	"password.change_title":       "Cambia password",
+	"password.must_change":        "La password è stata reimpostata. Scegline una nuova per continuare.",
+	"password.temporary":          "Password temporanea",
+	"password.current":            "Password attuale",
+	"password.new":                "Nuova password",
+	"password.current_wrong":      "Password attuale non corretta.",
+	"password.changed":            "Password aggiornata.",
+	"password.forgot_title":       "Password dimenticata",
+	"password.forgot_intro":       "Inserisci l'email del tuo account: ti invieremo un link per scegliere una nuova password.",
+	"password.reset_link_sent":    "Se l'email corrisponde a un account attivo, riceverai a breve un link per scegliere una nuova password. Il link vale un'ora.",
+	"password.send_link":          "Invia link",
+	"password.email_required":     "Inserisci l'email del tuo account.",
+	"password.reset_done":         "Password aggiornata. Accedi con la nuova password.",
+	"password.reset_link_invalid": "Il link non è valido o è scaduto. Richiedine uno nuovo.",
+	"password.new_required":       "Inserisci la nuova password.",
+	"password.save":               "Salva password",
+	"password.request_new_link":   "Richiedi un nuovo link",
+
+	// Two-factor authentication
+	"two_factor.verify_title":         "Verifica in due passaggi",
+	"two_factor.verify_intro":         "Inserisci il codice a 6 cifre dell'app di autenticazione, oppure uno dei codici di recupero.",
+	"two_factor.code":                 "Codice",
+	"two_factor.verify":               "Verifica",
+	"two_factor.current_code":         "Codice attuale",
+	"two_factor.title":                "Autenticazione a due fattori",
+	"two_factor.enabled_badge":        "attiva",
+	"two_factor.must_enrol":           "Il tuo account richiede l'autenticazione a due fattori. Configurala per continuare.",
+	"two_factor.recovery_codes":       "Codici di recupero",
+	"two_factor.recovery_codes_intro": "Conservali in un luogo sicuro: ognuno vale per un solo accesso se non hai il telefono. Non verranno mostrati di nuovo.",
+	"two_factor.recovery_codes_left":  "Codici di recupero rimasti:",
+	"two_factor.new_recovery_codes":   "Nuovi codici di recupero",
+	"two_factor.generate_codes":       "Genera nuovi codici",
+	"two_factor.disable":              "Disattiva",
+	"two_factor.disable_submit":       "Disattiva 2FA",
+	"two_factor.enrol_intro":          "Scansiona il codice QR con un'app di autenticazione (ad esempio Google Authenticator o Aegis), poi inserisci il codice a 6 cifre che mostra.",
+	"two_factor.manual_key":           "Chiave manuale:",
+	"two_factor.enable":               "Attiva",
+	"two_factor.enabled":              "Autenticazione a due fattori attivata.",
+	"two_factor.disabled":             "Autenticazione a due fattori disattivata.",
+	"two_factor.codes_regenerated":    "Nuovi codici di recupero generati. Quelli precedenti non valgono più.",
+
+	// Sessions and account
+	"sessions.title":              "Sessioni attive",
+	"sessions.intro":              "I dispositivi su cui hai effettuato l'accesso. Se non ne riconosci uno, terminalo e cambia la password.",
+	"sessions.device":             "Dispositivo",
+	"sessions.ip":                 "Indirizzo IP",
+	"sessions.signed_in":          "Accesso",
+	"sessions.last_seen":          "Ultima attività",
+	"sessions.current":            "questa sessione",
+	"sessions.revoke":             "Termina",
+	"sessions.revoke_others":      "Esci da tutte le altre sessioni",
+	"sessions.back_to_account":    "Torna al profilo",
+	"sessions.not_found":          "Sessione non trovata o già terminata.",
+	"sessions.revoked":            "Sessione terminata.",
+	"sessions.revoked_others":     "Sessioni terminate: %d.",
+	"account.title":               "Il mio account",
+	"account.language":            "Lingua",
+	"account.language_pharmacy":   "Lingua della farmacia",
+	"account.language_saved":      "Lingua aggiornata.",
+	"account.recent_logins":       "Accessi recenti",
+	"account.recent_logins_intro": "Se vedi un accesso che non riconosci, cambia subito la password e avvisa il titolare della farmacia.",
+	"account.no_logins":           "Nessun accesso registrato.",
+	"account.outcome":             "Esito",
+	"account.login_succeeded":     "riuscito",
+	"account.login_failed":        "non riuscito",
+
+	// Orders
+	"supply.ok":                      "ok",
+	"supply.approaching":             "in esaurimento",
+	"supply.depleted":                "esaurito",
+	"order_status.pending":           "In attesa",
+	"order_status.prepared":          "Preparato",
+	"order_status.fulfilled":         "Evaso",
+	"fulfillment.pickup":             "Ritiro",
+	"fulfillment.shipping":           "Spedizione",
+	"fulfillment.pickup_in_pharmacy": "Ritiro in farmacia",
+	"orders.title":                   "Dashboard Ordini",
+	"orders.mark_prepared":           "Segna preparato",
+	"orders.mark_fulfilled":          "Segna evaso",
+	"orders.filter_supply":           "Stato prescrizione",
+	"orders.filter_depleted":         "Esauriti",
+	"orders.filter_approaching":      "In esaurimento",
+	"orders.order_status":            "Stato ordine",
+	"orders.filter_active":           "Attivi",
+	"orders.prepare_from":            "Preparare dal",
+	"orders.prepare_to":              "al",
+	"orders.filter":                  "Filtra",
+	"orders.print":                   "Stampa ordini",
+	"orders.print_labels":            "Stampa etichette",
+	"orders.print_pdf":               "Ordini PDF",
+	"orders.labels_pdf":              "Etichette PDF",
+	"orders.empty":                   "Nessun ordine attivo. Aggiungi pazienti e prescrizioni per iniziare.",
+	"orders.depletion":               "Esaurimento",
+	"orders.prepare_by":              "Preparare entro",
+	"orders.days_left":               "Giorni rim.",
+	"orders.supply":                  "Stato presc.",
+	"orders.fulfillment":             "Consegna",
+	"orders.brought_forward":         "Anticipato per chiusura della farmacia",
+	"orders.pickup":                  "Ritiro",
+	"orders.label":                   "Etichetta",
+
+	// Days of the week, indexed like time.Weekday
+	"weekday.0":       "Domenica",
+	"weekday.1":       "Lunedì",
+	"weekday.2":       "Martedì",
+	"weekday.3":       "Mercoledì",
+	"weekday.4":       "Giovedì",
+	"weekday.5":       "Venerdì",
+	"weekday.6":       "Sabato",
+	"weekday_short.0": "Dom",
+	"weekday_short.1": "Lun",
+	"weekday_short.2": "Mar",
+	"weekday_short.3": "Mer",
+	"weekday_short.4": "Gio",
+	"weekday_short.5": "Ven",
+	"weekday_short.6": "Sab",
+
+	// Pickups
+	"pickup.confirmed":           "Confermato",
+	"pickup.suggested":           "Suggerito",
+	"pickup.notified":            "Avvisato",
+	"pickup.move":                "Sposta",
+	"pickup.day_title":           "Ritiri di %s",
+	"pickup.notify_failed":       "Ritiro fissato, ma non è stato possibile avvisare il paziente. Contattalo direttamente.",
+	"pickup.previous_day":        "Giorno prima",
+	"pickup.go":                  "Vai",
+	"pickup.next_day":            "Giorno dopo",
+	"pickup.hours_and_slots":     "Orari e fasce",
+	"pickup.closed":              "Farmacia chiusa in questo giorno.",
+	"pickup.booked":              "Ritiri previsti: %d su %d posti.",
+	"pickup.slot":                "Fascia",
+	"pickup.occupancy":           "Occupazione",
+	"pickup.outside_slots":       "Fuori dalle fasce",
+	"pickup.outside_slots_intro": "Ritiri fissati a orari che non corrispondono più a una fascia.",
+	"pickup.settings_title":      "Orari di ritiro",
+	"pickup.slots":               "Fasce",
+	"pickup.slot_minutes":        "Durata fascia (minuti)",
+	"pickup.slot_capacity":       "Pazienti per fascia",
+	"pickup.daily_capacity":      "Massimo giornaliero (0 = nessun limite)",
+	"pickup.opening_hours":       "Orari di apertura",
+	"pickup.opening_hours_intro": "Lascia vuoti gli orari dei giorni di chiusura.",
+	"pickup.day":                 "Giorno",
+	"pickup.morning":             "Mattina",
+	"pickup.afternoon":           "Pomeriggio",
+	"pickup.opens_at":            "%s apertura",
+	"pickup.closes_at":           "%s chiusura",
+	"pickup.assign_title":        "Fissa ritiro",
+	"pickup.expected_depletion":  "Esaurimento previsto: %s",
+	"pickup.confirmed_at":        "Ritiro fissato: %s",
+	"pickup.suggested_at":        "Ritiro suggerito: %s",
+	"pickup.no_free_slots":       "Nessuna fascia libera nei prossimi giorni. Controlla gli orari di ritiro.",
+	"pickup.slot_option":         "%s (%d liberi)",
+	"pickup.notify_patient":      "Avvisa il paziente",
+	"pickup.confirm":             "Conferma ritiro",
+	"pickup.choose_slot":         "Seleziona una fascia.",
+
+	// Calendar
+	"month.1":                     "gennaio",
+	"month.2":                     "febbraio",
+	"month.3":                     "marzo",
+	"month.4":                     "aprile",
+	"month.5":                     "maggio",
+	"month.6":                     "giugno",
+	"month.7":                     "luglio",
+	"month.8":                     "agosto",
+	"month.9":                     "settembre",
+	"month.10":                    "ottobre",
+	"month.11":                    "novembre",
+	"month.12":                    "dicembre",
+	"calendar.title":              "Calendario chiusure",
+	"calendar.intro":              "Gli ordini che si esauriscono in un giorno di chiusura vanno preparati entro l'ultimo giorno di apertura precedente.",
+	"calendar.recurring":          "Chiusure ricorrenti",
+	"calendar.weekly":             "Chiusura settimanale",
+	"calendar.national_holidays":  "Chiuso nelle festività nazionali",
+	"calendar.patron":             "Santo patrono",
+	"calendar.patron_placeholder": "Sant'Ambrogio",
+	"calendar.month":              "Mese",
+	"calendar.closures":           "Chiusure straordinarie",
+	"calendar.no_closures":        "Nessuna chiusura straordinaria.",
+	"calendar.period":             "Periodo",
+	"calendar.reason":             "Motivo",
+	"calendar.from":               "Dal",
+	"calendar.to":                 "Al",
+	"calendar.reason_placeholder": "Ferie estive",
+	"calendar.add_closure":        "Aggiungi chiusura",
+	"calendar.upcoming":           "Prossime chiusure",
+	"calendar.upcoming_intro":     "Festività e chiusure straordinarie dei prossimi dodici mesi, esclusa la chiusura settimanale.",
+	"calendar.no_upcoming":        "Nessuna chiusura prevista.",
+	"common.delete":               "Elimina",
+	"common.add":                  "Aggiungi",
+
+	// Scanning
+	"scan.title":             "Scansione etichette",
+	"scan.intro":             "Scansiona il codice a barre di un'etichetta per far avanzare l'ordine: da preparare a preparato, da preparato a evaso.",
+	"scan.code":              "Codice etichetta",
+	"scan.submit":            "Avanza ordine",
+	"scan.order_not_found":   "Ordine non trovato.",
+	"scan.already_fulfilled": "L'ordine di %s %s (%s) è già evaso.",
+	"scan.forbidden":         "Non hai il permesso di evadere gli ordini.",
+	"scan.advanced":          "%s %s — %s: %s.",
+
+	// Notifications
+	"notifications.mark_all_read": "Segna tutte come lette",
+	"notifications.empty":         "Nessuna notifica.",
+	"notifications.read":          "Letta",
+	"notifications.unread":        "Non letta",
+	"notifications.mark_read":     "Segna come letta",
+
+	// Patients
+	"patients.empty":             "Nessun paziente. Aggiungi il primo paziente.",
+	"patients.consensus":         "Consenso",
+	"patients.consensus_active":  "Attivo",
+	"patients.consensus_missing": "Da registrare",
+	"patients.new_title":         "Nuovo Paziente",
+	"patients.first_name":        "Nome",
+	"patients.last_name":         "Cognome",
+	"patients.fulfillment":       "Modalità di consegna",
+	"patients.create":            "Crea paziente",
+
+	// Delivery addresses
+	"address.title":                    "Indirizzo di consegna",
+	"address.legacy":                   "Indirizzo importato dal testo libero: «%s». Verifica i campi e salva per confermarlo.",
+	"address.street":                   "Via",
+	"address.house_number":             "Civico",
+	"address.cap":                      "CAP",
+	"address.city":                     "Comune",
+	"address.province":                 "Provincia",
+	"address.country":                  "Paese",
+	"address.notes":                    "Note per la consegna",
+	"address.notes_placeholder":        "citofono, piano, orari",
+	"common.edit":                      "Modifica",
+	"patients.consensus_missing_alert": "Consenso non registrato. Registra il consenso per attivare il paziente.",
+	"patients.record_consensus":        "Registra consenso",
+	"patients.consensus_active_badge":  "Consenso attivo",
+	"patients.back":                    "Torna ai pazienti",
+	"patients.transfer":                "Trasferisci ad altra sede",
+
+	// Prescriptions
+	"stock_source.portal":                   "area pazienti",
+	"stock_source.staff":                    "in farmacia",
+	"stock_source.reply":                    "risposta al promemoria",
+	"prescriptions.title":                   "Prescrizioni",
+	"prescriptions.add":                     "Aggiungi prescrizione",
+	"prescriptions.empty":                   "Nessuna prescrizione registrata.",
+	"prescriptions.units_per_box_short":     "Unità/conf.",
+	"prescriptions.daily_consumption_short": "Consumo/giorno",
+	"prescriptions.box_start_short":         "Inizio conf.",
+	"prescriptions.estimated_depletion":     "Esaurimento stimato",
+	"prescriptions.last_report":             "%d unità al %s (%s)",
+	"prescriptions.refill":                  "Rifornimento",
+	"prescriptions.units_left":              "Unità rimaste",
+	"prescriptions.record":                  "Registra",
+	"prescriptions.new_title":               "Nuova Prescrizione",
+	"prescriptions.edit_title":              "Modifica Prescrizione",
+	"prescriptions.medication_name":         "Nome farmaco",
+	"prescriptions.units_per_box":           "Unità per confezione",
+	"prescriptions.daily_consumption":       "Consumo giornaliero (unità/giorno)",
+	"prescriptions.box_start":               "Data inizio confezione",
+	"prescriptions.create":                  "Crea prescrizione",
+
+	// Shipping
+	"shipping_status.ready":     "Da spedire",
+	"shipping_status.shipped":   "Spedito",
+	"shipping_status.delivered": "Consegnato",
+	"batch_status.open":         "In preparazione",
+	"batch_status.shipped":      "Affidato al corriere",
+	"shipping.shippable":        "Ordini preparati da spedire",
+	"shipping.no_shippable":     "Nessun ordine preparato in attesa di spedizione.",
+	"shipping.waiting":          "%d ordini preparati in attesa del lotto.",
+	"shipping.address":          "Indirizzo",
+	"shipping.create_batch":     "Crea lotto di spedizione",
+	"shipping.batches":          "Lotti",
+	"shipping.no_batches":       "Nessun lotto di spedizione.",
+	"shipping.batch":            "Lotto",
+	"shipping.batch_title":      "Lotto #%d",
+	"shipping.created_on":       "Creato il",
+	"shipping.created_at":       "Creato il %s",
+	"shipping.shipped_at":       "affidato al corriere il %s",
+	"shipping.parcels":          "Colli",
+	"shipping.delivered":        "Consegnati",
+	"shipping.print_manifest":   "Stampa distinta",
+	"shipping.export_csv":       "Esporta CSV corriere",
+	"shipping.mark_shipped":     "Segna affidato al corriere",
+	"shipping.tracking":         "Tracking",
+	"shipping.mark_delivered":   "Segna consegnato",
+	"shipping.save_tracking":    "Salva tracking",
+
+	// Analytics
+	"analytics.one_day":               "1 giorno",
+	"analytics.days":                  "%s giorni",
+	"analytics.created":               "Creati",
+	"analytics.prepared":              "Preparati",
+	"analytics.fulfilled":             "Evasi",
+	"analytics.orders":                "Ordini",
+	"analytics.period":                "Ultime %d settimane, dal %s.",
+	"analytics.median_fulfillment":    "Tempo mediano da ordine a ritiro",
+	"analytics.no_fulfilled":          "Nessun ordine evaso nel periodo.",
+	"analytics.fulfilled_count":       "su %d ordini evasi",
+	"analytics.late_refills":          "Rifornimenti dopo l'esaurimento",
+	"analytics.no_refills":            "Nessun rifornimento nel periodo.",
+	"analytics.late_refills_count":    "%d su %d rifornimenti",
+	"analytics.forecast_total":        "Ordini previsti",
+	"analytics.next_days":             "nei prossimi %d giorni",
+	"analytics.weekly":                "Ordini per settimana",
+	"analytics.weekly_chart":          "Ordini creati, preparati ed evasi per settimana",
+	"analytics.forecast":              "Previsione ordini",
+	"analytics.forecast_intro":        "Ordini da preparare ogni giorno secondo l'esaurimento stimato delle prescrizioni attive e il calendario chiusure.",
+	"analytics.forecast_chart":        "Ordini da preparare per giorno",
+	"analytics.top_medications":       "Farmaci più richiesti",
+	"analytics.no_orders":             "Nessun ordine nel periodo.",
+	"analytics.top_medications_chart": "Farmaci con più ordini",
+	"common.back":                     "Indietro",
+
+	// Branches and transfers
+	"group.name":                 "Gruppo %s",
+	"group.none":                 "La farmacia non fa parte di un gruppo. L'amministratore può collegarla alle altre sedi.",
+	"group.active_patients":      "Pazienti attivi",
+	"group.prescriptions_count":  "%d prescrizioni",
+	"group.overdue_orders":       "Ordini in ritardo",
+	"group.branches_count":       "in %d sedi",
+	"group.unread_notifications": "Notifiche non lette",
+	"group.branch":               "Sede",
+	"group.active":               "attiva",
+	"group.switch":               "Passa a questa sede",
+	"transfer.title":             "Trasferisci paziente",
+	"transfer.heading":           "Trasferisci %s",
+	"transfer.intro":             "Prescrizioni, rifornimenti, segnalazioni di scorte e ordini passano alla nuova sede. Gli orari di ritiro già proposti vengono annullati.",
+	"transfer.no_targets":        "Nessuna altra sede disponibile.",
+	"transfer.target":            "Sede di destinazione",
+	"transfer.submit":            "Trasferisci",
+	"transfer.target_required":   "Seleziona la sede di destinazione.",
+
+	// Roles and permissions
+	"role_name.admin":                      "Amministratore",
+	"role_name.owner":                      "Titolare",
+	"role_name.pharmacist":                 "Farmacista responsabile",
+	"role_name.personnel":                  "Personale",
+	"role_name.trainee":                    "Tirocinante",
+	"role_name.driver":                     "Autista consegne",
+	"permission_name.patients.view":        "Vedere pazienti e prescrizioni",
+	"permission_name.patients.edit":        "Creare e modificare pazienti",
+	"permission_name.prescriptions.edit":   "Creare e modificare prescrizioni",
+	"permission_name.prescriptions.refill": "Registrare ricariche e scorte",
+	"permission_name.orders.view":          "Vedere ordini e notifiche",
+	"permission_name.orders.advance":       "Avanzare gli ordini",
+	"permission_name.pickups.assign":       "Fissare i ritiri",
+	"permission_name.shipping.view":        "Vedere le spedizioni",
+	"permission_name.shipping.manage":      "Preparare e spedire i lotti",
+	"permission_name.shipping.deliver":     "Segnare le consegne",
+	"permission_name.schedule.manage":      "Gestire orari e calendario",
+	"permission_name.analytics.view":       "Vedere le statistiche",
+	"permission_name.patients.transfer":    "Trasferire pazienti tra sedi",
+	"permission_name.personnel.manage":     "Gestire il personale",
+	"permission_name.roles.manage":         "Gestire i ruoli",
+	"permission_name.branches.switch":      "Passare alle altre sedi",
+	"permission_name.settings.manage":      "Gestire le impostazioni della farmacia",
+	"permission_name.pharmacies.manage":    "Gestire le farmacie",
+	"roles.new":                            "Nuovo ruolo",
+	"roles.role":                           "Ruolo",
+	"roles.custom":                         "Ruoli personalizzati",
+	"roles.no_custom":                      "Nessun ruolo personalizzato. Creane uno per dare al personale solo i permessi che servono.",
+	"roles.permissions":                    "Permessi",
+	"roles.holders":                        "Assegnato a",
+	"roles.builtin":                        "Ruoli predefiniti",
+	"roles.change_logs_out":                "Chi ha questo ruolo viene disconnesso se cambi i permessi, così li riceve al prossimo accesso.",
+	"roles.delete":                         "Elimina ruolo",
+
+	// Personnel
+	"personnel.empty":                 "Nessun personale.",
+	"personnel.role":                  "Ruolo",
+	"personnel.new_title":             "Nuovo Personale",
+	"personnel.create":                "Crea personale",
+	"personnel.deactivated":           "disattivato",
+	"personnel.temporary_password":    "password temporanea",
+	"personnel.locked":                "bloccato",
+	"personnel.self":                  "Questo è il tuo account. Per cambiare la password usa",
+	"personnel.access":                "Accesso",
+	"personnel.locked_until":          "Bloccato fino alle %s dopo troppi tentativi di accesso non riusciti.",
+	"personnel.unlock":                "Sblocca",
+	"personnel.deactivate_intro":      "Disattivando l'account l'utente viene disconnesso subito da tutti i dispositivi.",
+	"personnel.deactivate":            "Disattiva",
+	"personnel.deactivated_intro":     "L'account è disattivato e non può accedere.",
+	"personnel.reactivate":            "Riattiva",
+	"personnel.change_role":           "Cambia ruolo",
+	"personnel.reset_password":        "Reimposta password",
+	"personnel.reset":                 "Reimposta",
+	"personnel.reset_2fa_intro":       "Azzera il dispositivo se l'utente lo ha perso. Verrà disconnesso da tutti i dispositivi.",
+	"personnel.reset_2fa":             "Azzera 2FA",
+	"personnel.delete":                "Rimuovi definitivamente",
+	"personnel.fields_required":       "Tutti i campi sono obbligatori.",
+	"personnel.deactivated_done":      "Account disattivato.",
+	"personnel.reactivated_done":      "Account riattivato.",
+	"personnel.unlocked_done":         "Account sbloccato.",
+	"personnel.role_changed":          "Ruolo aggiornato.",
+	"personnel.password_reset_done":   "Password temporanea impostata. Verrà chiesto di cambiarla al prossimo accesso.",
+	"personnel.two_factor_reset_done": "Autenticazione a due fattori azzerata. Al prossimo accesso l'utente potrà configurare un nuovo dispositivo.",
+
+	// Administration
+	"admin.never":                 "mai",
+	"admin.new_pharmacy":          "Nuova farmacia",
+	"admin.new_pharmacy_title":    "Nuova Farmacia",
+	"admin.no_pharmacies":         "Nessuna farmacia registrata.",
+	"admin.inactive_count":        "%d inattive",
+	"admin.all_active":            "tutte attive",
+	"admin.unread_count":          "%d notifiche non lette",
+	"admin.last_login":            "Ultimo accesso",
+	"admin.inactive":              "inattiva",
+	"admin.details":               "Dettagli",
+	"admin.inactive_after":        "Una farmacia è inattiva se nessuno del personale accede da più di %d giorni.",
+	"admin.pharmacy":              "Farmacia",
+	"admin.create_pharmacy":       "Crea farmacia",
+	"admin.group":                 "Gruppo",
+	"admin.no_group":              "Nessun gruppo",
+	"admin.group_intro":           "Le farmacie con lo stesso gruppo sono sedi dello stesso titolare: i titolari possono passare da una all'altra e trasferire pazienti.",
+	"admin.require_2fa":           "Autenticazione a due fattori obbligatoria per il personale",
+	"admin.label_layout":          "Formato etichette (PDF)",
+	"admin.label_layout_a4":       "A4, 2×7 etichette",
+	"admin.label_layout_roll":     "Rotolo Brother 62 mm",
+	"admin.locale":                "Lingua predefinita",
+	"admin.locale_intro":          "La lingua dell'interfaccia per il personale che non ne ha scelta una.",
+	"admin.sso_intro":             "Il personale può accedere con l'identity provider aziendale della farmacia (OpenID Connect).",
+	"admin.configure_sso":         "Configura SSO",
+	"admin.sessions":              "Sessioni",
+	"admin.revoke_sessions_intro": "Disconnette tutto il personale della farmacia da ogni dispositivo, ad esempio dopo lo smarrimento di un computer. Dovranno accedere di nuovo.",
+	"admin.revoke_sessions":       "Disconnetti tutto il personale",
+	"admin.back":                  "Torna alle farmacie",
+	"admin.name_address_required": "Nome e indirizzo sono obbligatori.",
+	"admin.fields_required":       "Tutti i campi obbligatori devono essere compilati.",
+	"admin.owner_email_in_use":    "L'email del titolare è già in uso.",
+
+	// Single sign-on
+	"sso.title":                 "Accesso aziendale (SSO)",
+	"sso.title_short":           "Accesso aziendale",
+	"sso.enabled_badge":         "attivo",
+	"sso.intro":                 "Il personale della farmacia accede con l'identity provider aziendale (OpenID Connect). Al primo accesso l'account viene creato con il ruolo indicato dai claim.",
+	"sso.share":                 "Da comunicare",
+	"sso.redirect_uri":          "Redirect URI da registrare sul provider:",
+	"sso.login_link":            "Link di accesso per il personale:",
+	"sso.enabled":               "Accesso aziendale attivo",
+	"sso.keep_secret":           "Lascia vuoto per mantenere quello salvato",
+	"sso.role_claim":            "Claim del ruolo",
+	"sso.role_claim_intro":      "Claim dell'ID token con i gruppi o ruoli dell'utente, ad esempio groups o roles.",
+	"sso.owner_value":           "Valore per i titolari",
+	"sso.owner_value_intro":     "Chi ha questo valore diventa titolare. Vuoto: nessun titolare tramite SSO.",
+	"sso.personnel_value":       "Valore per il personale",
+	"sso.personnel_value_intro": "Chi ha questo valore diventa personale. Vuoto: chiunque si autentichi sul provider.",
+	"sso.back":                  "Torna alla farmacia",
+
+	// Pharmacy settings
+	"settings.title":                     "Impostazioni farmacia",
+	"settings.saved":                     "Impostazioni salvate.",
+	"settings.contacts":                  "Contatti",
+	"settings.vat_number":                "Partita IVA",
+	"settings.orders":                    "Ordini",
+	"settings.default_fulfillment":       "Consegna predefinita per i nuovi pazienti",
+	"settings.default_fulfillment_intro": "Viene proposta quando si aggiunge un paziente; si può sempre cambiare.",
+	"settings.lookahead_days":            "Giorni di anticipo degli ordini",
+	"settings.lookahead_intro":           "Quanti giorni prima dell'esaurimento del farmaco viene creato l'ordine. 0 usa l'impostazione del server.",
+	"settings.reminder":                  "Avviso al paziente",
+	"settings.pickup_reminder":           "Messaggio quando si fissa un ritiro",
+	"settings.reminder_fields":           "Puoi usare: %s",
+	"settings.opening_hours_intro":       "Gli orari di apertura e le fasce di ritiro si impostano in",
+	"settings.logo":                      "Logo sulle etichette",
+	"settings.logo_intro":                "Stampato sulle etichette degli ordini. PNG o JPEG, al massimo 2 MB.",
+	"settings.upload_logo":               "Nuovo logo",
+	"settings.upload":                    "Carica",
+	"settings.remove_logo":               "Rimuovi logo",
+
+	// Printing
+	"print.printed_at":   "Stampato il: %s",
+	"print.no_orders":    "Nessun ordine da stampare.",
+	"manifest.title":     "Distinta lotto #%d",
+	"manifest.heading":   "Distinta di spedizione #%d",
+	"manifest.ref":       "Rif.",
+	"manifest.recipient": "Destinatario",
+	"manifest.contents":  "Contenuto",
+	"manifest.signature": "Firma corriere:",
+
+	// Domain errors, keyed by their codes
+	"user.invalid_credentials":              "Credenziali non valide.",
+	"user.deactivated":                      "Account disattivato. Rivolgiti al titolare della farmacia.",
+	"user.account_locked":                   "Troppi tentativi non riusciti: account bloccato temporaneamente. Riprova più tardi o chiedi lo sblocco al titolare della farmacia.",
+	"user.too_many_attempts":                "Troppi tentativi non riusciti da questa rete. Riprova tra qualche minuto.",
+	"user.invalid_reset_token":              "Il link non è valido o è scaduto.",
+	"user.password_required":                "Inserisci una password.",
+	"user.invalid_second_factor":            "Codice non valido.",
+	"user.two_factor_required":              "L'autenticazione a due fattori è obbligatoria per il tuo account e non può essere disattivata.",
+	"user.email_taken":                      "Esiste già un account con questa email: accedi con email e password.",
+	"user.other_pharmacy":                   "Il tuo account appartiene a un'altra farmacia: usa il link di accesso di quella farmacia.",
+	"user.invalid_locale":                   "Lingua non supportata.",
+	"user.federated":                        "Questo account accede con il sistema di accesso aziendale della farmacia: usa il link di accesso della farmacia.",
+	"permission.forbidden":                  "Non hai il permesso per questa operazione.",
+	"permission.not_assignable":             "Uno dei permessi scelti non può essere dato a un ruolo personalizzato.",
+	"role.name_required":                    "Il nome del ruolo è obbligatorio.",
+	"role.name_too_long":                    "Il nome del ruolo è troppo lungo.",
+	"role.name_taken":                       "Esiste già un ruolo con questo nome.",
+	"role.no_permissions":                   "Scegli almeno un permesso.",
+	"role.in_use":                           "Il ruolo è ancora assegnato a qualcuno del personale: cambia prima il suo ruolo.",
+	"pharmacy.duplicate_email":              "L'email è già in uso.",
+	"pharmacy.invalid_label_layout":         "Formato etichette non valido.",
+	"pharmacy.invalid_locale":               "Lingua non supportata.",
+	"pharmacy.last_owner":                   "La farmacia deve avere almeno un titolare attivo.",
+	"pharmacy.self_change":                  "Non puoi modificare il tuo stesso account da qui.",
+	"pharmacy.federated_member":             "Questo membro accede con il sistema di accesso aziendale: la password si gestisce lì.",
+	"pharmacy.invalid_role":                 "Ruolo non valido.",
+	"pharmacy.address_required":             "L'indirizzo è obbligatorio.",
+	"pharmacy.invalid_email":                "Email non valida.",
+	"pharmacy.invalid_vat_number":           "Partita IVA non valida: servono 11 cifre con la cifra di controllo corretta.",
+	"pharmacy.invalid_fulfillment":          "Consegna predefinita non valida.",
+	"pharmacy.invalid_lookahead":            "I giorni di anticipo devono essere tra 0 e 60.",
+	"pharmacy.invalid_reminder":             "Il messaggio contiene un segnaposto sconosciuto o non chiuso.",
+	"pharmacy.reminder_too_long":            "Il messaggio è troppo lungo (massimo 459 caratteri).",
+	"pharmacy.invalid_logo":                 "Il logo deve essere un'immagine PNG o JPEG.",
+	"pharmacy.logo_too_large":               "Il logo è troppo grande.",
+	"personnel.temporary_password_required": "Inserisci una password temporanea.",
+	"patient.name_required":                 "Nome e cognome sono obbligatori.",
+	"patient.contact_required":              "È necessario almeno un contatto (telefono o email).",
+	"patient.delivery_address_required":     "L'indirizzo di consegna è obbligatorio per la spedizione.",
+	"patient.transfer_same_branch":          "Il paziente è già in questa sede.",
+	"patient.transfer_outside_group":        "La sede di destinazione non appartiene al gruppo.",
+	"patient.transfer_in_transit":           "Il paziente ha ordini in una spedizione non ancora consegnata.",
+	"address.street_required":               "Via e numero civico sono obbligatori.",
+	"address.city_required":                 "Il comune è obbligatorio.",
+	"address.invalid_cap":                   "Il CAP deve essere composto da 5 cifre.",
+	"address.cap_required":                  "Il codice postale è obbligatorio.",
+	"address.unknown_province":              "Seleziona una provincia valida.",
+	"address.cap_province_mismatch":         "Il CAP non appartiene alla provincia indicata.",
+	"address.invalid_country":               "Il paese deve essere un codice di 2 lettere (es. IT).",
+	"prescription.no_consensus":             "Il paziente deve dare il consenso prima di aggiungere prescrizioni.",
+	"prescription.medication_required":      "Il nome del farmaco è obbligatorio.",
+	"prescription.invalid_units_per_box":    "Le unità per confezione devono essere maggiori di zero.",
+	"prescription.invalid_consumption":      "Il consumo giornaliero deve essere maggiore di zero.",
+	"prescription.start_date_required":      "La data di inizio confezione è obbligatoria.",
+	"prescription.consumption_exceeds_box":  "Il consumo giornaliero deve essere inferiore alle unità per confezione.",
+	"prescription.invalid_stock_units":      "Il numero di unità rimaste non può essere negativo.",
+	"prescription.report_date_required":     "La data della segnalazione è obbligatoria.",
+	"prescription.invalid_stock_source":     "Origine della segnalazione non valida.",
+	"order.invalid_transition":              "Transizione di stato non valida.",
+	"order.invalid_reference":               "Codice non riconosciuto.",
+	"pickup.invalid_hours":                  "Ogni fascia oraria deve avere apertura e chiusura, con la chiusura dopo l'apertura.",
+	"pickup.overlapping_hours":              "Gli orari di mattina e pomeriggio dello stesso giorno si sovrappongono.",
+	"pickup.invalid_slot_length":            "La durata delle fasce deve essere tra 5 e 240 minuti.",
+	"pickup.invalid_capacity":               "Indica almeno 1 paziente per fascia e un massimo giornaliero non negativo.",
+	"pickup.not_pickup_order":               "L'ordine viene spedito a domicilio, non ritirato in farmacia.",
+	"pickup.order_not_prepared":             "L'ordine deve essere preparato prima di fissare il ritiro.",
+	"pickup.slot_unavailable":               "L'orario scelto non è più disponibile. Scegline un altro.",
+	"pickup.no_pickup_slot":                 "Non è ancora stato fissato un orario di ritiro.",
+	"pickup.order_collected":                "L'ordine è già stato ritirato.",
+	"pickup.not_later":                      "Il nuovo orario deve essere successivo a quello attuale.",
+	"shipping.no_shippable_orders":          "Seleziona almeno un ordine preparato da spedire.",
+	"shipping.batch_already_shipped":        "Il lotto è già stato affidato al corriere.",
+	"shipping.invalid_transition":           "Transizione di stato non valida.",
+	"shipping.tracking_too_long":            "Il numero di tracking è troppo lungo.",
+	"calendar.invalid_patron_date":          "Indica giorno e mese validi del santo patrono, oppure lascia vuoti tutti i campi.",
+	"calendar.patron_name_too_long":         "Il nome del santo patrono è troppo lungo.",
+	"calendar.always_closed":                "La farmacia deve essere aperta almeno un giorno a settimana.",
+	"calendar.invalid_weekday":              "Giorno della settimana non valido.",
+	"calendar.closure_date_missing":         "Indica le date di inizio e fine della chiusura.",
+	"calendar.invalid_closure":              "La data di fine deve essere uguale o successiva a quella di inizio.",
+	"calendar.closure_too_long":             "Una chiusura non può superare un anno.",
+	"calendar.reason_too_long":              "Il motivo della chiusura è troppo lungo.",
+	"sso.not_configured":                    "Accesso aziendale non disponibile per questa farmacia.",
+	"sso.invalid_issuer":                    "L'issuer deve essere un indirizzo https.",
+	"sso.client_id_required":                "Il client ID è obbligatorio.",
+	"sso.secret_required":                   "Il client secret è obbligatorio.",
+	"sso.role_claim_required":               "Indica il claim da cui leggere il ruolo.",
+	"sso.no_access":                         "Il tuo account aziendale non è abilitato a questa farmacia.",
+	"sso.email_missing":                     "Il provider di accesso aziendale non ha fornito un'email verificata.",
+	"sso.invalid_response":                  "Accesso aziendale non riuscito. Riprova.",
+	"portal.invalid_contact":                "Inserisci un indirizzo email o un numero di cellulare valido.",
+	"portal.invalid_link":                   "Il link non è valido o è scaduto.",
+	"portal.invalid_code":                   "Codice non valido o scaduto.",
+	"portal.confirmed":                      "Grazie, il ritiro è confermato.",
+	"portal.postponed":                      "Il ritiro è stato spostato.",
+	"portal.reported":                       "Grazie, la farmacia terrà conto delle unità che hai ancora.",
+	"portal.choose_time":                    "Scegli un orario.",
+	"portal.units_required":                 "Indica quante unità ti restano.",
+
+	// Patient portal pages
+	"portal.area":               "Area pazienti",
+	"portal.login_title":        "Accesso pazienti",
+	"portal.login_intro":        "Inserisci l'email o il cellulare che hai lasciato in farmacia. Ti invieremo un link o un codice di accesso.",
+	"portal.contact":            "Email o cellulare",
+	"portal.send":               "Invia",
+	"portal.check_mail":         "Controlla la posta",
+	"portal.link_sent":          "Se l'indirizzo è registrato presso una farmacia, riceverai a breve un link di accesso valido %d minuti.",
+	"portal.enter_code":         "Inserisci il codice",
+	"portal.code_sent":          "Se il numero è registrato presso una farmacia, riceverai a breve un SMS con un codice valido %d minuti.",
+	"portal.code":               "Codice",
+	"portal.sign_in":            "Accedi",
+	"portal.new_code":           "Richiedi un nuovo codice",
+	"portal.new_link":           "Richiedi un nuovo link",
+	"portal.enter":              "Entra nell'area pazienti",
+	"portal.expected_depletion": "Esaurimento previsto",
+	"portal.order":              "Ordine",
+	"portal.home_delivery":      "spedizione a domicilio",
+	"portal.pickup_confirmed":   "Ritiro fissato",
+	"portal.pickup_proposed":    "Ritiro proposto",
+	"portal.confirm_pickup":     "Confermo il ritiro",
+	"portal.postpone":           "Posticipa",
+	"portal.choose_slot":        "Scegli orario",
+	"portal.no_order":           "Nessun ordine in corso.",
+	"portal.stock_left":         "Ho ancora compresse",
+	"portal.last_report":        "Ultima segnalazione: %d unità il %s.",
+	"portal.units_left":         "Unità rimaste oggi",
+	"portal.home_title":         "Le tue terapie",
+	"portal.no_therapies":       "Nessuna terapia registrata.",
+	"portal.postpone_title":     "Sposta il ritiro",
+	"portal.current_pickup":     "Ritiro attuale",
+	"portal.no_slots":           "Nessun orario libero nei prossimi giorni. Contatta la farmacia.",
+	"portal.new_slot":           "Nuovo orario",
+	"portal.confirm":            "Conferma",
+}
@@ -0,0 +1,73 @@
+// Package logging configures the server's slog output and carries request
+// attributes in contexts. Records logged with a context, such as through
+// slog.ErrorContext(r.Context(), ...), include the attributes added to it
+// with With and the IDs of its trace span, so an error can be matched to the
+// request, user and trace it belongs to.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New returns a logger writing to w in format, text or JSON, that adds the
+// attributes of the context records are logged with.
+func New(w io.Writer, format string) *slog.Logger {
+	var h slog.Handler
+	if format == FormatJSON {
+		h = slog.NewJSONHandler(w, nil)
+	} else {
+		h = slog.NewTextHandler(w, nil)
+	}
+	return slog.New(NewHandler(h))
+}
+
+type attrsKey struct{}
+
+// With returns a copy of ctx whose records also carry attrs.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, attrsKey{}, append(slices.Clip(Attrs(ctx)), attrs...))
+}
+
+// Attrs returns the attributes added to ctx with With.
+func Attrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// handler adds the context's attributes to every record.
+type handler struct {
+	slog.Handler
+}
+
+// NewHandler wraps h to add the attributes of the context and the IDs of
+// its trace span to every record.
+func NewHandler(h slog.Handler) slog.Handler {
+	return handler{h}
+}
+
+func (h handler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(Attrs(ctx)...)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h handler) WithGroup(name string) slog.Handler {
+	return handler{h.Handler.WithGroup(name)}
+}
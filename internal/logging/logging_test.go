@@ -0,0 +1,56 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/logging"
+)
+
+func TestContextAttributesAreLogged(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.FormatJSON)
+
+	ctx := logging.With(context.Background(), slog.String("request_id", "abc"))
+	ctx = logging.With(ctx, slog.Int64("user_id", 7))
+	logger.ErrorContext(ctx, "listing patients", "error", "boom")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	if rec["msg"] != "listing patients" || rec["request_id"] != "abc" || rec["user_id"] != float64(7) {
+		t.Errorf("record = %v", rec)
+	}
+}
+
+func TestWithDoesNotLeakIntoParent(t *testing.T) {
+	parent := logging.With(context.Background(), slog.String("a", "1"))
+	logging.With(parent, slog.String("b", "2"))
+	child := logging.With(parent, slog.String("c", "3"))
+
+	if got := logging.Attrs(child); len(got) != 2 || got[1].Key != "c" {
+		t.Errorf("child attrs = %v, want a and c", got)
+	}
+}
+
+func TestTraceIDsAreLogged(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, logging.FormatText)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	})
+	logger.InfoContext(trace.ContextWithSpanContext(context.Background(), sc), "request")
+
+	if out := buf.String(); !strings.Contains(out, "trace_id=0102030405060708090a0b0c0d0e0f10") || !strings.Contains(out, "span_id=0102030405060708") {
+		t.Errorf("output = %s", out)
+	}
+}
@@ -0,0 +1,115 @@
+// Package mail sends plain-text email through an SMTP server, upgrading the
+// connection with STARTTLS when the server offers it.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrAuthWithoutTLS is returned when credentials would be sent over a
+// connection the server could not encrypt.
+var ErrAuthWithoutTLS = errors.New("mail.auth_without_tls")
+
+// SMTP sends email through the server at Host:Port. Username and Password,
+// when set, authenticate with PLAIN, which is only done over TLS.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers a plain-text message to the single address to. It gives up
+// when ctx is done.
+func (s SMTP) Send(ctx context.Context, to, subject, body string) error {
+	from, err := netmail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("parsing sender %q: %w", s.From, err)
+	}
+	rcpt, err := netmail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("parsing recipient: %w", err)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(s.Host, strconv.Itoa(s.Port)))
+	if err != nil {
+		return fmt.Errorf("connecting to mail server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		return fmt.Errorf("greeting mail server: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if _, tlsOn := c.TLSConnectionState(); !tlsOn {
+			return ErrAuthWithoutTLS
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("sending MAIL FROM: %w", err)
+	}
+	if err := c.Rcpt(rcpt.Address); err != nil {
+		return fmt.Errorf("sending RCPT TO: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("starting message: %w", err)
+	}
+	if _, err := w.Write(message(from, rcpt, subject, body, time.Now())); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("ending message: %w", err)
+	}
+	return c.Quit()
+}
+
+// message formats a UTF-8 plain-text message with CRLF line endings.
+func message(from, to *netmail.Address, subject, body string, now time.Time) []byte {
+	var b bytes.Buffer
+	header := func(name, value string) {
+		b.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
@@ -0,0 +1,103 @@
+package mail_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/mail"
+)
+
+// fakeServer speaks just enough SMTP to accept one message, without
+// STARTTLS, and returns the session as the server saw it.
+func fakeServer(t *testing.T) (port int, session <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	done := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- ""
+			return
+		}
+		defer conn.Close()
+		var seen strings.Builder
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 fake ESMTP")
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			seen.WriteString(line)
+			switch {
+			case inData && line == ".\r\n":
+				inData = false
+				reply("250 queued")
+			case inData:
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 fake")
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				reply("354 go ahead")
+			case strings.HasPrefix(line, "QUIT"):
+				reply("221 bye")
+				done <- seen.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+		done <- seen.String()
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, done
+}
+
+func TestSend(t *testing.T) {
+	port, session := fakeServer(t)
+	s := mail.SMTP{Host: "127.0.0.1", Port: port, From: "PharmaRecall <noreply@farmacia.example.it>"}
+
+	err := s.Send(context.Background(), "mario.rossi@example.it", "Reimpostazione password", "Ciao Mario,\napri il link.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := <-session
+	for _, want := range []string{
+		"MAIL FROM:<noreply@farmacia.example.it>",
+		"RCPT TO:<mario.rossi@example.it>",
+		"Subject: Reimpostazione password\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"Ciao Mario,\r\napri il link.\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("session missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSendRefusesAuthWithoutTLS(t *testing.T) {
+	port, _ := fakeServer(t)
+	s := mail.SMTP{Host: "127.0.0.1", Port: port, Username: "u", Password: "p", From: "noreply@farmacia.example.it"}
+
+	err := s.Send(context.Background(), "mario.rossi@example.it", "s", "b")
+	if !errors.Is(err, mail.ErrAuthWithoutTLS) {
+		t.Errorf("err = %v, want ErrAuthWithoutTLS", err)
+	}
+}
+
+func TestSendRejectsBadRecipient(t *testing.T) {
+	s := mail.SMTP{Host: "127.0.0.1", Port: 1, From: "noreply@farmacia.example.it"}
+	if err := s.Send(context.Background(), "not an address", "s", "b"); err == nil || !strings.Contains(err.Error(), "recipient") {
+		t.Errorf("err = %v, want a recipient error", err)
+	}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RequestDuration is the latency of HTTP requests per route pattern, such
+// as "GET /patients/{id}", and status code.
+var RequestDuration = NewHistogram("pharmarecall_http_request_duration_seconds",
+	"Latency of HTTP requests by route and status code.", DefaultBuckets, "route", "code")
+
+// unmatched labels requests no route pattern matched.
+const unmatched = "unmatched"
+
+type routeKey struct{}
+
+// Instrument times every request and records it in RequestDuration under
+// the pattern Route found for it. It goes outermost, so the latency covers
+// sessions and the rest of the middleware.
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := new(string)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), routeKey{}, route)))
+
+		if *route == "" {
+			*route = unmatched
+		}
+		RequestDuration.Observe(time.Since(start).Seconds(), *route, strconv.Itoa(rec.status))
+	})
+}
+
+// Route wraps a ServeMux to tell Instrument which pattern served the
+// request. Middleware between the two copies the request, so the pattern
+// the mux sets is passed back through the context. With nested muxes the
+// innermost, most specific pattern wins.
+func Route(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if route, ok := r.Context().Value(routeKey{}).(*string); ok && *route == "" && r.Pattern != "" {
+			*route = r.Pattern
+		}
+	})
+}
+
+// RouteOf returns the pattern Route found for the request of ctx, once the
+// request has been served; "" before, or outside Instrument.
+func RouteOf(ctx context.Context) string {
+	if route, ok := ctx.Value(routeKey{}).(*string); ok {
+		return *route
+	}
+	return ""
+}
+
+// Handler serves the registry in the text exposition format.
+func Handler(reg *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		reg.WriteTo(w)
+	})
+}
+
+// statusRecorder remembers the status code written.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the original writer.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,41 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/metrics"
+)
+
+func TestInstrumentRecordsInnermostRoute(t *testing.T) {
+	inner := http.NewServeMux()
+	inner.HandleFunc("GET /patients/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	outer := http.NewServeMux()
+	// A middleware between the muxes copies the request, as sessions do.
+	outer.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics.Route(inner).ServeHTTP(w, r.WithContext(r.Context()))
+	}))
+	h := metrics.Instrument(metrics.Route(outer))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/patients/42", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/patients/42", nil))
+
+	rec := httptest.NewRecorder()
+	metrics.Handler(metrics.Default).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`pharmarecall_http_request_duration_seconds_count{route="GET /patients/{id}",code="404"} 1`,
+		`pharmarecall_http_request_duration_seconds_count{route="/",code="405"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %s in:\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("content-type = %q", ct)
+	}
+}
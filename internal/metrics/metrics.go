@@ -0,0 +1,296 @@
+// Package metrics keeps the server's counters, histograms and gauges and
+// writes them in the Prometheus text exposition format.
+//
+// Packages declare what they measure as package variables registered in
+// Default, the registry served on /metrics:
+//
+//	var ordersCreated = metrics.NewCounter("pharmarecall_orders_created_total", "Orders created.")
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default is the registry of the running server.
+var Default = NewRegistry()
+
+// collector writes one metric family.
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds metric families in registration order.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry returns an empty registry. Tests use their own; the server
+// uses Default.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds c, panicking on a duplicate name as that is a programming
+// error caught at startup.
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.collectors {
+		if other.name() == c.name() {
+			panic("metrics: duplicate metric " + c.name())
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteTo writes every metric in the text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	for _, c := range collectors {
+		c.write(cw)
+	}
+	return cw.n, cw.err
+}
+
+// Counter is a monotonically increasing value per combination of labels.
+type Counter struct {
+	family
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter registers a counter in Default.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return Default.NewCounter(name, help, labels...)
+}
+
+// NewCounter registers a counter named name, whose series are told apart by
+// labels.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{family: family{n: name, help: help, kind: "counter", labels: labels}, values: map[string]float64{}}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series of labelValues, given in the order of the
+// counter's labels.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series of labelValues.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+// Value returns the current value of the series of labelValues.
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.n, key, formatFloat(c.values[key]))
+	}
+}
+
+// DefaultBuckets suit HTTP latencies, in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations into cumulative buckets per combination of
+// labels.
+type Histogram struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram registers a histogram in Default.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return Default.NewHistogram(name, help, buckets, labels...)
+}
+
+// NewHistogram registers a histogram with the given upper bounds, in
+// increasing order.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		family:  family{n: name, help: help, kind: "histogram", labels: labels},
+		buckets: buckets,
+		series:  map[string]*histogramSeries{},
+	}
+	r.register(h)
+	return h
+}
+
+// Observe records v in the series of labelValues.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, le := range h.buckets {
+		if v <= le {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		for i, le := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.n, withLabel(key, "le", formatFloat(le)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.n, withLabel(key, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.n, key, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.n, key, s.count)
+	}
+}
+
+// valueFunc reports a value read when metrics are scraped.
+type valueFunc struct {
+	family
+	f func() float64
+}
+
+// NewGaugeFunc registers in r a gauge whose value f returns at each scrape.
+func (r *Registry) NewGaugeFunc(name, help string, f func() float64) {
+	r.register(&valueFunc{family: family{n: name, help: help, kind: "gauge"}, f: f})
+}
+
+// NewCounterFunc registers in r a counter kept elsewhere, such as by the
+// connection pool, whose value f returns at each scrape.
+func (r *Registry) NewCounterFunc(name, help string, f func() float64) {
+	r.register(&valueFunc{family: family{n: name, help: help, kind: "counter"}, f: f})
+}
+
+func (v *valueFunc) write(w io.Writer) {
+	v.header(w)
+	fmt.Fprintf(w, "%s %s\n", v.n, formatFloat(v.f()))
+}
+
+// family is what every metric has: a name, its help, its type and the names
+// of its labels.
+type family struct {
+	n      string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (f *family) name() string { return f.n }
+
+func (f *family) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.n, escapeHelp(f.help), f.n, f.kind)
+}
+
+// key renders label values as the series' label set, such as
+// {method="GET",code="200"}, which doubles as its map key.
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", f.n, len(f.labels), len(values)))
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range f.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l + `="` + escapeLabel(values[i]) + `"`)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// withLabel appends one label to a rendered label set.
+func withLabel(key, name, value string) string {
+	label := name + `="` + value + `"`
+	if key == "" {
+		return "{" + label + "}"
+	}
+	return key[:len(key)-1] + "," + label + "}"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+// countingWriter remembers the bytes written and the first error.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,78 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/metrics"
+)
+
+func scrape(t *testing.T, reg *metrics.Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := reg.WriteTo(&b); err != nil {
+		t.Fatalf("writing metrics: %v", err)
+	}
+	return b.String()
+}
+
+func TestCounterWritesSortedSeries(t *testing.T) {
+	reg := metrics.NewRegistry()
+	c := reg.NewCounter("logins_total", "Refused logins.", "reason")
+	c.Inc("locked")
+	c.Add(2, `bad "password"`)
+
+	want := `# HELP logins_total Refused logins.
+# TYPE logins_total counter
+logins_total{reason="bad \"password\""} 2
+logins_total{reason="locked"} 1
+`
+	if got := scrape(t, reg); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if v := c.Value("locked"); v != 1 {
+		t.Errorf("Value = %v, want 1", v)
+	}
+}
+
+func TestHistogramBucketsAreCumulative(t *testing.T) {
+	reg := metrics.NewRegistry()
+	h := reg.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 3.55
+latency_seconds_count 3
+`
+	if got := scrape(t, reg); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGaugeFuncIsReadAtScrape(t *testing.T) {
+	reg := metrics.NewRegistry()
+	n := 1.0
+	reg.NewGaugeFunc("conns", "Open connections.", func() float64 { return n })
+	n = 4
+
+	if got := scrape(t, reg); !strings.Contains(got, "# TYPE conns gauge\nconns 4\n") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestDuplicateNamePanics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	reg.NewCounter("x_total", "X.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering x_total twice should panic")
+		}
+	}()
+	reg.NewCounter("x_total", "X again.")
+}
@@ -8,7 +8,7 @@ import (
 )
 
 var (
-	ErrNotFound = errors.New("notification not found")
+	ErrNotFound = errors.New("notification.not_found")
 )
 
 // Transition type constants.
@@ -31,9 +31,12 @@ type Notification struct {
 	PatientID        int64
 	FirstName        string
 	LastName         string
+	ReportedUnits    int       // latest stock report, meaningful when ReportedOn is set
+	ReportedOn       time.Time // zero when no stock was reported
 }
 
-// EstimatedDepletionDate calculates when the prescription's current box runs out.
+// EstimatedDepletionDate calculates when the prescription's current box runs out,
+// re-anchored on the latest stock report.
 func (n Notification) EstimatedDepletionDate() time.Time {
-	return depletion.EstimatedDate(n.UnitsPerBox, n.DailyConsumption, n.BoxStartDate)
+	return depletion.EstimatedDateFromReport(n.UnitsPerBox, n.DailyConsumption, n.BoxStartDate, n.ReportedUnits, n.ReportedOn)
 }
@@ -23,22 +23,23 @@ func NewPgxRepository(pool *pgxpool.Pool, queries *db.Queries) *PgxRepository {
 	return &PgxRepository{pool: pool, queries: queries}
 }
 
-func (r *PgxRepository) Create(ctx context.Context, pharmacyID, prescriptionID int64, transitionType string) error {
+func (r *PgxRepository) Create(ctx context.Context, pharmacyID, prescriptionID int64, transitionType string) (bool, error) {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("beginning transaction: %w", err)
+		return false, fmt.Errorf("beginning transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	if err := r.queries.WithTx(tx).CreateNotification(ctx, db.CreateNotificationParams{
+	n, err := r.queries.WithTx(tx).CreateNotification(ctx, db.CreateNotificationParams{
 		PharmacyID:     pharmacyID,
 		PrescriptionID: prescriptionID,
 		TransitionType: transitionType,
-	}); err != nil {
-		return fmt.Errorf("creating notification: %w", err)
+	})
+	if err != nil {
+		return false, fmt.Errorf("creating notification: %w", err)
 	}
 
-	return tx.Commit(ctx)
+	return n > 0, tx.Commit(ctx)
 }
 
 func (r *PgxRepository) ListByPharmacy(ctx context.Context, pharmacyID int64) ([]Notification, error) {
@@ -62,6 +63,8 @@ func (r *PgxRepository) ListByPharmacy(ctx context.Context, pharmacyID int64) ([
 			PatientID:        row.PatientID,
 			FirstName:        row.FirstName,
 			LastName:         row.LastName,
+			ReportedUnits:    int(row.ReportedUnits),
+			ReportedOn:       row.ReportedOn.Time,
 		}
 	}
 	return result, nil
@@ -2,9 +2,10 @@ package notification
 
 import "context"
 
-// NotificationCreator creates a notification (ON CONFLICT DO NOTHING).
+// NotificationCreator creates a notification (ON CONFLICT DO NOTHING),
+// reporting whether it is new.
 type NotificationCreator interface {
-	Create(ctx context.Context, pharmacyID, prescriptionID int64, transitionType string) error
+	Create(ctx context.Context, pharmacyID, prescriptionID int64, transitionType string) (bool, error)
 }
 
 // NotificationLister lists notifications for a pharmacy.
@@ -3,8 +3,12 @@ package notification
 import (
 	"context"
 	"fmt"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/metrics"
 )
 
+var generated = metrics.NewCounter("pharmarecall_notifications_generated_total", "Notifications generated, by transition.", "type")
+
 // ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
 type ServiceDeps struct {
 	Creator   NotificationCreator
@@ -39,9 +43,13 @@ func NewServiceWith(d ServiceDeps) *Service {
 // Uses ON CONFLICT DO NOTHING at the DB level for idempotency.
 func (s *Service) GenerateApproaching(ctx context.Context, pharmacyID int64, prescriptionIDs []int64) error {
 	for _, rxID := range prescriptionIDs {
-		if err := s.deps.Creator.Create(ctx, pharmacyID, rxID, TransitionApproaching); err != nil {
+		created, err := s.deps.Creator.Create(ctx, pharmacyID, rxID, TransitionApproaching)
+		if err != nil {
 			return fmt.Errorf("creating notification for prescription %d: %w", rxID, err)
 		}
+		if created {
+			generated.Inc(TransitionApproaching)
+		}
 	}
 	return nil
 }
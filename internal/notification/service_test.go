@@ -22,10 +22,10 @@ type mockCreateCall struct {
 	transitionType string
 }
 
-func (m *mockCreator) Create(_ context.Context, pharmacyID, prescriptionID int64, transitionType string) error {
+func (m *mockCreator) Create(_ context.Context, pharmacyID, prescriptionID int64, transitionType string) (bool, error) {
 	m.called = true
 	m.params = append(m.params, mockCreateCall{pharmacyID, prescriptionID, transitionType})
-	return m.err
+	return m.err == nil, m.err
 }
 
 type mockLister struct {
@@ -4,12 +4,14 @@ import (
 	"errors"
 	"time"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
 	"github.com/giorgiovilardo/pharmarecall/internal/depletion"
 )
 
 var (
-	ErrNotFound          = errors.New("order not found")
-	ErrInvalidTransition = errors.New("transizione di stato non valida")
+	ErrNotFound          = errors.New("order.not_found")
+	ErrInvalidTransition = errors.New("order.invalid_transition")
+	ErrInvalidReference  = errors.New("order.invalid_reference")
 )
 
 // Order status constants.
@@ -42,11 +44,15 @@ type PrescriptionSummary struct {
 	UnitsPerBox      int
 	DailyConsumption float64
 	BoxStartDate     time.Time
+	Fulfillment      string
+	ReportedUnits    int       // latest stock report, meaningful when ReportedOn is set
+	ReportedOn       time.Time // zero when no stock was reported
 }
 
-// EstimatedDepletionDate calculates when this prescription's current box runs out.
+// EstimatedDepletionDate calculates when this prescription's current box runs out,
+// re-anchored on the latest stock report.
 func (p PrescriptionSummary) EstimatedDepletionDate() time.Time {
-	return depletion.EstimatedDate(p.UnitsPerBox, p.DailyConsumption, p.BoxStartDate)
+	return depletion.EstimatedDateFromReport(p.UnitsPerBox, p.DailyConsumption, p.BoxStartDate, p.ReportedUnits, p.ReportedOn)
 }
 
 // DaysRemaining returns the number of days until depletion relative to now.
@@ -69,9 +75,14 @@ type DashboardEntry struct {
 	FirstName              string
 	LastName               string
 	Fulfillment            string
-	DeliveryAddress        string
+	DeliveryAddress        address.Address
 	Phone                  string
 	Email                  string
+	ShippingStatus         string // empty until the order is put in a shipping batch
+	TrackingNumber         string
+	PickupAt               *time.Time // nil until a pickup slot is suggested or assigned
+	PickupConfirmed        bool
+	PrepareBy              time.Time // last open day on or before depletion; set by Service.ListDashboard
 }
 
 // DaysRemaining returns the number of days until estimated depletion.
@@ -79,11 +90,27 @@ func (e DashboardEntry) DaysRemaining(now time.Time) int {
 	return depletion.DaysRemaining(e.EstimatedDepletionDate, now)
 }
 
+// DaysToPrepare returns the number of days until the order must be ready.
+func (e DashboardEntry) DaysToPrepare(now time.Time) int {
+	return depletion.DaysRemaining(e.PrepareBy, now)
+}
+
 // PrescriptionStatus classifies the entry: "ok" (>7), "approaching" (<=7), "depleted" (<=0).
 func (e DashboardEntry) PrescriptionStatus(now time.Time) string {
 	return depletion.Status(e.DaysRemaining(now))
 }
 
+// PrepareStatus classifies the entry like PrescriptionStatus, but by the
+// prepare-by date: an order whose supply runs out after a closure is due as
+// soon as the pharmacy's last open day before it is. Entries without a
+// prepare-by date fall back to PrescriptionStatus.
+func (e DashboardEntry) PrepareStatus(now time.Time) string {
+	if e.PrepareBy.IsZero() {
+		return e.PrescriptionStatus(now)
+	}
+	return depletion.Status(e.DaysToPrepare(now))
+}
+
 // NextStatus returns the next valid status in the lifecycle, or empty if terminal.
 func NextStatus(current string) string {
 	switch current {
@@ -76,6 +76,24 @@ func TestDashboardEntryPrescriptionStatus(t *testing.T) {
 	}
 }
 
+func TestDashboardEntryPrepareStatus(t *testing.T) {
+	now := date(2026, 8, 7)
+	// Runs out on Sunday 16 August; the pharmacy closes on the 15th too, so
+	// the order is due by Friday 14.
+	e := order.DashboardEntry{EstimatedDepletionDate: date(2026, 8, 16), PrepareBy: date(2026, 8, 14)}
+	if got := e.PrescriptionStatus(now); got != "ok" {
+		t.Errorf("PrescriptionStatus() = %q, want ok", got)
+	}
+	if got := e.PrepareStatus(now); got != "approaching" {
+		t.Errorf("PrepareStatus() = %q, want approaching", got)
+	}
+
+	e.PrepareBy = time.Time{}
+	if got := e.PrepareStatus(now); got != "ok" {
+		t.Errorf("PrepareStatus() without prepare-by = %q, want ok", got)
+	}
+}
+
 func TestNextStatus(t *testing.T) {
 	tests := []struct {
 		current string
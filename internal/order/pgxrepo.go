@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
 	"github.com/giorgiovilardo/pharmarecall/internal/db"
 	"github.com/giorgiovilardo/pharmarecall/internal/dbutil"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -85,9 +88,21 @@ func (r *PgxRepository) ListDashboard(ctx context.Context, pharmacyID int64) ([]
 			FirstName:              row.FirstName,
 			LastName:               row.LastName,
 			Fulfillment:            row.Fulfillment,
-			DeliveryAddress:        row.DeliveryAddress,
-			Phone:                  row.Phone,
-			Email:                  row.Email,
+			DeliveryAddress: address.WithLegacy(address.Address{
+				Street:      row.DeliveryStreet,
+				HouseNumber: row.DeliveryHouseNumber,
+				CAP:         row.DeliveryCap,
+				City:        row.DeliveryCity,
+				Province:    row.DeliveryProvince,
+				Country:     row.DeliveryCountry,
+				Notes:       row.DeliveryNotes,
+			}, row.DeliveryAddressLegacy),
+			Phone:           row.Phone,
+			Email:           row.Email,
+			ShippingStatus:  row.ShippingStatus,
+			TrackingNumber:  row.TrackingNumber,
+			PickupAt:        timePtr(row.PickupAt),
+			PickupConfirmed: row.PickupConfirmed,
 		}
 	}
 	return result, nil
@@ -128,17 +143,68 @@ func (r *PgxRepository) ListPrescriptionsForPharmacy(ctx context.Context, pharma
 	}
 	result := make([]PrescriptionSummary, len(rows))
 	for i, row := range rows {
-		result[i] = PrescriptionSummary{
-			ID:               row.PrescriptionID,
-			PatientID:        row.PatientID,
-			UnitsPerBox:      int(row.UnitsPerBox),
-			DailyConsumption: dbutil.NumericToFloat64(row.DailyConsumption),
-			BoxStartDate:     row.BoxStartDate.Time,
+		result[i] = mapPrescriptionSummary(db.GetPrescriptionSummaryRow(row))
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) GetPrescriptionSummary(ctx context.Context, pharmacyID, prescriptionID int64) (PrescriptionSummary, error) {
+	row, err := r.queries.GetPrescriptionSummary(ctx, db.GetPrescriptionSummaryParams{
+		PrescriptionID: prescriptionID,
+		PharmacyID:     pharmacyID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PrescriptionSummary{}, prescription.ErrNotFound
 		}
+		return PrescriptionSummary{}, fmt.Errorf("querying prescription summary: %w", err)
+	}
+	return mapPrescriptionSummary(row), nil
+}
+
+func (r *PgxRepository) ListPendingOrders(ctx context.Context, prescriptionID int64) ([]Order, error) {
+	rows, err := r.queries.ListPendingOrdersByPrescription(ctx, prescriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("listing pending orders: %w", err)
+	}
+	result := make([]Order, len(rows))
+	for i, row := range rows {
+		result[i] = mapOrder(row)
 	}
 	return result, nil
 }
 
+func (r *PgxRepository) Reschedule(ctx context.Context, id int64, depletion time.Time) error {
+	if err := r.queries.UpdateOrderDepletionDate(ctx, db.UpdateOrderDepletionDateParams{
+		ID:                     id,
+		EstimatedDepletionDate: dbutil.TimeToDate(depletion),
+	}); err != nil {
+		return fmt.Errorf("updating order depletion date: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) Withdraw(ctx context.Context, id int64) (bool, error) {
+	n, err := r.queries.DeletePendingOrder(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("deleting pending order: %w", err)
+	}
+	return n > 0, nil
+}
+
+func mapPrescriptionSummary(row db.GetPrescriptionSummaryRow) PrescriptionSummary {
+	return PrescriptionSummary{
+		ID:               row.PrescriptionID,
+		PatientID:        row.PatientID,
+		UnitsPerBox:      int(row.UnitsPerBox),
+		DailyConsumption: dbutil.NumericToFloat64(row.DailyConsumption),
+		BoxStartDate:     row.BoxStartDate.Time,
+		Fulfillment:      row.Fulfillment,
+		ReportedUnits:    int(row.ReportedUnits),
+		ReportedOn:       row.ReportedOn.Time,
+	}
+}
+
 func mapOrder(row db.Order) Order {
 	return Order{
 		ID:                     row.ID,
@@ -148,3 +214,17 @@ func mapOrder(row db.Order) Order {
 		Status:                 row.Status,
 	}
 }
+
+func timestampOf(t *time.Time) pgtype.Timestamp {
+	if t == nil {
+		return pgtype.Timestamp{}
+	}
+	return dbutil.TimeToTimestamp(*t)
+}
+
+func timePtr(ts pgtype.Timestamp) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
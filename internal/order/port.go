@@ -3,6 +3,9 @@ package order
 import (
 	"context"
 	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
 )
 
 // OrderCreator creates a new order in a transaction.
@@ -35,11 +38,55 @@ type PrescriptionLookaheadLister interface {
 	ListPrescriptionsForPharmacy(ctx context.Context, pharmacyID int64) ([]PrescriptionSummary, error)
 }
 
+// PrescriptionSummaryGetter gets one prescription of a pharmacy for order generation.
+type PrescriptionSummaryGetter interface {
+	GetPrescriptionSummary(ctx context.Context, pharmacyID, prescriptionID int64) (PrescriptionSummary, error)
+}
+
+// PendingOrderLister lists the pending orders of a prescription.
+type PendingOrderLister interface {
+	ListPendingOrders(ctx context.Context, prescriptionID int64) ([]Order, error)
+}
+
+// OrderRescheduler moves a pending order to a new depletion date.
+type OrderRescheduler interface {
+	Reschedule(ctx context.Context, id int64, depletion time.Time) error
+}
+
+// OrderWithdrawer deletes a pending order that is not yet due. It reports
+// false when the order can no longer be withdrawn.
+type OrderWithdrawer interface {
+	Withdraw(ctx context.Context, id int64) (bool, error)
+}
+
+// StockReporter stores a remaining-unit count for a prescription.
+type StockReporter interface {
+	ReportStock(ctx context.Context, p prescription.StockReportParams) error
+}
+
 // PrescriptionRefiller records a prescription refill when an order is fulfilled.
 type PrescriptionRefiller interface {
 	RecordRefill(ctx context.Context, prescriptionID int64, newStartDate time.Time) error
 }
 
+// PrescriptionStockRefiller is the prescription side of the order service:
+// refills on fulfillment and stock reports.
+type PrescriptionStockRefiller interface {
+	PrescriptionRefiller
+	StockReporter
+}
+
+// PickupSlotSuggester books a suggested pickup slot for a new order before
+// its depletion date, reporting false when none is free.
+type PickupSlotSuggester interface {
+	SuggestSlot(ctx context.Context, pharmacyID, orderID int64, today, depletion time.Time) (time.Time, bool, error)
+}
+
+// CalendarGetter returns a pharmacy's closure calendar.
+type CalendarGetter interface {
+	Calendar(ctx context.Context, pharmacyID int64) (calendar.Calendar, error)
+}
+
 // Repository composes all ports — used only by NewService for convenient wiring.
 type Repository interface {
 	OrderCreator
@@ -48,4 +95,8 @@ type Repository interface {
 	OrderStatusUpdater
 	OrderGetter
 	PrescriptionLookaheadLister
+	PrescriptionSummaryGetter
+	PendingOrderLister
+	OrderRescheduler
+	OrderWithdrawer
 }
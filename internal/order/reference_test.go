@@ -0,0 +1,65 @@
+package order_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+)
+
+func TestReferenceSignerRoundTrip(t *testing.T) {
+	s := order.NewReferenceSigner("secret")
+
+	ref := s.Sign(42)
+	if !strings.HasPrefix(ref, "PR-42-") {
+		t.Errorf("Sign(42) = %q, want PR-42- prefix", ref)
+	}
+
+	id, err := s.Verify(ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 42 {
+		t.Errorf("Verify() = %d, want 42", id)
+	}
+}
+
+func TestReferenceSignerAcceptsLowercaseAndWhitespace(t *testing.T) {
+	s := order.NewReferenceSigner("secret")
+	ref := "  " + strings.ToLower(s.Sign(7)) + "\n"
+
+	id, err := s.Verify(ref)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 7 {
+		t.Errorf("Verify() = %d, want 7", id)
+	}
+}
+
+func TestReferenceSignerRejectsTampering(t *testing.T) {
+	s := order.NewReferenceSigner("secret")
+	valid := s.Sign(42)
+	sig := valid[strings.LastIndex(valid, "-")+1:]
+
+	tests := []struct {
+		name string
+		ref  string
+	}{
+		{"empty", ""},
+		{"plain id", "42"},
+		{"wrong prefix", "XX-42-" + sig},
+		{"different id same signature", "PR-43-" + sig},
+		{"non-numeric id", "PR-abc-" + sig},
+		{"signed with another key", order.NewReferenceSigner("other").Sign(42)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Verify(tt.ref); !errors.Is(err, order.ErrInvalidReference) {
+				t.Errorf("Verify(%q) err = %v, want ErrInvalidReference", tt.ref, err)
+			}
+		})
+	}
+}
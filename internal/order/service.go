@@ -3,7 +3,20 @@ package order
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+	"github.com/giorgiovilardo/pharmarecall/internal/depletion"
+	"github.com/giorgiovilardo/pharmarecall/internal/metrics"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+var (
+	ordersCreated  = metrics.NewCounter("pharmarecall_orders_created_total", "Orders created for approaching prescriptions.")
+	ordersAdvanced = metrics.NewCounter("pharmarecall_orders_advanced_total", "Orders advanced, by the status reached.", "status")
 )
 
 // ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
@@ -14,7 +27,14 @@ type ServiceDeps struct {
 	StatusUpdater      OrderStatusUpdater
 	Getter             OrderGetter
 	PrescriptionLister PrescriptionLookaheadLister
+	Summary            PrescriptionSummaryGetter
+	Pending            PendingOrderLister
+	Rescheduler        OrderRescheduler
+	Withdrawer         OrderWithdrawer
 	Refiller           PrescriptionRefiller
+	Stock              StockReporter
+	Pickup             PickupSlotSuggester
+	Calendar           CalendarGetter
 }
 
 // Service contains order domain business logic.
@@ -22,9 +42,10 @@ type Service struct {
 	deps ServiceDeps
 }
 
-// NewService is the production constructor — takes a Repository (satisfies all ports)
-// and a PrescriptionRefiller for cross-domain refill on fulfillment.
-func NewService(repo Repository, refiller PrescriptionRefiller) *Service {
+// NewService is the production constructor — takes a Repository (satisfies all ports),
+// the prescription service for cross-domain refills and stock reports, a
+// PickupSlotSuggester for pickup orders and the pharmacy closure calendar.
+func NewService(repo Repository, rx PrescriptionStockRefiller, pickup PickupSlotSuggester, cal CalendarGetter) *Service {
 	return &Service{deps: ServiceDeps{
 		Creator:            repo,
 		ActiveChecker:      repo,
@@ -32,7 +53,14 @@ func NewService(repo Repository, refiller PrescriptionRefiller) *Service {
 		StatusUpdater:      repo,
 		Getter:             repo,
 		PrescriptionLister: repo,
-		Refiller:           refiller,
+		Summary:            repo,
+		Pending:            repo,
+		Rescheduler:        repo,
+		Withdrawer:         repo,
+		Refiller:           rx,
+		Stock:              rx,
+		Pickup:             pickup,
+		Calendar:           cal,
 	}}
 }
 
@@ -42,15 +70,22 @@ func NewServiceWith(d ServiceDeps) *Service {
 }
 
 // EnsureOrders creates pending orders for prescriptions in the lookahead window
-// that don't already have an active order for the current cycle.
+// that don't already have an active order for the current cycle. The window is
+// measured to the prepare-by date, so closures ahead of depletion pull orders
+// in earlier. Pickup orders get a suggested pickup slot before depletion when
+// one is free.
 func (s *Service) EnsureOrders(ctx context.Context, pharmacyID int64, now time.Time, lookaheadDays int) error {
+	cal, err := s.calendar(ctx, pharmacyID)
+	if err != nil {
+		return err
+	}
 	prescriptions, err := s.deps.PrescriptionLister.ListPrescriptionsForPharmacy(ctx, pharmacyID)
 	if err != nil {
 		return fmt.Errorf("listing prescriptions: %w", err)
 	}
 
 	for _, rx := range prescriptions {
-		if rx.DaysRemaining(now) > lookaheadDays {
+		if depletion.DaysRemaining(cal.PrepareBy(rx.EstimatedDepletionDate()), now) > lookaheadDays {
 			continue
 		}
 
@@ -62,7 +97,7 @@ func (s *Service) EnsureOrders(ctx context.Context, pharmacyID int64, now time.T
 			continue
 		}
 
-		_, err = s.deps.Creator.Create(ctx, CreateParams{
+		o, err := s.deps.Creator.Create(ctx, CreateParams{
 			PrescriptionID:         rx.ID,
 			CycleStartDate:         rx.BoxStartDate,
 			EstimatedDepletionDate: rx.EstimatedDepletionDate(),
@@ -70,23 +105,110 @@ func (s *Service) EnsureOrders(ctx context.Context, pharmacyID int64, now time.T
 		if err != nil {
 			return fmt.Errorf("creating order for prescription %d: %w", rx.ID, err)
 		}
+		ordersCreated.Inc()
+
+		if rx.Fulfillment == patient.FulfillmentPickup && s.deps.Pickup != nil {
+			if _, _, err := s.deps.Pickup.SuggestSlot(ctx, pharmacyID, o.ID, now, o.EstimatedDepletionDate); err != nil {
+				return fmt.Errorf("suggesting pickup slot for order %d: %w", o.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReportStock records how many units of a pharmacy's prescription the
+// patient still has and re-anchors its pending orders on the new depletion
+// estimate. An order whose prepare-by date moves beyond the lookahead window
+// is withdrawn, so EnsureOrders creates it again once it is due; the others
+// keep their place with the new date. Prepared orders are left alone: the
+// medication is already set aside.
+func (s *Service) ReportStock(ctx context.Context, pharmacyID int64, p prescription.StockReportParams, now time.Time, lookaheadDays int) error {
+	if err := permission.Check(ctx, permission.RecordRefills); err != nil {
+		return err
+	}
+	if _, err := s.deps.Summary.GetPrescriptionSummary(ctx, pharmacyID, p.PrescriptionID); err != nil {
+		return err
+	}
+	if err := s.deps.Stock.ReportStock(ctx, p); err != nil {
+		return err
+	}
+
+	cal, err := s.calendar(ctx, pharmacyID)
+	if err != nil {
+		return err
+	}
+	rx, err := s.deps.Summary.GetPrescriptionSummary(ctx, pharmacyID, p.PrescriptionID)
+	if err != nil {
+		return fmt.Errorf("getting prescription summary: %w", err)
+	}
+	pending, err := s.deps.Pending.ListPendingOrders(ctx, p.PrescriptionID)
+	if err != nil {
+		return fmt.Errorf("listing pending orders: %w", err)
 	}
 
+	depletesOn := rx.EstimatedDepletionDate()
+	outOfWindow := depletion.DaysRemaining(cal.PrepareBy(depletesOn), now) > lookaheadDays
+	for _, o := range pending {
+		if outOfWindow {
+			withdrawn, err := s.deps.Withdrawer.Withdraw(ctx, o.ID)
+			if err != nil {
+				return fmt.Errorf("withdrawing order %d: %w", o.ID, err)
+			}
+			if withdrawn {
+				continue
+			}
+		}
+		if o.EstimatedDepletionDate.Equal(depletesOn) {
+			continue
+		}
+		if err := s.deps.Rescheduler.Reschedule(ctx, o.ID, depletesOn); err != nil {
+			return fmt.Errorf("rescheduling order %d: %w", o.ID, err)
+		}
+	}
 	return nil
 }
 
-// ListDashboard returns dashboard entries for a pharmacy.
+// ListDashboard returns dashboard entries for a pharmacy with their
+// prepare-by dates, most urgent first.
 func (s *Service) ListDashboard(ctx context.Context, pharmacyID int64) ([]DashboardEntry, error) {
+	cal, err := s.calendar(ctx, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
 	entries, err := s.deps.Dashboard.ListDashboard(ctx, pharmacyID)
 	if err != nil {
 		return nil, fmt.Errorf("listing dashboard: %w", err)
 	}
+	for i := range entries {
+		entries[i].PrepareBy = cal.PrepareBy(entries[i].EstimatedDepletionDate)
+	}
+	slices.SortStableFunc(entries, func(a, b DashboardEntry) int {
+		return a.PrepareBy.Compare(b.PrepareBy)
+	})
 	return entries, nil
 }
 
+// calendar returns the pharmacy's closure calendar, or the always-open zero
+// Calendar when no calendar is wired.
+func (s *Service) calendar(ctx context.Context, pharmacyID int64) (calendar.Calendar, error) {
+	if s.deps.Calendar == nil {
+		return calendar.Calendar{}, nil
+	}
+	cal, err := s.deps.Calendar.Calendar(ctx, pharmacyID)
+	if err != nil {
+		return calendar.Calendar{}, fmt.Errorf("getting closure calendar: %w", err)
+	}
+	return cal, nil
+}
+
 // AdvanceStatus moves an order to the next status in the lifecycle.
-// When transitioning to fulfilled, it also records a prescription refill.
+// When transitioning to fulfilled, it also records a prescription refill, so
+// the caller needs RecordRefills as well as AdvanceOrders.
 func (s *Service) AdvanceStatus(ctx context.Context, orderID int64, now time.Time) error {
+	if err := permission.Check(ctx, permission.AdvanceOrders); err != nil {
+		return err
+	}
 	o, err := s.deps.Getter.GetByID(ctx, orderID)
 	if err != nil {
 		return fmt.Errorf("getting order: %w", err)
@@ -96,10 +218,16 @@ func (s *Service) AdvanceStatus(ctx context.Context, orderID int64, now time.Tim
 	if next == "" {
 		return ErrInvalidTransition
 	}
+	if next == StatusFulfilled {
+		if err := permission.Check(ctx, permission.RecordRefills); err != nil {
+			return err
+		}
+	}
 
 	if err := s.deps.StatusUpdater.UpdateStatus(ctx, orderID, next); err != nil {
 		return fmt.Errorf("updating order status: %w", err)
 	}
+	ordersAdvanced.Inc(next)
 
 	if next == StatusFulfilled {
 		if err := s.deps.Refiller.RecordRefill(ctx, o.PrescriptionID, now); err != nil {
@@ -6,7 +6,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
 	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
 )
 
 func date(y int, m time.Month, d int) time.Time {
@@ -173,6 +176,111 @@ func TestEnsureOrdersNoPrescriptions(t *testing.T) {
 	}
 }
 
+type mockSlotSuggester struct {
+	at        time.Time
+	ok        bool
+	orderID   int64
+	depletion time.Time
+	calls     int
+}
+
+func (m *mockSlotSuggester) SuggestSlot(_ context.Context, _, orderID int64, _, depletion time.Time) (time.Time, bool, error) {
+	m.calls++
+	m.orderID = orderID
+	m.depletion = depletion
+	return m.at, m.ok, nil
+}
+
+func TestEnsureOrdersSuggestsPickupSlot(t *testing.T) {
+	lister := &mockPrescriptionLister{result: []order.PrescriptionSummary{
+		{ID: 1, PatientID: 10, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1), Fulfillment: "pickup"},
+		{ID: 2, PatientID: 11, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1), Fulfillment: "shipping"},
+	}}
+	slot := time.Date(2026, 1, 30, 10, 30, 0, 0, time.UTC)
+	suggester := &mockSlotSuggester{at: slot, ok: true}
+	creator := &mockCreator{}
+
+	svc := order.NewServiceWith(order.ServiceDeps{
+		PrescriptionLister: lister,
+		ActiveChecker:      &mockActiveChecker{},
+		Creator:            creator,
+		Pickup:             suggester,
+	})
+
+	if err := svc.EnsureOrders(context.Background(), 1, date(2026, 1, 27), 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if suggester.calls != 1 {
+		t.Fatalf("SuggestSlot calls = %d, want 1 (pickup only)", suggester.calls)
+	}
+	if !suggester.depletion.Equal(date(2026, 1, 31)) {
+		t.Errorf("suggested before %s, want 2026-01-31", suggester.depletion.Format("2006-01-02"))
+	}
+	if suggester.orderID != 1 {
+		t.Errorf("slot suggested for order %d, want the new pickup order 1", suggester.orderID)
+	}
+	if len(creator.params) != 2 {
+		t.Errorf("orders created = %d, want 2", len(creator.params))
+	}
+}
+
+func TestEnsureOrdersWithoutFreeSlot(t *testing.T) {
+	lister := &mockPrescriptionLister{result: []order.PrescriptionSummary{
+		{ID: 1, PatientID: 10, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1), Fulfillment: "pickup"},
+	}}
+	creator := &mockCreator{}
+
+	svc := order.NewServiceWith(order.ServiceDeps{
+		PrescriptionLister: lister,
+		ActiveChecker:      &mockActiveChecker{},
+		Creator:            creator,
+		Pickup:             &mockSlotSuggester{ok: false},
+	})
+
+	if err := svc.EnsureOrders(context.Background(), 1, date(2026, 1, 27), 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(creator.params) != 1 {
+		t.Errorf("expected order created without pickup slot, got %+v", creator.params)
+	}
+}
+
+type mockCalendar struct {
+	cal calendar.Calendar
+}
+
+func (m *mockCalendar) Calendar(_ context.Context, _ int64) (calendar.Calendar, error) {
+	return m.cal, nil
+}
+
+func TestEnsureOrdersLookaheadUsesPrepareByDate(t *testing.T) {
+	// 30 units at 1/day from Jul 16 → depletes Aug 15, inside a closure from
+	// Aug 8 to Aug 23 → must be prepared by Aug 7. On Jul 31 that is 7 days
+	// away (inside the window) although depletion is 15 days away.
+	lister := &mockPrescriptionLister{result: []order.PrescriptionSummary{
+		{ID: 1, PatientID: 10, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 7, 16)},
+	}}
+	creator := &mockCreator{}
+	cal := calendar.Calendar{Closures: []calendar.Closure{{From: date(2026, 8, 8), To: date(2026, 8, 23)}}}
+
+	svc := order.NewServiceWith(order.ServiceDeps{
+		PrescriptionLister: lister,
+		ActiveChecker:      &mockActiveChecker{},
+		Creator:            creator,
+		Calendar:           &mockCalendar{cal: cal},
+	})
+
+	if err := svc.EnsureOrders(context.Background(), 1, date(2026, 7, 31), 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !creator.called {
+		t.Fatal("expected order to be created ahead of the closure")
+	}
+	if !creator.params[0].EstimatedDepletionDate.Equal(date(2026, 8, 15)) {
+		t.Errorf("EstimatedDepletionDate = %s, want the real depletion date", creator.params[0].EstimatedDepletionDate.Format("2006-01-02"))
+	}
+}
+
 // --- ListDashboard tests ---
 
 type mockDashboardLister struct {
@@ -201,6 +309,32 @@ func TestListDashboardSuccess(t *testing.T) {
 	}
 }
 
+func TestListDashboardSortsByPrepareBy(t *testing.T) {
+	// Both deplete after A; B depletes on a Sunday and must be ready on Saturday.
+	lister := &mockDashboardLister{result: []order.DashboardEntry{
+		{OrderID: 1, EstimatedDepletionDate: date(2026, 1, 30)}, // Friday
+		{OrderID: 2, EstimatedDepletionDate: date(2026, 2, 1)},  // Sunday
+		{OrderID: 3, EstimatedDepletionDate: date(2026, 1, 31)}, // Saturday
+	}}
+	cal := calendar.Calendar{Settings: calendar.Settings{ClosedWeekdays: []time.Weekday{time.Sunday}}}
+	svc := order.NewServiceWith(order.ServiceDeps{Dashboard: lister, Calendar: &mockCalendar{cal: cal}})
+
+	entries, err := svc.ListDashboard(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []int64
+	for _, e := range entries {
+		ids = append(ids, e.OrderID)
+	}
+	if ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Errorf("order = %v, want [1 2 3] (stable on equal prepare-by dates)", ids)
+	}
+	if !entries[1].PrepareBy.Equal(date(2026, 1, 31)) {
+		t.Errorf("PrepareBy = %s, want 2026-01-31", entries[1].PrepareBy.Format("2006-01-02"))
+	}
+}
+
 // --- AdvanceStatus tests ---
 
 type mockGetter struct {
@@ -246,7 +380,7 @@ func TestAdvanceStatusPendingToPrepared(t *testing.T) {
 	refiller := &mockRefiller{}
 	svc := order.NewServiceWith(order.ServiceDeps{Getter: getter, StatusUpdater: updater, Refiller: refiller})
 
-	err := svc.AdvanceStatus(context.Background(), 1, date(2026, 2, 23))
+	err := svc.AdvanceStatus(permission.WithSystem(context.Background()), 1, date(2026, 2, 23))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -268,7 +402,7 @@ func TestAdvanceStatusPreparedToFulfilled(t *testing.T) {
 	refiller := &mockRefiller{}
 	svc := order.NewServiceWith(order.ServiceDeps{Getter: getter, StatusUpdater: updater, Refiller: refiller})
 
-	err := svc.AdvanceStatus(context.Background(), 1, now)
+	err := svc.AdvanceStatus(permission.WithSystem(context.Background()), 1, now)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -292,7 +426,7 @@ func TestAdvanceStatusRefillErrorPropagates(t *testing.T) {
 	refiller := &mockRefiller{err: errors.New("refill failed")}
 	svc := order.NewServiceWith(order.ServiceDeps{Getter: getter, StatusUpdater: updater, Refiller: refiller})
 
-	err := svc.AdvanceStatus(context.Background(), 1, date(2026, 2, 23))
+	err := svc.AdvanceStatus(permission.WithSystem(context.Background()), 1, date(2026, 2, 23))
 	if err == nil {
 		t.Fatal("expected error when refill fails")
 	}
@@ -303,7 +437,7 @@ func TestAdvanceStatusFulfilledIsTerminal(t *testing.T) {
 	updater := &mockStatusUpdater{}
 	svc := order.NewServiceWith(order.ServiceDeps{Getter: getter, StatusUpdater: updater})
 
-	err := svc.AdvanceStatus(context.Background(), 1, date(2026, 2, 23))
+	err := svc.AdvanceStatus(permission.WithSystem(context.Background()), 1, date(2026, 2, 23))
 	if err == nil {
 		t.Fatal("expected error for terminal status")
 	}
@@ -315,11 +449,38 @@ func TestAdvanceStatusFulfilledIsTerminal(t *testing.T) {
 	}
 }
 
+func TestAdvanceStatusNeedsPermissions(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		perms  permission.Set
+	}{
+		{"trainee", order.StatusPending, permission.ForRole(permission.RoleTrainee, nil)},
+		{"fulfilling without refills", order.StatusPrepared, permission.Set{permission.ViewOrders, permission.AdvanceOrders}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := &mockGetter{result: order.Order{ID: 1, PrescriptionID: 42, Status: tt.status}}
+			updater := &mockStatusUpdater{}
+			refiller := &mockRefiller{}
+			svc := order.NewServiceWith(order.ServiceDeps{Getter: getter, StatusUpdater: updater, Refiller: refiller})
+
+			err := svc.AdvanceStatus(permission.NewContext(context.Background(), tt.perms), 1, date(2026, 2, 23))
+			if !errors.Is(err, permission.ErrForbidden) {
+				t.Fatalf("error = %v, want ErrForbidden", err)
+			}
+			if updater.called || refiller.called {
+				t.Error("a forbidden advance must not change the order")
+			}
+		})
+	}
+}
+
 func TestAdvanceStatusNotFound(t *testing.T) {
 	getter := &mockGetter{err: order.ErrNotFound}
 	svc := order.NewServiceWith(order.ServiceDeps{Getter: getter})
 
-	err := svc.AdvanceStatus(context.Background(), 999, date(2026, 2, 23))
+	err := svc.AdvanceStatus(permission.WithSystem(context.Background()), 999, date(2026, 2, 23))
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -327,3 +488,170 @@ func TestAdvanceStatusNotFound(t *testing.T) {
 		t.Errorf("error = %v, want ErrNotFound", err)
 	}
 }
+
+// --- ReportStock tests ---
+
+type mockSummary struct {
+	result order.PrescriptionSummary
+	err    error
+}
+
+func (m *mockSummary) GetPrescriptionSummary(_ context.Context, _, _ int64) (order.PrescriptionSummary, error) {
+	return m.result, m.err
+}
+
+// mockStockReporter stores the report on the summary, as the database would.
+type mockStockReporter struct {
+	summary *mockSummary
+	called  bool
+	err     error
+}
+
+func (m *mockStockReporter) ReportStock(_ context.Context, p prescription.StockReportParams) error {
+	m.called = true
+	if m.err != nil {
+		return m.err
+	}
+	m.summary.result.ReportedUnits = p.Units
+	m.summary.result.ReportedOn = p.ReportedOn
+	return nil
+}
+
+type mockPending struct {
+	result []order.Order
+}
+
+func (m *mockPending) ListPendingOrders(_ context.Context, _ int64) ([]order.Order, error) {
+	return m.result, nil
+}
+
+type mockRescheduler struct {
+	moved map[int64]time.Time
+}
+
+func (m *mockRescheduler) Reschedule(_ context.Context, id int64, depletion time.Time) error {
+	if m.moved == nil {
+		m.moved = map[int64]time.Time{}
+	}
+	m.moved[id] = depletion
+	return nil
+}
+
+type mockWithdrawer struct {
+	withdrawn []int64
+	locked    bool // simulates an order already in a shipping batch
+}
+
+func (m *mockWithdrawer) Withdraw(_ context.Context, id int64) (bool, error) {
+	if m.locked {
+		return false, nil
+	}
+	m.withdrawn = append(m.withdrawn, id)
+	return true, nil
+}
+
+// stockService wires a prescription of 30 units at 1/day from Jan 1
+// (depletes Jan 31) with a pending order for that date.
+func stockService() (*order.Service, *mockStockReporter, *mockRescheduler, *mockWithdrawer) {
+	summary := &mockSummary{result: order.PrescriptionSummary{ID: 5, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)}}
+	stock := &mockStockReporter{summary: summary}
+	rescheduler := &mockRescheduler{}
+	withdrawer := &mockWithdrawer{}
+	svc := order.NewServiceWith(order.ServiceDeps{
+		Summary:     summary,
+		Stock:       stock,
+		Pending:     &mockPending{result: []order.Order{{ID: 40, PrescriptionID: 5, EstimatedDepletionDate: date(2026, 1, 31), Status: order.StatusPending}}},
+		Rescheduler: rescheduler,
+		Withdrawer:  withdrawer,
+	})
+	return svc, stock, rescheduler, withdrawer
+}
+
+func TestReportStockMovesPendingOrderInsideWindow(t *testing.T) {
+	svc, _, rescheduler, withdrawer := stockService()
+
+	// On Jan 27, 8 units left → depletes Feb 4, 8 days away: still in a 10-day window.
+	err := svc.ReportStock(permission.WithSystem(context.Background()), 1, prescription.StockReportParams{
+		PrescriptionID: 5, Units: 8, ReportedOn: date(2026, 1, 27), Source: prescription.StockSourceStaff,
+	}, date(2026, 1, 27), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(withdrawer.withdrawn) != 0 {
+		t.Errorf("withdrawn %v, want none", withdrawer.withdrawn)
+	}
+	if got := rescheduler.moved[40]; !got.Equal(date(2026, 2, 4)) {
+		t.Errorf("order moved to %s, want 2026-02-04", got.Format("2006-01-02"))
+	}
+}
+
+func TestReportStockWithdrawsOrderPushedOutOfWindow(t *testing.T) {
+	svc, _, rescheduler, withdrawer := stockService()
+
+	// On Jan 27, 30 units left → depletes Feb 26, far beyond a 7-day window.
+	err := svc.ReportStock(permission.WithSystem(context.Background()), 1, prescription.StockReportParams{
+		PrescriptionID: 5, Units: 30, ReportedOn: date(2026, 1, 27), Source: prescription.StockSourcePortal,
+	}, date(2026, 1, 27), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(withdrawer.withdrawn) != 1 || withdrawer.withdrawn[0] != 40 {
+		t.Errorf("withdrawn %v, want [40]", withdrawer.withdrawn)
+	}
+	if len(rescheduler.moved) != 0 {
+		t.Errorf("withdrawn order should not be rescheduled, got %v", rescheduler.moved)
+	}
+}
+
+func TestReportStockReschedulesOrderThatCannotBeWithdrawn(t *testing.T) {
+	svc, _, rescheduler, withdrawer := stockService()
+	withdrawer.locked = true
+
+	err := svc.ReportStock(permission.WithSystem(context.Background()), 1, prescription.StockReportParams{
+		PrescriptionID: 5, Units: 30, ReportedOn: date(2026, 1, 27), Source: prescription.StockSourcePortal,
+	}, date(2026, 1, 27), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rescheduler.moved[40]; !got.Equal(date(2026, 2, 26)) {
+		t.Errorf("order moved to %s, want 2026-02-26", got.Format("2006-01-02"))
+	}
+}
+
+func TestReportStockUnknownPrescription(t *testing.T) {
+	stock := &mockStockReporter{summary: &mockSummary{}}
+	svc := order.NewServiceWith(order.ServiceDeps{
+		Summary: &mockSummary{err: prescription.ErrNotFound},
+		Stock:   stock,
+	})
+
+	err := svc.ReportStock(permission.WithSystem(context.Background()), 1, prescription.StockReportParams{
+		PrescriptionID: 5, Units: 3, ReportedOn: date(2026, 1, 27), Source: prescription.StockSourceStaff,
+	}, date(2026, 1, 27), 7)
+	if !errors.Is(err, prescription.ErrNotFound) {
+		t.Errorf("error = %v, want prescription.ErrNotFound", err)
+	}
+	if stock.called {
+		t.Error("a prescription of another pharmacy must not get a report")
+	}
+}
+
+func TestEnsureOrdersUsesStockReport(t *testing.T) {
+	// Box would run out Jan 31, but 20 units were left on Jan 25 → Feb 14.
+	lister := &mockPrescriptionLister{result: []order.PrescriptionSummary{
+		{ID: 1, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1), ReportedUnits: 20, ReportedOn: date(2026, 1, 25)},
+	}}
+	creator := &mockCreator{}
+	svc := order.NewServiceWith(order.ServiceDeps{
+		PrescriptionLister: lister,
+		ActiveChecker:      &mockActiveChecker{},
+		Creator:            creator,
+	})
+
+	if err := svc.EnsureOrders(context.Background(), 1, date(2026, 1, 27), 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creator.called {
+		t.Error("no order expected: the reported stock lasts beyond the window")
+	}
+}
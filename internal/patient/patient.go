@@ -1,12 +1,19 @@
 package patient
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
+)
 
 var (
-	ErrNotFound             = errors.New("patient not found")
-	ErrNameRequired         = errors.New("il nome e il cognome sono obbligatori")
-	ErrContactRequired      = errors.New("è necessario almeno un contatto (telefono o email)")
-	ErrDeliveryAddrRequired = errors.New("l'indirizzo di consegna è obbligatorio per la spedizione")
+	ErrNotFound             = errors.New("patient.not_found")
+	ErrNameRequired         = errors.New("patient.name_required")
+	ErrContactRequired      = errors.New("patient.contact_required")
+	ErrDeliveryAddrRequired = errors.New("patient.delivery_address_required")
+	ErrTransferSameBranch   = errors.New("patient.transfer_same_branch")
+	ErrTransferOutsideGroup = errors.New("patient.transfer_outside_group")
+	ErrTransferInTransit    = errors.New("patient.transfer_in_transit")
 )
 
 // Fulfillment constants.
@@ -23,7 +30,7 @@ type Patient struct {
 	LastName        string
 	Phone           string
 	Email           string
-	DeliveryAddress string
+	DeliveryAddress address.Address
 	Fulfillment     string
 	Notes           string
 	Consensus       bool
@@ -47,7 +54,7 @@ type CreateParams struct {
 	LastName        string
 	Phone           string
 	Email           string
-	DeliveryAddress string
+	DeliveryAddress address.Address
 	Fulfillment     string
 	Notes           string
 }
@@ -59,7 +66,7 @@ type UpdateParams struct {
 	LastName        string
 	Phone           string
 	Email           string
-	DeliveryAddress string
+	DeliveryAddress address.Address
 	Fulfillment     string
 	Notes           string
 }
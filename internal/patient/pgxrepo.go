@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
 	"github.com/giorgiovilardo/pharmarecall/internal/db"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -32,14 +33,20 @@ func (r *PgxRepository) Create(ctx context.Context, p CreateParams) (Patient, er
 	defer tx.Rollback(ctx)
 
 	row, err := r.queries.WithTx(tx).CreatePatient(ctx, db.CreatePatientParams{
-		PharmacyID:      p.PharmacyID,
-		FirstName:       p.FirstName,
-		LastName:        p.LastName,
-		Phone:           p.Phone,
-		Email:           p.Email,
-		DeliveryAddress: p.DeliveryAddress,
-		Fulfillment:     p.Fulfillment,
-		Notes:           p.Notes,
+		PharmacyID:          p.PharmacyID,
+		FirstName:           p.FirstName,
+		LastName:            p.LastName,
+		Phone:               p.Phone,
+		Email:               p.Email,
+		DeliveryStreet:      p.DeliveryAddress.Street,
+		DeliveryHouseNumber: p.DeliveryAddress.HouseNumber,
+		DeliveryCap:         p.DeliveryAddress.CAP,
+		DeliveryCity:        p.DeliveryAddress.City,
+		DeliveryProvince:    p.DeliveryAddress.Province,
+		DeliveryCountry:     p.DeliveryAddress.Country,
+		DeliveryNotes:       p.DeliveryAddress.Notes,
+		Fulfillment:         p.Fulfillment,
+		Notes:               p.Notes,
 	})
 	if err != nil {
 		return Patient{}, fmt.Errorf("creating patient: %w", err)
@@ -90,14 +97,20 @@ func (r *PgxRepository) Update(ctx context.Context, p UpdateParams) error {
 	defer tx.Rollback(ctx)
 
 	if err := r.queries.WithTx(tx).UpdatePatient(ctx, db.UpdatePatientParams{
-		ID:              p.ID,
-		FirstName:       p.FirstName,
-		LastName:        p.LastName,
-		Phone:           p.Phone,
-		Email:           p.Email,
-		DeliveryAddress: p.DeliveryAddress,
-		Fulfillment:     p.Fulfillment,
-		Notes:           p.Notes,
+		ID:                  p.ID,
+		FirstName:           p.FirstName,
+		LastName:            p.LastName,
+		Phone:               p.Phone,
+		Email:               p.Email,
+		DeliveryStreet:      p.DeliveryAddress.Street,
+		DeliveryHouseNumber: p.DeliveryAddress.HouseNumber,
+		DeliveryCap:         p.DeliveryAddress.CAP,
+		DeliveryCity:        p.DeliveryAddress.City,
+		DeliveryProvince:    p.DeliveryAddress.Province,
+		DeliveryCountry:     p.DeliveryAddress.Country,
+		DeliveryNotes:       p.DeliveryAddress.Notes,
+		Fulfillment:         p.Fulfillment,
+		Notes:               p.Notes,
 	}); err != nil {
 		return fmt.Errorf("updating patient: %w", err)
 	}
@@ -119,18 +132,71 @@ func (r *PgxRepository) SetConsensus(ctx context.Context, id int64) error {
 	return tx.Commit(ctx)
 }
 
+func (r *PgxRepository) Transfer(ctx context.Context, patientID, fromPharmacyID, toPharmacyID int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+
+	if _, err := qtx.LockPatientInPharmacy(ctx, db.LockPatientInPharmacyParams{ID: patientID, PharmacyID: fromPharmacyID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("locking patient: %w", err)
+	}
+
+	shared, err := qtx.PharmaciesShareGroup(ctx, db.PharmaciesShareGroupParams{FromPharmacyID: fromPharmacyID, ToPharmacyID: toPharmacyID})
+	if err != nil {
+		return fmt.Errorf("checking pharmacy group: %w", err)
+	}
+	if !shared {
+		return ErrTransferOutsideGroup
+	}
+
+	inTransit, err := qtx.CountPatientOrdersInTransit(ctx, patientID)
+	if err != nil {
+		return fmt.Errorf("counting orders in transit: %w", err)
+	}
+	if inTransit > 0 {
+		return ErrTransferInTransit
+	}
+
+	if err := qtx.MovePatientToPharmacy(ctx, db.MovePatientToPharmacyParams{ID: patientID, PharmacyID: toPharmacyID}); err != nil {
+		return fmt.Errorf("moving patient: %w", err)
+	}
+	if err := qtx.ClearPatientOrderPickups(ctx, patientID); err != nil {
+		return fmt.Errorf("clearing order pickups: %w", err)
+	}
+	if err := qtx.MovePatientNotifications(ctx, db.MovePatientNotificationsParams{PatientID: patientID, PharmacyID: toPharmacyID}); err != nil {
+		return fmt.Errorf("moving notifications: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
 func mapPatient(row db.Patient) Patient {
 	p := Patient{
-		ID:              row.ID,
-		PharmacyID:      row.PharmacyID,
-		FirstName:       row.FirstName,
-		LastName:        row.LastName,
-		Phone:           row.Phone,
-		Email:           row.Email,
-		DeliveryAddress: row.DeliveryAddress,
-		Fulfillment:     row.Fulfillment,
-		Notes:           row.Notes,
-		Consensus:       row.Consensus,
+		ID:         row.ID,
+		PharmacyID: row.PharmacyID,
+		FirstName:  row.FirstName,
+		LastName:   row.LastName,
+		Phone:      row.Phone,
+		Email:      row.Email,
+		DeliveryAddress: address.WithLegacy(address.Address{
+			Street:      row.DeliveryStreet,
+			HouseNumber: row.DeliveryHouseNumber,
+			CAP:         row.DeliveryCap,
+			City:        row.DeliveryCity,
+			Province:    row.DeliveryProvince,
+			Country:     row.DeliveryCountry,
+			Notes:       row.DeliveryNotes,
+		}, row.DeliveryAddressLegacy),
+		Fulfillment: row.Fulfillment,
+		Notes:       row.Notes,
+		Consensus:   row.Consensus,
 	}
 	if row.ConsensusDate.Valid {
 		d := row.ConsensusDate.Time.Format("2006-01-02")
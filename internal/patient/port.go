@@ -27,6 +27,12 @@ type ConsensusRecorder interface {
 	SetConsensus(ctx context.Context, id int64) error
 }
 
+// PatientTransferer moves a patient, with prescriptions and history, to
+// another branch of the same pharmacy group.
+type PatientTransferer interface {
+	Transfer(ctx context.Context, patientID, fromPharmacyID, toPharmacyID int64) error
+}
+
 // Repository composes all ports — used only by NewService for convenient wiring.
 type Repository interface {
 	PatientCreator
@@ -34,4 +40,5 @@ type Repository interface {
 	PatientLister
 	PatientUpdater
 	ConsensusRecorder
+	PatientTransferer
 }
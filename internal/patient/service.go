@@ -3,6 +3,9 @@ package patient
 import (
 	"context"
 	"fmt"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 )
 
 // ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
@@ -12,6 +15,7 @@ type ServiceDeps struct {
 	Lister    PatientLister
 	Updater   PatientUpdater
 	Consensus ConsensusRecorder
+	Transfer  PatientTransferer
 }
 
 // Service contains patient domain business logic.
@@ -27,6 +31,7 @@ func NewService(repo Repository) *Service {
 		Lister:    repo,
 		Updater:   repo,
 		Consensus: repo,
+		Transfer:  repo,
 	}}
 }
 
@@ -56,6 +61,9 @@ func (s *Service) HasConsensus(ctx context.Context, patientID int64) (bool, erro
 
 // Create validates and creates a patient.
 func (s *Service) Create(ctx context.Context, p CreateParams) (Patient, error) {
+	if err := permission.Check(ctx, permission.EditPatients); err != nil {
+		return Patient{}, err
+	}
 	if p.FirstName == "" || p.LastName == "" {
 		return Patient{}, ErrNameRequired
 	}
@@ -65,8 +73,9 @@ func (s *Service) Create(ctx context.Context, p CreateParams) (Patient, error) {
 	if p.Fulfillment == "" {
 		p.Fulfillment = FulfillmentPickup
 	}
-	if p.Fulfillment == FulfillmentShipping && p.DeliveryAddress == "" {
-		return Patient{}, ErrDeliveryAddrRequired
+	p.DeliveryAddress = address.Normalize(p.DeliveryAddress)
+	if err := validateDeliveryAddress(p.Fulfillment, p.DeliveryAddress); err != nil {
+		return Patient{}, err
 	}
 
 	pt, err := s.deps.Creator.Create(ctx, p)
@@ -78,14 +87,18 @@ func (s *Service) Create(ctx context.Context, p CreateParams) (Patient, error) {
 
 // Update validates and updates a patient.
 func (s *Service) Update(ctx context.Context, p UpdateParams) error {
+	if err := permission.Check(ctx, permission.EditPatients); err != nil {
+		return err
+	}
 	if p.FirstName == "" || p.LastName == "" {
 		return ErrNameRequired
 	}
 	if p.Phone == "" && p.Email == "" {
 		return ErrContactRequired
 	}
-	if p.Fulfillment == FulfillmentShipping && p.DeliveryAddress == "" {
-		return ErrDeliveryAddrRequired
+	p.DeliveryAddress = address.Normalize(p.DeliveryAddress)
+	if err := validateDeliveryAddress(p.Fulfillment, p.DeliveryAddress); err != nil {
+		return err
 	}
 
 	if err := s.deps.Updater.Update(ctx, p); err != nil {
@@ -96,8 +109,39 @@ func (s *Service) Update(ctx context.Context, p UpdateParams) error {
 
 // SetConsensus records that a patient has given consensus.
 func (s *Service) SetConsensus(ctx context.Context, id int64) error {
+	if err := permission.Check(ctx, permission.EditPatients); err != nil {
+		return err
+	}
 	if err := s.deps.Consensus.SetConsensus(ctx, id); err != nil {
 		return fmt.Errorf("setting consensus: %w", err)
 	}
 	return nil
 }
+
+// Transfer moves a patient from one branch to another of the same group.
+// Prescriptions, refill history, stock reports and orders follow the patient;
+// open orders lose their pickup slot, which belonged to the old branch.
+func (s *Service) Transfer(ctx context.Context, patientID, fromPharmacyID, toPharmacyID int64) error {
+	if err := permission.Check(ctx, permission.TransferPatients); err != nil {
+		return err
+	}
+	if fromPharmacyID == toPharmacyID {
+		return ErrTransferSameBranch
+	}
+	if err := s.deps.Transfer.Transfer(ctx, patientID, fromPharmacyID, toPharmacyID); err != nil {
+		return fmt.Errorf("transferring patient: %w", err)
+	}
+	return nil
+}
+
+// validateDeliveryAddress requires a complete address for shipping patients
+// and checks any address that was entered, even for pickup.
+func validateDeliveryAddress(fulfillment string, a address.Address) error {
+	if a.IsZero() {
+		if fulfillment == FulfillmentShipping {
+			return ErrDeliveryAddrRequired
+		}
+		return nil
+	}
+	return address.Validate(a)
+}
@@ -3,22 +3,25 @@ package patient_test
 import (
 	"context"
 	"errors"
-	"strings"
 	"testing"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
 	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 )
 
 // --- Mocks ---
 
 type mockPatientCreator struct {
 	called bool
+	params patient.CreateParams
 	result patient.Patient
 	err    error
 }
 
-func (m *mockPatientCreator) Create(_ context.Context, _ patient.CreateParams) (patient.Patient, error) {
+func (m *mockPatientCreator) Create(_ context.Context, p patient.CreateParams) (patient.Patient, error) {
 	m.called = true
+	m.params = p
 	return m.result, m.err
 }
 
@@ -38,7 +41,7 @@ func TestCreateSuccess(t *testing.T) {
 	creator := &mockPatientCreator{result: patient.Patient{ID: 1, FirstName: "Mario", LastName: "Rossi"}}
 	svc := patient.NewServiceWith(patient.ServiceDeps{Creator: creator})
 
-	got, err := svc.Create(context.Background(), patient.CreateParams{
+	got, err := svc.Create(permission.WithSystem(context.Background()), patient.CreateParams{
 		FirstName: "Mario",
 		LastName:  "Rossi",
 		Phone:     "333-1234567",
@@ -58,7 +61,7 @@ func TestCreateDefaultsFulfillmentToPickup(t *testing.T) {
 	creator := &mockPatientCreator{result: patient.Patient{ID: 1}}
 	svc := patient.NewServiceWith(patient.ServiceDeps{Creator: creator})
 
-	_, err := svc.Create(context.Background(), patient.CreateParams{
+	_, err := svc.Create(permission.WithSystem(context.Background()), patient.CreateParams{
 		FirstName: "Mario",
 		LastName:  "Rossi",
 		Phone:     "333-1234567",
@@ -73,53 +76,88 @@ func TestCreateDefaultsFulfillmentToPickup(t *testing.T) {
 
 func TestCreateValidation(t *testing.T) {
 	tests := []struct {
-		name   string
-		params patient.CreateParams
-		errStr string
+		name    string
+		params  patient.CreateParams
+		wantErr error
 	}{
 		{
-			name:   "missing first name",
-			params: patient.CreateParams{LastName: "Rossi", Phone: "333"},
-			errStr: "nome",
+			name:    "missing first name",
+			params:  patient.CreateParams{LastName: "Rossi", Phone: "333"},
+			wantErr: patient.ErrNameRequired,
 		},
 		{
-			name:   "missing last name",
-			params: patient.CreateParams{FirstName: "Mario", Phone: "333"},
-			errStr: "cognome",
+			name:    "missing last name",
+			params:  patient.CreateParams{FirstName: "Mario", Phone: "333"},
+			wantErr: patient.ErrNameRequired,
 		},
 		{
-			name:   "missing contact",
-			params: patient.CreateParams{FirstName: "Mario", LastName: "Rossi"},
-			errStr: "contatto",
+			name:    "missing contact",
+			params:  patient.CreateParams{FirstName: "Mario", LastName: "Rossi"},
+			wantErr: patient.ErrContactRequired,
 		},
 		{
-			name:   "shipping without address",
-			params: patient.CreateParams{FirstName: "Mario", LastName: "Rossi", Phone: "333", Fulfillment: "shipping"},
-			errStr: "indirizzo",
+			name:    "shipping without address",
+			params:  patient.CreateParams{FirstName: "Mario", LastName: "Rossi", Phone: "333", Fulfillment: "shipping"},
+			wantErr: patient.ErrDeliveryAddrRequired,
+		},
+		{
+			name: "shipping with legacy address only",
+			params: patient.CreateParams{FirstName: "Mario", LastName: "Rossi", Phone: "333", Fulfillment: "shipping",
+				DeliveryAddress: address.Address{Legacy: "Via Roma 1, Milano"}},
+			wantErr: patient.ErrDeliveryAddrRequired,
+		},
+		{
+			name: "CAP outside province",
+			params: patient.CreateParams{FirstName: "Mario", LastName: "Rossi", Phone: "333", Fulfillment: "shipping",
+				DeliveryAddress: address.Address{Street: "Via Roma", HouseNumber: "1", CAP: "00184", City: "Milano", Province: "MI"}},
+			wantErr: address.ErrCAPProvinceMismatch,
+		},
+		{
+			name: "incomplete address for pickup",
+			params: patient.CreateParams{FirstName: "Mario", LastName: "Rossi", Phone: "333",
+				DeliveryAddress: address.Address{Street: "Via Roma"}},
+			wantErr: address.ErrStreetRequired,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := patient.NewServiceWith(patient.ServiceDeps{})
-			_, err := svc.Create(context.Background(), tt.params)
+			_, err := svc.Create(permission.WithSystem(context.Background()), tt.params)
 			if err == nil {
 				t.Fatal("expected validation error")
 			}
-			if !strings.Contains(err.Error(), tt.errStr) {
-				t.Errorf("error = %q, want substring %q", err.Error(), tt.errStr)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+func TestCreateNormalizesAddress(t *testing.T) {
+	creator := &mockPatientCreator{}
+	svc := patient.NewServiceWith(patient.ServiceDeps{Creator: creator})
+
+	_, err := svc.Create(permission.WithSystem(context.Background()), patient.CreateParams{
+		FirstName: "Mario", LastName: "Rossi", Phone: "333", Fulfillment: "shipping",
+		DeliveryAddress: address.Address{Street: " Via Roma ", HouseNumber: "1", CAP: "20121", City: "Milano", Province: "mi"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := creator.params.DeliveryAddress
+	if got.Street != "Via Roma" || got.Province != "MI" || got.Country != "IT" {
+		t.Errorf("address not normalized: %+v", got)
+	}
+}
+
 // --- Update tests ---
 
 func TestUpdateSuccess(t *testing.T) {
 	updater := &mockPatientUpdater{}
 	svc := patient.NewServiceWith(patient.ServiceDeps{Updater: updater})
 
-	err := svc.Update(context.Background(), patient.UpdateParams{
+	err := svc.Update(permission.WithSystem(context.Background()), patient.UpdateParams{
 		ID: 1, FirstName: "Mario", LastName: "Rossi", Phone: "333",
 	})
 	if err != nil {
@@ -132,24 +170,24 @@ func TestUpdateSuccess(t *testing.T) {
 
 func TestUpdateValidation(t *testing.T) {
 	tests := []struct {
-		name   string
-		params patient.UpdateParams
-		errStr string
+		name    string
+		params  patient.UpdateParams
+		wantErr error
 	}{
 		{
-			name:   "missing name",
-			params: patient.UpdateParams{ID: 1, LastName: "Rossi", Phone: "333"},
-			errStr: "nome",
+			name:    "missing name",
+			params:  patient.UpdateParams{ID: 1, LastName: "Rossi", Phone: "333"},
+			wantErr: patient.ErrNameRequired,
 		},
 		{
-			name:   "missing contact",
-			params: patient.UpdateParams{ID: 1, FirstName: "Mario", LastName: "Rossi"},
-			errStr: "contatto",
+			name:    "missing contact",
+			params:  patient.UpdateParams{ID: 1, FirstName: "Mario", LastName: "Rossi"},
+			wantErr: patient.ErrContactRequired,
 		},
 		{
-			name:   "shipping without address",
-			params: patient.UpdateParams{ID: 1, FirstName: "Mario", LastName: "Rossi", Phone: "333", Fulfillment: "shipping"},
-			errStr: "indirizzo",
+			name:    "shipping without address",
+			params:  patient.UpdateParams{ID: 1, FirstName: "Mario", LastName: "Rossi", Phone: "333", Fulfillment: "shipping"},
+			wantErr: patient.ErrDeliveryAddrRequired,
 		},
 	}
 
@@ -157,7 +195,7 @@ func TestUpdateValidation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			updater := &mockPatientUpdater{}
 			svc := patient.NewServiceWith(patient.ServiceDeps{Updater: updater})
-			err := svc.Update(context.Background(), tt.params)
+			err := svc.Update(permission.WithSystem(context.Background()), tt.params)
 			if err == nil {
 				t.Fatal("expected validation error")
 			}
@@ -184,7 +222,7 @@ func TestSetConsensusSuccess(t *testing.T) {
 	recorder := &mockConsensusRecorder{}
 	svc := patient.NewServiceWith(patient.ServiceDeps{Consensus: recorder})
 
-	if err := svc.SetConsensus(context.Background(), 1); err != nil {
+	if err := svc.SetConsensus(permission.WithSystem(context.Background()), 1); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if !recorder.called {
@@ -196,8 +234,57 @@ func TestSetConsensusRepoError(t *testing.T) {
 	recorder := &mockConsensusRecorder{err: errors.New("db down")}
 	svc := patient.NewServiceWith(patient.ServiceDeps{Consensus: recorder})
 
-	err := svc.SetConsensus(context.Background(), 1)
+	err := svc.SetConsensus(permission.WithSystem(context.Background()), 1)
 	if err == nil {
 		t.Fatal("expected error")
 	}
 }
+
+// --- Transfer ---
+
+type mockPatientTransferer struct {
+	called    bool
+	patientID int64
+	from, to  int64
+	err       error
+}
+
+func (m *mockPatientTransferer) Transfer(_ context.Context, patientID, from, to int64) error {
+	m.called = true
+	m.patientID, m.from, m.to = patientID, from, to
+	return m.err
+}
+
+func TestTransferDelegatesToRepository(t *testing.T) {
+	tr := &mockPatientTransferer{}
+	svc := patient.NewServiceWith(patient.ServiceDeps{Transfer: tr})
+
+	if err := svc.Transfer(permission.WithSystem(context.Background()), 10, 7, 8); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.patientID != 10 || tr.from != 7 || tr.to != 8 {
+		t.Errorf("Transfer(%d, %d, %d), want (10, 7, 8)", tr.patientID, tr.from, tr.to)
+	}
+}
+
+func TestTransferToSameBranchIsRejected(t *testing.T) {
+	tr := &mockPatientTransferer{}
+	svc := patient.NewServiceWith(patient.ServiceDeps{Transfer: tr})
+
+	err := svc.Transfer(permission.WithSystem(context.Background()), 10, 7, 7)
+	if !errors.Is(err, patient.ErrTransferSameBranch) {
+		t.Errorf("err = %v, want ErrTransferSameBranch", err)
+	}
+	if tr.called {
+		t.Error("repository should not be called")
+	}
+}
+
+func TestTransferKeepsRepositoryErrors(t *testing.T) {
+	svc := patient.NewServiceWith(patient.ServiceDeps{Transfer: &mockPatientTransferer{err: patient.ErrTransferOutsideGroup}})
+
+	err := svc.Transfer(permission.WithSystem(context.Background()), 10, 7, 8)
+	if !errors.Is(err, patient.ErrTransferOutsideGroup) {
+		t.Errorf("err = %v, want ErrTransferOutsideGroup", err)
+	}
+}
@@ -0,0 +1,50 @@
+package pdf
+
+// LabelLayout describes a label sheet or roll: the page size and the grid of
+// labels printed on it. All measures are in millimetres.
+type LabelLayout struct {
+	PageWidth   float64
+	PageHeight  float64
+	Columns     int
+	Rows        int
+	LabelWidth  float64
+	LabelHeight float64
+	MarginTop   float64
+	MarginLeft  float64
+	GapX        float64
+	GapY        float64
+}
+
+var (
+	// LayoutA4x14 is an A4 sheet with 2 columns × 7 rows of 99.1 × 38.1 mm labels.
+	LayoutA4x14 = LabelLayout{
+		PageWidth: 210, PageHeight: 297,
+		Columns: 2, Rows: 7,
+		LabelWidth: 99.1, LabelHeight: 38.1,
+		MarginTop: 15.15, MarginLeft: 4.65,
+		GapX: 2.5,
+	}
+
+	// LayoutRoll62 is a 62 mm continuous roll (Brother DK-22205) cut at 45 mm,
+	// one label per page.
+	LayoutRoll62 = LabelLayout{
+		PageWidth: 62, PageHeight: 45,
+		Columns: 1, Rows: 1,
+		LabelWidth: 62, LabelHeight: 45,
+	}
+)
+
+// PerPage returns how many labels fit on one page.
+func (l LabelLayout) PerPage() int {
+	return l.Columns * l.Rows
+}
+
+// Position returns the top-left corner of the i-th label on a page,
+// filling rows left to right.
+func (l LabelLayout) Position(i int) (x, y float64) {
+	col := i % l.Columns
+	row := i / l.Columns
+	x = l.MarginLeft + float64(col)*(l.LabelWidth+l.GapX)
+	y = l.MarginTop + float64(row)*(l.LabelHeight+l.GapY)
+	return x, y
+}
@@ -0,0 +1,100 @@
+package pdf
+
+// Glyph widths (1/1000 em) for printable ASCII 32-126, from the Adobe
+// Helvetica and Helvetica-Bold AFM files.
+var widths = [2][95]int{
+	{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// winAnsiSpecials maps the non-Latin-1 characters in the 0x80-0x9F range of
+// WinAnsiEncoding that show up in Italian text.
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// baseLetter maps accented Latin-1 letters to the ASCII letter whose width
+// they share in Helvetica.
+var baseLetter = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ä': 'a', 'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ò': 'o', 'ó': 'o', 'ô': 'o', 'ö': 'o',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ç': 'c', 'ñ': 'n',
+	'À': 'A', 'Á': 'A', 'È': 'E', 'É': 'E', 'Ì': 'I', 'Í': 'I', 'Ò': 'O', 'Ó': 'O',
+	'Ù': 'U', 'Ú': 'U', 'Ä': 'A', 'Ö': 'O', 'Ü': 'U', 'Ç': 'C', 'Ñ': 'N', 'ß': 's',
+}
+
+// encodeWinAnsi converts s to WinAnsiEncoding bytes; unsupported characters become '?'.
+func encodeWinAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			out = append(out, byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsiSpecials[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+func glyphWidth(font Font, r rune) int {
+	if b, ok := baseLetter[r]; ok {
+		r = b
+	}
+	switch {
+	case r >= 32 && r <= 126:
+		return widths[font][r-32]
+	case r == '…' || r == '—':
+		return 1000
+	case r == '‘' || r == '’' || r == '‚':
+		return 222
+	default:
+		return 556
+	}
+}
+
+// TextWidth returns the width of s in millimetres at the given size (points).
+func TextWidth(s string, font Font, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		total += glyphWidth(font, r)
+	}
+	return float64(total) * size / 1000 / ptPerMM
+}
+
+// Truncate shortens s with a trailing ellipsis so it fits within maxWidth millimetres.
+func Truncate(s string, font Font, size, maxWidth float64) string {
+	if TextWidth(s, font, size) <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := string(runes) + "…"
+		if TextWidth(candidate, font, size) <= maxWidth {
+			return candidate
+		}
+	}
+	return ""
+}
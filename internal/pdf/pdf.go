@@ -0,0 +1,199 @@
+// Package pdf is a minimal PDF 1.4 writer for printed labels and order lists.
+// It supports the two standard Helvetica fonts, text, filled rectangles and
+// JPEG images — enough for server-side printing without external binaries
+// or font files.
+// All coordinates are millimetres from the top-left corner of the page.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Font selects one of the built-in PDF base fonts.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+const ptPerMM = 72 / 25.4
+
+// Document is an in-memory PDF with pages of a fixed size.
+type Document struct {
+	width, height float64 // page size in points
+	pages         []*bytes.Buffer
+	images        []*Image
+}
+
+// Image is a JPEG that can be drawn on the pages of a document. PDF viewers
+// decode JPEG themselves, so it is embedded unchanged.
+type Image struct {
+	data          []byte
+	width, height int
+	colorSpace    string
+}
+
+// NewJPEG prepares a JPEG for drawing.
+func NewJPEG(data []byte) (*Image, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("reading jpeg: %w", err)
+	}
+	cs := "/DeviceRGB"
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		cs = "/DeviceGray"
+	case color.CMYKModel:
+		cs = "/DeviceCMYK"
+	}
+	return &Image{data: data, width: cfg.Width, height: cfg.Height, colorSpace: cs}, nil
+}
+
+// AspectRatio returns the image's width divided by its height.
+func (i *Image) AspectRatio() float64 {
+	return float64(i.width) / float64(i.height)
+}
+
+// New creates an empty document whose pages measure widthMM × heightMM.
+func New(widthMM, heightMM float64) *Document {
+	return &Document{width: widthMM * ptPerMM, height: heightMM * ptPerMM}
+}
+
+// AddPage starts a new page; subsequent drawing goes to it.
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, new(bytes.Buffer))
+}
+
+// PageCount returns the number of pages added so far.
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) current() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws s with its baseline at (x, y).
+func (d *Document) Text(x, y float64, font Font, size float64, s string) {
+	fmt.Fprintf(d.current(), "BT /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		font+1, num(size), num(x*ptPerMM), num(d.height-y*ptPerMM), escape(encodeWinAnsi(s)))
+}
+
+// Rect fills a black rectangle whose top-left corner is at (x, y).
+func (d *Document) Rect(x, y, w, h float64) {
+	fmt.Fprintf(d.current(), "%s %s %s %s re f\n",
+		num(x*ptPerMM), num(d.height-(y+h)*ptPerMM), num(w*ptPerMM), num(h*ptPerMM))
+}
+
+// Image draws img stretched over the w × h box whose top-left corner is at
+// (x, y). An image drawn many times is embedded once.
+func (d *Document) Image(img *Image, x, y, w, h float64) {
+	i := slices.Index(d.images, img)
+	if i < 0 {
+		d.images = append(d.images, img)
+		i = len(d.images) - 1
+	}
+	fmt.Fprintf(d.current(), "q %s 0 0 %s %s %s cm /Im%d Do Q\n",
+		num(w*ptPerMM), num(h*ptPerMM), num(x*ptPerMM), num(d.height-(y+h)*ptPerMM), i+1)
+}
+
+// Line draws a straight line of the given thickness.
+func (d *Document) Line(x1, y1, x2, y2, thickness float64) {
+	fmt.Fprintf(d.current(), "%s w %s %s m %s %s l S\n",
+		num(thickness*ptPerMM), num(x1*ptPerMM), num(d.height-y1*ptPerMM), num(x2*ptPerMM), num(d.height-y2*ptPerMM))
+}
+
+// WriteTo serialises the document.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Object layout: 1 catalog, 2 page tree, 3-4 fonts, then images, then
+	// page/content pairs.
+	const firstImage = 5
+	firstPage := firstImage + len(d.images)
+	kids := new(bytes.Buffer)
+	for i := range d.pages {
+		fmt.Fprintf(kids, "%d 0 R ", firstPage+2*i)
+	}
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		bytes.TrimSpace(kids.Bytes()), len(d.pages), num(d.width), num(d.height)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	var xobjects []string
+	for i, img := range d.images {
+		obj(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n%s\nendstream",
+			img.width, img.height, img.colorSpace, len(img.data), img.data))
+		xobjects = append(xobjects, fmt.Sprintf("/Im%d %d 0 R", i+1, firstImage+i))
+	}
+	resources := "/Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(xobjects) > 0 {
+		resources += " /XObject << " + strings.Join(xobjects, " ") + " >>"
+	}
+
+	for i, content := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << %s >> /Contents %d 0 R >>", resources, firstPage+2*i+1))
+
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		if _, err := zw.Write(content.Bytes()); err != nil {
+			return 0, fmt.Errorf("compressing page %d: %w", i+1, err)
+		}
+		if err := zw.Close(); err != nil {
+			return 0, fmt.Errorf("compressing page %d: %w", i+1, err)
+		}
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// num formats a coordinate with at most two decimals.
+func num(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+func escape(b []byte) string {
+	var out bytes.Buffer
+	for _, c := range b {
+		if c == '(' || c == ')' || c == '\\' {
+			out.WriteByte('\\')
+		}
+		out.WriteByte(c)
+	}
+	return out.String()
+}
@@ -0,0 +1,136 @@
+package pdf_test
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/pdf"
+)
+
+func TestDocumentWritesValidStructure(t *testing.T) {
+	doc := pdf.New(210, 297)
+	doc.AddPage()
+	doc.Text(10, 20, pdf.Helvetica, 12, "Però (ciao)")
+	doc.Rect(10, 30, 5, 5)
+	doc.AddPage()
+	doc.Text(10, 20, pdf.HelveticaBold, 12, "Pagina 2")
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) {
+		t.Error("missing PDF header")
+	}
+	if !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Error("missing EOF marker")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Error("page tree should count 2 pages")
+	}
+
+	// startxref must point at the xref table.
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	off, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(out[off:], []byte("xref\n")) {
+		t.Errorf("startxref %d does not point at xref table", off)
+	}
+
+	// Every object offset in the xref table must point at "N 0 obj".
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out, -1)
+	if len(entries) != 8 {
+		t.Fatalf("xref entries = %d, want 8 (catalog, pages, 2 fonts, 2×page+content)", len(entries))
+	}
+	for i, e := range entries {
+		o, _ := strconv.Atoi(string(e[1]))
+		want := strconv.Itoa(i+1) + " 0 obj"
+		if !bytes.HasPrefix(out[o:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, out[o:o+len(want)], want)
+		}
+	}
+}
+
+func TestImageIsEmbeddedOnce(t *testing.T) {
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, image.NewRGBA(image.Rect(0, 0, 4, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	img, err := pdf.NewJPEG(jpg.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if img.AspectRatio() != 2 {
+		t.Errorf("AspectRatio = %v, want 2", img.AspectRatio())
+	}
+
+	doc := pdf.New(100, 100)
+	doc.Image(img, 10, 10, 20, 10)
+	doc.AddPage()
+	doc.Image(img, 10, 10, 20, 10)
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.Bytes()
+
+	if n := bytes.Count(out, []byte("/Subtype /Image")); n != 1 {
+		t.Errorf("image objects = %d, want 1", n)
+	}
+	if !bytes.Contains(out, []byte("/XObject << /Im1 5 0 R >>")) {
+		t.Error("pages should reference the image as /Im1")
+	}
+	if _, err := pdf.NewJPEG([]byte("not a jpeg")); err == nil {
+		t.Error("NewJPEG should reject other data")
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	// "Hello" in Helvetica = 722+556+222+222+556 = 2278/1000 em; at 10pt = 22.78pt = 8.036mm.
+	got := pdf.TextWidth("Hello", pdf.Helvetica, 10)
+	if got < 8.03 || got > 8.04 {
+		t.Errorf("TextWidth = %.3f, want ~8.036", got)
+	}
+	if pdf.TextWidth("Hello", pdf.HelveticaBold, 10) <= got {
+		t.Error("bold text should be wider")
+	}
+	if pdf.TextWidth("è", pdf.Helvetica, 10) != pdf.TextWidth("e", pdf.Helvetica, 10) {
+		t.Error("accented letters should share the base letter width")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	s := "Acido acetilsalicilico 100mg compresse gastroresistenti"
+	got := pdf.Truncate(s, pdf.Helvetica, 10, 30)
+	if pdf.TextWidth(got, pdf.Helvetica, 10) > 30 {
+		t.Errorf("truncated text %q exceeds max width", got)
+	}
+	if got == s || got[len(got)-len("…"):] != "…" {
+		t.Errorf("Truncate() = %q, want ellipsis suffix", got)
+	}
+	if short := pdf.Truncate("Ok", pdf.Helvetica, 10, 30); short != "Ok" {
+		t.Errorf("Truncate() = %q, want unchanged", short)
+	}
+}
+
+func TestLabelLayoutsFitPage(t *testing.T) {
+	for name, l := range map[string]pdf.LabelLayout{"a4": pdf.LayoutA4x14, "roll62": pdf.LayoutRoll62} {
+		t.Run(name, func(t *testing.T) {
+			x, y := l.Position(l.PerPage() - 1)
+			if right := x + l.LabelWidth; right > l.PageWidth+0.01 {
+				t.Errorf("last label right edge %.2f exceeds page width %.2f", right, l.PageWidth)
+			}
+			if bottom := y + l.LabelHeight; bottom > l.PageHeight+0.01 {
+				t.Errorf("last label bottom edge %.2f exceeds page height %.2f", bottom, l.PageHeight)
+			}
+		})
+	}
+}
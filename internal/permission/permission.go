@@ -0,0 +1,158 @@
+// Package permission names what a staff member may do and which built-in
+// roles grant it. Middleware guards routes with it, and services check the
+// set the web layer attaches to the request context, so a role is never
+// compared as a string outside this package.
+package permission
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+var (
+	// ErrForbidden is returned by services when the caller lacks a permission.
+	ErrForbidden     = errors.New("permission.forbidden")
+	ErrNotAssignable = errors.New("permission.not_assignable")
+)
+
+// Permission is a named capability, stored as its name in sessions and in
+// custom roles.
+type Permission string
+
+const (
+	ViewPatients      Permission = "patients.view"
+	EditPatients      Permission = "patients.edit"
+	TransferPatients  Permission = "patients.transfer"
+	EditPrescriptions Permission = "prescriptions.edit"
+	RecordRefills     Permission = "prescriptions.refill"
+	ViewOrders        Permission = "orders.view"
+	AdvanceOrders     Permission = "orders.advance"
+	AssignPickups     Permission = "pickups.assign"
+	ViewShipping      Permission = "shipping.view"
+	ManageShipping    Permission = "shipping.manage"
+	DeliverShipments  Permission = "shipping.deliver"
+	ManageSchedule    Permission = "schedule.manage"
+	ViewAnalytics     Permission = "analytics.view"
+	ManagePersonnel   Permission = "personnel.manage"
+	ManageRoles       Permission = "roles.manage"
+	SwitchBranch      Permission = "branches.switch"
+	ManageSettings    Permission = "settings.manage"
+	ManagePharmacies  Permission = "pharmacies.manage"
+)
+
+// displayOrder lists the permissions in the order the owner's role editor
+// shows them. The web layer translates their names.
+var displayOrder = []Permission{
+	ViewPatients, EditPatients, EditPrescriptions, RecordRefills,
+	ViewOrders, AdvanceOrders, AssignPickups,
+	ViewShipping, ManageShipping, DeliverShipments,
+	ManageSchedule, ViewAnalytics, TransferPatients,
+	ManagePersonnel, ManageRoles, SwitchBranch, ManageSettings, ManagePharmacies,
+}
+
+// Assignable lists, in display order, the permissions an owner can put in a
+// custom role. Managing personnel, roles and the pharmacy's settings,
+// switching branches and transferring patients stay with owners, so a custom
+// role cannot grant itself more.
+func Assignable() []Permission {
+	var list []Permission
+	for _, p := range displayOrder {
+		if assignable(p) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+func assignable(p Permission) bool {
+	switch p {
+	case TransferPatients, ManagePersonnel, ManageRoles, SwitchBranch, ManageSettings, ManagePharmacies:
+		return false
+	}
+	return slices.Contains(displayOrder, p)
+}
+
+// Set is the permissions a user holds.
+type Set []Permission
+
+// Has reports whether the set grants p.
+func (s Set) Has(p Permission) bool {
+	return slices.Contains(s, p)
+}
+
+// Strings returns the permission names, as stored in sessions and custom
+// roles.
+func (s Set) Strings() []string {
+	names := make([]string, len(s))
+	for i, p := range s {
+		names[i] = string(p)
+	}
+	return names
+}
+
+// FromStrings rebuilds a set from stored names.
+func FromStrings(names []string) Set {
+	s := make(Set, len(names))
+	for i, n := range names {
+		s[i] = Permission(n)
+	}
+	return s
+}
+
+// ParseAssignable builds a custom role's set from submitted names. Each must
+// be assignable; duplicates are dropped and the set follows display order.
+func ParseAssignable(names []string) (Set, error) {
+	var s Set
+	for _, p := range Assignable() {
+		if slices.Contains(names, string(p)) {
+			s = append(s, p)
+		}
+	}
+	for _, n := range names {
+		if !s.Has(Permission(n)) {
+			return nil, ErrNotAssignable
+		}
+	}
+	return s, nil
+}
+
+type (
+	contextKey struct{}
+	systemKey  struct{}
+)
+
+// NewContext returns a copy of ctx carrying the caller's permissions.
+func NewContext(ctx context.Context, s Set) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the caller's permissions, if ctx carries them.
+func FromContext(ctx context.Context) (Set, bool) {
+	s, ok := ctx.Value(contextKey{}).(Set)
+	return s, ok
+}
+
+// WithSystem returns a copy of ctx on behalf of the system itself: the
+// command-line tool, background jobs, or a flow such as the patient portal
+// that authorized its caller some other way. Check lets it through unless
+// ctx also carries a staff caller, whose permissions still apply.
+func WithSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemKey{}, true)
+}
+
+// Check returns ErrForbidden unless ctx carries a caller holding p or comes
+// from WithSystem. A context with neither is refused, so a path that forgot
+// to say who is acting fails closed.
+func Check(ctx context.Context, p Permission) error {
+	if s, ok := FromContext(ctx); ok {
+		if !s.Has(p) {
+			return ErrForbidden
+		}
+		return nil
+	}
+	if system, _ := ctx.Value(systemKey{}).(bool); system {
+		return nil
+	}
+	return ErrForbidden
+}
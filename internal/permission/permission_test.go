@@ -0,0 +1,78 @@
+package permission
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestForRole(t *testing.T) {
+	tests := []struct {
+		role string
+		has  []Permission
+		lack []Permission
+	}{
+		{RoleOwner, []Permission{ManagePersonnel, ManageRoles, TransferPatients, AdvanceOrders}, []Permission{ManagePharmacies}},
+		{RolePharmacist, []Permission{ManageSchedule, ViewAnalytics, RecordRefills}, []Permission{ManagePersonnel, ManageRoles}},
+		{RolePersonnel, []Permission{AdvanceOrders, RecordRefills, ManageShipping}, []Permission{ManageSchedule, ViewAnalytics}},
+		{RoleTrainee, []Permission{ViewPatients, ViewOrders}, []Permission{AdvanceOrders, RecordRefills, EditPatients, AssignPickups}},
+		{RoleDriver, []Permission{ViewShipping, DeliverShipments}, []Permission{ViewOrders, ViewPatients, ManageShipping}},
+		{RoleAdmin, []Permission{ManagePharmacies}, []Permission{ViewPatients}},
+		{"unknown", nil, []Permission{ViewPatients}},
+	}
+	for _, tt := range tests {
+		s := ForRole(tt.role, Set{ViewPatients})
+		for _, p := range tt.has {
+			if !s.Has(p) {
+				t.Errorf("%s should have %s", tt.role, p)
+			}
+		}
+		for _, p := range tt.lack {
+			if s.Has(p) {
+				t.Errorf("%s should not have %s", tt.role, p)
+			}
+		}
+	}
+}
+
+func TestForRoleCustomUsesRolePermissions(t *testing.T) {
+	s := ForRole(RoleCustom, Set{ViewOrders})
+	if !s.Has(ViewOrders) || s.Has(ViewPatients) {
+		t.Errorf("custom set = %v, want [orders.view]", s)
+	}
+}
+
+func TestParseAssignable(t *testing.T) {
+	s, err := ParseAssignable([]string{"orders.view", "patients.view", "orders.view"})
+	if err != nil {
+		t.Fatalf("ParseAssignable: %v", err)
+	}
+	if len(s) != 2 || s[0] != ViewPatients || s[1] != ViewOrders {
+		t.Errorf("set = %v, want [patients.view orders.view]", s)
+	}
+
+	for _, name := range []string{"personnel.manage", "roles.manage", "pharmacies.manage", "made.up"} {
+		if _, err := ParseAssignable([]string{name}); !errors.Is(err, ErrNotAssignable) {
+			t.Errorf("%s: error = %v, want ErrNotAssignable", name, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check(context.Background(), AdvanceOrders); !errors.Is(err, ErrForbidden) {
+		t.Errorf("no caller: error = %v, want ErrForbidden", err)
+	}
+	if err := Check(WithSystem(context.Background()), AdvanceOrders); err != nil {
+		t.Errorf("system caller: error = %v, want nil", err)
+	}
+	if err := Check(WithSystem(NewContext(context.Background(), ForRole(RoleTrainee, nil))), AdvanceOrders); !errors.Is(err, ErrForbidden) {
+		t.Errorf("trainee under system: error = %v, want ErrForbidden", err)
+	}
+	trainee := NewContext(context.Background(), ForRole(RoleTrainee, nil))
+	if err := Check(trainee, AdvanceOrders); !errors.Is(err, ErrForbidden) {
+		t.Errorf("trainee: error = %v, want ErrForbidden", err)
+	}
+	if err := Check(trainee, ViewOrders); err != nil {
+		t.Errorf("trainee viewing orders: error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,52 @@
+package permission
+
+// Built-in roles, as stored in users.role. RoleCustom means the user holds
+// one of the pharmacy's custom roles (users.custom_role_id), whose
+// permissions the owner chose.
+const (
+	RoleAdmin      = "admin"
+	RoleOwner      = "owner"
+	RolePharmacist = "pharmacist"
+	RolePersonnel  = "personnel"
+	RoleTrainee    = "trainee"
+	RoleDriver     = "driver"
+	RoleCustom     = "custom"
+)
+
+var (
+	personnel = Set{
+		ViewPatients, EditPatients, EditPrescriptions, RecordRefills,
+		ViewOrders, AdvanceOrders, AssignPickups,
+		ViewShipping, ManageShipping, DeliverShipments,
+	}
+	pharmacist = append(append(Set{}, personnel...), ManageSchedule, ViewAnalytics)
+	owner      = append(append(Set{}, pharmacist...), TransferPatients, ManagePersonnel, ManageRoles, SwitchBranch, ManageSettings)
+	builtin    = map[string]Set{
+		RoleAdmin:      {ManagePharmacies},
+		RoleOwner:      owner,
+		RolePharmacist: pharmacist,
+		RolePersonnel:  personnel,
+		RoleTrainee:    {ViewPatients, ViewOrders, ViewShipping},
+		RoleDriver:     {ViewShipping, DeliverShipments},
+	}
+)
+
+// PharmacyRoles lists the built-in roles a pharmacy member can hold, from
+// most to least powerful.
+var PharmacyRoles = []string{RoleOwner, RolePharmacist, RolePersonnel, RoleTrainee, RoleDriver}
+
+// ForRole returns the permissions of a role. custom holds the permissions of
+// the user's custom role and only counts for RoleCustom; an unknown role
+// grants nothing.
+func ForRole(role string, custom Set) Set {
+	if role == RoleCustom {
+		return custom
+	}
+	return builtin[role]
+}
+
+// PharmacyRole reports whether role is a built-in role for pharmacy members.
+func PharmacyRole(role string) bool {
+	_, ok := builtin[role]
+	return ok && role != RoleAdmin
+}
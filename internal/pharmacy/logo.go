@@ -0,0 +1,65 @@
+package pharmacy
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // logos may be uploaded as PNG
+)
+
+const (
+	// MaxLogoBytes is the largest logo upload accepted.
+	MaxLogoBytes = 2 << 20
+	// logoMaxSide is the longest side of a stored logo, in pixels: plenty
+	// for the couple of centimetres it takes on a label.
+	logoMaxSide = 400
+	// logoMaxPixels refuses images that would take too much memory to
+	// decode, whatever their file size.
+	logoMaxPixels = 40_000_000
+)
+
+// normalizeLogo decodes an uploaded PNG or JPEG and returns it as a JPEG on
+// a white background, scaled down to logoMaxSide, which PDF labels embed
+// as is.
+func normalizeLogo(data []byte) ([]byte, error) {
+	if len(data) > MaxLogoBytes {
+		return nil, ErrLogoTooLarge
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		return nil, ErrInvalidLogo
+	}
+	if cfg.Width*cfg.Height > logoMaxPixels {
+		return nil, ErrLogoTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidLogo
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if longest := max(w, h); longest > logoMaxSide {
+		w = max(1, w*logoMaxSide/longest)
+		h = max(1, h*logoMaxSide/longest)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	// Nearest-neighbour sampling, blended over white so transparent PNGs
+	// do not turn black.
+	for y := range h {
+		for x := range w {
+			sx := b.Min.X + x*b.Dx()/w
+			sy := b.Min.Y + y*b.Dy()/h
+			draw.Draw(dst, image.Rect(x, y, x+1, y+1), src, image.Pt(sx, sy), draw.Over)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
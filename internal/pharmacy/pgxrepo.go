@@ -49,7 +49,7 @@ func (r *PgxRepository) CreateWithOwner(ctx context.Context, p CreateParams, own
 		Email:        p.OwnerEmail,
 		PasswordHash: ownerPasswordHash,
 		Name:         p.OwnerName,
-		Role:         "owner",
+		Role:         RoleOwner,
 		PharmacyID:   pgtype.Int8{Int64: row.ID, Valid: true},
 	}); err != nil {
 		return Pharmacy{}, mapDuplicateEmail(err)
@@ -60,11 +60,12 @@ func (r *PgxRepository) CreateWithOwner(ctx context.Context, p CreateParams, own
 	}
 
 	return Pharmacy{
-		ID:      row.ID,
-		Name:    row.Name,
-		Address: row.Address,
-		Phone:   row.Phone,
-		Email:   row.Email,
+		ID:          row.ID,
+		Name:        row.Name,
+		Address:     row.Address,
+		Phone:       row.Phone,
+		Email:       row.Email,
+		LabelLayout: row.LabelLayout,
 	}, nil
 }
 
@@ -77,26 +78,50 @@ func (r *PgxRepository) GetByID(ctx context.Context, id int64) (Pharmacy, error)
 		return Pharmacy{}, fmt.Errorf("querying pharmacy by id: %w", err)
 	}
 	return Pharmacy{
-		ID:      row.ID,
-		Name:    row.Name,
-		Address: row.Address,
-		Phone:   row.Phone,
-		Email:   row.Email,
+		ID:               row.ID,
+		Name:             row.Name,
+		Address:          row.Address,
+		Phone:            row.Phone,
+		Email:            row.Email,
+		LabelLayout:      row.LabelLayout,
+		GroupID:          row.GroupID.Int64,
+		GroupName:        row.GroupName,
+		RequireTwoFactor: row.Require2fa,
+		Locale:           row.Locale,
+		HasLogo:          row.HasLogo,
 	}, nil
 }
 
 func (r *PgxRepository) List(ctx context.Context) ([]Summary, error) {
-	rows, err := r.queries.ListPharmacies(ctx)
+	return r.listSummaries(ctx, pgtype.Int8{})
+}
+
+func (r *PgxRepository) ListBranchSummaries(ctx context.Context, ownerID int64) ([]Summary, error) {
+	return r.listSummaries(ctx, pgtype.Int8{Int64: ownerID, Valid: true})
+}
+
+func (r *PgxRepository) listSummaries(ctx context.Context, ownerID pgtype.Int8) ([]Summary, error) {
+	rows, err := r.queries.ListPharmacies(ctx, ownerID)
 	if err != nil {
 		return nil, fmt.Errorf("listing pharmacies: %w", err)
 	}
 	summaries := make([]Summary, len(rows))
 	for i, row := range rows {
 		summaries[i] = Summary{
-			ID:             row.ID,
-			Name:           row.Name,
-			Address:        row.Address,
-			PersonnelCount: row.PersonnelCount,
+			ID:                      row.ID,
+			Name:                    row.Name,
+			Address:                 row.Address,
+			GroupName:               row.GroupName,
+			CreatedAt:               row.CreatedAt.Time,
+			PersonnelCount:          row.PersonnelCount,
+			ActivePatientCount:      row.ActivePatientCount,
+			PrescriptionCount:       row.PrescriptionCount,
+			OverdueOrderCount:       row.OverdueOrderCount,
+			UnreadNotificationCount: row.UnreadNotificationCount,
+		}
+		if row.LastLoginAt.Valid {
+			t := row.LastLoginAt.Time
+			summaries[i].LastLoginAt = &t
 		}
 	}
 	return summaries, nil
@@ -109,19 +134,51 @@ func (r *PgxRepository) Update(ctx context.Context, p UpdateParams) error {
 	}
 	defer tx.Rollback(ctx)
 
-	if err := r.queries.WithTx(tx).UpdatePharmacy(ctx, db.UpdatePharmacyParams{
-		ID:      p.ID,
-		Name:    p.Name,
-		Address: p.Address,
-		Phone:   p.Phone,
-		Email:   p.Email,
+	qtx := r.queries.WithTx(tx)
+
+	if err := qtx.UpdatePharmacy(ctx, db.UpdatePharmacyParams{
+		ID:          p.ID,
+		Name:        p.Name,
+		Address:     p.Address,
+		Phone:       p.Phone,
+		Email:       p.Email,
+		LabelLayout: p.LabelLayout,
+		Require2fa:  p.RequireTwoFactor,
+		Locale:      p.Locale,
 	}); err != nil {
 		return fmt.Errorf("updating pharmacy: %w", err)
 	}
 
+	var groupID pgtype.Int8
+	if p.GroupName != "" {
+		id, err := qtx.UpsertPharmacyGroup(ctx, p.GroupName)
+		if err != nil {
+			return fmt.Errorf("upserting pharmacy group: %w", err)
+		}
+		groupID = pgtype.Int8{Int64: id, Valid: true}
+	}
+	if err := qtx.SetPharmacyGroup(ctx, db.SetPharmacyGroupParams{ID: p.ID, GroupID: groupID}); err != nil {
+		return fmt.Errorf("setting pharmacy group: %w", err)
+	}
+	if err := qtx.DeleteEmptyPharmacyGroups(ctx); err != nil {
+		return fmt.Errorf("deleting empty pharmacy groups: %w", err)
+	}
+
 	return tx.Commit(ctx)
 }
 
+func (r *PgxRepository) ListBranches(ctx context.Context, userID int64) ([]Branch, error) {
+	rows, err := r.queries.ListUserBranches(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing user branches: %w", err)
+	}
+	branches := make([]Branch, len(rows))
+	for i, row := range rows {
+		branches[i] = Branch{ID: row.ID, Name: row.Name}
+	}
+	return branches, nil
+}
+
 func (r *PgxRepository) ListPersonnel(ctx context.Context, pharmacyID int64) ([]PersonnelMember, error) {
 	rows, err := r.queries.ListUsersByPharmacy(ctx, pharmacyID)
 	if err != nil {
@@ -130,10 +187,16 @@ func (r *PgxRepository) ListPersonnel(ctx context.Context, pharmacyID int64) ([]
 	members := make([]PersonnelMember, len(rows))
 	for i, row := range rows {
 		members[i] = PersonnelMember{
-			ID:    row.ID,
-			Name:  row.Name,
-			Email: row.Email,
-			Role:  row.Role,
+			ID:                 row.ID,
+			Name:               row.Name,
+			Email:              row.Email,
+			Role:               row.Role,
+			CustomRoleID:       row.CustomRoleID.Int64,
+			CustomRoleName:     row.CustomRoleName,
+			Active:             row.Active,
+			MustChangePassword: row.MustChangePassword,
+			TwoFactorEnabled:   row.TwoFactorEnabled,
+			LockedUntil:        row.LockedUntil.Time,
 		}
 	}
 	return members, nil
@@ -146,12 +209,18 @@ func (r *PgxRepository) CreatePersonnel(ctx context.Context, p CreatePersonnelPa
 	}
 	defer tx.Rollback(ctx)
 
-	row, err := r.queries.WithTx(tx).CreateUser(ctx, db.CreateUserParams{
+	qtx := r.queries.WithTx(tx)
+	roleName, err := customRoleName(ctx, qtx, p.PharmacyID, p.CustomRoleID)
+	if err != nil {
+		return PersonnelMember{}, err
+	}
+	row, err := qtx.CreateUser(ctx, db.CreateUserParams{
 		Email:        p.Email,
 		PasswordHash: passwordHash,
 		Name:         p.Name,
 		Role:         p.Role,
 		PharmacyID:   pgtype.Int8{Int64: p.PharmacyID, Valid: true},
+		CustomRoleID: nullableRoleID(p.CustomRoleID),
 	})
 	if err != nil {
 		return PersonnelMember{}, mapDuplicateEmail(err)
@@ -162,13 +231,192 @@ func (r *PgxRepository) CreatePersonnel(ctx context.Context, p CreatePersonnelPa
 	}
 
 	return PersonnelMember{
-		ID:    row.ID,
-		Name:  row.Name,
-		Email: row.Email,
-		Role:  row.Role,
+		ID:             row.ID,
+		Name:           row.Name,
+		Email:          row.Email,
+		Role:           row.Role,
+		CustomRoleID:   p.CustomRoleID,
+		CustomRoleName: roleName,
+		Active:         true,
+	}, nil
+}
+
+func (r *PgxRepository) GetPersonnel(ctx context.Context, pharmacyID, userID int64) (PersonnelMember, error) {
+	row, err := r.queries.GetPharmacyUser(ctx, db.GetPharmacyUserParams{ID: userID, PharmacyID: pharmacyID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PersonnelMember{}, ErrPersonnelNotFound
+		}
+		return PersonnelMember{}, fmt.Errorf("querying personnel member: %w", err)
+	}
+	return PersonnelMember{
+		ID:                 row.ID,
+		Name:               row.Name,
+		Email:              row.Email,
+		Role:               row.Role,
+		CustomRoleID:       row.CustomRoleID.Int64,
+		CustomRoleName:     row.CustomRoleName,
+		Active:             row.Active,
+		MustChangePassword: row.MustChangePassword,
+		TwoFactorEnabled:   row.TwoFactorEnabled,
+		LockedUntil:        row.LockedUntil.Time,
+		Federated:          row.Federated,
 	}, nil
 }
 
+func (r *PgxRepository) SetPersonnelActive(ctx context.Context, pharmacyID, userID int64, active bool) error {
+	return r.changePersonnel(ctx, pharmacyID, userID, func(qtx *db.Queries, target db.LockPharmacyUserRow, owners []int64) error {
+		if !active && target.Active && isLastOwner(owners, userID) {
+			return ErrLastOwner
+		}
+		if err := qtx.SetUserActive(ctx, db.SetUserActiveParams{ID: userID, Active: active}); err != nil {
+			return fmt.Errorf("setting user active: %w", err)
+		}
+		if !active {
+			return revokeSessions(ctx, qtx, userID)
+		}
+		return nil
+	})
+}
+
+func (r *PgxRepository) SetPersonnelRole(ctx context.Context, pharmacyID, userID int64, role string, customRoleID int64) error {
+	return r.changePersonnel(ctx, pharmacyID, userID, func(qtx *db.Queries, target db.LockPharmacyUserRow, owners []int64) error {
+		if target.Role == role && target.CustomRoleID.Int64 == customRoleID {
+			return nil
+		}
+		if role != RoleOwner && isLastOwner(owners, userID) {
+			return ErrLastOwner
+		}
+		if _, err := customRoleName(ctx, qtx, pharmacyID, customRoleID); err != nil {
+			return err
+		}
+		if err := qtx.SetUserRole(ctx, db.SetUserRoleParams{ID: userID, Role: role, CustomRoleID: nullableRoleID(customRoleID)}); err != nil {
+			return fmt.Errorf("setting user role: %w", err)
+		}
+		return revokeSessions(ctx, qtx, userID)
+	})
+}
+
+func (r *PgxRepository) ResetPersonnelPassword(ctx context.Context, pharmacyID, userID int64, passwordHash string) error {
+	return r.changePersonnel(ctx, pharmacyID, userID, func(qtx *db.Queries, target db.LockPharmacyUserRow, _ []int64) error {
+		if target.Federated {
+			return ErrFederatedMember
+		}
+		if err := qtx.ResetUserPassword(ctx, db.ResetUserPasswordParams{ID: userID, PasswordHash: passwordHash}); err != nil {
+			return fmt.Errorf("resetting user password: %w", err)
+		}
+		return revokeSessions(ctx, qtx, userID)
+	})
+}
+
+func (r *PgxRepository) UnlockPersonnel(ctx context.Context, pharmacyID, userID int64) error {
+	return r.changePersonnel(ctx, pharmacyID, userID, func(qtx *db.Queries, _ db.LockPharmacyUserRow, _ []int64) error {
+		if err := qtx.UnlockUser(ctx, userID); err != nil {
+			return fmt.Errorf("unlocking user: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *PgxRepository) RevokePharmacySessions(ctx context.Context, pharmacyID int64) (int, error) {
+	n, err := r.queries.DeletePharmacySessions(ctx, pharmacyID)
+	if err != nil {
+		return 0, fmt.Errorf("deleting pharmacy sessions: %w", err)
+	}
+	return int(n), nil
+}
+
+func (r *PgxRepository) ResetPersonnelTwoFactor(ctx context.Context, pharmacyID, userID int64) error {
+	return r.changePersonnel(ctx, pharmacyID, userID, func(qtx *db.Queries, _ db.LockPharmacyUserRow, _ []int64) error {
+		if err := qtx.DisableUserTOTP(ctx, userID); err != nil {
+			return fmt.Errorf("disabling two-factor auth: %w", err)
+		}
+		if err := qtx.DeleteUserRecoveryCodes(ctx, userID); err != nil {
+			return fmt.Errorf("deleting recovery codes: %w", err)
+		}
+		return revokeSessions(ctx, qtx, userID)
+	})
+}
+
+func (r *PgxRepository) RemovePersonnel(ctx context.Context, pharmacyID, userID int64) error {
+	return r.changePersonnel(ctx, pharmacyID, userID, func(qtx *db.Queries, _ db.LockPharmacyUserRow, owners []int64) error {
+		if isLastOwner(owners, userID) {
+			return ErrLastOwner
+		}
+		// Sessions first: user_sessions cascades on the user, the scs rows do not.
+		if err := revokeSessions(ctx, qtx, userID); err != nil {
+			return err
+		}
+		if err := qtx.DeleteUser(ctx, userID); err != nil {
+			return fmt.Errorf("deleting user: %w", err)
+		}
+		return nil
+	})
+}
+
+// changePersonnel runs fn in a transaction holding locks on the pharmacy's
+// active owners and on the target member, so the last-owner rule holds under
+// concurrent changes.
+func (r *PgxRepository) changePersonnel(ctx context.Context, pharmacyID, userID int64, fn func(qtx *db.Queries, target db.LockPharmacyUserRow, owners []int64) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+
+	owners, err := qtx.LockActivePharmacyOwners(ctx, pharmacyID)
+	if err != nil {
+		return fmt.Errorf("locking pharmacy owners: %w", err)
+	}
+	target, err := qtx.LockPharmacyUser(ctx, db.LockPharmacyUserParams{ID: userID, PharmacyID: pharmacyID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPersonnelNotFound
+		}
+		return fmt.Errorf("locking personnel member: %w", err)
+	}
+
+	if err := fn(qtx, target, owners); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// customRoleName checks that a custom role belongs to the pharmacy and
+// returns its name; a zero ID is no custom role.
+func customRoleName(ctx context.Context, qtx *db.Queries, pharmacyID, id int64) (string, error) {
+	if id == 0 {
+		return "", nil
+	}
+	role, err := qtx.GetPharmacyRole(ctx, db.GetPharmacyRoleParams{ID: id, PharmacyID: pharmacyID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrInvalidRole
+		}
+		return "", fmt.Errorf("querying custom role: %w", err)
+	}
+	return role.Name, nil
+}
+
+func nullableRoleID(id int64) pgtype.Int8 {
+	return pgtype.Int8{Int64: id, Valid: id != 0}
+}
+
+// isLastOwner reports whether userID is the only active owner left.
+func isLastOwner(owners []int64, userID int64) bool {
+	return len(owners) == 1 && owners[0] == userID
+}
+
+func revokeSessions(ctx context.Context, qtx *db.Queries, userID int64) error {
+	if err := qtx.DeleteUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("revoking user sessions: %w", err)
+	}
+	return nil
+}
+
 func mapDuplicateEmail(err error) error {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -176,3 +424,58 @@ func mapDuplicateEmail(err error) error {
 	}
 	return err
 }
+
+func (r *PgxRepository) GetSettings(ctx context.Context, pharmacyID int64) (Settings, error) {
+	row, err := r.queries.GetPharmacySettings(ctx, pharmacyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Settings{}, ErrNotFound
+		}
+		return Settings{}, fmt.Errorf("querying pharmacy settings: %w", err)
+	}
+	return Settings{
+		PharmacyID:         row.ID,
+		Address:            row.Address,
+		Phone:              row.Phone,
+		Email:              row.Email,
+		VATNumber:          row.VatNumber,
+		DefaultFulfillment: row.DefaultFulfillment,
+		LookaheadDays:      int(row.LookaheadDays),
+		PickupReminder:     row.PickupReminder,
+		HasLogo:            row.HasLogo,
+	}, nil
+}
+
+func (r *PgxRepository) UpdateSettings(ctx context.Context, s Settings) error {
+	if err := r.queries.UpdatePharmacySettings(ctx, db.UpdatePharmacySettingsParams{
+		ID:                 s.PharmacyID,
+		Address:            s.Address,
+		Phone:              s.Phone,
+		Email:              s.Email,
+		VatNumber:          s.VATNumber,
+		DefaultFulfillment: s.DefaultFulfillment,
+		LookaheadDays:      int32(s.LookaheadDays),
+		PickupReminder:     s.PickupReminder,
+	}); err != nil {
+		return fmt.Errorf("updating pharmacy settings: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) GetLogo(ctx context.Context, pharmacyID int64) ([]byte, error) {
+	logo, err := r.queries.GetPharmacyLogo(ctx, pharmacyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("querying pharmacy logo: %w", err)
+	}
+	return logo, nil
+}
+
+func (r *PgxRepository) SetLogo(ctx context.Context, pharmacyID int64, logo []byte) error {
+	if err := r.queries.SetPharmacyLogo(ctx, db.SetPharmacyLogoParams{ID: pharmacyID, Logo: logo}); err != nil {
+		return fmt.Errorf("setting pharmacy logo: %w", err)
+	}
+	return nil
+}
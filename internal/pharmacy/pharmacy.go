@@ -1,35 +1,154 @@
 package pharmacy
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+)
 
 var (
-	ErrNotFound       = errors.New("pharmacy not found")
-	ErrDuplicateEmail = errors.New("email already in use")
+	ErrNotFound           = errors.New("pharmacy.not_found")
+	ErrDuplicateEmail     = errors.New("pharmacy.duplicate_email")
+	ErrInvalidLabelLayout = errors.New("pharmacy.invalid_label_layout")
+	ErrNotBranch          = errors.New("pharmacy.not_branch")
+	ErrPersonnelNotFound  = errors.New("pharmacy.personnel_not_found")
+	ErrLastOwner          = errors.New("pharmacy.last_owner")
+	ErrSelfChange         = errors.New("pharmacy.self_change")
+	ErrInvalidRole        = errors.New("pharmacy.invalid_role")
+	ErrInvalidLocale      = errors.New("pharmacy.invalid_locale")
+	ErrFederatedMember    = errors.New("pharmacy.federated_member")
+)
+
+// Personnel roles within a pharmacy; permission.PharmacyRoles lists them all.
+const (
+	RoleOwner     = permission.RoleOwner
+	RolePersonnel = permission.RolePersonnel
 )
 
+// validRole reports whether a member can be given role: a built-in pharmacy
+// role, or RoleCustom with the ID of one of the pharmacy's custom roles.
+func validRole(role string, customRoleID int64) bool {
+	if role == permission.RoleCustom {
+		return customRoleID != 0
+	}
+	return permission.PharmacyRole(role) && customRoleID == 0
+}
+
+// Label layout constants — the label sheet or roll used for PDF printing.
+const (
+	LabelLayoutA4x14  = "a4-2x7"
+	LabelLayoutRoll62 = "roll-62mm"
+)
+
+// ValidLabelLayout reports whether layout is a supported label layout.
+func ValidLabelLayout(layout string) bool {
+	return layout == LabelLayoutA4x14 || layout == LabelLayoutRoll62
+}
+
 // Pharmacy is the domain representation of a pharmacy.
 type Pharmacy struct {
-	ID      int64
-	Name    string
-	Address string
-	Phone   string
-	Email   string
+	ID          int64
+	Name        string
+	Address     string
+	Phone       string
+	Email       string
+	LabelLayout string
+	GroupID     int64 // 0 when the pharmacy is not part of a group
+	GroupName   string
+	// RequireTwoFactor makes TOTP 2FA mandatory for all the pharmacy's staff.
+	RequireTwoFactor bool
+	// Locale is the interface language of staff who did not choose one.
+	Locale string
+	// HasLogo reports whether the owner uploaded a logo for labels.
+	HasLogo bool
 }
 
-// Summary is a pharmacy list item with personnel count.
+// Branch is a pharmacy an owner can switch to: their own or another branch
+// of the same group.
+type Branch struct {
+	ID   int64
+	Name string
+}
+
+// InactiveAfter is how long a pharmacy can go without a staff login before
+// the admin overview flags it as no longer using the system.
+const InactiveAfter = 30 * 24 * time.Hour
+
+// Summary is a pharmacy list item with usage aggregates for the admin
+// overview. It carries counts only, never patient data.
 type Summary struct {
-	ID             int64
-	Name           string
-	Address        string
-	PersonnelCount int64
+	ID                      int64
+	Name                    string
+	Address                 string
+	GroupName               string
+	CreatedAt               time.Time
+	PersonnelCount          int64
+	ActivePatientCount      int64 // patients with at least one prescription
+	PrescriptionCount       int64
+	OverdueOrderCount       int64 // not fulfilled, depletion date in the past
+	UnreadNotificationCount int64
+	LastLoginAt             *time.Time // latest staff login, nil if nobody ever logged in
+}
+
+// Inactive reports whether no staff member has logged in for InactiveAfter.
+// A pharmacy nobody ever logged into counts from its creation, so a new one
+// is not flagged on day one.
+func (s Summary) Inactive(now time.Time) bool {
+	last := s.CreatedAt
+	if s.LastLoginAt != nil {
+		last = *s.LastLoginAt
+	}
+	return now.Sub(last) > InactiveAfter
+}
+
+// Totals is the platform-wide sum of the pharmacy summaries.
+type Totals struct {
+	Pharmacies          int
+	Inactive            int
+	ActivePatients      int64
+	Prescriptions       int64
+	OverdueOrders       int64
+	UnreadNotifications int64
+}
+
+// SumSummaries adds up the summaries and counts the inactive pharmacies.
+func SumSummaries(list []Summary, now time.Time) Totals {
+	t := Totals{Pharmacies: len(list)}
+	for _, s := range list {
+		if s.Inactive(now) {
+			t.Inactive++
+		}
+		t.ActivePatients += s.ActivePatientCount
+		t.Prescriptions += s.PrescriptionCount
+		t.OverdueOrders += s.OverdueOrderCount
+		t.UnreadNotifications += s.UnreadNotificationCount
+	}
+	return t
 }
 
-// PersonnelMember is a user belonging to a pharmacy.
+// PersonnelMember is a user belonging to a pharmacy. CustomRoleID and
+// CustomRoleName are set when Role is permission.RoleCustom.
 type PersonnelMember struct {
-	ID    int64
-	Name  string
-	Email string
-	Role  string
+	ID                 int64
+	Name               string
+	Email              string
+	Role               string
+	CustomRoleID       int64
+	CustomRoleName     string
+	Active             bool
+	MustChangePassword bool
+	TwoFactorEnabled   bool
+	// LockedUntil is set after too many failed logins; zero when unlocked.
+	LockedUntil time.Time
+	// Federated members sign in through the pharmacy's identity provider
+	// and cannot be given a password.
+	Federated bool
+}
+
+// Locked reports whether failed logins keep the member locked out at now.
+func (m PersonnelMember) Locked(now time.Time) bool {
+	return now.Before(m.LockedUntil)
 }
 
 // CreateParams holds the data needed to create a pharmacy with its owner.
@@ -45,18 +164,24 @@ type CreateParams struct {
 
 // UpdateParams holds the data needed to update a pharmacy.
 type UpdateParams struct {
-	ID      int64
-	Name    string
-	Address string
-	Phone   string
-	Email   string
+	ID               int64
+	Name             string
+	Address          string
+	Phone            string
+	Email            string
+	LabelLayout      string
+	GroupName        string // empty removes the pharmacy from its group
+	RequireTwoFactor bool
+	Locale           string
 }
 
 // CreatePersonnelParams holds the data needed to create a personnel member.
+// CustomRoleID is set when Role is permission.RoleCustom.
 type CreatePersonnelParams struct {
-	PharmacyID int64
-	Name       string
-	Email      string
-	Password   string
-	Role       string
+	PharmacyID   int64
+	Name         string
+	Email        string
+	Password     string
+	Role         string
+	CustomRoleID int64
 }
@@ -0,0 +1,48 @@
+package pharmacy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+)
+
+func TestSummaryInactive(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -3)
+	old := now.AddDate(0, 0, -31)
+
+	tests := []struct {
+		name string
+		s    pharmacy.Summary
+		want bool
+	}{
+		{"recent login", pharmacy.Summary{CreatedAt: old, LastLoginAt: &recent}, false},
+		{"old login", pharmacy.Summary{CreatedAt: old, LastLoginAt: &old}, true},
+		{"never logged in, new pharmacy", pharmacy.Summary{CreatedAt: recent}, false},
+		{"never logged in, old pharmacy", pharmacy.Summary{CreatedAt: old}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Inactive(now); got != tt.want {
+				t.Errorf("Inactive = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSumSummaries(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -1)
+	old := now.AddDate(-1, 0, 0)
+
+	got := pharmacy.SumSummaries([]pharmacy.Summary{
+		{CreatedAt: old, LastLoginAt: &recent, ActivePatientCount: 10, PrescriptionCount: 25, OverdueOrderCount: 2, UnreadNotificationCount: 4},
+		{CreatedAt: old, ActivePatientCount: 3, PrescriptionCount: 5, OverdueOrderCount: 1, UnreadNotificationCount: 9},
+	}, now)
+
+	want := pharmacy.Totals{Pharmacies: 2, Inactive: 1, ActivePatients: 13, Prescriptions: 30, OverdueOrders: 3, UnreadNotifications: 13}
+	if got != want {
+		t.Errorf("SumSummaries = %+v, want %+v", got, want)
+	}
+}
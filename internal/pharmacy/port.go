@@ -12,11 +12,21 @@ type PharmacyGetter interface {
 	GetByID(ctx context.Context, id int64) (Pharmacy, error)
 }
 
-// PharmacyLister lists all pharmacies with personnel counts.
+// PharmacyLister lists all pharmacies with their usage aggregates.
 type PharmacyLister interface {
 	List(ctx context.Context) ([]Summary, error)
 }
 
+// BranchSummaryLister lists the aggregates of the branches an owner can reach.
+type BranchSummaryLister interface {
+	ListBranchSummaries(ctx context.Context, ownerID int64) ([]Summary, error)
+}
+
+// BranchLister lists the pharmacies an owner can switch to.
+type BranchLister interface {
+	ListBranches(ctx context.Context, userID int64) ([]Branch, error)
+}
+
 // PharmacyUpdater updates a pharmacy in a transaction.
 type PharmacyUpdater interface {
 	Update(ctx context.Context, p UpdateParams) error
@@ -32,12 +42,88 @@ type PersonnelCreator interface {
 	CreatePersonnel(ctx context.Context, p CreatePersonnelParams, passwordHash string) (PersonnelMember, error)
 }
 
+// PersonnelGetter fetches one member of a pharmacy's personnel.
+type PersonnelGetter interface {
+	GetPersonnel(ctx context.Context, pharmacyID, userID int64) (PersonnelMember, error)
+}
+
+// PersonnelStatusSetter deactivates or reactivates a member. Deactivation
+// revokes every session of the member in the same transaction.
+type PersonnelStatusSetter interface {
+	SetPersonnelActive(ctx context.Context, pharmacyID, userID int64, active bool) error
+}
+
+// PersonnelRoleSetter changes a member's role and revokes their sessions,
+// which carry the old role's permissions. A custom role must belong to the
+// pharmacy, or ErrInvalidRole is returned.
+type PersonnelRoleSetter interface {
+	SetPersonnelRole(ctx context.Context, pharmacyID, userID int64, role string, customRoleID int64) error
+}
+
+// PersonnelPasswordResetter sets a temporary password the member must change
+// at the next login, and revokes their sessions.
+type PersonnelPasswordResetter interface {
+	ResetPersonnelPassword(ctx context.Context, pharmacyID, userID int64, passwordHash string) error
+}
+
+// PersonnelUnlocker clears a member's failed logins and login lock.
+type PersonnelUnlocker interface {
+	UnlockPersonnel(ctx context.Context, pharmacyID, userID int64) error
+}
+
+// PharmacySessionRevoker logs out every member of a pharmacy and returns
+// how many sessions ended.
+type PharmacySessionRevoker interface {
+	RevokePharmacySessions(ctx context.Context, pharmacyID int64) (int, error)
+}
+
+// PersonnelTwoFactorResetter clears a member's authenticator and recovery
+// codes, and revokes their sessions.
+type PersonnelTwoFactorResetter interface {
+	ResetPersonnelTwoFactor(ctx context.Context, pharmacyID, userID int64) error
+}
+
+// PersonnelRemover deletes a member and their sessions.
+type PersonnelRemover interface {
+	RemovePersonnel(ctx context.Context, pharmacyID, userID int64) error
+}
+
+// SettingsGetter loads the settings an owner manages.
+type SettingsGetter interface {
+	GetSettings(ctx context.Context, pharmacyID int64) (Settings, error)
+}
+
+// SettingsUpdater saves the settings an owner manages; HasLogo is ignored.
+type SettingsUpdater interface {
+	UpdateSettings(ctx context.Context, s Settings) error
+}
+
+// LogoStore loads and replaces a pharmacy's label logo. A nil logo means
+// none.
+type LogoStore interface {
+	GetLogo(ctx context.Context, pharmacyID int64) ([]byte, error)
+	SetLogo(ctx context.Context, pharmacyID int64, logo []byte) error
+}
+
 // Repository composes all ports — used only by NewService for convenient wiring.
 type Repository interface {
 	PharmacyCreator
 	PharmacyGetter
 	PharmacyLister
+	BranchSummaryLister
+	BranchLister
 	PharmacyUpdater
 	PersonnelLister
 	PersonnelCreator
+	PersonnelGetter
+	PersonnelStatusSetter
+	PersonnelRoleSetter
+	PersonnelPasswordResetter
+	PersonnelUnlocker
+	PersonnelTwoFactorResetter
+	PersonnelRemover
+	PharmacySessionRevoker
+	SettingsGetter
+	SettingsUpdater
+	LogoStore
 }
@@ -0,0 +1,52 @@
+package pharmacy
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultPickupReminder is sent when a pickup is booked at a pharmacy that
+// did not write its own text.
+const DefaultPickupReminder = "Gentile {nome} {cognome}, il suo {farmaco} è pronto per il ritiro. La aspettiamo in farmacia il {data} alle {ora}."
+
+// MaxReminderLength is the longest reminder text, in characters: three SMS.
+const MaxReminderLength = 459
+
+// ReminderFields are the placeholders a reminder can use, written in braces
+// as in "{nome}".
+var ReminderFields = []string{"nome", "cognome", "farmaco", "data", "ora", "farmacia"}
+
+// validateReminder checks that every placeholder in t is closed and known.
+func validateReminder(t string) error {
+	if utf8.RuneCountInString(t) > MaxReminderLength {
+		return ErrReminderTooLong
+	}
+	for rest := t; ; {
+		_, after, ok := strings.Cut(rest, "{")
+		if !ok {
+			if strings.Contains(rest, "}") {
+				return ErrInvalidReminder
+			}
+			return nil
+		}
+		field, next, ok := strings.Cut(after, "}")
+		if !ok || !slices.Contains(ReminderFields, field) {
+			return ErrInvalidReminder
+		}
+		rest = next
+	}
+}
+
+// RenderReminder fills the placeholders of a reminder, using
+// DefaultPickupReminder when t is empty. values is keyed by field name.
+func RenderReminder(t string, values map[string]string) string {
+	if t == "" {
+		t = DefaultPickupReminder
+	}
+	pairs := make([]string, 0, 2*len(ReminderFields))
+	for _, f := range ReminderFields {
+		pairs = append(pairs, "{"+f+"}", values[f])
+	}
+	return strings.NewReplacer(pairs...).Replace(t)
+}
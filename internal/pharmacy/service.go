@@ -3,17 +3,34 @@ package pharmacy
 import (
 	"context"
 	"fmt"
+	"strings"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/i18n"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 )
 
 // ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
 type ServiceDeps struct {
-	Creator     PharmacyCreator
-	Getter      PharmacyGetter
-	Lister      PharmacyLister
-	Updater     PharmacyUpdater
-	Personnel   PersonnelLister
-	PersCreator PersonnelCreator
-	Hasher      func(string) (string, error)
+	Creator       PharmacyCreator
+	Getter        PharmacyGetter
+	Lister        PharmacyLister
+	Summaries     BranchSummaryLister
+	Branches      BranchLister
+	Updater       PharmacyUpdater
+	Personnel     PersonnelLister
+	PersCreator   PersonnelCreator
+	PersGetter    PersonnelGetter
+	PersStatus    PersonnelStatusSetter
+	PersRole      PersonnelRoleSetter
+	PersReset     PersonnelPasswordResetter
+	PersUnlocker  PersonnelUnlocker
+	PersTwoFactor PersonnelTwoFactorResetter
+	PersRemover   PersonnelRemover
+	Sessions      PharmacySessionRevoker
+	Settings      SettingsGetter
+	SettingsSaver SettingsUpdater
+	Logos         LogoStore
+	Hasher        func(string) (string, error)
 }
 
 // Service contains pharmacy domain business logic.
@@ -24,13 +41,26 @@ type Service struct {
 // NewService is the production constructor — takes a Repository (satisfies all ports).
 func NewService(repo Repository, hasher func(string) (string, error)) *Service {
 	return &Service{deps: ServiceDeps{
-		Creator:     repo,
-		Getter:      repo,
-		Lister:      repo,
-		Updater:     repo,
-		Personnel:   repo,
-		PersCreator: repo,
-		Hasher:      hasher,
+		Creator:       repo,
+		Getter:        repo,
+		Lister:        repo,
+		Summaries:     repo,
+		Branches:      repo,
+		Updater:       repo,
+		Personnel:     repo,
+		PersCreator:   repo,
+		PersGetter:    repo,
+		PersStatus:    repo,
+		PersRole:      repo,
+		PersReset:     repo,
+		PersUnlocker:  repo,
+		PersTwoFactor: repo,
+		PersRemover:   repo,
+		Sessions:      repo,
+		Settings:      repo,
+		SettingsSaver: repo,
+		Logos:         repo,
+		Hasher:        hasher,
 	}}
 }
 
@@ -54,7 +84,7 @@ func (s *Service) CreateWithOwner(ctx context.Context, p CreateParams) (Pharmacy
 	return ph, nil
 }
 
-// List returns all pharmacies with personnel counts.
+// List returns all pharmacies with their usage aggregates.
 func (s *Service) List(ctx context.Context) ([]Summary, error) {
 	return s.deps.Lister.List(ctx)
 }
@@ -64,8 +94,47 @@ func (s *Service) Get(ctx context.Context, id int64) (Pharmacy, error) {
 	return s.deps.Getter.GetByID(ctx, id)
 }
 
-// Update updates a pharmacy.
+// Branches returns the pharmacies the owner can switch to, their own included.
+func (s *Service) Branches(ctx context.Context, userID int64) ([]Branch, error) {
+	return s.deps.Branches.ListBranches(ctx, userID)
+}
+
+// GroupOverview returns the aggregates of every branch the owner can reach.
+func (s *Service) GroupOverview(ctx context.Context, userID int64) ([]Summary, error) {
+	return s.deps.Summaries.ListBranchSummaries(ctx, userID)
+}
+
+// SwitchBranch checks that the owner can reach pharmacyID and returns it, so
+// the caller can make it the active pharmacy of the session.
+func (s *Service) SwitchBranch(ctx context.Context, userID, pharmacyID int64) (Branch, error) {
+	branches, err := s.deps.Branches.ListBranches(ctx, userID)
+	if err != nil {
+		return Branch{}, fmt.Errorf("listing branches: %w", err)
+	}
+	for _, b := range branches {
+		if b.ID == pharmacyID {
+			return b, nil
+		}
+	}
+	return Branch{}, ErrNotBranch
+}
+
+// Update validates and updates a pharmacy. An empty label layout defaults to
+// A4 and an empty locale to the default language.
 func (s *Service) Update(ctx context.Context, p UpdateParams) error {
+	p.GroupName = strings.TrimSpace(p.GroupName)
+	if p.LabelLayout == "" {
+		p.LabelLayout = LabelLayoutA4x14
+	}
+	if !ValidLabelLayout(p.LabelLayout) {
+		return ErrInvalidLabelLayout
+	}
+	if p.Locale == "" {
+		p.Locale = string(i18n.Default)
+	}
+	if !i18n.Supported(p.Locale) {
+		return ErrInvalidLocale
+	}
 	return s.deps.Updater.Update(ctx, p)
 }
 
@@ -76,6 +145,9 @@ func (s *Service) ListPersonnel(ctx context.Context, pharmacyID int64) ([]Person
 
 // CreatePersonnel validates, hashes the password, and creates a personnel member.
 func (s *Service) CreatePersonnel(ctx context.Context, p CreatePersonnelParams) (PersonnelMember, error) {
+	if !validRole(p.Role, p.CustomRoleID) {
+		return PersonnelMember{}, ErrInvalidRole
+	}
 	hash, err := s.deps.Hasher(p.Password)
 	if err != nil {
 		return PersonnelMember{}, fmt.Errorf("hashing personnel password: %w", err)
@@ -88,3 +160,157 @@ func (s *Service) CreatePersonnel(ctx context.Context, p CreatePersonnelParams)
 
 	return m, nil
 }
+
+// GetPersonnel returns one member of a pharmacy's personnel.
+func (s *Service) GetPersonnel(ctx context.Context, pharmacyID, userID int64) (PersonnelMember, error) {
+	return s.deps.PersGetter.GetPersonnel(ctx, pharmacyID, userID)
+}
+
+// DeactivatePersonnel disables a member's login and logs them out everywhere.
+// actorID is the user making the change: nobody deactivates themselves.
+func (s *Service) DeactivatePersonnel(ctx context.Context, actorID, pharmacyID, userID int64) error {
+	if actorID == userID {
+		return ErrSelfChange
+	}
+	if err := s.deps.PersStatus.SetPersonnelActive(ctx, pharmacyID, userID, false); err != nil {
+		return fmt.Errorf("deactivating personnel: %w", err)
+	}
+	return nil
+}
+
+// ReactivatePersonnel enables a deactivated member's login again.
+func (s *Service) ReactivatePersonnel(ctx context.Context, pharmacyID, userID int64) error {
+	if err := s.deps.PersStatus.SetPersonnelActive(ctx, pharmacyID, userID, true); err != nil {
+		return fmt.Errorf("reactivating personnel: %w", err)
+	}
+	return nil
+}
+
+// ChangePersonnelRole gives a member another built-in role, or with
+// permission.RoleCustom one of the pharmacy's custom roles. The pharmacy
+// always keeps at least one active owner.
+func (s *Service) ChangePersonnelRole(ctx context.Context, actorID, pharmacyID, userID int64, role string, customRoleID int64) error {
+	if !validRole(role, customRoleID) {
+		return ErrInvalidRole
+	}
+	if actorID == userID {
+		return ErrSelfChange
+	}
+	if err := s.deps.PersRole.SetPersonnelRole(ctx, pharmacyID, userID, role, customRoleID); err != nil {
+		return fmt.Errorf("changing personnel role: %w", err)
+	}
+	return nil
+}
+
+// ResetPersonnelPassword sets a temporary password that the member must
+// replace at the next login. Their open sessions end.
+func (s *Service) ResetPersonnelPassword(ctx context.Context, actorID, pharmacyID, userID int64, password string) error {
+	if actorID == userID {
+		return ErrSelfChange
+	}
+	hash, err := s.deps.Hasher(password)
+	if err != nil {
+		return fmt.Errorf("hashing temporary password: %w", err)
+	}
+	if err := s.deps.PersReset.ResetPersonnelPassword(ctx, pharmacyID, userID, hash); err != nil {
+		return fmt.Errorf("resetting personnel password: %w", err)
+	}
+	return nil
+}
+
+// UnlockPersonnel lets a member locked out by failed logins try again at once.
+func (s *Service) UnlockPersonnel(ctx context.Context, pharmacyID, userID int64) error {
+	if err := s.deps.PersUnlocker.UnlockPersonnel(ctx, pharmacyID, userID); err != nil {
+		return fmt.Errorf("unlocking personnel: %w", err)
+	}
+	return nil
+}
+
+// RevokeSessions logs out everyone working at the pharmacy, for example
+// after a shared computer was lost. Patient portal sessions are unaffected.
+func (s *Service) RevokeSessions(ctx context.Context, pharmacyID int64) (int, error) {
+	n, err := s.deps.Sessions.RevokePharmacySessions(ctx, pharmacyID)
+	if err != nil {
+		return 0, fmt.Errorf("revoking pharmacy sessions: %w", err)
+	}
+	return n, nil
+}
+
+// ResetPersonnelTwoFactor removes a member's authenticator, for example
+// after a lost phone. They enrol again at the next login if 2FA is required.
+func (s *Service) ResetPersonnelTwoFactor(ctx context.Context, actorID, pharmacyID, userID int64) error {
+	if actorID == userID {
+		return ErrSelfChange
+	}
+	if err := s.deps.PersTwoFactor.ResetPersonnelTwoFactor(ctx, pharmacyID, userID); err != nil {
+		return fmt.Errorf("resetting personnel two-factor auth: %w", err)
+	}
+	return nil
+}
+
+// RemovePersonnel deletes a member for good. The last active owner cannot
+// be removed.
+func (s *Service) RemovePersonnel(ctx context.Context, actorID, pharmacyID, userID int64) error {
+	if actorID == userID {
+		return ErrSelfChange
+	}
+	if err := s.deps.PersRemover.RemovePersonnel(ctx, pharmacyID, userID); err != nil {
+		return fmt.Errorf("removing personnel: %w", err)
+	}
+	return nil
+}
+
+// Settings returns the settings an owner manages for their pharmacy.
+func (s *Service) Settings(ctx context.Context, pharmacyID int64) (Settings, error) {
+	return s.deps.Settings.GetSettings(ctx, pharmacyID)
+}
+
+// UpdateSettings validates and saves an owner's settings. Contact details
+// are trimmed, the partita IVA is stored as its 11 digits, an empty default
+// fulfillment means pickup and a reminder equal to the built-in text is
+// stored empty, so it follows later changes to the default.
+func (s *Service) UpdateSettings(ctx context.Context, st Settings) error {
+	if err := permission.Check(ctx, permission.ManageSettings); err != nil {
+		return err
+	}
+	if err := st.normalize(); err != nil {
+		return err
+	}
+	return s.deps.SettingsSaver.UpdateSettings(ctx, st)
+}
+
+// LookaheadDays returns how many days before depletion the pharmacy creates
+// orders, or fallback when it follows the server default.
+func (s *Service) LookaheadDays(ctx context.Context, pharmacyID int64, fallback int) (int, error) {
+	st, err := s.deps.Settings.GetSettings(ctx, pharmacyID)
+	if err != nil {
+		return 0, fmt.Errorf("getting pharmacy settings: %w", err)
+	}
+	return st.Lookahead(fallback), nil
+}
+
+// Logo returns the pharmacy's label logo as a JPEG, or nil when it has none.
+func (s *Service) Logo(ctx context.Context, pharmacyID int64) ([]byte, error) {
+	return s.deps.Logos.GetLogo(ctx, pharmacyID)
+}
+
+// SetLogo stores an uploaded PNG or JPEG as the pharmacy's label logo,
+// converted to a small JPEG.
+func (s *Service) SetLogo(ctx context.Context, pharmacyID int64, data []byte) error {
+	if err := permission.Check(ctx, permission.ManageSettings); err != nil {
+		return err
+	}
+	logo, err := normalizeLogo(data)
+	if err != nil {
+		return err
+	}
+	return s.deps.Logos.SetLogo(ctx, pharmacyID, logo)
+}
+
+// RemoveLogo stops printing a logo on the pharmacy's labels.
+func (s *Service) RemoveLogo(ctx context.Context, pharmacyID int64) error {
+	if err := permission.Check(ctx, permission.ManageSettings); err != nil {
+		return err
+	}
+	return s.deps.Logos.SetLogo(ctx, pharmacyID, nil)
+}
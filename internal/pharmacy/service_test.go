@@ -2,8 +2,10 @@ package pharmacy_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
 )
 
@@ -96,3 +98,314 @@ func TestCreatePersonnelSuccess(t *testing.T) {
 		t.Errorf("hash = %q, want hashed-temppass", creator.gotHash)
 	}
 }
+
+// --- Update tests ---
+
+type mockPharmacyUpdater struct {
+	called bool
+	got    pharmacy.UpdateParams
+	err    error
+}
+
+func (m *mockPharmacyUpdater) Update(_ context.Context, p pharmacy.UpdateParams) error {
+	m.called = true
+	m.got = p
+	return m.err
+}
+
+func TestUpdateDefaultsLabelLayout(t *testing.T) {
+	updater := &mockPharmacyUpdater{}
+	svc := pharmacy.NewServiceWith(pharmacy.ServiceDeps{Updater: updater})
+
+	if err := svc.Update(context.Background(), pharmacy.UpdateParams{ID: 1, Name: "Farmacia Rossi", Address: "Via Roma 1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updater.got.LabelLayout != pharmacy.LabelLayoutA4x14 {
+		t.Errorf("LabelLayout = %q, want %q", updater.got.LabelLayout, pharmacy.LabelLayoutA4x14)
+	}
+}
+
+func TestUpdateRejectsUnknownLabelLayout(t *testing.T) {
+	updater := &mockPharmacyUpdater{}
+	svc := pharmacy.NewServiceWith(pharmacy.ServiceDeps{Updater: updater})
+
+	err := svc.Update(context.Background(), pharmacy.UpdateParams{ID: 1, Name: "Farmacia Rossi", Address: "Via Roma 1", LabelLayout: "letter"})
+	if !errors.Is(err, pharmacy.ErrInvalidLabelLayout) {
+		t.Errorf("err = %v, want ErrInvalidLabelLayout", err)
+	}
+	if updater.called {
+		t.Error("Update should not reach the repository with an invalid layout")
+	}
+}
+
+func TestUpdateDefaultsLocale(t *testing.T) {
+	updater := &mockPharmacyUpdater{}
+	svc := pharmacy.NewServiceWith(pharmacy.ServiceDeps{Updater: updater})
+
+	if err := svc.Update(context.Background(), pharmacy.UpdateParams{ID: 1, Name: "Farmacia Rossi", Address: "Via Roma 1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updater.got.Locale != "it" {
+		t.Errorf("Locale = %q, want it", updater.got.Locale)
+	}
+}
+
+func TestUpdateRejectsUnsupportedLocale(t *testing.T) {
+	updater := &mockPharmacyUpdater{}
+	svc := pharmacy.NewServiceWith(pharmacy.ServiceDeps{Updater: updater})
+
+	err := svc.Update(context.Background(), pharmacy.UpdateParams{ID: 1, Name: "Farmacia Rossi", Address: "Via Roma 1", Locale: "fr"})
+	if !errors.Is(err, pharmacy.ErrInvalidLocale) {
+		t.Errorf("err = %v, want ErrInvalidLocale", err)
+	}
+	if updater.called {
+		t.Error("Update should not reach the repository with an unsupported locale")
+	}
+}
+
+func TestUpdateTrimsGroupName(t *testing.T) {
+	updater := &mockPharmacyUpdater{}
+	svc := pharmacy.NewServiceWith(pharmacy.ServiceDeps{Updater: updater})
+
+	if err := svc.Update(context.Background(), pharmacy.UpdateParams{ID: 1, Name: "Farmacia Rossi", Address: "Via Roma 1", GroupName: "  Gruppo Rossi "}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updater.got.GroupName != "Gruppo Rossi" {
+		t.Errorf("GroupName = %q, want %q", updater.got.GroupName, "Gruppo Rossi")
+	}
+}
+
+// --- Branch mocks ---
+
+type mockBranchLister struct {
+	branches []pharmacy.Branch
+	err      error
+}
+
+func (m *mockBranchLister) ListBranches(_ context.Context, _ int64) ([]pharmacy.Branch, error) {
+	return m.branches, m.err
+}
+
+func TestSwitchBranchReturnsReachableBranch(t *testing.T) {
+	svc := pharmacy.NewServiceWith(pharmacy.ServiceDeps{Branches: &mockBranchLister{branches: []pharmacy.Branch{
+		{ID: 7, Name: "Farmacia Rossi Centro"},
+		{ID: 8, Name: "Farmacia Rossi Stazione"},
+	}}})
+
+	b, err := svc.SwitchBranch(context.Background(), 1, 8)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Name != "Farmacia Rossi Stazione" {
+		t.Errorf("Name = %q, want Farmacia Rossi Stazione", b.Name)
+	}
+}
+
+func TestSwitchBranchRejectsPharmacyOutsideGroup(t *testing.T) {
+	svc := pharmacy.NewServiceWith(pharmacy.ServiceDeps{Branches: &mockBranchLister{branches: []pharmacy.Branch{
+		{ID: 7, Name: "Farmacia Rossi Centro"},
+	}}})
+
+	_, err := svc.SwitchBranch(context.Background(), 1, 99)
+	if !errors.Is(err, pharmacy.ErrNotBranch) {
+		t.Errorf("err = %v, want ErrNotBranch", err)
+	}
+}
+
+// --- Personnel lifecycle tests ---
+
+type mockPersonnelLifecycle struct {
+	called bool
+	active bool
+	role   string
+	hash   string
+	err    error
+
+	customRoleID int64
+}
+
+func (m *mockPersonnelLifecycle) SetPersonnelActive(_ context.Context, _, _ int64, active bool) error {
+	m.called, m.active = true, active
+	return m.err
+}
+
+func (m *mockPersonnelLifecycle) SetPersonnelRole(_ context.Context, _, _ int64, role string, customRoleID int64) error {
+	m.called, m.role, m.customRoleID = true, role, customRoleID
+	return m.err
+}
+
+func (m *mockPersonnelLifecycle) ResetPersonnelPassword(_ context.Context, _, _ int64, hash string) error {
+	m.called, m.hash = true, hash
+	return m.err
+}
+
+func (m *mockPersonnelLifecycle) UnlockPersonnel(_ context.Context, _, _ int64) error {
+	m.called = true
+	return m.err
+}
+
+func (m *mockPersonnelLifecycle) ResetPersonnelTwoFactor(_ context.Context, _, _ int64) error {
+	m.called = true
+	return m.err
+}
+
+func (m *mockPersonnelLifecycle) RemovePersonnel(_ context.Context, _, _ int64) error {
+	m.called = true
+	return m.err
+}
+
+func lifecycleService(m *mockPersonnelLifecycle) *pharmacy.Service {
+	return pharmacy.NewServiceWith(pharmacy.ServiceDeps{
+		PersStatus:    m,
+		PersRole:      m,
+		PersReset:     m,
+		PersUnlocker:  m,
+		PersTwoFactor: m,
+		PersRemover:   m,
+		Hasher:        func(s string) (string, error) { return "hashed-" + s, nil },
+	})
+}
+
+func TestPersonnelLifecycleRefusesSelfChange(t *testing.T) {
+	m := &mockPersonnelLifecycle{}
+	svc := lifecycleService(m)
+	ctx := context.Background()
+
+	for name, err := range map[string]error{
+		"deactivate": svc.DeactivatePersonnel(ctx, 3, 7, 3),
+		"role":       svc.ChangePersonnelRole(ctx, 3, 7, 3, pharmacy.RolePersonnel, 0),
+		"reset":      svc.ResetPersonnelPassword(ctx, 3, 7, 3, "temp"),
+		"2fa":        svc.ResetPersonnelTwoFactor(ctx, 3, 7, 3),
+		"remove":     svc.RemovePersonnel(ctx, 3, 7, 3),
+	} {
+		if !errors.Is(err, pharmacy.ErrSelfChange) {
+			t.Errorf("%s: error = %v, want ErrSelfChange", name, err)
+		}
+	}
+	if m.called {
+		t.Error("repository must not be called for a self change")
+	}
+}
+
+func TestDeactivatePersonnelDisablesLogin(t *testing.T) {
+	m := &mockPersonnelLifecycle{active: true}
+	if err := lifecycleService(m).DeactivatePersonnel(context.Background(), 1, 7, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !m.called || m.active {
+		t.Error("expected SetPersonnelActive(false)")
+	}
+}
+
+func TestChangePersonnelRoleRejectsUnknownRole(t *testing.T) {
+	tests := []struct {
+		name         string
+		role         string
+		customRoleID int64
+	}{
+		{"admin", "admin", 0},
+		{"made up", "boss", 0},
+		{"custom without role", permission.RoleCustom, 0},
+		{"built-in with custom role", permission.RoleTrainee, 4},
+	}
+	for _, tt := range tests {
+		m := &mockPersonnelLifecycle{}
+		err := lifecycleService(m).ChangePersonnelRole(context.Background(), 1, 7, 3, tt.role, tt.customRoleID)
+		if !errors.Is(err, pharmacy.ErrInvalidRole) {
+			t.Errorf("%s: error = %v, want ErrInvalidRole", tt.name, err)
+		}
+		if m.called {
+			t.Errorf("%s: repository must not be called for an invalid role", tt.name)
+		}
+	}
+}
+
+func TestChangePersonnelRoleToCustomRole(t *testing.T) {
+	m := &mockPersonnelLifecycle{}
+	if err := lifecycleService(m).ChangePersonnelRole(context.Background(), 1, 7, 3, permission.RoleCustom, 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.role != permission.RoleCustom || m.customRoleID != 4 {
+		t.Errorf("role = %q/%d, want custom/4", m.role, m.customRoleID)
+	}
+}
+
+func TestCreatePersonnelRejectsInvalidRole(t *testing.T) {
+	creator := &mockPersonnelCreator{}
+	svc := pharmacy.NewServiceWith(pharmacy.ServiceDeps{
+		PersCreator: creator,
+		Hasher:      func(s string) (string, error) { return "hashed-" + s, nil },
+	})
+	_, err := svc.CreatePersonnel(context.Background(), pharmacy.CreatePersonnelParams{PharmacyID: 1, Name: "Anna", Email: "anna@example.com", Password: "temppass", Role: "admin"})
+	if !errors.Is(err, pharmacy.ErrInvalidRole) {
+		t.Errorf("error = %v, want ErrInvalidRole", err)
+	}
+	if creator.called {
+		t.Error("repository must not be called for an invalid role")
+	}
+}
+
+func TestChangePersonnelRoleKeepsLastOwnerError(t *testing.T) {
+	m := &mockPersonnelLifecycle{err: pharmacy.ErrLastOwner}
+	err := lifecycleService(m).ChangePersonnelRole(context.Background(), 1, 7, 3, pharmacy.RolePersonnel, 0)
+	if !errors.Is(err, pharmacy.ErrLastOwner) {
+		t.Errorf("error = %v, want ErrLastOwner", err)
+	}
+}
+
+func TestResetPersonnelPasswordRefusesFederatedMember(t *testing.T) {
+	m := &mockPersonnelLifecycle{err: pharmacy.ErrFederatedMember}
+	err := lifecycleService(m).ResetPersonnelPassword(context.Background(), 1, 7, 3, "temp")
+	if !errors.Is(err, pharmacy.ErrFederatedMember) {
+		t.Errorf("error = %v, want ErrFederatedMember", err)
+	}
+}
+
+func TestResetPersonnelPasswordHashesTemporaryPassword(t *testing.T) {
+	m := &mockPersonnelLifecycle{}
+	if err := lifecycleService(m).ResetPersonnelPassword(context.Background(), 1, 7, 3, "temp"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.hash != "hashed-temp" {
+		t.Errorf("hash = %q, want %q", m.hash, "hashed-temp")
+	}
+}
+
+func TestUnlockPersonnelClearsLock(t *testing.T) {
+	m := &mockPersonnelLifecycle{}
+	if err := lifecycleService(m).UnlockPersonnel(context.Background(), 7, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !m.called {
+		t.Error("expected UnlockPersonnel on the repository")
+	}
+}
+
+type mockSessionRevoker struct {
+	pharmacyID int64
+	revoked    int
+	err        error
+}
+
+func (m *mockSessionRevoker) RevokePharmacySessions(_ context.Context, pharmacyID int64) (int, error) {
+	m.pharmacyID = pharmacyID
+	return m.revoked, m.err
+}
+
+func TestRevokeSessionsReturnsCount(t *testing.T) {
+	m := &mockSessionRevoker{revoked: 4}
+	n, err := pharmacy.NewServiceWith(pharmacy.ServiceDeps{Sessions: m}).RevokeSessions(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 4 || m.pharmacyID != 7 {
+		t.Errorf("revoked %d sessions of pharmacy %d, want 4 of 7", n, m.pharmacyID)
+	}
+}
+
+func TestRevokeSessionsWrapsError(t *testing.T) {
+	m := &mockSessionRevoker{err: errors.New("db down")}
+	if _, err := pharmacy.NewServiceWith(pharmacy.ServiceDeps{Sessions: m}).RevokeSessions(context.Background(), 7); err == nil {
+		t.Error("expected an error")
+	}
+}
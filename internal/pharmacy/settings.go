@@ -0,0 +1,120 @@
+package pharmacy
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+)
+
+var (
+	ErrAddressRequired    = errors.New("pharmacy.address_required")
+	ErrInvalidEmail       = errors.New("pharmacy.invalid_email")
+	ErrInvalidVATNumber   = errors.New("pharmacy.invalid_vat_number")
+	ErrInvalidFulfillment = errors.New("pharmacy.invalid_fulfillment")
+	ErrInvalidLookahead   = errors.New("pharmacy.invalid_lookahead")
+	ErrInvalidReminder    = errors.New("pharmacy.invalid_reminder")
+	ErrReminderTooLong    = errors.New("pharmacy.reminder_too_long")
+	ErrInvalidLogo        = errors.New("pharmacy.invalid_logo")
+	ErrLogoTooLarge       = errors.New("pharmacy.logo_too_large")
+)
+
+// MaxLookaheadDays bounds how far ahead a pharmacy can prepare orders.
+const MaxLookaheadDays = 60
+
+// Settings are what an owner configures for their own pharmacy. The name,
+// group, label layout and security options stay with the admin.
+type Settings struct {
+	PharmacyID int64
+	Address    string
+	Phone      string
+	Email      string
+	// VATNumber is the Italian partita IVA, empty when not given.
+	VATNumber string
+	// DefaultFulfillment is preselected when staff add a patient.
+	DefaultFulfillment string
+	// LookaheadDays is how many days before depletion orders are created;
+	// 0 follows the server's lookahead.days.
+	LookaheadDays int
+	// PickupReminder is the text sent when a pickup is booked; empty uses
+	// DefaultPickupReminder.
+	PickupReminder string
+	// HasLogo reports whether a logo is printed on labels.
+	HasLogo bool
+}
+
+// Lookahead returns the pharmacy's lookahead, or fallback when it follows
+// the server default.
+func (s Settings) Lookahead(fallback int) int {
+	if s.LookaheadDays == 0 {
+		return fallback
+	}
+	return s.LookaheadDays
+}
+
+// normalize trims the settings and fills in defaults, then validates them.
+func (s *Settings) normalize() error {
+	s.Address = strings.TrimSpace(s.Address)
+	s.Phone = strings.TrimSpace(s.Phone)
+	s.Email = strings.TrimSpace(s.Email)
+	s.PickupReminder = strings.TrimSpace(s.PickupReminder)
+	if s.DefaultFulfillment == "" {
+		s.DefaultFulfillment = patient.FulfillmentPickup
+	}
+
+	if s.Address == "" {
+		return ErrAddressRequired
+	}
+	if s.Email != "" {
+		if _, err := mail.ParseAddress(s.Email); err != nil {
+			return ErrInvalidEmail
+		}
+	}
+	vat, err := normalizeVATNumber(s.VATNumber)
+	if err != nil {
+		return err
+	}
+	s.VATNumber = vat
+	if s.DefaultFulfillment != patient.FulfillmentPickup && s.DefaultFulfillment != patient.FulfillmentShipping {
+		return ErrInvalidFulfillment
+	}
+	if s.LookaheadDays < 0 || s.LookaheadDays > MaxLookaheadDays {
+		return ErrInvalidLookahead
+	}
+	if s.PickupReminder == DefaultPickupReminder {
+		s.PickupReminder = ""
+	}
+	return validateReminder(s.PickupReminder)
+}
+
+// normalizeVATNumber checks an Italian partita IVA: 11 digits, the last a
+// Luhn check digit, optionally prefixed with "IT". It returns the digits.
+func normalizeVATNumber(v string) (string, error) {
+	v = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(v), " ", ""))
+	v = strings.TrimPrefix(v, "IT")
+	if v == "" {
+		return "", nil
+	}
+	if len(v) != 11 {
+		return "", ErrInvalidVATNumber
+	}
+	sum := 0
+	for i, c := range v {
+		if c < '0' || c > '9' {
+			return "", ErrInvalidVATNumber
+		}
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	if sum%10 != 0 {
+		return "", ErrInvalidVATNumber
+	}
+	return v, nil
+}
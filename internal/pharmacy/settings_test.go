@@ -0,0 +1,169 @@
+package pharmacy_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+)
+
+type mockSettingsStore struct {
+	settings pharmacy.Settings
+	saved    *pharmacy.Settings
+	logo     []byte
+	logoSet  bool
+}
+
+func (m *mockSettingsStore) GetSettings(_ context.Context, pharmacyID int64) (pharmacy.Settings, error) {
+	s := m.settings
+	s.PharmacyID = pharmacyID
+	return s, nil
+}
+
+func (m *mockSettingsStore) UpdateSettings(_ context.Context, s pharmacy.Settings) error {
+	m.saved = &s
+	return nil
+}
+
+func (m *mockSettingsStore) GetLogo(_ context.Context, _ int64) ([]byte, error) {
+	return m.logo, nil
+}
+
+func (m *mockSettingsStore) SetLogo(_ context.Context, _ int64, logo []byte) error {
+	m.logo = logo
+	m.logoSet = true
+	return nil
+}
+
+func settingsService(m *mockSettingsStore) *pharmacy.Service {
+	return pharmacy.NewServiceWith(pharmacy.ServiceDeps{Settings: m, SettingsSaver: m, Logos: m})
+}
+
+func TestUpdateSettingsNormalizes(t *testing.T) {
+	m := &mockSettingsStore{}
+	err := settingsService(m).UpdateSettings(permission.WithSystem(context.Background()), pharmacy.Settings{
+		PharmacyID:     7,
+		Address:        "  Via Roma 1  ",
+		VATNumber:      "it 123 456 789 03",
+		PickupReminder: pharmacy.DefaultPickupReminder,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := m.saved
+	if got.Address != "Via Roma 1" || got.VATNumber != "12345678903" || got.DefaultFulfillment != "pickup" || got.PickupReminder != "" {
+		t.Errorf("saved = %+v", got)
+	}
+}
+
+func TestUpdateSettingsValidates(t *testing.T) {
+	valid := pharmacy.Settings{PharmacyID: 7, Address: "Via Roma 1"}
+	tests := []struct {
+		name   string
+		change func(*pharmacy.Settings)
+		want   error
+	}{
+		{"no address", func(s *pharmacy.Settings) { s.Address = " " }, pharmacy.ErrAddressRequired},
+		{"bad email", func(s *pharmacy.Settings) { s.Email = "rossi@" }, pharmacy.ErrInvalidEmail},
+		{"short VAT", func(s *pharmacy.Settings) { s.VATNumber = "1234567890" }, pharmacy.ErrInvalidVATNumber},
+		{"VAT checksum", func(s *pharmacy.Settings) { s.VATNumber = "12345678900" }, pharmacy.ErrInvalidVATNumber},
+		{"fulfillment", func(s *pharmacy.Settings) { s.DefaultFulfillment = "drone" }, pharmacy.ErrInvalidFulfillment},
+		{"negative lookahead", func(s *pharmacy.Settings) { s.LookaheadDays = -1 }, pharmacy.ErrInvalidLookahead},
+		{"lookahead too far", func(s *pharmacy.Settings) { s.LookaheadDays = pharmacy.MaxLookaheadDays + 1 }, pharmacy.ErrInvalidLookahead},
+		{"unknown placeholder", func(s *pharmacy.Settings) { s.PickupReminder = "Ciao {nome} {indirizzo}" }, pharmacy.ErrInvalidReminder},
+		{"unclosed placeholder", func(s *pharmacy.Settings) { s.PickupReminder = "Ciao {nome" }, pharmacy.ErrInvalidReminder},
+		{"stray brace", func(s *pharmacy.Settings) { s.PickupReminder = "Ciao nome}" }, pharmacy.ErrInvalidReminder},
+		{"long reminder", func(s *pharmacy.Settings) { s.PickupReminder = strings.Repeat("à", pharmacy.MaxReminderLength+1) }, pharmacy.ErrReminderTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockSettingsStore{}
+			s := valid
+			tt.change(&s)
+			if err := settingsService(m).UpdateSettings(permission.WithSystem(context.Background()), s); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if m.saved != nil {
+				t.Error("invalid settings must not be saved")
+			}
+		})
+	}
+}
+
+func TestUpdateSettingsNeedsPermission(t *testing.T) {
+	m := &mockSettingsStore{}
+	ctx := permission.NewContext(context.Background(), permission.ForRole(permission.RolePharmacist, nil))
+
+	err := settingsService(m).UpdateSettings(ctx, pharmacy.Settings{PharmacyID: 7, Address: "Via Roma 1"})
+	if !errors.Is(err, permission.ErrForbidden) {
+		t.Errorf("err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestLookaheadDaysFallsBackToServerDefault(t *testing.T) {
+	m := &mockSettingsStore{}
+	svc := settingsService(m)
+
+	if days, _ := svc.LookaheadDays(context.Background(), 7, 10); days != 10 {
+		t.Errorf("days = %d, want the fallback 10", days)
+	}
+	m.settings.LookaheadDays = 3
+	if days, _ := svc.LookaheadDays(context.Background(), 7, 10); days != 3 {
+		t.Errorf("days = %d, want 3", days)
+	}
+}
+
+func TestRenderReminder(t *testing.T) {
+	values := map[string]string{"nome": "Mario", "cognome": "Rossi", "farmaco": "Tachipirina", "data": "03/03/2026", "ora": "10:30", "farmacia": "Farmacia Centrale"}
+
+	if got := pharmacy.RenderReminder("{farmacia}: {nome}, {farmaco} alle {ora}.", values); got != "Farmacia Centrale: Mario, Tachipirina alle 10:30." {
+		t.Errorf("got %q", got)
+	}
+	if got := pharmacy.RenderReminder("", values); !strings.HasPrefix(got, "Gentile Mario Rossi, il suo Tachipirina") {
+		t.Errorf("empty template should use the default, got %q", got)
+	}
+}
+
+func TestSetLogoConvertsToSmallJPEG(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1000, 500))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockSettingsStore{}
+	if err := settingsService(m).SetLogo(permission.WithSystem(context.Background()), 7, buf.Bytes()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(m.logo))
+	if err != nil {
+		t.Fatalf("stored logo is not a JPEG: %v", err)
+	}
+	if cfg.Width != 400 || cfg.Height != 200 {
+		t.Errorf("size = %dx%d, want 400x200", cfg.Width, cfg.Height)
+	}
+}
+
+func TestSetLogoRejectsOtherFiles(t *testing.T) {
+	m := &mockSettingsStore{}
+	svc := settingsService(m)
+
+	if err := svc.SetLogo(permission.WithSystem(context.Background()), 7, []byte("%PDF-1.4")); !errors.Is(err, pharmacy.ErrInvalidLogo) {
+		t.Errorf("err = %v, want ErrInvalidLogo", err)
+	}
+	if err := svc.SetLogo(permission.WithSystem(context.Background()), 7, make([]byte, pharmacy.MaxLogoBytes+1)); !errors.Is(err, pharmacy.ErrLogoTooLarge) {
+		t.Errorf("err = %v, want ErrLogoTooLarge", err)
+	}
+	if m.logoSet {
+		t.Error("a rejected logo must not be stored")
+	}
+}
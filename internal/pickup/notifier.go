@@ -0,0 +1,77 @@
+package pickup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/sms"
+)
+
+// Message returns the text sent to the patient for a notice, from the
+// pharmacy's reminder or the default one.
+func Message(n Notice) string {
+	return pharmacy.RenderReminder(n.Reminder, map[string]string{
+		"nome":     n.FirstName,
+		"cognome":  n.LastName,
+		"farmaco":  n.MedicationName,
+		"data":     n.At.Format("02/01/2006"),
+		"ora":      n.At.Format("15:04"),
+		"farmacia": n.PharmacyName,
+	})
+}
+
+// EmailSender sends a plain-text email, as mail.SMTP does.
+type EmailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// TextSender sends a text message, as sms.Gateway does.
+type TextSender interface {
+	Send(ctx context.Context, to, text string) error
+}
+
+// GatewayNotifier is the Notifier of deployed servers. It texts the
+// patient's phone when there is one and emails them otherwise; a patient
+// with neither gets ErrNoContact, so the order is not marked notified.
+type GatewayNotifier struct {
+	Email EmailSender
+	SMS   TextSender
+}
+
+// NotifyPickup sends the message.
+func (g GatewayNotifier) NotifyPickup(ctx context.Context, n Notice) error {
+	switch {
+	case sms.NationalNumber(n.Phone) != "":
+		if err := g.SMS.Send(ctx, sms.ItalianNumber(n.Phone), Message(n)); err != nil {
+			return fmt.Errorf("texting pickup notice: %w", err)
+		}
+	case n.Email != "":
+		if err := g.Email.Send(ctx, n.Email, "Ritiro in farmacia "+n.PharmacyName, Message(n)); err != nil {
+			return fmt.Errorf("emailing pickup notice: %w", err)
+		}
+	default:
+		return ErrNoContact
+	}
+	return nil
+}
+
+// LogNotifier is a Notifier that writes the message to the log instead of
+// sending it. The message names the patient and their medication, so it is
+// for development only: production configurations must set a mail server
+// and an SMS gateway.
+type LogNotifier struct {
+	Logger *slog.Logger
+}
+
+// NotifyPickup logs the message.
+func (l LogNotifier) NotifyPickup(ctx context.Context, n Notice) error {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.InfoContext(ctx, "pickup notification",
+		"pharmacy_id", n.PharmacyID, "order_id", n.OrderID, "message", Message(n))
+	return nil
+}
@@ -0,0 +1,231 @@
+package pickup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/giorgiovilardo/pharmarecall/internal/dbutil"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Ensure PgxRepository satisfies Repository at compile time.
+var _ Repository = (*PgxRepository)(nil)
+
+// PgxRepository implements all pickup port interfaces using pgx/sqlc.
+type PgxRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewPgxRepository creates a new PgxRepository.
+func NewPgxRepository(pool *pgxpool.Pool, queries *db.Queries) *PgxRepository {
+	return &PgxRepository{pool: pool, queries: queries}
+}
+
+func (r *PgxRepository) GetSchedule(ctx context.Context, pharmacyID int64) (Schedule, error) {
+	sch := Schedule{Settings: DefaultSettings()}
+	settings, err := r.queries.GetPickupSettings(ctx, pharmacyID)
+	switch {
+	case err == nil:
+		sch.Settings = Settings{
+			SlotMinutes:   int(settings.SlotMinutes),
+			SlotCapacity:  int(settings.SlotCapacity),
+			DailyCapacity: int(settings.DailyCapacity),
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return Schedule{}, fmt.Errorf("querying pickup settings: %w", err)
+	}
+
+	rows, err := r.queries.ListOpeningHours(ctx, pharmacyID)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("listing opening hours: %w", err)
+	}
+	for _, row := range rows {
+		sch.Hours = append(sch.Hours, Period{
+			Weekday: time.Weekday(row.Weekday),
+			Opens:   minutesOf(row.OpensAt),
+			Closes:  minutesOf(row.ClosesAt),
+		})
+	}
+	return sch, nil
+}
+
+func (r *PgxRepository) SaveSchedule(ctx context.Context, pharmacyID int64, s Schedule) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	if err := qtx.UpsertPickupSettings(ctx, db.UpsertPickupSettingsParams{
+		PharmacyID:    pharmacyID,
+		SlotMinutes:   int32(s.Settings.SlotMinutes),
+		SlotCapacity:  int32(s.Settings.SlotCapacity),
+		DailyCapacity: int32(s.Settings.DailyCapacity),
+	}); err != nil {
+		return fmt.Errorf("saving pickup settings: %w", err)
+	}
+	if err := qtx.DeleteOpeningHours(ctx, pharmacyID); err != nil {
+		return fmt.Errorf("clearing opening hours: %w", err)
+	}
+	for _, p := range s.Hours {
+		if err := qtx.InsertOpeningHours(ctx, db.InsertOpeningHoursParams{
+			PharmacyID: pharmacyID,
+			Weekday:    int16(p.Weekday),
+			OpensAt:    timeOfDay(p.Opens),
+			ClosesAt:   timeOfDay(p.Closes),
+		}); err != nil {
+			return fmt.Errorf("inserting opening hours: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) CountBookings(ctx context.Context, pharmacyID int64, from, to time.Time, excludeOrderID int64) (map[time.Time]int, error) {
+	return countBookings(ctx, r.queries, pharmacyID, from, to, excludeOrderID)
+}
+
+func countBookings(ctx context.Context, q *db.Queries, pharmacyID int64, from, to time.Time, excludeOrderID int64) (map[time.Time]int, error) {
+	rows, err := q.CountPickupBookings(ctx, db.CountPickupBookingsParams{
+		PharmacyID:     pharmacyID,
+		FromAt:         dbutil.TimeToTimestamp(from),
+		ToAt:           dbutil.TimeToTimestamp(to),
+		ExcludeOrderID: excludeOrderID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("counting pickup bookings: %w", err)
+	}
+	booked := make(map[time.Time]int, len(rows))
+	for _, row := range rows {
+		booked[row.PickupAt.Time] = int(row.Booked)
+	}
+	return booked, nil
+}
+
+func (r *PgxRepository) ListAppointments(ctx context.Context, pharmacyID int64, from, to time.Time) ([]Appointment, error) {
+	rows, err := r.queries.ListPickupAppointments(ctx, db.ListPickupAppointmentsParams{
+		PharmacyID: pharmacyID,
+		FromAt:     dbutil.TimeToTimestamp(from),
+		ToAt:       dbutil.TimeToTimestamp(to),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing pickup appointments: %w", err)
+	}
+	result := make([]Appointment, len(rows))
+	for i, row := range rows {
+		result[i] = Appointment{
+			OrderID:        row.OrderID,
+			PatientID:      row.PatientID,
+			At:             row.PickupAt.Time,
+			Confirmed:      row.PickupConfirmed,
+			NotifiedAt:     timestamptzPtr(row.PickupNotifiedAt),
+			OrderStatus:    row.OrderStatus,
+			MedicationName: row.MedicationName,
+			FirstName:      row.FirstName,
+			LastName:       row.LastName,
+			Phone:          row.Phone,
+			Email:          row.Email,
+		}
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) GetOrder(ctx context.Context, pharmacyID, orderID int64) (Order, error) {
+	row, err := r.queries.GetPickupOrder(ctx, db.GetPickupOrderParams{
+		OrderID:    orderID,
+		PharmacyID: pharmacyID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Order{}, ErrNotFound
+		}
+		return Order{}, fmt.Errorf("querying pickup order: %w", err)
+	}
+	o := Order{
+		ID:                     row.OrderID,
+		Status:                 row.OrderStatus,
+		Fulfillment:            row.Fulfillment,
+		EstimatedDepletionDate: row.EstimatedDepletionDate.Time,
+		Confirmed:              row.PickupConfirmed,
+		MedicationName:         row.MedicationName,
+		FirstName:              row.FirstName,
+		LastName:               row.LastName,
+		Phone:                  row.Phone,
+		Email:                  row.Email,
+		PharmacyName:           row.PharmacyName,
+		Reminder:               row.PickupReminder,
+	}
+	if row.PickupAt.Valid {
+		o.PickupAt = &row.PickupAt.Time
+	}
+	return o, nil
+}
+
+func (r *PgxRepository) BookSlot(ctx context.Context, pharmacyID, orderID int64, from, to time.Time, confirmed bool, choose func(booked map[time.Time]int) (time.Time, error)) (time.Time, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := r.queries.WithTx(tx)
+	if err := q.LockPickupBookings(ctx, pharmacyID); err != nil {
+		return time.Time{}, fmt.Errorf("locking pickup bookings: %w", err)
+	}
+	booked, err := countBookings(ctx, q, pharmacyID, from, to, orderID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	at, err := choose(booked)
+	if err != nil || at.IsZero() {
+		return time.Time{}, err
+	}
+	if err := q.AssignPickupSlot(ctx, db.AssignPickupSlotParams{
+		ID:              orderID,
+		PickupAt:        dbutil.TimeToTimestamp(at),
+		PickupConfirmed: confirmed,
+	}); err != nil {
+		return time.Time{}, fmt.Errorf("assigning pickup slot: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("committing transaction: %w", err)
+	}
+	return at, nil
+}
+
+func (r *PgxRepository) ConfirmSlot(ctx context.Context, orderID int64) error {
+	if err := r.queries.ConfirmPickupSlot(ctx, orderID); err != nil {
+		return fmt.Errorf("confirming pickup slot: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) MarkNotified(ctx context.Context, orderID int64) error {
+	if err := r.queries.MarkPickupNotified(ctx, orderID); err != nil {
+		return fmt.Errorf("marking pickup notified: %w", err)
+	}
+	return nil
+}
+
+func minutesOf(t pgtype.Time) int {
+	return int(t.Microseconds / int64(time.Minute/time.Microsecond))
+}
+
+func timeOfDay(minutes int) pgtype.Time {
+	return pgtype.Time{Microseconds: int64(minutes) * int64(time.Minute/time.Microsecond), Valid: true}
+}
+
+func timestamptzPtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
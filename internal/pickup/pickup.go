@@ -0,0 +1,159 @@
+// Package pickup plans when pickup patients collect their prepared orders:
+// per-pharmacy opening hours, fixed-length slots with a capacity, and the
+// appointments booked into them.
+//
+// Slot times are local wall-clock times carried in time.Time values with the
+// UTC location, the same way dates are carried elsewhere in the application.
+// Use WallClock to convert an instant (e.g. time.Now()) before comparing.
+package pickup
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound          = errors.New("pickup.not_found")
+	ErrInvalidHours      = errors.New("pickup.invalid_hours")
+	ErrOverlappingHours  = errors.New("pickup.overlapping_hours")
+	ErrInvalidSlotLength = errors.New("pickup.invalid_slot_length")
+	ErrInvalidCapacity   = errors.New("pickup.invalid_capacity")
+	ErrNotPickupOrder    = errors.New("pickup.not_pickup_order")
+	ErrOrderNotPrepared  = errors.New("pickup.order_not_prepared")
+	ErrSlotUnavailable   = errors.New("pickup.slot_unavailable")
+	ErrNotifyFailed      = errors.New("pickup.notify_failed")
+	ErrNoContact         = errors.New("pickup.no_contact")
+	ErrNoPickupSlot      = errors.New("pickup.no_pickup_slot")
+	ErrOrderCollected    = errors.New("pickup.order_collected")
+	ErrNotLater          = errors.New("pickup.not_later")
+)
+
+// Slot configuration defaults and bounds.
+const (
+	DefaultSlotMinutes  = 30
+	DefaultSlotCapacity = 4
+	MinSlotMinutes      = 5
+	MaxSlotMinutes      = 240
+)
+
+// SearchDays is how far ahead slots are offered and suggested.
+const SearchDays = 14
+
+// Period is one opening interval on a weekday, in minutes from midnight.
+// A day may have several periods (e.g. morning and afternoon).
+type Period struct {
+	Weekday time.Weekday
+	Opens   int
+	Closes  int
+}
+
+// Settings controls how opening hours are cut into slots.
+type Settings struct {
+	SlotMinutes   int
+	SlotCapacity  int
+	DailyCapacity int // 0 means no daily limit
+}
+
+// DefaultSettings returns the settings used until an owner saves their own.
+func DefaultSettings() Settings {
+	return Settings{SlotMinutes: DefaultSlotMinutes, SlotCapacity: DefaultSlotCapacity}
+}
+
+// Schedule is a pharmacy's pickup configuration.
+type Schedule struct {
+	Hours    []Period
+	Settings Settings
+	// Closed reports holidays and other closures on top of the weekly
+	// hours; nil means none.
+	Closed func(time.Time) bool
+}
+
+// Slot is a bookable pickup interval and how many pickups it already holds.
+type Slot struct {
+	Start    time.Time
+	End      time.Time
+	Capacity int
+	Booked   int
+}
+
+// Free returns how many more pickups fit in the slot.
+func (s Slot) Free() int {
+	return max(0, s.Capacity-s.Booked)
+}
+
+// Appointment is an order with a pickup time, as shown in the day view.
+type Appointment struct {
+	OrderID        int64
+	PatientID      int64
+	At             time.Time
+	Confirmed      bool       // false while the time is only a suggestion
+	NotifiedAt     *time.Time // nil until the patient has been told
+	OrderStatus    string
+	MedicationName string
+	FirstName      string
+	LastName       string
+	Phone          string
+	Email          string
+}
+
+// Order is the subset of an order needed to schedule its pickup.
+type Order struct {
+	ID                     int64
+	Status                 string
+	Fulfillment            string
+	EstimatedDepletionDate time.Time
+	PickupAt               *time.Time
+	Confirmed              bool
+	MedicationName         string
+	FirstName              string
+	LastName               string
+	Phone                  string
+	Email                  string
+	PharmacyName           string
+	// Reminder is the pharmacy's pickup reminder text, empty for the default.
+	Reminder string
+}
+
+// Notice is what the patient is told when a slot is assigned.
+type Notice struct {
+	PharmacyID     int64
+	PharmacyName   string
+	OrderID        int64
+	At             time.Time
+	MedicationName string
+	FirstName      string
+	LastName       string
+	Phone          string
+	Email          string
+	// Reminder is the pharmacy's text with placeholders, empty for the
+	// default one.
+	Reminder string
+}
+
+// DaySlot is a slot of the day view with the appointments booked in it.
+type DaySlot struct {
+	Slot
+	Appointments []Appointment
+}
+
+// Day is the day view: every slot of the date plus appointments whose time no
+// longer matches a slot (e.g. after the opening hours changed).
+type Day struct {
+	Date     time.Time
+	Slots    []DaySlot
+	Other    []Appointment
+	Booked   int
+	Capacity int // 0 when the pharmacy is closed
+}
+
+// Open reports whether the pharmacy has opening hours on the day.
+func (d Day) Open() bool {
+	return len(d.Slots) > 0
+}
+
+// WallClock returns t's local wall-clock time carried in UTC, the
+// representation used for slot times.
+func WallClock(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
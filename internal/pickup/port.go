@@ -0,0 +1,78 @@
+package pickup
+
+import (
+	"context"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+)
+
+// ScheduleGetter loads a pharmacy's schedule, falling back to DefaultSettings
+// when none was saved.
+type ScheduleGetter interface {
+	GetSchedule(ctx context.Context, pharmacyID int64) (Schedule, error)
+}
+
+// ScheduleSaver replaces a pharmacy's opening hours and slot settings in a transaction.
+type ScheduleSaver interface {
+	SaveSchedule(ctx context.Context, pharmacyID int64, s Schedule) error
+}
+
+// BookingCounter counts active pickups per slot start in [from, to), ignoring
+// excludeOrderID (0 to count all).
+type BookingCounter interface {
+	CountBookings(ctx context.Context, pharmacyID int64, from, to time.Time, excludeOrderID int64) (map[time.Time]int, error)
+}
+
+// AppointmentLister lists orders with a pickup time in [from, to).
+type AppointmentLister interface {
+	ListAppointments(ctx context.Context, pharmacyID int64, from, to time.Time) ([]Appointment, error)
+}
+
+// OrderGetter gets an order of the pharmacy for scheduling.
+type OrderGetter interface {
+	GetOrder(ctx context.Context, pharmacyID, orderID int64) (Order, error)
+}
+
+// SlotAssigner books pickup times one at a time per pharmacy: BookSlot
+// holds the pharmacy's booking lock while it counts the active bookings in
+// [from, to), except the order's own, lets choose pick a time from them and
+// stores it, so two bookings cannot both take a slot's last place. choose's
+// error is returned as is; a zero time books nothing. confirmed marks the
+// time as agreed with the patient.
+type SlotAssigner interface {
+	BookSlot(ctx context.Context, pharmacyID, orderID int64, from, to time.Time, confirmed bool, choose func(booked map[time.Time]int) (time.Time, error)) (time.Time, error)
+}
+
+// SlotConfirmer marks the order's current pickup time as agreed.
+type SlotConfirmer interface {
+	ConfirmSlot(ctx context.Context, orderID int64) error
+}
+
+// NotifiedMarker records that the patient was told about their pickup.
+type NotifiedMarker interface {
+	MarkNotified(ctx context.Context, orderID int64) error
+}
+
+// Notifier tells a patient when to collect their order.
+type Notifier interface {
+	NotifyPickup(ctx context.Context, n Notice) error
+}
+
+// ClosureCalendar returns the pharmacy's closure calendar; no slots are
+// offered on closed days.
+type ClosureCalendar interface {
+	Calendar(ctx context.Context, pharmacyID int64) (calendar.Calendar, error)
+}
+
+// Repository composes all ports — used only by NewService for convenient wiring.
+type Repository interface {
+	ScheduleGetter
+	ScheduleSaver
+	BookingCounter
+	AppointmentLister
+	OrderGetter
+	SlotAssigner
+	SlotConfirmer
+	NotifiedMarker
+}
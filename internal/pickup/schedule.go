@@ -0,0 +1,162 @@
+package pickup
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Validate checks the opening hours and slot settings.
+func (s Schedule) Validate() error {
+	if s.Settings.SlotMinutes < MinSlotMinutes || s.Settings.SlotMinutes > MaxSlotMinutes {
+		return ErrInvalidSlotLength
+	}
+	if s.Settings.SlotCapacity < 1 || s.Settings.DailyCapacity < 0 {
+		return ErrInvalidCapacity
+	}
+	for _, p := range s.Hours {
+		if p.Weekday < time.Sunday || p.Weekday > time.Saturday ||
+			p.Opens < 0 || p.Closes > 24*60 || p.Closes <= p.Opens {
+			return ErrInvalidHours
+		}
+	}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		periods := s.periods(wd)
+		for i := 1; i < len(periods); i++ {
+			if periods[i].Opens < periods[i-1].Closes {
+				return ErrOverlappingHours
+			}
+		}
+	}
+	return nil
+}
+
+// periods returns the opening periods of a weekday sorted by opening time.
+func (s Schedule) periods(wd time.Weekday) []Period {
+	var out []Period
+	for _, p := range s.Hours {
+		if p.Weekday == wd {
+			out = append(out, p)
+		}
+	}
+	slices.SortFunc(out, func(a, b Period) int { return a.Opens - b.Opens })
+	return out
+}
+
+// Slots returns the slots of date (any time of day is ignored), none on
+// closed days. Slots never cross the end of an opening period: a trailing
+// remainder shorter than the slot length is dropped.
+func (s Schedule) Slots(date time.Time) []Slot {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	length := s.Settings.SlotMinutes
+	if length <= 0 || (s.Closed != nil && s.Closed(day)) {
+		return nil
+	}
+	var slots []Slot
+	for _, p := range s.periods(day.Weekday()) {
+		for m := p.Opens; m+length <= p.Closes; m += length {
+			start := day.Add(time.Duration(m) * time.Minute)
+			slots = append(slots, Slot{
+				Start:    start,
+				End:      start.Add(time.Duration(length) * time.Minute),
+				Capacity: s.Settings.SlotCapacity,
+			})
+		}
+	}
+	return slots
+}
+
+// BookedSlots returns the slots of date with their bookings filled in from
+// booked, keyed by slot start.
+func (s Schedule) BookedSlots(date time.Time, booked map[time.Time]int) []Slot {
+	slots := s.Slots(date)
+	for i := range slots {
+		slots[i].Booked = booked[slots[i].Start]
+	}
+	return slots
+}
+
+// dayFull reports whether the daily capacity is exhausted.
+func (s Schedule) dayFull(slots []Slot) bool {
+	if s.Settings.DailyCapacity == 0 {
+		return false
+	}
+	total := 0
+	for _, sl := range slots {
+		total += sl.Booked
+	}
+	return total >= s.Settings.DailyCapacity
+}
+
+// Available returns the free slots of date that start after now.
+func (s Schedule) Available(date time.Time, booked map[time.Time]int, now time.Time) []Slot {
+	slots := s.BookedSlots(date, booked)
+	if s.dayFull(slots) {
+		return nil
+	}
+	var out []Slot
+	for _, sl := range slots {
+		if sl.Start.After(now) && sl.Free() > 0 {
+			out = append(out, sl)
+		}
+	}
+	return out
+}
+
+// Suggest picks a pickup slot for an order whose supply runs out on
+// depletion. It prefers the latest open day strictly before depletion, from
+// tomorrow onwards, so the patient collects just in time; within the day it
+// picks the least loaded slot, earliest first. When no such day has room it
+// falls back to the first free slot from depletion onwards, up to SearchDays
+// after today.
+func (s Schedule) Suggest(booked map[time.Time]int, today, depletion time.Time) (time.Time, bool) {
+	first := dateOf(today).AddDate(0, 0, 1)
+	last := dateOf(depletion).AddDate(0, 0, -1)
+	for d := last; !d.Before(first); d = d.AddDate(0, 0, -1) {
+		if sl, ok := leastLoaded(s.Available(d, booked, time.Time{})); ok {
+			return sl.Start, true
+		}
+	}
+	horizon := dateOf(today).AddDate(0, 0, SearchDays)
+	for d := later(first, dateOf(depletion)); d.Before(horizon); d = d.AddDate(0, 0, 1) {
+		if sl, ok := leastLoaded(s.Available(d, booked, time.Time{})); ok {
+			return sl.Start, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Check returns ErrSlotUnavailable unless at is the start of a slot that
+// starts after now and still has room.
+func (s Schedule) Check(at time.Time, booked map[time.Time]int, now time.Time) error {
+	for _, sl := range s.Available(at, booked, now) {
+		if sl.Start.Equal(at) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %w", at.Format("2006-01-02 15:04"), ErrSlotUnavailable)
+}
+
+func leastLoaded(slots []Slot) (Slot, bool) {
+	if len(slots) == 0 {
+		return Slot{}, false
+	}
+	best := slots[0]
+	for _, sl := range slots[1:] {
+		if sl.Booked < best.Booked {
+			best = sl
+		}
+	}
+	return best, true
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,255 @@
+package pickup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+)
+
+func at(d, h, m int) time.Time {
+	return time.Date(2026, 1, d, h, m, 0, 0, time.UTC)
+}
+
+// weekSchedule opens Monday to Friday 9–12 and 15–19, Saturday 9–12,
+// with 30-minute slots of two pickups each.
+func weekSchedule() pickup.Schedule {
+	var hours []pickup.Period
+	for wd := time.Monday; wd <= time.Friday; wd++ {
+		hours = append(hours,
+			pickup.Period{Weekday: wd, Opens: 15 * 60, Closes: 19 * 60},
+			pickup.Period{Weekday: wd, Opens: 9 * 60, Closes: 12 * 60},
+		)
+	}
+	hours = append(hours, pickup.Period{Weekday: time.Saturday, Opens: 9 * 60, Closes: 12 * 60})
+	return pickup.Schedule{Hours: hours, Settings: pickup.Settings{SlotMinutes: 30, SlotCapacity: 2}}
+}
+
+func TestSlots(t *testing.T) {
+	s := weekSchedule()
+
+	monday := s.Slots(at(26, 0, 0))
+	if len(monday) != 14 {
+		t.Fatalf("Monday slots = %d, want 14", len(monday))
+	}
+	if !monday[0].Start.Equal(at(26, 9, 0)) || !monday[0].End.Equal(at(26, 9, 30)) {
+		t.Errorf("first slot = %s–%s, want 09:00–09:30", monday[0].Start.Format("15:04"), monday[0].End.Format("15:04"))
+	}
+	if !monday[6].Start.Equal(at(26, 15, 0)) {
+		t.Errorf("first afternoon slot = %s, want 15:00", monday[6].Start.Format("15:04"))
+	}
+	if got := s.Slots(at(25, 10, 0)); len(got) != 0 {
+		t.Errorf("Sunday slots = %d, want 0", len(got))
+	}
+}
+
+func TestSlotsClosedDay(t *testing.T) {
+	s := weekSchedule()
+	s.Closed = func(d time.Time) bool { return d.Equal(at(30, 0, 0)) }
+	if got := s.Slots(at(30, 0, 0)); len(got) != 0 {
+		t.Errorf("closed Friday slots = %d, want 0", len(got))
+	}
+	got, ok := s.Suggest(nil, at(27, 0, 0), at(31, 0, 0))
+	if !ok || !got.Equal(at(29, 9, 0)) {
+		t.Errorf("Suggest() = %s, want Thursday 09:00", got.Format("2006-01-02 15:04"))
+	}
+}
+
+func TestSlotsDropShortRemainder(t *testing.T) {
+	s := pickup.Schedule{
+		Hours:    []pickup.Period{{Weekday: time.Monday, Opens: 9 * 60, Closes: 10*60 + 45}},
+		Settings: pickup.Settings{SlotMinutes: 30, SlotCapacity: 1},
+	}
+	slots := s.Slots(at(26, 0, 0))
+	if len(slots) != 3 {
+		t.Fatalf("slots = %d, want 3", len(slots))
+	}
+	if !slots[2].End.Equal(at(26, 10, 30)) {
+		t.Errorf("last slot ends %s, want 10:30", slots[2].End.Format("15:04"))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*pickup.Schedule)
+		want   error
+	}{
+		{"valid", func(*pickup.Schedule) {}, nil},
+		{"no hours", func(s *pickup.Schedule) { s.Hours = nil }, nil},
+		{"closes before opens", func(s *pickup.Schedule) {
+			s.Hours = []pickup.Period{{Weekday: time.Monday, Opens: 600, Closes: 540}}
+		}, pickup.ErrInvalidHours},
+		{"past midnight", func(s *pickup.Schedule) {
+			s.Hours = []pickup.Period{{Weekday: time.Monday, Opens: 600, Closes: 24*60 + 1}}
+		}, pickup.ErrInvalidHours},
+		{"overlap", func(s *pickup.Schedule) {
+			s.Hours = append(s.Hours, pickup.Period{Weekday: time.Tuesday, Opens: 11 * 60, Closes: 16 * 60})
+		}, pickup.ErrOverlappingHours},
+		{"slot too short", func(s *pickup.Schedule) { s.Settings.SlotMinutes = 2 }, pickup.ErrInvalidSlotLength},
+		{"slot too long", func(s *pickup.Schedule) { s.Settings.SlotMinutes = 300 }, pickup.ErrInvalidSlotLength},
+		{"zero capacity", func(s *pickup.Schedule) { s.Settings.SlotCapacity = 0 }, pickup.ErrInvalidCapacity},
+		{"negative daily capacity", func(s *pickup.Schedule) { s.Settings.DailyCapacity = -1 }, pickup.ErrInvalidCapacity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := weekSchedule()
+			tt.modify(&s)
+			if err := s.Validate(); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name      string
+		daily     int
+		booked    map[time.Time]int
+		today     time.Time
+		depletion time.Time
+		want      time.Time
+	}{
+		{
+			name:  "latest open day before depletion",
+			today: at(27, 0, 0), depletion: at(31, 0, 0), // Tue → Sat
+			want: at(30, 9, 0),
+		},
+		{
+			name:   "least loaded slot",
+			booked: map[time.Time]int{at(30, 9, 0): 1},
+			today:  at(27, 0, 0), depletion: at(31, 0, 0),
+			want: at(30, 9, 30),
+		},
+		{
+			name:  "skips closed day",
+			today: at(27, 0, 0), depletion: time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC), // day before is Sunday
+			want: at(31, 9, 0),
+		},
+		{
+			name:   "daily capacity reached",
+			daily:  1,
+			booked: map[time.Time]int{at(30, 15, 0): 1},
+			today:  at(27, 0, 0), depletion: at(31, 0, 0),
+			want: at(29, 9, 0),
+		},
+		{
+			name:  "never today",
+			today: at(29, 8, 0), depletion: at(30, 0, 0),
+			want: at(30, 9, 0),
+		},
+		{
+			name:  "already depleted falls back to first free slot",
+			today: time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC), depletion: at(31, 0, 0),
+			want: time.Date(2026, 2, 3, 9, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := weekSchedule()
+			s.Settings.DailyCapacity = tt.daily
+			got, ok := s.Suggest(tt.booked, tt.today, tt.depletion)
+			if !ok {
+				t.Fatal("expected a suggestion")
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Suggest() = %s, want %s", got.Format("2006-01-02 15:04"), tt.want.Format("2006-01-02 15:04"))
+			}
+		})
+	}
+}
+
+func TestSuggestWithoutOpeningHours(t *testing.T) {
+	s := pickup.Schedule{Settings: pickup.DefaultSettings()}
+	if _, ok := s.Suggest(nil, at(27, 0, 0), at(31, 0, 0)); ok {
+		t.Error("expected no suggestion without opening hours")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := weekSchedule()
+	now := at(27, 10, 5)
+	booked := map[time.Time]int{at(28, 9, 0): 2}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want error
+	}{
+		{"free slot", at(28, 9, 30), nil},
+		{"later today", at(27, 10, 30), nil},
+		{"already started", at(27, 10, 0), pickup.ErrSlotUnavailable},
+		{"full", at(28, 9, 0), pickup.ErrSlotUnavailable},
+		{"not a slot start", at(28, 9, 10), pickup.ErrSlotUnavailable},
+		{"closed", at(25, 10, 0), pickup.ErrSlotUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Check(tt.at, booked, now); !errors.Is(err, tt.want) {
+				t.Errorf("Check() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	got := pickup.Message(pickup.Notice{FirstName: "Mario", LastName: "Rossi", MedicationName: "Eutirox", At: at(30, 9, 30)})
+	want := "Gentile Mario Rossi, il suo Eutirox è pronto per il ritiro. La aspettiamo in farmacia il 30/01/2026 alle 09:30."
+	if got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+}
+
+func TestMessageUsesPharmacyReminder(t *testing.T) {
+	got := pickup.Message(pickup.Notice{
+		FirstName: "Mario", MedicationName: "Eutirox", PharmacyName: "Farmacia Centrale", At: at(30, 9, 30),
+		Reminder: "{farmacia}: {nome}, {farmaco} la aspetta il {data} alle {ora}.",
+	})
+	want := "Farmacia Centrale: Mario, Eutirox la aspetta il 30/01/2026 alle 09:30."
+	if got != want {
+		t.Errorf("Message() = %q, want %q", got, want)
+	}
+}
+
+type recordingSender struct{ to, subject, text string }
+
+func (r *recordingSender) Send(_ context.Context, to, text string) error {
+	r.to, r.text = to, text
+	return nil
+}
+
+type recordingEmail struct{ recordingSender }
+
+func (r *recordingEmail) Send(_ context.Context, to, subject, body string) error {
+	r.to, r.subject, r.text = to, subject, body
+	return nil
+}
+
+func TestGatewayNotifier(t *testing.T) {
+	email, text := &recordingEmail{}, &recordingSender{}
+	g := pickup.GatewayNotifier{Email: email, SMS: text}
+
+	n := pickup.Notice{FirstName: "Mario", MedicationName: "Eutirox", PharmacyName: "Farmacia Centrale", At: at(30, 9, 30), Phone: "333 123 4567", Email: "mario@example.it"}
+	if err := g.NotifyPickup(context.Background(), n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text.to != "+393331234567" || text.text != pickup.Message(n) || email.to != "" {
+		t.Errorf("text = %+v email = %+v, want only a text to the phone", text, email)
+	}
+
+	n.Phone = ""
+	if err := g.NotifyPickup(context.Background(), n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if email.to != "mario@example.it" || email.text != pickup.Message(n) {
+		t.Errorf("email = %+v, want the message emailed without a phone", email)
+	}
+
+	n.Email = ""
+	if err := g.NotifyPickup(context.Background(), n); !errors.Is(err, pickup.ErrNoContact) {
+		t.Errorf("error = %v, want ErrNoContact", err)
+	}
+}
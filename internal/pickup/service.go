@@ -0,0 +1,312 @@
+package pickup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+)
+
+// ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
+type ServiceDeps struct {
+	Getter       ScheduleGetter
+	Saver        ScheduleSaver
+	Counter      BookingCounter
+	Appointments AppointmentLister
+	Orders       OrderGetter
+	Assigner     SlotAssigner
+	Confirmer    SlotConfirmer
+	Notified     NotifiedMarker
+	Notifier     Notifier
+	Calendar     ClosureCalendar
+}
+
+// Service contains pickup scheduling business logic.
+type Service struct {
+	deps ServiceDeps
+}
+
+// NewService is the production constructor — takes a Repository (satisfies all ports),
+// the Notifier used to tell patients about their pickup and the closure calendar.
+func NewService(repo Repository, notifier Notifier, cal ClosureCalendar) *Service {
+	return &Service{deps: ServiceDeps{
+		Getter:       repo,
+		Saver:        repo,
+		Counter:      repo,
+		Appointments: repo,
+		Orders:       repo,
+		Assigner:     repo,
+		Confirmer:    repo,
+		Notified:     repo,
+		Notifier:     notifier,
+		Calendar:     cal,
+	}}
+}
+
+// NewServiceWith is the test constructor — inject only what you need, rest stays nil.
+func NewServiceWith(d ServiceDeps) *Service {
+	return &Service{deps: d}
+}
+
+// Schedule returns the pharmacy's opening hours and slot settings.
+func (s *Service) Schedule(ctx context.Context, pharmacyID int64) (Schedule, error) {
+	sch, err := s.deps.Getter.GetSchedule(ctx, pharmacyID)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("getting pickup schedule: %w", err)
+	}
+	return sch, nil
+}
+
+// bookable returns the schedule with the pharmacy's closures applied.
+func (s *Service) bookable(ctx context.Context, pharmacyID int64) (Schedule, error) {
+	sch, err := s.Schedule(ctx, pharmacyID)
+	if err != nil || s.deps.Calendar == nil {
+		return sch, err
+	}
+	cal, err := s.deps.Calendar.Calendar(ctx, pharmacyID)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("getting closure calendar: %w", err)
+	}
+	sch.Closed = cal.IsClosed
+	return sch, nil
+}
+
+// SaveSchedule validates and replaces the pharmacy's schedule.
+// Existing appointments are kept even if they no longer match a slot.
+func (s *Service) SaveSchedule(ctx context.Context, pharmacyID int64, sch Schedule) error {
+	if err := permission.Check(ctx, permission.ManageSchedule); err != nil {
+		return err
+	}
+	if err := sch.Validate(); err != nil {
+		return err
+	}
+	if err := s.deps.Saver.SaveSchedule(ctx, pharmacyID, sch); err != nil {
+		return fmt.Errorf("saving pickup schedule: %w", err)
+	}
+	return nil
+}
+
+// Day returns the day view for date: each slot with its appointments.
+func (s *Service) Day(ctx context.Context, pharmacyID int64, date time.Time) (Day, error) {
+	sch, err := s.bookable(ctx, pharmacyID)
+	if err != nil {
+		return Day{}, err
+	}
+	date = dateOf(date)
+	appts, err := s.deps.Appointments.ListAppointments(ctx, pharmacyID, date, date.AddDate(0, 0, 1))
+	if err != nil {
+		return Day{}, fmt.Errorf("listing pickup appointments: %w", err)
+	}
+
+	day := Day{Date: date}
+	index := make(map[time.Time]int)
+	for _, sl := range sch.Slots(date) {
+		index[sl.Start] = len(day.Slots)
+		day.Slots = append(day.Slots, DaySlot{Slot: sl})
+		day.Capacity += sl.Capacity
+	}
+	if sch.Settings.DailyCapacity > 0 && day.Open() {
+		day.Capacity = min(day.Capacity, sch.Settings.DailyCapacity)
+	}
+	for _, a := range appts {
+		if a.OrderStatus != order.StatusFulfilled {
+			day.Booked++
+		}
+		i, ok := index[a.At]
+		if !ok {
+			day.Other = append(day.Other, a)
+			continue
+		}
+		day.Slots[i].Appointments = append(day.Slots[i].Appointments, a)
+		if a.OrderStatus != order.StatusFulfilled {
+			day.Slots[i].Booked++
+		}
+	}
+	return day, nil
+}
+
+// GetOrder returns an order of the pharmacy with its pickup details.
+func (s *Service) GetOrder(ctx context.Context, pharmacyID, orderID int64) (Order, error) {
+	o, err := s.deps.Orders.GetOrder(ctx, pharmacyID, orderID)
+	if err != nil {
+		return Order{}, fmt.Errorf("getting pickup order: %w", err)
+	}
+	return o, nil
+}
+
+// AvailableSlots returns the free slots from now up to SearchDays ahead for
+// the order. The order's own current booking does not take up room.
+func (s *Service) AvailableSlots(ctx context.Context, pharmacyID, orderID int64, now time.Time) ([]Slot, error) {
+	sch, err := s.bookable(ctx, pharmacyID)
+	if err != nil {
+		return nil, err
+	}
+	from := dateOf(now)
+	to := from.AddDate(0, 0, SearchDays)
+	booked, err := s.deps.Counter.CountBookings(ctx, pharmacyID, from, to, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("counting pickup bookings: %w", err)
+	}
+	var slots []Slot
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		slots = append(slots, sch.Available(d, booked, now)...)
+	}
+	return slots, nil
+}
+
+// Assign books a prepared pickup order into the slot starting at at.
+// When notify is set the patient is told through the Notifier; if that
+// fails the booking stands and ErrNotifyFailed is returned.
+func (s *Service) Assign(ctx context.Context, pharmacyID, orderID int64, at time.Time, notify bool, now time.Time) error {
+	if err := permission.Check(ctx, permission.AssignPickups); err != nil {
+		return err
+	}
+	o, err := s.GetOrder(ctx, pharmacyID, orderID)
+	if err != nil {
+		return err
+	}
+	if o.Fulfillment != patient.FulfillmentPickup {
+		return ErrNotPickupOrder
+	}
+	if o.Status != order.StatusPrepared {
+		return ErrOrderNotPrepared
+	}
+
+	if err := s.book(ctx, pharmacyID, orderID, at, now); err != nil {
+		return err
+	}
+	if !notify {
+		return nil
+	}
+
+	if err := s.deps.Notifier.NotifyPickup(ctx, Notice{
+		PharmacyID:     pharmacyID,
+		PharmacyName:   o.PharmacyName,
+		OrderID:        orderID,
+		At:             at,
+		MedicationName: o.MedicationName,
+		FirstName:      o.FirstName,
+		LastName:       o.LastName,
+		Phone:          o.Phone,
+		Email:          o.Email,
+		Reminder:       o.Reminder,
+	}); err != nil {
+		return errors.Join(ErrNotifyFailed, err)
+	}
+	if err := s.deps.Notified.MarkNotified(ctx, orderID); err != nil {
+		return fmt.Errorf("marking pickup notified: %w", err)
+	}
+	return nil
+}
+
+// Confirm records that the patient agreed to the order's current pickup time,
+// typically one suggested when the order was created.
+func (s *Service) Confirm(ctx context.Context, pharmacyID, orderID int64) error {
+	o, err := s.openPickupOrder(ctx, pharmacyID, orderID)
+	if err != nil {
+		return err
+	}
+	if o.PickupAt == nil {
+		return ErrNoPickupSlot
+	}
+	if err := s.deps.Confirmer.ConfirmSlot(ctx, orderID); err != nil {
+		return fmt.Errorf("confirming pickup slot: %w", err)
+	}
+	return nil
+}
+
+// PostponeSlots returns the free slots later than the order's current pickup time.
+func (s *Service) PostponeSlots(ctx context.Context, pharmacyID, orderID int64, now time.Time) ([]Slot, error) {
+	o, err := s.openPickupOrder(ctx, pharmacyID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := s.AvailableSlots(ctx, pharmacyID, orderID, now)
+	if err != nil {
+		return nil, err
+	}
+	if o.PickupAt == nil {
+		return slots, nil
+	}
+	var later []Slot
+	for _, sl := range slots {
+		if sl.Start.After(*o.PickupAt) {
+			later = append(later, sl)
+		}
+	}
+	return later, nil
+}
+
+// Postpone moves an open pickup order to a later free slot chosen by the
+// patient. Unlike Assign it does not require the order to be prepared,
+// and the patient is not notified since they picked the time themselves.
+func (s *Service) Postpone(ctx context.Context, pharmacyID, orderID int64, at, now time.Time) error {
+	o, err := s.openPickupOrder(ctx, pharmacyID, orderID)
+	if err != nil {
+		return err
+	}
+	if o.PickupAt != nil && !at.After(*o.PickupAt) {
+		return ErrNotLater
+	}
+
+	return s.book(ctx, pharmacyID, orderID, at, now)
+}
+
+// book confirms the slot starting at at for the order, checking its room
+// against the bookings counted under the pharmacy's booking lock.
+func (s *Service) book(ctx context.Context, pharmacyID, orderID int64, at, now time.Time) error {
+	sch, err := s.bookable(ctx, pharmacyID)
+	if err != nil {
+		return err
+	}
+	day := dateOf(at)
+	_, err = s.deps.Assigner.BookSlot(ctx, pharmacyID, orderID, day, day.AddDate(0, 0, 1), true, func(booked map[time.Time]int) (time.Time, error) {
+		return at, sch.Check(at, booked, now)
+	})
+	if errors.Is(err, ErrSlotUnavailable) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("assigning pickup slot: %w", err)
+	}
+	return nil
+}
+
+// openPickupOrder loads a pickup order that has not been collected yet.
+func (s *Service) openPickupOrder(ctx context.Context, pharmacyID, orderID int64) (Order, error) {
+	o, err := s.GetOrder(ctx, pharmacyID, orderID)
+	if err != nil {
+		return Order{}, err
+	}
+	if o.Fulfillment != patient.FulfillmentPickup {
+		return Order{}, ErrNotPickupOrder
+	}
+	if o.Status == order.StatusFulfilled {
+		return Order{}, ErrOrderCollected
+	}
+	return o, nil
+}
+
+// SuggestSlot books a suggested, not yet confirmed, pickup slot before
+// depletion for a new order; see Schedule.Suggest. It reports false when no
+// slot is free.
+func (s *Service) SuggestSlot(ctx context.Context, pharmacyID, orderID int64, today, depletion time.Time) (time.Time, bool, error) {
+	sch, err := s.bookable(ctx, pharmacyID)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	from := dateOf(today)
+	to := later(from.AddDate(0, 0, SearchDays), dateOf(depletion))
+	at, err := s.deps.Assigner.BookSlot(ctx, pharmacyID, orderID, from, to, false, func(booked map[time.Time]int) (time.Time, error) {
+		at, _ := sch.Suggest(booked, today, depletion)
+		return at, nil
+	})
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("suggesting pickup slot: %w", err)
+	}
+	return at, !at.IsZero(), nil
+}
@@ -0,0 +1,412 @@
+package pickup_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+)
+
+// --- Mocks ---
+
+type mockScheduleGetter struct {
+	schedule pickup.Schedule
+}
+
+func (m *mockScheduleGetter) GetSchedule(_ context.Context, _ int64) (pickup.Schedule, error) {
+	return m.schedule, nil
+}
+
+type mockScheduleSaver struct {
+	called bool
+}
+
+func (m *mockScheduleSaver) SaveSchedule(_ context.Context, _ int64, _ pickup.Schedule) error {
+	m.called = true
+	return nil
+}
+
+type mockCounter struct {
+	booked  map[time.Time]int
+	exclude int64
+}
+
+func (m *mockCounter) CountBookings(_ context.Context, _ int64, _, _ time.Time, excludeOrderID int64) (map[time.Time]int, error) {
+	m.exclude = excludeOrderID
+	return m.booked, nil
+}
+
+type mockAppointments struct {
+	result []pickup.Appointment
+}
+
+func (m *mockAppointments) ListAppointments(_ context.Context, _ int64, _, _ time.Time) ([]pickup.Appointment, error) {
+	return m.result, nil
+}
+
+type mockOrderGetter struct {
+	result pickup.Order
+	err    error
+}
+
+func (m *mockOrderGetter) GetOrder(_ context.Context, _, _ int64) (pickup.Order, error) {
+	return m.result, m.err
+}
+
+// mockAssigner books like the repository: choose sees booked, counted
+// without the order's own booking.
+type mockAssigner struct {
+	booked    map[time.Time]int
+	exclude   int64
+	orderID   int64
+	at        time.Time
+	confirmed bool
+}
+
+func (m *mockAssigner) BookSlot(_ context.Context, _, orderID int64, _, _ time.Time, confirmed bool, choose func(map[time.Time]int) (time.Time, error)) (time.Time, error) {
+	m.exclude = orderID
+	at, err := choose(m.booked)
+	if err != nil || at.IsZero() {
+		return time.Time{}, err
+	}
+	m.orderID, m.at, m.confirmed = orderID, at, confirmed
+	return at, nil
+}
+
+type mockConfirmer struct {
+	orderID int64
+}
+
+func (m *mockConfirmer) ConfirmSlot(_ context.Context, orderID int64) error {
+	m.orderID = orderID
+	return nil
+}
+
+type mockNotified struct {
+	called bool
+}
+
+func (m *mockNotified) MarkNotified(_ context.Context, _ int64) error {
+	m.called = true
+	return nil
+}
+
+type mockNotifier struct {
+	notice pickup.Notice
+	err    error
+}
+
+func (m *mockNotifier) NotifyPickup(_ context.Context, n pickup.Notice) error {
+	m.notice = n
+	return m.err
+}
+
+func preparedPickupOrder() pickup.Order {
+	return pickup.Order{ID: 7, Status: "prepared", Fulfillment: "pickup", MedicationName: "Eutirox", FirstName: "Mario", LastName: "Rossi", Phone: "333"}
+}
+
+// --- Assign tests ---
+
+func TestAssignNotifiesPatient(t *testing.T) {
+	assigner := &mockAssigner{}
+	notifier := &mockNotifier{}
+	notified := &mockNotified{}
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Getter:   &mockScheduleGetter{schedule: weekSchedule()},
+		Orders:   &mockOrderGetter{result: preparedPickupOrder()},
+		Assigner: assigner,
+		Notified: notified,
+		Notifier: notifier,
+	})
+
+	err := svc.Assign(permission.WithSystem(context.Background()), 1, 7, at(28, 9, 30), true, at(27, 10, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assigner.orderID != 7 || !assigner.at.Equal(at(28, 9, 30)) {
+		t.Errorf("assigned order %d at %s", assigner.orderID, assigner.at)
+	}
+	if assigner.exclude != 7 || !assigner.confirmed {
+		t.Errorf("booking excluded order %d, confirmed %v; want 7, true", assigner.exclude, assigner.confirmed)
+	}
+	if notifier.notice.OrderID != 7 || notifier.notice.FirstName != "Mario" || !notifier.notice.At.Equal(at(28, 9, 30)) {
+		t.Errorf("unexpected notice %+v", notifier.notice)
+	}
+	if !notified.called {
+		t.Error("expected order to be marked notified")
+	}
+}
+
+func TestAssignWithoutNotify(t *testing.T) {
+	notifier := &mockNotifier{}
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Getter:   &mockScheduleGetter{schedule: weekSchedule()},
+		Counter:  &mockCounter{},
+		Orders:   &mockOrderGetter{result: preparedPickupOrder()},
+		Assigner: &mockAssigner{},
+		Notifier: notifier,
+	})
+
+	if err := svc.Assign(permission.WithSystem(context.Background()), 1, 7, at(28, 9, 30), false, at(27, 10, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if notifier.notice.OrderID != 0 {
+		t.Error("expected no notification")
+	}
+}
+
+func TestAssignNotifyFailureKeepsBooking(t *testing.T) {
+	assigner := &mockAssigner{}
+	notified := &mockNotified{}
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Getter:   &mockScheduleGetter{schedule: weekSchedule()},
+		Counter:  &mockCounter{},
+		Orders:   &mockOrderGetter{result: preparedPickupOrder()},
+		Assigner: assigner,
+		Notified: notified,
+		Notifier: &mockNotifier{err: errors.New("gateway down")},
+	})
+
+	err := svc.Assign(permission.WithSystem(context.Background()), 1, 7, at(28, 9, 30), true, at(27, 10, 0))
+	if !errors.Is(err, pickup.ErrNotifyFailed) {
+		t.Fatalf("expected ErrNotifyFailed, got %v", err)
+	}
+	if assigner.orderID != 7 {
+		t.Error("expected slot to be assigned anyway")
+	}
+	if notified.called {
+		t.Error("expected order not to be marked notified")
+	}
+}
+
+func TestAssignRejected(t *testing.T) {
+	pending := preparedPickupOrder()
+	pending.Status = "pending"
+	shipping := preparedPickupOrder()
+	shipping.Fulfillment = "shipping"
+
+	tests := []struct {
+		name   string
+		order  pickup.Order
+		booked map[time.Time]int
+		want   error
+	}{
+		{"not prepared", pending, nil, pickup.ErrOrderNotPrepared},
+		{"shipping order", shipping, nil, pickup.ErrNotPickupOrder},
+		{"slot full", preparedPickupOrder(), map[time.Time]int{at(28, 9, 30): 2}, pickup.ErrSlotUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assigner := &mockAssigner{booked: tt.booked}
+			svc := pickup.NewServiceWith(pickup.ServiceDeps{
+				Getter:   &mockScheduleGetter{schedule: weekSchedule()},
+				Orders:   &mockOrderGetter{result: tt.order},
+				Assigner: assigner,
+			})
+			err := svc.Assign(permission.WithSystem(context.Background()), 1, 7, at(28, 9, 30), false, at(27, 10, 0))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if assigner.orderID != 0 {
+				t.Error("expected no assignment")
+			}
+		})
+	}
+}
+
+func TestAssignOrderNotFound(t *testing.T) {
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Orders: &mockOrderGetter{err: pickup.ErrNotFound},
+	})
+	err := svc.Assign(permission.WithSystem(context.Background()), 1, 7, at(28, 9, 30), false, at(27, 10, 0))
+	if !errors.Is(err, pickup.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// --- Patient confirm/postpone tests ---
+
+func suggestedPickupOrder() pickup.Order {
+	o := preparedPickupOrder()
+	o.Status = "pending"
+	slot := at(28, 9, 30)
+	o.PickupAt = &slot
+	return o
+}
+
+func TestConfirm(t *testing.T) {
+	noSlot := suggestedPickupOrder()
+	noSlot.PickupAt = nil
+	collected := suggestedPickupOrder()
+	collected.Status = "fulfilled"
+
+	tests := []struct {
+		name  string
+		order pickup.Order
+		want  error
+	}{
+		{"suggested slot", suggestedPickupOrder(), nil},
+		{"no slot yet", noSlot, pickup.ErrNoPickupSlot},
+		{"already collected", collected, pickup.ErrOrderCollected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			confirmer := &mockConfirmer{}
+			svc := pickup.NewServiceWith(pickup.ServiceDeps{
+				Orders:    &mockOrderGetter{result: tt.order},
+				Confirmer: confirmer,
+			})
+			err := svc.Confirm(context.Background(), 1, 7)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+			if confirmed := confirmer.orderID == 7; confirmed != (tt.want == nil) {
+				t.Errorf("confirmed = %v", confirmed)
+			}
+		})
+	}
+}
+
+func TestPostponeMovesPendingOrderLater(t *testing.T) {
+	assigner := &mockAssigner{}
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Getter:   &mockScheduleGetter{schedule: weekSchedule()},
+		Counter:  &mockCounter{},
+		Orders:   &mockOrderGetter{result: suggestedPickupOrder()},
+		Assigner: assigner,
+	})
+
+	if err := svc.Postpone(context.Background(), 1, 7, at(29, 16, 0), at(27, 10, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assigner.orderID != 7 || !assigner.at.Equal(at(29, 16, 0)) {
+		t.Errorf("assigned order %d at %s", assigner.orderID, assigner.at)
+	}
+}
+
+func TestPostponeRejectsEarlierSlot(t *testing.T) {
+	assigner := &mockAssigner{}
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Getter:   &mockScheduleGetter{schedule: weekSchedule()},
+		Counter:  &mockCounter{},
+		Orders:   &mockOrderGetter{result: suggestedPickupOrder()},
+		Assigner: assigner,
+	})
+
+	err := svc.Postpone(context.Background(), 1, 7, at(28, 9, 0), at(27, 10, 0))
+	if !errors.Is(err, pickup.ErrNotLater) {
+		t.Fatalf("expected ErrNotLater, got %v", err)
+	}
+	if assigner.orderID != 0 {
+		t.Error("expected no assignment")
+	}
+}
+
+func TestPostponeSlotsAreLaterThanCurrent(t *testing.T) {
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Getter:  &mockScheduleGetter{schedule: weekSchedule()},
+		Counter: &mockCounter{},
+		Orders:  &mockOrderGetter{result: suggestedPickupOrder()},
+	})
+
+	slots, err := svc.PostponeSlots(context.Background(), 1, 7, at(27, 10, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(slots) == 0 {
+		t.Fatal("expected later slots")
+	}
+	if !slots[0].Start.Equal(at(28, 10, 0)) {
+		t.Errorf("first slot = %s, want the one after the current booking", slots[0].Start)
+	}
+}
+
+// --- SuggestSlot tests ---
+
+func TestSuggestSlotBooksUnconfirmed(t *testing.T) {
+	// Friday 09:00 is full, so the suggestion moves to 09:30.
+	assigner := &mockAssigner{booked: map[time.Time]int{at(30, 9, 0): 2}}
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Getter:   &mockScheduleGetter{schedule: weekSchedule()},
+		Assigner: assigner,
+	})
+
+	got, ok, err := svc.SuggestSlot(context.Background(), 1, 7, at(27, 0, 0), at(31, 0, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok || !got.Equal(at(30, 9, 30)) {
+		t.Errorf("SuggestSlot() = %s, %v; want Friday 09:30", got, ok)
+	}
+	if assigner.orderID != 7 || !assigner.at.Equal(got) || assigner.confirmed {
+		t.Errorf("booked order %d at %s, confirmed %v; want 7 unconfirmed", assigner.orderID, assigner.at, assigner.confirmed)
+	}
+}
+
+func TestSuggestSlotWithoutFreeSlot(t *testing.T) {
+	assigner := &mockAssigner{}
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Getter:   &mockScheduleGetter{schedule: pickup.Schedule{Settings: pickup.Settings{SlotMinutes: 30, SlotCapacity: 2}}},
+		Assigner: assigner,
+	})
+
+	_, ok, err := svc.SuggestSlot(context.Background(), 1, 7, at(27, 0, 0), at(31, 0, 0))
+	if err != nil || ok {
+		t.Fatalf("SuggestSlot() = %v, %v; want no slot", ok, err)
+	}
+	if assigner.orderID != 0 {
+		t.Error("expected no booking")
+	}
+}
+
+// --- Schedule tests ---
+
+func TestSaveScheduleValidates(t *testing.T) {
+	saver := &mockScheduleSaver{}
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{Saver: saver})
+
+	s := weekSchedule()
+	s.Settings.SlotCapacity = 0
+	if err := svc.SaveSchedule(permission.WithSystem(context.Background()), 1, s); !errors.Is(err, pickup.ErrInvalidCapacity) {
+		t.Fatalf("expected ErrInvalidCapacity, got %v", err)
+	}
+	if saver.called {
+		t.Error("expected invalid schedule not to be saved")
+	}
+}
+
+// --- Day tests ---
+
+func TestDayGroupsAppointmentsBySlot(t *testing.T) {
+	svc := pickup.NewServiceWith(pickup.ServiceDeps{
+		Getter: &mockScheduleGetter{schedule: weekSchedule()},
+		Appointments: &mockAppointments{result: []pickup.Appointment{
+			{OrderID: 1, At: at(28, 9, 0), OrderStatus: "prepared", Confirmed: true},
+			{OrderID: 2, At: at(28, 9, 0), OrderStatus: "fulfilled", Confirmed: true},
+			{OrderID: 3, At: at(28, 9, 10), OrderStatus: "pending"},
+		}},
+	})
+
+	day, err := svc.Day(context.Background(), 1, at(28, 14, 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !day.Open() || len(day.Slots) != 14 {
+		t.Fatalf("expected 14 slots, got %d", len(day.Slots))
+	}
+	if got := len(day.Slots[0].Appointments); got != 2 {
+		t.Errorf("09:00 appointments = %d, want 2", got)
+	}
+	if day.Slots[0].Booked != 1 {
+		t.Errorf("09:00 booked = %d, want 1 (fulfilled orders free their place)", day.Slots[0].Booked)
+	}
+	if len(day.Other) != 1 || day.Other[0].OrderID != 3 {
+		t.Errorf("expected misaligned appointment in Other, got %+v", day.Other)
+	}
+	if day.Booked != 2 || day.Capacity != 28 {
+		t.Errorf("day booked/capacity = %d/%d, want 2/28", day.Booked, day.Capacity)
+	}
+}
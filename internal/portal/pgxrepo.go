@@ -0,0 +1,167 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/giorgiovilardo/pharmarecall/internal/dbutil"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Ensure PgxRepository satisfies Repository at compile time.
+var _ Repository = (*PgxRepository)(nil)
+
+// PgxRepository implements all portal port interfaces using pgx/sqlc.
+type PgxRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewPgxRepository creates a new PgxRepository.
+func NewPgxRepository(pool *pgxpool.Pool, queries *db.Queries) *PgxRepository {
+	return &PgxRepository{pool: pool, queries: queries}
+}
+
+// FindPatients matches emails case-insensitively. Phones are narrowed down
+// in SQL by their trailing digits and then compared in normalized form, so
+// stored numbers may keep whatever spacing or prefix staff typed.
+func (r *PgxRepository) FindPatients(ctx context.Context, c Contact) ([]Patient, error) {
+	var result []Patient
+	if c.Channel == ChannelEmail {
+		rows, err := r.queries.FindPortalPatientsByEmail(ctx, c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("finding patients by email: %w", err)
+		}
+		for _, row := range rows {
+			result = append(result, Patient(row))
+		}
+		return result, nil
+	}
+
+	rows, err := r.queries.FindPortalPatientsByPhoneSuffix(ctx, c.Value)
+	if err != nil {
+		return nil, fmt.Errorf("finding patients by phone: %w", err)
+	}
+	for _, row := range rows {
+		if NormalizePhone(row.Phone) == c.Value {
+			result = append(result, Patient(row))
+		}
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) GetPatient(ctx context.Context, patientID int64) (Patient, error) {
+	row, err := r.queries.GetPortalPatient(ctx, patientID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Patient{}, ErrNotFound
+		}
+		return Patient{}, fmt.Errorf("querying portal patient: %w", err)
+	}
+	return Patient(row), nil
+}
+
+func (r *PgxRepository) CreateToken(ctx context.Context, patientID int64, channel string, hash []byte, expiresAt time.Time) error {
+	if err := r.queries.CreatePatientLoginToken(ctx, db.CreatePatientLoginTokenParams{
+		PatientID: patientID,
+		Channel:   channel,
+		TokenHash: hash,
+		ExpiresAt: dbutil.TimeToTimestamptz(expiresAt),
+	}); err != nil {
+		return fmt.Errorf("inserting login token: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) CountRecentTokens(ctx context.Context, patientID int64, since time.Time) (int, error) {
+	n, err := r.queries.CountRecentPatientLoginTokens(ctx, db.CountRecentPatientLoginTokensParams{
+		PatientID: patientID,
+		Since:     dbutil.TimeToTimestamptz(since),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("counting login tokens: %w", err)
+	}
+	return int(n), nil
+}
+
+func (r *PgxRepository) ConsumeLink(ctx context.Context, hash []byte, now time.Time) (int64, error) {
+	patientID, err := r.queries.ConsumePatientLoginLink(ctx, db.ConsumePatientLoginLinkParams{
+		Now:       dbutil.TimeToTimestamptz(now),
+		TokenHash: hash,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("consuming login link: %w", err)
+	}
+	return patientID, nil
+}
+
+func (r *PgxRepository) ListActiveCodes(ctx context.Context, patientIDs []int64, now time.Time) ([]Code, error) {
+	rows, err := r.queries.ListActivePatientLoginCodes(ctx, db.ListActivePatientLoginCodesParams{
+		PatientIds:  patientIDs,
+		Now:         dbutil.TimeToTimestamptz(now),
+		MaxAttempts: MaxCodeAttempts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing login codes: %w", err)
+	}
+	result := make([]Code, len(rows))
+	for i, row := range rows {
+		result[i] = Code{ID: row.ID, PatientID: row.PatientID, Hash: row.TokenHash}
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) UseCode(ctx context.Context, codeID int64, now time.Time) (bool, error) {
+	n, err := r.queries.UsePatientLoginToken(ctx, db.UsePatientLoginTokenParams{
+		ID:  codeID,
+		Now: dbutil.TimeToTimestamptz(now),
+	})
+	if err != nil {
+		return false, fmt.Errorf("using login code: %w", err)
+	}
+	return n == 1, nil
+}
+
+func (r *PgxRepository) RecordFailedAttempt(ctx context.Context, codeIDs []int64) error {
+	if err := r.queries.IncrementPatientLoginAttempts(ctx, codeIDs); err != nil {
+		return fmt.Errorf("incrementing login attempts: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) ListItems(ctx context.Context, patientID int64) ([]Item, error) {
+	rows, err := r.queries.ListPortalItems(ctx, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("listing portal items: %w", err)
+	}
+	result := make([]Item, len(rows))
+	for i, row := range rows {
+		it := Item{
+			PrescriptionID:   row.PrescriptionID,
+			MedicationName:   row.MedicationName,
+			UnitsPerBox:      int(row.UnitsPerBox),
+			DailyConsumption: dbutil.NumericToFloat64(row.DailyConsumption),
+			BoxStartDate:     row.BoxStartDate.Time,
+			Fulfillment:      row.Fulfillment,
+			OrderID:          row.OrderID,
+			OrderStatus:      row.OrderStatus,
+			PickupConfirmed:  row.PickupConfirmed,
+		}
+		if row.PickupAt.Valid {
+			at := row.PickupAt.Time
+			it.PickupAt = &at
+		}
+		if row.ReportedOn.Valid {
+			it.LastReport = &StockReport{Units: int(row.ReportedUnits), ReportedOn: row.ReportedOn.Time}
+		}
+		result[i] = it
+	}
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package portal
+
+import (
+	"context"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+// PatientFinder finds the consenting patients reachable at a contact.
+// Several patients may share a phone or email (e.g. a family).
+type PatientFinder interface {
+	FindPatients(ctx context.Context, c Contact) ([]Patient, error)
+}
+
+// PatientGetter gets a patient by ID.
+type PatientGetter interface {
+	GetPatient(ctx context.Context, patientID int64) (Patient, error)
+}
+
+// TokenCreator stores the hash of a new login link or code.
+type TokenCreator interface {
+	CreateToken(ctx context.Context, patientID int64, channel string, hash []byte, expiresAt time.Time) error
+}
+
+// RecentTokenCounter counts the logins requested for a patient since a time.
+type RecentTokenCounter interface {
+	CountRecentTokens(ctx context.Context, patientID int64, since time.Time) (int, error)
+}
+
+// LinkConsumer marks an unexpired, unused login link as used and returns
+// its patient. Returns ErrNotFound if no such link exists.
+type LinkConsumer interface {
+	ConsumeLink(ctx context.Context, hash []byte, now time.Time) (int64, error)
+}
+
+// CodeLister lists the unused, unexpired SMS codes of some patients that
+// still have guesses left.
+type CodeLister interface {
+	ListActiveCodes(ctx context.Context, patientIDs []int64, now time.Time) ([]Code, error)
+}
+
+// CodeUser marks a code as used, reporting false if it was used meanwhile.
+type CodeUser interface {
+	UseCode(ctx context.Context, codeID int64, now time.Time) (bool, error)
+}
+
+// AttemptRecorder counts a wrong guess against each of the codes.
+type AttemptRecorder interface {
+	RecordFailedAttempt(ctx context.Context, codeIDs []int64) error
+}
+
+// ItemLister lists a patient's prescriptions with their open orders.
+type ItemLister interface {
+	ListItems(ctx context.Context, patientID int64) ([]Item, error)
+}
+
+// Sender delivers a login link or code to the patient.
+type Sender interface {
+	SendLogin(ctx context.Context, l Login) error
+}
+
+// PickupScheduler confirms and postpones pickup appointments.
+type PickupScheduler interface {
+	Confirm(ctx context.Context, pharmacyID, orderID int64) error
+	PostponeSlots(ctx context.Context, pharmacyID, orderID int64, now time.Time) ([]pickup.Slot, error)
+	Postpone(ctx context.Context, pharmacyID, orderID int64, at, now time.Time) error
+}
+
+// StockReporter records the units a patient still has and re-anchors the
+// prescription's pending orders.
+type StockReporter interface {
+	ReportStock(ctx context.Context, pharmacyID int64, p prescription.StockReportParams, now time.Time, lookaheadDays int) error
+}
+
+// Repository composes all ports — used only by NewService for convenient wiring.
+type Repository interface {
+	PatientFinder
+	PatientGetter
+	TokenCreator
+	RecentTokenCounter
+	LinkConsumer
+	CodeLister
+	CodeUser
+	AttemptRecorder
+	ItemLister
+}
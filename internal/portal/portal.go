@@ -0,0 +1,160 @@
+// Package portal is the patient self-service area: passwordless login by
+// email link or SMS code, and a read-mostly view of the patient's own
+// prescriptions and orders.
+package portal
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/depletion"
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/sms"
+)
+
+var (
+	ErrNotFound       = errors.New("portal.not_found")
+	ErrInvalidContact = errors.New("portal.invalid_contact")
+	ErrInvalidLink    = errors.New("portal.invalid_link")
+	ErrInvalidCode    = errors.New("portal.invalid_code")
+	ErrSendFailed     = errors.New("portal.send_failed")
+)
+
+// Channel constants.
+const (
+	ChannelEmail = "email"
+	ChannelPhone = "phone"
+)
+
+// Login limits. Codes are short, so they live briefly and allow few guesses;
+// each patient can request a bounded number of logins per window.
+const (
+	LinkLifetime    = 30 * time.Minute
+	CodeLifetime    = 10 * time.Minute
+	CodeDigits      = 6
+	MaxCodeAttempts = 5
+	RequestWindow   = 15 * time.Minute
+	MaxRequests     = 3
+)
+
+// minPhoneDigits rejects inputs too short to identify anyone.
+const minPhoneDigits = 6
+
+// Contact is a normalized email address or phone number typed by the patient.
+type Contact struct {
+	Channel string
+	Value   string
+}
+
+// ParseContact classifies input as an email (anything with an @) or a phone
+// number and normalizes it for lookup.
+func ParseContact(input string) (Contact, error) {
+	input = strings.TrimSpace(input)
+	if strings.Contains(input, "@") {
+		local, domain, ok := strings.Cut(input, "@")
+		if !ok || local == "" || !strings.Contains(domain, ".") || strings.ContainsAny(input, " \t") {
+			return Contact{}, ErrInvalidContact
+		}
+		return Contact{Channel: ChannelEmail, Value: strings.ToLower(input)}, nil
+	}
+	phone := NormalizePhone(input)
+	if len(phone) < minPhoneDigits {
+		return Contact{}, ErrInvalidContact
+	}
+	return Contact{Channel: ChannelPhone, Value: phone}, nil
+}
+
+// NormalizePhone keeps only digits and drops the Italian country prefix, so
+// "+39 333 123 4567", "0039 3331234567" and "333-1234567" all compare equal.
+func NormalizePhone(phone string) string {
+	return sms.NationalNumber(phone)
+}
+
+// Patient is the portal's view of a patient account.
+type Patient struct {
+	ID           int64
+	PharmacyID   int64
+	FirstName    string
+	LastName     string
+	Phone        string
+	Email        string
+	PharmacyName string
+}
+
+// Login is a one-time credential on its way to the patient. Secret is the
+// full link for email and the numeric code for phone.
+type Login struct {
+	PatientID    int64
+	PharmacyID   int64
+	Channel      string
+	To           string
+	FirstName    string
+	PharmacyName string
+	Secret       string
+	ExpiresAt    time.Time
+}
+
+// Code is a pending SMS code, stored hashed.
+type Code struct {
+	ID        int64
+	PatientID int64
+	Hash      []byte
+}
+
+// StockReport is the patient's latest report of units still on hand.
+type StockReport struct {
+	Units      int
+	ReportedOn time.Time
+}
+
+// Item is one prescription as shown to the patient, with its open order.
+type Item struct {
+	PrescriptionID   int64
+	MedicationName   string
+	UnitsPerBox      int
+	DailyConsumption float64
+	BoxStartDate     time.Time
+	Fulfillment      string
+	OrderID          int64
+	OrderStatus      string
+	PickupAt         *time.Time
+	PickupConfirmed  bool
+	LastReport       *StockReport
+}
+
+// EstimatedDepletionDate returns the date when the current box runs out,
+// re-anchored on the latest stock report when there is one.
+func (i Item) EstimatedDepletionDate() time.Time {
+	if i.LastReport != nil {
+		return depletion.EstimatedDateFromReport(i.UnitsPerBox, i.DailyConsumption, i.BoxStartDate, i.LastReport.Units, i.LastReport.ReportedOn)
+	}
+	return depletion.EstimatedDate(i.UnitsPerBox, i.DailyConsumption, i.BoxStartDate)
+}
+
+// Status classifies the prescription based on days remaining.
+func (i Item) Status(now time.Time) string {
+	return depletion.Status(depletion.DaysRemaining(i.EstimatedDepletionDate(), now))
+}
+
+// HasOrder reports whether an order is currently open for the prescription.
+func (i Item) HasOrder() bool {
+	return i.OrderID != 0
+}
+
+// CanConfirmPickup reports whether the patient may accept the proposed pickup time.
+func (i Item) CanConfirmPickup() bool {
+	return i.CanPostponePickup() && i.PickupAt != nil && !i.PickupConfirmed
+}
+
+// CanPostponePickup reports whether the patient may move the pickup to a later slot.
+func (i Item) CanPostponePickup() bool {
+	return i.HasOrder() && i.Fulfillment == patient.FulfillmentPickup && i.OrderStatus != order.StatusFulfilled
+}
+
+// Overview is everything the portal home page shows.
+type Overview struct {
+	Patient Patient
+	Items   []Item
+}
@@ -0,0 +1,100 @@
+package portal_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/portal"
+)
+
+func TestParseContact(t *testing.T) {
+	tests := []struct {
+		input string
+		want  portal.Contact
+		err   error
+	}{
+		{" Mario.Rossi@Example.it ", portal.Contact{Channel: portal.ChannelEmail, Value: "mario.rossi@example.it"}, nil},
+		{"+39 333 123 4567", portal.Contact{Channel: portal.ChannelPhone, Value: "3331234567"}, nil},
+		{"0039 333-1234567", portal.Contact{Channel: portal.ChannelPhone, Value: "3331234567"}, nil},
+		{"02 1234567", portal.Contact{Channel: portal.ChannelPhone, Value: "021234567"}, nil},
+		{"mario@", portal.Contact{}, portal.ErrInvalidContact},
+		{"@example.it", portal.Contact{}, portal.ErrInvalidContact},
+		{"12345", portal.Contact{}, portal.ErrInvalidContact},
+		{"", portal.Contact{}, portal.ErrInvalidContact},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := portal.ParseContact(tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseContact(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItemPickupActions(t *testing.T) {
+	slot := time.Date(2026, 1, 28, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		item         portal.Item
+		wantConfirm  bool
+		wantPostpone bool
+	}{
+		{"no order", portal.Item{Fulfillment: "pickup"}, false, false},
+		{"suggested slot", portal.Item{OrderID: 1, OrderStatus: "pending", Fulfillment: "pickup", PickupAt: &slot}, true, true},
+		{"confirmed slot", portal.Item{OrderID: 1, OrderStatus: "prepared", Fulfillment: "pickup", PickupAt: &slot, PickupConfirmed: true}, false, true},
+		{"no slot yet", portal.Item{OrderID: 1, OrderStatus: "pending", Fulfillment: "pickup"}, false, true},
+		{"shipping", portal.Item{OrderID: 1, OrderStatus: "pending", Fulfillment: "shipping"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.item.CanConfirmPickup(); got != tt.wantConfirm {
+				t.Errorf("CanConfirmPickup() = %v, want %v", got, tt.wantConfirm)
+			}
+			if got := tt.item.CanPostponePickup(); got != tt.wantPostpone {
+				t.Errorf("CanPostponePickup() = %v, want %v", got, tt.wantPostpone)
+			}
+		})
+	}
+}
+
+type recordingEmail struct{ to, subject, body string }
+
+func (r *recordingEmail) Send(_ context.Context, to, subject, body string) error {
+	r.to, r.subject, r.body = to, subject, body
+	return nil
+}
+
+type recordingText struct{ to, text string }
+
+func (r *recordingText) Send(_ context.Context, to, text string) error {
+	r.to, r.text = to, text
+	return nil
+}
+
+func TestGatewaySender(t *testing.T) {
+	email, text := &recordingEmail{}, &recordingText{}
+	s := portal.GatewaySender{Email: email, SMS: text}
+
+	link := portal.Login{Channel: portal.ChannelEmail, To: "mario@example.it", FirstName: "Mario", PharmacyName: "Farmacia Centrale", Secret: "https://farmacia.example.it/portal/login/abc"}
+	if err := s.SendLogin(context.Background(), link); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if email.to != "mario@example.it" || !strings.Contains(email.subject, "Farmacia Centrale") || email.body != portal.Message(link) {
+		t.Errorf("email = %+v", email)
+	}
+
+	code := portal.Login{Channel: portal.ChannelPhone, To: "333 123 4567", PharmacyName: "Farmacia Centrale", Secret: "123456"}
+	if err := s.SendLogin(context.Background(), code); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text.to != "+393331234567" || text.text != portal.Message(code) {
+		t.Errorf("text = %+v", text)
+	}
+}
@@ -0,0 +1,95 @@
+package portal
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidReply   = errors.New("portal.invalid_reply")
+	ErrAmbiguousReply = errors.New("portal.ambiguous_reply")
+)
+
+// StockReply is a patient's texted count of the units they still have,
+// matched to one of their prescriptions.
+type StockReply struct {
+	PatientID      int64
+	PharmacyID     int64
+	PrescriptionID int64
+	MedicationName string
+	Units          int
+}
+
+// ReplyKeyword opens a texted stock report: "RIMASTE 12", or
+// "RIMASTE Eutirox 12" for a patient with several prescriptions.
+const ReplyKeyword = "RIMASTE"
+
+// ParseReplyUnits reads the count out of a texted stock report. The text
+// must start with ReplyKeyword, in any case, and end with the count as a
+// word of its own, so ordinary replies such as "passo alle 18" or
+// "RIMASTE fino alle 18:30" are ErrInvalidReply rather than a count. Words
+// in between may name the medication but not hold another number.
+func ParseReplyUnits(text string) (int, error) {
+	words := strings.Fields(text)
+	if len(words) < 2 || !strings.EqualFold(trimPunct(words[0]), ReplyKeyword) {
+		return 0, ErrInvalidReply
+	}
+	last := trimPunct(words[len(words)-1])
+	if !isNumber(last) {
+		return 0, ErrInvalidReply
+	}
+	for _, w := range words[1 : len(words)-1] {
+		if isNumber(trimPunct(w)) {
+			return 0, ErrInvalidReply
+		}
+	}
+	units, err := strconv.Atoi(last)
+	if err != nil || units > 10000 {
+		return 0, ErrInvalidReply
+	}
+	return units, nil
+}
+
+func trimPunct(w string) string {
+	return strings.Trim(w, ".,;:!?")
+}
+
+// isNumber reports whether w is made of digits only.
+func isNumber(w string) bool {
+	if w == "" {
+		return false
+	}
+	for _, r := range w {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// matchReplyItem picks the prescription a reply is about: the only one, or
+// the only one whose medication's first word the reply mentions.
+func matchReplyItem(items []Item, text string) (Item, error) {
+	switch len(items) {
+	case 0:
+		return Item{}, ErrNotFound
+	case 1:
+		return items[0], nil
+	}
+	words := strings.Fields(strings.ToLower(text))
+	var named []Item
+	for _, it := range items {
+		name, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(it.MedicationName)), " ")
+		for _, w := range words {
+			if name != "" && trimPunct(w) == name {
+				named = append(named, it)
+				break
+			}
+		}
+	}
+	if len(named) != 1 {
+		return Item{}, ErrAmbiguousReply
+	}
+	return named[0], nil
+}
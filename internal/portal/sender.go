@@ -0,0 +1,71 @@
+package portal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/sms"
+)
+
+// Message returns the text sent to the patient for a login.
+func Message(l Login) string {
+	if l.Channel == ChannelEmail {
+		return fmt.Sprintf("Gentile %s, per accedere all'area pazienti di %s apra questo link entro %d minuti: %s",
+			l.FirstName, l.PharmacyName, int(LinkLifetime.Minutes()), l.Secret)
+	}
+	return fmt.Sprintf("%s: il suo codice di accesso all'area pazienti è %s. Valido %d minuti.",
+		l.PharmacyName, l.Secret, int(CodeLifetime.Minutes()))
+}
+
+// EmailSender sends a plain-text email, as mail.SMTP does.
+type EmailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// TextSender sends a text message, as sms.Gateway does.
+type TextSender interface {
+	Send(ctx context.Context, to, text string) error
+}
+
+// GatewaySender is the Sender of deployed servers: it emails login links
+// and texts login codes. Phone numbers are stored as typed, so codes go to
+// their Italian international form.
+type GatewaySender struct {
+	Email EmailSender
+	SMS   TextSender
+}
+
+// SendLogin sends the message on the login's channel.
+func (g GatewaySender) SendLogin(ctx context.Context, login Login) error {
+	if login.Channel == ChannelEmail {
+		subject := "Accesso all'area pazienti di " + login.PharmacyName
+		if err := g.Email.Send(ctx, login.To, subject, Message(login)); err != nil {
+			return fmt.Errorf("emailing login link: %w", err)
+		}
+		return nil
+	}
+	if err := g.SMS.Send(ctx, sms.ItalianNumber(login.To), Message(login)); err != nil {
+		return fmt.Errorf("texting login code: %w", err)
+	}
+	return nil
+}
+
+// LogSender is a Sender that writes the message to the log instead of
+// sending it, so logins can be completed locally. The message carries the
+// login secret, so it is for development only: production configurations
+// must set a mail server and an SMS gateway.
+type LogSender struct {
+	Logger *slog.Logger
+}
+
+// SendLogin logs the message.
+func (l LogSender) SendLogin(ctx context.Context, login Login) error {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.InfoContext(ctx, "portal login",
+		"patient_id", login.PatientID, "channel", login.Channel, "message", Message(login))
+	return nil
+}
@@ -0,0 +1,367 @@
+package portal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+// ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
+type ServiceDeps struct {
+	Finder   PatientFinder
+	Patients PatientGetter
+	Tokens   TokenCreator
+	Recent   RecentTokenCounter
+	Links    LinkConsumer
+	Codes    CodeLister
+	UseCode  CodeUser
+	Attempts AttemptRecorder
+	Items    ItemLister
+	Sender   Sender
+	Pickups  PickupScheduler
+	Stock    StockReporter
+}
+
+// Service contains patient portal business logic.
+type Service struct {
+	deps ServiceDeps
+}
+
+// NewService is the production constructor — takes a Repository (satisfies all ports),
+// the Sender for login messages, the pickup scheduler and the stock reporter.
+func NewService(repo Repository, sender Sender, pickups PickupScheduler, stock StockReporter) *Service {
+	return &Service{deps: ServiceDeps{
+		Finder:   repo,
+		Patients: repo,
+		Tokens:   repo,
+		Recent:   repo,
+		Links:    repo,
+		Codes:    repo,
+		UseCode:  repo,
+		Attempts: repo,
+		Items:    repo,
+		Sender:   sender,
+		Pickups:  pickups,
+		Stock:    stock,
+	}}
+}
+
+// NewServiceWith is the test constructor — inject only what you need, rest stays nil.
+func NewServiceWith(d ServiceDeps) *Service {
+	return &Service{deps: d}
+}
+
+// RequestLogin sends a one-time link (email) or code (phone) to every
+// consenting patient reachable at input. It returns the parsed contact so
+// the caller knows which step comes next, and reveals nothing about whether
+// a patient was found: unknown contacts and rate-limited patients succeed
+// silently. Links point to baseURL + "/portal/login/<token>".
+// If some messages cannot be sent the others still go out and ErrSendFailed
+// is returned alongside the contact.
+func (s *Service) RequestLogin(ctx context.Context, input, baseURL string, now time.Time) (Contact, error) {
+	c, err := ParseContact(input)
+	if err != nil {
+		return Contact{}, err
+	}
+
+	patients, err := s.deps.Finder.FindPatients(ctx, c)
+	if err != nil {
+		return Contact{}, fmt.Errorf("finding portal patients: %w", err)
+	}
+
+	var sendErrs []error
+	for _, p := range patients {
+		recent, err := s.deps.Recent.CountRecentTokens(ctx, p.ID, now.Add(-RequestWindow))
+		if err != nil {
+			return Contact{}, fmt.Errorf("counting recent logins: %w", err)
+		}
+		if recent >= MaxRequests {
+			continue
+		}
+
+		l := Login{
+			PatientID:    p.ID,
+			PharmacyID:   p.PharmacyID,
+			Channel:      c.Channel,
+			FirstName:    p.FirstName,
+			PharmacyName: p.PharmacyName,
+		}
+		var secret string
+		if c.Channel == ChannelEmail {
+			secret, err = newLinkToken()
+			l.To = p.Email
+			l.Secret = strings.TrimRight(baseURL, "/") + "/portal/login/" + secret
+			l.ExpiresAt = now.Add(LinkLifetime)
+		} else {
+			secret, err = newCode()
+			l.To = p.Phone
+			l.Secret = secret
+			l.ExpiresAt = now.Add(CodeLifetime)
+		}
+		if err != nil {
+			return Contact{}, fmt.Errorf("generating login secret: %w", err)
+		}
+
+		if err := s.deps.Tokens.CreateToken(ctx, p.ID, c.Channel, hashSecret(secret), l.ExpiresAt); err != nil {
+			return Contact{}, fmt.Errorf("storing login token: %w", err)
+		}
+		if err := s.deps.Sender.SendLogin(ctx, l); err != nil {
+			sendErrs = append(sendErrs, fmt.Errorf("patient %d: %w", p.ID, err))
+		}
+	}
+
+	if len(sendErrs) > 0 {
+		return c, errors.Join(append([]error{ErrSendFailed}, sendErrs...)...)
+	}
+	return c, nil
+}
+
+// VerifyLink consumes the token of an email link and returns its patient.
+func (s *Service) VerifyLink(ctx context.Context, token string, now time.Time) (Patient, error) {
+	if token == "" {
+		return Patient{}, ErrInvalidLink
+	}
+	patientID, err := s.deps.Links.ConsumeLink(ctx, hashSecret(token), now)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Patient{}, ErrInvalidLink
+		}
+		return Patient{}, fmt.Errorf("consuming login link: %w", err)
+	}
+	return s.patient(ctx, patientID)
+}
+
+// VerifyCode checks an SMS code against the codes pending for the phone
+// number and returns the matching patient. A wrong guess counts against
+// every pending code for that number.
+func (s *Service) VerifyCode(ctx context.Context, phone, code string, now time.Time) (Patient, error) {
+	c, err := ParseContact(phone)
+	if err != nil || c.Channel != ChannelPhone {
+		return Patient{}, ErrInvalidCode
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != CodeDigits {
+		return Patient{}, ErrInvalidCode
+	}
+
+	patients, err := s.deps.Finder.FindPatients(ctx, c)
+	if err != nil {
+		return Patient{}, fmt.Errorf("finding portal patients: %w", err)
+	}
+	if len(patients) == 0 {
+		return Patient{}, ErrInvalidCode
+	}
+	ids := make([]int64, len(patients))
+	for i, p := range patients {
+		ids[i] = p.ID
+	}
+
+	codes, err := s.deps.Codes.ListActiveCodes(ctx, ids, now)
+	if err != nil {
+		return Patient{}, fmt.Errorf("listing login codes: %w", err)
+	}
+	hash := hashSecret(code)
+	codeIDs := make([]int64, 0, len(codes))
+	for _, pending := range codes {
+		codeIDs = append(codeIDs, pending.ID)
+		if subtle.ConstantTimeCompare(pending.Hash, hash) != 1 {
+			continue
+		}
+		ok, err := s.deps.UseCode.UseCode(ctx, pending.ID, now)
+		if err != nil {
+			return Patient{}, fmt.Errorf("using login code: %w", err)
+		}
+		if !ok {
+			return Patient{}, ErrInvalidCode
+		}
+		return s.patient(ctx, pending.PatientID)
+	}
+
+	if len(codeIDs) > 0 {
+		if err := s.deps.Attempts.RecordFailedAttempt(ctx, codeIDs); err != nil {
+			return Patient{}, fmt.Errorf("recording failed login attempt: %w", err)
+		}
+	}
+	return Patient{}, ErrInvalidCode
+}
+
+func (s *Service) patient(ctx context.Context, patientID int64) (Patient, error) {
+	p, err := s.deps.Patients.GetPatient(ctx, patientID)
+	if err != nil {
+		return Patient{}, fmt.Errorf("getting portal patient: %w", err)
+	}
+	return p, nil
+}
+
+// Overview returns the patient's prescriptions and open orders.
+func (s *Service) Overview(ctx context.Context, patientID int64) (Overview, error) {
+	p, err := s.patient(ctx, patientID)
+	if err != nil {
+		return Overview{}, err
+	}
+	items, err := s.deps.Items.ListItems(ctx, patientID)
+	if err != nil {
+		return Overview{}, fmt.Errorf("listing portal items: %w", err)
+	}
+	return Overview{Patient: p, Items: items}, nil
+}
+
+// ConfirmPickup accepts the proposed pickup time of one of the patient's orders.
+func (s *Service) ConfirmPickup(ctx context.Context, patientID, pharmacyID, orderID int64) error {
+	if _, err := s.orderItem(ctx, patientID, orderID); err != nil {
+		return err
+	}
+	return s.deps.Pickups.Confirm(ctx, pharmacyID, orderID)
+}
+
+// PostponeOptions returns one of the patient's orders with the later slots it can move to.
+func (s *Service) PostponeOptions(ctx context.Context, patientID, pharmacyID, orderID int64, now time.Time) (Item, []pickup.Slot, error) {
+	it, err := s.orderItem(ctx, patientID, orderID)
+	if err != nil {
+		return Item{}, nil, err
+	}
+	slots, err := s.deps.Pickups.PostponeSlots(ctx, pharmacyID, orderID, now)
+	if err != nil {
+		return Item{}, nil, err
+	}
+	return it, slots, nil
+}
+
+// PostponePickup moves one of the patient's orders to a later slot.
+func (s *Service) PostponePickup(ctx context.Context, patientID, pharmacyID, orderID int64, at, now time.Time) error {
+	if _, err := s.orderItem(ctx, patientID, orderID); err != nil {
+		return err
+	}
+	return s.deps.Pickups.Postpone(ctx, pharmacyID, orderID, at, now)
+}
+
+// ReportStock records that the patient still has units left of one of
+// their prescriptions, as of today. Pending orders move with the new
+// depletion estimate; see order.Service.ReportStock. The report goes
+// through as the system: the patient holds no staff permissions, and the
+// prescription was checked to be theirs.
+func (s *Service) ReportStock(ctx context.Context, patientID, pharmacyID, prescriptionID int64, units int, now time.Time, lookaheadDays int) error {
+	items, err := s.deps.Items.ListItems(ctx, patientID)
+	if err != nil {
+		return fmt.Errorf("listing portal items: %w", err)
+	}
+	for _, it := range items {
+		if it.PrescriptionID != prescriptionID {
+			continue
+		}
+		return s.deps.Stock.ReportStock(permission.WithSystem(ctx), pharmacyID, prescription.StockReportParams{
+			PrescriptionID: prescriptionID,
+			Units:          units,
+			ReportedOn:     time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+			Source:         prescription.StockSourcePortal,
+		}, now, lookaheadDays)
+	}
+	return ErrNotFound
+}
+
+// MatchReply matches a text a patient sent back to a reminder to the
+// prescription it reports on. from is the sender's phone number; every
+// consenting patient reachable there is considered, as families share
+// phones. The reply must name the medication when they have several; see
+// ParseReplyUnits for the count.
+func (s *Service) MatchReply(ctx context.Context, from, text string) (StockReply, error) {
+	units, err := ParseReplyUnits(text)
+	if err != nil {
+		return StockReply{}, err
+	}
+	c, err := ParseContact(from)
+	if err != nil || c.Channel != ChannelPhone {
+		return StockReply{}, ErrInvalidContact
+	}
+	patients, err := s.deps.Finder.FindPatients(ctx, c)
+	if err != nil {
+		return StockReply{}, fmt.Errorf("finding reply patients: %w", err)
+	}
+
+	var items []Item
+	owners := map[int64]Patient{}
+	for _, p := range patients {
+		list, err := s.deps.Items.ListItems(ctx, p.ID)
+		if err != nil {
+			return StockReply{}, fmt.Errorf("listing portal items: %w", err)
+		}
+		for _, it := range list {
+			if _, seen := owners[it.PrescriptionID]; !seen {
+				owners[it.PrescriptionID] = p
+				items = append(items, it)
+			}
+		}
+	}
+	it, err := matchReplyItem(items, text)
+	if err != nil {
+		return StockReply{}, err
+	}
+	p := owners[it.PrescriptionID]
+	return StockReply{PatientID: p.ID, PharmacyID: p.PharmacyID, PrescriptionID: it.PrescriptionID, MedicationName: it.MedicationName, Units: units}, nil
+}
+
+// ReportReply records a matched reply as a stock report of the day it
+// arrived. Pending orders move as with ReportStock; the reply was matched to
+// the sender's own prescription, so it too goes through as the system.
+func (s *Service) ReportReply(ctx context.Context, r StockReply, now time.Time, lookaheadDays int) error {
+	return s.deps.Stock.ReportStock(permission.WithSystem(ctx), r.PharmacyID, prescription.StockReportParams{
+		PrescriptionID: r.PrescriptionID,
+		Units:          r.Units,
+		ReportedOn:     time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Source:         prescription.StockSourceReply,
+	}, now, lookaheadDays)
+}
+
+// orderItem returns the patient's item holding orderID, so a patient can
+// only act on their own orders.
+func (s *Service) orderItem(ctx context.Context, patientID, orderID int64) (Item, error) {
+	items, err := s.deps.Items.ListItems(ctx, patientID)
+	if err != nil {
+		return Item{}, fmt.Errorf("listing portal items: %w", err)
+	}
+	for _, it := range items {
+		if it.HasOrder() && it.OrderID == orderID {
+			return it, nil
+		}
+	}
+	return Item{}, ErrNotFound
+}
+
+// newLinkToken returns a random URL-safe token for an email link.
+func newLinkToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// newCode returns a random zero-padded numeric code.
+func newCode() (string, error) {
+	limit := big.NewInt(1)
+	for range CodeDigits {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", CodeDigits, n), nil
+}
+
+// hashSecret is how links and codes are stored: only the hash reaches the database.
+func hashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
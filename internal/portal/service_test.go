@@ -0,0 +1,397 @@
+package portal_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/portal"
+	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
+)
+
+// --- Mocks ---
+
+type mockFinder struct {
+	result []portal.Patient
+}
+
+func (m *mockFinder) FindPatients(_ context.Context, _ portal.Contact) ([]portal.Patient, error) {
+	return m.result, nil
+}
+
+type mockPatients struct {
+	result portal.Patient
+}
+
+func (m *mockPatients) GetPatient(_ context.Context, _ int64) (portal.Patient, error) {
+	return m.result, nil
+}
+
+type storedToken struct {
+	patientID int64
+	channel   string
+	hash      []byte
+}
+
+// mockTokens keeps login tokens in memory and implements every token port.
+type mockTokens struct {
+	created  []storedToken
+	recent   int
+	attempts []int64
+	used     []int64
+}
+
+func (m *mockTokens) CreateToken(_ context.Context, patientID int64, channel string, hash []byte, _ time.Time) error {
+	m.created = append(m.created, storedToken{patientID, channel, hash})
+	return nil
+}
+
+func (m *mockTokens) CountRecentTokens(_ context.Context, _ int64, _ time.Time) (int, error) {
+	return m.recent, nil
+}
+
+func (m *mockTokens) ListActiveCodes(_ context.Context, _ []int64, _ time.Time) ([]portal.Code, error) {
+	var codes []portal.Code
+	for i, t := range m.created {
+		if t.channel == portal.ChannelPhone {
+			codes = append(codes, portal.Code{ID: int64(i + 1), PatientID: t.patientID, Hash: t.hash})
+		}
+	}
+	return codes, nil
+}
+
+func (m *mockTokens) UseCode(_ context.Context, codeID int64, _ time.Time) (bool, error) {
+	m.used = append(m.used, codeID)
+	return true, nil
+}
+
+func (m *mockTokens) RecordFailedAttempt(_ context.Context, codeIDs []int64) error {
+	m.attempts = append(m.attempts, codeIDs...)
+	return nil
+}
+
+type mockLinks struct {
+	err error
+}
+
+func (m mockLinks) ConsumeLink(_ context.Context, _ []byte, _ time.Time) (int64, error) {
+	return 0, m.err
+}
+
+type mockSender struct {
+	sent []portal.Login
+	err  error
+}
+
+func (m *mockSender) SendLogin(_ context.Context, l portal.Login) error {
+	m.sent = append(m.sent, l)
+	return m.err
+}
+
+type mockItems struct {
+	result []portal.Item
+}
+
+func (m *mockItems) ListItems(_ context.Context, _ int64) ([]portal.Item, error) {
+	return m.result, nil
+}
+
+type mockPickups struct {
+	confirmed int64
+	postponed int64
+}
+
+func (m *mockPickups) Confirm(_ context.Context, _, orderID int64) error {
+	m.confirmed = orderID
+	return nil
+}
+
+func (m *mockPickups) PostponeSlots(_ context.Context, _, _ int64, _ time.Time) ([]pickup.Slot, error) {
+	return nil, nil
+}
+
+func (m *mockPickups) Postpone(_ context.Context, _, orderID int64, _, _ time.Time) error {
+	m.postponed = orderID
+	return nil
+}
+
+type mockStock struct {
+	pharmacyID int64
+	params     *prescription.StockReportParams
+	lookahead  int
+}
+
+func (m *mockStock) ReportStock(_ context.Context, pharmacyID int64, p prescription.StockReportParams, _ time.Time, lookaheadDays int) error {
+	m.pharmacyID = pharmacyID
+	m.params = &p
+	m.lookahead = lookaheadDays
+	return nil
+}
+
+var (
+	now   = time.Date(2026, 1, 27, 10, 15, 0, 0, time.UTC)
+	mario = portal.Patient{ID: 3, PharmacyID: 7, FirstName: "Mario", LastName: "Rossi", Phone: "333 1234567", Email: "mario@example.it", PharmacyName: "Farmacia Centrale"}
+)
+
+func tokenService(tokens *mockTokens, sender *mockSender, patients ...portal.Patient) *portal.Service {
+	return portal.NewServiceWith(portal.ServiceDeps{
+		Finder:   &mockFinder{result: patients},
+		Patients: &mockPatients{result: mario},
+		Tokens:   tokens,
+		Recent:   tokens,
+		Codes:    tokens,
+		UseCode:  tokens,
+		Attempts: tokens,
+		Sender:   sender,
+	})
+}
+
+// --- Login tests ---
+
+func TestRequestLoginSendsHashedLink(t *testing.T) {
+	tokens := &mockTokens{}
+	sender := &mockSender{}
+	svc := tokenService(tokens, sender, mario)
+
+	c, err := svc.RequestLogin(context.Background(), "Mario@Example.it", "https://app.example.it/", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Channel != portal.ChannelEmail {
+		t.Errorf("channel = %q, want email", c.Channel)
+	}
+	if len(sender.sent) != 1 || len(tokens.created) != 1 {
+		t.Fatalf("sent %d messages and stored %d tokens, want 1 each", len(sender.sent), len(tokens.created))
+	}
+	l := sender.sent[0]
+	token, ok := strings.CutPrefix(l.Secret, "https://app.example.it/portal/login/")
+	if !ok || token == "" {
+		t.Fatalf("unexpected link %q", l.Secret)
+	}
+	if l.To != mario.Email || !l.ExpiresAt.Equal(now.Add(portal.LinkLifetime)) {
+		t.Errorf("unexpected login %+v", l)
+	}
+	if strings.Contains(string(tokens.created[0].hash), token) {
+		t.Error("expected only the token hash to be stored")
+	}
+}
+
+func TestRequestLoginUnknownContactIsSilent(t *testing.T) {
+	sender := &mockSender{}
+	svc := tokenService(&mockTokens{}, sender)
+
+	if _, err := svc.RequestLogin(context.Background(), "nobody@example.it", "https://app.example.it", now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sender.sent) != 0 {
+		t.Error("expected no message")
+	}
+}
+
+func TestRequestLoginRateLimited(t *testing.T) {
+	tokens := &mockTokens{recent: portal.MaxRequests}
+	sender := &mockSender{}
+	svc := tokenService(tokens, sender, mario)
+
+	if _, err := svc.RequestLogin(context.Background(), "3331234567", "https://app.example.it", now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sender.sent) != 0 || len(tokens.created) != 0 {
+		t.Error("expected rate-limited request to send nothing")
+	}
+}
+
+func TestRequestLoginSendFailure(t *testing.T) {
+	svc := tokenService(&mockTokens{}, &mockSender{err: errors.New("gateway down")}, mario)
+
+	c, err := svc.RequestLogin(context.Background(), "3331234567", "https://app.example.it", now)
+	if !errors.Is(err, portal.ErrSendFailed) {
+		t.Fatalf("expected ErrSendFailed, got %v", err)
+	}
+	if c.Channel != portal.ChannelPhone {
+		t.Errorf("expected contact alongside the error, got %+v", c)
+	}
+}
+
+func TestVerifyCode(t *testing.T) {
+	tokens := &mockTokens{}
+	sender := &mockSender{}
+	svc := tokenService(tokens, sender, mario)
+
+	if _, err := svc.RequestLogin(context.Background(), "+39 333 1234567", "https://app.example.it", now); err != nil {
+		t.Fatalf("requesting login: %v", err)
+	}
+	code := sender.sent[0].Secret
+	if len(code) != portal.CodeDigits {
+		t.Fatalf("code = %q, want %d digits", code, portal.CodeDigits)
+	}
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	if _, err := svc.VerifyCode(context.Background(), "3331234567", wrong, now); !errors.Is(err, portal.ErrInvalidCode) {
+		t.Fatalf("expected ErrInvalidCode for wrong code, got %v", err)
+	}
+	if len(tokens.attempts) != 1 {
+		t.Errorf("expected the failed attempt to be recorded, got %v", tokens.attempts)
+	}
+
+	p, err := svc.VerifyCode(context.Background(), "333 123 4567", code, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.ID != mario.ID || len(tokens.used) != 1 {
+		t.Errorf("logged in as %d with used codes %v", p.ID, tokens.used)
+	}
+}
+
+func TestVerifyLinkInvalid(t *testing.T) {
+	svc := portal.NewServiceWith(portal.ServiceDeps{Links: mockLinks{err: portal.ErrNotFound}})
+	if _, err := svc.VerifyLink(context.Background(), "expired", now); !errors.Is(err, portal.ErrInvalidLink) {
+		t.Fatalf("expected ErrInvalidLink, got %v", err)
+	}
+}
+
+// --- Patient action tests ---
+
+func TestActionsOnlyOnOwnItems(t *testing.T) {
+	items := &mockItems{result: []portal.Item{{PrescriptionID: 10, OrderID: 20, Fulfillment: "pickup", OrderStatus: "pending"}}}
+	pickups := &mockPickups{}
+	stock := &mockStock{}
+	svc := portal.NewServiceWith(portal.ServiceDeps{Items: items, Pickups: pickups, Stock: stock})
+
+	if err := svc.ConfirmPickup(context.Background(), 3, 7, 99); !errors.Is(err, portal.ErrNotFound) {
+		t.Errorf("confirm foreign order: expected ErrNotFound, got %v", err)
+	}
+	if err := svc.PostponePickup(context.Background(), 3, 7, 99, now, now); !errors.Is(err, portal.ErrNotFound) {
+		t.Errorf("postpone foreign order: expected ErrNotFound, got %v", err)
+	}
+	if err := svc.ReportStock(context.Background(), 3, 7, 99, 5, now, 7); !errors.Is(err, portal.ErrNotFound) {
+		t.Errorf("report foreign prescription: expected ErrNotFound, got %v", err)
+	}
+	if pickups.confirmed != 0 || pickups.postponed != 0 || stock.params != nil {
+		t.Fatal("expected no action on foreign items")
+	}
+
+	if err := svc.ConfirmPickup(context.Background(), 3, 7, 20); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if pickups.confirmed != 20 {
+		t.Errorf("confirmed order %d, want 20", pickups.confirmed)
+	}
+}
+
+func TestReportStockFromPortal(t *testing.T) {
+	stock := &mockStock{}
+	svc := portal.NewServiceWith(portal.ServiceDeps{
+		Items: &mockItems{result: []portal.Item{{PrescriptionID: 10}}},
+		Stock: stock,
+	})
+
+	if err := svc.ReportStock(context.Background(), 3, 7, 10, 14, now, 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := prescription.StockReportParams{
+		PrescriptionID: 10,
+		Units:          14,
+		ReportedOn:     time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC),
+		Source:         prescription.StockSourcePortal,
+	}
+	if stock.params == nil || *stock.params != want {
+		t.Errorf("reported %+v, want %+v", stock.params, want)
+	}
+	if stock.pharmacyID != 7 || stock.lookahead != 7 {
+		t.Errorf("pharmacy = %d, lookahead = %d, want 7 and 7", stock.pharmacyID, stock.lookahead)
+	}
+}
+
+// --- Reply tests ---
+
+func TestParseReplyUnits(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+		err  error
+	}{
+		{"RIMASTE 12", 12, nil},
+		{"rimaste eutirox 0", 0, nil},
+		{"Rimaste: Eutirox 50mcg 8!", 8, nil},
+		{"12", 0, portal.ErrInvalidReply},
+		{"ne ho ancora 12", 0, portal.ErrInvalidReply},
+		{"passo alle 18", 0, portal.ErrInvalidReply},
+		{"ok grazie, passo domani alle 18:30", 0, portal.ErrInvalidReply},
+		{"RIMASTE alle 18:30", 0, portal.ErrInvalidReply},
+		{"RIMASTE 12 13", 0, portal.ErrInvalidReply},
+		{"RIMASTE -3", 0, portal.ErrInvalidReply},
+		{"RIMASTE 20000", 0, portal.ErrInvalidReply},
+		{"RIMASTE", 0, portal.ErrInvalidReply},
+	}
+	for _, tt := range tests {
+		got, err := portal.ParseReplyUnits(tt.text)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("ParseReplyUnits(%q) = %d, %v; want %d, %v", tt.text, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestMatchReply(t *testing.T) {
+	eutirox := portal.Item{PrescriptionID: 10, MedicationName: "Eutirox 50mcg"}
+	cardioaspirin := portal.Item{PrescriptionID: 11, MedicationName: "Cardioaspirin"}
+
+	tests := []struct {
+		name   string
+		items  []portal.Item
+		from   string
+		text   string
+		wantRx int64
+		want   error
+	}{
+		{"single prescription", []portal.Item{eutirox}, "+39 333 1234567", "RIMASTE 12", 10, nil},
+		{"keyword in any case", []portal.Item{eutirox}, "+393331234567", "rimaste: 12.", 10, nil},
+		{"named medication", []portal.Item{eutirox, cardioaspirin}, "+393331234567", "Rimaste Cardioaspirin 20", 11, nil},
+		{"unnamed among several", []portal.Item{eutirox, cardioaspirin}, "+393331234567", "RIMASTE 20", 0, portal.ErrAmbiguousReply},
+		{"free text", []portal.Item{eutirox}, "+393331234567", "passo alle 18", 0, portal.ErrInvalidReply},
+		{"time of day", []portal.Item{eutirox}, "+393331234567", "RIMASTE alle 18:30", 0, portal.ErrInvalidReply},
+		{"bare number", []portal.Item{eutirox}, "+393331234567", "12", 0, portal.ErrInvalidReply},
+		{"no prescriptions", nil, "+393331234567", "RIMASTE 12", 0, portal.ErrNotFound},
+		{"not a phone number", []portal.Item{eutirox}, "mario@example.it", "RIMASTE 12", 0, portal.ErrInvalidContact},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := portal.NewServiceWith(portal.ServiceDeps{
+				Finder: &mockFinder{result: []portal.Patient{mario}},
+				Items:  &mockItems{result: tt.items},
+			})
+			got, err := svc.MatchReply(context.Background(), tt.from, tt.text)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err == nil && (got.PrescriptionID != tt.wantRx || got.PatientID != 3 || got.PharmacyID != 7) {
+				t.Errorf("matched %+v, want prescription %d of patient 3 at pharmacy 7", got, tt.wantRx)
+			}
+		})
+	}
+}
+
+func TestReportReplyRecordsReplySource(t *testing.T) {
+	stock := &mockStock{}
+	svc := portal.NewServiceWith(portal.ServiceDeps{Stock: stock})
+
+	r := portal.StockReply{PatientID: 3, PharmacyID: 7, PrescriptionID: 10, Units: 12}
+	if err := svc.ReportReply(context.Background(), r, now, 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := prescription.StockReportParams{
+		PrescriptionID: 10,
+		Units:          12,
+		ReportedOn:     time.Date(2026, 1, 27, 0, 0, 0, 0, time.UTC),
+		Source:         prescription.StockSourceReply,
+	}
+	if stock.params == nil || *stock.params != want || stock.pharmacyID != 7 || stock.lookahead != 5 {
+		t.Errorf("reported %+v at pharmacy %d with lookahead %d", stock.params, stock.pharmacyID, stock.lookahead)
+	}
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/giorgiovilardo/pharmarecall/internal/db"
 	"github.com/giorgiovilardo/pharmarecall/internal/dbutil"
-	"github.com/giorgiovilardo/pharmarecall/internal/depletion"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -48,7 +47,14 @@ func (r *PgxRepository) Create(ctx context.Context, p CreateParams) (Prescriptio
 		return Prescription{}, fmt.Errorf("committing transaction: %w", err)
 	}
 
-	return mapPrescription(row), nil
+	return mapPrescription(db.GetPrescriptionByIDRow{
+		ID:               row.ID,
+		PatientID:        row.PatientID,
+		MedicationName:   row.MedicationName,
+		UnitsPerBox:      row.UnitsPerBox,
+		DailyConsumption: row.DailyConsumption,
+		BoxStartDate:     row.BoxStartDate,
+	}), nil
 }
 
 func (r *PgxRepository) GetByID(ctx context.Context, id int64) (Prescription, error) {
@@ -69,7 +75,7 @@ func (r *PgxRepository) ListByPatient(ctx context.Context, patientID int64) ([]P
 	}
 	result := make([]Prescription, len(rows))
 	for i, row := range rows {
-		result[i] = mapPrescription(row)
+		result[i] = mapPrescription(db.GetPrescriptionByIDRow(row))
 	}
 	return result, nil
 }
@@ -112,9 +118,9 @@ func (r *PgxRepository) RecordRefill(ctx context.Context, p RefillParams) error
 		return fmt.Errorf("getting prescription for refill: %w", err)
 	}
 
-	// Calculate the old box end date (depletion date).
-	dailyConsumption := dbutil.NumericToFloat64(current.DailyConsumption)
-	oldEnd := depletion.EstimatedDate(int(current.UnitsPerBox), dailyConsumption, current.BoxStartDate.Time)
+	// Calculate the old box end date (depletion date), re-anchored on the
+	// latest stock report so a stockpiling patient's refill is not counted late.
+	oldEnd := mapPrescription(current).EstimatedDepletionDate()
 
 	// Insert refill history for the previous cycle.
 	if err := qtx.InsertRefillHistory(ctx, db.InsertRefillHistoryParams{
@@ -144,8 +150,20 @@ func (r *PgxRepository) RecordRefill(ctx context.Context, p RefillParams) error
 	return tx.Commit(ctx)
 }
 
-func mapPrescription(row db.Prescription) Prescription {
-	return Prescription{
+func (r *PgxRepository) ReportStock(ctx context.Context, p StockReportParams) error {
+	if err := r.queries.CreateStockReport(ctx, db.CreateStockReportParams{
+		PrescriptionID: p.PrescriptionID,
+		Units:          int32(p.Units),
+		ReportedOn:     dbutil.TimeToDate(p.ReportedOn),
+		Source:         p.Source,
+	}); err != nil {
+		return fmt.Errorf("inserting stock report: %w", err)
+	}
+	return nil
+}
+
+func mapPrescription(row db.GetPrescriptionByIDRow) Prescription {
+	rx := Prescription{
 		ID:               row.ID,
 		PatientID:        row.PatientID,
 		MedicationName:   row.MedicationName,
@@ -153,4 +171,12 @@ func mapPrescription(row db.Prescription) Prescription {
 		DailyConsumption: dbutil.NumericToFloat64(row.DailyConsumption),
 		BoxStartDate:     row.BoxStartDate.Time,
 	}
+	if row.ReportedOn.Valid {
+		rx.LastReport = &StockReport{
+			Units:      int(row.ReportedUnits),
+			ReportedOn: row.ReportedOn.Time,
+			Source:     row.ReportSource,
+		}
+	}
+	return rx
 }
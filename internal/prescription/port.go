@@ -27,6 +27,11 @@ type RefillRecorder interface {
 	RecordRefill(ctx context.Context, p RefillParams) error
 }
 
+// StockReporter stores a patient-reported remaining-unit count.
+type StockReporter interface {
+	ReportStock(ctx context.Context, p StockReportParams) error
+}
+
 // Repository composes all ports — used only by NewService for convenient wiring.
 type Repository interface {
 	PrescriptionCreator
@@ -34,4 +39,5 @@ type Repository interface {
 	PrescriptionLister
 	PrescriptionUpdater
 	RefillRecorder
+	StockReporter
 }
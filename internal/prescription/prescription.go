@@ -8,13 +8,16 @@ import (
 )
 
 var (
-	ErrNotFound              = errors.New("prescription not found")
-	ErrNoConsensus           = errors.New("il paziente deve dare il consenso prima di aggiungere prescrizioni")
-	ErrMedicationRequired    = errors.New("il nome del farmaco è obbligatorio")
-	ErrInvalidUnitsPerBox    = errors.New("le unità per confezione devono essere maggiori di zero")
-	ErrInvalidConsumption    = errors.New("il consumo giornaliero deve essere maggiore di zero")
-	ErrStartDateRequired     = errors.New("la data di inizio confezione è obbligatoria")
-	ErrConsumptionExceedsBox = errors.New("il consumo giornaliero deve essere inferiore alle unità per confezione (la confezione deve durare almeno un giorno)")
+	ErrNotFound              = errors.New("prescription.not_found")
+	ErrNoConsensus           = errors.New("prescription.no_consensus")
+	ErrMedicationRequired    = errors.New("prescription.medication_required")
+	ErrInvalidUnitsPerBox    = errors.New("prescription.invalid_units_per_box")
+	ErrInvalidConsumption    = errors.New("prescription.invalid_consumption")
+	ErrStartDateRequired     = errors.New("prescription.start_date_required")
+	ErrConsumptionExceedsBox = errors.New("prescription.consumption_exceeds_box")
+	ErrInvalidStockUnits     = errors.New("prescription.invalid_stock_units")
+	ErrReportDateRequired    = errors.New("prescription.report_date_required")
+	ErrInvalidStockSource    = errors.New("prescription.invalid_stock_source")
 )
 
 // Status constants — re-exported from depletion for backward compatibility.
@@ -24,6 +27,13 @@ const (
 	StatusDepleted    = depletion.StatusDepleted
 )
 
+// Stock report source constants: who supplied the count.
+const (
+	StockSourcePortal = "portal" // the patient, from the portal
+	StockSourceStaff  = "staff"  // pharmacy staff, at the counter
+	StockSourceReply  = "reply"  // the patient, replying to a reminder
+)
+
 // Prescription is the domain representation of a recurring prescription.
 type Prescription struct {
 	ID               int64
@@ -32,10 +42,22 @@ type Prescription struct {
 	UnitsPerBox      int
 	DailyConsumption float64
 	BoxStartDate     time.Time
+	LastReport       *StockReport // latest remaining-unit count, nil when none
+}
+
+// StockReport is a count of units the patient still had on a date.
+type StockReport struct {
+	Units      int
+	ReportedOn time.Time
+	Source     string
 }
 
 // EstimatedDepletionDate returns the date when the current box is expected to run out.
+// A stock report made during the current box re-anchors the estimate.
 func (p Prescription) EstimatedDepletionDate() time.Time {
+	if p.LastReport != nil {
+		return depletion.EstimatedDateFromReport(p.UnitsPerBox, p.DailyConsumption, p.BoxStartDate, p.LastReport.Units, p.LastReport.ReportedOn)
+	}
 	return depletion.EstimatedDate(p.UnitsPerBox, p.DailyConsumption, p.BoxStartDate)
 }
 
@@ -72,3 +94,13 @@ type RefillParams struct {
 	PrescriptionID int64
 	NewStartDate   time.Time
 }
+
+// StockReportParams holds a patient-reported count of units still on hand.
+// It is stored apart from refill_history: a report moves the depletion
+// estimate but is not a refill.
+type StockReportParams struct {
+	PrescriptionID int64
+	Units          int
+	ReportedOn     time.Time
+	Source         string
+}
@@ -64,6 +64,35 @@ func TestEstimatedDepletionDate(t *testing.T) {
 	}
 }
 
+func TestEstimatedDepletionDateReanchoredOnStockReport(t *testing.T) {
+	// 30 units at 1/day from Jan 1 would run out Jan 31.
+	tests := []struct {
+		name   string
+		report prescription.StockReport
+		want   time.Time
+	}{
+		{"stockpiled: 25 left on Jan 20", prescription.StockReport{Units: 25, ReportedOn: date(2026, 1, 20)}, date(2026, 2, 14)},
+		{"fewer than projected: 2 left on Jan 20", prescription.StockReport{Units: 2, ReportedOn: date(2026, 1, 20)}, date(2026, 1, 22)},
+		{"report from an earlier box is ignored", prescription.StockReport{Units: 25, ReportedOn: date(2025, 12, 20)}, date(2026, 1, 31)},
+		{"report on the box start day is ignored", prescription.StockReport{Units: 25, ReportedOn: date(2026, 1, 1)}, date(2026, 1, 31)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := prescription.Prescription{
+				UnitsPerBox:      30,
+				DailyConsumption: 1,
+				BoxStartDate:     date(2026, 1, 1),
+				LastReport:       &tt.report,
+			}
+			got := p.EstimatedDepletionDate()
+			if !got.Equal(tt.want) {
+				t.Errorf("EstimatedDepletionDate() = %s, want %s", got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
 func TestDaysRemaining(t *testing.T) {
 	p := prescription.Prescription{
 		UnitsPerBox:      30,
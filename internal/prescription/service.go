@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 )
 
 // ConsensusChecker checks if a patient has given consensus.
@@ -18,6 +20,7 @@ type ServiceDeps struct {
 	Lister    PrescriptionLister
 	Updater   PrescriptionUpdater
 	Refill    RefillRecorder
+	Stock     StockReporter
 	Consensus ConsensusChecker
 }
 
@@ -34,6 +37,7 @@ func NewService(repo Repository, consensus ConsensusChecker) *Service {
 		Lister:    repo,
 		Updater:   repo,
 		Refill:    repo,
+		Stock:     repo,
 		Consensus: consensus,
 	}}
 }
@@ -45,6 +49,9 @@ func NewServiceWith(d ServiceDeps) *Service {
 
 // Create validates and creates a prescription. Blocks if the patient has no consensus.
 func (s *Service) Create(ctx context.Context, p CreateParams) (Prescription, error) {
+	if err := permission.Check(ctx, permission.EditPrescriptions); err != nil {
+		return Prescription{}, err
+	}
 	if err := validatePrescription(p.MedicationName, p.UnitsPerBox, p.DailyConsumption, p.BoxStartDate); err != nil {
 		return Prescription{}, err
 	}
@@ -76,6 +83,9 @@ func (s *Service) ListByPatient(ctx context.Context, patientID int64) ([]Prescri
 
 // Update validates and updates a prescription.
 func (s *Service) Update(ctx context.Context, p UpdateParams) error {
+	if err := permission.Check(ctx, permission.EditPrescriptions); err != nil {
+		return err
+	}
 	if err := validatePrescription(p.MedicationName, p.UnitsPerBox, p.DailyConsumption, p.BoxStartDate); err != nil {
 		return err
 	}
@@ -88,6 +98,9 @@ func (s *Service) Update(ctx context.Context, p UpdateParams) error {
 
 // RecordRefill delegates to the refill recorder.
 func (s *Service) RecordRefill(ctx context.Context, prescriptionID int64, newStartDate time.Time) error {
+	if err := permission.Check(ctx, permission.RecordRefills); err != nil {
+		return err
+	}
 	if err := s.deps.Refill.RecordRefill(ctx, RefillParams{
 		PrescriptionID: prescriptionID,
 		NewStartDate:   newStartDate,
@@ -97,6 +110,25 @@ func (s *Service) RecordRefill(ctx context.Context, prescriptionID int64, newSta
 	return nil
 }
 
+// ReportStock validates and stores how many units the patient still has.
+func (s *Service) ReportStock(ctx context.Context, p StockReportParams) error {
+	if p.Units < 0 {
+		return ErrInvalidStockUnits
+	}
+	if p.ReportedOn.IsZero() {
+		return ErrReportDateRequired
+	}
+	switch p.Source {
+	case StockSourcePortal, StockSourceStaff, StockSourceReply:
+	default:
+		return ErrInvalidStockSource
+	}
+	if err := s.deps.Stock.ReportStock(ctx, p); err != nil {
+		return fmt.Errorf("reporting stock: %w", err)
+	}
+	return nil
+}
+
 func validatePrescription(medicationName string, unitsPerBox int, dailyConsumption float64, boxStartDate interface{ IsZero() bool }) error {
 	if medicationName == "" {
 		return ErrMedicationRequired
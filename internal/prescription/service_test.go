@@ -3,9 +3,10 @@ package prescription_test
 import (
 	"context"
 	"errors"
-	"strings"
 	"testing"
+	"time"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
 )
 
@@ -66,6 +67,18 @@ func (m *mockRefillRecorder) RecordRefill(_ context.Context, p prescription.Refi
 	return m.err
 }
 
+type mockStockReporter struct {
+	called bool
+	params prescription.StockReportParams
+	err    error
+}
+
+func (m *mockStockReporter) ReportStock(_ context.Context, p prescription.StockReportParams) error {
+	m.called = true
+	m.params = p
+	return m.err
+}
+
 type mockConsensusChecker struct {
 	consensus bool
 	err       error
@@ -82,7 +95,7 @@ func TestCreateSuccess(t *testing.T) {
 	checker := &mockConsensusChecker{consensus: true}
 	svc := prescription.NewServiceWith(prescription.ServiceDeps{Creator: creator, Consensus: checker})
 
-	got, err := svc.Create(context.Background(), prescription.CreateParams{
+	got, err := svc.Create(permission.WithSystem(context.Background()), prescription.CreateParams{
 		PatientID:        10,
 		MedicationName:   "Tachipirina",
 		UnitsPerBox:      30,
@@ -102,39 +115,39 @@ func TestCreateSuccess(t *testing.T) {
 
 func TestCreateValidation(t *testing.T) {
 	tests := []struct {
-		name   string
-		params prescription.CreateParams
-		errStr string
+		name    string
+		params  prescription.CreateParams
+		wantErr error
 	}{
 		{
-			name:   "missing medication name",
-			params: prescription.CreateParams{PatientID: 1, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)},
-			errStr: "farmaco",
+			name:    "missing medication name",
+			params:  prescription.CreateParams{PatientID: 1, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)},
+			wantErr: prescription.ErrMedicationRequired,
 		},
 		{
-			name:   "zero units per box",
-			params: prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 0, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)},
-			errStr: "unità",
+			name:    "zero units per box",
+			params:  prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 0, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)},
+			wantErr: prescription.ErrInvalidUnitsPerBox,
 		},
 		{
-			name:   "zero daily consumption",
-			params: prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 30, DailyConsumption: 0, BoxStartDate: date(2026, 1, 1)},
-			errStr: "consumo",
+			name:    "zero daily consumption",
+			params:  prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 30, DailyConsumption: 0, BoxStartDate: date(2026, 1, 1)},
+			wantErr: prescription.ErrInvalidConsumption,
 		},
 		{
-			name:   "zero box start date",
-			params: prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 30, DailyConsumption: 1},
-			errStr: "data",
+			name:    "zero box start date",
+			params:  prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 30, DailyConsumption: 1},
+			wantErr: prescription.ErrStartDateRequired,
 		},
 		{
-			name:   "consumption equals units (box lasts less than 1 day)",
-			params: prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 30, DailyConsumption: 30, BoxStartDate: date(2026, 1, 1)},
-			errStr: "consumo giornaliero deve essere inferiore",
+			name:    "consumption equals units (box lasts less than 1 day)",
+			params:  prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 30, DailyConsumption: 30, BoxStartDate: date(2026, 1, 1)},
+			wantErr: prescription.ErrConsumptionExceedsBox,
 		},
 		{
-			name:   "consumption exceeds units",
-			params: prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 10, DailyConsumption: 50, BoxStartDate: date(2026, 1, 1)},
-			errStr: "consumo giornaliero deve essere inferiore",
+			name:    "consumption exceeds units",
+			params:  prescription.CreateParams{PatientID: 1, MedicationName: "X", UnitsPerBox: 10, DailyConsumption: 50, BoxStartDate: date(2026, 1, 1)},
+			wantErr: prescription.ErrConsumptionExceedsBox,
 		},
 	}
 
@@ -142,12 +155,12 @@ func TestCreateValidation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			checker := &mockConsensusChecker{consensus: true}
 			svc := prescription.NewServiceWith(prescription.ServiceDeps{Consensus: checker})
-			_, err := svc.Create(context.Background(), tt.params)
+			_, err := svc.Create(permission.WithSystem(context.Background()), tt.params)
 			if err == nil {
 				t.Fatal("expected validation error")
 			}
-			if !strings.Contains(err.Error(), tt.errStr) {
-				t.Errorf("error = %q, want substring %q", err.Error(), tt.errStr)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -158,7 +171,7 @@ func TestCreateBlocksWithoutConsensus(t *testing.T) {
 	checker := &mockConsensusChecker{consensus: false}
 	svc := prescription.NewServiceWith(prescription.ServiceDeps{Creator: creator, Consensus: checker})
 
-	_, err := svc.Create(context.Background(), prescription.CreateParams{
+	_, err := svc.Create(permission.WithSystem(context.Background()), prescription.CreateParams{
 		PatientID:        10,
 		MedicationName:   "Tachipirina",
 		UnitsPerBox:      30,
@@ -181,7 +194,7 @@ func TestCreateRepoError(t *testing.T) {
 	checker := &mockConsensusChecker{consensus: true}
 	svc := prescription.NewServiceWith(prescription.ServiceDeps{Creator: creator, Consensus: checker})
 
-	_, err := svc.Create(context.Background(), prescription.CreateParams{
+	_, err := svc.Create(permission.WithSystem(context.Background()), prescription.CreateParams{
 		PatientID:        10,
 		MedicationName:   "Tachipirina",
 		UnitsPerBox:      30,
@@ -199,7 +212,7 @@ func TestUpdateSuccess(t *testing.T) {
 	updater := &mockUpdater{}
 	svc := prescription.NewServiceWith(prescription.ServiceDeps{Updater: updater})
 
-	err := svc.Update(context.Background(), prescription.UpdateParams{
+	err := svc.Update(permission.WithSystem(context.Background()), prescription.UpdateParams{
 		ID:               1,
 		MedicationName:   "Tachipirina",
 		UnitsPerBox:      60,
@@ -216,29 +229,29 @@ func TestUpdateSuccess(t *testing.T) {
 
 func TestUpdateValidation(t *testing.T) {
 	tests := []struct {
-		name   string
-		params prescription.UpdateParams
-		errStr string
+		name    string
+		params  prescription.UpdateParams
+		wantErr error
 	}{
 		{
-			name:   "missing medication name",
-			params: prescription.UpdateParams{ID: 1, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)},
-			errStr: "farmaco",
+			name:    "missing medication name",
+			params:  prescription.UpdateParams{ID: 1, UnitsPerBox: 30, DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)},
+			wantErr: prescription.ErrMedicationRequired,
 		},
 		{
-			name:   "zero units",
-			params: prescription.UpdateParams{ID: 1, MedicationName: "X", DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)},
-			errStr: "unità",
+			name:    "zero units",
+			params:  prescription.UpdateParams{ID: 1, MedicationName: "X", DailyConsumption: 1, BoxStartDate: date(2026, 1, 1)},
+			wantErr: prescription.ErrInvalidUnitsPerBox,
 		},
 		{
-			name:   "zero consumption",
-			params: prescription.UpdateParams{ID: 1, MedicationName: "X", UnitsPerBox: 30, BoxStartDate: date(2026, 1, 1)},
-			errStr: "consumo",
+			name:    "zero consumption",
+			params:  prescription.UpdateParams{ID: 1, MedicationName: "X", UnitsPerBox: 30, BoxStartDate: date(2026, 1, 1)},
+			wantErr: prescription.ErrInvalidConsumption,
 		},
 		{
-			name:   "consumption exceeds units",
-			params: prescription.UpdateParams{ID: 1, MedicationName: "X", UnitsPerBox: 10, DailyConsumption: 20, BoxStartDate: date(2026, 1, 1)},
-			errStr: "consumo giornaliero deve essere inferiore",
+			name:    "consumption exceeds units",
+			params:  prescription.UpdateParams{ID: 1, MedicationName: "X", UnitsPerBox: 10, DailyConsumption: 20, BoxStartDate: date(2026, 1, 1)},
+			wantErr: prescription.ErrConsumptionExceedsBox,
 		},
 	}
 
@@ -246,7 +259,7 @@ func TestUpdateValidation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			updater := &mockUpdater{}
 			svc := prescription.NewServiceWith(prescription.ServiceDeps{Updater: updater})
-			err := svc.Update(context.Background(), tt.params)
+			err := svc.Update(permission.WithSystem(context.Background()), tt.params)
 			if err == nil {
 				t.Fatal("expected validation error")
 			}
@@ -263,7 +276,7 @@ func TestRecordRefillSuccess(t *testing.T) {
 	recorder := &mockRefillRecorder{}
 	svc := prescription.NewServiceWith(prescription.ServiceDeps{Refill: recorder})
 
-	err := svc.RecordRefill(context.Background(), 1, date(2026, 2, 1))
+	err := svc.RecordRefill(permission.WithSystem(context.Background()), 1, date(2026, 2, 1))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -279,12 +292,74 @@ func TestRecordRefillRepoError(t *testing.T) {
 	recorder := &mockRefillRecorder{err: errors.New("db down")}
 	svc := prescription.NewServiceWith(prescription.ServiceDeps{Refill: recorder})
 
-	err := svc.RecordRefill(context.Background(), 1, date(2026, 2, 1))
+	err := svc.RecordRefill(permission.WithSystem(context.Background()), 1, date(2026, 2, 1))
 	if err == nil {
 		t.Fatal("expected error")
 	}
 }
 
+// --- Stock report tests ---
+
+func TestReportStockSuccess(t *testing.T) {
+	reporter := &mockStockReporter{}
+	svc := prescription.NewServiceWith(prescription.ServiceDeps{Stock: reporter})
+
+	err := svc.ReportStock(context.Background(), prescription.StockReportParams{
+		PrescriptionID: 1, Units: 12, ReportedOn: date(2026, 2, 1), Source: prescription.StockSourcePortal,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reporter.called {
+		t.Fatal("ReportStock was not called")
+	}
+	if reporter.params.Units != 12 {
+		t.Errorf("Units = %d, want 12", reporter.params.Units)
+	}
+}
+
+func TestReportStockAcceptsEverySource(t *testing.T) {
+	for _, source := range []string{prescription.StockSourcePortal, prescription.StockSourceStaff, prescription.StockSourceReply} {
+		reporter := &mockStockReporter{}
+		svc := prescription.NewServiceWith(prescription.ServiceDeps{Stock: reporter})
+
+		err := svc.ReportStock(context.Background(), prescription.StockReportParams{
+			PrescriptionID: 1, Units: 3, ReportedOn: date(2026, 2, 1), Source: source,
+		})
+		if err != nil {
+			t.Errorf("source %q: unexpected error: %v", source, err)
+		}
+	}
+}
+
+func TestReportStockValidation(t *testing.T) {
+	valid := prescription.StockReportParams{PrescriptionID: 1, Units: 0, ReportedOn: date(2026, 2, 1), Source: prescription.StockSourcePortal}
+	tests := []struct {
+		name   string
+		modify func(*prescription.StockReportParams)
+		want   error
+	}{
+		{"negative units", func(p *prescription.StockReportParams) { p.Units = -1 }, prescription.ErrInvalidStockUnits},
+		{"missing date", func(p *prescription.StockReportParams) { p.ReportedOn = time.Time{} }, prescription.ErrReportDateRequired},
+		{"unknown source", func(p *prescription.StockReportParams) { p.Source = "fax" }, prescription.ErrInvalidStockSource},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter := &mockStockReporter{}
+			svc := prescription.NewServiceWith(prescription.ServiceDeps{Stock: reporter})
+
+			p := valid
+			tt.modify(&p)
+			if err := svc.ReportStock(context.Background(), p); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			if reporter.called {
+				t.Error("ReportStock should not be called on invalid input")
+			}
+		})
+	}
+}
+
 // --- List tests ---
 
 func TestListByPatientSuccess(t *testing.T) {
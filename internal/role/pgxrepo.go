@@ -0,0 +1,125 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Ensure PgxRepository satisfies Repository at compile time.
+var _ Repository = (*PgxRepository)(nil)
+
+// PgxRepository implements all role port interfaces using pgx/sqlc.
+type PgxRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewPgxRepository creates a new PgxRepository.
+func NewPgxRepository(pool *pgxpool.Pool, queries *db.Queries) *PgxRepository {
+	return &PgxRepository{pool: pool, queries: queries}
+}
+
+func (r *PgxRepository) ListRoles(ctx context.Context, pharmacyID int64) ([]Role, error) {
+	rows, err := r.queries.ListPharmacyRoles(ctx, pharmacyID)
+	if err != nil {
+		return nil, fmt.Errorf("listing pharmacy roles: %w", err)
+	}
+	roles := make([]Role, len(rows))
+	for i, row := range rows {
+		roles[i] = Role{
+			ID:          row.ID,
+			PharmacyID:  pharmacyID,
+			Name:        row.Name,
+			Permissions: permission.FromStrings(row.Permissions),
+			Holders:     row.Members,
+		}
+	}
+	return roles, nil
+}
+
+func (r *PgxRepository) GetRole(ctx context.Context, pharmacyID, id int64) (Role, error) {
+	row, err := r.queries.GetPharmacyRole(ctx, db.GetPharmacyRoleParams{ID: id, PharmacyID: pharmacyID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Role{}, ErrNotFound
+		}
+		return Role{}, fmt.Errorf("querying pharmacy role: %w", err)
+	}
+	return Role{
+		ID:          row.ID,
+		PharmacyID:  pharmacyID,
+		Name:        row.Name,
+		Permissions: permission.FromStrings(row.Permissions),
+	}, nil
+}
+
+func (r *PgxRepository) CreateRole(ctx context.Context, role Role) (int64, error) {
+	id, err := r.queries.CreatePharmacyRole(ctx, db.CreatePharmacyRoleParams{
+		PharmacyID:  role.PharmacyID,
+		Name:        role.Name,
+		Permissions: role.Permissions.Strings(),
+	})
+	if err != nil {
+		return 0, mapDuplicateName(err)
+	}
+	return id, nil
+}
+
+func (r *PgxRepository) UpdateRole(ctx context.Context, role Role) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	current, err := qtx.LockPharmacyRole(ctx, db.LockPharmacyRoleParams{ID: role.ID, PharmacyID: role.PharmacyID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("locking pharmacy role: %w", err)
+	}
+	perms := role.Permissions.Strings()
+	if err := qtx.UpdatePharmacyRole(ctx, db.UpdatePharmacyRoleParams{ID: role.ID, Name: role.Name, Permissions: perms}); err != nil {
+		return mapDuplicateName(err)
+	}
+	if !slices.Equal(current.Permissions, perms) {
+		if err := qtx.DeleteRoleHolderSessions(ctx, role.ID); err != nil {
+			return fmt.Errorf("revoking role holder sessions: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) DeleteRole(ctx context.Context, pharmacyID, id int64) error {
+	n, err := r.queries.DeletePharmacyRole(ctx, db.DeletePharmacyRoleParams{ID: id, PharmacyID: pharmacyID})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.ConstraintName == "fk_users_custom_role" {
+			return ErrInUse
+		}
+		return fmt.Errorf("deleting pharmacy role: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func mapDuplicateName(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_pharmacy_roles_name" {
+		return ErrNameTaken
+	}
+	return fmt.Errorf("saving pharmacy role: %w", err)
+}
@@ -0,0 +1,39 @@
+package role
+
+import "context"
+
+// RoleLister lists a pharmacy's custom roles with their holder counts.
+type RoleLister interface {
+	ListRoles(ctx context.Context, pharmacyID int64) ([]Role, error)
+}
+
+// RoleGetter fetches one of a pharmacy's custom roles.
+type RoleGetter interface {
+	GetRole(ctx context.Context, pharmacyID, id int64) (Role, error)
+}
+
+// RoleCreator stores a new custom role and returns its ID.
+type RoleCreator interface {
+	CreateRole(ctx context.Context, r Role) (int64, error)
+}
+
+// RoleUpdater renames a custom role or changes its permissions. When the
+// permissions change, its holders' sessions are revoked in the same
+// transaction, since sessions carry the permissions granted at login.
+type RoleUpdater interface {
+	UpdateRole(ctx context.Context, r Role) error
+}
+
+// RoleDeleter removes a custom role nobody holds.
+type RoleDeleter interface {
+	DeleteRole(ctx context.Context, pharmacyID, id int64) error
+}
+
+// Repository composes all ports — used only by NewService for convenient wiring.
+type Repository interface {
+	RoleLister
+	RoleGetter
+	RoleCreator
+	RoleUpdater
+	RoleDeleter
+}
@@ -0,0 +1,62 @@
+// Package role manages the custom roles an owner defines for their
+// pharmacy, each a name and a set of assignable permissions. The built-in
+// roles live in package permission.
+package role
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+)
+
+var (
+	ErrNotFound      = errors.New("role.not_found")
+	ErrNameRequired  = errors.New("role.name_required")
+	ErrNameTooLong   = errors.New("role.name_too_long")
+	ErrNameTaken     = errors.New("role.name_taken")
+	ErrNoPermissions = errors.New("role.no_permissions")
+	ErrInUse         = errors.New("role.in_use")
+)
+
+// MaxNameLength is the longest custom role name, in characters.
+const MaxNameLength = 100
+
+// Role is a pharmacy's custom role. Holders counts the staff members who
+// have it.
+type Role struct {
+	ID          int64
+	PharmacyID  int64
+	Name        string
+	Permissions permission.Set
+	Holders     int64
+}
+
+// Params holds the data of a role being created or changed; ID is zero for
+// a new role. Permissions are names as submitted by the role form.
+type Params struct {
+	ID          int64
+	PharmacyID  int64
+	Name        string
+	Permissions []string
+}
+
+// parse validates p and returns the role it describes.
+func parse(p Params) (Role, error) {
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		return Role{}, ErrNameRequired
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return Role{}, ErrNameTooLong
+	}
+	perms, err := permission.ParseAssignable(p.Permissions)
+	if err != nil {
+		return Role{}, err
+	}
+	if len(perms) == 0 {
+		return Role{}, ErrNoPermissions
+	}
+	return Role{ID: p.ID, PharmacyID: p.PharmacyID, Name: name, Permissions: perms}, nil
+}
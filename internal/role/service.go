@@ -0,0 +1,97 @@
+package role
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+)
+
+// ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
+type ServiceDeps struct {
+	Lister  RoleLister
+	Getter  RoleGetter
+	Creator RoleCreator
+	Updater RoleUpdater
+	Deleter RoleDeleter
+}
+
+// Service contains custom role business logic.
+type Service struct {
+	deps ServiceDeps
+}
+
+// NewService is the production constructor — takes a Repository (satisfies all ports).
+func NewService(repo Repository) *Service {
+	return &Service{deps: ServiceDeps{
+		Lister:  repo,
+		Getter:  repo,
+		Creator: repo,
+		Updater: repo,
+		Deleter: repo,
+	}}
+}
+
+// NewServiceWith is the test constructor — inject only what you need, rest stays nil.
+func NewServiceWith(d ServiceDeps) *Service {
+	return &Service{deps: d}
+}
+
+// List returns the pharmacy's custom roles.
+func (s *Service) List(ctx context.Context, pharmacyID int64) ([]Role, error) {
+	roles, err := s.deps.Lister.ListRoles(ctx, pharmacyID)
+	if err != nil {
+		return nil, fmt.Errorf("listing roles: %w", err)
+	}
+	return roles, nil
+}
+
+// Get returns one of the pharmacy's custom roles.
+func (s *Service) Get(ctx context.Context, pharmacyID, id int64) (Role, error) {
+	return s.deps.Getter.GetRole(ctx, pharmacyID, id)
+}
+
+// Create validates and stores a new custom role.
+func (s *Service) Create(ctx context.Context, p Params) (Role, error) {
+	if err := permission.Check(ctx, permission.ManageRoles); err != nil {
+		return Role{}, err
+	}
+	r, err := parse(p)
+	if err != nil {
+		return Role{}, err
+	}
+	id, err := s.deps.Creator.CreateRole(ctx, r)
+	if err != nil {
+		return Role{}, fmt.Errorf("creating role: %w", err)
+	}
+	r.ID = id
+	return r, nil
+}
+
+// Update validates and saves a custom role. Holders are logged out when its
+// permissions change, so they get the new ones at their next login.
+func (s *Service) Update(ctx context.Context, p Params) error {
+	if err := permission.Check(ctx, permission.ManageRoles); err != nil {
+		return err
+	}
+	r, err := parse(p)
+	if err != nil {
+		return err
+	}
+	if err := s.deps.Updater.UpdateRole(ctx, r); err != nil {
+		return fmt.Errorf("updating role: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a custom role. A role still held by someone cannot be
+// deleted: their role must be changed first.
+func (s *Service) Delete(ctx context.Context, pharmacyID, id int64) error {
+	if err := permission.Check(ctx, permission.ManageRoles); err != nil {
+		return err
+	}
+	if err := s.deps.Deleter.DeleteRole(ctx, pharmacyID, id); err != nil {
+		return fmt.Errorf("deleting role: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package role_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/role"
+)
+
+type mockRoleStore struct {
+	saved   []role.Role
+	deleted int64
+	err     error
+}
+
+func (m *mockRoleStore) CreateRole(_ context.Context, r role.Role) (int64, error) {
+	m.saved = append(m.saved, r)
+	return 9, m.err
+}
+
+func (m *mockRoleStore) UpdateRole(_ context.Context, r role.Role) error {
+	m.saved = append(m.saved, r)
+	return m.err
+}
+
+func (m *mockRoleStore) DeleteRole(_ context.Context, _, id int64) error {
+	m.deleted = id
+	return m.err
+}
+
+func roleService(m *mockRoleStore) *role.Service {
+	return role.NewServiceWith(role.ServiceDeps{Creator: m, Updater: m, Deleter: m})
+}
+
+func TestCreateRoleValidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		p     role.Params
+		want  error
+		perms permission.Set
+	}{
+		{"valid", role.Params{PharmacyID: 7, Name: " Magazziniere ", Permissions: []string{"shipping.view", "orders.view"}}, nil, permission.Set{permission.ViewOrders, permission.ViewShipping}},
+		{"blank name", role.Params{PharmacyID: 7, Name: "  ", Permissions: []string{"orders.view"}}, role.ErrNameRequired, nil},
+		{"long name", role.Params{PharmacyID: 7, Name: strings.Repeat("a", role.MaxNameLength+1), Permissions: []string{"orders.view"}}, role.ErrNameTooLong, nil},
+		{"no permissions", role.Params{PharmacyID: 7, Name: "Vuoto"}, role.ErrNoPermissions, nil},
+		{"owner permission", role.Params{PharmacyID: 7, Name: "Capo", Permissions: []string{"personnel.manage"}}, permission.ErrNotAssignable, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockRoleStore{}
+			r, err := roleService(m).Create(permission.WithSystem(context.Background()), tt.p)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if len(m.saved) != 0 {
+					t.Error("invalid role must not be saved")
+				}
+				return
+			}
+			if r.ID != 9 || r.Name != "Magazziniere" {
+				t.Errorf("role = %+v, want ID 9 named Magazziniere", r)
+			}
+			if len(r.Permissions) != len(tt.perms) {
+				t.Fatalf("permissions = %v, want %v", r.Permissions, tt.perms)
+			}
+			for i := range tt.perms {
+				if r.Permissions[i] != tt.perms[i] {
+					t.Errorf("permissions = %v, want %v", r.Permissions, tt.perms)
+				}
+			}
+		})
+	}
+}
+
+func TestRoleChangesNeedManageRoles(t *testing.T) {
+	m := &mockRoleStore{}
+	svc := roleService(m)
+	ctx := permission.NewContext(context.Background(), permission.ForRole(permission.RolePharmacist, nil))
+	p := role.Params{ID: 3, PharmacyID: 7, Name: "Magazziniere", Permissions: []string{"orders.view"}}
+
+	if _, err := svc.Create(ctx, p); !errors.Is(err, permission.ErrForbidden) {
+		t.Errorf("create: error = %v, want ErrForbidden", err)
+	}
+	if err := svc.Update(ctx, p); !errors.Is(err, permission.ErrForbidden) {
+		t.Errorf("update: error = %v, want ErrForbidden", err)
+	}
+	if err := svc.Delete(ctx, 7, 3); !errors.Is(err, permission.ErrForbidden) {
+		t.Errorf("delete: error = %v, want ErrForbidden", err)
+	}
+	if len(m.saved) != 0 || m.deleted != 0 {
+		t.Error("repository must not be called without permission")
+	}
+
+	owner := permission.NewContext(context.Background(), permission.ForRole(permission.RoleOwner, nil))
+	if err := svc.Update(owner, p); err != nil {
+		t.Errorf("owner update: error = %v", err)
+	}
+}
+
+func TestDeleteRoleInUse(t *testing.T) {
+	m := &mockRoleStore{err: role.ErrInUse}
+	if err := roleService(m).Delete(permission.WithSystem(context.Background()), 7, 3); !errors.Is(err, role.ErrInUse) {
+		t.Errorf("error = %v, want ErrInUse", err)
+	}
+}
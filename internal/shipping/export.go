@@ -0,0 +1,82 @@
+package shipping
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// courierColumns is the header row of the courier upload file.
+var courierColumns = []string{
+	"riferimento", "destinatario", "indirizzo", "civico", "cap", "localita", "provincia", "nazione",
+	"note_consegna", "telefono", "email", "contenuto", "colli", "tracking",
+}
+
+// WriteCourierCSV writes the batch manifest in the semicolon-separated format
+// accepted by the courier upload: one parcel per order, referenced by order ID.
+// Addresses not yet structured are written whole in the street column.
+func WriteCourierCSV(w io.Writer, shipments []Shipment) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+
+	if err := cw.Write(courierColumns); err != nil {
+		return fmt.Errorf("writing courier header: %w", err)
+	}
+	for _, s := range shipments {
+		a := s.DeliveryAddress
+		street := a.Street
+		if a.IsZero() {
+			street = a.Legacy
+		}
+		record := []string{
+			strconv.FormatInt(s.OrderID, 10),
+			cell(s.FirstName + " " + s.LastName),
+			cell(street),
+			cell(a.HouseNumber),
+			a.CAP,
+			cell(a.City),
+			a.Province,
+			a.Country,
+			cell(a.Notes),
+			phoneCell(s.Phone),
+			cell(s.Email),
+			cell(s.MedicationName),
+			"1",
+			cell(s.TrackingNumber),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("writing courier row for order %d: %w", s.OrderID, err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("flushing courier csv: %w", err)
+	}
+	return nil
+}
+
+// cell neutralises values a spreadsheet would evaluate as formulas.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if s != "" && strings.ContainsRune("=@+-\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// phonePattern is a phone number as the courier expects it: digits and
+// spaces, with an optional leading '+'.
+var phonePattern = regexp.MustCompile(`^\+?[0-9 ]*$`)
+
+// phoneCell writes well-formed phone numbers verbatim, so an international
+// '+' survives, and anything else in the free-text field through cell.
+func phoneCell(s string) string {
+	if phonePattern.MatchString(s) {
+		return s
+	}
+	return cell(s)
+}
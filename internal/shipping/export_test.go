@@ -0,0 +1,92 @@
+package shipping_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
+	"github.com/giorgiovilardo/pharmarecall/internal/shipping"
+)
+
+func TestWriteCourierCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := shipping.WriteCourierCSV(&buf, []shipping.Shipment{
+		{
+			OrderID:   42,
+			FirstName: "Mario",
+			LastName:  "Rossi",
+			DeliveryAddress: address.Address{
+				Street: "Via Roma", HouseNumber: "1", CAP: "20121", City: "Milano", Province: "MI", Country: "IT",
+				Notes: "citofono Rossi; 2° piano",
+			},
+			Phone:          "+39 333 1234567",
+			Email:          "mario@example.com",
+			MedicationName: "Tachipirina",
+			TrackingNumber: "BRT123",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if lines[0] != "riferimento;destinatario;indirizzo;civico;cap;localita;provincia;nazione;note_consegna;telefono;email;contenuto;colli;tracking" {
+		t.Errorf("header = %q", lines[0])
+	}
+	want := `42;Mario Rossi;Via Roma;1;20121;Milano;MI;IT;"citofono Rossi; 2° piano";+39 333 1234567;mario@example.com;Tachipirina;1;BRT123`
+	if lines[1] != want {
+		t.Errorf("row = %q, want %q", lines[1], want)
+	}
+}
+
+func TestWriteCourierCSVNeutralisesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	err := shipping.WriteCourierCSV(&buf, []shipping.Shipment{
+		{OrderID: 1, FirstName: "=cmd()", LastName: "x", MedicationName: "@SUM(A1)"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "'=cmd() x") || !strings.Contains(out, "'@SUM(A1)") {
+		t.Errorf("formulas not neutralised:\n%s", out)
+	}
+}
+
+func TestWriteCourierCSVPhone(t *testing.T) {
+	cases := []struct {
+		phone, want string
+	}{
+		{"+39 333 1234567", "+39 333 1234567"},
+		{"0521 234567", "0521 234567"},
+		{"=HYPERLINK(\"http://evil\")", `"'=HYPERLINK(""http://evil"")"`},
+		{"+cmd|' /C calc'!A0", "'+cmd|' /C calc'!A0"},
+		{"-2+3", "'-2+3"},
+	}
+	for _, tc := range cases {
+		var buf bytes.Buffer
+		if err := shipping.WriteCourierCSV(&buf, []shipping.Shipment{{OrderID: 1, Phone: tc.phone}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(buf.String(), ";"+tc.want+";") {
+			t.Errorf("phone %q written as:\n%s\nwant %s", tc.phone, buf.String(), tc.want)
+		}
+	}
+}
+
+func TestWriteCourierCSVLegacyAddressInStreetColumn(t *testing.T) {
+	var buf bytes.Buffer
+	err := shipping.WriteCourierCSV(&buf, []shipping.Shipment{
+		{OrderID: 7, FirstName: "Anna", LastName: "Verdi", DeliveryAddress: address.Address{Legacy: "Cascina Bianca, Lodi"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "7;Anna Verdi;Cascina Bianca, Lodi;;;;;;;") {
+		t.Errorf("legacy address not in street column:\n%s", buf.String())
+	}
+}
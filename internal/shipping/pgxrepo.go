@@ -0,0 +1,220 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
+	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Ensure PgxRepository satisfies Repository at compile time.
+var _ Repository = (*PgxRepository)(nil)
+
+// PgxRepository implements all shipping port interfaces using pgx/sqlc.
+type PgxRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewPgxRepository creates a new PgxRepository.
+func NewPgxRepository(pool *pgxpool.Pool, queries *db.Queries) *PgxRepository {
+	return &PgxRepository{pool: pool, queries: queries}
+}
+
+func (r *PgxRepository) ListShippable(ctx context.Context, pharmacyID int64) ([]ShippableOrder, error) {
+	rows, err := r.queries.ListShippableOrders(ctx, pharmacyID)
+	if err != nil {
+		return nil, fmt.Errorf("listing shippable orders: %w", err)
+	}
+	result := make([]ShippableOrder, len(rows))
+	for i, row := range rows {
+		result[i] = ShippableOrder{
+			OrderID:                row.OrderID,
+			EstimatedDepletionDate: row.EstimatedDepletionDate.Time,
+			MedicationName:         row.MedicationName,
+			FirstName:              row.FirstName,
+			LastName:               row.LastName,
+			DeliveryAddress: address.WithLegacy(address.Address{
+				Street:      row.DeliveryStreet,
+				HouseNumber: row.DeliveryHouseNumber,
+				CAP:         row.DeliveryCap,
+				City:        row.DeliveryCity,
+				Province:    row.DeliveryProvince,
+				Country:     row.DeliveryCountry,
+				Notes:       row.DeliveryNotes,
+			}, row.DeliveryAddressLegacy),
+		}
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) CreateBatch(ctx context.Context, pharmacyID int64, orderIDs []int64) (Batch, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return Batch{}, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := r.queries.WithTx(tx)
+	row, err := q.CreateShippingBatch(ctx, pharmacyID)
+	if err != nil {
+		return Batch{}, fmt.Errorf("inserting shipping batch: %w", err)
+	}
+
+	added, err := q.AddShipmentsToBatch(ctx, db.AddShipmentsToBatchParams{
+		BatchID:    row.ID,
+		OrderIds:   orderIDs,
+		PharmacyID: pharmacyID,
+	})
+	if err != nil {
+		return Batch{}, fmt.Errorf("adding shipments to batch: %w", err)
+	}
+	if added == 0 {
+		return Batch{}, ErrNoShippableOrders
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Batch{}, fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return Batch{
+		ID:          row.ID,
+		PharmacyID:  row.PharmacyID,
+		Status:      row.Status,
+		CreatedAt:   row.CreatedAt.Time,
+		ParcelCount: int(added),
+	}, nil
+}
+
+func (r *PgxRepository) ListBatches(ctx context.Context, pharmacyID int64) ([]Batch, error) {
+	rows, err := r.queries.ListShippingBatches(ctx, pharmacyID)
+	if err != nil {
+		return nil, fmt.Errorf("listing shipping batches: %w", err)
+	}
+	result := make([]Batch, len(rows))
+	for i, row := range rows {
+		result[i] = Batch{
+			ID:             row.ID,
+			PharmacyID:     row.PharmacyID,
+			Status:         row.Status,
+			CreatedAt:      row.CreatedAt.Time,
+			ShippedAt:      timestampPtr(row.ShippedAt),
+			ParcelCount:    int(row.ParcelCount),
+			DeliveredCount: int(row.DeliveredCount),
+		}
+	}
+	return result, nil
+}
+
+func (r *PgxRepository) GetBatch(ctx context.Context, pharmacyID, batchID int64) (Batch, []Shipment, error) {
+	row, err := r.queries.GetShippingBatch(ctx, db.GetShippingBatchParams{ID: batchID, PharmacyID: pharmacyID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Batch{}, nil, ErrNotFound
+		}
+		return Batch{}, nil, fmt.Errorf("querying shipping batch: %w", err)
+	}
+	b := Batch{
+		ID:             row.ID,
+		PharmacyID:     row.PharmacyID,
+		Status:         row.Status,
+		CreatedAt:      row.CreatedAt.Time,
+		ShippedAt:      timestampPtr(row.ShippedAt),
+		ParcelCount:    int(row.ParcelCount),
+		DeliveredCount: int(row.DeliveredCount),
+	}
+
+	rows, err := r.queries.ListBatchShipments(ctx, db.ListBatchShipmentsParams{BatchID: batchID, PharmacyID: pharmacyID})
+	if err != nil {
+		return Batch{}, nil, fmt.Errorf("listing batch shipments: %w", err)
+	}
+	shipments := make([]Shipment, len(rows))
+	for i, s := range rows {
+		shipments[i] = Shipment{
+			OrderID:        s.OrderID,
+			BatchID:        s.BatchID,
+			TrackingNumber: s.TrackingNumber,
+			Status:         s.Status,
+			MedicationName: s.MedicationName,
+			FirstName:      s.FirstName,
+			LastName:       s.LastName,
+			Phone:          s.Phone,
+			Email:          s.Email,
+			DeliveryAddress: address.WithLegacy(address.Address{
+				Street:      s.DeliveryStreet,
+				HouseNumber: s.DeliveryHouseNumber,
+				CAP:         s.DeliveryCap,
+				City:        s.DeliveryCity,
+				Province:    s.DeliveryProvince,
+				Country:     s.DeliveryCountry,
+				Notes:       s.DeliveryNotes,
+			}, s.DeliveryAddressLegacy),
+		}
+	}
+	return b, shipments, nil
+}
+
+func (r *PgxRepository) UpdateTracking(ctx context.Context, pharmacyID, batchID, orderID int64, trackingNumber string) error {
+	if err := r.queries.UpdateShipmentTracking(ctx, db.UpdateShipmentTrackingParams{
+		TrackingNumber: trackingNumber,
+		BatchID:        batchID,
+		OrderID:        orderID,
+		PharmacyID:     pharmacyID,
+	}); err != nil {
+		return fmt.Errorf("updating shipment tracking: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) MarkShipped(ctx context.Context, pharmacyID, batchID int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := r.queries.WithTx(tx)
+	n, err := q.MarkShippingBatchShipped(ctx, db.MarkShippingBatchShippedParams{ID: batchID, PharmacyID: pharmacyID})
+	if err != nil {
+		return fmt.Errorf("updating shipping batch: %w", err)
+	}
+	if n == 0 {
+		// Nothing updated: the batch is not open, or not this pharmacy's.
+		if _, err := q.GetShippingBatch(ctx, db.GetShippingBatchParams{ID: batchID, PharmacyID: pharmacyID}); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("querying shipping batch: %w", err)
+		}
+		return ErrBatchAlreadyShipped
+	}
+	if err := q.MarkBatchShipmentsShipped(ctx, batchID); err != nil {
+		return fmt.Errorf("updating batch shipments: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) MarkDelivered(ctx context.Context, pharmacyID, orderID int64) error {
+	n, err := r.queries.MarkShipmentDelivered(ctx, db.MarkShipmentDeliveredParams{OrderID: orderID, PharmacyID: pharmacyID})
+	if err != nil {
+		return fmt.Errorf("updating shipment: %w", err)
+	}
+	if n == 0 {
+		return ErrInvalidTransition
+	}
+	return nil
+}
+
+func timestampPtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
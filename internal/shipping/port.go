@@ -0,0 +1,53 @@
+package shipping
+
+import "context"
+
+// ShippableLister lists prepared shipping orders that are not in any batch.
+type ShippableLister interface {
+	ListShippable(ctx context.Context, pharmacyID int64) ([]ShippableOrder, error)
+}
+
+// BatchCreator creates a batch with the given orders. Orders that are not
+// shippable for the pharmacy are skipped; if none remain it returns ErrNoShippableOrders.
+type BatchCreator interface {
+	CreateBatch(ctx context.Context, pharmacyID int64, orderIDs []int64) (Batch, error)
+}
+
+// BatchLister lists the batches of a pharmacy, newest first.
+type BatchLister interface {
+	ListBatches(ctx context.Context, pharmacyID int64) ([]Batch, error)
+}
+
+// BatchGetter retrieves a batch and its shipments, scoped to a pharmacy.
+type BatchGetter interface {
+	GetBatch(ctx context.Context, pharmacyID, batchID int64) (Batch, []Shipment, error)
+}
+
+// TrackingUpdater sets the courier tracking number of a shipment.
+type TrackingUpdater interface {
+	UpdateTracking(ctx context.Context, pharmacyID, batchID, orderID int64, trackingNumber string) error
+}
+
+// BatchShipper marks an open batch and its shipments as shipped.
+// Returns ErrNotFound if the pharmacy has no such batch and
+// ErrBatchAlreadyShipped if the batch is not open.
+type BatchShipper interface {
+	MarkShipped(ctx context.Context, pharmacyID, batchID int64) error
+}
+
+// DeliveryMarker marks a shipped shipment as delivered.
+// Returns ErrInvalidTransition if the shipment is not in the shipped state.
+type DeliveryMarker interface {
+	MarkDelivered(ctx context.Context, pharmacyID, orderID int64) error
+}
+
+// Repository composes all ports — used only by NewService for convenient wiring.
+type Repository interface {
+	ShippableLister
+	BatchCreator
+	BatchLister
+	BatchGetter
+	TrackingUpdater
+	BatchShipper
+	DeliveryMarker
+}
@@ -0,0 +1,128 @@
+package shipping
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+)
+
+// ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
+type ServiceDeps struct {
+	Shippable ShippableLister
+	Creator   BatchCreator
+	Lister    BatchLister
+	Getter    BatchGetter
+	Tracking  TrackingUpdater
+	Shipper   BatchShipper
+	Delivery  DeliveryMarker
+}
+
+// Service contains shipping domain business logic.
+type Service struct {
+	deps ServiceDeps
+}
+
+// NewService is the production constructor — takes a Repository (satisfies all ports).
+func NewService(repo Repository) *Service {
+	return &Service{deps: ServiceDeps{
+		Shippable: repo,
+		Creator:   repo,
+		Lister:    repo,
+		Getter:    repo,
+		Tracking:  repo,
+		Shipper:   repo,
+		Delivery:  repo,
+	}}
+}
+
+// NewServiceWith is the test constructor — inject only what you need, rest stays nil.
+func NewServiceWith(d ServiceDeps) *Service {
+	return &Service{deps: d}
+}
+
+// ListShippable returns prepared shipping orders waiting to be put in a batch.
+func (s *Service) ListShippable(ctx context.Context, pharmacyID int64) ([]ShippableOrder, error) {
+	orders, err := s.deps.Shippable.ListShippable(ctx, pharmacyID)
+	if err != nil {
+		return nil, fmt.Errorf("listing shippable orders: %w", err)
+	}
+	return orders, nil
+}
+
+// CreateBatch groups the given prepared orders into a new batch.
+func (s *Service) CreateBatch(ctx context.Context, pharmacyID int64, orderIDs []int64) (Batch, error) {
+	if err := permission.Check(ctx, permission.ManageShipping); err != nil {
+		return Batch{}, err
+	}
+	if len(orderIDs) == 0 {
+		return Batch{}, ErrNoShippableOrders
+	}
+	b, err := s.deps.Creator.CreateBatch(ctx, pharmacyID, orderIDs)
+	if err != nil {
+		return Batch{}, fmt.Errorf("creating shipping batch: %w", err)
+	}
+	return b, nil
+}
+
+// ListBatches returns the batches of a pharmacy.
+func (s *Service) ListBatches(ctx context.Context, pharmacyID int64) ([]Batch, error) {
+	batches, err := s.deps.Lister.ListBatches(ctx, pharmacyID)
+	if err != nil {
+		return nil, fmt.Errorf("listing shipping batches: %w", err)
+	}
+	return batches, nil
+}
+
+// GetBatch returns a batch and its shipments (the manifest).
+func (s *Service) GetBatch(ctx context.Context, pharmacyID, batchID int64) (Batch, []Shipment, error) {
+	b, shipments, err := s.deps.Getter.GetBatch(ctx, pharmacyID, batchID)
+	if err != nil {
+		return Batch{}, nil, fmt.Errorf("getting shipping batch: %w", err)
+	}
+	return b, shipments, nil
+}
+
+// SetTracking records the courier tracking numbers of a batch, keyed by order ID.
+// Numbers are trimmed; an empty value clears the tracking number.
+func (s *Service) SetTracking(ctx context.Context, pharmacyID, batchID int64, tracking map[int64]string) error {
+	if err := permission.Check(ctx, permission.ManageShipping); err != nil {
+		return err
+	}
+	for _, number := range tracking {
+		if utf8.RuneCountInString(strings.TrimSpace(number)) > MaxTrackingLength {
+			return ErrTrackingTooLong
+		}
+	}
+	for orderID, number := range tracking {
+		if err := s.deps.Tracking.UpdateTracking(ctx, pharmacyID, batchID, orderID, strings.TrimSpace(number)); err != nil {
+			return fmt.Errorf("updating tracking for order %d: %w", orderID, err)
+		}
+	}
+	return nil
+}
+
+// MarkShipped records that the batch was handed to the courier.
+func (s *Service) MarkShipped(ctx context.Context, pharmacyID, batchID int64) error {
+	if err := permission.Check(ctx, permission.ManageShipping); err != nil {
+		return err
+	}
+	if err := s.deps.Shipper.MarkShipped(ctx, pharmacyID, batchID); err != nil {
+		return fmt.Errorf("marking batch shipped: %w", err)
+	}
+	return nil
+}
+
+// MarkDelivered records that the parcel for an order reached the patient.
+// The order itself is fulfilled separately by staff, as for pickups.
+func (s *Service) MarkDelivered(ctx context.Context, pharmacyID, orderID int64) error {
+	if err := permission.Check(ctx, permission.DeliverShipments); err != nil {
+		return err
+	}
+	if err := s.deps.Delivery.MarkDelivered(ctx, pharmacyID, orderID); err != nil {
+		return fmt.Errorf("marking shipment delivered: %w", err)
+	}
+	return nil
+}
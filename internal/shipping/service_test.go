@@ -0,0 +1,158 @@
+package shipping_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/shipping"
+)
+
+// --- Mocks ---
+
+type mockCreator struct {
+	called     bool
+	pharmacyID int64
+	orderIDs   []int64
+	result     shipping.Batch
+	err        error
+}
+
+func (m *mockCreator) CreateBatch(_ context.Context, pharmacyID int64, orderIDs []int64) (shipping.Batch, error) {
+	m.called = true
+	m.pharmacyID = pharmacyID
+	m.orderIDs = orderIDs
+	return m.result, m.err
+}
+
+type mockTracking struct {
+	updates map[int64]string
+	err     error
+}
+
+func (m *mockTracking) UpdateTracking(_ context.Context, _, _, orderID int64, trackingNumber string) error {
+	if m.updates == nil {
+		m.updates = map[int64]string{}
+	}
+	m.updates[orderID] = trackingNumber
+	return m.err
+}
+
+type mockShipper struct {
+	err error
+}
+
+func (m *mockShipper) MarkShipped(_ context.Context, _, _ int64) error {
+	return m.err
+}
+
+type mockDelivery struct {
+	orderID int64
+	err     error
+}
+
+func (m *mockDelivery) MarkDelivered(_ context.Context, _, orderID int64) error {
+	m.orderID = orderID
+	return m.err
+}
+
+// --- CreateBatch tests ---
+
+func TestCreateBatchPassesOrders(t *testing.T) {
+	creator := &mockCreator{result: shipping.Batch{ID: 3, ParcelCount: 2}}
+	svc := shipping.NewServiceWith(shipping.ServiceDeps{Creator: creator})
+
+	b, err := svc.CreateBatch(permission.WithSystem(context.Background()), 7, []int64{10, 11})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.ID != 3 {
+		t.Errorf("batch ID = %d, want 3", b.ID)
+	}
+	if creator.pharmacyID != 7 || len(creator.orderIDs) != 2 {
+		t.Errorf("creator got pharmacy %d orders %v", creator.pharmacyID, creator.orderIDs)
+	}
+}
+
+func TestCreateBatchWithoutOrdersFails(t *testing.T) {
+	creator := &mockCreator{}
+	svc := shipping.NewServiceWith(shipping.ServiceDeps{Creator: creator})
+
+	_, err := svc.CreateBatch(permission.WithSystem(context.Background()), 7, nil)
+	if !errors.Is(err, shipping.ErrNoShippableOrders) {
+		t.Errorf("expected ErrNoShippableOrders, got %v", err)
+	}
+	if creator.called {
+		t.Error("creator should not be called without orders")
+	}
+}
+
+func TestCreateBatchPropagatesNoShippableOrders(t *testing.T) {
+	creator := &mockCreator{err: shipping.ErrNoShippableOrders}
+	svc := shipping.NewServiceWith(shipping.ServiceDeps{Creator: creator})
+
+	_, err := svc.CreateBatch(permission.WithSystem(context.Background()), 7, []int64{99})
+	if !errors.Is(err, shipping.ErrNoShippableOrders) {
+		t.Errorf("expected ErrNoShippableOrders, got %v", err)
+	}
+}
+
+// --- SetTracking tests ---
+
+func TestSetTrackingTrimsNumbers(t *testing.T) {
+	tracking := &mockTracking{}
+	svc := shipping.NewServiceWith(shipping.ServiceDeps{Tracking: tracking})
+
+	err := svc.SetTracking(permission.WithSystem(context.Background()), 7, 3, map[int64]string{10: "  BRT123  ", 11: ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tracking.updates[10] != "BRT123" {
+		t.Errorf("tracking for 10 = %q, want BRT123", tracking.updates[10])
+	}
+	if v, ok := tracking.updates[11]; !ok || v != "" {
+		t.Errorf("tracking for 11 = %q (set %v), want cleared", v, ok)
+	}
+}
+
+func TestSetTrackingRejectsTooLong(t *testing.T) {
+	tracking := &mockTracking{}
+	svc := shipping.NewServiceWith(shipping.ServiceDeps{Tracking: tracking})
+
+	long := make([]byte, shipping.MaxTrackingLength+1)
+	for i := range long {
+		long[i] = 'A'
+	}
+	err := svc.SetTracking(permission.WithSystem(context.Background()), 7, 3, map[int64]string{10: "OK", 11: string(long)})
+	if !errors.Is(err, shipping.ErrTrackingTooLong) {
+		t.Errorf("expected ErrTrackingTooLong, got %v", err)
+	}
+	if len(tracking.updates) != 0 {
+		t.Error("no tracking number should be saved when one is invalid")
+	}
+}
+
+// --- MarkShipped / MarkDelivered tests ---
+
+func TestMarkShippedPropagatesAlreadyShipped(t *testing.T) {
+	svc := shipping.NewServiceWith(shipping.ServiceDeps{Shipper: &mockShipper{err: shipping.ErrBatchAlreadyShipped}})
+
+	err := svc.MarkShipped(permission.WithSystem(context.Background()), 7, 3)
+	if !errors.Is(err, shipping.ErrBatchAlreadyShipped) {
+		t.Errorf("expected ErrBatchAlreadyShipped, got %v", err)
+	}
+}
+
+func TestMarkDeliveredPropagatesInvalidTransition(t *testing.T) {
+	delivery := &mockDelivery{err: shipping.ErrInvalidTransition}
+	svc := shipping.NewServiceWith(shipping.ServiceDeps{Delivery: delivery})
+
+	err := svc.MarkDelivered(permission.WithSystem(context.Background()), 7, 10)
+	if !errors.Is(err, shipping.ErrInvalidTransition) {
+		t.Errorf("expected ErrInvalidTransition, got %v", err)
+	}
+	if delivery.orderID != 10 {
+		t.Errorf("delivery orderID = %d, want 10", delivery.orderID)
+	}
+}
@@ -0,0 +1,70 @@
+// Package shipping groups prepared orders for home delivery into batches
+// handed to a courier, and tracks each parcel until it is delivered.
+package shipping
+
+import (
+	"errors"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
+)
+
+var (
+	ErrNotFound            = errors.New("shipping.not_found")
+	ErrNoShippableOrders   = errors.New("shipping.no_shippable_orders")
+	ErrBatchAlreadyShipped = errors.New("shipping.batch_already_shipped")
+	ErrInvalidTransition   = errors.New("shipping.invalid_transition")
+	ErrTrackingTooLong     = errors.New("shipping.tracking_too_long")
+)
+
+// Batch status constants.
+const (
+	BatchStatusOpen    = "open"
+	BatchStatusShipped = "shipped"
+)
+
+// Shipment status constants. A shipment is "ready" while its batch waits for
+// the courier, "shipped" once the batch is handed over, then "delivered".
+const (
+	StatusReady     = "ready"
+	StatusShipped   = "shipped"
+	StatusDelivered = "delivered"
+)
+
+// MaxTrackingLength matches the tracking_number column size.
+const MaxTrackingLength = 100
+
+// Batch is a group of parcels handed to the courier together.
+type Batch struct {
+	ID             int64
+	PharmacyID     int64
+	Status         string
+	CreatedAt      time.Time
+	ShippedAt      *time.Time
+	ParcelCount    int
+	DeliveredCount int
+}
+
+// Shipment is one order in a batch, joined with what the courier needs.
+type Shipment struct {
+	OrderID         int64
+	BatchID         int64
+	TrackingNumber  string
+	Status          string
+	MedicationName  string
+	FirstName       string
+	LastName        string
+	Phone           string
+	Email           string
+	DeliveryAddress address.Address
+}
+
+// ShippableOrder is a prepared order for a shipping patient not yet in a batch.
+type ShippableOrder struct {
+	OrderID                int64
+	EstimatedDepletionDate time.Time
+	MedicationName         string
+	FirstName              string
+	LastName               string
+	DeliveryAddress        address.Address
+}
@@ -0,0 +1,83 @@
+// Package sms sends text messages through an HTTP SMS gateway.
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NationalNumber keeps only the digits of a phone number as typed and drops
+// the Italian country prefix, so "+39 333 123 4567", "0039 3331234567" and
+// "333-1234567" all give 3331234567.
+func NationalNumber(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	switch {
+	case strings.HasPrefix(digits, "0039"):
+		return digits[4:]
+	case strings.HasPrefix(digits, "39") && len(digits) > 10:
+		return digits[2:]
+	}
+	return digits
+}
+
+// ItalianNumber returns the international form, +39 and the national
+// digits, that gateways expect for a number typed by staff or a patient.
+func ItalianNumber(phone string) string {
+	return "+39" + NationalNumber(phone)
+}
+
+// Gateway posts each message as JSON, {"to": ..., "from": ..., "text": ...},
+// to URL with Token as a bearer token, the request most SMS providers and
+// small relays accept. Any 2xx reply means the message was taken.
+type Gateway struct {
+	URL    string
+	Token  string
+	From   string
+	Client *http.Client
+}
+
+// Send texts the message to the phone number to.
+func (g Gateway) Send(ctx context.Context, to, text string) error {
+	body, err := json.Marshal(struct {
+		To   string `json:"to"`
+		From string `json:"from,omitempty"`
+		Text string `json:"text"`
+	}{to, g.From, text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling SMS gateway: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("SMS gateway replied %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
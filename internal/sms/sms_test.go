@@ -0,0 +1,48 @@
+package sms_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/sms"
+)
+
+func TestSend(t *testing.T) {
+	var got struct{ To, From, Text string }
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	g := sms.Gateway{URL: srv.URL, Token: "t0k", From: "Farmacia"}
+	if err := g.Send(context.Background(), "+393331234567", "codice 123456"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.To != "+393331234567" || got.From != "Farmacia" || got.Text != "codice 123456" {
+		t.Errorf("request = %+v", got)
+	}
+	if auth != "Bearer t0k" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
+
+func TestSendReportsGatewayErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "invalid number", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	err := sms.Gateway{URL: srv.URL}.Send(context.Background(), "+39", "x")
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "invalid number") {
+		t.Errorf("err = %v, want the gateway's reply", err)
+	}
+}
@@ -0,0 +1,127 @@
+package sso
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCProvider is the Provider for real identity providers. It discovers
+// each issuer's endpoints once and caches them with their signing keys.
+type OIDCProvider struct {
+	client *http.Client
+
+	mu        sync.Mutex
+	providers map[string]*oidc.Provider
+}
+
+// NewOIDCProvider returns a Provider making its requests with client.
+func NewOIDCProvider(client *http.Client) *OIDCProvider {
+	return &OIDCProvider{client: client, providers: map[string]*oidc.Provider{}}
+}
+
+func (p *OIDCProvider) AuthURL(ctx context.Context, c Config, redirectURL, state, nonce, verifier string) (string, error) {
+	prov, err := p.discover(ctx, c.Issuer)
+	if err != nil {
+		return "", err
+	}
+	return oauthConfig(prov, c, redirectURL).AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, c Config, redirectURL, code, nonce, verifier string) (Claims, error) {
+	prov, err := p.discover(ctx, c.Issuer)
+	if err != nil {
+		return Claims{}, err
+	}
+	ctx = oidc.ClientContext(ctx, p.client)
+
+	tok, err := oauthConfig(prov, c, redirectURL).Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Claims{}, fmt.Errorf("redeeming code: %w", err)
+	}
+	raw, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return Claims{}, fmt.Errorf("token response without id_token: %w", ErrInvalidResponse)
+	}
+	idt, err := prov.Verifier(&oidc.Config{ClientID: c.ClientID}).Verify(ctx, raw)
+	if err != nil {
+		return Claims{}, fmt.Errorf("verifying id token: %w", err)
+	}
+	if idt.Nonce != nonce {
+		return Claims{}, fmt.Errorf("id token nonce mismatch: %w", ErrInvalidResponse)
+	}
+
+	var fields map[string]any
+	if err := idt.Claims(&fields); err != nil {
+		return Claims{}, fmt.Errorf("decoding id token claims: %w", err)
+	}
+	email, _ := fields["email"].(string)
+	name, _ := fields["name"].(string)
+	return Claims{
+		Subject:       idt.Subject,
+		Email:         email,
+		EmailVerified: isTrue(fields["email_verified"]),
+		Name:          name,
+		RoleValues:    stringValues(fields[c.RoleClaim]),
+	}, nil
+}
+
+// discover fetches the issuer's metadata, once per issuer. Failures are not
+// cached, so a provider that was down is tried again at the next login.
+func (p *OIDCProvider) discover(ctx context.Context, issuer string) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if prov, ok := p.providers[issuer]; ok {
+		return prov, nil
+	}
+	prov, err := oidc.NewProvider(oidc.ClientContext(ctx, p.client), issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discovering %s: %w", issuer, err)
+	}
+	p.providers[issuer] = prov
+	return prov, nil
+}
+
+func oauthConfig(prov *oidc.Provider, c Config, redirectURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Endpoint:     prov.Endpoint(),
+		RedirectURL:  redirectURL,
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+}
+
+// isTrue reads a boolean claim; some providers send "true" as a string.
+func isTrue(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+// stringValues reads a claim holding a string or a list of strings.
+func stringValues(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
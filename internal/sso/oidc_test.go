@@ -0,0 +1,83 @@
+package sso_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/sso"
+	"github.com/giorgiovilardo/pharmarecall/internal/sso/ssotest"
+)
+
+// authorize follows the authorization URL to the mock provider and returns
+// the state and code it redirects back with.
+func authorize(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorizing: %v", err)
+	}
+	resp.Body.Close()
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parsing redirect: %v", err)
+	}
+	return loc.Query().Get("state"), loc.Query().Get("code")
+}
+
+func TestOIDCProviderAgainstMockProvider(t *testing.T) {
+	idp := ssotest.NewProvider(t)
+	idp.SignInAs(ssotest.User{Subject: "u-1", Email: "g.verdi@example.it", EmailVerified: true, Name: "Giulia Verdi", Groups: []string{"farmacisti"}})
+
+	store := &mockConfigStore{config: sso.Config{
+		PharmacyID:     7,
+		Enabled:        true,
+		Issuer:         idp.Issuer,
+		ClientID:       idp.ClientID,
+		ClientSecret:   idp.ClientSecret,
+		RoleClaim:      "groups",
+		PersonnelValue: "farmacisti",
+	}}
+	svc := sso.NewService(store, sso.NewOIDCProvider(http.DefaultClient))
+	const redirect = "http://localhost:8080/login/sso/callback"
+
+	l, err := svc.Begin(context.Background(), 7, redirect)
+	if err != nil {
+		t.Fatalf("beginning: %v", err)
+	}
+	state, code := authorize(t, l.URL)
+
+	id, err := svc.Complete(context.Background(), 7, redirect, l, state, code)
+	if err != nil {
+		t.Fatalf("completing: %v", err)
+	}
+	want := sso.Identity{Issuer: idp.Issuer, Subject: "u-1", Email: "g.verdi@example.it", Name: "Giulia Verdi", Role: "personnel"}
+	if id != want {
+		t.Errorf("identity = %+v, want %+v", id, want)
+	}
+
+	if _, err := svc.Complete(context.Background(), 7, redirect, l, state, code); err == nil {
+		t.Error("a code must not be redeemed twice")
+	}
+}
+
+func TestOIDCProviderRejectsWrongVerifier(t *testing.T) {
+	idp := ssotest.NewProvider(t)
+	idp.SignInAs(ssotest.User{Subject: "u-1", Email: "g.verdi@example.it", EmailVerified: true})
+	store := &mockConfigStore{config: sso.Config{PharmacyID: 7, Enabled: true, Issuer: idp.Issuer, ClientID: idp.ClientID, ClientSecret: idp.ClientSecret, RoleClaim: "groups"}}
+	svc := sso.NewService(store, sso.NewOIDCProvider(http.DefaultClient))
+	const redirect = "http://localhost:8080/login/sso/callback"
+
+	l, err := svc.Begin(context.Background(), 7, redirect)
+	if err != nil {
+		t.Fatalf("beginning: %v", err)
+	}
+	state, code := authorize(t, l.URL)
+	l.Verifier = "stolen-code-without-the-verifier-aaaaaaaaaaa"
+
+	if _, err := svc.Complete(context.Background(), 7, redirect, l, state, code); err == nil {
+		t.Error("expected the exchange to fail without the right PKCE verifier")
+	}
+}
@@ -0,0 +1,61 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Ensure PgxRepository satisfies Repository at compile time.
+var _ Repository = (*PgxRepository)(nil)
+
+// PgxRepository implements all sso port interfaces using pgx/sqlc.
+type PgxRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewPgxRepository creates a new PgxRepository.
+func NewPgxRepository(pool *pgxpool.Pool, queries *db.Queries) *PgxRepository {
+	return &PgxRepository{pool: pool, queries: queries}
+}
+
+func (r *PgxRepository) GetConfig(ctx context.Context, pharmacyID int64) (Config, error) {
+	row, err := r.queries.GetPharmacySSO(ctx, pharmacyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Config{PharmacyID: pharmacyID, RoleClaim: DefaultRoleClaim}, nil
+		}
+		return Config{}, fmt.Errorf("querying pharmacy sso: %w", err)
+	}
+	return Config{
+		PharmacyID:     row.PharmacyID,
+		Enabled:        row.Enabled,
+		Issuer:         row.Issuer,
+		ClientID:       row.ClientID,
+		ClientSecret:   row.ClientSecret,
+		RoleClaim:      row.RoleClaim,
+		OwnerValue:     row.OwnerValue,
+		PersonnelValue: row.PersonnelValue,
+	}, nil
+}
+
+func (r *PgxRepository) SaveConfig(ctx context.Context, c Config) error {
+	if err := r.queries.UpsertPharmacySSO(ctx, db.UpsertPharmacySSOParams{
+		PharmacyID:     c.PharmacyID,
+		Enabled:        c.Enabled,
+		Issuer:         c.Issuer,
+		ClientID:       c.ClientID,
+		ClientSecret:   c.ClientSecret,
+		RoleClaim:      c.RoleClaim,
+		OwnerValue:     c.OwnerValue,
+		PersonnelValue: c.PersonnelValue,
+	}); err != nil {
+		return fmt.Errorf("upserting pharmacy sso: %w", err)
+	}
+	return nil
+}
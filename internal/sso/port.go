@@ -0,0 +1,28 @@
+package sso
+
+import "context"
+
+// ConfigGetter loads a pharmacy's provider settings. A pharmacy that never
+// configured one gets a disabled Config with the default role claim.
+type ConfigGetter interface {
+	GetConfig(ctx context.Context, pharmacyID int64) (Config, error)
+}
+
+// ConfigSaver saves a pharmacy's provider settings.
+type ConfigSaver interface {
+	SaveConfig(ctx context.Context, c Config) error
+}
+
+// Provider speaks OpenID Connect to a pharmacy's identity provider: it
+// builds the authorization URL, then redeems the code and verifies the ID
+// token, including its nonce.
+type Provider interface {
+	AuthURL(ctx context.Context, c Config, redirectURL, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, c Config, redirectURL, code, nonce, verifier string) (Claims, error)
+}
+
+// Repository composes all ports — used only by NewService for convenient wiring.
+type Repository interface {
+	ConfigGetter
+	ConfigSaver
+}
@@ -0,0 +1,140 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
+type ServiceDeps struct {
+	Getter   ConfigGetter
+	Saver    ConfigSaver
+	Provider Provider
+}
+
+// Service contains single sign-on business logic.
+type Service struct {
+	deps ServiceDeps
+}
+
+// NewService is the production constructor — takes a Repository (satisfies all ports)
+// and the Provider that talks to identity providers.
+func NewService(repo Repository, provider Provider) *Service {
+	return &Service{deps: ServiceDeps{
+		Getter:   repo,
+		Saver:    repo,
+		Provider: provider,
+	}}
+}
+
+// NewServiceWith is the test constructor — inject only what you need, rest stays nil.
+func NewServiceWith(d ServiceDeps) *Service {
+	return &Service{deps: d}
+}
+
+// Config returns the pharmacy's provider settings.
+func (s *Service) Config(ctx context.Context, pharmacyID int64) (Config, error) {
+	c, err := s.deps.Getter.GetConfig(ctx, pharmacyID)
+	if err != nil {
+		return Config{}, fmt.Errorf("getting sso config: %w", err)
+	}
+	return c, nil
+}
+
+// SaveConfig validates and saves the pharmacy's provider settings. An empty
+// client secret keeps the saved one, so the form never has to show it. A
+// disabled configuration is saved without validation.
+func (s *Service) SaveConfig(ctx context.Context, c Config) error {
+	c = trimConfig(c)
+	if c.ClientSecret == "" {
+		current, err := s.deps.Getter.GetConfig(ctx, c.PharmacyID)
+		if err != nil {
+			return fmt.Errorf("getting sso config: %w", err)
+		}
+		c.ClientSecret = current.ClientSecret
+	}
+	if c.Enabled {
+		if err := validateConfig(c); err != nil {
+			return err
+		}
+	}
+	if err := s.deps.Saver.SaveConfig(ctx, c); err != nil {
+		return fmt.Errorf("saving sso config: %w", err)
+	}
+	return nil
+}
+
+// Begin starts a sign-in with the pharmacy's provider. The returned Login
+// must be kept until the provider redirects back to redirectURL.
+func (s *Service) Begin(ctx context.Context, pharmacyID int64, redirectURL string) (Login, error) {
+	c, err := s.enabledConfig(ctx, pharmacyID)
+	if err != nil {
+		return Login{}, err
+	}
+
+	var l Login
+	for _, v := range []*string{&l.State, &l.Nonce, &l.Verifier} {
+		if *v, err = newToken(); err != nil {
+			return Login{}, fmt.Errorf("generating login token: %w", err)
+		}
+	}
+	l.URL, err = s.deps.Provider.AuthURL(ctx, c, redirectURL, l.State, l.Nonce, l.Verifier)
+	if err != nil {
+		return Login{}, fmt.Errorf("building authorization url: %w", err)
+	}
+	return l, nil
+}
+
+// Complete finishes the sign-in begun as pending, once the provider
+// redirected back with state and code, and maps the user's claims to a
+// role. The provider must vouch for the email address.
+func (s *Service) Complete(ctx context.Context, pharmacyID int64, redirectURL string, pending Login, state, code string) (Identity, error) {
+	if pending.State == "" || state != pending.State || code == "" {
+		return Identity{}, ErrInvalidResponse
+	}
+	c, err := s.enabledConfig(ctx, pharmacyID)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	claims, err := s.deps.Provider.Exchange(ctx, c, redirectURL, code, pending.Nonce, pending.Verifier)
+	if err != nil {
+		return Identity{}, fmt.Errorf("exchanging authorization code: %w", err)
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return Identity{}, ErrEmailMissing
+	}
+	role, err := c.Role(claims.RoleValues)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+	return Identity{Issuer: c.Issuer, Subject: claims.Subject, Email: claims.Email, Name: name, Role: role}, nil
+}
+
+func (s *Service) enabledConfig(ctx context.Context, pharmacyID int64) (Config, error) {
+	c, err := s.deps.Getter.GetConfig(ctx, pharmacyID)
+	if err != nil {
+		return Config{}, fmt.Errorf("getting sso config: %w", err)
+	}
+	if !c.Enabled {
+		return Config{}, ErrNotConfigured
+	}
+	return c, nil
+}
+
+// newToken returns a random URL-safe token, used as state, nonce and PKCE
+// verifier alike.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
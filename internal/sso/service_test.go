@@ -0,0 +1,165 @@
+package sso_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/sso"
+)
+
+// --- Mocks ---
+
+type mockConfigStore struct {
+	config sso.Config
+	saved  *sso.Config
+}
+
+func (m *mockConfigStore) GetConfig(_ context.Context, _ int64) (sso.Config, error) {
+	return m.config, nil
+}
+
+func (m *mockConfigStore) SaveConfig(_ context.Context, c sso.Config) error {
+	m.saved = &c
+	return nil
+}
+
+type mockProvider struct {
+	claims sso.Claims
+	nonce  string
+}
+
+func (m *mockProvider) AuthURL(_ context.Context, _ sso.Config, _, state, _, _ string) (string, error) {
+	return "https://idp.example.it/authorize?state=" + state, nil
+}
+
+func (m *mockProvider) Exchange(_ context.Context, _ sso.Config, _, _, nonce, _ string) (sso.Claims, error) {
+	m.nonce = nonce
+	return m.claims, nil
+}
+
+func enabledConfig() sso.Config {
+	return sso.Config{
+		PharmacyID:   7,
+		Enabled:      true,
+		Issuer:       "https://idp.example.it",
+		ClientID:     "pharmarecall",
+		ClientSecret: "secret",
+		RoleClaim:    "groups",
+		OwnerValue:   "titolari",
+	}
+}
+
+// --- Tests ---
+
+func TestConfigRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		personnel string
+		values    []string
+		want      string
+		wantErr   error
+	}{
+		{"owner value", "", []string{"staff", "titolari"}, "owner", nil},
+		{"anyone is personnel without a personnel value", "", nil, "personnel", nil},
+		{"personnel value present", "farmacisti", []string{"farmacisti"}, "personnel", nil},
+		{"personnel value missing", "farmacisti", []string{"magazzino"}, "", sso.ErrNoAccess},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := enabledConfig()
+			c.PersonnelValue = tt.personnel
+			got, err := c.Role(tt.values)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Role(%v) = %q, %v; want %q, %v", tt.values, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSaveConfigValidatesEnabledConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*sso.Config)
+		want   error
+	}{
+		{"plain http issuer", func(c *sso.Config) { c.Issuer = "http://idp.example.it" }, sso.ErrInvalidIssuer},
+		{"missing client ID", func(c *sso.Config) { c.ClientID = " " }, sso.ErrClientIDRequired},
+		{"missing role claim", func(c *sso.Config) { c.RoleClaim = "" }, sso.ErrRoleClaimRequired},
+		{"loopback http issuer", func(c *sso.Config) { c.Issuer = "http://127.0.0.1:5556" }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := enabledConfig()
+			tt.change(&c)
+			err := sso.NewServiceWith(sso.ServiceDeps{Getter: &mockConfigStore{}, Saver: &mockConfigStore{}}).SaveConfig(context.Background(), c)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveConfigKeepsSecretWhenBlank(t *testing.T) {
+	store := &mockConfigStore{config: enabledConfig()}
+	c := enabledConfig()
+	c.ClientSecret = ""
+
+	if err := sso.NewServiceWith(sso.ServiceDeps{Getter: store, Saver: store}).SaveConfig(context.Background(), c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.saved == nil || store.saved.ClientSecret != "secret" {
+		t.Errorf("saved = %+v, want the stored secret kept", store.saved)
+	}
+}
+
+func TestBeginRefusesDisabledPharmacy(t *testing.T) {
+	svc := sso.NewServiceWith(sso.ServiceDeps{Getter: &mockConfigStore{config: sso.Config{PharmacyID: 7}}})
+	if _, err := svc.Begin(context.Background(), 7, "https://app.example.it/login/sso/callback"); !errors.Is(err, sso.ErrNotConfigured) {
+		t.Errorf("error = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestCompleteMapsClaimsToIdentity(t *testing.T) {
+	provider := &mockProvider{claims: sso.Claims{Subject: "abc", Email: "a.bianchi@example.it", EmailVerified: true, RoleValues: []string{"titolari"}}}
+	svc := sso.NewServiceWith(sso.ServiceDeps{Getter: &mockConfigStore{config: enabledConfig()}, Provider: provider})
+
+	l, err := svc.Begin(context.Background(), 7, "https://app.example.it/login/sso/callback")
+	if err != nil {
+		t.Fatalf("beginning: %v", err)
+	}
+	id, err := svc.Complete(context.Background(), 7, "https://app.example.it/login/sso/callback", l, l.State, "code")
+	if err != nil {
+		t.Fatalf("completing: %v", err)
+	}
+
+	want := sso.Identity{Issuer: "https://idp.example.it", Subject: "abc", Email: "a.bianchi@example.it", Name: "a.bianchi@example.it", Role: "owner"}
+	if id != want {
+		t.Errorf("identity = %+v, want %+v", id, want)
+	}
+	if provider.nonce != l.Nonce {
+		t.Error("the exchange must check the nonce of the login")
+	}
+}
+
+func TestCompleteRefusals(t *testing.T) {
+	pending := sso.Login{State: "s", Nonce: "n", Verifier: "v"}
+	tests := []struct {
+		name   string
+		state  string
+		claims sso.Claims
+		want   error
+	}{
+		{"state mismatch", "other", sso.Claims{Email: "a@example.it", EmailVerified: true}, sso.ErrInvalidResponse},
+		{"unverified email", "s", sso.Claims{Email: "a@example.it"}, sso.ErrEmailMissing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := sso.NewServiceWith(sso.ServiceDeps{Getter: &mockConfigStore{config: enabledConfig()}, Provider: &mockProvider{claims: tt.claims}})
+			_, err := svc.Complete(context.Background(), 7, "https://app.example.it/login/sso/callback", pending, tt.state, "code")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Package sso lets a pharmacy's staff sign in through the pharmacy's own
+// OpenID Connect identity provider, such as a chain's corporate directory.
+// Each pharmacy configures its provider and how a claim of the ID token maps
+// to the owner and personnel roles; the user domain provisions accounts on
+// first sign-in.
+package sso
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+)
+
+var (
+	ErrNotConfigured     = errors.New("sso.not_configured")
+	ErrInvalidIssuer     = errors.New("sso.invalid_issuer")
+	ErrClientIDRequired  = errors.New("sso.client_id_required")
+	ErrSecretRequired    = errors.New("sso.secret_required")
+	ErrRoleClaimRequired = errors.New("sso.role_claim_required")
+	ErrNoAccess          = errors.New("sso.no_access")
+	ErrEmailMissing      = errors.New("sso.email_missing")
+	ErrInvalidResponse   = errors.New("sso.invalid_response")
+)
+
+// DefaultRoleClaim is the ID token claim most providers put group names in.
+const DefaultRoleClaim = "groups"
+
+// Config is a pharmacy's identity provider. RoleClaim names the ID token
+// claim to read (a string or a list of strings): a user whose values include
+// OwnerValue becomes an owner; otherwise one whose values include
+// PersonnelValue, or anyone when PersonnelValue is empty, becomes personnel.
+type Config struct {
+	PharmacyID     int64
+	Enabled        bool
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RoleClaim      string
+	OwnerValue     string
+	PersonnelValue string
+}
+
+// Role maps the values of the role claim to a pharmacy role.
+func (c Config) Role(values []string) (string, error) {
+	if c.OwnerValue != "" && slices.Contains(values, c.OwnerValue) {
+		return permission.RoleOwner, nil
+	}
+	if c.PersonnelValue == "" || slices.Contains(values, c.PersonnelValue) {
+		return permission.RolePersonnel, nil
+	}
+	return "", ErrNoAccess
+}
+
+// Claims is what a verified ID token says about the user. RoleValues holds
+// the values of the configured role claim.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	RoleValues    []string
+}
+
+// Identity is a user the pharmacy's provider vouched for, with the role its
+// claims map to.
+type Identity struct {
+	Issuer  string
+	Subject string
+	Email   string
+	Name    string
+	Role    string
+}
+
+// Login is an authorization request in flight. State, Nonce and Verifier
+// must be kept (in the session) until the provider redirects back.
+type Login struct {
+	URL      string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+func validateConfig(c Config) error {
+	u, err := url.Parse(c.Issuer)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && !isLoopback(u.Hostname())) {
+		return ErrInvalidIssuer
+	}
+	if c.ClientID == "" {
+		return ErrClientIDRequired
+	}
+	if c.ClientSecret == "" {
+		return ErrSecretRequired
+	}
+	if c.RoleClaim == "" {
+		return ErrRoleClaimRequired
+	}
+	return nil
+}
+
+// isLoopback allows plain http for a provider on the same machine, as in
+// development and tests.
+func isLoopback(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+func trimConfig(c Config) Config {
+	c.Issuer = strings.TrimSpace(c.Issuer)
+	c.ClientID = strings.TrimSpace(c.ClientID)
+	c.ClientSecret = strings.TrimSpace(c.ClientSecret)
+	c.RoleClaim = strings.TrimSpace(c.RoleClaim)
+	c.OwnerValue = strings.TrimSpace(c.OwnerValue)
+	c.PersonnelValue = strings.TrimSpace(c.PersonnelValue)
+	return c
+}
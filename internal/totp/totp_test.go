@@ -0,0 +1,83 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/totp"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeMatchesRFC6238Vectors(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last 6 digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateToleratesOneStepOfDrift(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, _ := totp.Code(rfcSecret, totp.Step(now)-1)
+	tooOld, _ := totp.Code(rfcSecret, totp.Step(now)-2)
+
+	step, ok := totp.Validate(rfcSecret, previous, now)
+	if !ok || step != totp.Step(now)-1 {
+		t.Errorf("Validate(previous) = %d, %v; want the previous step", step, ok)
+	}
+	if _, ok := totp.Validate(rfcSecret, tooOld, now); ok {
+		t.Error("a code two steps old must be refused")
+	}
+	if _, ok := totp.Validate(rfcSecret, "12345", now); ok {
+		t.Error("a short code must be refused")
+	}
+}
+
+func TestNewSecretRoundTrips(t *testing.T) {
+	secret, err := totp.NewSecret()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("secret length = %d, want 32 base32 characters", len(secret))
+	}
+	now := time.Now()
+	code, err := totp.Code(secret, totp.Step(now))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := totp.Validate(secret, code, now); !ok {
+		t.Error("a fresh code must validate")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("PharmaRecall", "anna@example.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/PharmaRecall:anna@example.com?") {
+		t.Errorf("uri = %q", uri)
+	}
+	for _, want := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=PharmaRecall", "digits=6", "period=30"} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("uri missing %q: %s", want, uri)
+		}
+	}
+}
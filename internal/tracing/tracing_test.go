@@ -0,0 +1,76 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/tracing"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func runQuery(ctx context.Context, sql string, err error) {
+	var qt tracing.QueryTracer
+	ctx = qt.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql})
+	qt.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1"), Err: err})
+}
+
+func TestQueryTracerSpansQueriesOfTracedRequests(t *testing.T) {
+	rec := recordSpans(t)
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "GET /patients")
+	runQuery(ctx, "-- name: ListPatients :many\nSELECT id FROM patients", nil)
+	runQuery(ctx, "-- name: GetPatient :one\nSELECT id FROM patients WHERE id = $1", pgx.ErrNoRows)
+	runQuery(ctx, "begin", nil)
+	parent.End()
+
+	spans := rec.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("got %d spans, want 3 queries and the request", len(spans))
+	}
+	for i, want := range []string{"ListPatients", "GetPatient", "BEGIN"} {
+		s := spans[i]
+		if s.Name() != want {
+			t.Errorf("span %d = %q, want %q", i, s.Name(), want)
+		}
+		if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %q is not a child of the request", s.Name())
+		}
+		if len(s.Events()) > 0 {
+			t.Errorf("span %q recorded %v; no rows is not an error", s.Name(), s.Events())
+		}
+	}
+}
+
+func TestQueryTracerIgnoresUntracedQueries(t *testing.T) {
+	rec := recordSpans(t)
+
+	runQuery(context.Background(), "-- name: ListPatients :many\nSELECT id FROM patients", nil)
+
+	if n := len(rec.Ended()); n != 0 {
+		t.Errorf("got %d spans for a query outside a trace", n)
+	}
+}
+
+func TestSetupWithoutEndpointIsNoop(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}
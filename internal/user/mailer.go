@@ -0,0 +1,50 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// ResetMessage returns the text of the password reset email.
+func ResetMessage(r PasswordReset) string {
+	return fmt.Sprintf("Ciao %s, per scegliere una nuova password di PharmaRecall apri questo link entro %d minuti: %s\n"+
+		"Se non hai chiesto tu di reimpostare la password, ignora questa email.",
+		r.Name, int(ResetLinkLifetime.Minutes()), r.Link)
+}
+
+// EmailSender sends a plain-text email, as mail.SMTP does.
+type EmailSender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// EmailMailer is the Mailer of deployed servers: it emails the reset message.
+type EmailMailer struct {
+	Email EmailSender
+}
+
+// SendPasswordReset emails the message to the user.
+func (m EmailMailer) SendPasswordReset(ctx context.Context, r PasswordReset) error {
+	if err := m.Email.Send(ctx, r.To, "Reimposta la password di PharmaRecall", ResetMessage(r)); err != nil {
+		return fmt.Errorf("emailing password reset: %w", err)
+	}
+	return nil
+}
+
+// LogMailer is a Mailer that writes the message to the log instead of
+// sending it, so resets can be completed locally. The message carries the
+// reset link, so it is for development only: production configurations
+// must set a mail server.
+type LogMailer struct {
+	Logger *slog.Logger
+}
+
+// SendPasswordReset logs the message.
+func (l LogMailer) SendPasswordReset(ctx context.Context, r PasswordReset) error {
+	logger := l.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.InfoContext(ctx, "password reset", "user_id", r.UserID, "to", r.To, "message", ResetMessage(r))
+	return nil
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/giorgiovilardo/pharmarecall/internal/db"
+	"github.com/giorgiovilardo/pharmarecall/internal/dbutil"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -33,14 +38,95 @@ func (r *PgxRepository) GetByEmail(ctx context.Context, email string) (User, str
 		return User{}, "", fmt.Errorf("querying user by email: %w", err)
 	}
 	return User{
-		ID:         row.ID,
-		Email:      row.Email,
-		Name:       row.Name,
-		Role:       row.Role,
-		PharmacyID: row.PharmacyID.Int64,
+		ID:                        row.ID,
+		Email:                     row.Email,
+		Name:                      row.Name,
+		Role:                      row.Role,
+		PharmacyID:                row.PharmacyID.Int64,
+		Active:                    row.Active,
+		MustChangePassword:        row.MustChangePassword,
+		TwoFactorEnabled:          row.TotpEnabledAt.Valid,
+		PharmacyRequiresTwoFactor: row.PharmacyRequires2fa,
+		LockedUntil:               row.LockedUntil.Time,
+		Permissions:               permission.ForRole(row.Role, permission.FromStrings(row.CustomPermissions)),
+		Locale:                    row.Locale,
+		PharmacyLocale:            row.PharmacyLocale,
+		Federated:                 row.Federated,
 	}, row.PasswordHash, nil
 }
 
+func (r *PgxRepository) GetByIdentity(ctx context.Context, issuer, subject string) (User, error) {
+	row, err := r.queries.GetUserByIdentity(ctx, db.GetUserByIdentityParams{Issuer: issuer, Subject: subject})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, ErrNotFound
+		}
+		return User{}, fmt.Errorf("querying user by identity: %w", err)
+	}
+	return User{
+		ID:                        row.ID,
+		Email:                     row.Email,
+		Name:                      row.Name,
+		Role:                      row.Role,
+		PharmacyID:                row.PharmacyID.Int64,
+		Active:                    row.Active,
+		MustChangePassword:        row.MustChangePassword,
+		TwoFactorEnabled:          row.TotpEnabledAt.Valid,
+		PharmacyRequiresTwoFactor: row.PharmacyRequires2fa,
+		LockedUntil:               row.LockedUntil.Time,
+		Permissions:               permission.ForRole(row.Role, permission.FromStrings(row.CustomPermissions)),
+		Locale:                    row.Locale,
+		PharmacyLocale:            row.PharmacyLocale,
+		Federated:                 row.Federated,
+	}, nil
+}
+
+func (r *PgxRepository) CreateFederated(ctx context.Context, f FederatedLogin) (int64, error) {
+	id, err := r.queries.CreateFederatedUser(ctx, db.CreateFederatedUserParams{
+		Email:      f.Email,
+		Name:       f.Name,
+		Role:       f.Role,
+		PharmacyID: f.PharmacyID,
+		Issuer:     f.Issuer,
+		Subject:    f.Subject,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_users_email" {
+			return 0, ErrEmailTaken
+		}
+		return 0, fmt.Errorf("creating federated user: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PgxRepository) SetFederatedRole(ctx context.Context, pharmacyID, userID int64, role string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	// Locking the active owners keeps a concurrent change in the personnel
+	// page from removing the other owner at the same time.
+	owners, err := qtx.LockActivePharmacyOwners(ctx, pharmacyID)
+	if err != nil {
+		return fmt.Errorf("locking pharmacy owners: %w", err)
+	}
+	if role != "owner" && len(owners) == 1 && owners[0] == userID {
+		return ErrLastOwner
+	}
+	if err := qtx.SetUserRole(ctx, db.SetUserRoleParams{ID: userID, Role: role}); err != nil {
+		return fmt.Errorf("setting user role: %w", err)
+	}
+	if err := qtx.DeleteUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("revoking user sessions: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
 func (r *PgxRepository) GetByID(ctx context.Context, id int64) (User, string, error) {
 	row, err := r.queries.GetUserByID(ctx, id)
 	if err != nil {
@@ -50,14 +136,30 @@ func (r *PgxRepository) GetByID(ctx context.Context, id int64) (User, string, er
 		return User{}, "", fmt.Errorf("querying user by id: %w", err)
 	}
 	return User{
-		ID:         row.ID,
-		Email:      row.Email,
-		Name:       row.Name,
-		Role:       row.Role,
-		PharmacyID: row.PharmacyID.Int64,
+		ID:                        row.ID,
+		Email:                     row.Email,
+		Name:                      row.Name,
+		Role:                      row.Role,
+		PharmacyID:                row.PharmacyID.Int64,
+		Active:                    row.Active,
+		MustChangePassword:        row.MustChangePassword,
+		TwoFactorEnabled:          row.TotpEnabledAt.Valid,
+		PharmacyRequiresTwoFactor: row.PharmacyRequires2fa,
+		LockedUntil:               row.LockedUntil.Time,
+		Permissions:               permission.ForRole(row.Role, permission.FromStrings(row.CustomPermissions)),
+		Locale:                    row.Locale,
+		PharmacyLocale:            row.PharmacyLocale,
+		Federated:                 row.Federated,
 	}, row.PasswordHash, nil
 }
 
+func (r *PgxRepository) SetLocale(ctx context.Context, id int64, locale string) error {
+	if err := r.queries.SetUserLocale(ctx, db.SetUserLocaleParams{ID: id, Locale: locale}); err != nil {
+		return fmt.Errorf("setting user locale: %w", err)
+	}
+	return nil
+}
+
 func (r *PgxRepository) UpdatePassword(ctx context.Context, id int64, hash string) error {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
@@ -97,9 +199,363 @@ func (r *PgxRepository) Create(ctx context.Context, email, passwordHash, name, r
 	}
 
 	return User{
-		ID:    row.ID,
-		Email: row.Email,
-		Name:  row.Name,
-		Role:  row.Role,
+		ID:          row.ID,
+		Email:       row.Email,
+		Name:        row.Name,
+		Role:        row.Role,
+		Active:      true,
+		Permissions: permission.ForRole(row.Role, nil),
 	}, nil
 }
+
+func (r *PgxRepository) RecordLogin(ctx context.Context, ev LoginEvent) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	if err := qtx.RecordUserLogin(ctx, ev.UserID); err != nil {
+		return fmt.Errorf("recording user login: %w", err)
+	}
+	if err := createLoginEvent(ctx, qtx, ev); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) RecordLoginEvent(ctx context.Context, ev LoginEvent) error {
+	return createLoginEvent(ctx, r.queries, ev)
+}
+
+func (r *PgxRepository) RecordLoginFailure(ctx context.Context, ev LoginEvent) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	failures, err := qtx.IncrementFailedLogins(ctx, ev.UserID)
+	if err != nil {
+		return 0, fmt.Errorf("incrementing failed logins: %w", err)
+	}
+	if err := createLoginEvent(ctx, qtx, ev); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("committing transaction: %w", err)
+	}
+	return int(failures), nil
+}
+
+func (r *PgxRepository) LockAccount(ctx context.Context, userID int64, until time.Time) error {
+	if err := r.queries.LockUser(ctx, db.LockUserParams{
+		ID:          userID,
+		LockedUntil: dbutil.TimeToTimestamptz(until),
+	}); err != nil {
+		return fmt.Errorf("locking user: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) CountFailedLogins(ctx context.Context, ip string, since time.Time) (int, error) {
+	n, err := r.queries.CountRecentFailedLoginsByIP(ctx, db.CountRecentFailedLoginsByIPParams{
+		Ip:    ip,
+		Since: dbutil.TimeToTimestamptz(since),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("counting failed logins by ip: %w", err)
+	}
+	return int(n), nil
+}
+
+func (r *PgxRepository) ListLoginEvents(ctx context.Context, userID int64, limit int) ([]LoginEvent, error) {
+	rows, err := r.queries.ListLoginEventsByUser(ctx, db.ListLoginEventsByUserParams{
+		UserID:    userID,
+		MaxEvents: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing login events: %w", err)
+	}
+	events := make([]LoginEvent, len(rows))
+	for i, row := range rows {
+		events[i] = LoginEvent{
+			UserID:    userID,
+			IP:        row.Ip,
+			UserAgent: row.UserAgent,
+			Success:   row.Success,
+			At:        row.CreatedAt.Time,
+		}
+	}
+	return events, nil
+}
+
+func createLoginEvent(ctx context.Context, q *db.Queries, ev LoginEvent) error {
+	if err := q.CreateLoginEvent(ctx, db.CreateLoginEventParams{
+		UserID:    pgtype.Int8{Int64: ev.UserID, Valid: ev.UserID != 0},
+		Email:     ev.Email,
+		Ip:        ev.IP,
+		UserAgent: ev.UserAgent,
+		Success:   ev.Success,
+		CreatedAt: dbutil.TimeToTimestamptz(ev.At),
+	}); err != nil {
+		return fmt.Errorf("creating login event: %w", err)
+	}
+	return nil
+}
+
+// TrackSession records the token and drops the records of the user's
+// sessions that no longer exist in the scs store.
+func (r *PgxRepository) TrackSession(ctx context.Context, userID int64, token string, d Device) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	if err := qtx.PruneUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("pruning user sessions: %w", err)
+	}
+	if err := qtx.TrackUserSession(ctx, db.TrackUserSessionParams{
+		Token:     token,
+		UserID:    userID,
+		Ip:        d.IP,
+		UserAgent: d.UserAgent,
+	}); err != nil {
+		return fmt.Errorf("tracking user session: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) TouchSession(ctx context.Context, token string, at time.Time) error {
+	if err := r.queries.TouchUserSession(ctx, db.TouchUserSessionParams{
+		Token:      token,
+		LastSeenAt: dbutil.TimeToTimestamptz(at),
+	}); err != nil {
+		return fmt.Errorf("touching user session: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) ListSessions(ctx context.Context, userID int64, currentToken string) ([]Session, error) {
+	rows, err := r.queries.ListUserSessions(ctx, db.ListUserSessionsParams{
+		UserID:       userID,
+		CurrentToken: currentToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing user sessions: %w", err)
+	}
+	sessions := make([]Session, len(rows))
+	for i, row := range rows {
+		sessions[i] = Session{
+			ID:         row.ID,
+			Device:     Device{IP: row.Ip, UserAgent: row.UserAgent},
+			CreatedAt:  row.CreatedAt.Time,
+			LastSeenAt: row.LastSeenAt.Time,
+			Current:    row.Current,
+		}
+	}
+	return sessions, nil
+}
+
+func (r *PgxRepository) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	n, err := r.queries.DeleteUserSession(ctx, db.DeleteUserSessionParams{ID: sessionID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("deleting user session: %w", err)
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *PgxRepository) RevokeOtherSessions(ctx context.Context, userID int64, keepToken string) (int, error) {
+	n, err := r.queries.DeleteOtherUserSessions(ctx, db.DeleteOtherUserSessionsParams{UserID: userID, KeepToken: keepToken})
+	if err != nil {
+		return 0, fmt.Errorf("deleting other user sessions: %w", err)
+	}
+	return int(n), nil
+}
+
+func (r *PgxRepository) CreateResetToken(ctx context.Context, userID int64, hash []byte, expiresAt time.Time) error {
+	if err := r.queries.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		UserID:    userID,
+		TokenHash: hash,
+		ExpiresAt: dbutil.TimeToTimestamptz(expiresAt),
+	}); err != nil {
+		return fmt.Errorf("inserting reset token: %w", err)
+	}
+	return nil
+}
+
+func (r *PgxRepository) CountRecentResets(ctx context.Context, userID int64, since time.Time) (int, error) {
+	n, err := r.queries.CountRecentPasswordResetTokens(ctx, db.CountRecentPasswordResetTokensParams{
+		UserID: userID,
+		Since:  dbutil.TimeToTimestamptz(since),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("counting reset tokens: %w", err)
+	}
+	return int(n), nil
+}
+
+// ResetPassword consumes the link, stores the new hash (clearing a forced
+// change), expires the user's other links and logs them out everywhere.
+func (r *PgxRepository) ResetPassword(ctx context.Context, tokenHash []byte, passwordHash string, now time.Time) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	userID, err := qtx.ConsumePasswordResetToken(ctx, db.ConsumePasswordResetTokenParams{
+		Now:       dbutil.TimeToTimestamptz(now),
+		TokenHash: tokenHash,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("consuming reset token: %w", err)
+	}
+	if err := qtx.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{ID: userID, PasswordHash: passwordHash}); err != nil {
+		return fmt.Errorf("updating user password: %w", err)
+	}
+	if err := qtx.ExpirePasswordResetTokens(ctx, db.ExpirePasswordResetTokensParams{
+		UserID: userID,
+		Now:    dbutil.TimeToTimestamptz(now),
+	}); err != nil {
+		return fmt.Errorf("expiring reset tokens: %w", err)
+	}
+	if err := qtx.DeleteUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("revoking user sessions: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) SetTemporaryPassword(ctx context.Context, userID int64, passwordHash string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	if err := qtx.ResetUserPassword(ctx, db.ResetUserPasswordParams{ID: userID, PasswordHash: passwordHash}); err != nil {
+		return fmt.Errorf("resetting user password: %w", err)
+	}
+	if err := qtx.UnlockUser(ctx, userID); err != nil {
+		return fmt.Errorf("unlocking user: %w", err)
+	}
+	if err := qtx.DeleteUserSessions(ctx, userID); err != nil {
+		return fmt.Errorf("revoking user sessions: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) GetTOTP(ctx context.Context, userID int64) (string, int64, error) {
+	row, err := r.queries.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", 0, ErrTwoFactorDisabled
+		}
+		return "", 0, fmt.Errorf("querying totp secret: %w", err)
+	}
+	return row.TotpSecret, row.TotpLastStep, nil
+}
+
+func (r *PgxRepository) UseTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	n, err := r.queries.UseUserTOTPStep(ctx, db.UseUserTOTPStepParams{ID: userID, Step: step})
+	if err != nil {
+		return false, fmt.Errorf("updating totp step: %w", err)
+	}
+	return n == 1, nil
+}
+
+func (r *PgxRepository) EnableTwoFactor(ctx context.Context, userID int64, secret string, step int64, recoveryHashes [][]byte) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	if err := qtx.EnableUserTOTP(ctx, db.EnableUserTOTPParams{ID: userID, TotpSecret: secret, Step: step}); err != nil {
+		return fmt.Errorf("enabling totp: %w", err)
+	}
+	if err := insertRecoveryCodes(ctx, qtx, userID, recoveryHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) DisableTwoFactor(ctx context.Context, userID int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+	if err := qtx.DisableUserTOTP(ctx, userID); err != nil {
+		return fmt.Errorf("disabling totp: %w", err)
+	}
+	if err := qtx.DeleteUserRecoveryCodes(ctx, userID); err != nil {
+		return fmt.Errorf("deleting recovery codes: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes [][]byte) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertRecoveryCodes(ctx, r.queries.WithTx(tx), userID, hashes); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PgxRepository) UseRecoveryCode(ctx context.Context, userID int64, hash []byte) (bool, error) {
+	n, err := r.queries.UseUserRecoveryCode(ctx, db.UseUserRecoveryCodeParams{UserID: userID, CodeHash: hash})
+	if err != nil {
+		return false, fmt.Errorf("using recovery code: %w", err)
+	}
+	return n == 1, nil
+}
+
+func (r *PgxRepository) CountRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	n, err := r.queries.CountUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("counting recovery codes: %w", err)
+	}
+	return int(n), nil
+}
+
+// insertRecoveryCodes replaces the user's recovery codes within a transaction.
+func insertRecoveryCodes(ctx context.Context, qtx *db.Queries, userID int64, hashes [][]byte) error {
+	if err := qtx.DeleteUserRecoveryCodes(ctx, userID); err != nil {
+		return fmt.Errorf("deleting recovery codes: %w", err)
+	}
+	for _, h := range hashes {
+		if err := qtx.CreateUserRecoveryCode(ctx, db.CreateUserRecoveryCodeParams{UserID: userID, CodeHash: h}); err != nil {
+			return fmt.Errorf("inserting recovery code: %w", err)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package user
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // UserByEmailGetter fetches a user by email, returning their password hash for verification.
 type UserByEmailGetter interface {
@@ -12,20 +15,192 @@ type UserByIDGetter interface {
 	GetByID(ctx context.Context, id int64) (User, string, error)
 }
 
+// UserByIdentityGetter fetches a user by their identity provider account.
+type UserByIdentityGetter interface {
+	GetByIdentity(ctx context.Context, issuer, subject string) (User, error)
+}
+
+// FederatedUserCreator provisions a user for a first federated sign-in and
+// returns their ID. It returns ErrEmailTaken if the email is already used.
+type FederatedUserCreator interface {
+	CreateFederated(ctx context.Context, f FederatedLogin) (int64, error)
+}
+
+// FederatedRoleSetter replaces a federated user's role with the one their
+// identity provider's claims map to, dropping any custom role, and revokes
+// their sessions so none keeps the old permissions. Taking the owner role
+// from the pharmacy's last active owner is ErrLastOwner.
+type FederatedRoleSetter interface {
+	SetFederatedRole(ctx context.Context, pharmacyID, userID int64, role string) error
+}
+
 // PasswordUpdater updates a user's password hash.
 type PasswordUpdater interface {
 	UpdatePassword(ctx context.Context, id int64, hash string) error
 }
 
+// LocaleSetter stores the interface language a user chose.
+type LocaleSetter interface {
+	SetLocale(ctx context.Context, id int64, locale string) error
+}
+
 // UserCreator creates a new user.
 type UserCreator interface {
 	Create(ctx context.Context, email, passwordHash, name, role string) (User, error)
 }
 
+// LoginRecorder records a successful login: it stamps the user's last login,
+// clears their failed attempts and lock, and stores the event.
+type LoginRecorder interface {
+	RecordLogin(ctx context.Context, ev LoginEvent) error
+}
+
+// LoginEventRecorder stores a login event without touching the account.
+type LoginEventRecorder interface {
+	RecordLoginEvent(ctx context.Context, ev LoginEvent) error
+}
+
+// LoginFailureRecorder stores a wrong-password event and returns the user's
+// consecutive failed logins, this one included.
+type LoginFailureRecorder interface {
+	RecordLoginFailure(ctx context.Context, ev LoginEvent) (int, error)
+}
+
+// AccountLocker refuses logins to an account until the given time.
+type AccountLocker interface {
+	LockAccount(ctx context.Context, userID int64, until time.Time) error
+}
+
+// FailedLoginCounter counts failed logins from an IP address since a time.
+type FailedLoginCounter interface {
+	CountFailedLogins(ctx context.Context, ip string, since time.Time) (int, error)
+}
+
+// LoginEventLister lists a user's most recent login events, newest first.
+type LoginEventLister interface {
+	ListLoginEvents(ctx context.Context, userID int64, limit int) ([]LoginEvent, error)
+}
+
+// SessionTracker records which user a session token belongs to, so the
+// sessions of a user can be revoked.
+type SessionTracker interface {
+	TrackSession(ctx context.Context, userID int64, token string, d Device) error
+}
+
+// SessionToucher records that a session was just used.
+type SessionToucher interface {
+	TouchSession(ctx context.Context, token string, at time.Time) error
+}
+
+// SessionLister lists a user's live sessions, marking the one holding
+// currentToken.
+type SessionLister interface {
+	ListSessions(ctx context.Context, userID int64, currentToken string) ([]Session, error)
+}
+
+// SessionRevoker ends one of a user's sessions, or all but one. RevokeSession
+// returns ErrSessionNotFound if the user has no such live session.
+type SessionRevoker interface {
+	RevokeSession(ctx context.Context, userID, sessionID int64) error
+	RevokeOtherSessions(ctx context.Context, userID int64, keepToken string) (int, error)
+}
+
+// ResetTokenCreator stores the hash of a new password reset link.
+type ResetTokenCreator interface {
+	CreateResetToken(ctx context.Context, userID int64, hash []byte, expiresAt time.Time) error
+}
+
+// RecentResetCounter counts the reset links requested for a user since a time.
+type RecentResetCounter interface {
+	CountRecentResets(ctx context.Context, userID int64, since time.Time) (int, error)
+}
+
+// PasswordResetter consumes an unexpired, unused reset link and sets the new
+// password hash in one step. It also expires the user's other links and ends
+// their sessions. Returns ErrNotFound if no such link exists.
+type PasswordResetter interface {
+	ResetPassword(ctx context.Context, tokenHash []byte, passwordHash string, now time.Time) error
+}
+
+// TemporaryPasswordSetter sets a password hash the user must change at the
+// next login, lifts a lock and ends their sessions, in one step.
+type TemporaryPasswordSetter interface {
+	SetTemporaryPassword(ctx context.Context, userID int64, passwordHash string) error
+}
+
+// Mailer delivers a password reset link.
+type Mailer interface {
+	SendPasswordReset(ctx context.Context, r PasswordReset) error
+}
+
+// TOTPGetter returns a user's TOTP secret and the time step of the last code
+// they used. Returns ErrTwoFactorDisabled if the user has not enrolled.
+type TOTPGetter interface {
+	GetTOTP(ctx context.Context, userID int64) (secret string, lastStep int64, err error)
+}
+
+// TOTPStepUser records the time step of an accepted code, reporting false if
+// that step or a later one was already used.
+type TOTPStepUser interface {
+	UseTOTPStep(ctx context.Context, userID, step int64) (bool, error)
+}
+
+// TwoFactorEnabler stores a confirmed TOTP secret with a fresh set of
+// recovery code hashes, replacing any previous ones.
+type TwoFactorEnabler interface {
+	EnableTwoFactor(ctx context.Context, userID int64, secret string, step int64, recoveryHashes [][]byte) error
+}
+
+// TwoFactorDisabler removes a user's TOTP secret and recovery codes.
+type TwoFactorDisabler interface {
+	DisableTwoFactor(ctx context.Context, userID int64) error
+}
+
+// RecoveryCodeReplacer replaces a user's recovery codes.
+type RecoveryCodeReplacer interface {
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes [][]byte) error
+}
+
+// RecoveryCodeUser marks an unused recovery code as used, reporting false if
+// the user has no such code.
+type RecoveryCodeUser interface {
+	UseRecoveryCode(ctx context.Context, userID int64, hash []byte) (bool, error)
+}
+
+// RecoveryCodeCounter counts a user's unused recovery codes.
+type RecoveryCodeCounter interface {
+	CountRecoveryCodes(ctx context.Context, userID int64) (int, error)
+}
+
 // Repository composes all ports — used only by NewService for convenient wiring.
 type Repository interface {
 	UserByEmailGetter
 	UserByIDGetter
+	UserByIdentityGetter
+	FederatedUserCreator
+	FederatedRoleSetter
 	PasswordUpdater
+	LocaleSetter
 	UserCreator
+	LoginRecorder
+	LoginEventRecorder
+	LoginFailureRecorder
+	AccountLocker
+	FailedLoginCounter
+	LoginEventLister
+	SessionTracker
+	SessionToucher
+	SessionLister
+	SessionRevoker
+	ResetTokenCreator
+	RecentResetCounter
+	PasswordResetter
+	TemporaryPasswordSetter
+	TOTPGetter
+	TOTPStepUser
+	TwoFactorEnabler
+	TwoFactorDisabler
+	RecoveryCodeReplacer
+	RecoveryCodeUser
+	RecoveryCodeCounter
 }
@@ -2,16 +2,56 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/i18n"
+	"github.com/giorgiovilardo/pharmarecall/internal/metrics"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/totp"
 )
 
+var loginFailures = metrics.NewCounter("pharmarecall_login_failures_total", "Refused staff logins, by reason.", "reason")
+
 // ServiceDeps holds individual port interfaces — used by tests to inject only what's needed.
 type ServiceDeps struct {
 	EmailGetter     UserByEmailGetter
 	IDGetter        UserByIDGetter
+	Identities      UserByIdentityGetter
+	Federated       FederatedUserCreator
+	FederatedRoles  FederatedRoleSetter
 	PasswordUpdater PasswordUpdater
+	Locales         LocaleSetter
 	Creator         UserCreator
+	LoginRecorder   LoginRecorder
+	LoginEvents     LoginEventRecorder
+	LoginFailures   LoginFailureRecorder
+	Locker          AccountLocker
+	IPFailures      FailedLoginCounter
+	EventLister     LoginEventLister
+	Sessions        SessionTracker
+	SessionToucher  SessionToucher
+	SessionLister   SessionLister
+	SessionRevoker  SessionRevoker
+	ResetTokens     ResetTokenCreator
+	RecentResets    RecentResetCounter
+	Resetter        PasswordResetter
+	TempPasswords   TemporaryPasswordSetter
+	Mailer          Mailer
+	TOTP            TOTPGetter
+	TOTPSteps       TOTPStepUser
+	Enabler         TwoFactorEnabler
+	Disabler        TwoFactorDisabler
+	Recovery        RecoveryCodeReplacer
+	UseRecovery     RecoveryCodeUser
+	CountRecovery   RecoveryCodeCounter
 	Hasher          func(string) (string, error)
 	Verifier        func(hash, password string) error
 }
@@ -21,13 +61,40 @@ type Service struct {
 	deps ServiceDeps
 }
 
-// NewService is the production constructor — takes a Repository (satisfies all ports).
-func NewService(repo Repository, hasher func(string) (string, error), verifier func(hash, password string) error) *Service {
+// NewService is the production constructor — takes a Repository (satisfies all ports)
+// and the Mailer for password reset links.
+func NewService(repo Repository, mailer Mailer, hasher func(string) (string, error), verifier func(hash, password string) error) *Service {
 	return &Service{deps: ServiceDeps{
 		EmailGetter:     repo,
 		IDGetter:        repo,
+		Identities:      repo,
+		Federated:       repo,
+		FederatedRoles:  repo,
 		PasswordUpdater: repo,
+		Locales:         repo,
 		Creator:         repo,
+		LoginRecorder:   repo,
+		LoginEvents:     repo,
+		LoginFailures:   repo,
+		Locker:          repo,
+		IPFailures:      repo,
+		EventLister:     repo,
+		Sessions:        repo,
+		SessionToucher:  repo,
+		SessionLister:   repo,
+		SessionRevoker:  repo,
+		ResetTokens:     repo,
+		RecentResets:    repo,
+		Resetter:        repo,
+		TempPasswords:   repo,
+		Mailer:          mailer,
+		TOTP:            repo,
+		TOTPSteps:       repo,
+		Enabler:         repo,
+		Disabler:        repo,
+		Recovery:        repo,
+		UseRecovery:     repo,
+		CountRecovery:   repo,
 		Hasher:          hasher,
 		Verifier:        verifier,
 	}}
@@ -38,23 +105,177 @@ func NewServiceWith(d ServiceDeps) *Service {
 	return &Service{deps: d}
 }
 
-// Authenticate verifies credentials and returns the user.
-func (s *Service) Authenticate(ctx context.Context, email, password string) (User, error) {
-	u, hash, err := s.deps.EmailGetter.GetByEmail(ctx, email)
+// Authenticate verifies credentials and returns the user. Every attempt is
+// recorded. Too many failures from the attempt's IP refuse it outright, and
+// consecutive wrong passwords lock the account for progressively longer.
+// A deactivated or federated account is refused only after the password
+// matched, so the error does not tell a stranger which emails exist; a
+// federated account holds no password unless one predates this check. For accounts with 2FA
+// the right password only opens the second step: nothing is recorded and
+// failed attempts are not cleared until VerifySecondFactor passes.
+func (s *Service) Authenticate(ctx context.Context, a LoginAttempt, now time.Time) (User, error) {
+	failures, err := s.deps.IPFailures.CountFailedLogins(ctx, a.IP, now.Add(-FailedLoginWindow))
+	if err != nil {
+		return User{}, fmt.Errorf("counting failed logins: %w", err)
+	}
+	if failures >= MaxFailedLoginsPerIP {
+		return User{}, failLogin(ErrTooManyAttempts)
+	}
+
+	ev := LoginEvent{Email: a.Email, IP: a.IP, UserAgent: a.UserAgent, At: now}
+	u, hash, err := s.deps.EmailGetter.GetByEmail(ctx, a.Email)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
-			return User{}, ErrInvalidCredentials
+			return User{}, s.refuseLogin(ctx, ev, ErrInvalidCredentials)
 		}
 		return User{}, fmt.Errorf("looking up user: %w", err)
 	}
+	ev.UserID = u.ID
 
-	if err := s.deps.Verifier(hash, password); err != nil {
-		return User{}, ErrInvalidCredentials
+	if u.Locked(now) {
+		return User{}, s.refuseLogin(ctx, ev, ErrAccountLocked)
+	}
+
+	if err := s.deps.Verifier(hash, a.Password); err != nil {
+		return User{}, s.recordLoginFailure(ctx, ev, ErrInvalidCredentials)
+	}
+
+	if !u.Active {
+		return User{}, s.refuseLogin(ctx, ev, ErrDeactivated)
+	}
+	if u.Federated {
+		return User{}, s.refuseLogin(ctx, ev, ErrFederated)
+	}
+	if u.TwoFactorEnabled {
+		return u, nil
+	}
+
+	ev.Success = true
+	if err := s.deps.LoginRecorder.RecordLogin(ctx, ev); err != nil {
+		return User{}, fmt.Errorf("recording login: %w", err)
 	}
 
 	return u, nil
 }
 
+// recordLoginFailure counts a wrong password or code towards the account
+// lock, locking the account once LockoutDuration says so.
+func (s *Service) recordLoginFailure(ctx context.Context, ev LoginEvent, reason error) error {
+	n, err := s.deps.LoginFailures.RecordLoginFailure(ctx, ev)
+	if err != nil {
+		return fmt.Errorf("recording failed login: %w", err)
+	}
+	if lock := LockoutDuration(n); lock > 0 {
+		if err := s.deps.Locker.LockAccount(ctx, ev.UserID, ev.At.Add(lock)); err != nil {
+			return fmt.Errorf("locking account: %w", err)
+		}
+		return failLogin(ErrAccountLocked)
+	}
+	return failLogin(reason)
+}
+
+// SignInFederated logs in a user vouched for by their pharmacy's identity
+// provider, provisioning the account on first sign-in. Accounts are matched
+// by provider identity, never by email, so an existing password account is
+// not taken over. Password locks do not apply: the provider decides, and
+// the role its claims map to replaces the stored one on every sign-in, so a
+// demotion there takes effect here at the next login.
+func (s *Service) SignInFederated(ctx context.Context, f FederatedLogin, now time.Time) (User, error) {
+	ev := LoginEvent{Email: f.Email, IP: f.IP, UserAgent: f.UserAgent, At: now}
+	u, err := s.deps.Identities.GetByIdentity(ctx, f.Issuer, f.Subject)
+	if errors.Is(err, ErrNotFound) {
+		id, err := s.deps.Federated.CreateFederated(ctx, f)
+		if err != nil {
+			if errors.Is(err, ErrEmailTaken) {
+				return User{}, s.refuseLogin(ctx, ev, ErrEmailTaken)
+			}
+			return User{}, fmt.Errorf("provisioning federated user: %w", err)
+		}
+		u, _, err = s.deps.IDGetter.GetByID(ctx, id)
+		if err != nil {
+			return User{}, fmt.Errorf("fetching provisioned user: %w", err)
+		}
+	} else if err != nil {
+		return User{}, fmt.Errorf("looking up federated user: %w", err)
+	}
+	ev.UserID = u.ID
+
+	if u.PharmacyID != f.PharmacyID {
+		return User{}, s.refuseLogin(ctx, ev, ErrOtherPharmacy)
+	}
+	if !u.Active {
+		return User{}, s.refuseLogin(ctx, ev, ErrDeactivated)
+	}
+	if u.Role != f.Role {
+		err := s.deps.FederatedRoles.SetFederatedRole(ctx, u.PharmacyID, u.ID, f.Role)
+		switch {
+		case errors.Is(err, ErrLastOwner):
+			// Demoting the last owner would leave nobody to manage the
+			// pharmacy; they keep the owner role until another owner exists.
+			slog.WarnContext(ctx, "identity provider demotes the last owner, keeping the stored role",
+				"user_id", u.ID, "pharmacy_id", u.PharmacyID, "role", f.Role)
+		case err != nil:
+			return User{}, fmt.Errorf("updating federated role: %w", err)
+		default:
+			u, _, err = s.deps.IDGetter.GetByID(ctx, u.ID)
+			if err != nil {
+				return User{}, fmt.Errorf("fetching federated user: %w", err)
+			}
+		}
+	}
+
+	ev.Success = true
+	if err := s.deps.LoginRecorder.RecordLogin(ctx, ev); err != nil {
+		return User{}, fmt.Errorf("recording login: %w", err)
+	}
+	return u, nil
+}
+
+// refuseLogin records a failed attempt that does not count towards the
+// account lock, and returns reason.
+func (s *Service) refuseLogin(ctx context.Context, ev LoginEvent, reason error) error {
+	if err := s.deps.LoginEvents.RecordLoginEvent(ctx, ev); err != nil {
+		return fmt.Errorf("recording login event: %w", err)
+	}
+	return failLogin(reason)
+}
+
+// failLogin counts a refused login under its reason and returns it.
+func failLogin(reason error) error {
+	loginFailures.Inc(reason.Error())
+	return reason
+}
+
+// LoginEvents returns the user's most recent login attempts.
+func (s *Service) LoginEvents(ctx context.Context, userID int64) ([]LoginEvent, error) {
+	events, err := s.deps.EventLister.ListLoginEvents(ctx, userID, LoginEventsShown)
+	if err != nil {
+		return nil, fmt.Errorf("listing login events: %w", err)
+	}
+	return events, nil
+}
+
+// Get returns a user by ID.
+func (s *Service) Get(ctx context.Context, userID int64) (User, error) {
+	u, _, err := s.deps.IDGetter.GetByID(ctx, userID)
+	if err != nil {
+		return User{}, fmt.Errorf("looking up user: %w", err)
+	}
+	return u, nil
+}
+
+// SetLocale stores the interface language the user chose and returns the
+// user as it now stands. An empty locale follows the pharmacy's default.
+func (s *Service) SetLocale(ctx context.Context, userID int64, locale string) (User, error) {
+	if locale != "" && !i18n.Supported(locale) {
+		return User{}, ErrInvalidLocale
+	}
+	if err := s.deps.Locales.SetLocale(ctx, userID, locale); err != nil {
+		return User{}, fmt.Errorf("setting locale: %w", err)
+	}
+	return s.Get(ctx, userID)
+}
+
 // ChangePassword verifies the current password and updates to the new one.
 func (s *Service) ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword string) error {
 	_, hash, err := s.deps.IDGetter.GetByID(ctx, userID)
@@ -78,17 +299,366 @@ func (s *Service) ChangePassword(ctx context.Context, userID int64, currentPassw
 	return nil
 }
 
+// RequestPasswordReset emails a single-use reset link to the active user
+// with that email. Like Authenticate, it reveals nothing about whether the
+// account exists: unknown, deactivated, federated and rate-limited accounts
+// succeed silently. Links point to baseURL + "/reset-password/<token>".
+func (s *Service) RequestPasswordReset(ctx context.Context, email, baseURL string, now time.Time) error {
+	u, _, err := s.deps.EmailGetter.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if !u.Active || u.Federated {
+		return nil
+	}
+
+	recent, err := s.deps.RecentResets.CountRecentResets(ctx, u.ID, now.Add(-ResetRequestWindow))
+	if err != nil {
+		return fmt.Errorf("counting recent resets: %w", err)
+	}
+	if recent >= MaxResetRequests {
+		return nil
+	}
+
+	token, err := newResetToken()
+	if err != nil {
+		return fmt.Errorf("generating reset token: %w", err)
+	}
+	r := PasswordReset{
+		UserID:    u.ID,
+		To:        u.Email,
+		Name:      u.Name,
+		Link:      strings.TrimRight(baseURL, "/") + "/reset-password/" + token,
+		ExpiresAt: now.Add(ResetLinkLifetime),
+	}
+	if err := s.deps.ResetTokens.CreateResetToken(ctx, u.ID, hashResetToken(token), r.ExpiresAt); err != nil {
+		return fmt.Errorf("storing reset token: %w", err)
+	}
+	if err := s.deps.Mailer.SendPasswordReset(ctx, r); err != nil {
+		return fmt.Errorf("%w: %w", ErrSendFailed, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with the token of a reset link. The
+// link stops working, and so do the user's other links and open sessions.
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string, now time.Time) error {
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	if newPassword == "" {
+		return ErrPasswordRequired
+	}
+
+	hash, err := s.deps.Hasher(newPassword)
+	if err != nil {
+		return fmt.Errorf("hashing new password: %w", err)
+	}
+
+	if err := s.deps.Resetter.ResetPassword(ctx, hashResetToken(token), hash, now); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("resetting password: %w", err)
+	}
+	return nil
+}
+
+// BeginTwoFactorEnrolment generates a TOTP secret for the account. Nothing
+// is stored until EnableTwoFactor confirms it.
+func (s *Service) BeginTwoFactorEnrolment(ctx context.Context, userID int64) (TwoFactorEnrolment, error) {
+	u, _, err := s.deps.IDGetter.GetByID(ctx, userID)
+	if err != nil {
+		return TwoFactorEnrolment{}, fmt.Errorf("looking up user: %w", err)
+	}
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return TwoFactorEnrolment{}, fmt.Errorf("generating totp secret: %w", err)
+	}
+	return TwoFactorEnrolment{Secret: secret, URI: totp.URI(TwoFactorIssuer, u.Email, secret)}, nil
+}
+
+// EnableTwoFactor turns on 2FA once code proves the user's authenticator
+// app holds secret. It returns the recovery codes, shown to the user once.
+func (s *Service) EnableTwoFactor(ctx context.Context, userID int64, secret, code string, now time.Time) ([]string, error) {
+	step, ok := totp.Validate(secret, code, now)
+	if !ok {
+		return nil, ErrInvalidSecondFactor
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deps.Enabler.EnableTwoFactor(ctx, userID, secret, step, hashes); err != nil {
+		return nil, fmt.Errorf("enabling two-factor: %w", err)
+	}
+	return codes, nil
+}
+
+// VerifySecondFactor completes a login started by Authenticate. The code is
+// either the current TOTP code or one of the user's recovery codes, each
+// usable once. A wrong code counts like a wrong password, so guessing codes
+// across fresh password steps still locks the account; the login is
+// recorded, clearing failed attempts, only when the code is right.
+func (s *Service) VerifySecondFactor(ctx context.Context, a SecondFactorAttempt, now time.Time) (User, error) {
+	u, _, err := s.deps.IDGetter.GetByID(ctx, a.UserID)
+	if err != nil {
+		return User{}, fmt.Errorf("looking up user: %w", err)
+	}
+	ev := LoginEvent{UserID: u.ID, Email: u.Email, IP: a.IP, UserAgent: a.UserAgent, At: now}
+	if u.Locked(now) {
+		return User{}, s.refuseLogin(ctx, ev, ErrAccountLocked)
+	}
+
+	if err := s.checkSecondFactor(ctx, a.UserID, a.Code, now); err != nil {
+		if errors.Is(err, ErrInvalidSecondFactor) {
+			return User{}, s.recordLoginFailure(ctx, ev, ErrInvalidSecondFactor)
+		}
+		return User{}, err
+	}
+	if !u.Active {
+		return User{}, s.refuseLogin(ctx, ev, ErrDeactivated)
+	}
+
+	ev.Success = true
+	if err := s.deps.LoginRecorder.RecordLogin(ctx, ev); err != nil {
+		return User{}, fmt.Errorf("recording login: %w", err)
+	}
+	return u, nil
+}
+
+// TwoFactorStatus reports whether the user has 2FA, must have it, and how
+// many recovery codes are left.
+func (s *Service) TwoFactorStatus(ctx context.Context, userID int64) (TwoFactorStatus, error) {
+	u, _, err := s.deps.IDGetter.GetByID(ctx, userID)
+	if err != nil {
+		return TwoFactorStatus{}, fmt.Errorf("looking up user: %w", err)
+	}
+	st := TwoFactorStatus{Enabled: u.TwoFactorEnabled, Required: u.TwoFactorRequired()}
+	if st.Enabled {
+		st.RecoveryCodesLeft, err = s.deps.CountRecovery.CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			return TwoFactorStatus{}, fmt.Errorf("counting recovery codes: %w", err)
+		}
+	}
+	return st, nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking
+// a current code, and returns the new ones.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string, now time.Time) ([]string, error) {
+	if err := s.checkSecondFactor(ctx, userID, code, now); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deps.Recovery.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("replacing recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns 2FA off after checking a current code. Accounts
+// that must use 2FA cannot turn it off.
+func (s *Service) DisableTwoFactor(ctx context.Context, userID int64, code string, now time.Time) error {
+	u, _, err := s.deps.IDGetter.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if u.TwoFactorRequired() {
+		return ErrTwoFactorRequired
+	}
+	if err := s.checkSecondFactor(ctx, userID, code, now); err != nil {
+		return err
+	}
+	if err := s.deps.Disabler.DisableTwoFactor(ctx, userID); err != nil {
+		return fmt.Errorf("disabling two-factor: %w", err)
+	}
+	return nil
+}
+
+// checkSecondFactor accepts a TOTP code newer than the last one used, or an
+// unused recovery code.
+func (s *Service) checkSecondFactor(ctx context.Context, userID int64, code string, now time.Time) error {
+	secret, _, err := s.deps.TOTP.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorDisabled) {
+			return ErrInvalidSecondFactor
+		}
+		return fmt.Errorf("looking up totp secret: %w", err)
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(secret, code, now)
+		if !ok {
+			return ErrInvalidSecondFactor
+		}
+		fresh, err := s.deps.TOTPSteps.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return fmt.Errorf("recording totp step: %w", err)
+		}
+		if !fresh {
+			return ErrInvalidSecondFactor
+		}
+		return nil
+	}
+
+	used, err := s.deps.UseRecovery.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return fmt.Errorf("using recovery code: %w", err)
+	}
+	if !used {
+		return ErrInvalidSecondFactor
+	}
+	return nil
+}
+
+// TrackSession links a freshly issued session token to the user and the
+// device it was issued to.
+func (s *Service) TrackSession(ctx context.Context, userID int64, token string, d Device) error {
+	if err := s.deps.Sessions.TrackSession(ctx, userID, token, d); err != nil {
+		return fmt.Errorf("tracking session: %w", err)
+	}
+	return nil
+}
+
+// TouchSession records that the session holding token was used at at.
+func (s *Service) TouchSession(ctx context.Context, token string, at time.Time) error {
+	if err := s.deps.SessionToucher.TouchSession(ctx, token, at); err != nil {
+		return fmt.Errorf("touching session: %w", err)
+	}
+	return nil
+}
+
+// Sessions lists the user's open sessions, most recently used first.
+func (s *Service) Sessions(ctx context.Context, userID int64, currentToken string) ([]Session, error) {
+	sessions, err := s.deps.SessionLister.ListSessions(ctx, userID, currentToken)
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession logs the user out of one of their sessions.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	if err := s.deps.SessionRevoker.RevokeSession(ctx, userID, sessionID); err != nil {
+		return fmt.Errorf("revoking session: %w", err)
+	}
+	return nil
+}
+
+// RevokeOtherSessions logs the user out everywhere but the session holding
+// keepToken, and returns how many sessions ended.
+func (s *Service) RevokeOtherSessions(ctx context.Context, userID int64, keepToken string) (int, error) {
+	n, err := s.deps.SessionRevoker.RevokeOtherSessions(ctx, userID, keepToken)
+	if err != nil {
+		return 0, fmt.Errorf("revoking other sessions: %w", err)
+	}
+	return n, nil
+}
+
 // SeedAdmin creates an admin user with the given email and password.
+// FindByEmail returns the user with the given email, or ErrNotFound.
+func (s *Service) FindByEmail(ctx context.Context, email string) (User, error) {
+	u, _, err := s.deps.EmailGetter.GetByEmail(ctx, email)
+	if err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+// SetTemporaryPassword gives the user a password they must change at the
+// next login, lifts a lock and logs them out everywhere. It is the
+// operator's way in when a user, an admin included, cannot reset their own.
+// Federated accounts are refused with ErrFederated: their provider decides.
+func (s *Service) SetTemporaryPassword(ctx context.Context, userID int64, password string) error {
+	if password == "" {
+		return ErrPasswordRequired
+	}
+	u, _, err := s.deps.IDGetter.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if u.Federated {
+		return ErrFederated
+	}
+	hash, err := s.deps.Hasher(password)
+	if err != nil {
+		return fmt.Errorf("hashing temporary password: %w", err)
+	}
+	if err := s.deps.TempPasswords.SetTemporaryPassword(ctx, userID, hash); err != nil {
+		return fmt.Errorf("setting temporary password: %w", err)
+	}
+	return nil
+}
+
 func (s *Service) SeedAdmin(ctx context.Context, email, password string) (User, error) {
 	hash, err := s.deps.Hasher(password)
 	if err != nil {
 		return User{}, fmt.Errorf("hashing admin password: %w", err)
 	}
 
-	u, err := s.deps.Creator.Create(ctx, email, hash, "Admin", "admin")
+	u, err := s.deps.Creator.Create(ctx, email, hash, "Admin", permission.RoleAdmin)
 	if err != nil {
 		return User{}, fmt.Errorf("creating admin user: %w", err)
 	}
 
 	return u, nil
 }
+
+// newResetToken returns a random URL-safe token.
+func newResetToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashResetToken is how reset tokens are stored: only the hash reaches the database.
+func hashResetToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// recoveryAlphabet leaves out characters that are easy to misread.
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// newRecoveryCodes returns RecoveryCodeCount codes formatted as xxxxx-xxxxx
+// and their hashes.
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([][]byte, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 0, 10)
+		for len(b) < cap(b) {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryAlphabet))))
+			if err != nil {
+				return nil, nil, fmt.Errorf("generating recovery code: %w", err)
+			}
+			b = append(b, recoveryAlphabet[n.Int64()])
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code as typed, ignoring case, spaces
+// and dashes.
+func hashRecoveryCode(code string) []byte {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return sum[:]
+}
@@ -3,8 +3,11 @@ package user_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/totp"
 	"github.com/giorgiovilardo/pharmarecall/internal/user"
 )
 
@@ -20,18 +23,71 @@ func (m *mockEmailGetter) GetByEmail(_ context.Context, _ string) (user.User, st
 	return m.user, m.passHash, m.err
 }
 
+// mockLoginRecorder keeps the login attempts of one account in memory.
+type mockLoginRecorder struct {
+	recorded    []int64
+	events      []user.LoginEvent
+	failures    int
+	lockedUntil time.Time
+	ipFailures  int
+	err         error
+}
+
+func (m *mockLoginRecorder) RecordLogin(_ context.Context, ev user.LoginEvent) error {
+	m.recorded = append(m.recorded, ev.UserID)
+	m.events = append(m.events, ev)
+	m.failures = 0
+	return m.err
+}
+
+func (m *mockLoginRecorder) RecordLoginEvent(_ context.Context, ev user.LoginEvent) error {
+	m.events = append(m.events, ev)
+	return nil
+}
+
+func (m *mockLoginRecorder) RecordLoginFailure(_ context.Context, ev user.LoginEvent) (int, error) {
+	m.events = append(m.events, ev)
+	m.failures++
+	return m.failures, nil
+}
+
+func (m *mockLoginRecorder) LockAccount(_ context.Context, _ int64, until time.Time) error {
+	m.lockedUntil = until
+	return nil
+}
+
+func (m *mockLoginRecorder) CountFailedLogins(_ context.Context, _ string, _ time.Time) (int, error) {
+	return m.ipFailures, nil
+}
+
+func authService(getter *mockEmailGetter, recorder *mockLoginRecorder, verifier func(hash, password string) error) *user.Service {
+	return user.NewServiceWith(user.ServiceDeps{
+		EmailGetter:   getter,
+		LoginRecorder: recorder,
+		LoginEvents:   recorder,
+		LoginFailures: recorder,
+		Locker:        recorder,
+		IPFailures:    recorder,
+		Verifier:      verifier,
+	})
+}
+
+func attempt(email, password string) user.LoginAttempt {
+	return user.LoginAttempt{Email: email, Password: password, IP: "192.0.2.10", UserAgent: "Firefox"}
+}
+
+func wrongPassword(_, _ string) error { return errors.New("mismatch") }
+
 // --- Tests ---
 
 func TestAuthenticateSuccess(t *testing.T) {
-	svc := user.NewServiceWith(user.ServiceDeps{
-		EmailGetter: &mockEmailGetter{
-			user:     user.User{ID: 1, Email: "admin@example.com", Name: "Admin", Role: "admin"},
-			passHash: "hashed-password",
-		},
-		Verifier: func(hash, password string) error { return nil },
-	})
+	recorder := &mockLoginRecorder{}
+	svc := authService(&mockEmailGetter{
+		user:     user.User{ID: 1, Email: "admin@example.com", Name: "Admin", Role: "admin", Active: true},
+		passHash: "hashed-password",
+	}, recorder, func(hash, password string) error { return nil })
 
-	got, err := svc.Authenticate(context.Background(), "admin@example.com", "secret123")
+	got, err := svc.Authenticate(context.Background(), attempt("admin@example.com", "secret123"), time.Now())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -44,32 +100,213 @@ func TestAuthenticateSuccess(t *testing.T) {
 	if got.Role != "admin" {
 		t.Errorf("Role = %q, want admin", got.Role)
 	}
+	if len(recorder.recorded) != 1 || recorder.recorded[0] != 1 {
+		t.Errorf("recorded logins = %v, want [1]", recorder.recorded)
+	}
+	if ev := recorder.events[0]; !ev.Success || ev.IP != "192.0.2.10" || ev.UserAgent != "Firefox" {
+		t.Errorf("event = %+v, want a successful login from 192.0.2.10 with Firefox", ev)
+	}
+}
+
+func TestAuthenticateRecordLoginErrorFails(t *testing.T) {
+	svc := authService(&mockEmailGetter{
+		user:     user.User{ID: 1, Email: "admin@example.com", Active: true},
+		passHash: "hashed-password",
+	}, &mockLoginRecorder{err: errors.New("db down")}, func(_, _ string) error { return nil })
+
+	_, err := svc.Authenticate(context.Background(), attempt("admin@example.com", "secret123"), time.Now())
+	if err == nil || errors.Is(err, user.ErrInvalidCredentials) {
+		t.Errorf("error = %v, want wrapped repository error", err)
+	}
 }
 
 func TestAuthenticateUserNotFound(t *testing.T) {
-	svc := user.NewServiceWith(user.ServiceDeps{
-		EmailGetter: &mockEmailGetter{err: user.ErrNotFound},
-	})
+	recorder := &mockLoginRecorder{}
+	svc := authService(&mockEmailGetter{err: user.ErrNotFound}, recorder, nil)
 
-	_, err := svc.Authenticate(context.Background(), "nobody@example.com", "whatever")
+	_, err := svc.Authenticate(context.Background(), attempt("nobody@example.com", "whatever"), time.Now())
 	if !errors.Is(err, user.ErrInvalidCredentials) {
 		t.Errorf("error = %v, want ErrInvalidCredentials", err)
 	}
+	if len(recorder.events) != 1 || recorder.events[0].UserID != 0 || recorder.events[0].Success {
+		t.Errorf("events = %+v, want one failed attempt without a user", recorder.events)
+	}
 }
 
 func TestAuthenticateWrongPassword(t *testing.T) {
-	svc := user.NewServiceWith(user.ServiceDeps{
-		EmailGetter: &mockEmailGetter{
-			user:     user.User{ID: 1, Email: "user@example.com"},
-			passHash: "hashed",
-		},
-		Verifier: func(_, _ string) error { return errors.New("mismatch") },
-	})
+	recorder := &mockLoginRecorder{}
+	svc := authService(&mockEmailGetter{
+		user:     user.User{ID: 1, Email: "user@example.com", Active: true},
+		passHash: "hashed",
+	}, recorder, wrongPassword)
 
-	_, err := svc.Authenticate(context.Background(), "user@example.com", "wrong")
+	_, err := svc.Authenticate(context.Background(), attempt("user@example.com", "wrong"), time.Now())
 	if !errors.Is(err, user.ErrInvalidCredentials) {
 		t.Errorf("error = %v, want ErrInvalidCredentials", err)
 	}
+	if recorder.failures != 1 {
+		t.Errorf("failures = %d, want 1", recorder.failures)
+	}
+}
+
+func TestAuthenticateDeactivatedUserIsRefused(t *testing.T) {
+	recorder := &mockLoginRecorder{}
+	svc := authService(&mockEmailGetter{
+		user:     user.User{ID: 1, Email: "gone@example.com", Active: false},
+		passHash: "hashed",
+	}, recorder, func(_, _ string) error { return nil })
+
+	_, err := svc.Authenticate(context.Background(), attempt("gone@example.com", "secret123"), time.Now())
+	if !errors.Is(err, user.ErrDeactivated) {
+		t.Errorf("error = %v, want ErrDeactivated", err)
+	}
+	if len(recorder.recorded) != 0 {
+		t.Error("a refused login must not be recorded as a login")
+	}
+}
+
+func TestAuthenticateRefusesFederatedUser(t *testing.T) {
+	recorder := &mockLoginRecorder{}
+	svc := authService(&mockEmailGetter{
+		user:     user.User{ID: 1, Email: "sso@example.com", Active: true, Federated: true},
+		passHash: "hash-set-before-federation",
+	}, recorder, func(_, _ string) error { return nil })
+
+	_, err := svc.Authenticate(context.Background(), attempt("sso@example.com", "secret123"), time.Now())
+	if !errors.Is(err, user.ErrFederated) {
+		t.Errorf("error = %v, want ErrFederated", err)
+	}
+	if len(recorder.recorded) != 0 {
+		t.Error("a password login of a federated account must not be recorded as a login")
+	}
+}
+
+func TestAuthenticateDeactivatedWrongPasswordLooksLikeBadCredentials(t *testing.T) {
+	svc := authService(&mockEmailGetter{
+		user:     user.User{ID: 1, Email: "gone@example.com", Active: false},
+		passHash: "hashed",
+	}, &mockLoginRecorder{}, wrongPassword)
+
+	_, err := svc.Authenticate(context.Background(), attempt("gone@example.com", "wrong"), time.Now())
+	if !errors.Is(err, user.ErrInvalidCredentials) {
+		t.Errorf("error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestAuthenticateLocksAccountProgressively(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	recorder := &mockLoginRecorder{failures: user.MaxFailedLogins - 1}
+	getter := &mockEmailGetter{user: user.User{ID: 1, Email: "user@example.com", Active: true}, passHash: "hashed"}
+	svc := authService(getter, recorder, wrongPassword)
+
+	_, err := svc.Authenticate(context.Background(), attempt("user@example.com", "wrong"), now)
+	if !errors.Is(err, user.ErrAccountLocked) {
+		t.Fatalf("error = %v, want ErrAccountLocked", err)
+	}
+	if want := now.Add(user.LockoutBase); !recorder.lockedUntil.Equal(want) {
+		t.Errorf("locked until %v, want %v", recorder.lockedUntil, want)
+	}
+
+	// After the lock expires, the next wrong password locks for twice as long.
+	getter.user.LockedUntil = recorder.lockedUntil
+	later := recorder.lockedUntil.Add(time.Second)
+	if _, err := svc.Authenticate(context.Background(), attempt("user@example.com", "wrong"), later); !errors.Is(err, user.ErrAccountLocked) {
+		t.Fatalf("error = %v, want ErrAccountLocked", err)
+	}
+	if want := later.Add(2 * user.LockoutBase); !recorder.lockedUntil.Equal(want) {
+		t.Errorf("locked until %v, want %v", recorder.lockedUntil, want)
+	}
+}
+
+func TestAuthenticateLockedAccountRefusesRightPassword(t *testing.T) {
+	now := time.Now()
+	recorder := &mockLoginRecorder{}
+	svc := authService(&mockEmailGetter{
+		user:     user.User{ID: 1, Email: "user@example.com", Active: true, LockedUntil: now.Add(time.Minute)},
+		passHash: "hashed",
+	}, recorder, func(_, _ string) error { return nil })
+
+	_, err := svc.Authenticate(context.Background(), attempt("user@example.com", "right"), now)
+	if !errors.Is(err, user.ErrAccountLocked) {
+		t.Errorf("error = %v, want ErrAccountLocked", err)
+	}
+	if recorder.failures != 0 || len(recorder.events) != 1 {
+		t.Errorf("failures = %d events = %d, want the attempt recorded without extending the lock", recorder.failures, len(recorder.events))
+	}
+}
+
+func TestAuthenticateThrottlesIP(t *testing.T) {
+	getter := &mockEmailGetter{user: user.User{ID: 1, Active: true}}
+	svc := authService(getter, &mockLoginRecorder{ipFailures: user.MaxFailedLoginsPerIP}, func(_, _ string) error { return nil })
+
+	_, err := svc.Authenticate(context.Background(), attempt("user@example.com", "right"), time.Now())
+	if !errors.Is(err, user.ErrTooManyAttempts) {
+		t.Errorf("error = %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{user.MaxFailedLogins - 1, 0},
+		{user.MaxFailedLogins, time.Minute},
+		{user.MaxFailedLogins + 1, 2 * time.Minute},
+		{user.MaxFailedLogins + 3, 8 * time.Minute},
+		{user.MaxFailedLogins + 20, user.LockoutMax},
+	}
+	for _, tt := range tests {
+		if got := user.LockoutDuration(tt.failures); got != tt.want {
+			t.Errorf("LockoutDuration(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// --- Locale ---
+
+type mockLocaleSetter struct {
+	called bool
+	got    string
+}
+
+func (m *mockLocaleSetter) SetLocale(_ context.Context, _ int64, locale string) error {
+	m.called = true
+	m.got = locale
+	return nil
+}
+
+func TestSetLocaleStoresChoice(t *testing.T) {
+	setter := &mockLocaleSetter{}
+	getter := &mockIDGetter{user: user.User{ID: 1, Locale: "de", PharmacyLocale: "it"}}
+	svc := user.NewServiceWith(user.ServiceDeps{Locales: setter, IDGetter: getter})
+
+	u, err := svc.SetLocale(context.Background(), 1, "de")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if setter.got != "de" || u.PreferredLocale() != "de" {
+		t.Errorf("stored %q, preferred %q, want de", setter.got, u.PreferredLocale())
+	}
+}
+
+func TestSetLocaleRejectsUnsupported(t *testing.T) {
+	setter := &mockLocaleSetter{}
+	svc := user.NewServiceWith(user.ServiceDeps{Locales: setter})
+
+	if _, err := svc.SetLocale(context.Background(), 1, "fr"); !errors.Is(err, user.ErrInvalidLocale) {
+		t.Errorf("err = %v, want ErrInvalidLocale", err)
+	}
+	if setter.called {
+		t.Error("an unsupported locale should not be stored")
+	}
+}
+
+func TestPreferredLocaleFallsBackToPharmacy(t *testing.T) {
+	u := user.User{PharmacyLocale: "de"}
+	if got := u.PreferredLocale(); got != "de" {
+		t.Errorf("PreferredLocale = %q, want de", got)
+	}
 }
 
 // --- ChangePassword mocks ---
@@ -160,7 +397,7 @@ func (m *mockCreator) Create(_ context.Context, email, passwordHash, name, role
 // --- SeedAdmin tests ---
 
 func TestSeedAdminSuccess(t *testing.T) {
-	creator := &mockCreator{user: user.User{ID: 1, Email: "admin@example.com", Name: "Admin", Role: "admin"}}
+	creator := &mockCreator{user: user.User{ID: 1, Email: "admin@example.com", Name: "Admin", Role: "admin", Active: true}}
 	svc := user.NewServiceWith(user.ServiceDeps{
 		Creator: creator,
 		Hasher:  func(s string) (string, error) { return "hashed-" + s, nil },
@@ -189,3 +426,686 @@ func TestSeedAdminSuccess(t *testing.T) {
 		t.Errorf("name = %q, want Admin", creator.gotName)
 	}
 }
+
+// --- SetTemporaryPassword tests ---
+
+type mockTempPasswords struct {
+	gotID   int64
+	gotHash string
+}
+
+func (m *mockTempPasswords) SetTemporaryPassword(_ context.Context, userID int64, hash string) error {
+	m.gotID, m.gotHash = userID, hash
+	return nil
+}
+
+func TestSetTemporaryPassword(t *testing.T) {
+	store := &mockTempPasswords{}
+	svc := user.NewServiceWith(user.ServiceDeps{
+		IDGetter:      &mockIDGetter{user: user.User{ID: 4, Active: true}},
+		TempPasswords: store,
+		Hasher:        func(s string) (string, error) { return "hashed-" + s, nil },
+	})
+
+	if err := svc.SetTemporaryPassword(context.Background(), 4, "temp"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.gotID != 4 || store.gotHash != "hashed-temp" {
+		t.Errorf("stored %d %q, want 4 hashed-temp", store.gotID, store.gotHash)
+	}
+	if err := svc.SetTemporaryPassword(context.Background(), 4, ""); !errors.Is(err, user.ErrPasswordRequired) {
+		t.Errorf("empty password: err = %v, want ErrPasswordRequired", err)
+	}
+}
+
+func TestSetTemporaryPasswordRefusesFederatedUser(t *testing.T) {
+	store := &mockTempPasswords{}
+	svc := user.NewServiceWith(user.ServiceDeps{
+		IDGetter:      &mockIDGetter{user: user.User{ID: 4, Active: true, Federated: true}},
+		TempPasswords: store,
+		Hasher:        func(s string) (string, error) { return "hashed-" + s, nil },
+	})
+
+	if err := svc.SetTemporaryPassword(context.Background(), 4, "temp"); !errors.Is(err, user.ErrFederated) {
+		t.Errorf("err = %v, want ErrFederated", err)
+	}
+	if store.gotHash != "" {
+		t.Error("a federated account must not get a password")
+	}
+}
+
+// --- Password reset tests ---
+
+type mockResetStore struct {
+	recent    int
+	userID    int64
+	hash      []byte
+	expiresAt time.Time
+	resetHash []byte
+	newHash   string
+	resetErr  error
+}
+
+func (m *mockResetStore) CreateResetToken(_ context.Context, userID int64, hash []byte, expiresAt time.Time) error {
+	m.userID, m.hash, m.expiresAt = userID, hash, expiresAt
+	return nil
+}
+
+func (m *mockResetStore) CountRecentResets(_ context.Context, _ int64, _ time.Time) (int, error) {
+	return m.recent, nil
+}
+
+func (m *mockResetStore) ResetPassword(_ context.Context, tokenHash []byte, passwordHash string, _ time.Time) error {
+	m.resetHash, m.newHash = tokenHash, passwordHash
+	return m.resetErr
+}
+
+type mockMailer struct {
+	sent []user.PasswordReset
+	err  error
+}
+
+func (m *mockMailer) SendPasswordReset(_ context.Context, r user.PasswordReset) error {
+	m.sent = append(m.sent, r)
+	return m.err
+}
+
+var resetNow = time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+func resetService(getter *mockEmailGetter, store *mockResetStore, mailer *mockMailer) *user.Service {
+	return user.NewServiceWith(user.ServiceDeps{
+		EmailGetter:  getter,
+		ResetTokens:  store,
+		RecentResets: store,
+		Resetter:     store,
+		Mailer:       mailer,
+		Hasher:       func(s string) (string, error) { return "hashed-" + s, nil },
+	})
+}
+
+func TestRequestPasswordResetSendsHashedSingleUseLink(t *testing.T) {
+	store := &mockResetStore{}
+	mailer := &mockMailer{}
+	getter := &mockEmailGetter{user: user.User{ID: 4, Email: "anna@example.com", Name: "Anna", Active: true}}
+
+	if err := resetService(getter, store, mailer).RequestPasswordReset(context.Background(), "anna@example.com", "https://farmacia.example.it/", resetNow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(mailer.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(mailer.sent))
+	}
+	r := mailer.sent[0]
+	token, ok := strings.CutPrefix(r.Link, "https://farmacia.example.it/reset-password/")
+	if !ok || token == "" {
+		t.Fatalf("link = %q, want a reset link on the base URL", r.Link)
+	}
+	if r.To != "anna@example.com" {
+		t.Errorf("to = %q, want anna@example.com", r.To)
+	}
+	if store.userID != 4 || !r.ExpiresAt.Equal(resetNow.Add(user.ResetLinkLifetime)) {
+		t.Errorf("stored token for user %d expiring %v", store.userID, r.ExpiresAt)
+	}
+	if strings.Contains(string(store.hash), token) || len(store.hash) != 32 {
+		t.Error("only the SHA-256 hash of the token must be stored")
+	}
+
+	// The emailed token is what ResetPassword looks up.
+	if err := resetService(getter, store, mailer).ResetPassword(context.Background(), token, "nuova", resetNow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(store.resetHash) != string(store.hash) {
+		t.Error("reset must look up the stored hash of the emailed token")
+	}
+	if store.newHash != "hashed-nuova" {
+		t.Errorf("new hash = %q, want hashed-nuova", store.newHash)
+	}
+}
+
+func TestRequestPasswordResetRevealsNothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		getter *mockEmailGetter
+		recent int
+	}{
+		{"unknown email", &mockEmailGetter{err: user.ErrNotFound}, 0},
+		{"deactivated account", &mockEmailGetter{user: user.User{ID: 4, Active: false}}, 0},
+		{"federated account", &mockEmailGetter{user: user.User{ID: 4, Active: true, Federated: true}}, 0},
+		{"rate limited", &mockEmailGetter{user: user.User{ID: 4, Active: true}}, user.MaxResetRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := &mockMailer{}
+			err := resetService(tt.getter, &mockResetStore{recent: tt.recent}, mailer).RequestPasswordReset(context.Background(), "x@example.com", "http://localhost", resetNow)
+			if err != nil {
+				t.Errorf("error = %v, want nil", err)
+			}
+			if len(mailer.sent) != 0 {
+				t.Error("no email must be sent")
+			}
+		})
+	}
+}
+
+func TestRequestPasswordResetSendFailure(t *testing.T) {
+	mailer := &mockMailer{err: errors.New("smtp down")}
+	getter := &mockEmailGetter{user: user.User{ID: 4, Active: true}}
+
+	err := resetService(getter, &mockResetStore{}, mailer).RequestPasswordReset(context.Background(), "x@example.com", "http://localhost", resetNow)
+	if !errors.Is(err, user.ErrSendFailed) {
+		t.Errorf("error = %v, want ErrSendFailed", err)
+	}
+}
+
+func TestResetPasswordInvalidToken(t *testing.T) {
+	store := &mockResetStore{resetErr: user.ErrNotFound}
+	err := resetService(nil, store, nil).ResetPassword(context.Background(), "stale", "nuova", resetNow)
+	if !errors.Is(err, user.ErrInvalidResetToken) {
+		t.Errorf("error = %v, want ErrInvalidResetToken", err)
+	}
+}
+
+func TestResetPasswordRequiresPassword(t *testing.T) {
+	store := &mockResetStore{}
+	err := resetService(nil, store, nil).ResetPassword(context.Background(), "token", "", resetNow)
+	if !errors.Is(err, user.ErrPasswordRequired) {
+		t.Errorf("error = %v, want ErrPasswordRequired", err)
+	}
+	if store.resetHash != nil {
+		t.Error("the token must not be consumed without a password")
+	}
+}
+
+// --- Two-factor tests ---
+
+// mockTwoFactorStore keeps one user's TOTP state in memory, enforcing the
+// same single-use rules as the database.
+type mockTwoFactorStore struct {
+	secret   string
+	lastStep int64
+	recovery map[string]bool // hex hash → used
+	disabled bool
+}
+
+func (m *mockTwoFactorStore) GetTOTP(_ context.Context, _ int64) (string, int64, error) {
+	if m.secret == "" {
+		return "", 0, user.ErrTwoFactorDisabled
+	}
+	return m.secret, m.lastStep, nil
+}
+
+func (m *mockTwoFactorStore) UseTOTPStep(_ context.Context, _, step int64) (bool, error) {
+	if step <= m.lastStep {
+		return false, nil
+	}
+	m.lastStep = step
+	return true, nil
+}
+
+func (m *mockTwoFactorStore) EnableTwoFactor(_ context.Context, _ int64, secret string, step int64, hashes [][]byte) error {
+	m.secret, m.lastStep = secret, step
+	return m.ReplaceRecoveryCodes(context.Background(), 0, hashes)
+}
+
+func (m *mockTwoFactorStore) DisableTwoFactor(_ context.Context, _ int64) error {
+	m.secret, m.recovery, m.disabled = "", nil, true
+	return nil
+}
+
+func (m *mockTwoFactorStore) ReplaceRecoveryCodes(_ context.Context, _ int64, hashes [][]byte) error {
+	m.recovery = map[string]bool{}
+	for _, h := range hashes {
+		m.recovery[string(h)] = false
+	}
+	return nil
+}
+
+func (m *mockTwoFactorStore) UseRecoveryCode(_ context.Context, _ int64, hash []byte) (bool, error) {
+	used, ok := m.recovery[string(hash)]
+	if !ok || used {
+		return false, nil
+	}
+	m.recovery[string(hash)] = true
+	return true, nil
+}
+
+func (m *mockTwoFactorStore) CountRecoveryCodes(_ context.Context, _ int64) (int, error) {
+	n := 0
+	for _, used := range m.recovery {
+		if !used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func twoFactorService(u user.User, store *mockTwoFactorStore) *user.Service {
+	recorder := &mockLoginRecorder{}
+	return user.NewServiceWith(user.ServiceDeps{
+		IDGetter:      &mockIDGetter{user: u},
+		LoginRecorder: recorder,
+		LoginEvents:   recorder,
+		LoginFailures: recorder,
+		Locker:        recorder,
+		TOTP:          store,
+		TOTPSteps:     store,
+		Enabler:       store,
+		Disabler:      store,
+		Recovery:      store,
+		UseRecovery:   store,
+		CountRecovery: store,
+	})
+}
+
+func secondFactorService(ids *mockIDGetter, store *mockTwoFactorStore, recorder *mockLoginRecorder) *user.Service {
+	return user.NewServiceWith(user.ServiceDeps{
+		IDGetter:      ids,
+		LoginRecorder: recorder,
+		LoginEvents:   recorder,
+		LoginFailures: recorder,
+		Locker:        recorder,
+		TOTP:          store,
+		TOTPSteps:     store,
+		Enabler:       store,
+		Recovery:      store,
+		UseRecovery:   store,
+	})
+}
+
+func secondFactor(code string) user.SecondFactorAttempt {
+	return user.SecondFactorAttempt{UserID: 1, Code: code, IP: "192.0.2.10", UserAgent: "Firefox"}
+}
+
+func enrolled(t *testing.T, svc *user.Service, now time.Time) []string {
+	t.Helper()
+	e, err := svc.BeginTwoFactorEnrolment(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("beginning enrolment: %v", err)
+	}
+	if !strings.HasPrefix(e.URI, "otpauth://totp/") || !strings.Contains(e.URI, "secret="+e.Secret) {
+		t.Errorf("URI = %q, want an otpauth URI with the secret", e.URI)
+	}
+	code, err := totp.Code(e.Secret, totp.Step(now))
+	if err != nil {
+		t.Fatalf("computing code: %v", err)
+	}
+	codes, err := svc.EnableTwoFactor(context.Background(), 1, e.Secret, code, now)
+	if err != nil {
+		t.Fatalf("enabling: %v", err)
+	}
+	return codes
+}
+
+func TestEnableTwoFactorRequiresValidCode(t *testing.T) {
+	store := &mockTwoFactorStore{}
+	svc := twoFactorService(user.User{ID: 1, Email: "a@example.com", Active: true}, store)
+
+	_, err := svc.EnableTwoFactor(context.Background(), 1, "JBSWY3DPEHPK3PXP", "000000", time.Now())
+	if !errors.Is(err, user.ErrInvalidSecondFactor) {
+		t.Errorf("error = %v, want ErrInvalidSecondFactor", err)
+	}
+	if store.secret != "" {
+		t.Error("secret must not be stored without a valid code")
+	}
+}
+
+func TestEnableTwoFactorReturnsRecoveryCodes(t *testing.T) {
+	store := &mockTwoFactorStore{}
+	svc := twoFactorService(user.User{ID: 1, Email: "a@example.com", Active: true}, store)
+
+	codes := enrolled(t, svc, time.Now())
+	if len(codes) != user.RecoveryCodeCount || len(store.recovery) != user.RecoveryCodeCount {
+		t.Errorf("got %d codes and %d stored hashes, want %d", len(codes), len(store.recovery), user.RecoveryCodeCount)
+	}
+	for h := range store.recovery {
+		for _, c := range codes {
+			if strings.Contains(h, c) {
+				t.Fatal("recovery codes must be stored hashed")
+			}
+		}
+	}
+}
+
+func TestVerifySecondFactorRefusesReplayedCode(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	store := &mockTwoFactorStore{}
+	svc := twoFactorService(user.User{ID: 1, Email: "a@example.com", Active: true}, store)
+	enrolled(t, svc, now)
+
+	later := now.Add(totp.Period)
+	code, err := totp.Code(store.secret, totp.Step(later))
+	if err != nil {
+		t.Fatalf("computing code: %v", err)
+	}
+	if _, err := svc.VerifySecondFactor(context.Background(), secondFactor(code), later); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := svc.VerifySecondFactor(context.Background(), secondFactor(code), later); !errors.Is(err, user.ErrInvalidSecondFactor) {
+		t.Errorf("replay error = %v, want ErrInvalidSecondFactor", err)
+	}
+}
+
+func TestVerifySecondFactorRecoveryCodeIsSingleUse(t *testing.T) {
+	store := &mockTwoFactorStore{}
+	svc := twoFactorService(user.User{ID: 1, Email: "a@example.com", Active: true}, store)
+	codes := enrolled(t, svc, time.Now())
+
+	// Codes are accepted however the user types them.
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))
+	if _, err := svc.VerifySecondFactor(context.Background(), secondFactor(typed), time.Now()); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := svc.VerifySecondFactor(context.Background(), secondFactor(codes[0]), time.Now()); !errors.Is(err, user.ErrInvalidSecondFactor) {
+		t.Errorf("reuse error = %v, want ErrInvalidSecondFactor", err)
+	}
+	if n, _ := store.CountRecoveryCodes(context.Background(), 1); n != user.RecoveryCodeCount-1 {
+		t.Errorf("unused codes = %d, want %d", n, user.RecoveryCodeCount-1)
+	}
+}
+
+func TestVerifySecondFactorRefusesDeactivatedUser(t *testing.T) {
+	store := &mockTwoFactorStore{}
+	svc := twoFactorService(user.User{ID: 1, Email: "a@example.com", Active: true}, store)
+	codes := enrolled(t, svc, time.Now())
+
+	svc = twoFactorService(user.User{ID: 1, Active: false}, store)
+	if _, err := svc.VerifySecondFactor(context.Background(), secondFactor(codes[0]), time.Now()); !errors.Is(err, user.ErrDeactivated) {
+		t.Errorf("error = %v, want ErrDeactivated", err)
+	}
+}
+
+func TestAuthenticateLeavesTwoFactorLoginPending(t *testing.T) {
+	recorder := &mockLoginRecorder{failures: 2}
+	svc := authService(&mockEmailGetter{
+		user:     user.User{ID: 1, Email: "a@example.com", Active: true, TwoFactorEnabled: true},
+		passHash: "hashed",
+	}, recorder, func(_, _ string) error { return nil })
+
+	if _, err := svc.Authenticate(context.Background(), attempt("a@example.com", "right"), time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recorder.recorded) != 0 || recorder.failures != 2 {
+		t.Errorf("recorded = %v failures = %d, want no login and failures kept until the second factor", recorder.recorded, recorder.failures)
+	}
+}
+
+func TestVerifySecondFactorRecordsLogin(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	store := &mockTwoFactorStore{}
+	recorder := &mockLoginRecorder{failures: 2}
+	svc := secondFactorService(&mockIDGetter{user: user.User{ID: 1, Email: "a@example.com", Active: true}}, store, recorder)
+	enrolled(t, svc, now)
+
+	later := now.Add(totp.Period)
+	code, err := totp.Code(store.secret, totp.Step(later))
+	if err != nil {
+		t.Fatalf("computing code: %v", err)
+	}
+	if _, err := svc.VerifySecondFactor(context.Background(), secondFactor(code), later); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recorder.recorded) != 1 || recorder.failures != 0 {
+		t.Errorf("recorded = %v failures = %d, want one login clearing the failures", recorder.recorded, recorder.failures)
+	}
+	if ev := recorder.events[len(recorder.events)-1]; !ev.Success || ev.IP != "192.0.2.10" || ev.UserAgent != "Firefox" {
+		t.Errorf("event = %+v, want a successful login from 192.0.2.10 with Firefox", ev)
+	}
+}
+
+// Starting over with the right password must not reset the count of wrong
+// codes, or the second factor could be guessed without limit.
+func TestWrongSecondFactorsLockAccount(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	u := user.User{ID: 1, Email: "a@example.com", Active: true, TwoFactorEnabled: true}
+	getter := &mockEmailGetter{user: u, passHash: "hashed"}
+	ids := &mockIDGetter{user: u}
+	store := &mockTwoFactorStore{}
+	recorder := &mockLoginRecorder{}
+	svc := secondFactorService(ids, store, recorder)
+	enrolled(t, svc, now)
+	svc = user.NewServiceWith(user.ServiceDeps{
+		EmailGetter:   getter,
+		IDGetter:      ids,
+		LoginRecorder: recorder,
+		LoginEvents:   recorder,
+		LoginFailures: recorder,
+		Locker:        recorder,
+		IPFailures:    recorder,
+		Verifier:      func(_, _ string) error { return nil },
+		TOTP:          store,
+		TOTPSteps:     store,
+		UseRecovery:   store,
+	})
+
+	var err error
+	for i := range user.MaxFailedLogins {
+		if _, err := svc.Authenticate(context.Background(), attempt("a@example.com", "right"), now); err != nil {
+			t.Fatalf("cycle %d: password step: %v", i, err)
+		}
+		_, err = svc.VerifySecondFactor(context.Background(), secondFactor("not-a-code"), now)
+	}
+	if !errors.Is(err, user.ErrAccountLocked) {
+		t.Fatalf("error = %v, want ErrAccountLocked", err)
+	}
+	if want := now.Add(user.LockoutBase); !recorder.lockedUntil.Equal(want) {
+		t.Errorf("locked until %v, want %v", recorder.lockedUntil, want)
+	}
+
+	getter.user.LockedUntil = recorder.lockedUntil
+	ids.user.LockedUntil = recorder.lockedUntil
+	if _, err := svc.Authenticate(context.Background(), attempt("a@example.com", "right"), now); !errors.Is(err, user.ErrAccountLocked) {
+		t.Errorf("password step while locked: error = %v, want ErrAccountLocked", err)
+	}
+	if _, err := svc.VerifySecondFactor(context.Background(), secondFactor("not-a-code"), now); !errors.Is(err, user.ErrAccountLocked) {
+		t.Errorf("second factor while locked: error = %v, want ErrAccountLocked", err)
+	}
+}
+
+func TestDisableTwoFactorRefusedWhenRequired(t *testing.T) {
+	for name, u := range map[string]user.User{
+		"admin":             {ID: 1, Role: "admin", Active: true},
+		"pharmacy requires": {ID: 1, Role: "owner", Active: true, PharmacyRequiresTwoFactor: true},
+	} {
+		store := &mockTwoFactorStore{}
+		svc := twoFactorService(u, store)
+		codes := enrolled(t, svc, time.Now())
+
+		if err := svc.DisableTwoFactor(context.Background(), 1, codes[0], time.Now()); !errors.Is(err, user.ErrTwoFactorRequired) {
+			t.Errorf("%s: error = %v, want ErrTwoFactorRequired", name, err)
+		}
+		if store.disabled {
+			t.Errorf("%s: 2FA must stay on", name)
+		}
+	}
+}
+
+func TestDisableTwoFactorWithValidCode(t *testing.T) {
+	store := &mockTwoFactorStore{}
+	svc := twoFactorService(user.User{ID: 1, Role: "personnel", Active: true}, store)
+	codes := enrolled(t, svc, time.Now())
+
+	if err := svc.DisableTwoFactor(context.Background(), 1, codes[1], time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !store.disabled {
+		t.Error("expected DisableTwoFactor on the repository")
+	}
+}
+
+type mockSessions struct {
+	kept    string
+	revoked int
+	err     error
+}
+
+func (m *mockSessions) RevokeSession(_ context.Context, _, _ int64) error {
+	return m.err
+}
+
+func (m *mockSessions) RevokeOtherSessions(_ context.Context, _ int64, keepToken string) (int, error) {
+	m.kept = keepToken
+	return m.revoked, m.err
+}
+
+func TestRevokeSessionNotFound(t *testing.T) {
+	svc := user.NewServiceWith(user.ServiceDeps{SessionRevoker: &mockSessions{err: user.ErrSessionNotFound}})
+	if err := svc.RevokeSession(context.Background(), 1, 99); !errors.Is(err, user.ErrSessionNotFound) {
+		t.Errorf("error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestRevokeOtherSessionsKeepsCurrent(t *testing.T) {
+	m := &mockSessions{revoked: 2}
+	svc := user.NewServiceWith(user.ServiceDeps{SessionRevoker: m})
+	n, err := svc.RevokeOtherSessions(context.Background(), 1, "current-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 2 || m.kept != "current-token" {
+		t.Errorf("revoked %d keeping %q, want 2 keeping the current token", n, m.kept)
+	}
+}
+
+type mockIdentities struct {
+	user        user.User
+	found       bool
+	createErr   error
+	provisioned *user.FederatedLogin
+	roleSet     string
+	revoked     bool
+	roleErr     error
+}
+
+func (m *mockIdentities) GetByID(_ context.Context, _ int64) (user.User, string, error) {
+	return m.user, "", nil
+}
+
+func (m *mockIdentities) SetFederatedRole(_ context.Context, _, _ int64, role string) error {
+	if m.roleErr != nil {
+		return m.roleErr
+	}
+	m.roleSet = role
+	m.user.Role = role
+	m.revoked = true
+	return nil
+}
+
+func (m *mockIdentities) GetByIdentity(_ context.Context, _, _ string) (user.User, error) {
+	if !m.found {
+		return user.User{}, user.ErrNotFound
+	}
+	return m.user, nil
+}
+
+func (m *mockIdentities) CreateFederated(_ context.Context, f user.FederatedLogin) (int64, error) {
+	m.provisioned = &f
+	return m.user.ID, m.createErr
+}
+
+func federatedService(ids *mockIdentities, recorder *mockLoginRecorder) *user.Service {
+	return user.NewServiceWith(user.ServiceDeps{
+		Identities:     ids,
+		Federated:      ids,
+		FederatedRoles: ids,
+		IDGetter:       ids,
+		LoginRecorder:  recorder,
+		LoginEvents:    recorder,
+	})
+}
+
+func federatedLogin() user.FederatedLogin {
+	return user.FederatedLogin{PharmacyID: 7, Issuer: "https://idp.example.it", Subject: "abc", Email: "m.rossi@example.it", Name: "Mario Rossi", Role: "personnel"}
+}
+
+func TestSignInFederatedProvisionsNewUser(t *testing.T) {
+	ids := &mockIdentities{user: user.User{ID: 9, PharmacyID: 7, Active: true, Role: "personnel"}}
+	recorder := &mockLoginRecorder{}
+
+	u, err := federatedService(ids, recorder).SignInFederated(context.Background(), federatedLogin(), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.ID != 9 {
+		t.Errorf("user = %d, want 9", u.ID)
+	}
+	if ids.provisioned == nil || ids.provisioned.PharmacyID != 7 || ids.provisioned.Role != "personnel" {
+		t.Errorf("provisioned = %+v, want personnel of pharmacy 7", ids.provisioned)
+	}
+	if len(recorder.recorded) != 1 || recorder.recorded[0] != 9 {
+		t.Errorf("recorded logins = %v, want [9]", recorder.recorded)
+	}
+}
+
+func TestSignInFederatedReusesLinkedUser(t *testing.T) {
+	ids := &mockIdentities{found: true, user: user.User{ID: 9, PharmacyID: 7, Active: true, Role: "personnel"}}
+
+	u, err := federatedService(ids, &mockLoginRecorder{}).SignInFederated(context.Background(), federatedLogin(), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids.provisioned != nil {
+		t.Error("a linked user must not be provisioned again")
+	}
+	if u.ID != 9 || ids.revoked {
+		t.Errorf("user = %d revoked = %v, want user 9 with sessions untouched", u.ID, ids.revoked)
+	}
+}
+
+func TestSignInFederatedAppliesDemotion(t *testing.T) {
+	ids := &mockIdentities{found: true, user: user.User{ID: 9, PharmacyID: 7, Active: true, Role: "owner"}}
+	recorder := &mockLoginRecorder{}
+
+	u, err := federatedService(ids, recorder).SignInFederated(context.Background(), federatedLogin(), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids.roleSet != "personnel" || u.Role != "personnel" {
+		t.Errorf("role set = %q returned = %q, want the provider's personnel role", ids.roleSet, u.Role)
+	}
+	if !ids.revoked {
+		t.Error("sessions opened with the owner role must be revoked")
+	}
+	if len(recorder.recorded) != 1 {
+		t.Errorf("recorded logins = %v, want one", recorder.recorded)
+	}
+}
+
+func TestSignInFederatedKeepsLastOwner(t *testing.T) {
+	ids := &mockIdentities{found: true, user: user.User{ID: 9, PharmacyID: 7, Active: true, Role: "owner"}, roleErr: user.ErrLastOwner}
+	recorder := &mockLoginRecorder{}
+
+	u, err := federatedService(ids, recorder).SignInFederated(context.Background(), federatedLogin(), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u.Role != "owner" || ids.revoked {
+		t.Errorf("role = %q, revoked = %v; the last owner must keep the owner role", u.Role, ids.revoked)
+	}
+	if len(recorder.recorded) != 1 {
+		t.Errorf("recorded logins = %v, want one", recorder.recorded)
+	}
+}
+
+func TestSignInFederatedRefusals(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  *mockIdentities
+		want error
+	}{
+		{"email of a password account", &mockIdentities{createErr: user.ErrEmailTaken}, user.ErrEmailTaken},
+		{"other pharmacy", &mockIdentities{found: true, user: user.User{ID: 9, PharmacyID: 8, Active: true}}, user.ErrOtherPharmacy},
+		{"deactivated", &mockIdentities{found: true, user: user.User{ID: 9, PharmacyID: 7}}, user.ErrDeactivated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &mockLoginRecorder{}
+			_, err := federatedService(tt.ids, recorder).SignInFederated(context.Background(), federatedLogin(), time.Now())
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			if len(recorder.recorded) != 0 || len(recorder.events) != 1 || recorder.events[0].Success {
+				t.Errorf("want one failed event and no login, got events %+v", recorder.events)
+			}
+		})
+	}
+}
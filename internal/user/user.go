@@ -1,12 +1,73 @@
 package user
 
-import "errors"
+import (
+	"cmp"
+	"errors"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+)
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrNotFound           = errors.New("user not found")
+	ErrInvalidCredentials  = errors.New("user.invalid_credentials")
+	ErrNotFound            = errors.New("user.not_found")
+	ErrDeactivated         = errors.New("user.deactivated")
+	ErrInvalidResetToken   = errors.New("user.invalid_reset_token")
+	ErrPasswordRequired    = errors.New("user.password_required")
+	ErrSendFailed          = errors.New("user.send_failed")
+	ErrInvalidSecondFactor = errors.New("user.invalid_second_factor")
+	ErrTwoFactorRequired   = errors.New("user.two_factor_required")
+	ErrTwoFactorDisabled   = errors.New("user.two_factor_disabled")
+	ErrAccountLocked       = errors.New("user.account_locked")
+	ErrTooManyAttempts     = errors.New("user.too_many_attempts")
+	ErrSessionNotFound     = errors.New("user.session_not_found")
+	ErrEmailTaken          = errors.New("user.email_taken")
+	ErrOtherPharmacy       = errors.New("user.other_pharmacy")
+	ErrInvalidLocale       = errors.New("user.invalid_locale")
+	ErrFederated           = errors.New("user.federated")
+	ErrLastOwner           = errors.New("user.last_owner")
+)
+
+// Two-factor settings. The issuer is the name authenticator apps show.
+const (
+	TwoFactorIssuer   = "PharmaRecall"
+	RecoveryCodeCount = 10
+)
+
+// Password reset limits. Links are single-use and short-lived; each account
+// can request a bounded number of them per window.
+const (
+	ResetLinkLifetime  = time.Hour
+	ResetRequestWindow = 15 * time.Minute
+	MaxResetRequests   = 3
+)
+
+// Login throttling. After MaxFailedLogins consecutive wrong passwords an
+// account is locked for LockoutBase, doubling with each further failure up to
+// LockoutMax. An IP address with MaxFailedLoginsPerIP failures within
+// FailedLoginWindow cannot try again until older failures leave the window.
+const (
+	MaxFailedLogins      = 5
+	LockoutBase          = time.Minute
+	LockoutMax           = time.Hour
+	MaxFailedLoginsPerIP = 20
+	FailedLoginWindow    = 15 * time.Minute
+	LoginEventsShown     = 20
 )
 
+// LockoutDuration is how long an account stays locked after its n-th
+// consecutive failed login.
+func LockoutDuration(failures int) time.Duration {
+	if failures < MaxFailedLogins {
+		return 0
+	}
+	d := LockoutBase
+	for i := MaxFailedLogins; i < failures && d < LockoutMax; i++ {
+		d *= 2
+	}
+	return min(d, LockoutMax)
+}
+
 // User is the domain representation of a user.
 type User struct {
 	ID         int64
@@ -14,4 +75,122 @@ type User struct {
 	Name       string
 	Role       string
 	PharmacyID int64 // 0 means no pharmacy (admin users)
+	Active     bool
+	// MustChangePassword is set when an owner or admin reset the password:
+	// the user has to pick a new one before doing anything else.
+	MustChangePassword bool
+	TwoFactorEnabled   bool
+	// PharmacyRequiresTwoFactor is the pharmacy's choice to make 2FA
+	// mandatory for all its staff.
+	PharmacyRequiresTwoFactor bool
+	// LockedUntil is set after too many failed logins; zero when unlocked.
+	LockedUntil time.Time
+	// Permissions are those of the user's role, or of their pharmacy's
+	// custom role.
+	Permissions permission.Set
+	// Locale is the interface language the user chose; empty follows
+	// PharmacyLocale, the default of their pharmacy.
+	Locale         string
+	PharmacyLocale string
+	// Federated is set for accounts provisioned by a pharmacy's identity
+	// provider. They sign in there only and never hold a local password.
+	Federated bool
+}
+
+// PreferredLocale is the interface language to show the user: their own
+// choice, else their pharmacy's. Empty for admins who chose none.
+func (u User) PreferredLocale() string {
+	return cmp.Or(u.Locale, u.PharmacyLocale)
+}
+
+// Locked reports whether failed logins keep the account locked at now.
+func (u User) Locked(now time.Time) bool {
+	return now.Before(u.LockedUntil)
+}
+
+// TwoFactorRequired reports whether the user may not work without 2FA:
+// always for admins, and for staff of pharmacies that require it.
+func (u User) TwoFactorRequired() bool {
+	return u.Role == permission.RoleAdmin || u.PharmacyRequiresTwoFactor
+}
+
+// TwoFactorEnrolment is a new TOTP secret waiting for the user to confirm
+// it with a code from their authenticator app.
+type TwoFactorEnrolment struct {
+	Secret string
+	URI    string // otpauth:// URI, shown as a QR code
+}
+
+// TwoFactorStatus is what the 2FA settings page shows.
+type TwoFactorStatus struct {
+	Enabled           bool
+	Required          bool
+	RecoveryCodesLeft int
+}
+
+// LoginAttempt is what a login form submits, with where it came from.
+type LoginAttempt struct {
+	Email     string
+	Password  string
+	IP        string
+	UserAgent string
+}
+
+// LoginEvent records one login attempt. UserID is 0 when the email matched
+// no account.
+// SecondFactorAttempt is the code submitted to finish a password-verified
+// login, with where it came from.
+type SecondFactorAttempt struct {
+	UserID    int64
+	Code      string
+	IP        string
+	UserAgent string
+}
+
+type LoginEvent struct {
+	UserID    int64
+	Email     string
+	IP        string
+	UserAgent string
+	Success   bool
+	At        time.Time
+}
+
+// FederatedLogin is a sign-in vouched for by a pharmacy's identity provider.
+// Issuer and Subject identify the account there; Role is what the provider's
+// claims map to, applied at provisioning and on every later sign-in.
+type FederatedLogin struct {
+	PharmacyID int64
+	Issuer     string
+	Subject    string
+	Email      string
+	Name       string
+	Role       string
+	IP         string
+	UserAgent  string
+}
+
+// Device describes where a session was opened from.
+type Device struct {
+	IP        string
+	UserAgent string
+}
+
+// Session is one of a user's open sessions. Current marks the one making
+// the request.
+type Session struct {
+	ID         int64
+	Device     Device
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	Current    bool
+}
+
+// PasswordReset is a reset link on its way to the user's mailbox.
+type PasswordReset struct {
+	UserID    int64
+	To        string
+	Name      string
+	Link      string
+	ExpiresAt time.Time
 }
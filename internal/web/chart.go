@@ -0,0 +1,101 @@
+package web
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ChartSeries is one set of values of a bar chart, one value per label.
+type ChartSeries struct {
+	Name   string
+	Values []int
+}
+
+// ChartBar is a bar placed in the chart's SVG coordinates.
+type ChartBar struct {
+	X, Y, Width, Height float64
+	Series              int    // index into BarChart.Series, selects the fill
+	Title               string // tooltip
+}
+
+// ChartLabel is a label under the x axis.
+type ChartLabel struct {
+	X    float64
+	Text string
+}
+
+// BarChart is a grouped bar chart laid out for SVG: one group of bars per
+// label, one bar per series. It is computed server-side so pages need no
+// charting JavaScript.
+type BarChart struct {
+	Width, Height float64
+	Top, Bottom   float64 // y of the highest value and of zero
+	Left          float64 // x where the plot starts, after the y labels
+	Max           int
+	Bars          []ChartBar
+	Labels        []ChartLabel
+	Series        []ChartSeries
+}
+
+const (
+	chartWidth       = 640
+	chartHeight      = 220
+	chartPadTop      = 16
+	chartPadBottom   = 28
+	chartPadLeft     = 36
+	chartGroupMargin = 0.2 // share of a group's width left empty
+)
+
+// NewBarChart lays out the series over the labels. With labelEvery > 1 only
+// every labelEvery-th label is shown, for dense charts.
+func NewBarChart(labels []string, series []ChartSeries, labelEvery int) BarChart {
+	c := BarChart{
+		Width:  chartWidth,
+		Height: chartHeight,
+		Top:    chartPadTop,
+		Bottom: chartHeight - chartPadBottom,
+		Left:   chartPadLeft,
+		Max:    1,
+		Series: series,
+	}
+	for _, s := range series {
+		for _, v := range s.Values {
+			c.Max = max(c.Max, v)
+		}
+	}
+	if len(labels) == 0 || len(series) == 0 {
+		return c
+	}
+	labelEvery = max(labelEvery, 1)
+
+	group := (c.Width - c.Left) / float64(len(labels))
+	bar := group * (1 - chartGroupMargin) / float64(len(series))
+	scale := (c.Bottom - c.Top) / float64(c.Max)
+	for i, label := range labels {
+		x := c.Left + float64(i)*group + group*chartGroupMargin/2
+		for j, s := range series {
+			v := 0
+			if i < len(s.Values) {
+				v = s.Values[i]
+			}
+			h := float64(v) * scale
+			c.Bars = append(c.Bars, ChartBar{
+				X:      x + float64(j)*bar,
+				Y:      c.Bottom - h,
+				Width:  bar,
+				Height: h,
+				Series: j,
+				Title:  fmt.Sprintf("%s — %s: %d", label, s.Name, v),
+			})
+		}
+		if i%labelEvery == 0 {
+			c.Labels = append(c.Labels, ChartLabel{X: c.Left + (float64(i)+0.5)*group, Text: label})
+		}
+	}
+	return c
+}
+
+// svgNum formats a coordinate for an SVG attribute.
+func svgNum(f float64) string {
+	return strconv.FormatFloat(f, 'f', 1, 64)
+}
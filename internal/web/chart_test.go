@@ -0,0 +1,45 @@
+package web_test
+
+import (
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+func TestNewBarChartScalesToMax(t *testing.T) {
+	c := web.NewBarChart([]string{"a", "b"}, []web.ChartSeries{
+		{Name: "creati", Values: []int{2, 4}},
+		{Name: "evasi", Values: []int{1, 0}},
+	}, 1)
+
+	if c.Max != 4 || len(c.Bars) != 4 || len(c.Labels) != 2 {
+		t.Fatalf("max = %d, %d bars, %d labels; want 4, 4, 2", c.Max, len(c.Bars), len(c.Labels))
+	}
+	tallest := c.Bars[2] // label b, first series
+	if tallest.Y != c.Top || tallest.Height != c.Bottom-c.Top {
+		t.Errorf("tallest bar spans %v..%v, want %v..%v", tallest.Y, tallest.Y+tallest.Height, c.Top, c.Bottom)
+	}
+	if half := c.Bars[0]; half.Height != (c.Bottom-c.Top)/2 {
+		t.Errorf("half bar height = %v, want %v", half.Height, (c.Bottom-c.Top)/2)
+	}
+	if c.Bars[3].Height != 0 || c.Bars[3].Y != c.Bottom {
+		t.Errorf("zero bar = %+v, want it on the baseline", c.Bars[3])
+	}
+	if c.Bars[1].X <= c.Bars[0].X || c.Bars[1].Series != 1 {
+		t.Errorf("series bars should sit side by side: %+v, %+v", c.Bars[0], c.Bars[1])
+	}
+	if c.Bars[2].Title != "b — creati: 4" {
+		t.Errorf("title = %q", c.Bars[2].Title)
+	}
+}
+
+func TestNewBarChartEmptyAndSparseLabels(t *testing.T) {
+	c := web.NewBarChart([]string{"1", "2", "3", "4", "5"}, []web.ChartSeries{{Name: "ordini", Values: []int{0, 0, 0, 0, 0}}}, 2)
+
+	if c.Max != 1 {
+		t.Errorf("max = %d, want 1 so empty charts keep a scale", c.Max)
+	}
+	if len(c.Labels) != 3 || c.Labels[1].Text != "3" {
+		t.Errorf("labels = %+v, want 1, 3, 5", c.Labels)
+	}
+}
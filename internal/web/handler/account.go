@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// LoginEventLister lists a user's recent login attempts.
+type LoginEventLister interface {
+	LoginEvents(ctx context.Context, userID int64) ([]user.LoginEvent, error)
+}
+
+// AccountGetter retrieves the signed-in user's account.
+type AccountGetter interface {
+	Get(ctx context.Context, userID int64) (user.User, error)
+}
+
+// LocaleSetter stores the interface language a user chose.
+type LocaleSetter interface {
+	SetLocale(ctx context.Context, userID int64, locale string) (user.User, error)
+}
+
+// HandleAccountPage renders the user's profile with their language and their
+// recent logins, so they can spot attempts they did not make.
+func HandleAccountPage(accounts AccountGetter, lister LoginEventLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		msg := ""
+		if r.URL.Query().Get("saved") != "" {
+			msg = web.T(r.Context(), "account.language_saved")
+		}
+		renderAccount(w, r, accounts, lister, msg)
+	}
+}
+
+// HandleSetLocale saves the user's language and switches the session to it.
+// Choosing none follows the pharmacy's default.
+func HandleSetLocale(sessions *scs.SessionManager, setter LocaleSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		u, err := setter.SetLocale(r.Context(), web.UserID(r.Context()), r.FormValue("locale"))
+		if err != nil {
+			if errors.Is(err, user.ErrInvalidLocale) {
+				http.Error(w, web.ErrorMessage(r.Context(), err), http.StatusBadRequest)
+				return
+			}
+			slog.ErrorContext(r.Context(), "setting locale", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		sessions.Put(r.Context(), "locale", u.PreferredLocale())
+		http.Redirect(w, r, "/account?saved=1", http.StatusSeeOther)
+	}
+}
+
+func renderAccount(w http.ResponseWriter, r *http.Request, accounts AccountGetter, lister LoginEventLister, msg string) {
+	u, err := accounts.Get(r.Context(), web.UserID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "getting account", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	events, err := lister.LoginEvents(r.Context(), web.UserID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing login events", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	web.AccountPage(events, u.Locale, msg).Render(r.Context(), w)
+}
@@ -0,0 +1,139 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubLoginEventLister struct {
+	events []user.LoginEvent
+	userID int64
+}
+
+func (s *stubLoginEventLister) LoginEvents(_ context.Context, userID int64) ([]user.LoginEvent, error) {
+	s.userID = userID
+	return s.events, nil
+}
+
+type stubAccounts struct {
+	user   user.User
+	locale string
+	err    error
+}
+
+func (s *stubAccounts) Get(_ context.Context, userID int64) (user.User, error) {
+	u := s.user
+	u.ID = userID
+	return u, nil
+}
+
+func (s *stubAccounts) SetLocale(_ context.Context, userID int64, locale string) (user.User, error) {
+	if s.err != nil {
+		return user.User{}, s.err
+	}
+	s.locale = locale
+	s.user.Locale = locale
+	return s.Get(context.Background(), userID)
+}
+
+func accountTestServer(sm *scs.SessionManager, accounts *stubAccounts, lister *stubLoginEventLister) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /account", handler.HandleAccountPage(accounts, lister))
+	mux.HandleFunc("POST /account/locale", handler.HandleSetLocale(sm, accounts))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", "owner")
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		sm.Put(r.Context(), "locale", accounts.user.PreferredLocale())
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func TestAccountPageListsLoginEvents(t *testing.T) {
+	lister := &stubLoginEventLister{events: []user.LoginEvent{
+		{IP: "192.0.2.10", UserAgent: "Firefox/140", Success: true, At: time.Now()},
+		{IP: "198.51.100.7", UserAgent: "curl/8", Success: false, At: time.Now()},
+	}}
+	srv := accountTestServer(scs.New(), &stubAccounts{}, lister)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/account")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	html := string(body)
+	for _, want := range []string{"192.0.2.10", "Firefox/140", "198.51.100.7", "non riuscito"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if lister.userID != 1 {
+		t.Errorf("listed events of user %d, want 1", lister.userID)
+	}
+}
+
+func TestSetLocaleSwitchesTheInterfaceLanguage(t *testing.T) {
+	accounts := &stubAccounts{user: user.User{PharmacyLocale: "it"}}
+	srv := accountTestServer(scs.New(), accounts, &stubLoginEventLister{})
+	defer srv.Close()
+
+	// One client keeps the session across the switch and the next page.
+	jar, _ := cookiejar.New(nil)
+	client := noFollowClient()
+	client.Jar = jar
+	setup, err := client.Get(srv.URL + "/setup-session")
+	if err != nil {
+		t.Fatalf("setting up session: %v", err)
+	}
+	setup.Body.Close()
+
+	resp, err := client.PostForm(srv.URL+"/account/locale", url.Values{"locale": {"de"}})
+	if err != nil {
+		t.Fatalf("posting: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/account?saved=1" {
+		t.Fatalf("status = %d, location = %q, want 303 to /account?saved=1", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if accounts.locale != "de" {
+		t.Errorf("stored locale = %q, want de", accounts.locale)
+	}
+
+	resp, err = client.Get(srv.URL + "/account?saved=1")
+	if err != nil {
+		t.Fatalf("requesting account: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{`lang="de"`, "Mein Konto", "Sprache gespeichert."} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+}
+
+func TestSetLocaleRejectsUnsupportedLanguage(t *testing.T) {
+	accounts := &stubAccounts{err: user.ErrInvalidLocale}
+	srv := accountTestServer(scs.New(), accounts, &stubLoginEventLister{})
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/account/locale", url.Values{"locale": {"fr"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
@@ -4,25 +4,27 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 )
 
-// PharmacyLister lists all pharmacies with personnel counts.
+// PharmacyLister lists all pharmacies with their usage aggregates.
 type PharmacyLister interface {
 	List(ctx context.Context) ([]pharmacy.Summary, error)
 }
 
-// HandleAdminDashboard renders the admin dashboard with the pharmacy list.
+// HandleAdminDashboard renders the admin dashboard: platform totals and the
+// pharmacy list with per-pharmacy aggregates, inactive pharmacies highlighted.
 func HandleAdminDashboard(lister PharmacyLister) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		rows, err := lister.List(r.Context())
 		if err != nil {
-			slog.Error("listing pharmacies", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "listing pharmacies", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
-		web.AdminDashboardPage(rows).Render(r.Context(), w)
+		web.AdminDashboardPage(rows, time.Now()).Render(r.Context(), w)
 	}
 }
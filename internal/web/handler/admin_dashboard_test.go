@@ -8,8 +8,10 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
@@ -26,7 +28,7 @@ func (s *stubPharmacyLister) List(_ context.Context) ([]pharmacy.Summary, error)
 
 func adminDashboardTestServer(sm *scs.SessionManager, lister handler.PharmacyLister) *httptest.Server {
 	mux := http.NewServeMux()
-	mux.Handle("GET /admin", web.RequireAdmin(http.HandlerFunc(handler.HandleAdminDashboard(lister))))
+	mux.Handle("GET /admin", web.RequirePermission(permission.ManagePharmacies)(http.HandlerFunc(handler.HandleAdminDashboard(lister))))
 	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
 		sm.Put(r.Context(), "userID", int64(1))
 		sm.Put(r.Context(), "role", "admin")
@@ -64,6 +66,36 @@ func TestAdminDashboardRendersPharmacyList(t *testing.T) {
 	}
 }
 
+func TestAdminDashboardShowsAggregatesAndHighlightsInactive(t *testing.T) {
+	recent := time.Now().Add(-24 * time.Hour)
+	old := time.Now().AddDate(-1, 0, 0)
+	lister := &stubPharmacyLister{
+		pharmacies: []pharmacy.Summary{
+			{ID: 1, Name: "Farmacia Rossi", CreatedAt: old, LastLoginAt: &recent, ActivePatientCount: 42, PrescriptionCount: 97, OverdueOrderCount: 3, UnreadNotificationCount: 11},
+			{ID: 2, Name: "Farmacia Bianchi", CreatedAt: old},
+		},
+	}
+
+	sm := scs.New()
+	srv := adminDashboardTestServer(sm, lister)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/admin")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	bodyStr := string(body)
+
+	for _, want := range []string{"Pazienti attivi", "42", "97", "Ultimo accesso", "mai", "1 inattive"} {
+		if !strings.Contains(bodyStr, want) {
+			t.Errorf("body missing %q", want)
+		}
+	}
+	if n := strings.Count(bodyStr, "pharmacy-inactive"); n != 1 {
+		t.Errorf("inactive rows = %d, want 1", n)
+	}
+}
+
 func TestAdminDashboardEmptyShowsMessage(t *testing.T) {
 	lister := &stubPharmacyLister{pharmacies: nil}
 
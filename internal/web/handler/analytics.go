@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/analytics"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// AnalyticsReporter computes a pharmacy's reporting figures.
+type AnalyticsReporter interface {
+	Report(ctx context.Context, pharmacyID int64, now time.Time) (analytics.Report, error)
+}
+
+// HandleAnalyticsPage renders the owner's analytics page.
+func HandleAnalyticsPage(reporter AnalyticsReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report, err := reporter.Report(r.Context(), web.PharmacyID(r.Context()), time.Now())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "computing analytics", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		web.AnalyticsPage(report).Render(r.Context(), w)
+	}
+}
@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/analytics"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubAnalyticsReporter struct {
+	report     analytics.Report
+	pharmacyID int64
+	err        error
+}
+
+func (s *stubAnalyticsReporter) Report(_ context.Context, pharmacyID int64, _ time.Time) (analytics.Report, error) {
+	s.pharmacyID = pharmacyID
+	return s.report, s.err
+}
+
+func analyticsTestServer(sm *scs.SessionManager, reporter *stubAnalyticsReporter, role string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /analytics", web.RequirePermission(permission.ViewAnalytics)(http.HandlerFunc(handler.HandleAnalyticsPage(reporter))))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", role)
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func TestAnalyticsPageRendersChartsAndFigures(t *testing.T) {
+	reporter := &stubAnalyticsReporter{report: analytics.Report{
+		Since:             time.Date(2025, 11, 10, 0, 0, 0, 0, time.UTC),
+		Weeks:             []analytics.WeekCount{{Start: time.Date(2026, 1, 26, 0, 0, 0, 0, time.UTC), Created: 3, Prepared: 2, Fulfilled: 1}},
+		MedianFulfillment: 36 * time.Hour,
+		Fulfilled:         1,
+		Refills:           4,
+		LateRefills:       1,
+		TopMedications:    []analytics.MedicationCount{{Name: "Tachipirina", Orders: 3}},
+		Forecast:          []analytics.DayCount{{Date: time.Date(2026, 1, 28, 0, 0, 0, 0, time.UTC), Orders: 2}},
+	}}
+	srv := analyticsTestServer(scs.New(), reporter, "owner")
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/analytics")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	html := string(body)
+	for _, want := range []string{"<svg", "<rect", "1,5 giorni", "25%", "1 su 4", "Tachipirina", "26/01 — Creati: 3"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if strings.Contains(html, "<script src=\"https://cdn") {
+		t.Error("charts must not need a JS framework")
+	}
+	if reporter.pharmacyID != 7 {
+		t.Errorf("pharmacyID = %d, want 7", reporter.pharmacyID)
+	}
+}
+
+func TestAnalyticsPageIsOwnerOnly(t *testing.T) {
+	srv := analyticsTestServer(scs.New(), &stubAnalyticsReporter{}, "personnel")
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/analytics")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestAnalyticsPageErrorReturns500(t *testing.T) {
+	srv := analyticsTestServer(scs.New(), &stubAnalyticsReporter{err: errors.New("db down")}, "owner")
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/analytics")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+}
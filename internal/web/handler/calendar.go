@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// ClosureCalendarGetter returns a pharmacy's closure calendar.
+type ClosureCalendarGetter interface {
+	Calendar(ctx context.Context, pharmacyID int64) (calendar.Calendar, error)
+}
+
+// CalendarSettingsSaver saves the recurring closure rules.
+type CalendarSettingsSaver interface {
+	SaveSettings(ctx context.Context, pharmacyID int64, s calendar.Settings) error
+}
+
+// ClosureAdder adds an ad-hoc closure.
+type ClosureAdder interface {
+	AddClosure(ctx context.Context, pharmacyID int64, c calendar.Closure) (calendar.Closure, error)
+}
+
+// ClosureRemover deletes an ad-hoc closure.
+type ClosureRemover interface {
+	DeleteClosure(ctx context.Context, pharmacyID, id int64) error
+}
+
+// HandleCalendarPage renders the closure calendar with the closures of the coming year.
+func HandleCalendarPage(getter ClosureCalendarGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderCalendarPage(w, r, getter, nil, "")
+	}
+}
+
+// HandleSaveCalendarSettings saves the weekly closing days, national
+// holidays switch and patron-saint day.
+func HandleSaveCalendarSettings(saver CalendarSettingsSaver, getter ClosureCalendarGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		s := calendar.Settings{
+			NationalHolidays: r.FormValue("national_holidays") != "",
+			Patron:           calendar.PatronSaint{Name: r.FormValue("patron_name")},
+		}
+		for _, v := range r.Form["closed_weekday"] {
+			wd, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+				return
+			}
+			s.ClosedWeekdays = append(s.ClosedWeekdays, time.Weekday(wd))
+		}
+		month, errMonth := strconv.Atoi(r.FormValue("patron_month"))
+		day, errDay := strconv.Atoi(strings.TrimSpace(r.FormValue("patron_day")))
+		if errMonth == nil || errDay == nil {
+			// A half-filled date is left for the service to reject.
+			s.Patron.Month, s.Patron.Day = time.Month(month), day
+		}
+
+		if err := saver.SaveSettings(r.Context(), web.PharmacyID(r.Context()), s); err != nil {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				renderCalendarPage(w, r, getter, &s, msg)
+				return
+			}
+			slog.ErrorContext(r.Context(), "saving calendar settings", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/calendar", http.StatusSeeOther)
+	}
+}
+
+// HandleAddClosure adds an ad-hoc closure such as the summer holidays.
+func HandleAddClosure(adder ClosureAdder, getter ClosureCalendarGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		from, _ := time.Parse("2006-01-02", r.FormValue("from"))
+		to, _ := time.Parse("2006-01-02", r.FormValue("to"))
+		_, err := adder.AddClosure(r.Context(), web.PharmacyID(r.Context()), calendar.Closure{
+			From:   from,
+			To:     to,
+			Reason: r.FormValue("reason"),
+		})
+		if err != nil {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				renderCalendarPage(w, r, getter, nil, msg)
+				return
+			}
+			slog.ErrorContext(r.Context(), "adding closure", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/calendar", http.StatusSeeOther)
+	}
+}
+
+// HandleDeleteClosure removes an ad-hoc closure.
+func HandleDeleteClosure(remover ClosureRemover) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := remover.DeleteClosure(r.Context(), web.PharmacyID(r.Context()), id); err != nil {
+			if errors.Is(err, calendar.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			slog.ErrorContext(r.Context(), "deleting closure", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/calendar", http.StatusSeeOther)
+	}
+}
+
+// renderCalendarPage renders the calendar page; submitted settings, when
+// given, replace the saved ones so the form keeps the user's input.
+func renderCalendarPage(w http.ResponseWriter, r *http.Request, getter ClosureCalendarGetter, submitted *calendar.Settings, errMsg string) {
+	cal, err := getter.Calendar(r.Context(), web.PharmacyID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "getting closure calendar", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	if submitted != nil {
+		cal.Settings = *submitted
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	upcoming := cal.SpecialClosures(today, today.AddDate(1, 0, -1))
+	web.CalendarPage(cal, upcoming, errMsg).Render(r.Context(), w)
+}
@@ -0,0 +1,178 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/calendar"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+// --- Stubs ---
+
+type stubCalendarService struct {
+	cal       calendar.Calendar
+	saved     *calendar.Settings
+	saveErr   error
+	added     *calendar.Closure
+	addErr    error
+	deleted   int64
+	deleteErr error
+}
+
+func (s *stubCalendarService) Calendar(_ context.Context, _ int64) (calendar.Calendar, error) {
+	return s.cal, nil
+}
+
+func (s *stubCalendarService) SaveSettings(_ context.Context, _ int64, settings calendar.Settings) error {
+	s.saved = &settings
+	return s.saveErr
+}
+
+func (s *stubCalendarService) AddClosure(_ context.Context, _ int64, c calendar.Closure) (calendar.Closure, error) {
+	s.added = &c
+	return c, s.addErr
+}
+
+func (s *stubCalendarService) DeleteClosure(_ context.Context, _, id int64) error {
+	s.deleted = id
+	return s.deleteErr
+}
+
+func calendarTestServer(sm *scs.SessionManager, svc *stubCalendarService) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /calendar", web.RequirePermission(permission.ManageSchedule)(http.HandlerFunc(handler.HandleCalendarPage(svc))))
+	mux.Handle("POST /calendar", web.RequirePermission(permission.ManageSchedule)(http.HandlerFunc(handler.HandleSaveCalendarSettings(svc, svc))))
+	mux.Handle("POST /calendar/closures", web.RequirePermission(permission.ManageSchedule)(http.HandlerFunc(handler.HandleAddClosure(svc, svc))))
+	mux.Handle("POST /calendar/closures/{id}/delete", web.RequirePermission(permission.ManageSchedule)(http.HandlerFunc(handler.HandleDeleteClosure(svc))))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", "owner")
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func TestCalendarPageShowsClosures(t *testing.T) {
+	svc := &stubCalendarService{cal: calendar.Calendar{
+		Settings: calendar.DefaultSettings(),
+		Closures: []calendar.Closure{{ID: 4, From: time.Date(2026, 8, 10, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 8, 23, 0, 0, 0, 0, time.UTC), Reason: "Ferie estive"}},
+	}}
+	srv := calendarTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/calendar")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{"10/08/2026 – 23/08/2026", "Ferie estive", "/calendar/closures/4/delete", `value="0" checked`, "Natale"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("calendar page missing %q", want)
+		}
+	}
+}
+
+func TestSaveCalendarSettingsParsesForm(t *testing.T) {
+	svc := &stubCalendarService{}
+	srv := calendarTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/calendar", url.Values{
+		"closed_weekday":    {"0", "6"},
+		"national_holidays": {"1"},
+		"patron_name":       {"Sant'Ambrogio"},
+		"patron_day":        {"7"},
+		"patron_month":      {"12"},
+	})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("status = %d, want 303", resp.StatusCode)
+	}
+	got := svc.saved
+	if got == nil {
+		t.Fatal("expected settings to be saved")
+	}
+	if len(got.ClosedWeekdays) != 2 || !got.NationalHolidays {
+		t.Errorf("settings = %+v", got)
+	}
+	if got.Patron != (calendar.PatronSaint{Name: "Sant'Ambrogio", Month: time.December, Day: 7}) {
+		t.Errorf("patron = %+v", got.Patron)
+	}
+}
+
+func TestSaveCalendarSettingsShowsValidationError(t *testing.T) {
+	svc := &stubCalendarService{saveErr: calendar.ErrInvalidPatronDate}
+	srv := calendarTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/calendar", url.Values{"patron_name": {"San Giovanni"}, "patron_day": {"31"}, "patron_month": {"6"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "santo patrono") || !strings.Contains(string(body), `value="San Giovanni"`) {
+		t.Error("expected error message with the submitted values kept")
+	}
+}
+
+func TestAddClosure(t *testing.T) {
+	svc := &stubCalendarService{}
+	srv := calendarTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/calendar/closures", url.Values{"from": {"2026-08-10"}, "to": {"2026-08-23"}, "reason": {"Ferie"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("status = %d, want 303", resp.StatusCode)
+	}
+	if svc.added == nil || !svc.added.From.Equal(time.Date(2026, 8, 10, 0, 0, 0, 0, time.UTC)) || svc.added.Reason != "Ferie" {
+		t.Errorf("added = %+v", svc.added)
+	}
+}
+
+func TestAddClosureInvalidShowsError(t *testing.T) {
+	svc := &stubCalendarService{addErr: calendar.ErrInvalidClosure}
+	srv := calendarTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/calendar/closures", url.Values{"from": {"2026-08-23"}, "to": {"2026-08-10"}})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "data di fine") {
+		t.Errorf("status = %d, expected validation message", resp.StatusCode)
+	}
+}
+
+func TestDeleteClosureNotFound(t *testing.T) {
+	svc := &stubCalendarService{deleteErr: calendar.ErrNotFound}
+	srv := calendarTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/calendar/closures/9/delete", url.Values{})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", resp.StatusCode)
+	}
+	if svc.deleted != 9 {
+		t.Errorf("deleted = %d, want 9", svc.deleted)
+	}
+}
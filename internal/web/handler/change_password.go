@@ -24,10 +24,11 @@ func HandleChangePasswordPage() http.HandlerFunc {
 }
 
 // HandleChangePasswordPost verifies the current password and updates to the new one.
+// After a forced change the user goes on to their landing page.
 func HandleChangePasswordPost(sessions *scs.SessionManager, changer PasswordChanger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := r.ParseForm(); err != nil {
-			web.ChangePasswordPage("Richiesta non valida.", "").Render(r.Context(), w)
+			web.ChangePasswordPage(web.T(r.Context(), "common.bad_request"), "").Render(r.Context(), w)
 			return
 		}
 
@@ -36,14 +37,20 @@ func HandleChangePasswordPost(sessions *scs.SessionManager, changer PasswordChan
 		err := changer.ChangePassword(r.Context(), userID, r.FormValue("current_password"), r.FormValue("new_password"))
 		if err != nil {
 			if errors.Is(err, user.ErrInvalidCredentials) {
-				web.ChangePasswordPage("Password attuale non corretta.", "").Render(r.Context(), w)
+				web.ChangePasswordPage(web.T(r.Context(), "password.current_wrong"), "").Render(r.Context(), w)
 				return
 			}
-			slog.Error("changing password", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "changing password", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
-		web.ChangePasswordPage("", "Password aggiornata.").Render(r.Context(), w)
+		if web.MustChangePassword(r.Context()) {
+			sessions.Remove(r.Context(), "mustChangePassword")
+			http.Redirect(w, r, web.Home(web.Permissions(r.Context())), http.StatusSeeOther)
+			return
+		}
+
+		web.ChangePasswordPage("", web.T(r.Context(), "password.changed")).Render(r.Context(), w)
 	}
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
 	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
 )
 
@@ -90,3 +91,41 @@ func TestChangePasswordPostWrongCurrentPassword(t *testing.T) {
 		t.Errorf("status = %d, want 200 (re-render with error)", resp.StatusCode)
 	}
 }
+
+func TestChangePasswordPostAfterResetGoesToLandingPage(t *testing.T) {
+	sm := scs.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /change-password", handler.HandleChangePasswordPost(sm, &stubPasswordChanger{}))
+	mux.HandleFunc("GET /dashboard", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", "personnel")
+		sm.Put(r.Context(), "mustChangePassword", true)
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(web.RequirePasswordChanged(mux))))
+	defer srv.Close()
+
+	form := url.Values{"current_password": {"temporanea"}, "new_password": {"new-password"}}
+	resp := authenticatedPost(t, srv, "/change-password", form)
+	defer resp.Body.Close()
+
+	if loc := resp.Header.Get("Location"); resp.StatusCode != http.StatusSeeOther || loc != "/dashboard" {
+		t.Fatalf("status = %d location = %q, want 303 to /dashboard", resp.StatusCode, loc)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/dashboard", nil)
+	for _, c := range resp.Cookies() {
+		req.AddCookie(c)
+	}
+	resp, err := noFollowClient().Do(req)
+	if err != nil {
+		t.Fatalf("requesting dashboard: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("dashboard status = %d, want 200 once the password is changed", resp.StatusCode)
+	}
+}
@@ -1,14 +1,17 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 )
 
@@ -27,6 +30,11 @@ type OrderStatusAdvancer interface {
 	AdvanceStatus(ctx context.Context, orderID int64, now time.Time) error
 }
 
+// OrderReferenceSigner produces the signed reference printed as a barcode on labels.
+type OrderReferenceSigner interface {
+	Sign(orderID int64) string
+}
+
 // DashboardFilters holds parsed filter parameters.
 type DashboardFilters struct {
 	PrescriptionStatus string
@@ -35,21 +43,23 @@ type DashboardFilters struct {
 	DateTo             string
 }
 
-// HandleDashboard renders the order dashboard for pharmacy staff.
-func HandleDashboard(ensurer OrderEnsurer, lister DashboardLister, notifier ApproachingNotifier, lookaheadDays int) http.HandlerFunc {
+// HandleDashboard renders the order dashboard for pharmacy staff. Orders are
+// created with the pharmacy's lookahead, or defaultDays when it has none.
+func HandleDashboard(ensurer OrderEnsurer, lister DashboardLister, notifier ApproachingNotifier, lookahead LookaheadGetter, defaultDays int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		pharmacyID := web.PharmacyID(r.Context())
 		now := time.Now()
 
 		// Ensure orders are created for prescriptions in the window.
-		if err := ensurer.EnsureOrders(r.Context(), pharmacyID, now, lookaheadDays); err != nil {
-			slog.Error("ensuring orders", "error", err)
+		days := lookaheadDays(r.Context(), lookahead, pharmacyID, defaultDays)
+		if err := ensurer.EnsureOrders(r.Context(), pharmacyID, now, days); err != nil {
+			slog.ErrorContext(r.Context(), "ensuring orders", "error", err)
 		}
 
 		entries, err := lister.ListDashboard(r.Context(), pharmacyID)
 		if err != nil {
-			slog.Error("listing dashboard", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "listing dashboard", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -62,7 +72,7 @@ func HandleDashboard(ensurer OrderEnsurer, lister DashboardLister, notifier Appr
 		}
 		if len(approachingIDs) > 0 {
 			if err := notifier.GenerateApproaching(r.Context(), pharmacyID, approachingIDs); err != nil {
-				slog.Error("generating notifications", "error", err)
+				slog.ErrorContext(r.Context(), "generating notifications", "error", err)
 			}
 		}
 
@@ -94,11 +104,15 @@ func HandleAdvanceOrderStatus(advancer OrderStatusAdvancer) http.HandlerFunc {
 				return
 			}
 			if errors.Is(err, order.ErrInvalidTransition) {
-				http.Error(w, "Transizione di stato non valida.", http.StatusBadRequest)
+				http.Error(w, web.ErrorMessage(r.Context(), err), http.StatusBadRequest)
 				return
 			}
-			slog.Error("advancing order status", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			if errors.Is(err, permission.ErrForbidden) {
+				http.Error(w, web.T(r.Context(), "common.forbidden"), http.StatusForbidden)
+				return
+			}
+			slog.ErrorContext(r.Context(), "advancing order status", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -111,7 +125,8 @@ func HandleAdvanceOrderStatus(advancer OrderStatusAdvancer) http.HandlerFunc {
 	}
 }
 
-// HandlePrintDashboard renders a print-friendly version of the order dashboard.
+// HandlePrintDashboard renders a print-friendly version of the order dashboard,
+// or a PDF with ?format=pdf.
 func HandlePrintDashboard(lister DashboardLister) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		pharmacyID := web.PharmacyID(r.Context())
@@ -119,8 +134,8 @@ func HandlePrintDashboard(lister DashboardLister) http.HandlerFunc {
 
 		entries, err := lister.ListDashboard(r.Context(), pharmacyID)
 		if err != nil {
-			slog.Error("listing dashboard for print", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "listing dashboard for print", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -134,12 +149,32 @@ func HandlePrintDashboard(lister DashboardLister) http.HandlerFunc {
 		filtered := applyDashboardFilters(entries, filters, now)
 
 		pharmacyName := web.PharmacyName(r.Context())
+
+		if wantsPDF(r) {
+			var buf bytes.Buffer
+			if err := web.PrintDashboardPDF(&buf, filtered, now, pharmacyName); err != nil {
+				slog.ErrorContext(r.Context(), "rendering dashboard pdf", "error", err)
+				http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+				return
+			}
+			writePDF(w, buf.Bytes(), "ordini.pdf")
+			return
+		}
+
 		web.PrintDashboardPage(filtered, now, pharmacyName).Render(r.Context(), w)
 	}
 }
 
-// HandlePrintLabel renders a print-friendly label for a single order.
-func HandlePrintLabel(lister DashboardLister) http.HandlerFunc {
+// LabelPharmacyGetter loads what labels take from the pharmacy: its label
+// layout and logo.
+type LabelPharmacyGetter interface {
+	PharmacyGetter
+	LogoGetter
+}
+
+// HandlePrintLabel renders a print-friendly label for a single order,
+// or a PDF on the pharmacy's label layout with ?format=pdf.
+func HandlePrintLabel(lister DashboardLister, signer OrderReferenceSigner, pharmacies LabelPharmacyGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		orderID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 		if err != nil {
@@ -151,8 +186,8 @@ func HandlePrintLabel(lister DashboardLister) http.HandlerFunc {
 
 		entries, err := lister.ListDashboard(r.Context(), pharmacyID)
 		if err != nil {
-			slog.Error("listing dashboard for label", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "listing dashboard for label", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -168,20 +203,24 @@ func HandlePrintLabel(lister DashboardLister) http.HandlerFunc {
 			return
 		}
 
-		web.PrintLabelsPage([]order.DashboardEntry{*found}).Render(r.Context(), w)
+		labels := []order.DashboardEntry{*found}
+		refs := signReferences(labels, signer)
+
+		renderLabels(w, r, labels, refs, pharmacies, fmt.Sprintf("etichetta-%d.pdf", orderID))
 	}
 }
 
-// HandlePrintBatchLabels renders print-friendly labels for all filtered orders.
-func HandlePrintBatchLabels(lister DashboardLister) http.HandlerFunc {
+// HandlePrintBatchLabels renders print-friendly labels for all filtered orders,
+// or a PDF on the pharmacy's label layout with ?format=pdf.
+func HandlePrintBatchLabels(lister DashboardLister, signer OrderReferenceSigner, pharmacies LabelPharmacyGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		pharmacyID := web.PharmacyID(r.Context())
 		now := time.Now()
 
 		entries, err := lister.ListDashboard(r.Context(), pharmacyID)
 		if err != nil {
-			slog.Error("listing dashboard for batch labels", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "listing dashboard for batch labels", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -194,8 +233,65 @@ func HandlePrintBatchLabels(lister DashboardLister) http.HandlerFunc {
 
 		filtered := applyDashboardFilters(entries, filters, now)
 
-		web.PrintLabelsPage(filtered).Render(r.Context(), w)
+		refs := signReferences(filtered, signer)
+
+		renderLabels(w, r, filtered, refs, pharmacies, "etichette.pdf")
+	}
+}
+
+// wantsPDF reports whether a print route was requested with ?format=pdf.
+func wantsPDF(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "pdf"
+}
+
+// renderLabels renders labels as a page, or with ?format=pdf on the label
+// layout configured for the session's pharmacy, with its logo if it has one.
+func renderLabels(w http.ResponseWriter, r *http.Request, entries []order.DashboardEntry, refs map[int64]string, pharmacies LabelPharmacyGetter, filename string) {
+	ph, err := pharmacies.Get(r.Context(), web.PharmacyID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "getting pharmacy for labels", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+
+	if !wantsPDF(r) {
+		web.PrintLabelsPage(entries, refs, ph.HasLogo).Render(r.Context(), w)
+		return
+	}
+
+	var logo []byte
+	if ph.HasLogo {
+		if logo, err = pharmacies.Logo(r.Context(), ph.ID); err != nil {
+			slog.ErrorContext(r.Context(), "getting pharmacy logo", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := web.PrintLabelsPDF(&buf, entries, refs, ph.LabelLayout, logo); err != nil {
+		slog.ErrorContext(r.Context(), "rendering labels pdf", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	writePDF(w, buf.Bytes(), filename)
+}
+
+// writePDF sends a rendered PDF inline so the browser opens its viewer.
+func writePDF(w http.ResponseWriter, data []byte, filename string) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+// signReferences maps each entry's order ID to its signed barcode reference.
+func signReferences(entries []order.DashboardEntry, signer OrderReferenceSigner) map[int64]string {
+	refs := make(map[int64]string, len(entries))
+	for _, e := range entries {
+		refs[e.OrderID] = signer.Sign(e.OrderID)
 	}
+	return refs
 }
 
 func applyDashboardFilters(entries []order.DashboardEntry, filters DashboardFilters, now time.Time) []order.DashboardEntry {
@@ -205,8 +301,9 @@ func applyDashboardFilters(entries []order.DashboardEntry, filters DashboardFilt
 	dateTo, _ := time.Parse("2006-01-02", filters.DateTo)
 
 	for _, e := range entries {
+		// Like the date filters, the status filter accounts for closures.
 		if filters.PrescriptionStatus != "" && filters.PrescriptionStatus != "all" {
-			if e.PrescriptionStatus(now) != filters.PrescriptionStatus {
+			if e.PrepareStatus(now) != filters.PrescriptionStatus {
 				continue
 			}
 		}
@@ -226,16 +323,17 @@ func applyDashboardFilters(entries []order.DashboardEntry, filters DashboardFilt
 			}
 		}
 
+		// Date filters apply to the prepare-by date, which accounts for closures.
 		if !dateFrom.IsZero() {
-			depletion := e.EstimatedDepletionDate.Truncate(24 * time.Hour)
-			if depletion.Before(dateFrom) {
+			prepareBy := e.PrepareBy.Truncate(24 * time.Hour)
+			if prepareBy.Before(dateFrom) {
 				continue
 			}
 		}
 
 		if !dateTo.IsZero() {
-			depletion := e.EstimatedDepletionDate.Truncate(24 * time.Hour)
-			if depletion.After(dateTo) {
+			prepareBy := e.PrepareBy.Truncate(24 * time.Hour)
+			if prepareBy.After(dateTo) {
 				continue
 			}
 		}
@@ -1,6 +1,7 @@
 package handler_test
 
 import (
+	"cmp"
 	"context"
 	"io"
 	"net/http"
@@ -11,7 +12,10 @@ import (
 	"time"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
 	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
 )
@@ -21,12 +25,14 @@ import (
 type stubOrderEnsurer struct {
 	called     bool
 	pharmacyID int64
+	lookahead  int
 	err        error
 }
 
-func (s *stubOrderEnsurer) EnsureOrders(_ context.Context, pharmacyID int64, _ time.Time, _ int) error {
+func (s *stubOrderEnsurer) EnsureOrders(_ context.Context, pharmacyID int64, _ time.Time, lookaheadDays int) error {
 	s.called = true
 	s.pharmacyID = pharmacyID
+	s.lookahead = lookaheadDays
 	return s.err
 }
 
@@ -75,6 +81,8 @@ type dashTestDeps struct {
 	lister   handler.DashboardLister
 	notifier handler.ApproachingNotifier
 	advancer handler.OrderStatusAdvancer
+	settings *stubPharmacySettings
+	role     string
 }
 
 func dashTestServer(d dashTestDeps) *httptest.Server {
@@ -84,17 +92,20 @@ func dashTestServer(d dashTestDeps) *httptest.Server {
 		if notifier == nil {
 			notifier = &stubApproachingNotifier{}
 		}
-		mux.Handle("GET /dashboard", web.RequirePharmacyStaff(http.HandlerFunc(handler.HandleDashboard(d.ensurer, d.lister, notifier, 7))))
-		mux.Handle("GET /dashboard/print", web.RequirePharmacyStaff(http.HandlerFunc(handler.HandlePrintDashboard(d.lister))))
-		mux.Handle("GET /dashboard/labels", web.RequirePharmacyStaff(http.HandlerFunc(handler.HandlePrintBatchLabels(d.lister))))
-		mux.Handle("GET /orders/{id}/label", web.RequirePharmacyStaff(http.HandlerFunc(handler.HandlePrintLabel(d.lister))))
+		settings := cmp.Or(d.settings, &stubPharmacySettings{})
+		mux.Handle("GET /dashboard", web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(handler.HandleDashboard(d.ensurer, d.lister, notifier, settings, 7))))
+		mux.Handle("GET /dashboard/print", web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(handler.HandlePrintDashboard(d.lister))))
+		signer := order.NewReferenceSigner("test-secret")
+		pharmacies := &stubPharmacyGetter{pharmacy: pharmacy.Pharmacy{ID: 7, LabelLayout: pharmacy.LabelLayoutA4x14}}
+		mux.Handle("GET /dashboard/labels", web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(handler.HandlePrintBatchLabels(d.lister, signer, pharmacies))))
+		mux.Handle("GET /orders/{id}/label", web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(handler.HandlePrintLabel(d.lister, signer, pharmacies))))
 	}
 	if d.advancer != nil {
-		mux.Handle("POST /orders/{id}/advance", web.RequirePharmacyStaff(http.HandlerFunc(handler.HandleAdvanceOrderStatus(d.advancer))))
+		mux.Handle("POST /orders/{id}/advance", web.RequirePermission(permission.AdvanceOrders)(http.HandlerFunc(handler.HandleAdvanceOrderStatus(d.advancer))))
 	}
 	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
 		d.sm.Put(r.Context(), "userID", int64(1))
-		d.sm.Put(r.Context(), "role", "personnel")
+		d.sm.Put(r.Context(), "role", cmp.Or(d.role, "personnel"))
 		d.sm.Put(r.Context(), "pharmacyID", int64(7))
 		w.WriteHeader(http.StatusOK)
 	})
@@ -145,6 +156,51 @@ func TestDashboardRendersEntries(t *testing.T) {
 	if ensurer.pharmacyID != 7 {
 		t.Errorf("pharmacyID = %d, want 7", ensurer.pharmacyID)
 	}
+	if ensurer.lookahead != 7 {
+		t.Errorf("lookahead = %d, want the server default 7", ensurer.lookahead)
+	}
+}
+
+func TestDashboardUsesPharmacyLookahead(t *testing.T) {
+	ensurer := &stubOrderEnsurer{}
+	sm := scs.New()
+	srv := dashTestServer(dashTestDeps{sm: sm, ensurer: ensurer, lister: &stubDashboardLister{}, settings: &stubPharmacySettings{
+		settings: pharmacy.Settings{LookaheadDays: 21},
+	}})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/dashboard")
+	resp.Body.Close()
+
+	if ensurer.lookahead != 21 {
+		t.Errorf("lookahead = %d, want 21", ensurer.lookahead)
+	}
+}
+
+func TestDashboardTraineeCannotAdvance(t *testing.T) {
+	lister := &stubDashboardLister{result: []order.DashboardEntry{
+		{OrderID: 1, OrderStatus: order.StatusPending, MedicationName: "Tachipirina", FirstName: "Mario", LastName: "Rossi", Fulfillment: "pickup"},
+	}}
+	advancer := &stubOrderAdvancer{}
+
+	srv := dashTestServer(dashTestDeps{sm: scs.New(), ensurer: &stubOrderEnsurer{}, lister: lister, advancer: advancer, role: "trainee"})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/dashboard")
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Tachipirina") {
+		t.Error("trainees should see the orders")
+	}
+	if strings.Contains(string(body), "/orders/1/advance") {
+		t.Error("trainees must not be offered the advance button")
+	}
+
+	resp = authenticatedPost(t, srv, "/orders/1/advance", url.Values{})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || advancer.called {
+		t.Errorf("status = %d, want 403 without advancing", resp.StatusCode)
+	}
 }
 
 func TestDashboardEmptyShowsMessage(t *testing.T) {
@@ -173,27 +229,79 @@ func TestDashboardEmptyShowsMessage(t *testing.T) {
 func TestDashboardFiltersByPrescriptionStatus(t *testing.T) {
 	ensurer := &stubOrderEnsurer{}
 	lister := &stubDashboardLister{result: []order.DashboardEntry{
-		{OrderID: 1, MedicationName: "Tachipirina", EstimatedDepletionDate: time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC), OrderStatus: order.StatusPending, FirstName: "Mario", LastName: "Rossi"},
-		{OrderID: 2, MedicationName: "Aspirina", EstimatedDepletionDate: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), OrderStatus: order.StatusPending, FirstName: "Luca", LastName: "Bianchi"},
+		{OrderID: 1, MedicationName: "Tachipirina", EstimatedDepletionDate: time.Now().AddDate(0, 0, -2), OrderStatus: order.StatusPending, FirstName: "Mario", LastName: "Rossi"},
+		{OrderID: 2, MedicationName: "Aspirina", EstimatedDepletionDate: time.Now().AddDate(0, 0, 60), OrderStatus: order.StatusPending, FirstName: "Luca", LastName: "Bianchi"},
 	}}
 
 	sm := scs.New()
 	srv := dashTestServer(dashTestDeps{sm: sm, ensurer: ensurer, lister: lister})
 	defer srv.Close()
 
-	// Filter to "approaching" only — Aspirina (May 1) should be "ok", Tachipirina (Feb 20) should be "approaching" or "depleted"
+	// Filter to "ok" only — Aspirina (60 days out) is "ok", Tachipirina (2 days ago) is "depleted"
 	resp := authenticatedGet(t, srv, "/dashboard?rx_status=ok")
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
 	bodyStr := string(body)
 
-	// Aspirina has depletion May 1 → far in the future → "ok" → should appear
+	// Aspirina depletes in 60 days → "ok" → should appear
 	if !strings.Contains(bodyStr, "Aspirina") {
 		t.Error("body should contain Aspirina (status ok)")
 	}
 }
 
+func TestDashboardDateFiltersUsePrepareBy(t *testing.T) {
+	ensurer := &stubOrderEnsurer{}
+	lister := &stubDashboardLister{result: []order.DashboardEntry{
+		// Depletes on Sunday 16 August but the pharmacy is closed: ready by Friday 14.
+		{OrderID: 1, MedicationName: "Tachipirina", EstimatedDepletionDate: time.Date(2026, 8, 16, 0, 0, 0, 0, time.UTC), PrepareBy: time.Date(2026, 8, 14, 0, 0, 0, 0, time.UTC), OrderStatus: order.StatusPending},
+		{OrderID: 2, MedicationName: "Aspirina", EstimatedDepletionDate: time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC), PrepareBy: time.Date(2026, 8, 17, 0, 0, 0, 0, time.UTC), OrderStatus: order.StatusPending},
+	}}
+
+	srv := dashTestServer(dashTestDeps{sm: scs.New(), ensurer: ensurer, lister: lister})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/dashboard?date_to=2026-08-15")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	bodyStr := string(body)
+	if !strings.Contains(bodyStr, "Tachipirina") {
+		t.Error("order to prepare by 14/08 should match date_to=2026-08-15")
+	}
+	if strings.Contains(bodyStr, "Aspirina") {
+		t.Error("order to prepare by 17/08 should be filtered out")
+	}
+	if !strings.Contains(bodyStr, "14/08/2026") {
+		t.Error("expected prepare-by date to be shown")
+	}
+}
+
+func TestDashboardStatusFilterUsesPrepareBy(t *testing.T) {
+	today := time.Now().Truncate(24 * time.Hour)
+	lister := &stubDashboardLister{result: []order.DashboardEntry{
+		// Runs out in 9 days, but the pharmacy closes the two days before:
+		// it must be ready in 6, so it is already approaching.
+		{OrderID: 1, MedicationName: "Tachipirina", EstimatedDepletionDate: today.AddDate(0, 0, 9), PrepareBy: today.AddDate(0, 0, 6), OrderStatus: order.StatusPending},
+		{OrderID: 2, MedicationName: "Aspirina", EstimatedDepletionDate: today.AddDate(0, 0, 9), PrepareBy: today.AddDate(0, 0, 9), OrderStatus: order.StatusPending},
+	}}
+
+	srv := dashTestServer(dashTestDeps{sm: scs.New(), ensurer: &stubOrderEnsurer{}, lister: lister})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/dashboard?rx_status=approaching")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	bodyStr := string(body)
+	if !strings.Contains(bodyStr, "Tachipirina") {
+		t.Error("order due before a closure should be approaching")
+	}
+	if strings.Contains(bodyStr, "Aspirina") {
+		t.Error("order due in 9 days should be filtered out")
+	}
+}
+
 func TestDashboardFiltersByOrderStatus(t *testing.T) {
 	ensurer := &stubOrderEnsurer{}
 	lister := &stubDashboardLister{result: []order.DashboardEntry{
@@ -441,12 +549,15 @@ func TestPrintDashboardRespectsFilters(t *testing.T) {
 func TestPrintSingleLabelShippingIncludesAddress(t *testing.T) {
 	lister := &stubDashboardLister{result: []order.DashboardEntry{
 		{
-			OrderID:                5,
-			MedicationName:         "Tachipirina",
-			FirstName:              "Mario",
-			LastName:               "Rossi",
-			Fulfillment:            "shipping",
-			DeliveryAddress:        "Via Roma 1, Milano",
+			OrderID:        5,
+			MedicationName: "Tachipirina",
+			FirstName:      "Mario",
+			LastName:       "Rossi",
+			Fulfillment:    "shipping",
+			DeliveryAddress: address.Address{
+				Street: "Via Roma", HouseNumber: "1", CAP: "20121", City: "Milano", Province: "MI", Country: "IT",
+				Notes: "citofono Rossi",
+			},
 			Phone:                  "3331234567",
 			EstimatedDepletionDate: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC),
 			OrderStatus:            order.StatusPending,
@@ -467,7 +578,7 @@ func TestPrintSingleLabelShippingIncludesAddress(t *testing.T) {
 	body, _ := io.ReadAll(resp.Body)
 	bodyStr := string(body)
 
-	for _, want := range []string{"Mario", "Rossi", "Tachipirina", "Via Roma 1, Milano", "Spedizione"} {
+	for _, want := range []string{"Mario", "Rossi", "Tachipirina", "Via Roma 1", "20121 Milano (MI)", "citofono Rossi", "Spedizione"} {
 		if !strings.Contains(bodyStr, want) {
 			t.Errorf("body missing %q", want)
 		}
@@ -478,6 +589,30 @@ func TestPrintSingleLabelShippingIncludesAddress(t *testing.T) {
 	}
 }
 
+func TestPrintSingleLabelIncludesSignedBarcode(t *testing.T) {
+	lister := &stubDashboardLister{result: []order.DashboardEntry{
+		{OrderID: 5, MedicationName: "Tachipirina", FirstName: "Mario", LastName: "Rossi", Fulfillment: "pickup", OrderStatus: order.StatusPending},
+	}}
+
+	sm := scs.New()
+	srv := dashTestServer(dashTestDeps{sm: sm, ensurer: &stubOrderEnsurer{}, lister: lister})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/orders/5/label")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	bodyStr := string(body)
+
+	ref := order.NewReferenceSigner("test-secret").Sign(5)
+	if !strings.Contains(bodyStr, ref) {
+		t.Errorf("label missing signed reference %q", ref)
+	}
+	if !strings.Contains(bodyStr, "<svg") {
+		t.Error("label missing barcode svg")
+	}
+}
+
 func TestPrintSingleLabelPickupIncludesContact(t *testing.T) {
 	lister := &stubDashboardLister{result: []order.DashboardEntry{
 		{
@@ -534,7 +669,7 @@ func TestPrintSingleLabelNotFoundReturns404(t *testing.T) {
 func TestPrintBatchLabelsRendersAllFilteredEntries(t *testing.T) {
 	lister := &stubDashboardLister{result: []order.DashboardEntry{
 		{OrderID: 1, MedicationName: "Tachipirina", FirstName: "Mario", LastName: "Rossi", Fulfillment: "pickup", Phone: "333111", EstimatedDepletionDate: time.Date(2026, 2, 25, 0, 0, 0, 0, time.UTC), OrderStatus: order.StatusPending},
-		{OrderID: 2, MedicationName: "Aspirina", FirstName: "Luca", LastName: "Bianchi", Fulfillment: "shipping", DeliveryAddress: "Via Dante 5", EstimatedDepletionDate: time.Date(2026, 2, 25, 0, 0, 0, 0, time.UTC), OrderStatus: order.StatusPrepared},
+		{OrderID: 2, MedicationName: "Aspirina", FirstName: "Luca", LastName: "Bianchi", Fulfillment: "shipping", DeliveryAddress: address.Address{Street: "Via Dante", HouseNumber: "5"}, EstimatedDepletionDate: time.Date(2026, 2, 25, 0, 0, 0, 0, time.UTC), OrderStatus: order.StatusPrepared},
 	}}
 
 	sm := scs.New()
@@ -585,3 +720,72 @@ func TestPrintBatchLabelsRespectsFilters(t *testing.T) {
 		t.Error("batch labels should not contain Tachipirina (pending, filtered out)")
 	}
 }
+
+// --- PDF print tests ---
+
+func TestPrintDashboardPDF(t *testing.T) {
+	lister := &stubDashboardLister{result: []order.DashboardEntry{
+		{OrderID: 1, MedicationName: "Tachipirina", FirstName: "Mario", LastName: "Rossi", Fulfillment: "pickup", OrderStatus: order.StatusPending},
+	}}
+
+	sm := scs.New()
+	srv := dashTestServer(dashTestDeps{sm: sm, ensurer: &stubOrderEnsurer{}, lister: lister})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/dashboard/print?format=pdf")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("content-type = %q, want application/pdf", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.HasPrefix(string(body), "%PDF-") {
+		t.Error("body is not a PDF")
+	}
+}
+
+func TestPrintBatchLabelsPDF(t *testing.T) {
+	lister := &stubDashboardLister{result: []order.DashboardEntry{
+		{OrderID: 1, MedicationName: "Tachipirina", FirstName: "Mario", LastName: "Rossi", Fulfillment: "pickup", OrderStatus: order.StatusPending},
+		{OrderID: 2, MedicationName: "Aspirina", FirstName: "Luca", LastName: "Bianchi", Fulfillment: "shipping", DeliveryAddress: address.Address{Street: "Via Roma", HouseNumber: "1"}, OrderStatus: order.StatusPrepared},
+	}}
+
+	sm := scs.New()
+	srv := dashTestServer(dashTestDeps{sm: sm, ensurer: &stubOrderEnsurer{}, lister: lister})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/dashboard/labels?format=pdf")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("content-type = %q, want application/pdf", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	bodyStr := string(body)
+	if !strings.HasPrefix(bodyStr, "%PDF-") {
+		t.Error("body is not a PDF")
+	}
+	// Both labels fit on one A4 sheet.
+	if !strings.Contains(bodyStr, "/Count 1") {
+		t.Error("expected a single page for two labels on A4")
+	}
+}
+
+func TestPrintSingleLabelPDFNotFoundReturns404(t *testing.T) {
+	sm := scs.New()
+	srv := dashTestServer(dashTestDeps{sm: sm, ensurer: &stubOrderEnsurer{}, lister: &stubDashboardLister{}})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/orders/999/label?format=pdf")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
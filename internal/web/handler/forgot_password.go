@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// PasswordResetRequester emails a password reset link.
+type PasswordResetRequester interface {
+	RequestPasswordReset(ctx context.Context, email, baseURL string, now time.Time) error
+}
+
+// PasswordResetter sets a new password with the token of a reset link.
+type PasswordResetter interface {
+	ResetPassword(ctx context.Context, token, newPassword string, now time.Time) error
+}
+
+// HandleForgotPasswordPage renders the form asking for the account's email.
+func HandleForgotPasswordPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		web.ForgotPasswordPage(false, "").Render(r.Context(), w)
+	}
+}
+
+// HandleForgotPasswordPost sends a reset link. The answer is the same
+// whether or not the email belongs to an account.
+func HandleForgotPasswordPost(requester PasswordResetRequester, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			web.ForgotPasswordPage(false, web.T(r.Context(), "common.bad_request")).Render(r.Context(), w)
+			return
+		}
+		email := r.FormValue("email")
+		if email == "" {
+			web.ForgotPasswordPage(false, web.T(r.Context(), "password.email_required")).Render(r.Context(), w)
+			return
+		}
+
+		err := requester.RequestPasswordReset(r.Context(), email, baseURL, time.Now())
+		switch {
+		case err == nil:
+		case errors.Is(err, user.ErrSendFailed):
+			// Showing the failure would reveal that the account exists.
+			slog.ErrorContext(r.Context(), "sending password reset", "error", err)
+		default:
+			slog.ErrorContext(r.Context(), "requesting password reset", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		web.ForgotPasswordPage(true, "").Render(r.Context(), w)
+	}
+}
+
+// HandleResetPasswordPage renders the new password form of a reset link.
+// The token is checked only when the form is submitted.
+func HandleResetPasswordPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		web.ResetPasswordPage(r.PathValue("token"), "", false).Render(r.Context(), w)
+	}
+}
+
+// HandleResetPasswordPost sets the new password and consumes the link.
+func HandleResetPasswordPost(resetter PasswordResetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.PathValue("token")
+		if err := r.ParseForm(); err != nil {
+			web.ResetPasswordPage(token, web.T(r.Context(), "common.bad_request"), false).Render(r.Context(), w)
+			return
+		}
+
+		if err := resetter.ResetPassword(r.Context(), token, r.FormValue("new_password"), time.Now()); err != nil {
+			switch {
+			case errors.Is(err, user.ErrInvalidResetToken):
+				web.ResetPasswordPage(token, web.T(r.Context(), "password.reset_link_invalid"), false).Render(r.Context(), w)
+			case errors.Is(err, user.ErrPasswordRequired):
+				web.ResetPasswordPage(token, web.T(r.Context(), "password.new_required"), false).Render(r.Context(), w)
+			default:
+				slog.ErrorContext(r.Context(), "resetting password", "error", err)
+				http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		web.ResetPasswordPage("", "", true).Render(r.Context(), w)
+	}
+}
@@ -0,0 +1,116 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubPasswordReset struct {
+	email, baseURL  string
+	token, password string
+	requestErr      error
+	resetErr        error
+}
+
+func (s *stubPasswordReset) RequestPasswordReset(_ context.Context, email, baseURL string, _ time.Time) error {
+	s.email, s.baseURL = email, baseURL
+	return s.requestErr
+}
+
+func (s *stubPasswordReset) ResetPassword(_ context.Context, token, password string, _ time.Time) error {
+	s.token, s.password = token, password
+	return s.resetErr
+}
+
+func forgotPasswordTestServer(s *stubPasswordReset) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /forgot-password", handler.HandleForgotPasswordPage())
+	mux.HandleFunc("POST /forgot-password", handler.HandleForgotPasswordPost(s, "https://farmacia.example.it"))
+	mux.HandleFunc("GET /reset-password/{token}", handler.HandleResetPasswordPage())
+	mux.HandleFunc("POST /reset-password/{token}", handler.HandleResetPasswordPost(s))
+	return httptest.NewServer(mux)
+}
+
+func readForm(t *testing.T, srv *httptest.Server, path string, form url.Values) (int, string) {
+	t.Helper()
+	resp := postForm(t, srv, path, form)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestForgotPasswordSameAnswerWhetherOrNotSendFails(t *testing.T) {
+	for _, err := range []error{nil, user.ErrSendFailed} {
+		s := &stubPasswordReset{requestErr: err}
+		srv := forgotPasswordTestServer(s)
+
+		status, body := readForm(t, srv, "/forgot-password", url.Values{"email": {"anna@example.com"}})
+		srv.Close()
+
+		if status != http.StatusOK || !strings.Contains(body, "corrisponde a un account attivo") {
+			t.Errorf("requestErr %v: status = %d, expected the generic confirmation", err, status)
+		}
+		if s.email != "anna@example.com" || s.baseURL != "https://farmacia.example.it" {
+			t.Errorf("RequestPasswordReset(%q, %q)", s.email, s.baseURL)
+		}
+	}
+}
+
+func TestForgotPasswordInternalErrorReturns500(t *testing.T) {
+	srv := forgotPasswordTestServer(&stubPasswordReset{requestErr: errors.New("db down")})
+	defer srv.Close()
+
+	status, _ := readForm(t, srv, "/forgot-password", url.Values{"email": {"anna@example.com"}})
+	if status != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", status)
+	}
+}
+
+func TestResetPasswordPageCarriesToken(t *testing.T) {
+	srv := forgotPasswordTestServer(&stubPasswordReset{})
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/reset-password/abc123")
+	if err != nil {
+		t.Fatalf("requesting reset page: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `action="/reset-password/abc123"`) {
+		t.Error("form must post back to the link's token")
+	}
+}
+
+func TestResetPasswordPostSuccess(t *testing.T) {
+	s := &stubPasswordReset{}
+	srv := forgotPasswordTestServer(s)
+	defer srv.Close()
+
+	status, body := readForm(t, srv, "/reset-password/abc123", url.Values{"new_password": {"nuova-password"}})
+	if status != http.StatusOK || !strings.Contains(body, "Password aggiornata") {
+		t.Errorf("status = %d, expected the confirmation", status)
+	}
+	if s.token != "abc123" || s.password != "nuova-password" {
+		t.Errorf("ResetPassword(%q, %q)", s.token, s.password)
+	}
+}
+
+func TestResetPasswordPostInvalidTokenShowsError(t *testing.T) {
+	srv := forgotPasswordTestServer(&stubPasswordReset{resetErr: user.ErrInvalidResetToken})
+	defer srv.Close()
+
+	status, body := readForm(t, srv, "/reset-password/stale", url.Values{"new_password": {"nuova-password"}})
+	if status != http.StatusOK || !strings.Contains(body, "non è valido o è scaduto") {
+		t.Errorf("status = %d, expected the invalid link message", status)
+	}
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// GroupOverviewer lists the aggregates of the branches an owner can reach.
+type GroupOverviewer interface {
+	GroupOverview(ctx context.Context, userID int64) ([]pharmacy.Summary, error)
+}
+
+// BranchSwitcher checks that an owner can reach a pharmacy.
+type BranchSwitcher interface {
+	SwitchBranch(ctx context.Context, userID, pharmacyID int64) (pharmacy.Branch, error)
+}
+
+// BranchLister lists the pharmacies an owner can switch to.
+type BranchLister interface {
+	Branches(ctx context.Context, userID int64) ([]pharmacy.Branch, error)
+}
+
+// PatientTransferer moves a patient to another branch of the group.
+type PatientTransferer interface {
+	Transfer(ctx context.Context, patientID, fromPharmacyID, toPharmacyID int64) error
+}
+
+// HandleGroupDashboard renders the per-branch aggregates of the owner's group.
+func HandleGroupDashboard(overviewer GroupOverviewer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		branches, err := overviewer.GroupOverview(r.Context(), web.UserID(r.Context()))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "listing group branches", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+		web.GroupDashboardPage(branches, web.PharmacyID(r.Context()), time.Now()).Render(r.Context(), w)
+	}
+}
+
+// HandleSwitchBranch makes another branch of the group the active pharmacy
+// of the session. Every pharmacy-scoped page follows the session.
+func HandleSwitchBranch(sessions *scs.SessionManager, switcher BranchSwitcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.ParseInt(r.FormValue("pharmacy_id"), 10, 64)
+		if err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		b, err := switcher.SwitchBranch(r.Context(), web.UserID(r.Context()), id)
+		if err != nil {
+			if errors.Is(err, pharmacy.ErrNotBranch) {
+				http.Error(w, web.T(r.Context(), "common.forbidden"), http.StatusForbidden)
+				return
+			}
+			slog.ErrorContext(r.Context(), "switching branch", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		sessions.Put(r.Context(), "pharmacyID", b.ID)
+		sessions.Put(r.Context(), "pharmacyName", b.Name)
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+	}
+}
+
+// HandlePatientTransferPage renders the form to move a patient to another branch.
+func HandlePatientTransferPage(getter PatientGetter, branches BranchLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		renderPatientTransfer(w, r, getter, branches, id, "")
+	}
+}
+
+// HandleTransferPatient moves a patient, with prescriptions and history, to
+// another branch of the group.
+func HandleTransferPatient(getter PatientGetter, branches BranchLister, transferer PatientTransferer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+		to, err := strconv.ParseInt(r.FormValue("pharmacy_id"), 10, 64)
+		if err != nil {
+			renderPatientTransfer(w, r, getter, branches, id, web.T(r.Context(), "transfer.target_required"))
+			return
+		}
+
+		if err := transferer.Transfer(r.Context(), id, web.PharmacyID(r.Context()), to); err != nil {
+			if errors.Is(err, patient.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				renderPatientTransfer(w, r, getter, branches, id, msg)
+				return
+			}
+			slog.ErrorContext(r.Context(), "transferring patient", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/patients", http.StatusSeeOther)
+	}
+}
+
+func renderPatientTransfer(w http.ResponseWriter, r *http.Request, getter PatientGetter, lister BranchLister, id int64, errMsg string) {
+	p, err := getter.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, patient.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		slog.ErrorContext(r.Context(), "getting patient", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	if p.PharmacyID != web.PharmacyID(r.Context()) {
+		http.NotFound(w, r)
+		return
+	}
+
+	all, err := lister.Branches(r.Context(), web.UserID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing branches", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	var targets []pharmacy.Branch
+	for _, b := range all {
+		if b.ID != p.PharmacyID {
+			targets = append(targets, b)
+		}
+	}
+
+	web.PatientTransferPage(p, targets, errMsg).Render(r.Context(), w)
+}
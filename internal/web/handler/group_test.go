@@ -0,0 +1,253 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubGroup struct {
+	summaries []pharmacy.Summary
+	branches  []pharmacy.Branch
+}
+
+func (s *stubGroup) GroupOverview(_ context.Context, _ int64) ([]pharmacy.Summary, error) {
+	return s.summaries, nil
+}
+
+func (s *stubGroup) Branches(_ context.Context, _ int64) ([]pharmacy.Branch, error) {
+	return s.branches, nil
+}
+
+func (s *stubGroup) SwitchBranch(_ context.Context, _ int64, id int64) (pharmacy.Branch, error) {
+	for _, b := range s.branches {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return pharmacy.Branch{}, pharmacy.ErrNotBranch
+}
+
+type stubPatientTransferer struct {
+	patientID, from, to int64
+	err                 error
+}
+
+func (s *stubPatientTransferer) Transfer(_ context.Context, patientID, from, to int64) error {
+	s.patientID, s.from, s.to = patientID, from, to
+	return s.err
+}
+
+type groupTestDeps struct {
+	sm            *scs.SessionManager
+	role          string
+	group         *stubGroup
+	patientGetter *stubPatientGetter
+	transferer    *stubPatientTransferer
+}
+
+func groupTestServer(d groupTestDeps) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /group", web.RequirePermission(permission.SwitchBranch)(http.HandlerFunc(handler.HandleGroupDashboard(d.group))))
+	mux.Handle("POST /group/switch", web.RequirePermission(permission.SwitchBranch)(http.HandlerFunc(handler.HandleSwitchBranch(d.sm, d.group))))
+	mux.Handle("GET /patients/{id}/transfer", web.RequirePermission(permission.TransferPatients)(http.HandlerFunc(handler.HandlePatientTransferPage(d.patientGetter, d.group))))
+	mux.Handle("POST /patients/{id}/transfer", web.RequirePermission(permission.TransferPatients)(http.HandlerFunc(handler.HandleTransferPatient(d.patientGetter, d.group, d.transferer))))
+	mux.HandleFunc("GET /whoami", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%d %s", web.PharmacyID(r.Context()), web.PharmacyName(r.Context()))
+	})
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		d.sm.Put(r.Context(), "userID", int64(1))
+		d.sm.Put(r.Context(), "role", d.role)
+		d.sm.Put(r.Context(), "pharmacyID", int64(7))
+		d.sm.Put(r.Context(), "pharmacyName", "Farmacia Rossi Centro")
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(d.sm.LoadAndSave(web.LoadUser(d.sm)(mux)))
+}
+
+func rossiGroup() *stubGroup {
+	return &stubGroup{
+		summaries: []pharmacy.Summary{
+			{ID: 7, Name: "Farmacia Rossi Centro", GroupName: "Gruppo Rossi", ActivePatientCount: 40, OverdueOrderCount: 2},
+			{ID: 8, Name: "Farmacia Rossi Stazione", GroupName: "Gruppo Rossi", ActivePatientCount: 15},
+		},
+		branches: []pharmacy.Branch{{ID: 7, Name: "Farmacia Rossi Centro"}, {ID: 8, Name: "Farmacia Rossi Stazione"}},
+	}
+}
+
+func TestGroupDashboardListsBranches(t *testing.T) {
+	srv := groupTestServer(groupTestDeps{sm: scs.New(), role: "owner", group: rossiGroup()})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/group")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	html := string(body)
+	for _, want := range []string{"Gruppo Rossi", "Farmacia Rossi Stazione", "55", "Passa a questa sede"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if n := strings.Count(html, "Passa a questa sede"); n != 1 {
+		t.Errorf("switch buttons = %d, want 1 (the active branch has none)", n)
+	}
+}
+
+func TestGroupDashboardIsOwnerOnly(t *testing.T) {
+	srv := groupTestServer(groupTestDeps{sm: scs.New(), role: "personnel", group: rossiGroup()})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/group")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestSwitchBranchUpdatesSession(t *testing.T) {
+	srv := groupTestServer(groupTestDeps{sm: scs.New(), role: "owner", group: rossiGroup()})
+	defer srv.Close()
+	client := noFollowClient()
+
+	setup, err := client.Get(srv.URL + "/setup-session")
+	if err != nil {
+		t.Fatalf("setting up session: %v", err)
+	}
+	setup.Body.Close()
+	cookies := setup.Cookies()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/group/switch", strings.NewReader(url.Values{"pharmacy_id": {"8"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("switching: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/dashboard" {
+		t.Fatalf("status = %d location = %q, want 303 to /dashboard", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/whoami", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("probing session: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if got := string(body); got != "8 Farmacia Rossi Stazione" {
+		t.Errorf("active pharmacy = %q, want %q", got, "8 Farmacia Rossi Stazione")
+	}
+}
+
+func TestSwitchBranchOutsideGroupReturns403(t *testing.T) {
+	srv := groupTestServer(groupTestDeps{sm: scs.New(), role: "owner", group: rossiGroup()})
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/group/switch", url.Values{"pharmacy_id": {"99"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestPatientTransferPageListsOtherBranches(t *testing.T) {
+	getter := &stubPatientGetter{patient: patient.Patient{ID: 10, PharmacyID: 7, FirstName: "Mario", LastName: "Rossi"}}
+	srv := groupTestServer(groupTestDeps{sm: scs.New(), role: "owner", group: rossiGroup(), patientGetter: getter})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/patients/10/transfer")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	html := string(body)
+	if !strings.Contains(html, "Farmacia Rossi Stazione") {
+		t.Error("page missing the target branch")
+	}
+	if strings.Contains(html, `<option value="7"`) {
+		t.Error("the current branch must not be offered")
+	}
+}
+
+func TestPatientTransferPageForeignPatientReturns404(t *testing.T) {
+	getter := &stubPatientGetter{patient: patient.Patient{ID: 10, PharmacyID: 99}}
+	srv := groupTestServer(groupTestDeps{sm: scs.New(), role: "owner", group: rossiGroup(), patientGetter: getter})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/patients/10/transfer")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestTransferPatientRedirectsToPatientList(t *testing.T) {
+	tr := &stubPatientTransferer{}
+	srv := groupTestServer(groupTestDeps{sm: scs.New(), role: "owner", group: rossiGroup(), patientGetter: &stubPatientGetter{}, transferer: tr})
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/patients/10/transfer", url.Values{"pharmacy_id": {"8"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/patients" {
+		t.Errorf("status = %d location = %q, want 303 to /patients", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if tr.patientID != 10 || tr.from != 7 || tr.to != 8 {
+		t.Errorf("Transfer(%d, %d, %d), want (10, 7, 8)", tr.patientID, tr.from, tr.to)
+	}
+}
+
+func TestTransferPatientInTransitShowsError(t *testing.T) {
+	getter := &stubPatientGetter{patient: patient.Patient{ID: 10, PharmacyID: 7, FirstName: "Mario", LastName: "Rossi"}}
+	tr := &stubPatientTransferer{err: patient.ErrTransferInTransit}
+	srv := groupTestServer(groupTestDeps{sm: scs.New(), role: "owner", group: rossiGroup(), patientGetter: getter, transferer: tr})
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/patients/10/transfer", url.Values{"pharmacy_id": {"8"}})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "spedizione non ancora consegnata") {
+		t.Errorf("status = %d, expected the transfer page with an error", resp.StatusCode)
+	}
+}
+
+func TestTransferPatientIsOwnerOnly(t *testing.T) {
+	tr := &stubPatientTransferer{}
+	srv := groupTestServer(groupTestDeps{sm: scs.New(), role: "personnel", group: rossiGroup(), patientGetter: &stubPatientGetter{}, transferer: tr})
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/patients/10/transfer", url.Values{"pharmacy_id": {"8"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
+	}
+	if tr.patientID != 0 {
+		t.Error("personnel must not transfer patients")
+	}
+}
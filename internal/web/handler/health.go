@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout bounds all readiness checks together, so a hung database
+// fails the probe instead of hanging it.
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck probes one dependency the server needs to serve requests.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HandleHealthz answers as long as the process serves HTTP, for liveness
+// probes. It checks nothing else, so a database outage does not get the
+// server restarted.
+func HandleHealthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "ok")
+	}
+}
+
+// HandleReadyz runs every check and answers 503 if any fails, so the load
+// balancer stops sending traffic. The body lists each check; failures are
+// logged with their error.
+func HandleReadyz(checks ...ReadinessCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		status := http.StatusOK
+		lines := make([]string, len(checks))
+		for i, c := range checks {
+			if err := c.Check(ctx); err != nil {
+				slog.WarnContext(r.Context(), "readiness check failed", "check", c.Name, "error", err)
+				status = http.StatusServiceUnavailable
+				lines[i] = c.Name + ": fail"
+				continue
+			}
+			lines[i] = c.Name + ": ok"
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		for _, l := range lines {
+			fmt.Fprintln(w, l)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+func TestHealthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler.HandleHealthz()(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok\n" {
+		t.Errorf("got %d %q, want 200 ok", rec.Code, rec.Body.String())
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	ok := handler.ReadinessCheck{Name: "database", Check: func(context.Context) error { return nil }}
+	behind := handler.ReadinessCheck{Name: "migrations", Check: func(context.Context) error { return errors.New("at 27, want 28") }}
+
+	rec := httptest.NewRecorder()
+	handler.HandleReadyz(ok)(rec, httptest.NewRequest("GET", "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("all passing: status = %d, want 200", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.HandleReadyz(ok, behind)(rec, httptest.NewRequest("GET", "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("one failing: status = %d, want 503", rec.Code)
+	}
+	if body := rec.Body.String(); body != "database: ok\nmigrations: fail\n" {
+		t.Errorf("body = %q", body)
+	}
+}
@@ -2,9 +2,10 @@ package handler
 
 import (
 	"context"
-	"errors"
 	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
@@ -14,7 +15,13 @@ import (
 
 // Authenticator verifies credentials and returns a user.
 type Authenticator interface {
-	Authenticate(ctx context.Context, email, password string) (user.User, error)
+	Authenticate(ctx context.Context, a user.LoginAttempt, now time.Time) (user.User, error)
+}
+
+// SessionTracker links a session token to the user and device it was issued
+// to, so the user can review it and deactivating the user can revoke it.
+type SessionTracker interface {
+	TrackSession(ctx context.Context, userID int64, token string, d user.Device) error
 }
 
 // PharmacyNameGetter retrieves a pharmacy by ID (used to enrich the session).
@@ -30,49 +37,90 @@ func HandleLoginPage() http.HandlerFunc {
 }
 
 // HandleLoginPost validates credentials, creates a session, and redirects.
-func HandleLoginPost(sessions *scs.SessionManager, auth Authenticator, pharmacies PharmacyNameGetter) http.HandlerFunc {
+func HandleLoginPost(sessions *scs.SessionManager, auth Authenticator, tracker SessionTracker, pharmacies PharmacyNameGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := r.ParseForm(); err != nil {
-			web.LoginPage("Richiesta non valida.").Render(r.Context(), w)
+			web.LoginPage(web.T(r.Context(), "common.bad_request")).Render(r.Context(), w)
 			return
 		}
 
-		u, err := auth.Authenticate(r.Context(), r.FormValue("email"), r.FormValue("password"))
+		u, err := auth.Authenticate(r.Context(), user.LoginAttempt{
+			Email:     r.FormValue("email"),
+			Password:  r.FormValue("password"),
+			IP:        clientIP(r),
+			UserAgent: r.UserAgent(),
+		}, time.Now())
 		if err != nil {
-			if errors.Is(err, user.ErrInvalidCredentials) {
-				web.LoginPage("Credenziali non valide.").Render(r.Context(), w)
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				web.LoginPage(msg).Render(r.Context(), w)
 				return
 			}
-			slog.Error("authenticating", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "authenticating", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
-		if err := sessions.RenewToken(r.Context()); err != nil {
-			slog.Error("renewing session token", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+		if u.TwoFactorEnabled {
+			startSecondFactor(w, r, sessions, u.ID)
 			return
 		}
 
-		sessions.Put(r.Context(), "userID", u.ID)
-		sessions.Put(r.Context(), "role", u.Role)
-		sessions.Put(r.Context(), "pharmacyID", u.PharmacyID)
-		sessions.Put(r.Context(), "userName", u.Name)
-
-		if u.PharmacyID != 0 && pharmacies != nil {
-			ph, err := pharmacies.Get(r.Context(), u.PharmacyID)
-			if err != nil {
-				slog.Error("fetching pharmacy name for session", "pharmacyID", u.PharmacyID, "error", err)
-			} else {
-				sessions.Put(r.Context(), "pharmacyName", ph.Name)
-			}
-		}
+		completeLogin(w, r, sessions, tracker, pharmacies, u)
+	}
+}
+
+// clientIP is the address the request came from, without the port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
 
-		dest := "/dashboard"
-		if u.Role == "admin" {
-			dest = "/admin"
+// completeLogin issues the authenticated session for u and redirects to the
+// home page, or to the page the user must visit first.
+func completeLogin(w http.ResponseWriter, r *http.Request, sessions *scs.SessionManager, tracker SessionTracker, pharmacies PharmacyNameGetter, u user.User) {
+	if err := sessions.RenewToken(r.Context()); err != nil {
+		slog.ErrorContext(r.Context(), "renewing session token", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+
+	device := user.Device{IP: clientIP(r), UserAgent: r.UserAgent()}
+	if err := tracker.TrackSession(r.Context(), u.ID, sessions.Token(r.Context()), device); err != nil {
+		slog.ErrorContext(r.Context(), "tracking session", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+
+	sessions.Put(r.Context(), "userID", u.ID)
+	sessions.Put(r.Context(), "role", u.Role)
+	sessions.Put(r.Context(), "pharmacyID", u.PharmacyID)
+	sessions.Put(r.Context(), "homePharmacyID", u.PharmacyID)
+	sessions.Put(r.Context(), "userName", u.Name)
+	sessions.Put(r.Context(), "permissions", u.Permissions.Strings())
+	sessions.Put(r.Context(), "locale", u.PreferredLocale())
+
+	if u.PharmacyID != 0 && pharmacies != nil {
+		ph, err := pharmacies.Get(r.Context(), u.PharmacyID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "fetching pharmacy name for session", "pharmacyID", u.PharmacyID, "error", err)
+		} else {
+			sessions.Put(r.Context(), "pharmacyName", ph.Name)
+			sessions.Put(r.Context(), "homePharmacyName", ph.Name)
 		}
+	}
 
-		http.Redirect(w, r, dest, http.StatusSeeOther)
+	dest := web.Home(u.Permissions)
+	if !u.TwoFactorEnabled && u.TwoFactorRequired() {
+		sessions.Put(r.Context(), "mustEnrolTwoFactor", true)
+		dest = "/account/2fa"
 	}
+	if u.MustChangePassword {
+		sessions.Put(r.Context(), "mustChangePassword", true)
+		dest = "/change-password"
+	}
+
+	http.Redirect(w, r, dest, http.StatusSeeOther)
 }
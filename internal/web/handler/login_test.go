@@ -9,19 +9,23 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
 	"github.com/giorgiovilardo/pharmarecall/internal/user"
 	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
 )
 
 type stubAuthenticator struct {
-	user user.User
-	err  error
+	user    user.User
+	err     error
+	attempt user.LoginAttempt
 }
 
-func (s *stubAuthenticator) Authenticate(_ context.Context, _, _ string) (user.User, error) {
+func (s *stubAuthenticator) Authenticate(_ context.Context, a user.LoginAttempt, _ time.Time) (user.User, error) {
+	s.attempt = a
 	return s.user, s.err
 }
 
@@ -34,16 +38,28 @@ func (s *stubPharmacyNameGetter) Get(_ context.Context, _ int64) (pharmacy.Pharm
 	return s.pharmacy, s.err
 }
 
-func loginTestServer(sm *scs.SessionManager, auth handler.Authenticator, pharmacies handler.PharmacyNameGetter) *httptest.Server {
+type stubSessionTracker struct {
+	userID int64
+	token  string
+	device user.Device
+	err    error
+}
+
+func (s *stubSessionTracker) TrackSession(_ context.Context, userID int64, token string, d user.Device) error {
+	s.userID, s.token, s.device = userID, token, d
+	return s.err
+}
+
+func loginTestServer(sm *scs.SessionManager, auth handler.Authenticator, tracker handler.SessionTracker, pharmacies handler.PharmacyNameGetter) *httptest.Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /login", handler.HandleLoginPage())
-	mux.HandleFunc("POST /login", handler.HandleLoginPost(sm, auth, pharmacies))
+	mux.HandleFunc("POST /login", handler.HandleLoginPost(sm, auth, tracker, pharmacies))
 	return httptest.NewServer(sm.LoadAndSave(mux))
 }
 
 func TestLoginPageRendersForm(t *testing.T) {
 	sm := scs.New()
-	srv := loginTestServer(sm, &stubAuthenticator{}, nil)
+	srv := loginTestServer(sm, &stubAuthenticator{}, &stubSessionTracker{}, nil)
 	defer srv.Close()
 
 	resp, err := http.Get(srv.URL + "/login")
@@ -59,11 +75,11 @@ func TestLoginPageRendersForm(t *testing.T) {
 
 func TestLoginPostValidCredentialsRedirects(t *testing.T) {
 	auth := &stubAuthenticator{
-		user: user.User{ID: 1, Email: "admin@example.com", Name: "Admin", Role: "admin"},
+		user: user.User{ID: 1, Email: "admin@example.com", Name: "Admin", Role: "admin", Permissions: permission.ForRole("admin", nil)},
 	}
 
 	sm := scs.New()
-	srv := loginTestServer(sm, auth, nil)
+	srv := loginTestServer(sm, auth, &stubSessionTracker{}, nil)
 	defer srv.Close()
 
 	form := url.Values{"email": {"admin@example.com"}, "password": {"secret123"}}
@@ -76,8 +92,8 @@ func TestLoginPostValidCredentialsRedirects(t *testing.T) {
 	if resp.StatusCode != http.StatusSeeOther {
 		t.Errorf("POST /login status = %d, want 303", resp.StatusCode)
 	}
-	if loc := resp.Header.Get("Location"); loc != "/admin" {
-		t.Errorf("POST /login redirect = %q, want /admin", loc)
+	if loc := resp.Header.Get("Location"); loc != "/account/2fa" {
+		t.Errorf("POST /login redirect = %q, want /account/2fa (admins must enrol 2FA)", loc)
 	}
 }
 
@@ -85,7 +101,7 @@ func TestLoginPostInvalidCredentialsShowsError(t *testing.T) {
 	auth := &stubAuthenticator{err: user.ErrInvalidCredentials}
 
 	sm := scs.New()
-	srv := loginTestServer(sm, auth, nil)
+	srv := loginTestServer(sm, auth, &stubSessionTracker{}, nil)
 	defer srv.Close()
 
 	form := url.Values{"email": {"user@example.com"}, "password": {"wrong-password"}}
@@ -106,19 +122,21 @@ func TestLoginPostRedirectsByRole(t *testing.T) {
 		role    string
 		wantLoc string
 	}{
-		{"admin goes to /admin", "admin", "/admin"},
+		{"admin without 2FA enrols first", "admin", "/account/2fa"},
 		{"owner goes to /dashboard", "owner", "/dashboard"},
 		{"personnel goes to /dashboard", "personnel", "/dashboard"},
+		{"trainee goes to /dashboard", "trainee", "/dashboard"},
+		{"driver goes to /shipping", "driver", "/shipping"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			auth := &stubAuthenticator{
-				user: user.User{ID: 1, Email: "user@example.com", Role: tt.role},
+				user: user.User{ID: 1, Email: "user@example.com", Role: tt.role, Permissions: permission.ForRole(tt.role, nil)},
 			}
 
 			sm := scs.New()
-			srv := loginTestServer(sm, auth, nil)
+			srv := loginTestServer(sm, auth, &stubSessionTracker{}, nil)
 			defer srv.Close()
 
 			form := url.Values{"email": {"user@example.com"}, "password": {"pass"}}
@@ -137,7 +155,7 @@ func TestLoginPostRedirectsByRole(t *testing.T) {
 
 func TestLoginPostStoresUserNameAndPharmacyNameInSession(t *testing.T) {
 	auth := &stubAuthenticator{
-		user: user.User{ID: 5, Email: "owner@example.com", Name: "Mario Rossi", Role: "owner", PharmacyID: 10},
+		user: user.User{ID: 5, Email: "owner@example.com", Name: "Mario Rossi", Role: "owner", PharmacyID: 10, Permissions: permission.ForRole("owner", nil)},
 	}
 	pharmacies := &stubPharmacyNameGetter{
 		pharmacy: pharmacy.Pharmacy{ID: 10, Name: "Farmacia Centrale"},
@@ -148,7 +166,7 @@ func TestLoginPostStoresUserNameAndPharmacyNameInSession(t *testing.T) {
 	// We need to verify session contents after login, so add a /check route.
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /login", handler.HandleLoginPage())
-	mux.HandleFunc("POST /login", handler.HandleLoginPost(sm, auth, pharmacies))
+	mux.HandleFunc("POST /login", handler.HandleLoginPost(sm, auth, &stubSessionTracker{}, pharmacies))
 	mux.HandleFunc("GET /check", func(w http.ResponseWriter, r *http.Request) {
 		userName := sm.GetString(r.Context(), "userName")
 		pharmacyName := sm.GetString(r.Context(), "pharmacyName")
@@ -186,14 +204,14 @@ func TestLoginPostStoresUserNameAndPharmacyNameInSession(t *testing.T) {
 
 func TestLoginPostPharmacyNameErrorIsNonFatal(t *testing.T) {
 	auth := &stubAuthenticator{
-		user: user.User{ID: 5, Email: "owner@example.com", Name: "Mario Rossi", Role: "owner", PharmacyID: 10},
+		user: user.User{ID: 5, Email: "owner@example.com", Name: "Mario Rossi", Role: "owner", PharmacyID: 10, Permissions: permission.ForRole("owner", nil)},
 	}
 	pharmacies := &stubPharmacyNameGetter{
 		err: errors.New("db connection lost"),
 	}
 
 	sm := scs.New()
-	srv := loginTestServer(sm, auth, pharmacies)
+	srv := loginTestServer(sm, auth, &stubSessionTracker{}, pharmacies)
 	defer srv.Close()
 
 	form := url.Values{"email": {"owner@example.com"}, "password": {"pass"}}
@@ -210,3 +228,115 @@ func TestLoginPostPharmacyNameErrorIsNonFatal(t *testing.T) {
 		t.Errorf("redirect = %q, want /dashboard", loc)
 	}
 }
+
+func postLogin(t *testing.T, srv *httptest.Server) *http.Response {
+	t.Helper()
+	form := url.Values{"email": {"user@example.com"}, "password": {"secret123"}}
+	resp, err := noFollowClient().Post(srv.URL+"/login", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatalf("posting login: %v", err)
+	}
+	return resp
+}
+
+func TestLoginPostDeactivatedAccountShowsError(t *testing.T) {
+	tracker := &stubSessionTracker{}
+	srv := loginTestServer(scs.New(), &stubAuthenticator{err: user.ErrDeactivated}, tracker, nil)
+	defer srv.Close()
+
+	resp := postLogin(t, srv)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Account disattivato") {
+		t.Errorf("status = %d, expected the login form with the deactivated message", resp.StatusCode)
+	}
+	if tracker.userID != 0 {
+		t.Error("no session must be tracked for a deactivated account")
+	}
+}
+
+func TestLoginPostLockedAccountShowsError(t *testing.T) {
+	srv := loginTestServer(scs.New(), &stubAuthenticator{err: user.ErrAccountLocked}, &stubSessionTracker{}, nil)
+	defer srv.Close()
+
+	resp := postLogin(t, srv)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "account bloccato temporaneamente") {
+		t.Errorf("status = %d, expected the login form with the lockout message", resp.StatusCode)
+	}
+}
+
+func TestLoginPostPassesClientDetails(t *testing.T) {
+	auth := &stubAuthenticator{err: user.ErrInvalidCredentials}
+	srv := loginTestServer(scs.New(), auth, &stubSessionTracker{}, nil)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/login", strings.NewReader(url.Values{"email": {"a@example.com"}, "password": {"pw"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Firefox/140")
+	resp, err := noFollowClient().Do(req)
+	if err != nil {
+		t.Fatalf("posting login: %v", err)
+	}
+	resp.Body.Close()
+
+	if auth.attempt.Email != "a@example.com" || auth.attempt.IP != "127.0.0.1" || auth.attempt.UserAgent != "Firefox/140" {
+		t.Errorf("attempt = %+v, want email, loopback IP and user agent", auth.attempt)
+	}
+}
+
+func TestLoginPostTracksSession(t *testing.T) {
+	tracker := &stubSessionTracker{}
+	auth := &stubAuthenticator{user: user.User{ID: 5, Role: "personnel", PharmacyID: 7, Permissions: permission.ForRole("personnel", nil)}}
+	srv := loginTestServer(scs.New(), auth, tracker, &stubPharmacyNameGetter{})
+	defer srv.Close()
+
+	resp := postLogin(t, srv)
+	defer resp.Body.Close()
+
+	if tracker.userID != 5 || tracker.token == "" {
+		t.Errorf("TrackSession(%d, %q), want user 5 with the renewed token", tracker.userID, tracker.token)
+	}
+	var cookie string
+	for _, c := range resp.Cookies() {
+		if c.Name == "session" {
+			cookie = c.Value
+		}
+	}
+	if cookie != tracker.token {
+		t.Errorf("tracked token %q differs from the session cookie %q", tracker.token, cookie)
+	}
+	if tracker.device.IP != "127.0.0.1" || tracker.device.UserAgent != "Go-http-client/1.1" {
+		t.Errorf("tracked device = %+v, want loopback IP and the client user agent", tracker.device)
+	}
+}
+
+func TestLoginPostTrackSessionErrorFails(t *testing.T) {
+	tracker := &stubSessionTracker{err: errors.New("db down")}
+	auth := &stubAuthenticator{user: user.User{ID: 5, Role: "personnel", PharmacyID: 7, Permissions: permission.ForRole("personnel", nil)}}
+	srv := loginTestServer(scs.New(), auth, tracker, &stubPharmacyNameGetter{})
+	defer srv.Close()
+
+	resp := postLogin(t, srv)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+}
+
+func TestLoginPostMustChangePasswordRedirectsToChangePassword(t *testing.T) {
+	auth := &stubAuthenticator{user: user.User{ID: 5, Role: "personnel", PharmacyID: 7, MustChangePassword: true, Permissions: permission.ForRole("personnel", nil)}}
+	srv := loginTestServer(scs.New(), auth, &stubSessionTracker{}, &stubPharmacyNameGetter{})
+	defer srv.Close()
+
+	resp := postLogin(t, srv)
+	defer resp.Body.Close()
+
+	if loc := resp.Header.Get("Location"); resp.StatusCode != http.StatusSeeOther || loc != "/change-password" {
+		t.Errorf("status = %d location = %q, want 303 to /change-password", resp.StatusCode, loc)
+	}
+}
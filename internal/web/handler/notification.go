@@ -37,8 +37,8 @@ func HandleNotificationList(lister NotificationLister) http.HandlerFunc {
 
 		notifs, err := lister.List(r.Context(), pharmacyID)
 		if err != nil {
-			slog.Error("listing notifications", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "listing notifications", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -58,8 +58,8 @@ func HandleMarkNotificationRead(reader NotificationMarkReader) http.HandlerFunc
 		pharmacyID := web.PharmacyID(r.Context())
 
 		if err := reader.MarkRead(r.Context(), id, pharmacyID); err != nil {
-			slog.Error("marking notification read", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "marking notification read", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -73,8 +73,8 @@ func HandleMarkAllNotificationsRead(reader NotificationMarkAllReader) http.Handl
 		pharmacyID := web.PharmacyID(r.Context())
 
 		if err := reader.MarkAllRead(r.Context(), pharmacyID); err != nil {
-			slog.Error("marking all notifications read", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "marking all notifications read", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
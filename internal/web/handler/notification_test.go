@@ -13,6 +13,7 @@ import (
 
 	"github.com/alexedwards/scs/v2"
 	"github.com/giorgiovilardo/pharmarecall/internal/notification"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
 )
@@ -66,13 +67,13 @@ type notifTestDeps struct {
 func notifTestServer(d notifTestDeps) *httptest.Server {
 	mux := http.NewServeMux()
 	if d.lister != nil {
-		mux.Handle("GET /notifications", web.RequirePharmacyStaff(http.HandlerFunc(handler.HandleNotificationList(d.lister))))
+		mux.Handle("GET /notifications", web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(handler.HandleNotificationList(d.lister))))
 	}
 	if d.markRead != nil {
-		mux.Handle("POST /notifications/{id}/read", web.RequirePharmacyStaff(http.HandlerFunc(handler.HandleMarkNotificationRead(d.markRead))))
+		mux.Handle("POST /notifications/{id}/read", web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(handler.HandleMarkNotificationRead(d.markRead))))
 	}
 	if d.markAll != nil {
-		mux.Handle("POST /notifications/read-all", web.RequirePharmacyStaff(http.HandlerFunc(handler.HandleMarkAllNotificationsRead(d.markAll))))
+		mux.Handle("POST /notifications/read-all", web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(handler.HandleMarkAllNotificationsRead(d.markAll))))
 	}
 	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
 		d.sm.Put(r.Context(), "userID", int64(1))
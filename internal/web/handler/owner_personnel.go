@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"cmp"
 	"errors"
 	"log/slog"
 	"net/http"
@@ -16,8 +17,8 @@ func HandleOwnerPersonnelList(lister PersonnelLister) http.HandlerFunc {
 
 		members, err := lister.ListPersonnel(r.Context(), pharmacyID)
 		if err != nil {
-			slog.Error("listing personnel", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "listing personnel", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -26,17 +27,18 @@ func HandleOwnerPersonnelList(lister PersonnelLister) http.HandlerFunc {
 }
 
 // HandleOwnerAddPersonnelPage renders the add-personnel form for a pharmacy owner.
-func HandleOwnerAddPersonnelPage() http.HandlerFunc {
+func HandleOwnerAddPersonnelPage(roles CustomRoleLister) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		web.OwnerAddPersonnelPage("").Render(r.Context(), w)
+		renderOwnerAddPersonnel(w, r, roles, "")
 	}
 }
 
-// HandleOwnerCreatePersonnel creates a new personnel user scoped to the owner's pharmacy.
-func HandleOwnerCreatePersonnel(creator PersonnelCreator) http.HandlerFunc {
+// HandleOwnerCreatePersonnel creates a new personnel user scoped to the
+// owner's pharmacy, with the built-in or custom role chosen in the form.
+func HandleOwnerCreatePersonnel(creator PersonnelCreator, roles CustomRoleLister) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := r.ParseForm(); err != nil {
-			web.OwnerAddPersonnelPage("Richiesta non valida.").Render(r.Context(), w)
+			renderOwnerAddPersonnel(w, r, roles, web.T(r.Context(), "common.bad_request"))
 			return
 		}
 
@@ -45,29 +47,45 @@ func HandleOwnerCreatePersonnel(creator PersonnelCreator) http.HandlerFunc {
 		password := r.FormValue("password")
 
 		if name == "" || email == "" || password == "" {
-			web.OwnerAddPersonnelPage("Tutti i campi sono obbligatori.").Render(r.Context(), w)
+			renderOwnerAddPersonnel(w, r, roles, web.T(r.Context(), "personnel.fields_required"))
 			return
 		}
 
 		pharmacyID := web.PharmacyID(r.Context())
+		role, customRoleID := web.ParseRoleChoice(cmp.Or(r.FormValue("role"), pharmacy.RolePersonnel))
 
 		_, err := creator.CreatePersonnel(r.Context(), pharmacy.CreatePersonnelParams{
-			PharmacyID: pharmacyID,
-			Name:       name,
-			Email:      email,
-			Password:   password,
-			Role:       "personnel",
+			PharmacyID:   pharmacyID,
+			Name:         name,
+			Email:        email,
+			Password:     password,
+			Role:         role,
+			CustomRoleID: customRoleID,
 		})
 		if err != nil {
 			if errors.Is(err, pharmacy.ErrDuplicateEmail) {
-				web.OwnerAddPersonnelPage("L'email è già in uso.").Render(r.Context(), w)
+				renderOwnerAddPersonnel(w, r, roles, web.ErrorMessage(r.Context(), err))
 				return
 			}
-			slog.Error("creating personnel user", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			if errors.Is(err, pharmacy.ErrInvalidRole) {
+				renderOwnerAddPersonnel(w, r, roles, web.ErrorMessage(r.Context(), err))
+				return
+			}
+			slog.ErrorContext(r.Context(), "creating personnel user", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
 		http.Redirect(w, r, "/personnel", http.StatusSeeOther)
 	}
 }
+
+func renderOwnerAddPersonnel(w http.ResponseWriter, r *http.Request, roles CustomRoleLister, errMsg string) {
+	custom, err := roles.List(r.Context(), web.PharmacyID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing roles", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	web.OwnerAddPersonnelPage(custom, errMsg).Render(r.Context(), w)
+}
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
@@ -42,10 +43,10 @@ func (s *stubPersonnelCreator) CreatePersonnel(_ context.Context, p pharmacy.Cre
 
 func ownerPersonnelTestServer(sm *scs.SessionManager, lister handler.PersonnelLister, creator handler.PersonnelCreator) *httptest.Server {
 	mux := http.NewServeMux()
-	mux.Handle("GET /personnel", web.RequireOwner(http.HandlerFunc(handler.HandleOwnerPersonnelList(lister))))
-	mux.Handle("GET /personnel/new", web.RequireOwner(http.HandlerFunc(handler.HandleOwnerAddPersonnelPage())))
+	mux.Handle("GET /personnel", web.RequirePermission(permission.ManagePersonnel)(http.HandlerFunc(handler.HandleOwnerPersonnelList(lister))))
+	mux.Handle("GET /personnel/new", web.RequirePermission(permission.ManagePersonnel)(http.HandlerFunc(handler.HandleOwnerAddPersonnelPage(magazziniere()))))
 	if creator != nil {
-		mux.Handle("POST /personnel", web.RequireOwner(http.HandlerFunc(handler.HandleOwnerCreatePersonnel(creator))))
+		mux.Handle("POST /personnel", web.RequirePermission(permission.ManagePersonnel)(http.HandlerFunc(handler.HandleOwnerCreatePersonnel(creator, magazziniere()))))
 	}
 	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
 		sm.Put(r.Context(), "userID", int64(1))
@@ -143,7 +144,7 @@ func TestOwnerAddPersonnelPageRendersForm(t *testing.T) {
 	body, _ := io.ReadAll(resp.Body)
 	bodyStr := string(body)
 
-	for _, want := range []string{"Nuovo Personale", "name", "email", "password"} {
+	for _, want := range []string{"Nuovo Personale", "name", "email", "password", `<option value="trainee"`, `<option value="custom:5"`} {
 		if !strings.Contains(bodyStr, want) {
 			t.Errorf("body missing %q", want)
 		}
@@ -184,6 +185,27 @@ func TestOwnerCreatePersonnelSuccessRedirects(t *testing.T) {
 	}
 }
 
+func TestOwnerCreatePersonnelWithCustomRole(t *testing.T) {
+	stub := &stubPersonnelCreator{member: pharmacy.PersonnelMember{ID: 5}}
+
+	sm := scs.New()
+	srv := ownerPersonnelTestServer(sm, nil, stub)
+	defer srv.Close()
+
+	form := url.Values{
+		"name":     {"Anna Verdi"},
+		"email":    {"anna@example.com"},
+		"password": {"temppass123"},
+		"role":     {"custom:5"},
+	}
+	resp := authenticatedPost(t, srv, "/personnel", form)
+	defer resp.Body.Close()
+
+	if stub.params.Role != "custom" || stub.params.CustomRoleID != 5 {
+		t.Errorf("role = %q/%d, want custom/5", stub.params.Role, stub.params.CustomRoleID)
+	}
+}
+
 func TestOwnerCreatePersonnelMissingFieldsShowsError(t *testing.T) {
 	stub := &stubPersonnelCreator{}
 
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
 	"github.com/giorgiovilardo/pharmarecall/internal/patient"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 )
@@ -45,8 +46,8 @@ func HandlePatientList(lister PatientLister) http.HandlerFunc {
 
 		patients, err := lister.List(r.Context(), pharmacyID)
 		if err != nil {
-			slog.Error("listing patients", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "listing patients", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -54,24 +55,30 @@ func HandlePatientList(lister PatientLister) http.HandlerFunc {
 	}
 }
 
-// HandleNewPatientPage renders the patient creation form.
-func HandleNewPatientPage() http.HandlerFunc {
+// HandleNewPatientPage renders the patient creation form with the
+// pharmacy's default fulfillment preselected.
+func HandleNewPatientPage(settings PharmacySettingsGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		web.PatientNewPage("").Render(r.Context(), w)
+		s, err := settings.Settings(r.Context(), web.PharmacyID(r.Context()))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "getting pharmacy settings", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+		web.PatientNewPage(s.DefaultFulfillment, "").Render(r.Context(), w)
 	}
 }
 
-// patientValidationMessage maps domain validation errors to user-facing messages.
-func patientValidationMessage(err error) string {
-	switch {
-	case errors.Is(err, patient.ErrNameRequired):
-		return "Nome e cognome sono obbligatori."
-	case errors.Is(err, patient.ErrContactRequired):
-		return "È necessario almeno un contatto (telefono o email)."
-	case errors.Is(err, patient.ErrDeliveryAddrRequired):
-		return "L'indirizzo di consegna è obbligatorio per la spedizione."
-	default:
-		return ""
+// deliveryAddressFromForm reads the structured delivery address fields.
+func deliveryAddressFromForm(r *http.Request) address.Address {
+	return address.Address{
+		Street:      r.FormValue("delivery_street"),
+		HouseNumber: r.FormValue("delivery_house_number"),
+		CAP:         r.FormValue("delivery_cap"),
+		City:        r.FormValue("delivery_city"),
+		Province:    r.FormValue("delivery_province"),
+		Country:     r.FormValue("delivery_country"),
+		Notes:       r.FormValue("delivery_notes"),
 	}
 }
 
@@ -79,7 +86,7 @@ func patientValidationMessage(err error) string {
 func HandleCreatePatient(creator PatientCreator) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := r.ParseForm(); err != nil {
-			web.PatientNewPage("Richiesta non valida.").Render(r.Context(), w)
+			web.PatientNewPage("", web.T(r.Context(), "common.bad_request")).Render(r.Context(), w)
 			return
 		}
 
@@ -91,17 +98,17 @@ func HandleCreatePatient(creator PatientCreator) http.HandlerFunc {
 			LastName:        r.FormValue("last_name"),
 			Phone:           r.FormValue("phone"),
 			Email:           r.FormValue("email"),
-			DeliveryAddress: r.FormValue("delivery_address"),
+			DeliveryAddress: deliveryAddressFromForm(r),
 			Fulfillment:     r.FormValue("fulfillment"),
 			Notes:           r.FormValue("notes"),
 		})
 		if err != nil {
-			if msg := patientValidationMessage(err); msg != "" {
-				web.PatientNewPage(msg).Render(r.Context(), w)
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				web.PatientNewPage(r.FormValue("fulfillment"), msg).Render(r.Context(), w)
 				return
 			}
-			slog.Error("creating patient", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "creating patient", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -118,26 +125,35 @@ func HandlePatientDetail(getter PatientGetter, rxLister PrescriptionLister) http
 			return
 		}
 
-		p, err := getter.Get(r.Context(), id)
-		if err != nil {
-			if errors.Is(err, patient.ErrNotFound) {
-				http.NotFound(w, r)
-				return
-			}
-			slog.Error("getting patient", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
-			return
-		}
+		renderPatientDetail(w, r, getter, rxLister, id, "")
+	}
+}
 
-		rxs, err := rxLister.ListByPatient(r.Context(), id)
-		if err != nil {
-			slog.Error("listing prescriptions", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+func renderPatientDetail(w http.ResponseWriter, r *http.Request, getter PatientGetter, rxLister PrescriptionLister, id int64, errMsg string) {
+	p, err := getter.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, patient.ErrNotFound) {
+			http.NotFound(w, r)
 			return
 		}
+		slog.ErrorContext(r.Context(), "getting patient", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	// A patient transferred to another branch is no longer visible here.
+	if p.PharmacyID != web.PharmacyID(r.Context()) {
+		http.NotFound(w, r)
+		return
+	}
 
-		web.PatientDetailPage(p, rxs, time.Now(), "").Render(r.Context(), w)
+	rxs, err := rxLister.ListByPatient(r.Context(), id)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing prescriptions", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
 	}
+
+	web.PatientDetailPage(p, rxs, time.Now(), errMsg).Render(r.Context(), w)
 }
 
 // HandleUpdatePatient parses the form and updates a patient.
@@ -150,7 +166,7 @@ func HandleUpdatePatient(getter PatientGetter, updater PatientUpdater, rxLister
 		}
 
 		if err := r.ParseForm(); err != nil {
-			http.Error(w, "Richiesta non valida.", http.StatusBadRequest)
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
 			return
 		}
 
@@ -166,16 +182,16 @@ func HandleUpdatePatient(getter PatientGetter, updater PatientUpdater, rxLister
 			LastName:        r.FormValue("last_name"),
 			Phone:           r.FormValue("phone"),
 			Email:           r.FormValue("email"),
-			DeliveryAddress: r.FormValue("delivery_address"),
+			DeliveryAddress: deliveryAddressFromForm(r),
 			Fulfillment:     r.FormValue("fulfillment"),
 			Notes:           r.FormValue("notes"),
 		}); err != nil {
-			if msg := patientValidationMessage(err); msg != "" {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
 				renderError(msg)
 				return
 			}
-			slog.Error("updating patient", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "updating patient", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -193,8 +209,8 @@ func HandleSetConsensus(recorder PatientConsensusRecorder) http.HandlerFunc {
 		}
 
 		if err := recorder.SetConsensus(r.Context(), id); err != nil {
-			slog.Error("setting consensus", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "setting consensus", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
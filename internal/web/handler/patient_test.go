@@ -11,7 +11,9 @@ import (
 	"testing"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
 	"github.com/giorgiovilardo/pharmarecall/internal/patient"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
 	"github.com/giorgiovilardo/pharmarecall/internal/prescription"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
@@ -97,6 +99,7 @@ type patientTestDeps struct {
 	updater   handler.PatientUpdater
 	consensus handler.PatientConsensusRecorder
 	rxLister  handler.PrescriptionLister
+	settings  handler.PharmacySettingsGetter
 }
 
 func patientTestServer(sm *scs.SessionManager, lister handler.PatientLister, creator handler.PatientCreator) *httptest.Server {
@@ -107,11 +110,14 @@ func patientTestServerFull(d patientTestDeps) *httptest.Server {
 	if d.rxLister == nil {
 		d.rxLister = &stubPrescriptionLister{}
 	}
+	if d.settings == nil {
+		d.settings = &stubPharmacySettings{}
+	}
 	mux := http.NewServeMux()
 	if d.lister != nil {
 		mux.Handle("GET /patients", web.RequireAuth(http.HandlerFunc(handler.HandlePatientList(d.lister))))
 	}
-	mux.Handle("GET /patients/new", web.RequireAuth(http.HandlerFunc(handler.HandleNewPatientPage())))
+	mux.Handle("GET /patients/new", web.RequireAuth(http.HandlerFunc(handler.HandleNewPatientPage(d.settings))))
 	if d.creator != nil {
 		mux.Handle("POST /patients", web.RequireAuth(http.HandlerFunc(handler.HandleCreatePatient(d.creator))))
 	}
@@ -227,6 +233,22 @@ func TestNewPatientPageRendersForm(t *testing.T) {
 	}
 }
 
+func TestNewPatientPagePreselectsPharmacyFulfillment(t *testing.T) {
+	sm := scs.New()
+	srv := patientTestServerFull(patientTestDeps{sm: sm, settings: &stubPharmacySettings{
+		settings: pharmacy.Settings{DefaultFulfillment: "shipping"},
+	}})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/patients/new")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `<option value="shipping" selected>`) {
+		t.Error("shipping should be preselected")
+	}
+}
+
 // --- Create patient handler tests (5.5) ---
 
 func TestCreatePatientSuccessRedirects(t *testing.T) {
@@ -363,7 +385,8 @@ func TestPatientDetailRendersPatient(t *testing.T) {
 	getter := &stubPatientGetter{patient: patient.Patient{
 		ID: 10, PharmacyID: 7, FirstName: "Mario", LastName: "Rossi",
 		Phone: "333-1234567", Email: "mario@example.com",
-		DeliveryAddress: "Via Roma 1", Fulfillment: "pickup", Notes: "Nota test",
+		DeliveryAddress: address.Address{Street: "Via Roma", HouseNumber: "1", CAP: "20121", City: "Milano", Province: "MI", Country: "IT"},
+		Fulfillment:     "pickup", Notes: "Nota test",
 	}}
 
 	sm := scs.New()
@@ -380,7 +403,7 @@ func TestPatientDetailRendersPatient(t *testing.T) {
 	body, _ := io.ReadAll(resp.Body)
 	bodyStr := string(body)
 
-	for _, want := range []string{"Mario", "Rossi", "333-1234567", "mario@example.com", "Via Roma 1", "Nota test"} {
+	for _, want := range []string{"Mario", "Rossi", "333-1234567", "mario@example.com", `value="Via Roma"`, `value="20121"`, `value="MI" selected`, "Nota test"} {
 		if !strings.Contains(bodyStr, want) {
 			t.Errorf("body missing %q", want)
 		}
@@ -38,7 +38,7 @@ func HandleCreatePersonnel(creator PersonnelCreator) http.HandlerFunc {
 		}
 
 		if err := r.ParseForm(); err != nil {
-			web.AddPersonnelPage(pharmacyID, "Richiesta non valida.").Render(r.Context(), w)
+			web.AddPersonnelPage(pharmacyID, web.T(r.Context(), "common.bad_request")).Render(r.Context(), w)
 			return
 		}
 
@@ -47,13 +47,13 @@ func HandleCreatePersonnel(creator PersonnelCreator) http.HandlerFunc {
 		password := r.FormValue("password")
 
 		if name == "" || email == "" || password == "" {
-			web.AddPersonnelPage(pharmacyID, "Tutti i campi sono obbligatori.").Render(r.Context(), w)
+			web.AddPersonnelPage(pharmacyID, web.T(r.Context(), "personnel.fields_required")).Render(r.Context(), w)
 			return
 		}
 
-		role := "personnel"
+		role := pharmacy.RolePersonnel
 		if r.FormValue("owner") == "true" {
-			role = "owner"
+			role = pharmacy.RoleOwner
 		}
 
 		_, err = creator.CreatePersonnel(r.Context(), pharmacy.CreatePersonnelParams{
@@ -65,11 +65,11 @@ func HandleCreatePersonnel(creator PersonnelCreator) http.HandlerFunc {
 		})
 		if err != nil {
 			if errors.Is(err, pharmacy.ErrDuplicateEmail) {
-				web.AddPersonnelPage(pharmacyID, "L'email è già in uso.").Render(r.Context(), w)
+				web.AddPersonnelPage(pharmacyID, web.ErrorMessage(r.Context(), err)).Render(r.Context(), w)
 				return
 			}
-			slog.Error("creating personnel user", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "creating personnel user", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// PersonnelGetter retrieves one member of a pharmacy's personnel.
+type PersonnelGetter interface {
+	GetPersonnel(ctx context.Context, pharmacyID, userID int64) (pharmacy.PersonnelMember, error)
+}
+
+// PersonnelManager changes the status, role and credentials of personnel.
+type PersonnelManager interface {
+	DeactivatePersonnel(ctx context.Context, actorID, pharmacyID, userID int64) error
+	ReactivatePersonnel(ctx context.Context, pharmacyID, userID int64) error
+	UnlockPersonnel(ctx context.Context, pharmacyID, userID int64) error
+	ChangePersonnelRole(ctx context.Context, actorID, pharmacyID, userID int64, role string, customRoleID int64) error
+	ResetPersonnelPassword(ctx context.Context, actorID, pharmacyID, userID int64, password string) error
+	ResetPersonnelTwoFactor(ctx context.Context, actorID, pharmacyID, userID int64) error
+	RemovePersonnel(ctx context.Context, actorID, pharmacyID, userID int64) error
+}
+
+// PersonnelScoper resolves the pharmacy whose personnel a request manages:
+// the session's for owners, the path's for admins.
+type PersonnelScoper func(r *http.Request) (web.PersonnelScope, bool)
+
+// OwnerPersonnelScope scopes personnel pages to the owner's active pharmacy.
+func OwnerPersonnelScope(r *http.Request) (web.PersonnelScope, bool) {
+	return web.PersonnelScope{
+		PharmacyID: web.PharmacyID(r.Context()),
+		Base:       "/personnel",
+		Back:       "/personnel",
+	}, true
+}
+
+// AdminPersonnelScope scopes personnel pages to the pharmacy in the path.
+func AdminPersonnelScope(r *http.Request) (web.PersonnelScope, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return web.PersonnelScope{}, false
+	}
+	return web.PersonnelScope{
+		PharmacyID: id,
+		Base:       fmt.Sprintf("/admin/pharmacies/%d/personnel", id),
+		Back:       fmt.Sprintf("/admin/pharmacies/%d", id),
+	}, true
+}
+
+// HandlePersonnelMember renders a member's page with the lifecycle actions.
+func HandlePersonnelMember(scope PersonnelScoper, getter PersonnelGetter, roles CustomRoleLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, uid, ok := personnelTarget(r, scope)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		renderPersonnelMember(w, r, getter, roles, sc, uid, "", "")
+	}
+}
+
+// HandleDeactivatePersonnel disables a member's login and ends their sessions.
+func HandleDeactivatePersonnel(scope PersonnelScoper, getter PersonnelGetter, roles CustomRoleLister, manager PersonnelManager) http.HandlerFunc {
+	return personnelAction(scope, getter, roles, func(r *http.Request, sc web.PersonnelScope, uid int64) (string, error) {
+		return web.T(r.Context(), "personnel.deactivated_done"), manager.DeactivatePersonnel(r.Context(), web.UserID(r.Context()), sc.PharmacyID, uid)
+	})
+}
+
+// HandleReactivatePersonnel enables a deactivated member's login again.
+func HandleReactivatePersonnel(scope PersonnelScoper, getter PersonnelGetter, roles CustomRoleLister, manager PersonnelManager) http.HandlerFunc {
+	return personnelAction(scope, getter, roles, func(r *http.Request, sc web.PersonnelScope, uid int64) (string, error) {
+		return web.T(r.Context(), "personnel.reactivated_done"), manager.ReactivatePersonnel(r.Context(), sc.PharmacyID, uid)
+	})
+}
+
+// HandleUnlockPersonnel lifts the lock left by too many failed logins.
+func HandleUnlockPersonnel(scope PersonnelScoper, getter PersonnelGetter, roles CustomRoleLister, manager PersonnelManager) http.HandlerFunc {
+	return personnelAction(scope, getter, roles, func(r *http.Request, sc web.PersonnelScope, uid int64) (string, error) {
+		return web.T(r.Context(), "personnel.unlocked_done"), manager.UnlockPersonnel(r.Context(), sc.PharmacyID, uid)
+	})
+}
+
+// HandleChangePersonnelRole gives a member a built-in or custom role.
+func HandleChangePersonnelRole(scope PersonnelScoper, getter PersonnelGetter, roles CustomRoleLister, manager PersonnelManager) http.HandlerFunc {
+	return personnelAction(scope, getter, roles, func(r *http.Request, sc web.PersonnelScope, uid int64) (string, error) {
+		roleName, customRoleID := web.ParseRoleChoice(r.FormValue("role"))
+		return web.T(r.Context(), "personnel.role_changed"), manager.ChangePersonnelRole(r.Context(), web.UserID(r.Context()), sc.PharmacyID, uid, roleName, customRoleID)
+	})
+}
+
+// HandleResetPersonnelPassword sets a temporary password the member must
+// change at the next login.
+func HandleResetPersonnelPassword(scope PersonnelScoper, getter PersonnelGetter, roles CustomRoleLister, manager PersonnelManager) http.HandlerFunc {
+	return personnelAction(scope, getter, roles, func(r *http.Request, sc web.PersonnelScope, uid int64) (string, error) {
+		password := r.FormValue("password")
+		if password == "" {
+			return "", errMissingTemporaryPassword
+		}
+		return web.T(r.Context(), "personnel.password_reset_done"),
+			manager.ResetPersonnelPassword(r.Context(), web.UserID(r.Context()), sc.PharmacyID, uid, password)
+	})
+}
+
+// HandleResetPersonnelTwoFactor removes a member's authenticator so they can
+// enrol a new device.
+func HandleResetPersonnelTwoFactor(scope PersonnelScoper, getter PersonnelGetter, roles CustomRoleLister, manager PersonnelManager) http.HandlerFunc {
+	return personnelAction(scope, getter, roles, func(r *http.Request, sc web.PersonnelScope, uid int64) (string, error) {
+		return web.T(r.Context(), "personnel.two_factor_reset_done"),
+			manager.ResetPersonnelTwoFactor(r.Context(), web.UserID(r.Context()), sc.PharmacyID, uid)
+	})
+}
+
+// HandleRemovePersonnel deletes a member and goes back to the personnel list.
+func HandleRemovePersonnel(scope PersonnelScoper, getter PersonnelGetter, roles CustomRoleLister, manager PersonnelManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, uid, ok := personnelTarget(r, scope)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := manager.RemovePersonnel(r.Context(), web.UserID(r.Context()), sc.PharmacyID, uid); err != nil {
+			if errors.Is(err, pharmacy.ErrPersonnelNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				renderPersonnelMember(w, r, getter, roles, sc, uid, msg, "")
+				return
+			}
+			slog.ErrorContext(r.Context(), "removing personnel", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, sc.Back, http.StatusSeeOther)
+	}
+}
+
+var errMissingTemporaryPassword = errors.New("personnel.temporary_password_required")
+
+// personnelAction runs a lifecycle change and re-renders the member page
+// with its outcome.
+func personnelAction(scope PersonnelScoper, getter PersonnelGetter, roles CustomRoleLister, apply func(*http.Request, web.PersonnelScope, int64) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc, uid, ok := personnelTarget(r, scope)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		msg, err := apply(r, sc, uid)
+		if err != nil {
+			if errors.Is(err, pharmacy.ErrPersonnelNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if vmsg := web.ErrorMessage(r.Context(), err); vmsg != "" {
+				renderPersonnelMember(w, r, getter, roles, sc, uid, vmsg, "")
+				return
+			}
+			slog.ErrorContext(r.Context(), "updating personnel", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		renderPersonnelMember(w, r, getter, roles, sc, uid, "", msg)
+	}
+}
+
+func personnelTarget(r *http.Request, scope PersonnelScoper) (web.PersonnelScope, int64, bool) {
+	sc, ok := scope(r)
+	if !ok {
+		return web.PersonnelScope{}, 0, false
+	}
+	uid, err := strconv.ParseInt(r.PathValue("uid"), 10, 64)
+	if err != nil {
+		return web.PersonnelScope{}, 0, false
+	}
+	return sc, uid, true
+}
+
+func renderPersonnelMember(w http.ResponseWriter, r *http.Request, getter PersonnelGetter, roles CustomRoleLister, sc web.PersonnelScope, uid int64, errMsg, msg string) {
+	m, err := getter.GetPersonnel(r.Context(), sc.PharmacyID, uid)
+	if err != nil {
+		if errors.Is(err, pharmacy.ErrPersonnelNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		slog.ErrorContext(r.Context(), "getting personnel", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	custom, err := roles.List(r.Context(), sc.PharmacyID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing roles", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	web.PersonnelMemberPage(m, custom, sc, web.UserID(r.Context()) == m.ID, errMsg, msg).Render(r.Context(), w)
+}
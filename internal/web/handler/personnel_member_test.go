@@ -0,0 +1,286 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubPersonnelManager struct {
+	member       pharmacy.PersonnelMember
+	pharmacyID   int64
+	actorID      int64
+	userID       int64
+	action       string
+	role         string
+	customRoleID int64
+	password     string
+	err          error
+}
+
+func (s *stubPersonnelManager) GetPersonnel(_ context.Context, pharmacyID, userID int64) (pharmacy.PersonnelMember, error) {
+	if s.member.ID != userID {
+		return pharmacy.PersonnelMember{}, pharmacy.ErrPersonnelNotFound
+	}
+	return s.member, nil
+}
+
+func (s *stubPersonnelManager) record(action string, actorID, pharmacyID, userID int64) error {
+	s.action, s.actorID, s.pharmacyID, s.userID = action, actorID, pharmacyID, userID
+	return s.err
+}
+
+func (s *stubPersonnelManager) DeactivatePersonnel(_ context.Context, actorID, pharmacyID, userID int64) error {
+	return s.record("deactivate", actorID, pharmacyID, userID)
+}
+
+func (s *stubPersonnelManager) ReactivatePersonnel(_ context.Context, pharmacyID, userID int64) error {
+	return s.record("reactivate", 0, pharmacyID, userID)
+}
+
+func (s *stubPersonnelManager) UnlockPersonnel(_ context.Context, pharmacyID, userID int64) error {
+	return s.record("unlock", 0, pharmacyID, userID)
+}
+
+func (s *stubPersonnelManager) ChangePersonnelRole(_ context.Context, actorID, pharmacyID, userID int64, role string, customRoleID int64) error {
+	s.role, s.customRoleID = role, customRoleID
+	return s.record("role", actorID, pharmacyID, userID)
+}
+
+func (s *stubPersonnelManager) ResetPersonnelPassword(_ context.Context, actorID, pharmacyID, userID int64, password string) error {
+	s.password = password
+	return s.record("password", actorID, pharmacyID, userID)
+}
+
+func (s *stubPersonnelManager) ResetPersonnelTwoFactor(_ context.Context, actorID, pharmacyID, userID int64) error {
+	return s.record("2fa", actorID, pharmacyID, userID)
+}
+
+func (s *stubPersonnelManager) RemovePersonnel(_ context.Context, actorID, pharmacyID, userID int64) error {
+	return s.record("remove", actorID, pharmacyID, userID)
+}
+
+func personnelMemberTestServer(sm *scs.SessionManager, role string, m *stubPersonnelManager) *httptest.Server {
+	mux := http.NewServeMux()
+	roles := magazziniere()
+	owner := func(h http.HandlerFunc) http.Handler { return web.RequirePermission(permission.ManagePersonnel)(h) }
+	admin := func(h http.HandlerFunc) http.Handler { return web.RequirePermission(permission.ManagePharmacies)(h) }
+	mux.Handle("GET /personnel/{uid}", owner(handler.HandlePersonnelMember(handler.OwnerPersonnelScope, m, roles)))
+	mux.Handle("POST /personnel/{uid}/deactivate", owner(handler.HandleDeactivatePersonnel(handler.OwnerPersonnelScope, m, roles, m)))
+	mux.Handle("POST /personnel/{uid}/unlock", owner(handler.HandleUnlockPersonnel(handler.OwnerPersonnelScope, m, roles, m)))
+	mux.Handle("POST /personnel/{uid}/role", owner(handler.HandleChangePersonnelRole(handler.OwnerPersonnelScope, m, roles, m)))
+	mux.Handle("POST /personnel/{uid}/password", owner(handler.HandleResetPersonnelPassword(handler.OwnerPersonnelScope, m, roles, m)))
+	mux.Handle("POST /personnel/{uid}/2fa/reset", owner(handler.HandleResetPersonnelTwoFactor(handler.OwnerPersonnelScope, m, roles, m)))
+	mux.Handle("POST /personnel/{uid}/delete", owner(handler.HandleRemovePersonnel(handler.OwnerPersonnelScope, m, roles, m)))
+	mux.Handle("POST /admin/pharmacies/{id}/personnel/{uid}/reactivate", admin(handler.HandleReactivatePersonnel(handler.AdminPersonnelScope, m, roles, m)))
+	mux.Handle("POST /admin/pharmacies/{id}/personnel/{uid}/delete", admin(handler.HandleRemovePersonnel(handler.AdminPersonnelScope, m, roles, m)))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", role)
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func annaVerdi() *stubPersonnelManager {
+	return &stubPersonnelManager{member: pharmacy.PersonnelMember{ID: 3, Name: "Anna Verdi", Email: "anna@example.com", Role: "personnel", Active: true}}
+}
+
+func TestPersonnelMemberPageShowsActions(t *testing.T) {
+	srv := personnelMemberTestServer(scs.New(), "owner", annaVerdi())
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/personnel/3")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	html := string(body)
+	for _, want := range []string{"Anna Verdi", `action="/personnel/3/deactivate"`, `action="/personnel/3/role"`, `action="/personnel/3/password"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+}
+
+func TestPersonnelMemberPageUnknownReturns404(t *testing.T) {
+	srv := personnelMemberTestServer(scs.New(), "owner", annaVerdi())
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/personnel/99")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestDeactivatePersonnelUsesSessionScope(t *testing.T) {
+	m := annaVerdi()
+	srv := personnelMemberTestServer(scs.New(), "owner", m)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/personnel/3/deactivate", url.Values{})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Account disattivato.") {
+		t.Errorf("status = %d, expected the member page with a confirmation", resp.StatusCode)
+	}
+	if m.action != "deactivate" || m.actorID != 1 || m.pharmacyID != 7 || m.userID != 3 {
+		t.Errorf("got %s(actor %d, pharmacy %d, user %d), want deactivate(1, 7, 3)", m.action, m.actorID, m.pharmacyID, m.userID)
+	}
+}
+
+func TestChangePersonnelRoleLastOwnerShowsError(t *testing.T) {
+	m := annaVerdi()
+	m.err = pharmacy.ErrLastOwner
+	srv := personnelMemberTestServer(scs.New(), "owner", m)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/personnel/3/role", url.Values{"role": {"personnel"}})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "almeno un titolare attivo") {
+		t.Errorf("status = %d, expected the member page with the last-owner error", resp.StatusCode)
+	}
+}
+
+func TestChangePersonnelRoleToCustomRole(t *testing.T) {
+	m := annaVerdi()
+	srv := personnelMemberTestServer(scs.New(), "owner", m)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/personnel/3/role", url.Values{"role": {"custom:5"}})
+	defer resp.Body.Close()
+
+	if m.role != "custom" || m.customRoleID != 5 {
+		t.Errorf("role = %q/%d, want custom/5", m.role, m.customRoleID)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `<option value="custom:5"`) || !strings.Contains(string(body), "Magazziniere") {
+		t.Error("the role select should offer the pharmacy's custom roles")
+	}
+}
+
+func TestResetPersonnelPasswordRequiresPassword(t *testing.T) {
+	m := annaVerdi()
+	srv := personnelMemberTestServer(scs.New(), "owner", m)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/personnel/3/password", url.Values{"password": {""}})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "Inserisci una password temporanea.") {
+		t.Error("expected the missing password error")
+	}
+	if m.action != "" {
+		t.Error("the service must not be called without a password")
+	}
+}
+
+func TestUnlockLockedPersonnel(t *testing.T) {
+	m := annaVerdi()
+	m.member.LockedUntil = time.Now().Add(time.Hour)
+	srv := personnelMemberTestServer(scs.New(), "owner", m)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/personnel/3")
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "bloccato") || !strings.Contains(string(body), `action="/personnel/3/unlock"`) {
+		t.Error("page missing the lock badge or the unlock action")
+	}
+
+	resp = authenticatedPost(t, srv, "/personnel/3/unlock", url.Values{})
+	defer resp.Body.Close()
+	if m.action != "unlock" || m.pharmacyID != 7 || m.userID != 3 {
+		t.Errorf("got %s(pharmacy %d, user %d), want unlock(7, 3)", m.action, m.pharmacyID, m.userID)
+	}
+}
+
+func TestResetPersonnelTwoFactor(t *testing.T) {
+	m := annaVerdi()
+	m.member.TwoFactorEnabled = true
+	srv := personnelMemberTestServer(scs.New(), "owner", m)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/personnel/3")
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `action="/personnel/3/2fa/reset"`) {
+		t.Error("page missing the 2FA reset action")
+	}
+
+	resp = authenticatedPost(t, srv, "/personnel/3/2fa/reset", url.Values{})
+	defer resp.Body.Close()
+	if m.action != "2fa" || m.actorID != 1 || m.pharmacyID != 7 || m.userID != 3 {
+		t.Errorf("got %s(actor %d, pharmacy %d, user %d), want 2fa(1, 7, 3)", m.action, m.actorID, m.pharmacyID, m.userID)
+	}
+}
+
+func TestRemovePersonnelRedirectsToList(t *testing.T) {
+	m := annaVerdi()
+	srv := personnelMemberTestServer(scs.New(), "owner", m)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/personnel/3/delete", url.Values{})
+	defer resp.Body.Close()
+
+	if loc := resp.Header.Get("Location"); resp.StatusCode != http.StatusSeeOther || loc != "/personnel" {
+		t.Errorf("status = %d location = %q, want 303 to /personnel", resp.StatusCode, loc)
+	}
+	if m.action != "remove" {
+		t.Error("RemovePersonnel was not called")
+	}
+}
+
+func TestPersonnelLifecycleIsOwnerOnly(t *testing.T) {
+	m := annaVerdi()
+	srv := personnelMemberTestServer(scs.New(), "personnel", m)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/personnel/3/deactivate", url.Values{})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
+	}
+	if m.action != "" {
+		t.Error("personnel must not deactivate colleagues")
+	}
+}
+
+func TestAdminPersonnelUsesPathScope(t *testing.T) {
+	m := annaVerdi()
+	srv := personnelMemberTestServer(scs.New(), "admin", m)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/admin/pharmacies/12/personnel/3/reactivate", url.Values{})
+	resp.Body.Close()
+	if m.action != "reactivate" || m.pharmacyID != 12 {
+		t.Errorf("got %s for pharmacy %d, want reactivate for pharmacy 12", m.action, m.pharmacyID)
+	}
+
+	resp = authenticatedPost(t, srv, "/admin/pharmacies/12/personnel/3/delete", url.Values{})
+	defer resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/admin/pharmacies/12" {
+		t.Errorf("location = %q, want /admin/pharmacies/12", loc)
+	}
+}
@@ -47,15 +47,15 @@ func HandlePharmacyDetail(getter PharmacyGetter, personnel PersonnelLister) http
 				http.NotFound(w, r)
 				return
 			}
-			slog.Error("getting pharmacy", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "getting pharmacy", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
 		members, err := personnel.ListPersonnel(r.Context(), id)
 		if err != nil {
-			slog.Error("listing pharmacy personnel", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "listing pharmacy personnel", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -73,7 +73,7 @@ func HandleUpdatePharmacy(getter PharmacyGetter, personnel PersonnelLister, upda
 		}
 
 		if err := r.ParseForm(); err != nil {
-			http.Error(w, "Richiesta non valida.", http.StatusBadRequest)
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
 			return
 		}
 
@@ -85,19 +85,29 @@ func HandleUpdatePharmacy(getter PharmacyGetter, personnel PersonnelLister, upda
 		if name == "" || address == "" {
 			p, _ := getter.Get(r.Context(), id)
 			members, _ := personnel.ListPersonnel(r.Context(), id)
-			web.PharmacyDetailPage(p, members, "Nome e indirizzo sono obbligatori.").Render(r.Context(), w)
+			web.PharmacyDetailPage(p, members, web.T(r.Context(), "admin.name_address_required")).Render(r.Context(), w)
 			return
 		}
 
 		if err := updater.Update(r.Context(), pharmacy.UpdateParams{
-			ID:      id,
-			Name:    name,
-			Address: address,
-			Phone:   phone,
-			Email:   email,
+			ID:               id,
+			Name:             name,
+			Address:          address,
+			Phone:            phone,
+			Email:            email,
+			LabelLayout:      r.FormValue("label_layout"),
+			Locale:           r.FormValue("locale"),
+			GroupName:        r.FormValue("group_name"),
+			RequireTwoFactor: r.FormValue("require_2fa") == "1",
 		}); err != nil {
-			slog.Error("updating pharmacy", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			if errors.Is(err, pharmacy.ErrInvalidLabelLayout) || errors.Is(err, pharmacy.ErrInvalidLocale) {
+				p, _ := getter.Get(r.Context(), id)
+				members, _ := personnel.ListPersonnel(r.Context(), id)
+				web.PharmacyDetailPage(p, members, web.ErrorMessage(r.Context(), err)).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "updating pharmacy", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -116,7 +126,7 @@ func HandleNewPharmacyPage() http.HandlerFunc {
 func HandleCreatePharmacy(creator PharmacyCreatorWithOwner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := r.ParseForm(); err != nil {
-			web.NewPharmacyPage("Richiesta non valida.").Render(r.Context(), w)
+			web.NewPharmacyPage(web.T(r.Context(), "common.bad_request")).Render(r.Context(), w)
 			return
 		}
 
@@ -129,7 +139,7 @@ func HandleCreatePharmacy(creator PharmacyCreatorWithOwner) http.HandlerFunc {
 		ownerPassword := r.FormValue("owner_password")
 
 		if pharmacyName == "" || address == "" || ownerName == "" || ownerEmail == "" || ownerPassword == "" {
-			web.NewPharmacyPage("Tutti i campi obbligatori devono essere compilati.").Render(r.Context(), w)
+			web.NewPharmacyPage(web.T(r.Context(), "admin.fields_required")).Render(r.Context(), w)
 			return
 		}
 
@@ -144,11 +154,11 @@ func HandleCreatePharmacy(creator PharmacyCreatorWithOwner) http.HandlerFunc {
 		})
 		if err != nil {
 			if errors.Is(err, pharmacy.ErrDuplicateEmail) {
-				web.NewPharmacyPage("L'email del titolare è già in uso.").Render(r.Context(), w)
+				web.NewPharmacyPage(web.T(r.Context(), "admin.owner_email_in_use")).Render(r.Context(), w)
 				return
 			}
-			slog.Error("creating pharmacy with owner", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "creating pharmacy with owner", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
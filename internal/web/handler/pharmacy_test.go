@@ -19,6 +19,7 @@ import (
 
 type stubPharmacyGetter struct {
 	pharmacy pharmacy.Pharmacy
+	logo     []byte
 	err      error
 }
 
@@ -26,6 +27,10 @@ func (s *stubPharmacyGetter) Get(_ context.Context, _ int64) (pharmacy.Pharmacy,
 	return s.pharmacy, s.err
 }
 
+func (s *stubPharmacyGetter) Logo(_ context.Context, _ int64) ([]byte, error) {
+	return s.logo, s.err
+}
+
 type stubPharmacyUpdater struct {
 	called bool
 	params pharmacy.UpdateParams
@@ -153,10 +158,12 @@ func TestUpdatePharmacySuccessRedirects(t *testing.T) {
 	defer srv.Close()
 
 	form := url.Values{
-		"name":    {"Farmacia Nuova"},
-		"address": {"Via Milano 10"},
-		"phone":   {"999"},
-		"email":   {"new@example.com"},
+		"name":        {"Farmacia Nuova"},
+		"address":     {"Via Milano 10"},
+		"phone":       {"999"},
+		"email":       {"new@example.com"},
+		"group_name":  {"Gruppo Rossi"},
+		"require_2fa": {"1"},
 	}
 	resp := authenticatedPost(t, srv, "/admin/pharmacies/1", form)
 	defer resp.Body.Close()
@@ -173,6 +180,12 @@ func TestUpdatePharmacySuccessRedirects(t *testing.T) {
 	if updater.params.Name != "Farmacia Nuova" {
 		t.Errorf("update name = %q, want Farmacia Nuova", updater.params.Name)
 	}
+	if updater.params.GroupName != "Gruppo Rossi" {
+		t.Errorf("update group = %q, want Gruppo Rossi", updater.params.GroupName)
+	}
+	if !updater.params.RequireTwoFactor {
+		t.Error("update must require 2FA when the box is ticked")
+	}
 }
 
 func TestUpdatePharmacyMissingFieldsShowsError(t *testing.T) {
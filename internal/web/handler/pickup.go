@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// PickupDayViewer returns the pickup day view for a date.
+type PickupDayViewer interface {
+	Day(ctx context.Context, pharmacyID int64, date time.Time) (pickup.Day, error)
+}
+
+// PickupScheduleGetter returns a pharmacy's opening hours and slot settings.
+type PickupScheduleGetter interface {
+	Schedule(ctx context.Context, pharmacyID int64) (pickup.Schedule, error)
+}
+
+// PickupScheduleSaver validates and saves a pharmacy's pickup schedule.
+type PickupScheduleSaver interface {
+	SaveSchedule(ctx context.Context, pharmacyID int64, s pickup.Schedule) error
+}
+
+// PickupSlotLister returns the order and the slots it can be booked into.
+type PickupSlotLister interface {
+	GetOrder(ctx context.Context, pharmacyID, orderID int64) (pickup.Order, error)
+	AvailableSlots(ctx context.Context, pharmacyID, orderID int64, now time.Time) ([]pickup.Slot, error)
+}
+
+// PickupSlotAssigner books an order into a pickup slot.
+type PickupSlotAssigner interface {
+	Assign(ctx context.Context, pharmacyID, orderID int64, at time.Time, notify bool, now time.Time) error
+}
+
+// HandlePickupDayPage lists the pickups expected on the requested day
+// (?date=YYYY-MM-DD, today by default).
+func HandlePickupDayPage(viewer PickupDayViewer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date := pickup.WallClock(time.Now())
+		if v := r.URL.Query().Get("date"); v != "" {
+			if d, err := time.Parse("2006-01-02", v); err == nil {
+				date = d
+			}
+		}
+
+		day, err := viewer.Day(r.Context(), web.PharmacyID(r.Context()), date)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "loading pickup day", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		notifyFailed := r.URL.Query().Get("notify_failed") != ""
+		web.PickupDayPage(day, web.Can(r.Context(), permission.ManageSchedule), notifyFailed).Render(r.Context(), w)
+	}
+}
+
+// HandlePickupSettingsPage renders the opening hours and slot settings form.
+func HandlePickupSettingsPage(getter PickupScheduleGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, err := getter.Schedule(r.Context(), web.PharmacyID(r.Context()))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "getting pickup schedule", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+		web.PickupSettingsPage(s, "").Render(r.Context(), w)
+	}
+}
+
+// HandleSavePickupSettings saves the opening hours and slot settings.
+func HandleSavePickupSettings(saver PickupScheduleSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		s, err := pickupScheduleFromForm(r)
+		if err == nil {
+			err = saver.SaveSchedule(r.Context(), web.PharmacyID(r.Context()), s)
+		}
+		if err != nil {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				web.PickupSettingsPage(s, msg).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "saving pickup schedule", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/pickup", http.StatusSeeOther)
+	}
+}
+
+// pickupScheduleFromForm reads the settings form. Periods with both times
+// empty are closed; a half-filled period is invalid.
+func pickupScheduleFromForm(r *http.Request) (pickup.Schedule, error) {
+	var s pickup.Schedule
+	var errs []error
+	for _, f := range []struct {
+		name string
+		dst  *int
+		err  error
+	}{
+		{"slot_minutes", &s.Settings.SlotMinutes, pickup.ErrInvalidSlotLength},
+		{"slot_capacity", &s.Settings.SlotCapacity, pickup.ErrInvalidCapacity},
+		{"daily_capacity", &s.Settings.DailyCapacity, pickup.ErrInvalidCapacity},
+	} {
+		n, err := strconv.Atoi(strings.TrimSpace(r.FormValue(f.name)))
+		if err != nil {
+			errs = append(errs, f.err)
+		}
+		*f.dst = n
+	}
+
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		for i := range web.PickupPeriodsPerDay {
+			opens := strings.TrimSpace(r.FormValue(fmt.Sprintf("open_%d_%d", wd, i)))
+			closes := strings.TrimSpace(r.FormValue(fmt.Sprintf("close_%d_%d", wd, i)))
+			if opens == "" && closes == "" {
+				continue
+			}
+			o, err1 := parseClock(opens)
+			c, err2 := parseClock(closes)
+			if err1 != nil || err2 != nil {
+				errs = append(errs, pickup.ErrInvalidHours)
+				continue
+			}
+			s.Hours = append(s.Hours, pickup.Period{Weekday: wd, Opens: o, Closes: c})
+		}
+	}
+	return s, errors.Join(errs...)
+}
+
+// parseClock parses "HH:MM" into minutes from midnight.
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// HandlePickupAssignPage renders the slot picker for a prepared pickup order.
+func HandlePickupAssignPage(lister PickupSlotLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderPickupAssignPage(w, r, lister, "")
+	}
+}
+
+// HandleAssignPickupSlot books the order into the chosen slot, optionally
+// notifying the patient, and returns to the day view of the slot.
+func HandleAssignPickupSlot(assigner PickupSlotAssigner, lister PickupSlotLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orderID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+		at, err := time.Parse("2006-01-02T15:04", r.FormValue("slot"))
+		if err != nil {
+			renderPickupAssignPage(w, r, lister, web.T(r.Context(), "pickup.choose_slot"))
+			return
+		}
+
+		redirect := "/pickup?date=" + at.Format("2006-01-02")
+		err = assigner.Assign(r.Context(), web.PharmacyID(r.Context()), orderID, at, r.FormValue("notify") != "", pickup.WallClock(time.Now()))
+		switch {
+		case err == nil:
+		case errors.Is(err, pickup.ErrNotFound):
+			http.NotFound(w, r)
+			return
+		case errors.Is(err, pickup.ErrNotifyFailed):
+			// The booking stands; the patient has to be told by other means.
+			slog.ErrorContext(r.Context(), "notifying pickup", "order_id", orderID, "error", err)
+			redirect += "&notify_failed=1"
+		case errors.Is(err, pickup.ErrSlotUnavailable):
+			renderPickupAssignPage(w, r, lister, web.ErrorMessage(r.Context(), err))
+			return
+		case errors.Is(err, pickup.ErrOrderNotPrepared):
+			renderPickupAssignPage(w, r, lister, web.ErrorMessage(r.Context(), err))
+			return
+		case errors.Is(err, pickup.ErrNotPickupOrder):
+			renderPickupAssignPage(w, r, lister, web.ErrorMessage(r.Context(), err))
+			return
+		default:
+			slog.ErrorContext(r.Context(), "assigning pickup slot", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, redirect, http.StatusSeeOther)
+	}
+}
+
+func renderPickupAssignPage(w http.ResponseWriter, r *http.Request, lister PickupSlotLister, errMsg string) {
+	orderID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	pharmacyID := web.PharmacyID(r.Context())
+
+	o, err := lister.GetOrder(r.Context(), pharmacyID, orderID)
+	if err != nil {
+		if errors.Is(err, pickup.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		slog.ErrorContext(r.Context(), "getting pickup order", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	slots, err := lister.AvailableSlots(r.Context(), pharmacyID, orderID, pickup.WallClock(time.Now()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing pickup slots", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+
+	web.PickupAssignPage(o, slots, errMsg).Render(r.Context(), w)
+}
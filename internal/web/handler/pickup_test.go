@@ -0,0 +1,281 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+// --- Stubs ---
+
+type stubPickupService struct {
+	day        pickup.Day
+	dayFor     time.Time
+	schedule   pickup.Schedule
+	saved      *pickup.Schedule
+	saveErr    error
+	order      pickup.Order
+	getErr     error
+	slots      []pickup.Slot
+	assignErr  error
+	assignedAt time.Time
+	notify     bool
+}
+
+func (s *stubPickupService) Day(_ context.Context, _ int64, date time.Time) (pickup.Day, error) {
+	s.dayFor = date
+	return s.day, nil
+}
+
+func (s *stubPickupService) Schedule(_ context.Context, _ int64) (pickup.Schedule, error) {
+	return s.schedule, nil
+}
+
+func (s *stubPickupService) SaveSchedule(_ context.Context, _ int64, sch pickup.Schedule) error {
+	s.saved = &sch
+	return s.saveErr
+}
+
+func (s *stubPickupService) GetOrder(_ context.Context, _, _ int64) (pickup.Order, error) {
+	return s.order, s.getErr
+}
+
+func (s *stubPickupService) AvailableSlots(_ context.Context, _, _ int64, _ time.Time) ([]pickup.Slot, error) {
+	return s.slots, nil
+}
+
+func (s *stubPickupService) Assign(_ context.Context, _, _ int64, at time.Time, notify bool, _ time.Time) error {
+	s.assignedAt = at
+	s.notify = notify
+	return s.assignErr
+}
+
+func pickupTestServer(sm *scs.SessionManager, svc *stubPickupService, role string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /pickup", web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(handler.HandlePickupDayPage(svc))))
+	mux.Handle("GET /pickup/settings", web.RequirePermission(permission.ManageSchedule)(http.HandlerFunc(handler.HandlePickupSettingsPage(svc))))
+	mux.Handle("POST /pickup/settings", web.RequirePermission(permission.ManageSchedule)(http.HandlerFunc(handler.HandleSavePickupSettings(svc))))
+	mux.Handle("GET /orders/{id}/pickup", web.RequirePermission(permission.AssignPickups)(http.HandlerFunc(handler.HandlePickupAssignPage(svc))))
+	mux.Handle("POST /orders/{id}/pickup", web.RequirePermission(permission.AssignPickups)(http.HandlerFunc(handler.HandleAssignPickupSlot(svc, svc))))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", role)
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func slotAt(d, h, m int) time.Time {
+	return time.Date(2026, 1, d, h, m, 0, 0, time.UTC)
+}
+
+func TestPickupDayPageListsAppointments(t *testing.T) {
+	svc := &stubPickupService{day: pickup.Day{
+		Date:     slotAt(30, 0, 0),
+		Capacity: 2,
+		Booked:   1,
+		Slots: []pickup.DaySlot{{
+			Slot:         pickup.Slot{Start: slotAt(30, 9, 0), End: slotAt(30, 9, 30), Capacity: 2, Booked: 1},
+			Appointments: []pickup.Appointment{{OrderID: 5, PatientID: 3, FirstName: "Mario", LastName: "Rossi", MedicationName: "Eutirox", OrderStatus: "prepared", Confirmed: true}},
+		}},
+	}}
+	srv := pickupTestServer(scs.New(), svc, "personnel")
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/pickup?date=2026-01-30")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if !svc.dayFor.Equal(slotAt(30, 0, 0)) {
+		t.Errorf("day requested = %s, want 2026-01-30", svc.dayFor)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{"Venerdì 30/01/2026", "09:00–09:30", "1/2", "Mario", "Eutirox", "/orders/5/pickup"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("day page missing %q", want)
+		}
+	}
+	if strings.Contains(string(body), "/pickup/settings") {
+		t.Error("personnel should not see the settings link")
+	}
+}
+
+func TestPickupSettingsRequireOwner(t *testing.T) {
+	srv := pickupTestServer(scs.New(), &stubPickupService{}, "personnel")
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/pickup/settings")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestSavePickupSettingsParsesForm(t *testing.T) {
+	svc := &stubPickupService{}
+	srv := pickupTestServer(scs.New(), svc, "owner")
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/pickup/settings", url.Values{
+		"slot_minutes":   {"20"},
+		"slot_capacity":  {"3"},
+		"daily_capacity": {"0"},
+		"open_1_0":       {"09:00"},
+		"close_1_0":      {"12:30"},
+		"open_1_1":       {"15:00"},
+		"close_1_1":      {"19:00"},
+		"open_6_0":       {"09:00"},
+		"close_6_0":      {"12:00"},
+	})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("status = %d, want 303", resp.StatusCode)
+	}
+	if svc.saved == nil {
+		t.Fatal("expected schedule to be saved")
+	}
+	if svc.saved.Settings != (pickup.Settings{SlotMinutes: 20, SlotCapacity: 3}) {
+		t.Errorf("settings = %+v", svc.saved.Settings)
+	}
+	want := []pickup.Period{
+		{Weekday: time.Monday, Opens: 540, Closes: 750},
+		{Weekday: time.Monday, Opens: 900, Closes: 1140},
+		{Weekday: time.Saturday, Opens: 540, Closes: 720},
+	}
+	if len(svc.saved.Hours) != len(want) {
+		t.Fatalf("hours = %+v, want %+v", svc.saved.Hours, want)
+	}
+	for i := range want {
+		if svc.saved.Hours[i] != want[i] {
+			t.Errorf("hours[%d] = %+v, want %+v", i, svc.saved.Hours[i], want[i])
+		}
+	}
+}
+
+func TestSavePickupSettingsShowsValidationError(t *testing.T) {
+	svc := &stubPickupService{saveErr: pickup.ErrOverlappingHours}
+	srv := pickupTestServer(scs.New(), svc, "owner")
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/pickup/settings", url.Values{
+		"slot_minutes": {"30"}, "slot_capacity": {"2"}, "daily_capacity": {"0"},
+		"open_2_0": {"09:00"}, "close_2_0": {"13:00"},
+		"open_2_1": {"12:00"}, "close_2_1": {"19:00"},
+	})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "si sovrappongono") {
+		t.Error("expected overlap error message")
+	}
+	if !strings.Contains(string(body), `value="13:00"`) {
+		t.Error("expected submitted hours to be kept")
+	}
+}
+
+func TestSavePickupSettingsRejectsHalfPeriod(t *testing.T) {
+	svc := &stubPickupService{}
+	srv := pickupTestServer(scs.New(), svc, "owner")
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/pickup/settings", url.Values{
+		"slot_minutes": {"30"}, "slot_capacity": {"2"}, "daily_capacity": {"0"},
+		"open_3_0": {"09:00"},
+	})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if svc.saved != nil {
+		t.Error("expected schedule not to be saved")
+	}
+}
+
+func TestAssignPickupSlotRedirectsToDay(t *testing.T) {
+	svc := &stubPickupService{}
+	srv := pickupTestServer(scs.New(), svc, "personnel")
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/orders/5/pickup", url.Values{"slot": {"2026-01-30T09:30"}, "notify": {"1"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("status = %d, want 303", resp.StatusCode)
+	}
+	if loc := resp.Header.Get("Location"); loc != "/pickup?date=2026-01-30" {
+		t.Errorf("Location = %q", loc)
+	}
+	if !svc.assignedAt.Equal(slotAt(30, 9, 30)) || !svc.notify {
+		t.Errorf("assigned at %s notify=%v", svc.assignedAt, svc.notify)
+	}
+}
+
+func TestAssignPickupSlotNotifyFailure(t *testing.T) {
+	svc := &stubPickupService{assignErr: pickup.ErrNotifyFailed}
+	srv := pickupTestServer(scs.New(), svc, "personnel")
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/orders/5/pickup", url.Values{"slot": {"2026-01-30T09:30"}, "notify": {"1"}})
+	defer resp.Body.Close()
+
+	if loc := resp.Header.Get("Location"); loc != "/pickup?date=2026-01-30&notify_failed=1" {
+		t.Errorf("Location = %q", loc)
+	}
+}
+
+func TestAssignPickupSlotUnavailableShowsSlots(t *testing.T) {
+	svc := &stubPickupService{
+		assignErr: pickup.ErrSlotUnavailable,
+		order:     pickup.Order{ID: 5, FirstName: "Mario", LastName: "Rossi", Status: "prepared", Fulfillment: "pickup"},
+		slots:     []pickup.Slot{{Start: slotAt(30, 10, 0), End: slotAt(30, 10, 30), Capacity: 2}},
+	}
+	srv := pickupTestServer(scs.New(), svc, "personnel")
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/orders/5/pickup", url.Values{"slot": {"2026-01-30T09:30"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "non è più disponibile") || !strings.Contains(string(body), `value="2026-01-30T10:00"`) {
+		t.Error("expected error and remaining slots")
+	}
+	if svc.notify {
+		t.Error("notify should be off when the box is unchecked")
+	}
+}
+
+func TestPickupAssignPageNotFound(t *testing.T) {
+	svc := &stubPickupService{getErr: pickup.ErrNotFound}
+	srv := pickupTestServer(scs.New(), svc, "personnel")
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/orders/99/pickup")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/portal"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// PortalLoginRequester sends a login link or code to a patient contact.
+type PortalLoginRequester interface {
+	RequestLogin(ctx context.Context, input, baseURL string, now time.Time) (portal.Contact, error)
+}
+
+// PortalLinkVerifier consumes an email login link.
+type PortalLinkVerifier interface {
+	VerifyLink(ctx context.Context, token string, now time.Time) (portal.Patient, error)
+}
+
+// PortalCodeVerifier checks an SMS login code.
+type PortalCodeVerifier interface {
+	VerifyCode(ctx context.Context, phone, code string, now time.Time) (portal.Patient, error)
+}
+
+// PortalOverviewer returns a patient's prescriptions and open orders.
+type PortalOverviewer interface {
+	Overview(ctx context.Context, patientID int64) (portal.Overview, error)
+}
+
+// PortalPickupConfirmer accepts a proposed pickup time for a patient.
+type PortalPickupConfirmer interface {
+	ConfirmPickup(ctx context.Context, patientID, pharmacyID, orderID int64) error
+}
+
+// PortalPostponeLister lists the later slots a patient's order can move to.
+type PortalPostponeLister interface {
+	PostponeOptions(ctx context.Context, patientID, pharmacyID, orderID int64, now time.Time) (portal.Item, []pickup.Slot, error)
+}
+
+// PortalPickupPostponer moves a patient's pickup to a later slot.
+type PortalPickupPostponer interface {
+	PostponePickup(ctx context.Context, patientID, pharmacyID, orderID int64, at, now time.Time) error
+}
+
+// PortalStockReporter records the units a patient still has.
+type PortalStockReporter interface {
+	ReportStock(ctx context.Context, patientID, pharmacyID, prescriptionID int64, units int, now time.Time, lookaheadDays int) error
+}
+
+// StockReplyRecorder matches a patient's texted reply to a prescription and
+// records its stock count.
+type StockReplyRecorder interface {
+	MatchReply(ctx context.Context, from, text string) (portal.StockReply, error)
+	ReportReply(ctx context.Context, r portal.StockReply, now time.Time, lookaheadDays int) error
+}
+
+// portalDoneMessage is the confirmation shown on the portal home after an
+// action, or "" for an unknown one.
+func portalDoneMessage(ctx context.Context, done string) string {
+	switch done {
+	case "confirmed", "postponed", "reported":
+		return web.T(ctx, "portal."+done)
+	}
+	return ""
+}
+
+// HandlePortalLoginPage renders the patient login form.
+func HandlePortalLoginPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		web.PortalLoginPage("").Render(r.Context(), w)
+	}
+}
+
+// HandlePortalLoginPost sends a login link or code. The next page is the
+// same whether or not the contact belongs to a patient.
+func HandlePortalLoginPost(requester PortalLoginRequester, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			web.PortalLoginPage(web.T(r.Context(), "common.bad_request")).Render(r.Context(), w)
+			return
+		}
+
+		c, err := requester.RequestLogin(r.Context(), r.FormValue("contact"), baseURL, time.Now())
+		switch {
+		case err == nil:
+		case errors.Is(err, portal.ErrInvalidContact):
+			web.PortalLoginPage(web.T(r.Context(), "portal.invalid_contact")).Render(r.Context(), w)
+			return
+		case errors.Is(err, portal.ErrSendFailed):
+			// Showing the failure would reveal that the contact exists.
+			slog.ErrorContext(r.Context(), "sending portal login", "error", err)
+		default:
+			slog.ErrorContext(r.Context(), "requesting portal login", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		if c.Channel == portal.ChannelPhone {
+			web.PortalCodePage(r.FormValue("contact"), "").Render(r.Context(), w)
+			return
+		}
+		web.PortalLinkSentPage().Render(r.Context(), w)
+	}
+}
+
+// HandlePortalCodePost checks the SMS code and starts the patient session.
+func HandlePortalCodePost(sessions *scs.SessionManager, verifier PortalCodeVerifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+		phone := r.FormValue("phone")
+
+		p, err := verifier.VerifyCode(r.Context(), phone, r.FormValue("code"), time.Now())
+		if err != nil {
+			if errors.Is(err, portal.ErrInvalidCode) {
+				web.PortalCodePage(phone, web.T(r.Context(), "portal.invalid_code")).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "verifying portal code", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		startPortalSession(w, r, sessions, p)
+	}
+}
+
+// HandlePortalLinkPage asks the patient to confirm before the link is used.
+func HandlePortalLinkPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		web.PortalLinkConfirmPage(r.PathValue("token"), "").Render(r.Context(), w)
+	}
+}
+
+// HandlePortalLinkPost consumes the email link and starts the patient session.
+func HandlePortalLinkPost(sessions *scs.SessionManager, verifier PortalLinkVerifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := verifier.VerifyLink(r.Context(), r.PathValue("token"), time.Now())
+		if err != nil {
+			if errors.Is(err, portal.ErrInvalidLink) {
+				web.PortalLinkConfirmPage("", web.T(r.Context(), "portal.invalid_link")).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "verifying portal link", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		startPortalSession(w, r, sessions, p)
+	}
+}
+
+func startPortalSession(w http.ResponseWriter, r *http.Request, sessions *scs.SessionManager, p portal.Patient) {
+	if err := sessions.RenewToken(r.Context()); err != nil {
+		slog.ErrorContext(r.Context(), "renewing patient session token", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	sessions.Put(r.Context(), "patientID", p.ID)
+	sessions.Put(r.Context(), "pharmacyID", p.PharmacyID)
+	sessions.Put(r.Context(), "patientName", p.FirstName+" "+p.LastName)
+	http.Redirect(w, r, "/portal/", http.StatusSeeOther)
+}
+
+// HandlePortalLogout ends the patient session.
+func HandlePortalLogout(sessions *scs.SessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := sessions.Destroy(r.Context()); err != nil {
+			slog.ErrorContext(r.Context(), "destroying patient session", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/portal/login", http.StatusSeeOther)
+	}
+}
+
+// HandlePortalHome shows the patient's prescriptions and orders.
+func HandlePortalHome(overviewer PortalOverviewer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderPortalHome(w, r, overviewer, portalDoneMessage(r.Context(), r.URL.Query().Get("done")), "")
+	}
+}
+
+func renderPortalHome(w http.ResponseWriter, r *http.Request, overviewer PortalOverviewer, msg, errMsg string) {
+	ov, err := overviewer.Overview(r.Context(), web.PortalPatientID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "loading portal overview", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	web.PortalHomePage(ov, time.Now(), msg, errMsg).Render(r.Context(), w)
+}
+
+// HandlePortalConfirmPickup accepts the proposed pickup time.
+func HandlePortalConfirmPickup(confirmer PortalPickupConfirmer, overviewer PortalOverviewer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orderID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		err = confirmer.ConfirmPickup(r.Context(), web.PortalPatientID(r.Context()), web.PortalPharmacyID(r.Context()), orderID)
+		if err != nil {
+			if errors.Is(err, portal.ErrNotFound) || errors.Is(err, pickup.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				renderPortalHome(w, r, overviewer, "", msg)
+				return
+			}
+			slog.ErrorContext(r.Context(), "confirming portal pickup", "order_id", orderID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/portal/?done=confirmed", http.StatusSeeOther)
+	}
+}
+
+// HandlePortalPostponePage lists the later slots the pickup can move to.
+func HandlePortalPostponePage(lister PortalPostponeLister, overviewer PortalOverviewer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderPortalPostponePage(w, r, lister, overviewer, "")
+	}
+}
+
+// HandlePortalPostpone moves the pickup to the chosen later slot.
+func HandlePortalPostpone(postponer PortalPickupPostponer, lister PortalPostponeLister, overviewer PortalOverviewer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orderID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+		at, err := time.Parse("2006-01-02T15:04", r.FormValue("slot"))
+		if err != nil {
+			renderPortalPostponePage(w, r, lister, overviewer, web.T(r.Context(), "portal.choose_time"))
+			return
+		}
+
+		err = postponer.PostponePickup(r.Context(), web.PortalPatientID(r.Context()), web.PortalPharmacyID(r.Context()), orderID, at, pickup.WallClock(time.Now()))
+		if err != nil {
+			if errors.Is(err, portal.ErrNotFound) || errors.Is(err, pickup.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				renderPortalPostponePage(w, r, lister, overviewer, msg)
+				return
+			}
+			slog.ErrorContext(r.Context(), "postponing portal pickup", "order_id", orderID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/portal/?done=postponed", http.StatusSeeOther)
+	}
+}
+
+// renderPortalPostponePage shows the slot picker; if the order can no
+// longer be moved it falls back to the home page with the reason.
+func renderPortalPostponePage(w http.ResponseWriter, r *http.Request, lister PortalPostponeLister, overviewer PortalOverviewer, errMsg string) {
+	orderID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	it, slots, err := lister.PostponeOptions(r.Context(), web.PortalPatientID(r.Context()), web.PortalPharmacyID(r.Context()), orderID, pickup.WallClock(time.Now()))
+	if err != nil {
+		if errors.Is(err, portal.ErrNotFound) || errors.Is(err, pickup.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+			renderPortalHome(w, r, overviewer, "", msg)
+			return
+		}
+		slog.ErrorContext(r.Context(), "listing portal postpone slots", "order_id", orderID, "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+
+	web.PortalPostponePage(it, slots, errMsg).Render(r.Context(), w)
+}
+
+// HandlePortalReportStock records how many units the patient still has,
+// moving orders within the pharmacy's lookahead or defaultDays.
+func HandlePortalReportStock(reporter PortalStockReporter, overviewer PortalOverviewer, lookahead LookaheadGetter, defaultDays int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rxID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+		units, err := strconv.Atoi(r.FormValue("units"))
+		if err != nil {
+			renderPortalHome(w, r, overviewer, "", web.T(r.Context(), "portal.units_required"))
+			return
+		}
+
+		ctx := r.Context()
+		pharmacyID := web.PortalPharmacyID(ctx)
+		days := lookaheadDays(ctx, lookahead, pharmacyID, defaultDays)
+		if err := reporter.ReportStock(ctx, web.PortalPatientID(ctx), pharmacyID, rxID, units, time.Now(), days); err != nil {
+			if errors.Is(err, portal.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				renderPortalHome(w, r, overviewer, "", msg)
+				return
+			}
+			slog.ErrorContext(r.Context(), "reporting portal stock", "prescription_id", rxID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/portal/?done=reported", http.StatusSeeOther)
+	}
+}
+
+// HandleInboundReply receives a text a patient sent back to a reminder, as
+// the SMS gateway forwards it: a JSON body {"from": ..., "text": ...}. A
+// "RIMASTE <n>" count it can match to one prescription is recorded as a
+// stock report (204); any other text, and replies it cannot place, are
+// logged and refused with 422, so the gateway does not retry them.
+func HandleInboundReply(recorder StockReplyRecorder, lookahead LookaheadGetter, defaultDays int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			From string `json:"from"`
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16<<10)).Decode(&in); err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		reply, err := recorder.MatchReply(ctx, in.From, in.Text)
+		switch {
+		case errors.Is(err, portal.ErrInvalidReply), errors.Is(err, portal.ErrAmbiguousReply),
+			errors.Is(err, portal.ErrInvalidContact), errors.Is(err, portal.ErrNotFound):
+			slog.InfoContext(ctx, "inbound reply not recorded", "reason", err.Error())
+			http.Error(w, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+			return
+		case err != nil:
+			slog.ErrorContext(ctx, "matching inbound reply", "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		days := lookaheadDays(ctx, lookahead, reply.PharmacyID, defaultDays)
+		if err := recorder.ReportReply(ctx, reply, time.Now(), days); err != nil {
+			if msg := web.ErrorMessage(ctx, err); msg != "" {
+				slog.InfoContext(ctx, "inbound reply not recorded", "prescription_id", reply.PrescriptionID, "reason", err.Error())
+				http.Error(w, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+				return
+			}
+			slog.ErrorContext(ctx, "recording inbound reply", "prescription_id", reply.PrescriptionID, "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		slog.InfoContext(ctx, "inbound reply recorded", "patient_id", reply.PatientID, "prescription_id", reply.PrescriptionID, "units", reply.Units)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,386 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/pickup"
+	"github.com/giorgiovilardo/pharmarecall/internal/portal"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+// --- Stubs ---
+
+type stubPortalService struct {
+	contact     portal.Contact
+	requestErr  error
+	baseURL     string
+	patient     portal.Patient
+	verifyErr   error
+	overview    portal.Overview
+	confirmErr  error
+	confirmed   int64
+	postponeErr error
+	postponedAt time.Time
+	item        portal.Item
+	slots       []pickup.Slot
+	reportErr   error
+	reported    int
+}
+
+func (s *stubPortalService) RequestLogin(_ context.Context, _, baseURL string, _ time.Time) (portal.Contact, error) {
+	s.baseURL = baseURL
+	return s.contact, s.requestErr
+}
+
+func (s *stubPortalService) VerifyLink(_ context.Context, _ string, _ time.Time) (portal.Patient, error) {
+	return s.patient, s.verifyErr
+}
+
+func (s *stubPortalService) VerifyCode(_ context.Context, _, _ string, _ time.Time) (portal.Patient, error) {
+	return s.patient, s.verifyErr
+}
+
+func (s *stubPortalService) Overview(_ context.Context, _ int64) (portal.Overview, error) {
+	return s.overview, nil
+}
+
+func (s *stubPortalService) ConfirmPickup(_ context.Context, _, _, orderID int64) error {
+	s.confirmed = orderID
+	return s.confirmErr
+}
+
+func (s *stubPortalService) PostponeOptions(_ context.Context, _, _, _ int64, _ time.Time) (portal.Item, []pickup.Slot, error) {
+	return s.item, s.slots, nil
+}
+
+func (s *stubPortalService) PostponePickup(_ context.Context, _, _, _ int64, at, _ time.Time) error {
+	s.postponedAt = at
+	return s.postponeErr
+}
+
+func (s *stubPortalService) ReportStock(_ context.Context, _, _, _ int64, units int, _ time.Time, _ int) error {
+	s.reported = units
+	return s.reportErr
+}
+
+func portalTestServer(sm *scs.SessionManager, svc *stubPortalService) *httptest.Server {
+	mux := web.NewPortalRouter(web.PortalHandlers{
+		LoginPage:    handler.HandlePortalLoginPage(),
+		LoginPost:    handler.HandlePortalLoginPost(svc, "https://farmacia.example.it"),
+		CodePost:     handler.HandlePortalCodePost(sm, svc),
+		LinkPage:     handler.HandlePortalLinkPage(),
+		LinkPost:     handler.HandlePortalLinkPost(sm, svc),
+		Logout:       handler.HandlePortalLogout(sm),
+		Home:         handler.HandlePortalHome(svc),
+		Confirm:      handler.HandlePortalConfirmPickup(svc, svc),
+		PostponePage: handler.HandlePortalPostponePage(svc, svc),
+		Postpone:     handler.HandlePortalPostpone(svc, svc, svc),
+		ReportStock:  handler.HandlePortalReportStock(svc, svc, &stubPharmacySettings{}, 7),
+	})
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "patientID", int64(3))
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		sm.Put(r.Context(), "patientName", "Mario Rossi")
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadPatient(sm)(mux)))
+}
+
+func postForm(t *testing.T, srv *httptest.Server, path string, form url.Values) *http.Response {
+	t.Helper()
+	resp, err := noFollowClient().PostForm(srv.URL+path, form)
+	if err != nil {
+		t.Fatalf("posting %s: %v", path, err)
+	}
+	return resp
+}
+
+func TestPortalSpeaksTheBrowserLanguage(t *testing.T) {
+	srv := portalTestServer(scs.New(), &stubPortalService{requestErr: portal.ErrInvalidContact})
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/portal/login", strings.NewReader(url.Values{"contact": {"x"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9")
+	resp, err := noFollowClient().Do(req)
+	if err != nil {
+		t.Fatalf("posting login: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{`lang="de"`, "Anmeldung für Patienten", "gültige E-Mail-Adresse"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("body missing %q", want)
+		}
+	}
+}
+
+func TestPortalLoginPostNextStepByChannel(t *testing.T) {
+	tests := []struct {
+		name string
+		svc  *stubPortalService
+		want string
+	}{
+		{"email", &stubPortalService{contact: portal.Contact{Channel: portal.ChannelEmail}}, "Controlla la posta"},
+		{"phone", &stubPortalService{contact: portal.Contact{Channel: portal.ChannelPhone}}, "Inserisci il codice"},
+		{"send failure looks the same", &stubPortalService{contact: portal.Contact{Channel: portal.ChannelEmail}, requestErr: portal.ErrSendFailed}, "Controlla la posta"},
+		{"invalid contact", &stubPortalService{requestErr: portal.ErrInvalidContact}, "valido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := portalTestServer(scs.New(), tt.svc)
+			defer srv.Close()
+
+			resp := postForm(t, srv, "/portal/login", url.Values{"contact": {"3331234567"}})
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), tt.want) {
+				t.Errorf("body missing %q", tt.want)
+			}
+			if tt.svc.baseURL != "https://farmacia.example.it" {
+				t.Errorf("base URL = %q", tt.svc.baseURL)
+			}
+		})
+	}
+}
+
+func TestPortalCodeLoginStartsPatientSession(t *testing.T) {
+	sm := scs.New()
+	svc := &stubPortalService{
+		patient:  portal.Patient{ID: 3, PharmacyID: 7, FirstName: "Mario", LastName: "Rossi"},
+		overview: portal.Overview{Patient: portal.Patient{PharmacyName: "Farmacia Centrale"}},
+	}
+	srv := portalTestServer(sm, svc)
+	defer srv.Close()
+
+	resp := postForm(t, srv, "/portal/code", url.Values{"phone": {"3331234567"}, "code": {"123456"}})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/portal/" {
+		t.Fatalf("status = %d to %q, want 303 to /portal/", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/portal/", nil)
+	for _, c := range resp.Cookies() {
+		req.AddCookie(c)
+	}
+	home, err := noFollowClient().Do(req)
+	if err != nil {
+		t.Fatalf("requesting home: %v", err)
+	}
+	defer home.Body.Close()
+	body, _ := io.ReadAll(home.Body)
+	if home.StatusCode != http.StatusOK || !strings.Contains(string(body), "Mario Rossi") {
+		t.Errorf("home status = %d, expected the patient to be logged in", home.StatusCode)
+	}
+}
+
+func TestPortalCodeInvalid(t *testing.T) {
+	srv := portalTestServer(scs.New(), &stubPortalService{verifyErr: portal.ErrInvalidCode})
+	defer srv.Close()
+
+	resp := postForm(t, srv, "/portal/code", url.Values{"phone": {"3331234567"}, "code": {"000000"}})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Codice non valido") {
+		t.Errorf("status = %d, expected the code form with an error", resp.StatusCode)
+	}
+	if len(resp.Cookies()) != 0 {
+		t.Error("expected no session cookie")
+	}
+}
+
+func TestPortalHomeRequiresPatient(t *testing.T) {
+	srv := portalTestServer(scs.New(), &stubPortalService{})
+	defer srv.Close()
+
+	resp, err := noFollowClient().Get(srv.URL + "/portal/")
+	if err != nil {
+		t.Fatalf("requesting home: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/portal/login" {
+		t.Errorf("status = %d to %q, want redirect to /portal/login", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
+
+func TestPortalHomeShowsItems(t *testing.T) {
+	at := slotAt(30, 9, 30)
+	svc := &stubPortalService{overview: portal.Overview{
+		Patient: portal.Patient{PharmacyName: "Farmacia Centrale"},
+		Items: []portal.Item{{
+			PrescriptionID: 10, MedicationName: "Eutirox", UnitsPerBox: 30, DailyConsumption: 1,
+			BoxStartDate: slotAt(1, 0, 0), Fulfillment: "pickup",
+			OrderID: 20, OrderStatus: "pending", PickupAt: &at,
+		}},
+	}}
+	srv := portalTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/portal/")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{"Eutirox", "31/01/2026", "In attesa", "Ritiro proposto", "/portal/orders/20/confirm", "/portal/orders/20/postpone", "/portal/prescriptions/10/stock"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("home page missing %q", want)
+		}
+	}
+}
+
+func TestPortalConfirmPickup(t *testing.T) {
+	svc := &stubPortalService{}
+	srv := portalTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/portal/orders/20/confirm", url.Values{})
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/portal/?done=confirmed" {
+		t.Errorf("status = %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if svc.confirmed != 20 {
+		t.Errorf("confirmed order %d, want 20", svc.confirmed)
+	}
+}
+
+func TestPortalConfirmForeignOrder(t *testing.T) {
+	srv := portalTestServer(scs.New(), &stubPortalService{confirmErr: portal.ErrNotFound})
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/portal/orders/99/confirm", url.Values{})
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestPortalPostponeRejectsEarlierSlot(t *testing.T) {
+	svc := &stubPortalService{
+		postponeErr: pickup.ErrNotLater,
+		item:        portal.Item{OrderID: 20, MedicationName: "Eutirox"},
+		slots:       []pickup.Slot{{Start: slotAt(31, 10, 0)}},
+	}
+	srv := portalTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/portal/orders/20/postpone", url.Values{"slot": {"2026-01-29T10:00"}})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "successivo a quello attuale") {
+		t.Errorf("status = %d, expected the picker with an error", resp.StatusCode)
+	}
+	if !svc.postponedAt.Equal(slotAt(29, 10, 0)) {
+		t.Errorf("postponed to %s", svc.postponedAt)
+	}
+}
+
+func TestPortalReportStock(t *testing.T) {
+	svc := &stubPortalService{}
+	srv := portalTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/portal/prescriptions/10/stock", url.Values{"units": {"12"}})
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/portal/?done=reported" {
+		t.Errorf("status = %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if svc.reported != 12 {
+		t.Errorf("reported %d units, want 12", svc.reported)
+	}
+}
+
+func TestPortalReportStockRequiresNumber(t *testing.T) {
+	svc := &stubPortalService{reported: -1}
+	srv := portalTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/portal/prescriptions/10/stock", url.Values{"units": {"tante"}})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Indica quante unità") {
+		t.Errorf("status = %d, expected the home page with an error", resp.StatusCode)
+	}
+	if svc.reported != -1 {
+		t.Error("expected no report")
+	}
+}
+
+// --- Inbound reply tests ---
+
+type stubReplyRecorder struct {
+	match    portal.StockReply
+	matchErr error
+	reported *portal.StockReply
+	days     int
+}
+
+func (s *stubReplyRecorder) MatchReply(_ context.Context, _, _ string) (portal.StockReply, error) {
+	return s.match, s.matchErr
+}
+
+func (s *stubReplyRecorder) ReportReply(_ context.Context, r portal.StockReply, _ time.Time, lookaheadDays int) error {
+	s.reported = &r
+	s.days = lookaheadDays
+	return nil
+}
+
+func postReply(t *testing.T, rec *stubReplyRecorder, body string) *http.Response {
+	t.Helper()
+	srv := httptest.NewServer(handler.HandleInboundReply(rec, &stubPharmacySettings{}, 7))
+	t.Cleanup(srv.Close)
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestInboundReplyRecordsStock(t *testing.T) {
+	rec := &stubReplyRecorder{match: portal.StockReply{PatientID: 3, PharmacyID: 7, PrescriptionID: 10, Units: 12}}
+
+	resp := postReply(t, rec, `{"from": "+393331234567", "text": "RIMASTE 12"}`)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", resp.StatusCode)
+	}
+	if rec.reported == nil || rec.reported.PrescriptionID != 10 || rec.days != 7 {
+		t.Errorf("reported %+v with lookahead %d", rec.reported, rec.days)
+	}
+}
+
+func TestInboundReplyRefusesUnplacedReplies(t *testing.T) {
+	for _, err := range []error{portal.ErrInvalidReply, portal.ErrAmbiguousReply, portal.ErrNotFound} {
+		rec := &stubReplyRecorder{matchErr: err}
+		resp := postReply(t, rec, `{"from": "+393331234567", "text": "grazie"}`)
+		if resp.StatusCode != http.StatusUnprocessableEntity {
+			t.Errorf("%v: status = %d, want 422", err, resp.StatusCode)
+		}
+		if rec.reported != nil {
+			t.Errorf("%v: expected no report", err)
+		}
+	}
+
+	if resp := postReply(t, &stubReplyRecorder{}, "from=+39333"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("malformed body: status = %d, want 400", resp.StatusCode)
+	}
+}
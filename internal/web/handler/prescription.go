@@ -39,24 +39,9 @@ type PrescriptionRefiller interface {
 	RecordRefill(ctx context.Context, prescriptionID int64, newStartDate time.Time) error
 }
 
-// prescriptionValidationMessage maps domain validation errors to user-facing messages.
-func prescriptionValidationMessage(err error) string {
-	switch {
-	case errors.Is(err, prescription.ErrMedicationRequired):
-		return "Il nome del farmaco è obbligatorio."
-	case errors.Is(err, prescription.ErrInvalidUnitsPerBox):
-		return "Le unità per confezione devono essere maggiori di zero."
-	case errors.Is(err, prescription.ErrInvalidConsumption):
-		return "Il consumo giornaliero deve essere maggiore di zero."
-	case errors.Is(err, prescription.ErrStartDateRequired):
-		return "La data di inizio confezione è obbligatoria."
-	case errors.Is(err, prescription.ErrConsumptionExceedsBox):
-		return "Il consumo giornaliero deve essere inferiore alle unità per confezione."
-	case errors.Is(err, prescription.ErrNoConsensus):
-		return "Il paziente deve dare il consenso prima di aggiungere prescrizioni."
-	default:
-		return ""
-	}
+// PrescriptionStockReporter records a remaining-unit count and re-anchors pending orders.
+type PrescriptionStockReporter interface {
+	ReportStock(ctx context.Context, pharmacyID int64, p prescription.StockReportParams, now time.Time, lookaheadDays int) error
 }
 
 // parsePrescriptionForm extracts prescription fields from the request form.
@@ -83,8 +68,8 @@ func HandleNewPrescriptionPage(patientGetter PatientGetter) http.HandlerFunc {
 				http.NotFound(w, r)
 				return
 			}
-			slog.Error("getting patient", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "getting patient", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -107,7 +92,7 @@ func HandleCreatePrescription(creator PrescriptionCreator, patientGetter Patient
 		}
 
 		if err := r.ParseForm(); err != nil {
-			http.Error(w, "Richiesta non valida.", http.StatusBadRequest)
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
 			return
 		}
 
@@ -117,8 +102,8 @@ func HandleCreatePrescription(creator PrescriptionCreator, patientGetter Patient
 				http.NotFound(w, r)
 				return
 			}
-			slog.Error("getting patient", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "getting patient", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -132,12 +117,12 @@ func HandleCreatePrescription(creator PrescriptionCreator, patientGetter Patient
 			BoxStartDate:     boxStartDate,
 		})
 		if err != nil {
-			if msg := prescriptionValidationMessage(err); msg != "" {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
 				web.PrescriptionNewPage(p, msg).Render(r.Context(), w)
 				return
 			}
-			slog.Error("creating prescription", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "creating prescription", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -166,8 +151,8 @@ func HandlePrescriptionEditPage(prescriptionGetter PrescriptionGetter, patientGe
 				http.NotFound(w, r)
 				return
 			}
-			slog.Error("getting patient", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "getting patient", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -177,8 +162,8 @@ func HandlePrescriptionEditPage(prescriptionGetter PrescriptionGetter, patientGe
 				http.NotFound(w, r)
 				return
 			}
-			slog.Error("getting prescription", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "getting prescription", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -202,7 +187,7 @@ func HandleUpdatePrescription(updater PrescriptionUpdater, prescriptionGetter Pr
 		}
 
 		if err := r.ParseForm(); err != nil {
-			http.Error(w, "Richiesta non valida.", http.StatusBadRequest)
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
 			return
 		}
 
@@ -212,8 +197,8 @@ func HandleUpdatePrescription(updater PrescriptionUpdater, prescriptionGetter Pr
 				http.NotFound(w, r)
 				return
 			}
-			slog.Error("getting patient", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "getting patient", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -223,8 +208,8 @@ func HandleUpdatePrescription(updater PrescriptionUpdater, prescriptionGetter Pr
 				http.NotFound(w, r)
 				return
 			}
-			slog.Error("getting prescription", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "getting prescription", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -237,12 +222,12 @@ func HandleUpdatePrescription(updater PrescriptionUpdater, prescriptionGetter Pr
 			DailyConsumption: dailyConsumption,
 			BoxStartDate:     boxStartDate,
 		}); err != nil {
-			if msg := prescriptionValidationMessage(err); msg != "" {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
 				web.PrescriptionEditPage(p, rx, msg).Render(r.Context(), w)
 				return
 			}
-			slog.Error("updating prescription", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "updating prescription", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -266,8 +251,61 @@ func HandleRecordRefill(refiller PrescriptionRefiller) http.HandlerFunc {
 		}
 
 		if err := refiller.RecordRefill(r.Context(), rxID, time.Now().Truncate(24*time.Hour)); err != nil {
-			slog.Error("recording refill", "error", err)
-			http.Error(w, "Errore interno.", http.StatusInternalServerError)
+			slog.ErrorContext(r.Context(), "recording refill", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/patients/%d", patientID), http.StatusSeeOther)
+	}
+}
+
+// HandleReportStock records the units a patient says they still have, as
+// told at the counter. Pending orders follow the new depletion estimate,
+// within the pharmacy's lookahead or defaultDays.
+func HandleReportStock(reporter PrescriptionStockReporter, patientGetter PatientGetter, rxLister PrescriptionLister, lookahead LookaheadGetter, defaultDays int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		patientID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		rxID, err := strconv.ParseInt(r.PathValue("rxid"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		units, err := strconv.Atoi(r.FormValue("units"))
+		if err != nil {
+			units = -1
+		}
+		reportedOn, _ := time.Parse("2006-01-02", r.FormValue("reported_on"))
+
+		pharmacyID := web.PharmacyID(r.Context())
+		err = reporter.ReportStock(r.Context(), pharmacyID, prescription.StockReportParams{
+			PrescriptionID: rxID,
+			Units:          units,
+			ReportedOn:     reportedOn,
+			Source:         prescription.StockSourceStaff,
+		}, time.Now(), lookaheadDays(r.Context(), lookahead, pharmacyID, defaultDays))
+		if err != nil {
+			if errors.Is(err, prescription.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				renderPatientDetail(w, r, patientGetter, rxLister, patientID, msg)
+				return
+			}
+			slog.ErrorContext(r.Context(), "reporting stock", "prescription_id", rxID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
 			return
 		}
 
@@ -68,6 +68,20 @@ func (s *stubRxRefiller) RecordRefill(_ context.Context, prescriptionID int64, n
 	return s.err
 }
 
+type stubRxStockReporter struct {
+	pharmacyID int64
+	params     prescription.StockReportParams
+	lookahead  int
+	err        error
+}
+
+func (s *stubRxStockReporter) ReportStock(_ context.Context, pharmacyID int64, p prescription.StockReportParams, _ time.Time, lookaheadDays int) error {
+	s.pharmacyID = pharmacyID
+	s.params = p
+	s.lookahead = lookaheadDays
+	return s.err
+}
+
 // --- Prescription test server ---
 
 type rxTestDeps struct {
@@ -77,6 +91,8 @@ type rxTestDeps struct {
 	rxGetter      handler.PrescriptionGetter
 	rxUpdater     handler.PrescriptionUpdater
 	rxRefiller    handler.PrescriptionRefiller
+	rxStock       handler.PrescriptionStockReporter
+	rxLister      handler.PrescriptionLister
 }
 
 func rxTestServer(d rxTestDeps) *httptest.Server {
@@ -96,6 +112,9 @@ func rxTestServer(d rxTestDeps) *httptest.Server {
 	if d.rxRefiller != nil {
 		mux.Handle("POST /patients/{id}/prescriptions/{rxid}/refill", web.RequireAuth(http.HandlerFunc(handler.HandleRecordRefill(d.rxRefiller))))
 	}
+	if d.rxStock != nil && d.patientGetter != nil && d.rxLister != nil {
+		mux.Handle("POST /patients/{id}/prescriptions/{rxid}/stock", web.RequireAuth(http.HandlerFunc(handler.HandleReportStock(d.rxStock, d.patientGetter, d.rxLister, &stubPharmacySettings{}, 7))))
+	}
 	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
 		d.sm.Put(r.Context(), "userID", int64(1))
 		d.sm.Put(r.Context(), "role", "personnel")
@@ -413,3 +432,73 @@ func TestRecordRefillErrorReturns500(t *testing.T) {
 		t.Errorf("status = %d, want 500", resp.StatusCode)
 	}
 }
+
+// --- Stock report handler tests ---
+
+func TestReportStockAtCounterRedirects(t *testing.T) {
+	reporter := &stubRxStockReporter{}
+
+	sm := scs.New()
+	srv := rxTestServer(rxTestDeps{sm: sm, rxStock: reporter, patientGetter: &stubPatientGetter{}, rxLister: &stubPrescriptionLister{}})
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/patients/10/prescriptions/5/stock", url.Values{
+		"units":       {"18"},
+		"reported_on": {"2026-02-03"},
+	})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/patients/10" {
+		t.Errorf("status = %d to %q, want 303 to /patients/10", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	want := prescription.StockReportParams{
+		PrescriptionID: 5,
+		Units:          18,
+		ReportedOn:     time.Date(2026, 2, 3, 0, 0, 0, 0, time.UTC),
+		Source:         prescription.StockSourceStaff,
+	}
+	if reporter.params != want {
+		t.Errorf("params = %+v, want %+v", reporter.params, want)
+	}
+	if reporter.pharmacyID != 7 || reporter.lookahead != 7 {
+		t.Errorf("pharmacy = %d, lookahead = %d, want 7 and 7", reporter.pharmacyID, reporter.lookahead)
+	}
+}
+
+func TestReportStockInvalidShowsError(t *testing.T) {
+	reporter := &stubRxStockReporter{err: prescription.ErrInvalidStockUnits}
+	getter := &stubPatientGetter{patient: patient.Patient{ID: 10, PharmacyID: 7, FirstName: "Mario", LastName: "Rossi", Consensus: true}}
+
+	sm := scs.New()
+	srv := rxTestServer(rxTestDeps{sm: sm, rxStock: reporter, patientGetter: getter, rxLister: &stubPrescriptionLister{}})
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/patients/10/prescriptions/5/stock", url.Values{
+		"units":       {"-3"},
+		"reported_on": {"2026-02-03"},
+	})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "non può essere negativo") {
+		t.Errorf("status = %d, expected the patient page with an error", resp.StatusCode)
+	}
+}
+
+func TestReportStockForeignPrescriptionReturns404(t *testing.T) {
+	reporter := &stubRxStockReporter{err: prescription.ErrNotFound}
+
+	sm := scs.New()
+	srv := rxTestServer(rxTestDeps{sm: sm, rxStock: reporter, patientGetter: &stubPatientGetter{}, rxLister: &stubPrescriptionLister{}})
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/patients/10/prescriptions/5/stock", url.Values{
+		"units":       {"3"},
+		"reported_on": {"2026-02-03"},
+	})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/role"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// CustomRoleLister lists a pharmacy's custom roles.
+type CustomRoleLister interface {
+	List(ctx context.Context, pharmacyID int64) ([]role.Role, error)
+}
+
+// CustomRoleGetter retrieves one of a pharmacy's custom roles.
+type CustomRoleGetter interface {
+	Get(ctx context.Context, pharmacyID, id int64) (role.Role, error)
+}
+
+// CustomRoleSaver creates and changes custom roles.
+type CustomRoleSaver interface {
+	Create(ctx context.Context, p role.Params) (role.Role, error)
+	Update(ctx context.Context, p role.Params) error
+}
+
+// CustomRoleDeleter removes custom roles.
+type CustomRoleDeleter interface {
+	Delete(ctx context.Context, pharmacyID, id int64) error
+}
+
+// HandleRoles renders the pharmacy's custom roles next to the built-in ones.
+func HandleRoles(lister CustomRoleLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderRoles(w, r, lister, "")
+	}
+}
+
+// HandleNewRole renders the empty role form.
+func HandleNewRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		web.RoleFormPage(role.Role{}, "").Render(r.Context(), w)
+	}
+}
+
+// HandleCreateRole creates a custom role from the form.
+func HandleCreateRole(saver CustomRoleSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			web.RoleFormPage(role.Role{}, web.T(r.Context(), "common.bad_request")).Render(r.Context(), w)
+			return
+		}
+
+		p := roleParams(r, 0)
+		if _, err := saver.Create(r.Context(), p); err != nil {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				web.RoleFormPage(submittedRole(p), msg).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "creating role", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/roles", http.StatusSeeOther)
+	}
+}
+
+// HandleEditRole renders the form of an existing custom role.
+func HandleEditRole(getter CustomRoleGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		ro, err := getter.Get(r.Context(), web.PharmacyID(r.Context()), id)
+		if err != nil {
+			if errors.Is(err, role.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			slog.ErrorContext(r.Context(), "getting role", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		web.RoleFormPage(ro, "").Render(r.Context(), w)
+	}
+}
+
+// HandleUpdateRole saves the form of an existing custom role.
+func HandleUpdateRole(saver CustomRoleSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		p := roleParams(r, id)
+		if err := saver.Update(r.Context(), p); err != nil {
+			if errors.Is(err, role.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				web.RoleFormPage(submittedRole(p), msg).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "updating role", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/roles", http.StatusSeeOther)
+	}
+}
+
+// HandleDeleteRole deletes a custom role nobody holds any more.
+func HandleDeleteRole(lister CustomRoleLister, deleter CustomRoleDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := deleter.Delete(r.Context(), web.PharmacyID(r.Context()), id); err != nil {
+			if errors.Is(err, role.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if errors.Is(err, role.ErrInUse) {
+				renderRoles(w, r, lister, web.ErrorMessage(r.Context(), err))
+				return
+			}
+			slog.ErrorContext(r.Context(), "deleting role", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/roles", http.StatusSeeOther)
+	}
+}
+
+func roleParams(r *http.Request, id int64) role.Params {
+	return role.Params{
+		ID:          id,
+		PharmacyID:  web.PharmacyID(r.Context()),
+		Name:        r.FormValue("name"),
+		Permissions: r.Form["permission"],
+	}
+}
+
+// submittedRole keeps what the owner typed when the form is shown again.
+func submittedRole(p role.Params) role.Role {
+	return role.Role{ID: p.ID, Name: p.Name, Permissions: permission.FromStrings(p.Permissions)}
+}
+
+func renderRoles(w http.ResponseWriter, r *http.Request, lister CustomRoleLister, errMsg string) {
+	roles, err := lister.List(r.Context(), web.PharmacyID(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing roles", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	web.RolesPage(roles, errMsg).Render(r.Context(), w)
+}
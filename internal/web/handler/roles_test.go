@@ -0,0 +1,169 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/role"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubCustomRoles struct {
+	roles      []role.Role
+	pharmacyID int64
+	params     role.Params
+	deleted    int64
+	err        error
+}
+
+func (s *stubCustomRoles) List(_ context.Context, pharmacyID int64) ([]role.Role, error) {
+	s.pharmacyID = pharmacyID
+	return s.roles, nil
+}
+
+func (s *stubCustomRoles) Get(_ context.Context, _, id int64) (role.Role, error) {
+	for _, r := range s.roles {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return role.Role{}, role.ErrNotFound
+}
+
+func (s *stubCustomRoles) Create(_ context.Context, p role.Params) (role.Role, error) {
+	s.params = p
+	return role.Role{ID: 9}, s.err
+}
+
+func (s *stubCustomRoles) Update(_ context.Context, p role.Params) error {
+	s.params = p
+	return s.err
+}
+
+func (s *stubCustomRoles) Delete(_ context.Context, _, id int64) error {
+	s.deleted = id
+	return s.err
+}
+
+func magazziniere() *stubCustomRoles {
+	return &stubCustomRoles{roles: []role.Role{{ID: 5, PharmacyID: 7, Name: "Magazziniere", Permissions: permission.Set{permission.ViewOrders, permission.ViewShipping}, Holders: 2}}}
+}
+
+func rolesTestServer(sm *scs.SessionManager, userRole string, roles *stubCustomRoles) *httptest.Server {
+	mux := http.NewServeMux()
+	guard := func(h http.HandlerFunc) http.Handler { return web.RequirePermission(permission.ManageRoles)(h) }
+	mux.Handle("GET /roles", guard(handler.HandleRoles(roles)))
+	mux.Handle("POST /roles", guard(handler.HandleCreateRole(roles)))
+	mux.Handle("GET /roles/{id}", guard(handler.HandleEditRole(roles)))
+	mux.Handle("POST /roles/{id}", guard(handler.HandleUpdateRole(roles)))
+	mux.Handle("POST /roles/{id}/delete", guard(handler.HandleDeleteRole(roles, roles)))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", userRole)
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func TestRolesPageListsCustomAndBuiltinRoles(t *testing.T) {
+	roles := magazziniere()
+	srv := rolesTestServer(scs.New(), "owner", roles)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/roles")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	html := string(body)
+	for _, want := range []string{"Magazziniere", "Vedere le spedizioni", "Tirocinante", "Autista consegne", "Farmacista responsabile"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if roles.pharmacyID != 7 {
+		t.Errorf("pharmacyID = %d, want 7", roles.pharmacyID)
+	}
+}
+
+func TestRolesAreOwnerOnly(t *testing.T) {
+	for _, userRole := range []string{"pharmacist", "personnel", "trainee"} {
+		srv := rolesTestServer(scs.New(), userRole, magazziniere())
+		resp := authenticatedGet(t, srv, "/roles")
+		resp.Body.Close()
+		srv.Close()
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want 403", userRole, resp.StatusCode)
+		}
+	}
+}
+
+func TestCreateRoleRedirects(t *testing.T) {
+	roles := magazziniere()
+	srv := rolesTestServer(scs.New(), "owner", roles)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/roles", url.Values{"name": {"Cassa"}, "permission": {"orders.view", "orders.advance"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/roles" {
+		t.Errorf("status = %d, location = %q, want 303 to /roles", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if roles.params.PharmacyID != 7 || roles.params.Name != "Cassa" || len(roles.params.Permissions) != 2 {
+		t.Errorf("params = %+v", roles.params)
+	}
+}
+
+func TestUpdateRoleValidationKeepsForm(t *testing.T) {
+	roles := magazziniere()
+	roles.err = role.ErrNoPermissions
+	srv := rolesTestServer(scs.New(), "owner", roles)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/roles/5", url.Values{"name": {"Magazzino"}})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "almeno un permesso") || !strings.Contains(string(body), `value="Magazzino"`) {
+		t.Errorf("expected the form with the error and the submitted name, got: %s", body)
+	}
+	if roles.params.ID != 5 {
+		t.Errorf("updated role = %d, want 5", roles.params.ID)
+	}
+}
+
+func TestDeleteRoleInUseShowsError(t *testing.T) {
+	roles := magazziniere()
+	roles.err = role.ErrInUse
+	srv := rolesTestServer(scs.New(), "owner", roles)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/roles/5/delete", url.Values{})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "ancora assegnato") {
+		t.Errorf("status = %d, expected the roles page with the in-use error", resp.StatusCode)
+	}
+}
+
+func TestEditUnknownRoleReturns404(t *testing.T) {
+	srv := rolesTestServer(scs.New(), "owner", magazziniere())
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/roles/99")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// OrderReferenceVerifier decodes a scanned label reference into an order ID.
+type OrderReferenceVerifier interface {
+	Verify(ref string) (int64, error)
+}
+
+// HandleScanPage renders the scan form used with a USB barcode scanner.
+func HandleScanPage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		web.ScanPage("", "").Render(r.Context(), w)
+	}
+}
+
+// HandleScanPost looks up the order encoded in a scanned label and advances
+// it to the next status. The page is re-rendered with the outcome so staff
+// can keep scanning bags without touching the mouse.
+func HandleScanPost(verifier OrderReferenceVerifier, lister DashboardLister, advancer OrderStatusAdvancer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			web.ScanPage(web.T(r.Context(), "common.bad_request"), "").Render(r.Context(), w)
+			return
+		}
+
+		orderID, err := verifier.Verify(r.FormValue("ref"))
+		if err != nil {
+			web.ScanPage(web.T(r.Context(), "order.invalid_reference"), "").Render(r.Context(), w)
+			return
+		}
+
+		pharmacyID := web.PharmacyID(r.Context())
+
+		entries, err := lister.ListDashboard(r.Context(), pharmacyID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "listing dashboard for scan", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		var found *order.DashboardEntry
+		for _, e := range entries {
+			if e.OrderID == orderID {
+				found = &e
+				break
+			}
+		}
+		if found == nil {
+			web.ScanPage(web.T(r.Context(), "scan.order_not_found"), "").Render(r.Context(), w)
+			return
+		}
+
+		if err := advancer.AdvanceStatus(r.Context(), orderID, time.Now().Truncate(24*time.Hour)); err != nil {
+			if errors.Is(err, order.ErrInvalidTransition) {
+				web.ScanPage(web.T(r.Context(), "scan.already_fulfilled", found.FirstName, found.LastName, found.MedicationName), "").Render(r.Context(), w)
+				return
+			}
+			if errors.Is(err, permission.ErrForbidden) {
+				web.ScanPage(web.T(r.Context(), "scan.forbidden"), "").Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "advancing scanned order", "orderID", orderID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		msg := web.T(r.Context(), "scan.advanced", found.FirstName, found.LastName, found.MedicationName, web.OrderStatusLabel(r.Context(), order.NextStatus(found.OrderStatus)))
+		web.ScanPage("", msg).Render(r.Context(), w)
+	}
+}
@@ -0,0 +1,137 @@
+package handler_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+func scanTestServer(sm *scs.SessionManager, lister handler.DashboardLister, advancer handler.OrderStatusAdvancer) *httptest.Server {
+	signer := order.NewReferenceSigner("test-secret")
+	mux := http.NewServeMux()
+	mux.Handle("GET /scan", web.RequirePermission(permission.AdvanceOrders)(http.HandlerFunc(handler.HandleScanPage())))
+	mux.Handle("POST /scan", web.RequirePermission(permission.AdvanceOrders)(http.HandlerFunc(handler.HandleScanPost(signer, lister, advancer))))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", "personnel")
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func TestScanPageRendersForm(t *testing.T) {
+	srv := scanTestServer(scs.New(), &stubDashboardLister{}, &stubOrderAdvancer{})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/scan")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `name="ref"`) {
+		t.Error("scan page missing ref input")
+	}
+}
+
+func TestScanPostAdvancesOrder(t *testing.T) {
+	lister := &stubDashboardLister{result: []order.DashboardEntry{
+		{OrderID: 5, MedicationName: "Tachipirina", FirstName: "Mario", LastName: "Rossi", OrderStatus: order.StatusPending},
+	}}
+	advancer := &stubOrderAdvancer{}
+	srv := scanTestServer(scs.New(), lister, advancer)
+	defer srv.Close()
+
+	ref := order.NewReferenceSigner("test-secret").Sign(5)
+	resp := authenticatedPost(t, srv, "/scan", url.Values{"ref": {ref}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if !advancer.called {
+		t.Fatal("AdvanceStatus was not called")
+	}
+	if advancer.orderID != 5 {
+		t.Errorf("orderID = %d, want 5", advancer.orderID)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	bodyStr := string(body)
+	for _, want := range []string{"Mario", "Tachipirina", "Preparato"} {
+		if !strings.Contains(bodyStr, want) {
+			t.Errorf("body missing %q", want)
+		}
+	}
+}
+
+func TestScanPostRejectsForgedReference(t *testing.T) {
+	lister := &stubDashboardLister{result: []order.DashboardEntry{{OrderID: 5, OrderStatus: order.StatusPending}}}
+	advancer := &stubOrderAdvancer{}
+	srv := scanTestServer(scs.New(), lister, advancer)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/scan", url.Values{"ref": {"PR-5-AAAAAAAAAA"}})
+	defer resp.Body.Close()
+
+	if advancer.called {
+		t.Error("AdvanceStatus should not be called for a forged reference")
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "Codice non riconosciuto") {
+		t.Error("body missing unrecognised code message")
+	}
+}
+
+func TestScanPostOrderFromOtherPharmacyNotFound(t *testing.T) {
+	// The lister is scoped to the session pharmacy, so an order from another
+	// pharmacy never shows up even with a valid signature.
+	lister := &stubDashboardLister{result: nil}
+	advancer := &stubOrderAdvancer{}
+	srv := scanTestServer(scs.New(), lister, advancer)
+	defer srv.Close()
+
+	ref := order.NewReferenceSigner("test-secret").Sign(99)
+	resp := authenticatedPost(t, srv, "/scan", url.Values{"ref": {ref}})
+	defer resp.Body.Close()
+
+	if advancer.called {
+		t.Error("AdvanceStatus should not be called for an order outside the pharmacy")
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "Ordine non trovato") {
+		t.Error("body missing not found message")
+	}
+}
+
+func TestScanPostFulfilledOrderShowsMessage(t *testing.T) {
+	lister := &stubDashboardLister{result: []order.DashboardEntry{
+		{OrderID: 5, MedicationName: "Tachipirina", FirstName: "Mario", LastName: "Rossi", OrderStatus: order.StatusFulfilled},
+	}}
+	advancer := &stubOrderAdvancer{err: order.ErrInvalidTransition}
+	srv := scanTestServer(scs.New(), lister, advancer)
+	defer srv.Close()
+
+	ref := order.NewReferenceSigner("test-secret").Sign(5)
+	resp := authenticatedPost(t, srv, "/scan", url.Values{"ref": {ref}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "già evaso") {
+		t.Error("body missing already fulfilled message")
+	}
+}
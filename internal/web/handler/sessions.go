@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// UserSessionManager lists and ends the current user's sessions.
+type UserSessionManager interface {
+	Sessions(ctx context.Context, userID int64, currentToken string) ([]user.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID int64) error
+	RevokeOtherSessions(ctx context.Context, userID int64, keepToken string) (int, error)
+}
+
+// PharmacySessionRevoker logs out every member of a pharmacy.
+type PharmacySessionRevoker interface {
+	RevokeSessions(ctx context.Context, pharmacyID int64) (int, error)
+}
+
+// HandleSessionsPage lists the devices the user is logged in on.
+func HandleSessionsPage(sessions *scs.SessionManager, manager UserSessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderSessions(w, r, sessions, manager, "", "")
+	}
+}
+
+// HandleRevokeSession logs the user out of one of their other devices.
+func HandleRevokeSession(sessions *scs.SessionManager, manager UserSessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := manager.RevokeSession(r.Context(), web.UserID(r.Context()), id); err != nil {
+			if errors.Is(err, user.ErrSessionNotFound) {
+				renderSessions(w, r, sessions, manager, "", web.T(r.Context(), "sessions.not_found"))
+				return
+			}
+			slog.ErrorContext(r.Context(), "revoking session", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		renderSessions(w, r, sessions, manager, web.T(r.Context(), "sessions.revoked"), "")
+	}
+}
+
+// HandleRevokeOtherSessions logs the user out everywhere but here.
+func HandleRevokeOtherSessions(sessions *scs.SessionManager, manager UserSessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n, err := manager.RevokeOtherSessions(r.Context(), web.UserID(r.Context()), sessions.Token(r.Context()))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "revoking other sessions", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		renderSessions(w, r, sessions, manager, web.T(r.Context(), "sessions.revoked_others", n), "")
+	}
+}
+
+func renderSessions(w http.ResponseWriter, r *http.Request, sessions *scs.SessionManager, manager UserSessionManager, msg, errMsg string) {
+	list, err := manager.Sessions(r.Context(), web.UserID(r.Context()), sessions.Token(r.Context()))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing sessions", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	web.SessionsPage(list, msg, errMsg).Render(r.Context(), w)
+}
+
+// HandleRevokePharmacySessions logs out the whole staff of a pharmacy, for
+// admins reacting to a compromised account or device.
+func HandleRevokePharmacySessions(revoker PharmacySessionRevoker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		n, err := revoker.RevokeSessions(r.Context(), id)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "revoking pharmacy sessions", "pharmacyID", id, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+		slog.InfoContext(r.Context(), "revoked pharmacy sessions", "pharmacyID", id, "sessions", n, "adminID", web.UserID(r.Context()))
+
+		http.Redirect(w, r, fmt.Sprintf("/admin/pharmacies/%d", id), http.StatusSeeOther)
+	}
+}
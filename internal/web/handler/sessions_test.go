@@ -0,0 +1,142 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubSessionManager struct {
+	sessions  []user.Session
+	listToken string
+	revokedID int64
+	keptToken string
+	err       error
+}
+
+func (s *stubSessionManager) Sessions(_ context.Context, _ int64, currentToken string) ([]user.Session, error) {
+	s.listToken = currentToken
+	return s.sessions, nil
+}
+
+func (s *stubSessionManager) RevokeSession(_ context.Context, _, sessionID int64) error {
+	s.revokedID = sessionID
+	return s.err
+}
+
+func (s *stubSessionManager) RevokeOtherSessions(_ context.Context, _ int64, keepToken string) (int, error) {
+	s.keptToken = keepToken
+	return 2, s.err
+}
+
+func sessionsTestServer(sm *scs.SessionManager, manager *stubSessionManager) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /account/sessions", handler.HandleSessionsPage(sm, manager))
+	mux.HandleFunc("POST /account/sessions/{id}/revoke", handler.HandleRevokeSession(sm, manager))
+	mux.HandleFunc("POST /account/sessions/revoke-others", handler.HandleRevokeOtherSessions(sm, manager))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", "personnel")
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func TestSessionsPageMarksCurrentSession(t *testing.T) {
+	manager := &stubSessionManager{sessions: []user.Session{
+		{ID: 1, Device: user.Device{IP: "192.0.2.10", UserAgent: "Firefox/140"}, CreatedAt: time.Now(), LastSeenAt: time.Now(), Current: true},
+		{ID: 2, Device: user.Device{IP: "198.51.100.7", UserAgent: "Safari/18"}, CreatedAt: time.Now(), LastSeenAt: time.Now()},
+	}}
+	srv := sessionsTestServer(scs.New(), manager)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/account/sessions")
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	html := string(b)
+	for _, want := range []string{"Firefox/140", "198.51.100.7", "questa sessione", "/account/sessions/2/revoke", "Esci da tutte le altre sessioni"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+	if strings.Contains(html, "/account/sessions/1/revoke") {
+		t.Error("the current session must not offer a revoke button")
+	}
+	if manager.listToken == "" {
+		t.Error("expected the current session token to be passed")
+	}
+}
+
+func TestRevokeSessionNotFoundShowsError(t *testing.T) {
+	manager := &stubSessionManager{err: user.ErrSessionNotFound}
+	srv := sessionsTestServer(scs.New(), manager)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/account/sessions/9/revoke", nil)
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	if manager.revokedID != 9 {
+		t.Errorf("revoked session %d, want 9", manager.revokedID)
+	}
+	if !strings.Contains(string(b), "Sessione non trovata") {
+		t.Error("expected the not found message")
+	}
+}
+
+func TestRevokeOtherSessionsKeepsCurrent(t *testing.T) {
+	manager := &stubSessionManager{}
+	srv := sessionsTestServer(scs.New(), manager)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/account/sessions/revoke-others", nil)
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	if manager.keptToken == "" || manager.keptToken != manager.listToken {
+		t.Errorf("kept token %q, want the current session token", manager.keptToken)
+	}
+	if !strings.Contains(string(b), "Sessioni terminate: 2.") {
+		t.Error("expected the revoked sessions count")
+	}
+}
+
+type stubPharmacySessionRevoker struct {
+	pharmacyID int64
+}
+
+func (s *stubPharmacySessionRevoker) RevokeSessions(_ context.Context, pharmacyID int64) (int, error) {
+	s.pharmacyID = pharmacyID
+	return 3, nil
+}
+
+func TestRevokePharmacySessionsRedirectsToPharmacy(t *testing.T) {
+	revoker := &stubPharmacySessionRevoker{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /admin/pharmacies/{id}/sessions/revoke", handler.HandleRevokePharmacySessions(revoker))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := noFollowClient().Post(srv.URL+"/admin/pharmacies/7/sessions/revoke", "", nil)
+	if err != nil {
+		t.Fatalf("posting: %v", err)
+	}
+	resp.Body.Close()
+
+	if revoker.pharmacyID != 7 {
+		t.Errorf("revoked sessions of pharmacy %d, want 7", revoker.pharmacyID)
+	}
+	if loc := resp.Header.Get("Location"); resp.StatusCode != http.StatusSeeOther || loc != "/admin/pharmacies/7" {
+		t.Errorf("response = %d %q, want 303 to /admin/pharmacies/7", resp.StatusCode, loc)
+	}
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// PharmacySettingsGetter retrieves the settings an owner manages.
+type PharmacySettingsGetter interface {
+	Settings(ctx context.Context, pharmacyID int64) (pharmacy.Settings, error)
+}
+
+// PharmacySettingsUpdater saves the settings an owner manages.
+type PharmacySettingsUpdater interface {
+	UpdateSettings(ctx context.Context, s pharmacy.Settings) error
+}
+
+// LogoGetter retrieves the logo printed on a pharmacy's labels.
+type LogoGetter interface {
+	Logo(ctx context.Context, pharmacyID int64) ([]byte, error)
+}
+
+// LogoSetter replaces or removes the logo printed on a pharmacy's labels.
+type LogoSetter interface {
+	SetLogo(ctx context.Context, pharmacyID int64, data []byte) error
+	RemoveLogo(ctx context.Context, pharmacyID int64) error
+}
+
+// LookaheadGetter resolves how many days ahead a pharmacy prepares orders,
+// falling back to the server default.
+type LookaheadGetter interface {
+	LookaheadDays(ctx context.Context, pharmacyID int64, fallback int) (int, error)
+}
+
+// lookaheadDays resolves the pharmacy's lookahead. A failure is logged and
+// falls back to the server default, so orders are still generated.
+func lookaheadDays(ctx context.Context, lookahead LookaheadGetter, pharmacyID int64, fallback int) int {
+	days, err := lookahead.LookaheadDays(ctx, pharmacyID, fallback)
+	if err != nil {
+		slog.ErrorContext(ctx, "getting lookahead days", "pharmacy_id", pharmacyID, "error", err)
+		return fallback
+	}
+	return days
+}
+
+// HandleSettingsPage renders the pharmacy settings of the signed-in owner.
+func HandleSettingsPage(getter PharmacySettingsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, err := getter.Settings(r.Context(), web.PharmacyID(r.Context()))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "getting pharmacy settings", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		msg := ""
+		if r.URL.Query().Get("saved") != "" {
+			msg = web.T(r.Context(), "settings.saved")
+		}
+		web.SettingsPage(s, msg, "").Render(r.Context(), w)
+	}
+}
+
+// HandleUpdateSettings saves the pharmacy settings form.
+func HandleUpdateSettings(getter PharmacySettingsGetter, updater PharmacySettingsUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		pharmacyID := web.PharmacyID(r.Context())
+		current, err := getter.Settings(r.Context(), pharmacyID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "getting pharmacy settings", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		s := pharmacy.Settings{
+			PharmacyID:         pharmacyID,
+			Address:            r.FormValue("address"),
+			Phone:              r.FormValue("phone"),
+			Email:              r.FormValue("email"),
+			VATNumber:          r.FormValue("vat_number"),
+			DefaultFulfillment: r.FormValue("default_fulfillment"),
+			PickupReminder:     r.FormValue("pickup_reminder"),
+			HasLogo:            current.HasLogo,
+		}
+		days, err := strconv.Atoi(r.FormValue("lookahead_days"))
+		if err != nil {
+			web.SettingsPage(s, "", web.ErrorMessage(r.Context(), pharmacy.ErrInvalidLookahead)).Render(r.Context(), w)
+			return
+		}
+		s.LookaheadDays = days
+
+		if err := updater.UpdateSettings(r.Context(), s); err != nil {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				web.SettingsPage(s, "", msg).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "updating pharmacy settings", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/settings?saved=1", http.StatusSeeOther)
+	}
+}
+
+// HandleUploadLogo replaces the logo printed on labels with the uploaded
+// image.
+func HandleUploadLogo(getter PharmacySettingsGetter, setter LogoSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pharmacyID := web.PharmacyID(r.Context())
+		data, err := readLogo(w, r)
+		if err == nil {
+			err = setter.SetLogo(r.Context(), pharmacyID, data)
+		}
+		if err != nil {
+			msg := web.ErrorMessage(r.Context(), err)
+			if msg == "" {
+				slog.ErrorContext(r.Context(), "setting pharmacy logo", "error", err)
+				http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+				return
+			}
+			s, gerr := getter.Settings(r.Context(), pharmacyID)
+			if gerr != nil {
+				slog.ErrorContext(r.Context(), "getting pharmacy settings", "error", gerr)
+				http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+				return
+			}
+			web.SettingsPage(s, "", msg).Render(r.Context(), w)
+			return
+		}
+
+		http.Redirect(w, r, "/settings?saved=1", http.StatusSeeOther)
+	}
+}
+
+// HandleRemoveLogo stops printing a logo on labels.
+func HandleRemoveLogo(setter LogoSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := setter.RemoveLogo(r.Context(), web.PharmacyID(r.Context())); err != nil {
+			slog.ErrorContext(r.Context(), "removing pharmacy logo", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/settings?saved=1", http.StatusSeeOther)
+	}
+}
+
+// HandleLogo serves the pharmacy's logo for printed labels and the settings
+// preview.
+func HandleLogo(getter LogoGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := getter.Logo(r.Context(), web.PharmacyID(r.Context()))
+		if err != nil {
+			if errors.Is(err, pharmacy.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			slog.ErrorContext(r.Context(), "getting pharmacy logo", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+		if data == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Cache-Control", "private, no-cache")
+		w.Write(data)
+	}
+}
+
+// readLogo reads the "logo" file of a multipart upload, refusing anything
+// larger than pharmacy.MaxLogoBytes.
+func readLogo(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	// Leave room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, pharmacy.MaxLogoBytes+64<<10)
+	file, _, err := r.FormFile("logo")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, pharmacy.ErrLogoTooLarge
+		}
+		return nil, pharmacy.ErrInvalidLogo
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, pharmacy.MaxLogoBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > pharmacy.MaxLogoBytes {
+		return nil, pharmacy.ErrLogoTooLarge
+	}
+	return data, nil
+}
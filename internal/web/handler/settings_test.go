@@ -0,0 +1,214 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubPharmacySettings struct {
+	settings pharmacy.Settings
+	saved    *pharmacy.Settings
+	logo     []byte
+	removed  bool
+	err      error
+}
+
+func (s *stubPharmacySettings) Settings(_ context.Context, pharmacyID int64) (pharmacy.Settings, error) {
+	st := s.settings
+	st.PharmacyID = pharmacyID
+	return st, nil
+}
+
+func (s *stubPharmacySettings) UpdateSettings(_ context.Context, st pharmacy.Settings) error {
+	s.saved = &st
+	return s.err
+}
+
+func (s *stubPharmacySettings) Logo(_ context.Context, _ int64) ([]byte, error) {
+	return s.logo, nil
+}
+
+func (s *stubPharmacySettings) SetLogo(_ context.Context, _ int64, data []byte) error {
+	s.logo = data
+	return s.err
+}
+
+func (s *stubPharmacySettings) RemoveLogo(_ context.Context, _ int64) error {
+	s.removed = true
+	return s.err
+}
+
+func (s *stubPharmacySettings) LookaheadDays(_ context.Context, _ int64, fallback int) (int, error) {
+	return s.settings.Lookahead(fallback), nil
+}
+
+func settingsTestServer(sm *scs.SessionManager, userRole string, settings *stubPharmacySettings) *httptest.Server {
+	mux := http.NewServeMux()
+	guard := func(h http.HandlerFunc) http.Handler { return web.RequirePermission(permission.ManageSettings)(h) }
+	mux.Handle("GET /settings", guard(handler.HandleSettingsPage(settings)))
+	mux.Handle("POST /settings", guard(handler.HandleUpdateSettings(settings, settings)))
+	mux.Handle("POST /settings/logo", guard(handler.HandleUploadLogo(settings, settings)))
+	mux.Handle("POST /settings/logo/delete", guard(handler.HandleRemoveLogo(settings)))
+	mux.Handle("GET /settings/logo", web.RequirePermission(permission.ViewOrders)(handler.HandleLogo(settings)))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", userRole)
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func TestSettingsPageShowsDefaultReminder(t *testing.T) {
+	sm := scs.New()
+	srv := settingsTestServer(sm, "owner", &stubPharmacySettings{settings: pharmacy.Settings{Address: "Via Roma 1", VATNumber: "12345678903"}})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/settings?saved=1")
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{"Via Roma 1", "12345678903", "è pronto per il ritiro", "{farmacia}", "Impostazioni salvate.", `href="/pickup/settings"`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("body missing %q", want)
+		}
+	}
+}
+
+func TestSettingsAreForOwnersOnly(t *testing.T) {
+	sm := scs.New()
+	srv := settingsTestServer(sm, "personnel", &stubPharmacySettings{})
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/settings")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestUpdateSettingsSavesForm(t *testing.T) {
+	settings := &stubPharmacySettings{settings: pharmacy.Settings{HasLogo: true}}
+	sm := scs.New()
+	srv := settingsTestServer(sm, "owner", settings)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/settings", url.Values{
+		"address":             {"Via Roma 1"},
+		"vat_number":          {"IT12345678903"},
+		"default_fulfillment": {"shipping"},
+		"lookahead_days":      {"14"},
+		"pickup_reminder":     {"Ciao {nome}"},
+	})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/settings?saved=1" {
+		t.Fatalf("status = %d, location = %q, want 303 to /settings?saved=1", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	got := settings.saved
+	if got == nil || got.PharmacyID != 7 || got.LookaheadDays != 14 || got.DefaultFulfillment != "shipping" || got.PickupReminder != "Ciao {nome}" || !got.HasLogo {
+		t.Errorf("saved = %+v", got)
+	}
+}
+
+func TestUpdateSettingsShowsValidationError(t *testing.T) {
+	settings := &stubPharmacySettings{err: pharmacy.ErrInvalidVATNumber}
+	sm := scs.New()
+	srv := settingsTestServer(sm, "owner", settings)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/settings", url.Values{
+		"address":        {"Via Roma 1"},
+		"vat_number":     {"12345678900"},
+		"lookahead_days": {"0"},
+	})
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Partita IVA non valida") || !strings.Contains(string(body), "12345678900") {
+		t.Errorf("status = %d, want the form again with the error and the submitted values", resp.StatusCode)
+	}
+}
+
+func TestUploadLogo(t *testing.T) {
+	settings := &stubPharmacySettings{}
+	sm := scs.New()
+	srv := settingsTestServer(sm, "owner", settings)
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("logo", "logo.png")
+	fw.Write([]byte("png bytes"))
+	mw.Close()
+
+	client := noFollowClient()
+	setup, err := client.Get(srv.URL + "/setup-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup.Body.Close()
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/settings/logo", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	for _, c := range setup.Cookies() {
+		req.AddCookie(c)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || string(settings.logo) != "png bytes" {
+		t.Errorf("status = %d, logo = %q", resp.StatusCode, settings.logo)
+	}
+}
+
+func TestLogoIsServedOrNotFound(t *testing.T) {
+	settings := &stubPharmacySettings{}
+	sm := scs.New()
+	srv := settingsTestServer(sm, "personnel", settings)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/settings/logo")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without a logo", resp.StatusCode)
+	}
+
+	settings.logo = []byte("jpeg")
+	resp = authenticatedGet(t, srv, "/settings/logo")
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.Header.Get("Content-Type") != "image/jpeg" || string(body) != "jpeg" {
+		t.Errorf("content type = %q, body = %q", resp.Header.Get("Content-Type"), body)
+	}
+}
+
+func TestRemoveLogo(t *testing.T) {
+	settings := &stubPharmacySettings{}
+	sm := scs.New()
+	srv := settingsTestServer(sm, "owner", settings)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/settings/logo/delete", url.Values{})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || !settings.removed {
+		t.Errorf("status = %d, removed = %v", resp.StatusCode, settings.removed)
+	}
+}
@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/shipping"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// ShippableOrderLister lists prepared shipping orders not yet in a batch.
+type ShippableOrderLister interface {
+	ListShippable(ctx context.Context, pharmacyID int64) ([]shipping.ShippableOrder, error)
+}
+
+// ShippingBatchLister lists the shipping batches of a pharmacy.
+type ShippingBatchLister interface {
+	ListBatches(ctx context.Context, pharmacyID int64) ([]shipping.Batch, error)
+}
+
+// ShippingBatchCreator groups prepared orders into a new batch.
+type ShippingBatchCreator interface {
+	CreateBatch(ctx context.Context, pharmacyID int64, orderIDs []int64) (shipping.Batch, error)
+}
+
+// ShippingBatchGetter retrieves a batch and its shipments.
+type ShippingBatchGetter interface {
+	GetBatch(ctx context.Context, pharmacyID, batchID int64) (shipping.Batch, []shipping.Shipment, error)
+}
+
+// ShipmentTrackingSetter records courier tracking numbers for a batch.
+type ShipmentTrackingSetter interface {
+	SetTracking(ctx context.Context, pharmacyID, batchID int64, tracking map[int64]string) error
+}
+
+// ShippingBatchShipper marks a batch as handed to the courier.
+type ShippingBatchShipper interface {
+	MarkShipped(ctx context.Context, pharmacyID, batchID int64) error
+}
+
+// ShipmentDeliveryMarker marks a shipment as delivered.
+type ShipmentDeliveryMarker interface {
+	MarkDelivered(ctx context.Context, pharmacyID, orderID int64) error
+}
+
+// HandleShippingPage lists orders waiting to be shipped and existing batches.
+func HandleShippingPage(shippable ShippableOrderLister, batches ShippingBatchLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderShippingPage(w, r, shippable, batches, "")
+	}
+}
+
+// HandleCreateShippingBatch groups the selected orders into a new batch and
+// redirects to its manifest.
+func HandleCreateShippingBatch(creator ShippingBatchCreator, shippable ShippableOrderLister, batches ShippingBatchLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		var orderIDs []int64
+		for _, v := range r.Form["order_id"] {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+				return
+			}
+			orderIDs = append(orderIDs, id)
+		}
+
+		b, err := creator.CreateBatch(r.Context(), web.PharmacyID(r.Context()), orderIDs)
+		if err != nil {
+			if errors.Is(err, shipping.ErrNoShippableOrders) {
+				renderShippingPage(w, r, shippable, batches, web.ErrorMessage(r.Context(), err))
+				return
+			}
+			slog.ErrorContext(r.Context(), "creating shipping batch", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/shipping/batches/%d", b.ID), http.StatusSeeOther)
+	}
+}
+
+func renderShippingPage(w http.ResponseWriter, r *http.Request, shippable ShippableOrderLister, batches ShippingBatchLister, errMsg string) {
+	pharmacyID := web.PharmacyID(r.Context())
+
+	orders, err := shippable.ListShippable(r.Context(), pharmacyID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing shippable orders", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	list, err := batches.ListBatches(r.Context(), pharmacyID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "listing shipping batches", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+
+	web.ShippingPage(orders, list, errMsg).Render(r.Context(), w)
+}
+
+// HandleShippingBatchPage shows a batch with its parcels and tracking numbers.
+func HandleShippingBatchPage(getter ShippingBatchGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, shipments, ok := loadShippingBatch(w, r, getter)
+		if !ok {
+			return
+		}
+		web.ShippingBatchPage(b, shipments, "").Render(r.Context(), w)
+	}
+}
+
+// HandleShippingManifest renders the printable manifest signed by the courier.
+func HandleShippingManifest(getter ShippingBatchGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, shipments, ok := loadShippingBatch(w, r, getter)
+		if !ok {
+			return
+		}
+		web.ShippingManifestPage(b, shipments, web.PharmacyName(r.Context()), time.Now()).Render(r.Context(), w)
+	}
+}
+
+// HandleShippingExport downloads the batch in the courier's CSV upload format.
+func HandleShippingExport(getter ShippingBatchGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, shipments, ok := loadShippingBatch(w, r, getter)
+		if !ok {
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := shipping.WriteCourierCSV(&buf, shipments); err != nil {
+			slog.ErrorContext(r.Context(), "writing courier csv", "batchID", b.ID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("spedizione-%d.csv", b.ID)))
+		w.Write(buf.Bytes())
+	}
+}
+
+// HandleSetShipmentTracking saves the tracking numbers typed into the batch page.
+// Form fields are named tracking_<orderID>.
+func HandleSetShipmentTracking(setter ShipmentTrackingSetter, getter ShippingBatchGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		batchID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		tracking := map[int64]string{}
+		for key, values := range r.PostForm {
+			raw, ok := strings.CutPrefix(key, "tracking_")
+			if !ok {
+				continue
+			}
+			orderID, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				continue
+			}
+			tracking[orderID] = values[0]
+		}
+
+		if err := setter.SetTracking(r.Context(), web.PharmacyID(r.Context()), batchID, tracking); err != nil {
+			if errors.Is(err, shipping.ErrTrackingTooLong) {
+				b, shipments, ok := loadShippingBatch(w, r, getter)
+				if !ok {
+					return
+				}
+				web.ShippingBatchPage(b, shipments, err.Error()).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "setting shipment tracking", "batchID", batchID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/shipping/batches/%d", batchID), http.StatusSeeOther)
+	}
+}
+
+// HandleShipBatch marks the batch and all its parcels as handed to the courier.
+func HandleShipBatch(shipper ShippingBatchShipper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		batchID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := shipper.MarkShipped(r.Context(), web.PharmacyID(r.Context()), batchID); err != nil {
+			if errors.Is(err, shipping.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			if errors.Is(err, shipping.ErrBatchAlreadyShipped) {
+				http.Error(w, web.ErrorMessage(r.Context(), err), http.StatusBadRequest)
+				return
+			}
+			slog.ErrorContext(r.Context(), "marking batch shipped", "batchID", batchID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/shipping/batches/%d", batchID), http.StatusSeeOther)
+	}
+}
+
+// HandleMarkShipmentDelivered records that a parcel reached the patient.
+func HandleMarkShipmentDelivered(marker ShipmentDeliveryMarker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		batchID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		orderID, err := strconv.ParseInt(r.PathValue("orderID"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := marker.MarkDelivered(r.Context(), web.PharmacyID(r.Context()), orderID); err != nil {
+			if errors.Is(err, shipping.ErrInvalidTransition) {
+				http.Error(w, web.ErrorMessage(r.Context(), err), http.StatusBadRequest)
+				return
+			}
+			slog.ErrorContext(r.Context(), "marking shipment delivered", "orderID", orderID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/shipping/batches/%d", batchID), http.StatusSeeOther)
+	}
+}
+
+// loadShippingBatch parses the batch ID from the path and loads it, writing
+// a 404 or 500 response and returning false on failure.
+func loadShippingBatch(w http.ResponseWriter, r *http.Request, getter ShippingBatchGetter) (shipping.Batch, []shipping.Shipment, bool) {
+	batchID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return shipping.Batch{}, nil, false
+	}
+
+	b, shipments, err := getter.GetBatch(r.Context(), web.PharmacyID(r.Context()), batchID)
+	if err != nil {
+		if errors.Is(err, shipping.ErrNotFound) {
+			http.NotFound(w, r)
+			return shipping.Batch{}, nil, false
+		}
+		slog.ErrorContext(r.Context(), "getting shipping batch", "batchID", batchID, "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return shipping.Batch{}, nil, false
+	}
+	return b, shipments, true
+}
@@ -0,0 +1,218 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/address"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/shipping"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+// --- Stubs ---
+
+type stubShippingService struct {
+	shippable  []shipping.ShippableOrder
+	batches    []shipping.Batch
+	batch      shipping.Batch
+	shipments  []shipping.Shipment
+	getErr     error
+	createErr  error
+	createdFor []int64
+	pharmacyID int64
+	tracking   map[int64]string
+	shipErr    error
+}
+
+func (s *stubShippingService) ListShippable(_ context.Context, _ int64) ([]shipping.ShippableOrder, error) {
+	return s.shippable, nil
+}
+
+func (s *stubShippingService) ListBatches(_ context.Context, _ int64) ([]shipping.Batch, error) {
+	return s.batches, nil
+}
+
+func (s *stubShippingService) CreateBatch(_ context.Context, pharmacyID int64, orderIDs []int64) (shipping.Batch, error) {
+	s.pharmacyID = pharmacyID
+	s.createdFor = orderIDs
+	return s.batch, s.createErr
+}
+
+func (s *stubShippingService) GetBatch(_ context.Context, pharmacyID, _ int64) (shipping.Batch, []shipping.Shipment, error) {
+	s.pharmacyID = pharmacyID
+	return s.batch, s.shipments, s.getErr
+}
+
+func (s *stubShippingService) SetTracking(_ context.Context, _, _ int64, tracking map[int64]string) error {
+	s.tracking = tracking
+	return nil
+}
+
+func (s *stubShippingService) MarkShipped(_ context.Context, _, _ int64) error {
+	return s.shipErr
+}
+
+func shippingTestServer(sm *scs.SessionManager, svc *stubShippingService) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /shipping", web.RequirePermission(permission.ViewShipping)(http.HandlerFunc(handler.HandleShippingPage(svc, svc))))
+	mux.Handle("POST /shipping/batches", web.RequirePermission(permission.ManageShipping)(http.HandlerFunc(handler.HandleCreateShippingBatch(svc, svc, svc))))
+	mux.Handle("GET /shipping/batches/{id}", web.RequirePermission(permission.ViewShipping)(http.HandlerFunc(handler.HandleShippingBatchPage(svc))))
+	mux.Handle("GET /shipping/batches/{id}/export.csv", web.RequirePermission(permission.ViewShipping)(http.HandlerFunc(handler.HandleShippingExport(svc))))
+	mux.Handle("POST /shipping/batches/{id}/tracking", web.RequirePermission(permission.ManageShipping)(http.HandlerFunc(handler.HandleSetShipmentTracking(svc, svc))))
+	mux.Handle("POST /shipping/batches/{id}/ship", web.RequirePermission(permission.ManageShipping)(http.HandlerFunc(handler.HandleShipBatch(svc))))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", "personnel")
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func TestShippingPageListsShippableOrders(t *testing.T) {
+	svc := &stubShippingService{shippable: []shipping.ShippableOrder{
+		{OrderID: 10, FirstName: "Mario", LastName: "Rossi", MedicationName: "Tachipirina", DeliveryAddress: address.Address{Street: "Via Roma", HouseNumber: "1"}},
+	}}
+	srv := shippingTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/shipping")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `value="10"`) || !strings.Contains(string(body), "Via Roma 1") {
+		t.Error("shipping page missing shippable order")
+	}
+}
+
+func TestCreateShippingBatchRedirectsToBatch(t *testing.T) {
+	svc := &stubShippingService{batch: shipping.Batch{ID: 3}}
+	srv := shippingTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/shipping/batches", url.Values{"order_id": {"10", "11"}})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("status = %d, want 303", resp.StatusCode)
+	}
+	if loc := resp.Header.Get("Location"); loc != "/shipping/batches/3" {
+		t.Errorf("Location = %q, want /shipping/batches/3", loc)
+	}
+	if len(svc.createdFor) != 2 || svc.pharmacyID != 7 {
+		t.Errorf("created for %v in pharmacy %d", svc.createdFor, svc.pharmacyID)
+	}
+}
+
+func TestCreateShippingBatchWithoutOrdersShowsError(t *testing.T) {
+	svc := &stubShippingService{createErr: shipping.ErrNoShippableOrders}
+	srv := shippingTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/shipping/batches", url.Values{})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "Seleziona almeno un ordine") {
+		t.Error("expected error message")
+	}
+}
+
+func TestShippingBatchNotFoundReturns404(t *testing.T) {
+	svc := &stubShippingService{getErr: shipping.ErrNotFound}
+	srv := shippingTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/shipping/batches/99")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestShippingExportReturnsCSV(t *testing.T) {
+	svc := &stubShippingService{
+		batch:     shipping.Batch{ID: 3},
+		shipments: []shipping.Shipment{{OrderID: 10, FirstName: "Mario", LastName: "Rossi", TrackingNumber: "BRT1"}},
+	}
+	srv := shippingTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedGet(t, srv, "/shipping/batches/3/export.csv")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, "spedizione-3.csv") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "10;Mario Rossi;") {
+		t.Errorf("csv missing shipment row:\n%s", body)
+	}
+}
+
+func TestSetShipmentTrackingParsesFields(t *testing.T) {
+	svc := &stubShippingService{}
+	srv := shippingTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/shipping/batches/3/tracking", url.Values{
+		"tracking_10": {"BRT1"},
+		"tracking_11": {""},
+		"other":       {"x"},
+	})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("status = %d, want 303", resp.StatusCode)
+	}
+	if len(svc.tracking) != 2 || svc.tracking[10] != "BRT1" {
+		t.Errorf("tracking = %v", svc.tracking)
+	}
+}
+
+func TestShipBatchNotFoundReturns404(t *testing.T) {
+	svc := &stubShippingService{shipErr: shipping.ErrNotFound}
+	srv := shippingTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/shipping/batches/3/ship", url.Values{})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestShipBatchAlreadyShippedReturns400(t *testing.T) {
+	svc := &stubShippingService{shipErr: shipping.ErrBatchAlreadyShipped}
+	srv := shippingTestServer(scs.New(), svc)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/shipping/batches/3/ship", url.Values{})
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
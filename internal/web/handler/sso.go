@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/sso"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// A sign-in sent to the identity provider must come back within this time.
+const ssoLoginTTL = 10 * time.Minute
+
+// SSOAuthenticator signs users in through their pharmacy's identity provider.
+type SSOAuthenticator interface {
+	Begin(ctx context.Context, pharmacyID int64, redirectURL string) (sso.Login, error)
+	Complete(ctx context.Context, pharmacyID int64, redirectURL string, pending sso.Login, state, code string) (sso.Identity, error)
+}
+
+// FederatedSignIn logs in, and provisions on first sign-in, a user vouched
+// for by an identity provider.
+type FederatedSignIn interface {
+	SignInFederated(ctx context.Context, f user.FederatedLogin, now time.Time) (user.User, error)
+}
+
+// SSOConfigManager reads and saves a pharmacy's identity provider settings.
+type SSOConfigManager interface {
+	Config(ctx context.Context, pharmacyID int64) (sso.Config, error)
+	SaveConfig(ctx context.Context, c sso.Config) error
+}
+
+// ssoCallbackURL is where identity providers send users back; it must be
+// registered with each provider.
+func ssoCallbackURL(baseURL string) string {
+	return baseURL + "/login/sso/callback"
+}
+
+// HandleSSOLogin sends the user to the identity provider of the pharmacy in
+// the URL. Pharmacies hand this link to their staff.
+func HandleSSOLogin(sessions *scs.SessionManager, auth SSOAuthenticator, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pharmacyID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		l, err := auth.Begin(r.Context(), pharmacyID, ssoCallbackURL(baseURL))
+		if err != nil {
+			if errors.Is(err, sso.ErrNotConfigured) {
+				web.LoginPage(web.ErrorMessage(r.Context(), err)).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "starting sso login", "pharmacyID", pharmacyID, "error", err)
+			web.LoginPage(web.T(r.Context(), "login.sso_unavailable")).Render(r.Context(), w)
+			return
+		}
+
+		if err := sessions.RenewToken(r.Context()); err != nil {
+			slog.ErrorContext(r.Context(), "renewing session token", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+		sessions.Put(r.Context(), "ssoPharmacyID", pharmacyID)
+		sessions.Put(r.Context(), "ssoState", l.State)
+		sessions.Put(r.Context(), "ssoNonce", l.Nonce)
+		sessions.Put(r.Context(), "ssoVerifier", l.Verifier)
+		sessions.Put(r.Context(), "ssoSince", time.Now().Unix())
+		http.Redirect(w, r, l.URL, http.StatusSeeOther)
+	}
+}
+
+// HandleSSOCallback completes the sign-in when the identity provider sends
+// the user back, then continues like a password login: second factor if
+// enabled, then the session.
+func HandleSSOCallback(sessions *scs.SessionManager, auth SSOAuthenticator, signIn FederatedSignIn, tracker SessionTracker, pharmacies PharmacyNameGetter, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pharmacyID, pending, ok := pendingSSOLogin(r.Context(), sessions)
+		if !ok {
+			web.LoginPage(web.T(r.Context(), "login.session_expired")).Render(r.Context(), w)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("error") != "" {
+			web.LoginPage(web.T(r.Context(), "login.sso_cancelled")).Render(r.Context(), w)
+			return
+		}
+
+		id, err := auth.Complete(r.Context(), pharmacyID, ssoCallbackURL(baseURL), pending, q.Get("state"), q.Get("code"))
+		if err != nil {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				web.LoginPage(msg).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "completing sso login", "pharmacyID", pharmacyID, "error", err)
+			web.LoginPage(web.T(r.Context(), "sso.invalid_response")).Render(r.Context(), w)
+			return
+		}
+
+		u, err := signIn.SignInFederated(r.Context(), user.FederatedLogin{
+			PharmacyID: pharmacyID,
+			Issuer:     id.Issuer,
+			Subject:    id.Subject,
+			Email:      id.Email,
+			Name:       id.Name,
+			Role:       id.Role,
+			IP:         clientIP(r),
+			UserAgent:  r.UserAgent(),
+		}, time.Now())
+		if err != nil {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				web.LoginPage(msg).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "signing in federated user", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		if u.TwoFactorEnabled {
+			startSecondFactor(w, r, sessions, u.ID)
+			return
+		}
+		completeLogin(w, r, sessions, tracker, pharmacies, u)
+	}
+}
+
+// pendingSSOLogin returns, and clears from the session, the sign-in sent to
+// the identity provider, if it has not expired.
+func pendingSSOLogin(ctx context.Context, sessions *scs.SessionManager) (int64, sso.Login, bool) {
+	pharmacyID := sessions.GetInt64(ctx, "ssoPharmacyID")
+	since := time.Unix(sessions.GetInt64(ctx, "ssoSince"), 0)
+	pending := sso.Login{
+		State:    sessions.PopString(ctx, "ssoState"),
+		Nonce:    sessions.PopString(ctx, "ssoNonce"),
+		Verifier: sessions.PopString(ctx, "ssoVerifier"),
+	}
+	sessions.Remove(ctx, "ssoPharmacyID")
+	sessions.Remove(ctx, "ssoSince")
+	if pharmacyID == 0 || time.Since(since) > ssoLoginTTL {
+		return 0, sso.Login{}, false
+	}
+	return pharmacyID, pending, true
+}
+
+// HandleSSOSettingsPage shows a pharmacy's identity provider settings to
+// admins, with the URLs to give the provider and the staff.
+func HandleSSOSettingsPage(manager SSOConfigManager, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pharmacyID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		c, err := manager.Config(r.Context(), pharmacyID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "getting sso settings", "pharmacyID", pharmacyID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+		renderSSOSettings(w, r, baseURL, c, c.ClientSecret != "", "")
+	}
+}
+
+// HandleSaveSSOSettings saves a pharmacy's identity provider settings.
+func HandleSaveSSOSettings(manager SSOConfigManager, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pharmacyID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		c := sso.Config{
+			PharmacyID:     pharmacyID,
+			Enabled:        r.FormValue("enabled") == "1",
+			Issuer:         r.FormValue("issuer"),
+			ClientID:       r.FormValue("client_id"),
+			ClientSecret:   r.FormValue("client_secret"),
+			RoleClaim:      r.FormValue("role_claim"),
+			OwnerValue:     r.FormValue("owner_value"),
+			PersonnelValue: r.FormValue("personnel_value"),
+		}
+		if err := manager.SaveConfig(r.Context(), c); err != nil {
+			if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+				saved, err := manager.Config(r.Context(), pharmacyID)
+				if err != nil {
+					slog.ErrorContext(r.Context(), "getting sso settings", "pharmacyID", pharmacyID, "error", err)
+					http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+					return
+				}
+				c.ClientSecret = ""
+				renderSSOSettings(w, r, baseURL, c, saved.ClientSecret != "", msg)
+				return
+			}
+			slog.ErrorContext(r.Context(), "saving sso settings", "pharmacyID", pharmacyID, "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/admin/pharmacies/%d/sso", pharmacyID), http.StatusSeeOther)
+	}
+}
+
+// renderSSOSettings shows c without its secret; hasSecret tells whether one
+// is saved.
+func renderSSOSettings(w http.ResponseWriter, r *http.Request, baseURL string, c sso.Config, hasSecret bool, errMsg string) {
+	c.ClientSecret = ""
+	web.SSOSettingsPage(web.SSOSettingsView{
+		Config:      c,
+		HasSecret:   hasSecret,
+		LoginURL:    fmt.Sprintf("%s/login/sso/%d", baseURL, c.PharmacyID),
+		CallbackURL: ssoCallbackURL(baseURL),
+		ErrMsg:      errMsg,
+	}).Render(r.Context(), w)
+}
@@ -0,0 +1,275 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/sso"
+	"github.com/giorgiovilardo/pharmarecall/internal/sso/ssotest"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubSSOConfigs struct {
+	config sso.Config
+	saved  int
+}
+
+func (s *stubSSOConfigs) GetConfig(_ context.Context, _ int64) (sso.Config, error) {
+	return s.config, nil
+}
+
+func (s *stubSSOConfigs) SaveConfig(_ context.Context, c sso.Config) error {
+	s.config = c
+	s.saved++
+	return nil
+}
+
+type stubFederatedSignIn struct {
+	login user.FederatedLogin
+	user  user.User
+	err   error
+}
+
+func (s *stubFederatedSignIn) SignInFederated(_ context.Context, f user.FederatedLogin, _ time.Time) (user.User, error) {
+	s.login = f
+	return s.user, s.err
+}
+
+// ssoServer serves the sign-in routes; they are registered once the server
+// runs because the callback URL includes its address.
+func ssoServer(configs *stubSSOConfigs, signIn *stubFederatedSignIn, tracker *stubSessionTracker) *httptest.Server {
+	sm := scs.New()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(sm.LoadAndSave(mux))
+
+	auth := sso.NewServiceWith(sso.ServiceDeps{Getter: configs, Saver: configs, Provider: sso.NewOIDCProvider(http.DefaultClient)})
+	mux.HandleFunc("GET /login/sso/{id}", handler.HandleSSOLogin(sm, auth, srv.URL))
+	mux.HandleFunc("GET /login/sso/callback", handler.HandleSSOCallback(sm, auth, signIn, tracker, nil, srv.URL))
+	mux.HandleFunc("GET /admin/pharmacies/{id}/sso", handler.HandleSSOSettingsPage(auth, srv.URL))
+	mux.HandleFunc("POST /admin/pharmacies/{id}/sso", handler.HandleSaveSSOSettings(auth, srv.URL))
+	return srv
+}
+
+func enabledSSOConfig(p *ssotest.Provider) sso.Config {
+	return sso.Config{
+		PharmacyID:     7,
+		Enabled:        true,
+		Issuer:         p.Issuer,
+		ClientID:       p.ClientID,
+		ClientSecret:   p.ClientSecret,
+		RoleClaim:      sso.DefaultRoleClaim,
+		OwnerValue:     "titolari",
+		PersonnelValue: "farmacisti",
+	}
+}
+
+func TestSSOLoginProvisionsAndSignsIn(t *testing.T) {
+	provider := ssotest.NewProvider(t)
+	provider.SignInAs(ssotest.User{Subject: "u-123", Email: "mario@catena.it", EmailVerified: true, Name: "Mario Rossi", Groups: []string{"farmacisti"}})
+	signIn := &stubFederatedSignIn{user: user.User{ID: 12, Role: "personnel", PharmacyID: 7, Name: "Mario Rossi", Permissions: permission.ForRole("personnel", nil)}}
+	tracker := &stubSessionTracker{}
+	srv := ssoServer(&stubSSOConfigs{config: enabledSSOConfig(provider)}, signIn, tracker)
+	defer srv.Close()
+	client := jarClient(t)
+
+	resp, err := client.Get(srv.URL + "/login/sso/7")
+	if err != nil {
+		t.Fatalf("starting sso login: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther || !strings.HasPrefix(resp.Header.Get("Location"), provider.Issuer+"/authorize") {
+		t.Fatalf("status = %d, location = %q, want redirect to the provider", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp, err = client.Get(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("authorizing: %v", err)
+	}
+	resp.Body.Close()
+	callback := resp.Header.Get("Location")
+	if !strings.HasPrefix(callback, srv.URL+"/login/sso/callback") {
+		t.Fatalf("provider redirect = %q, want the callback", callback)
+	}
+
+	resp, err = client.Get(callback)
+	if err != nil {
+		t.Fatalf("calling back: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/dashboard" {
+		t.Fatalf("redirect = %q, want /dashboard", loc)
+	}
+	want := user.FederatedLogin{PharmacyID: 7, Issuer: provider.Issuer, Subject: "u-123", Email: "mario@catena.it", Name: "Mario Rossi", Role: "personnel"}
+	got := signIn.login
+	got.IP, got.UserAgent = "", ""
+	if got != want {
+		t.Errorf("federated login = %+v, want %+v", got, want)
+	}
+	if tracker.userID != 12 {
+		t.Errorf("tracked user = %d, want 12", tracker.userID)
+	}
+
+	resp, err = client.Get(callback)
+	if err != nil {
+		t.Fatalf("replaying callback: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Sessione scaduta") {
+		t.Error("a replayed callback must not sign in again")
+	}
+}
+
+func TestSSOLoginRefusesUserWithoutRole(t *testing.T) {
+	provider := ssotest.NewProvider(t)
+	provider.SignInAs(ssotest.User{Subject: "u-9", Email: "ospite@catena.it", EmailVerified: true, Groups: []string{"magazzino"}})
+	signIn := &stubFederatedSignIn{}
+	srv := ssoServer(&stubSSOConfigs{config: enabledSSOConfig(provider)}, signIn, &stubSessionTracker{})
+	defer srv.Close()
+	client := jarClient(t)
+
+	resp, err := client.Get(srv.URL + "/login/sso/7")
+	if err != nil {
+		t.Fatalf("starting sso login: %v", err)
+	}
+	resp.Body.Close()
+	resp, err = client.Get(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("authorizing: %v", err)
+	}
+	resp.Body.Close()
+	resp, err = client.Get(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("calling back: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !strings.Contains(string(body), "non è abilitato a questa farmacia") {
+		t.Errorf("expected no-access message, got: %s", body)
+	}
+	if signIn.login.Subject != "" {
+		t.Error("a user without a mapped role must not be provisioned")
+	}
+}
+
+func TestSSOLoginNotConfigured(t *testing.T) {
+	srv := ssoServer(&stubSSOConfigs{config: sso.Config{PharmacyID: 7, RoleClaim: sso.DefaultRoleClaim}}, &stubFederatedSignIn{}, &stubSessionTracker{})
+	defer srv.Close()
+
+	resp, err := noFollowClient().Get(srv.URL + "/login/sso/7")
+	if err != nil {
+		t.Fatalf("starting sso login: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "non disponibile") {
+		t.Errorf("status = %d, want login page with not-available message", resp.StatusCode)
+	}
+}
+
+func TestSSOCallbackWithoutPendingLogin(t *testing.T) {
+	signIn := &stubFederatedSignIn{}
+	srv := ssoServer(&stubSSOConfigs{}, signIn, &stubSessionTracker{})
+	defer srv.Close()
+
+	resp, err := noFollowClient().Get(srv.URL + "/login/sso/callback?code=abc&state=xyz")
+	if err != nil {
+		t.Fatalf("calling back: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !strings.Contains(string(body), "Sessione scaduta") {
+		t.Errorf("expected expired message, got: %s", body)
+	}
+}
+
+func TestSSOSettingsPageHidesSecret(t *testing.T) {
+	provider := ssotest.NewProvider(t)
+	srv := ssoServer(&stubSSOConfigs{config: enabledSSOConfig(provider)}, &stubFederatedSignIn{}, &stubSessionTracker{})
+	defer srv.Close()
+
+	resp, err := noFollowClient().Get(srv.URL + "/admin/pharmacies/7/sso")
+	if err != nil {
+		t.Fatalf("getting settings: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if strings.Contains(string(body), `value="secret"`) {
+		t.Error("the client secret must not be rendered")
+	}
+	for _, want := range []string{srv.URL + "/login/sso/callback", srv.URL + "/login/sso/7", "Lascia vuoto"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %q in settings page", want)
+		}
+	}
+}
+
+func TestSaveSSOSettingsInvalidIssuerShowsError(t *testing.T) {
+	configs := &stubSSOConfigs{config: sso.Config{PharmacyID: 7, RoleClaim: sso.DefaultRoleClaim}}
+	srv := ssoServer(configs, &stubFederatedSignIn{}, &stubSessionTracker{})
+	defer srv.Close()
+
+	resp, err := noFollowClient().PostForm(srv.URL+"/admin/pharmacies/7/sso", url.Values{
+		"enabled":       {"1"},
+		"issuer":        {"http://login.catena.it"},
+		"client_id":     {"pharmarecall"},
+		"client_secret": {"s3cret"},
+		"role_claim":    {"groups"},
+	})
+	if err != nil {
+		t.Fatalf("saving settings: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !strings.Contains(string(body), "indirizzo https") {
+		t.Errorf("expected issuer error, got: %s", body)
+	}
+	if !strings.Contains(string(body), "http://login.catena.it") {
+		t.Error("the submitted issuer should be kept in the form")
+	}
+	if strings.Contains(string(body), "s3cret") {
+		t.Error("the submitted secret must not be rendered")
+	}
+	if configs.saved != 0 {
+		t.Error("invalid settings must not be saved")
+	}
+}
+
+func TestSaveSSOSettingsRedirects(t *testing.T) {
+	configs := &stubSSOConfigs{config: sso.Config{PharmacyID: 7, RoleClaim: sso.DefaultRoleClaim}}
+	srv := ssoServer(configs, &stubFederatedSignIn{}, &stubSessionTracker{})
+	defer srv.Close()
+
+	resp, err := noFollowClient().PostForm(srv.URL+"/admin/pharmacies/7/sso", url.Values{
+		"enabled":       {"1"},
+		"issuer":        {"https://login.catena.it"},
+		"client_id":     {"pharmarecall"},
+		"client_secret": {"s3cret"},
+		"role_claim":    {"groups"},
+	})
+	if err != nil {
+		t.Fatalf("saving settings: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/admin/pharmacies/7/sso" {
+		t.Errorf("status = %d, location = %q, want 303 to settings", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if !configs.config.Enabled || configs.config.Issuer != "https://login.catena.it" {
+		t.Errorf("saved config = %+v", configs.config)
+	}
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+)
+
+// A password-verified login waits this long for its second factor, and
+// accepts this many wrong codes before the user has to start over.
+const (
+	secondFactorTTL         = 5 * time.Minute
+	maxSecondFactorAttempts = 5
+)
+
+// TwoFactorVerifier checks the second factor of a login.
+type TwoFactorVerifier interface {
+	VerifySecondFactor(ctx context.Context, a user.SecondFactorAttempt, now time.Time) (user.User, error)
+}
+
+// TwoFactorEnroller sets up an authenticator app for an account.
+type TwoFactorEnroller interface {
+	BeginTwoFactorEnrolment(ctx context.Context, userID int64) (user.TwoFactorEnrolment, error)
+	EnableTwoFactor(ctx context.Context, userID int64, secret, code string, now time.Time) ([]string, error)
+}
+
+// TwoFactorManager reports and changes an account's 2FA settings.
+type TwoFactorManager interface {
+	TwoFactorStatus(ctx context.Context, userID int64) (user.TwoFactorStatus, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string, now time.Time) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID int64, code string, now time.Time) error
+}
+
+// startSecondFactor remembers a password-verified user in a fresh,
+// unauthenticated session and asks for the code.
+func startSecondFactor(w http.ResponseWriter, r *http.Request, sessions *scs.SessionManager, userID int64) {
+	if err := sessions.RenewToken(r.Context()); err != nil {
+		slog.ErrorContext(r.Context(), "renewing session token", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	sessions.Put(r.Context(), "twoFactorUserID", userID)
+	sessions.Put(r.Context(), "twoFactorSince", time.Now().Unix())
+	sessions.Put(r.Context(), "twoFactorAttempts", 0)
+	http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+}
+
+// pendingSecondFactor returns the user waiting for the second step, if the
+// step has not expired.
+func pendingSecondFactor(ctx context.Context, sessions *scs.SessionManager) (int64, bool) {
+	userID := sessions.GetInt64(ctx, "twoFactorUserID")
+	if userID == 0 {
+		return 0, false
+	}
+	if time.Since(time.Unix(sessions.GetInt64(ctx, "twoFactorSince"), 0)) > secondFactorTTL {
+		clearSecondFactor(ctx, sessions)
+		return 0, false
+	}
+	return userID, true
+}
+
+func clearSecondFactor(ctx context.Context, sessions *scs.SessionManager) {
+	sessions.Remove(ctx, "twoFactorUserID")
+	sessions.Remove(ctx, "twoFactorSince")
+	sessions.Remove(ctx, "twoFactorAttempts")
+}
+
+// HandleTwoFactorLoginPage asks for the authenticator or recovery code.
+func HandleTwoFactorLoginPage(sessions *scs.SessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := pendingSecondFactor(r.Context(), sessions); !ok {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		web.TwoFactorLoginPage("").Render(r.Context(), w)
+	}
+}
+
+// HandleTwoFactorLoginPost checks the code and completes the login.
+func HandleTwoFactorLoginPost(sessions *scs.SessionManager, verifier TwoFactorVerifier, tracker SessionTracker, pharmacies PharmacyNameGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := pendingSecondFactor(r.Context(), sessions)
+		if !ok {
+			web.LoginPage(web.T(r.Context(), "login.session_expired")).Render(r.Context(), w)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			web.TwoFactorLoginPage(web.T(r.Context(), "common.bad_request")).Render(r.Context(), w)
+			return
+		}
+
+		u, err := verifier.VerifySecondFactor(r.Context(), user.SecondFactorAttempt{
+			UserID:    userID,
+			Code:      r.FormValue("code"),
+			IP:        clientIP(r),
+			UserAgent: r.UserAgent(),
+		}, time.Now())
+		if err != nil {
+			if errors.Is(err, user.ErrInvalidSecondFactor) {
+				attempts := sessions.GetInt(r.Context(), "twoFactorAttempts") + 1
+				if attempts >= maxSecondFactorAttempts {
+					clearSecondFactor(r.Context(), sessions)
+					web.LoginPage(web.T(r.Context(), "login.too_many_codes")).Render(r.Context(), w)
+					return
+				}
+				sessions.Put(r.Context(), "twoFactorAttempts", attempts)
+				web.TwoFactorLoginPage(web.T(r.Context(), "user.invalid_second_factor")).Render(r.Context(), w)
+				return
+			}
+			if errors.Is(err, user.ErrDeactivated) || errors.Is(err, user.ErrAccountLocked) {
+				clearSecondFactor(r.Context(), sessions)
+				web.LoginPage(web.ErrorMessage(r.Context(), err)).Render(r.Context(), w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "verifying second factor", "error", err)
+			http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+			return
+		}
+
+		clearSecondFactor(r.Context(), sessions)
+		completeLogin(w, r, sessions, tracker, pharmacies, u)
+	}
+}
+
+// HandleTwoFactorSettings shows the account's 2FA status. Without 2FA it
+// offers a QR code to enrol; the pending secret lives in the session so a
+// reload shows the same code.
+func HandleTwoFactorSettings(sessions *scs.SessionManager, enroller TwoFactorEnroller, manager TwoFactorManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderTwoFactorSettings(w, r, sessions, enroller, manager, web.TwoFactorView{})
+	}
+}
+
+// HandleEnableTwoFactor confirms the pending secret with a code and shows
+// the recovery codes once.
+func HandleEnableTwoFactor(sessions *scs.SessionManager, enroller TwoFactorEnroller, manager TwoFactorManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+		secret := sessions.GetString(r.Context(), "totpPendingSecret")
+		if secret == "" {
+			http.Redirect(w, r, "/account/2fa", http.StatusSeeOther)
+			return
+		}
+
+		codes, err := enroller.EnableTwoFactor(r.Context(), web.UserID(r.Context()), secret, r.FormValue("code"), time.Now())
+		if err != nil {
+			twoFactorFailure(w, r, sessions, enroller, manager, err)
+			return
+		}
+
+		sessions.Remove(r.Context(), "totpPendingSecret")
+		sessions.Remove(r.Context(), "totpPendingURI")
+		sessions.Remove(r.Context(), "mustEnrolTwoFactor")
+		renderTwoFactorSettings(w, r, sessions, enroller, manager, web.TwoFactorView{
+			RecoveryCodes: codes,
+			Msg:           web.T(r.Context(), "two_factor.enabled"),
+		})
+	}
+}
+
+// HandleRegenerateRecoveryCodes replaces the recovery codes after checking a
+// current code.
+func HandleRegenerateRecoveryCodes(sessions *scs.SessionManager, enroller TwoFactorEnroller, manager TwoFactorManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		codes, err := manager.RegenerateRecoveryCodes(r.Context(), web.UserID(r.Context()), r.FormValue("code"), time.Now())
+		if err != nil {
+			twoFactorFailure(w, r, sessions, enroller, manager, err)
+			return
+		}
+
+		renderTwoFactorSettings(w, r, sessions, enroller, manager, web.TwoFactorView{
+			RecoveryCodes: codes,
+			Msg:           web.T(r.Context(), "two_factor.codes_regenerated"),
+		})
+	}
+}
+
+// HandleDisableTwoFactor turns 2FA off after checking a current code.
+func HandleDisableTwoFactor(sessions *scs.SessionManager, enroller TwoFactorEnroller, manager TwoFactorManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, web.T(r.Context(), "common.bad_request"), http.StatusBadRequest)
+			return
+		}
+
+		if err := manager.DisableTwoFactor(r.Context(), web.UserID(r.Context()), r.FormValue("code"), time.Now()); err != nil {
+			twoFactorFailure(w, r, sessions, enroller, manager, err)
+			return
+		}
+
+		renderTwoFactorSettings(w, r, sessions, enroller, manager, web.TwoFactorView{Msg: web.T(r.Context(), "two_factor.disabled")})
+	}
+}
+
+func twoFactorFailure(w http.ResponseWriter, r *http.Request, sessions *scs.SessionManager, enroller TwoFactorEnroller, manager TwoFactorManager, err error) {
+	if msg := web.ErrorMessage(r.Context(), err); msg != "" {
+		renderTwoFactorSettings(w, r, sessions, enroller, manager, web.TwoFactorView{ErrMsg: msg})
+		return
+	}
+	slog.ErrorContext(r.Context(), "updating two-factor settings", "error", err)
+	http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+}
+
+func renderTwoFactorSettings(w http.ResponseWriter, r *http.Request, sessions *scs.SessionManager, enroller TwoFactorEnroller, manager TwoFactorManager, view web.TwoFactorView) {
+	userID := web.UserID(r.Context())
+	st, err := manager.TwoFactorStatus(r.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "getting two-factor status", "error", err)
+		http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+		return
+	}
+	view.Status = st
+
+	if !st.Enabled {
+		view.Enrolment = user.TwoFactorEnrolment{
+			Secret: sessions.GetString(r.Context(), "totpPendingSecret"),
+			URI:    sessions.GetString(r.Context(), "totpPendingURI"),
+		}
+		if view.Enrolment.Secret == "" {
+			view.Enrolment, err = enroller.BeginTwoFactorEnrolment(r.Context(), userID)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "starting two-factor enrolment", "error", err)
+				http.Error(w, web.T(r.Context(), "common.internal_error"), http.StatusInternalServerError)
+				return
+			}
+			sessions.Put(r.Context(), "totpPendingSecret", view.Enrolment.Secret)
+			sessions.Put(r.Context(), "totpPendingURI", view.Enrolment.URI)
+		}
+	}
+
+	web.TwoFactorSettingsPage(view).Render(r.Context(), w)
+}
@@ -0,0 +1,253 @@
+package handler_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/user"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"github.com/giorgiovilardo/pharmarecall/internal/web/handler"
+)
+
+type stubTwoFactor struct {
+	user     user.User
+	err      error
+	status   user.TwoFactorStatus
+	codes    []string
+	verified int
+	enabled  string
+	begun    int
+}
+
+func (s *stubTwoFactor) VerifySecondFactor(_ context.Context, _ user.SecondFactorAttempt, _ time.Time) (user.User, error) {
+	s.verified++
+	return s.user, s.err
+}
+
+func (s *stubTwoFactor) BeginTwoFactorEnrolment(_ context.Context, _ int64) (user.TwoFactorEnrolment, error) {
+	s.begun++
+	return user.TwoFactorEnrolment{Secret: "JBSWY3DPEHPK3PXP", URI: "otpauth://totp/PharmaRecall:a%40example.com?secret=JBSWY3DPEHPK3PXP"}, nil
+}
+
+func (s *stubTwoFactor) EnableTwoFactor(_ context.Context, _ int64, secret, _ string, _ time.Time) ([]string, error) {
+	s.enabled = secret
+	s.status.Enabled = s.err == nil
+	return s.codes, s.err
+}
+
+func (s *stubTwoFactor) TwoFactorStatus(_ context.Context, _ int64) (user.TwoFactorStatus, error) {
+	return s.status, nil
+}
+
+func (s *stubTwoFactor) RegenerateRecoveryCodes(_ context.Context, _ int64, _ string, _ time.Time) ([]string, error) {
+	return s.codes, s.err
+}
+
+func (s *stubTwoFactor) DisableTwoFactor(_ context.Context, _ int64, _ string, _ time.Time) error {
+	return s.err
+}
+
+func twoFactorLoginServer(sm *scs.SessionManager, auth handler.Authenticator, tf *stubTwoFactor, tracker *stubSessionTracker) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", handler.HandleLoginPost(sm, auth, tracker, nil))
+	mux.HandleFunc("GET /login/2fa", handler.HandleTwoFactorLoginPage(sm))
+	mux.HandleFunc("POST /login/2fa", handler.HandleTwoFactorLoginPost(sm, tf, tracker, nil))
+	return httptest.NewServer(sm.LoadAndSave(mux))
+}
+
+func jarClient(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("creating cookie jar: %v", err)
+	}
+	c := noFollowClient()
+	c.Jar = jar
+	return c
+}
+
+func TestLoginWithTwoFactorAsksForCode(t *testing.T) {
+	owner := user.User{ID: 4, Role: "owner", PharmacyID: 7, TwoFactorEnabled: true, Permissions: permission.ForRole("owner", nil)}
+	tracker := &stubSessionTracker{}
+	srv := twoFactorLoginServer(scs.New(), &stubAuthenticator{user: owner}, &stubTwoFactor{user: owner}, tracker)
+	defer srv.Close()
+	client := jarClient(t)
+
+	resp, err := client.PostForm(srv.URL+"/login", url.Values{"email": {"a@example.com"}, "password": {"pw"}})
+	if err != nil {
+		t.Fatalf("posting login: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/login/2fa" {
+		t.Fatalf("redirect = %q, want /login/2fa", loc)
+	}
+	if tracker.userID != 0 {
+		t.Error("the session must not be tracked before the second factor")
+	}
+
+	resp, err = client.PostForm(srv.URL+"/login/2fa", url.Values{"code": {"123456"}})
+	if err != nil {
+		t.Fatalf("posting code: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/dashboard" {
+		t.Errorf("redirect = %q, want /dashboard", loc)
+	}
+	if tracker.userID != 4 {
+		t.Errorf("tracked user = %d, want 4", tracker.userID)
+	}
+}
+
+func TestTwoFactorLoginWithoutPendingStepRedirects(t *testing.T) {
+	srv := twoFactorLoginServer(scs.New(), &stubAuthenticator{}, &stubTwoFactor{}, &stubSessionTracker{})
+	defer srv.Close()
+
+	resp, err := noFollowClient().Get(srv.URL + "/login/2fa")
+	if err != nil {
+		t.Fatalf("requesting code page: %v", err)
+	}
+	resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "/login" {
+		t.Errorf("redirect = %q, want /login", loc)
+	}
+}
+
+func TestTwoFactorLoginGivesUpAfterTooManyCodes(t *testing.T) {
+	owner := user.User{ID: 4, Role: "owner", TwoFactorEnabled: true, Permissions: permission.ForRole("owner", nil)}
+	tf := &stubTwoFactor{err: user.ErrInvalidSecondFactor}
+	srv := twoFactorLoginServer(scs.New(), &stubAuthenticator{user: owner}, tf, &stubSessionTracker{})
+	defer srv.Close()
+	client := jarClient(t)
+
+	resp, err := client.PostForm(srv.URL+"/login", url.Values{"email": {"a@example.com"}, "password": {"pw"}})
+	if err != nil {
+		t.Fatalf("posting login: %v", err)
+	}
+	resp.Body.Close()
+
+	var body string
+	for range 6 {
+		resp, err := client.PostForm(srv.URL+"/login/2fa", url.Values{"code": {"000000"}})
+		if err != nil {
+			t.Fatalf("posting code: %v", err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		body = string(b)
+	}
+
+	if tf.verified != 5 {
+		t.Errorf("verified %d codes, want 5 before giving up", tf.verified)
+	}
+	if !strings.Contains(body, "Accedi di nuovo") {
+		t.Error("expected the login page after too many codes")
+	}
+}
+
+func TestTwoFactorLoginLockedAccountEndsStep(t *testing.T) {
+	owner := user.User{ID: 4, Role: "owner", TwoFactorEnabled: true, Permissions: permission.ForRole("owner", nil)}
+	tf := &stubTwoFactor{err: user.ErrAccountLocked}
+	srv := twoFactorLoginServer(scs.New(), &stubAuthenticator{user: owner}, tf, &stubSessionTracker{})
+	defer srv.Close()
+	client := jarClient(t)
+
+	resp, err := client.PostForm(srv.URL+"/login", url.Values{"email": {"a@example.com"}, "password": {"pw"}})
+	if err != nil {
+		t.Fatalf("posting login: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.PostForm(srv.URL+"/login/2fa", url.Values{"code": {"000000"}})
+	if err != nil {
+		t.Fatalf("posting code: %v", err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(b), "account bloccato") {
+		t.Error("expected the login page saying the account is locked")
+	}
+
+	resp, err = client.PostForm(srv.URL+"/login/2fa", url.Values{"code": {"000000"}})
+	if err != nil {
+		t.Fatalf("posting code: %v", err)
+	}
+	resp.Body.Close()
+	if tf.verified != 1 {
+		t.Errorf("verified %d codes, want the step ended after the lock", tf.verified)
+	}
+}
+
+func twoFactorSettingsServer(sm *scs.SessionManager, tf *stubTwoFactor) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /account/2fa", handler.HandleTwoFactorSettings(sm, tf, tf))
+	mux.HandleFunc("POST /account/2fa/enable", handler.HandleEnableTwoFactor(sm, tf, tf))
+	mux.HandleFunc("POST /account/2fa/disable", handler.HandleDisableTwoFactor(sm, tf, tf))
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", "admin")
+		sm.Put(r.Context(), "mustEnrolTwoFactor", true)
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
+}
+
+func TestTwoFactorSettingsEnrolsWithPendingSecret(t *testing.T) {
+	tf := &stubTwoFactor{codes: []string{"abcde-fghij"}}
+	srv := twoFactorSettingsServer(scs.New(), tf)
+	defer srv.Close()
+	client := jarClient(t)
+
+	for _, path := range []string{"/setup-session", "/account/2fa", "/account/2fa"} {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("requesting %s: %v", path, err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if path == "/account/2fa" && (!strings.Contains(string(b), "<svg") || !strings.Contains(string(b), "JBSWY3DPEHPK3PXP")) {
+			t.Error("enrolment page missing the QR code or the manual key")
+		}
+	}
+	if tf.begun != 1 {
+		t.Errorf("enrolment started %d times, want 1 (reloads reuse the secret)", tf.begun)
+	}
+
+	resp, err := client.PostForm(srv.URL+"/account/2fa/enable", url.Values{"code": {"123456"}})
+	if err != nil {
+		t.Fatalf("enabling: %v", err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if tf.enabled != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("enabled secret = %q, want the pending one", tf.enabled)
+	}
+	if !strings.Contains(string(b), "abcde-fghij") {
+		t.Error("recovery codes not shown after enabling")
+	}
+	if strings.Contains(string(b), "Configurala per continuare") {
+		t.Error("the enrolment requirement must be cleared once enabled")
+	}
+}
+
+func TestDisableTwoFactorWhenRequiredShowsError(t *testing.T) {
+	tf := &stubTwoFactor{status: user.TwoFactorStatus{Enabled: true, Required: true}, err: user.ErrTwoFactorRequired}
+	srv := twoFactorSettingsServer(scs.New(), tf)
+	defer srv.Close()
+
+	resp := authenticatedPost(t, srv, "/account/2fa/disable", url.Values{"code": {"123456"}})
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(b), "non può essere disattivata") {
+		t.Error("expected the required 2FA error")
+	}
+}
@@ -0,0 +1,39 @@
+package web
+
+import (
+	"context"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/i18n"
+)
+
+// Locale returns the language of the current request.
+func Locale(ctx context.Context) i18n.Locale {
+	return i18n.FromContext(ctx)
+}
+
+// T translates a catalogue key into the language of the current request.
+func T(ctx context.Context, key string, args ...any) string {
+	return i18n.FromContext(ctx).T(key, args...)
+}
+
+// ErrorMessage translates a domain error for the user. It returns "" for
+// errors users should not see, which handlers answer with a 500.
+func ErrorMessage(ctx context.Context, err error) string {
+	return i18n.FromContext(ctx).Error(err)
+}
+
+func fmtDate(ctx context.Context, t time.Time) string {
+	return i18n.FromContext(ctx).Date(t)
+}
+
+// fmtDateTime formats an instant in local time, e.g. "02/03/2026 09:30".
+func fmtDateTime(ctx context.Context, t time.Time) string {
+	return i18n.FromContext(ctx).DateTime(t)
+}
+
+// fmtNumber formats a quantity for reading, with the locale's decimal
+// separator. Form values keep fmtFloat, which browsers parse.
+func fmtNumber(ctx context.Context, f float64) string {
+	return i18n.FromContext(ctx).Number(f, -1)
+}
@@ -2,10 +2,17 @@ package web
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/i18n"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
 )
 
 type contextKey string
@@ -17,14 +24,28 @@ const (
 	ctxKeyUserName            contextKey = "userName"
 	ctxKeyPharmacyName        contextKey = "pharmacyName"
 	ctxKeyUnreadNotifications contextKey = "unreadNotifications"
+	ctxKeyPatientID           contextKey = "patientID"
+	ctxKeyPatientPharmacyID   contextKey = "patientPharmacyID"
+	ctxKeyPatientName         contextKey = "patientName"
+	ctxKeyMustChangePassword  contextKey = "mustChangePassword"
+	ctxKeyMustEnrolTwoFactor  contextKey = "mustEnrolTwoFactor"
+	ctxKeyRequestInfo         contextKey = "requestInfo"
 )
 
-// LoadUser reads userID and role from the session and attaches them to
-// the request context. Does not redirect — use on all routes so the
-// layout can conditionally show nav items.
+// LoadUser reads userID, role and permissions from the session and attaches
+// them to the request context, where services check the permissions too.
+// It also picks the request's language: the one stored at login, else the
+// browser's. Does not redirect — use on all routes so the layout can
+// conditionally show nav items.
 func LoadUser(sessions *scs.SessionManager) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			locale := i18n.Negotiate(r.Header.Get("Accept-Language"))
+			if l := sessions.GetString(r.Context(), "locale"); l != "" {
+				locale = i18n.Parse(l)
+			}
+			r = r.WithContext(i18n.NewContext(r.Context(), locale))
+
 			userID := sessions.GetInt64(r.Context(), "userID")
 			if userID == 0 {
 				next.ServeHTTP(w, r)
@@ -36,17 +57,111 @@ func LoadUser(sessions *scs.SessionManager) func(http.Handler) http.Handler {
 			userName := sessions.GetString(r.Context(), "userName")
 			pharmacyName := sessions.GetString(r.Context(), "pharmacyName")
 
-			ctx := context.WithValue(r.Context(), ctxKeyUserID, userID)
+			ctx := identifyUser(r.Context(), userID, pharmacyID)
+			ctx = context.WithValue(ctx, ctxKeyUserID, userID)
 			ctx = context.WithValue(ctx, ctxKeyRole, role)
 			ctx = context.WithValue(ctx, ctxKeyPharmacyID, pharmacyID)
 			ctx = context.WithValue(ctx, ctxKeyUserName, userName)
 			ctx = context.WithValue(ctx, ctxKeyPharmacyName, pharmacyName)
+			ctx = context.WithValue(ctx, ctxKeyMustChangePassword, sessions.GetBool(r.Context(), "mustChangePassword"))
+			ctx = context.WithValue(ctx, ctxKeyMustEnrolTwoFactor, sessions.GetBool(r.Context(), "mustEnrolTwoFactor"))
+			ctx = permission.NewContext(ctx, sessionPermissions(sessions, r.Context(), role))
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// sessionPermissions returns the permissions stored at login. Sessions from
+// before permissions existed fall back to the built-in role's set.
+func sessionPermissions(sessions *scs.SessionManager, ctx context.Context, role string) permission.Set {
+	if names, ok := sessions.Get(ctx, "permissions").([]string); ok {
+		return permission.FromStrings(names)
+	}
+	return permission.ForRole(role, nil)
+}
+
+// BranchChecker confirms an owner can still reach a branch of their group.
+// Defined here (consumer-side).
+type BranchChecker interface {
+	SwitchBranch(ctx context.Context, userID, pharmacyID int64) (pharmacy.Branch, error)
+}
+
+// RecheckBranch keeps a switched branch within reach: while the session's
+// active pharmacy is not the user's own, every request asks checker again,
+// so a branch that leaves the group is out of reach at once. A session that
+// lost its branch falls back to the home pharmacy; one from before home
+// pharmacies were stored is logged out. Use before LoadUser, which then
+// sees the corrected session.
+func RecheckBranch(sessions *scs.SessionManager, checker BranchChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			userID := sessions.GetInt64(ctx, "userID")
+			active := sessions.GetInt64(ctx, "pharmacyID")
+			homeKnown := sessions.Exists(ctx, "homePharmacyID")
+			home := sessions.GetInt64(ctx, "homePharmacyID")
+			switched := homeKnown && active != home
+			legacy := !homeKnown && sessions.GetString(ctx, "role") == permission.RoleOwner
+			if userID == 0 || active == 0 || (!switched && !legacy) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			_, err := checker.SwitchBranch(ctx, userID, active)
+			switch {
+			case err == nil:
+			case errors.Is(err, pharmacy.ErrNotBranch) && homeKnown:
+				slog.InfoContext(ctx, "branch no longer reachable, back to home pharmacy", "user_id", userID, "pharmacy_id", active)
+				sessions.Put(ctx, "pharmacyID", home)
+				sessions.Put(ctx, "pharmacyName", sessions.GetString(ctx, "homePharmacyName"))
+			case errors.Is(err, pharmacy.ErrNotBranch):
+				if err := sessions.Destroy(ctx); err != nil {
+					slog.ErrorContext(ctx, "destroying session", "error", err)
+				}
+				http.Redirect(w, r, "/login", http.StatusSeeOther)
+				return
+			default:
+				slog.ErrorContext(ctx, "rechecking branch", "pharmacy_id", active, "error", err)
+				http.Error(w, T(ctx, "common.internal_error"), http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// sessionTouchInterval is how stale a session's last activity may get before
+// TouchSession writes it again, so every request does not hit the database.
+const sessionTouchInterval = 5 * time.Minute
+
+// SessionToucher records that a session was just used. Defined here
+// (consumer-side).
+type SessionToucher interface {
+	TouchSession(ctx context.Context, token string, at time.Time) error
+}
+
+// TouchSession keeps the last activity of a logged-in session up to date for
+// the sessions page. Must be used after LoadUser.
+func TouchSession(sessions *scs.SessionManager, toucher SessionToucher) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if UserID(r.Context()) != 0 {
+				now := time.Now()
+				last := time.Unix(sessions.GetInt64(r.Context(), "lastSeenAt"), 0)
+				if now.Sub(last) >= sessionTouchInterval {
+					if err := toucher.TouchSession(r.Context(), sessions.Token(r.Context()), now); err != nil {
+						slog.ErrorContext(r.Context(), "touching session", "error", err)
+					} else {
+						sessions.Put(r.Context(), "lastSeenAt", now.Unix())
+					}
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // RequireAuth redirects to /login if the user is not loaded in context.
 // Must be used after LoadUser.
 func RequireAuth(next http.Handler) http.Handler {
@@ -59,41 +174,90 @@ func RequireAuth(next http.Handler) http.Handler {
 	})
 }
 
-// RequireAdmin returns 403 Forbidden if the authenticated user is not an admin.
-// Must be used after LoadUser and RequireAuth.
-func RequireAdmin(next http.Handler) http.Handler {
+// RequirePasswordChanged sends a user whose password was reset by an owner or
+// admin to /change-password until they pick a new one. Logout and static
+// assets stay reachable. Must be used after LoadUser.
+func RequirePasswordChanged(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if Role(r.Context()) != "admin" {
-			http.Error(w, "Accesso negato.", http.StatusForbidden)
-			return
+		if MustChangePassword(r.Context()) {
+			switch {
+			case r.URL.Path == "/change-password", r.URL.Path == "/logout", strings.HasPrefix(r.URL.Path, "/static/"):
+			default:
+				http.Redirect(w, r, "/change-password", http.StatusSeeOther)
+				return
+			}
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-// RequirePharmacyStaff returns 403 Forbidden if the authenticated user has no
-// pharmacy association (i.e., is not an owner or personnel).
-// Must be used after LoadUser and RequireAuth.
-func RequirePharmacyStaff(next http.Handler) http.Handler {
+// RequireTwoFactorEnrolled sends a user who must use 2FA but has not set it
+// up to /account/2fa until they do. Password changes, logout and static
+// assets stay reachable. Must be used after LoadUser.
+func RequireTwoFactorEnrolled(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if PharmacyID(r.Context()) == 0 {
-			http.Error(w, "Accesso negato.", http.StatusForbidden)
-			return
+		if MustEnrolTwoFactor(r.Context()) {
+			switch {
+			case r.URL.Path == "/account/2fa", strings.HasPrefix(r.URL.Path, "/account/2fa/"),
+				r.URL.Path == "/change-password", r.URL.Path == "/logout", strings.HasPrefix(r.URL.Path, "/static/"):
+			default:
+				http.Redirect(w, r, "/account/2fa", http.StatusSeeOther)
+				return
+			}
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-// RequireOwner returns 403 Forbidden if the authenticated user is not an owner.
+// RequirePermission returns 403 Forbidden if the authenticated user lacks p.
 // Must be used after LoadUser and RequireAuth.
-func RequireOwner(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if Role(r.Context()) != "owner" {
-			http.Error(w, "Accesso negato.", http.StatusForbidden)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+func RequirePermission(p permission.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !Can(r.Context(), p) {
+				http.Error(w, T(r.Context(), "common.forbidden"), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireBearerToken answers 401 Unauthorized unless the request carries
+// "Authorization: Bearer <token>". It guards machine endpoints such as
+// /metrics, which have no session.
+func RequireBearerToken(token string) func(http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="pharmarecall"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Can reports whether the authenticated user holds p.
+func Can(ctx context.Context, p permission.Permission) bool {
+	return Permissions(ctx).Has(p)
+}
+
+// Home returns the page a user lands on after signing in: the pharmacies
+// for admins, otherwise the first area their permissions open.
+func Home(perms permission.Set) string {
+	switch {
+	case perms.Has(permission.ManagePharmacies):
+		return "/admin"
+	case perms.Has(permission.ViewOrders):
+		return "/dashboard"
+	case perms.Has(permission.ViewShipping):
+		return "/shipping"
+	default:
+		return "/account"
+	}
 }
 
 // UserID returns the authenticated user's ID from the request context.
@@ -108,6 +272,12 @@ func Role(ctx context.Context) string {
 	return role
 }
 
+// Permissions returns the authenticated user's permissions from the request context.
+func Permissions(ctx context.Context) permission.Set {
+	s, _ := permission.FromContext(ctx)
+	return s
+}
+
 // PharmacyID returns the authenticated user's pharmacy ID from the request context.
 func PharmacyID(ctx context.Context) int64 {
 	id, _ := ctx.Value(ctxKeyPharmacyID).(int64)
@@ -126,6 +296,20 @@ func PharmacyName(ctx context.Context) string {
 	return name
 }
 
+// MustChangePassword reports whether the authenticated user has to replace a
+// temporary password before using the application.
+func MustChangePassword(ctx context.Context) bool {
+	must, _ := ctx.Value(ctxKeyMustChangePassword).(bool)
+	return must
+}
+
+// MustEnrolTwoFactor reports whether the authenticated user has to set up
+// two-factor authentication before using the application.
+func MustEnrolTwoFactor(ctx context.Context) bool {
+	must, _ := ctx.Value(ctxKeyMustEnrolTwoFactor).(bool)
+	return must
+}
+
 // UnreadNotificationCounter counts unread notifications. Defined here (consumer-side).
 type UnreadNotificationCounter interface {
 	CountUnread(ctx context.Context, pharmacyID int64) (int64, error)
@@ -140,7 +324,7 @@ func LoadNotificationCount(counter UnreadNotificationCounter) func(http.Handler)
 			if pharmacyID != 0 {
 				count, err := counter.CountUnread(r.Context(), pharmacyID)
 				if err != nil {
-					slog.Error("counting unread notifications", "error", err)
+					slog.ErrorContext(r.Context(), "counting unread notifications", "error", err)
 				} else if count > 0 {
 					ctx := context.WithValue(r.Context(), ctxKeyUnreadNotifications, count)
 					r = r.WithContext(ctx)
@@ -156,3 +340,59 @@ func UnreadNotificationCount(ctx context.Context) int64 {
 	count, _ := ctx.Value(ctxKeyUnreadNotifications).(int64)
 	return count
 }
+
+// LoadPatient reads the portal patient from the patient session and attaches
+// it to the request context, with the browser's language. It is only mounted
+// on the portal, which never sees the staff session, so staff accessors stay
+// empty there and vice versa.
+func LoadPatient(sessions *scs.SessionManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(i18n.NewContext(r.Context(), i18n.Negotiate(r.Header.Get("Accept-Language"))))
+
+			patientID := sessions.GetInt64(r.Context(), "patientID")
+			if patientID == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			pharmacyID := sessions.GetInt64(r.Context(), "pharmacyID")
+			ctx := identifyPatient(r.Context(), patientID, pharmacyID)
+			ctx = context.WithValue(ctx, ctxKeyPatientID, patientID)
+			ctx = context.WithValue(ctx, ctxKeyPatientPharmacyID, pharmacyID)
+			ctx = context.WithValue(ctx, ctxKeyPatientName, sessions.GetString(r.Context(), "patientName"))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequirePatient redirects to /portal/login if no patient is loaded in context.
+// Must be used after LoadPatient.
+func RequirePatient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if PortalPatientID(r.Context()) == 0 {
+			http.Redirect(w, r, "/portal/login", http.StatusSeeOther)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PortalPatientID returns the logged-in patient's ID from the request context.
+func PortalPatientID(ctx context.Context) int64 {
+	id, _ := ctx.Value(ctxKeyPatientID).(int64)
+	return id
+}
+
+// PortalPharmacyID returns the logged-in patient's pharmacy ID from the request context.
+func PortalPharmacyID(ctx context.Context) int64 {
+	id, _ := ctx.Value(ctxKeyPatientPharmacyID).(int64)
+	return id
+}
+
+// PortalPatientName returns the logged-in patient's display name from the request context.
+func PortalPatientName(ctx context.Context) string {
+	name, _ := ctx.Value(ctxKeyPatientName).(string)
+	return name
+}
@@ -2,11 +2,16 @@ package web_test
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 )
 
@@ -21,6 +26,15 @@ func (s *stubUnreadCounter) CountUnread(_ context.Context, _ int64) (int64, erro
 	return s.result, s.err
 }
 
+type stubSessionToucher struct {
+	tokens []string
+}
+
+func (s *stubSessionToucher) TouchSession(_ context.Context, token string, _ time.Time) error {
+	s.tokens = append(s.tokens, token)
+	return nil
+}
+
 func TestRequireAuthRedirectsUnauthenticated(t *testing.T) {
 	sm := scs.New()
 
@@ -102,54 +116,25 @@ func TestLoadUserSetsContextForAuthenticatedUser(t *testing.T) {
 	}
 }
 
-func TestRequireAdminAllowsAdminRole(t *testing.T) {
-	sm := scs.New()
-
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-
-	mux := http.NewServeMux()
-	mux.Handle("GET /admin-only", web.RequireAdmin(handler))
-	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
-		sm.Put(r.Context(), "userID", int64(1))
-		sm.Put(r.Context(), "role", "admin")
-		w.WriteHeader(http.StatusOK)
-	})
-
-	srv := httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
-	defer srv.Close()
-
-	client := noFollowClient()
-	setupResp, err := client.Get(srv.URL + "/setup-session")
-	if err != nil {
-		t.Fatalf("setting up session: %v", err)
-	}
-	setupResp.Body.Close()
-
-	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/admin-only", nil)
-	for _, c := range setupResp.Cookies() {
-		req.AddCookie(c)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		t.Fatalf("requesting admin page: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("status = %d, want 200", resp.StatusCode)
-	}
-}
-
-func TestRequireAdminDeniesNonAdminRoles(t *testing.T) {
+func TestRequirePermission(t *testing.T) {
 	tests := []struct {
-		name string
-		role string
+		name        string
+		role        string
+		permissions []string
+		permission  permission.Permission
+		want        int
 	}{
-		{"owner denied", "owner"},
-		{"personnel denied", "personnel"},
+		{"admin manages pharmacies", "admin", nil, permission.ManagePharmacies, http.StatusOK},
+		{"owner manages personnel", "owner", nil, permission.ManagePersonnel, http.StatusOK},
+		{"trainee views orders", "trainee", nil, permission.ViewOrders, http.StatusOK},
+		{"custom role uses session permissions", "custom", []string{"shipping.view"}, permission.ViewShipping, http.StatusOK},
+		{"personnel cannot manage pharmacies", "personnel", nil, permission.ManagePharmacies, http.StatusForbidden},
+		{"admin cannot see patients", "admin", nil, permission.ViewPatients, http.StatusForbidden},
+		{"pharmacist cannot manage personnel", "pharmacist", nil, permission.ManagePersonnel, http.StatusForbidden},
+		{"trainee cannot advance orders", "trainee", nil, permission.AdvanceOrders, http.StatusForbidden},
+		{"driver cannot see patients", "driver", nil, permission.ViewPatients, http.StatusForbidden},
+		{"custom role without permission", "custom", []string{"shipping.view"}, permission.ViewOrders, http.StatusForbidden},
+		{"session permissions override role", "owner", []string{"orders.view"}, permission.ManagePersonnel, http.StatusForbidden},
 	}
 
 	for _, tt := range tests {
@@ -161,10 +146,13 @@ func TestRequireAdminDeniesNonAdminRoles(t *testing.T) {
 			})
 
 			mux := http.NewServeMux()
-			mux.Handle("GET /admin-only", web.RequireAdmin(handler))
+			mux.Handle("GET /guarded", web.RequirePermission(tt.permission)(handler))
 			mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
 				sm.Put(r.Context(), "userID", int64(1))
 				sm.Put(r.Context(), "role", tt.role)
+				if tt.permissions != nil {
+					sm.Put(r.Context(), "permissions", tt.permissions)
+				}
 				w.WriteHeader(http.StatusOK)
 			})
 
@@ -178,115 +166,84 @@ func TestRequireAdminDeniesNonAdminRoles(t *testing.T) {
 			}
 			setupResp.Body.Close()
 
-			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/admin-only", nil)
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/guarded", nil)
 			for _, c := range setupResp.Cookies() {
 				req.AddCookie(c)
 			}
 
 			resp, err := client.Do(req)
 			if err != nil {
-				t.Fatalf("requesting admin page: %v", err)
+				t.Fatalf("requesting guarded page: %v", err)
 			}
 			defer resp.Body.Close()
 
-			if resp.StatusCode != http.StatusForbidden {
-				t.Errorf("status = %d, want 403", resp.StatusCode)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
 			}
 		})
 	}
 }
 
-func TestRequireOwnerAllowsOwnerRole(t *testing.T) {
-	sm := scs.New()
-
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+func TestRequireBearerToken(t *testing.T) {
+	h := web.RequireBearerToken("s3cret")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
-	})
-
-	mux := http.NewServeMux()
-	mux.Handle("GET /owner-only", web.RequireOwner(handler))
-	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
-		sm.Put(r.Context(), "userID", int64(1))
-		sm.Put(r.Context(), "role", "owner")
-		w.WriteHeader(http.StatusOK)
-	})
-
-	srv := httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
-	defer srv.Close()
+	}))
 
-	client := noFollowClient()
-	setupResp, err := client.Get(srv.URL + "/setup-session")
-	if err != nil {
-		t.Fatalf("setting up session: %v", err)
+	for header, want := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"Basic s3cret":  http.StatusUnauthorized,
+		"Bearer s3cret": http.StatusOK,
+	} {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Authorization %q: status = %d, want %d", header, rec.Code, want)
+		}
+		if want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: missing WWW-Authenticate", header)
+		}
 	}
-	setupResp.Body.Close()
+}
 
-	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/owner-only", nil)
-	for _, c := range setupResp.Cookies() {
-		req.AddCookie(c)
-	}
+func TestRequirePermissionDeniesAnonymous(t *testing.T) {
+	sm := scs.New()
+	guarded := web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv := httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(guarded)))
+	defer srv.Close()
 
-	resp, err := client.Do(req)
+	resp, err := noFollowClient().Get(srv.URL + "/dashboard")
 	if err != nil {
-		t.Fatalf("requesting owner page: %v", err)
+		t.Fatalf("requesting guarded page: %v", err)
 	}
-	defer resp.Body.Close()
+	resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("status = %d, want 200", resp.StatusCode)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
 	}
 }
 
-func TestRequireOwnerDeniesNonOwnerRoles(t *testing.T) {
+func TestHome(t *testing.T) {
 	tests := []struct {
-		name string
 		role string
+		want string
 	}{
-		{"admin denied", "admin"},
-		{"personnel denied", "personnel"},
+		{"admin", "/admin"},
+		{"owner", "/dashboard"},
+		{"trainee", "/dashboard"},
+		{"driver", "/shipping"},
+		{"custom", "/account"},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			sm := scs.New()
-
-			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(http.StatusOK)
-			})
-
-			mux := http.NewServeMux()
-			mux.Handle("GET /owner-only", web.RequireOwner(handler))
-			mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
-				sm.Put(r.Context(), "userID", int64(1))
-				sm.Put(r.Context(), "role", tt.role)
-				w.WriteHeader(http.StatusOK)
-			})
-
-			srv := httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(mux)))
-			defer srv.Close()
-
-			client := noFollowClient()
-			setupResp, err := client.Get(srv.URL + "/setup-session")
-			if err != nil {
-				t.Fatalf("setting up session: %v", err)
-			}
-			setupResp.Body.Close()
-
-			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/owner-only", nil)
-			for _, c := range setupResp.Cookies() {
-				req.AddCookie(c)
-			}
-
-			resp, err := client.Do(req)
-			if err != nil {
-				t.Fatalf("requesting owner page: %v", err)
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusForbidden {
-				t.Errorf("status = %d, want 403", resp.StatusCode)
-			}
-		})
+		if got := web.Home(permission.ForRole(tt.role, nil)); got != tt.want {
+			t.Errorf("Home(%s) = %q, want %q", tt.role, got, tt.want)
+		}
 	}
 }
 
@@ -586,3 +543,246 @@ func TestLoadNotificationCountSkipsForUnauthenticated(t *testing.T) {
 		t.Errorf("unreadCount = %d, want 0 (unauthenticated)", gotCount)
 	}
 }
+
+func TestRequirePasswordChangedRedirectsUntilPasswordChanged(t *testing.T) {
+	sm := scs.New()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /dashboard", ok)
+	mux.Handle("GET /change-password", ok)
+	mux.Handle("POST /logout", ok)
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", "personnel")
+		sm.Put(r.Context(), "mustChangePassword", true)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(web.RequirePasswordChanged(mux))))
+	defer srv.Close()
+
+	client := noFollowClient()
+	setupResp, err := client.Get(srv.URL + "/setup-session")
+	if err != nil {
+		t.Fatalf("setting up session: %v", err)
+	}
+	setupResp.Body.Close()
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/dashboard", http.StatusSeeOther},
+		{http.MethodGet, "/change-password", http.StatusOK},
+		{http.MethodPost, "/logout", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+		for _, c := range setupResp.Cookies() {
+			req.AddCookie(c)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("requesting %s: %v", tt.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestRequireTwoFactorEnrolledRedirectsUntilEnrolled(t *testing.T) {
+	sm := scs.New()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /admin", ok)
+	mux.Handle("GET /account/2fa", ok)
+	mux.Handle("POST /account/2fa/enable", ok)
+	mux.Handle("POST /logout", ok)
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		sm.Put(r.Context(), "role", "admin")
+		sm.Put(r.Context(), "mustEnrolTwoFactor", true)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(web.RequireTwoFactorEnrolled(mux))))
+	defer srv.Close()
+
+	client := noFollowClient()
+	setupResp, err := client.Get(srv.URL + "/setup-session")
+	if err != nil {
+		t.Fatalf("setting up session: %v", err)
+	}
+	setupResp.Body.Close()
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/admin", http.StatusSeeOther},
+		{http.MethodGet, "/account/2fa", http.StatusOK},
+		{http.MethodPost, "/account/2fa/enable", http.StatusOK},
+		{http.MethodPost, "/logout", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+		for _, c := range setupResp.Cookies() {
+			req.AddCookie(c)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("requesting %s: %v", tt.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+// --- TouchSession tests ---
+
+func TestTouchSessionWritesAtMostOncePerInterval(t *testing.T) {
+	sm := scs.New()
+	toucher := &stubSessionToucher{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /check", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(1))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := httptest.NewServer(sm.LoadAndSave(web.LoadUser(sm)(web.TouchSession(sm, toucher)(mux))))
+	defer srv.Close()
+
+	client := noFollowClient()
+	setupResp, err := client.Get(srv.URL + "/setup-session")
+	if err != nil {
+		t.Fatalf("setting up session: %v", err)
+	}
+	setupResp.Body.Close()
+	if len(toucher.tokens) != 0 {
+		t.Fatal("an anonymous request must not touch a session")
+	}
+
+	for range 3 {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/check", nil)
+		for _, c := range setupResp.Cookies() {
+			req.AddCookie(c)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("requesting page: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(toucher.tokens) != 1 {
+		t.Fatalf("touched %d times, want 1", len(toucher.tokens))
+	}
+	if toucher.tokens[0] != setupResp.Cookies()[0].Value {
+		t.Errorf("touched token %q, want the session cookie", toucher.tokens[0])
+	}
+}
+
+// --- RecheckBranch tests ---
+
+type stubBranchChecker struct {
+	allowed map[int64]bool
+	checked []int64
+}
+
+func (s *stubBranchChecker) SwitchBranch(_ context.Context, _, pharmacyID int64) (pharmacy.Branch, error) {
+	s.checked = append(s.checked, pharmacyID)
+	if !s.allowed[pharmacyID] {
+		return pharmacy.Branch{}, pharmacy.ErrNotBranch
+	}
+	return pharmacy.Branch{ID: pharmacyID}, nil
+}
+
+func TestRecheckBranch(t *testing.T) {
+	tests := []struct {
+		name       string
+		home       int64 // 0: a session from before home pharmacies were stored
+		active     int64
+		allowed    map[int64]bool
+		wantStatus int
+		wantActive string
+		wantChecks int
+	}{
+		{"home pharmacy is not checked", 1, 1, nil, http.StatusOK, "1 Centrale", 0},
+		{"branch still in the group", 1, 2, map[int64]bool{2: true}, http.StatusOK, "2 Nord", 1},
+		{"branch left the group", 1, 2, nil, http.StatusOK, "1 Centrale", 1},
+		{"legacy session keeps a reachable branch", 0, 2, map[int64]bool{2: true}, http.StatusOK, "2 Nord", 1},
+		{"legacy session on a lost branch is logged out", 0, 2, nil, http.StatusSeeOther, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := scs.New()
+			checker := &stubBranchChecker{allowed: tt.allowed}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+				sm.Put(r.Context(), "userID", int64(5))
+				sm.Put(r.Context(), "role", permission.RoleOwner)
+				sm.Put(r.Context(), "pharmacyID", tt.active)
+				sm.Put(r.Context(), "pharmacyName", "Nord")
+				if tt.home != 0 {
+					sm.Put(r.Context(), "homePharmacyID", tt.home)
+					sm.Put(r.Context(), "homePharmacyName", "Centrale")
+				}
+				if tt.active == tt.home {
+					sm.Put(r.Context(), "pharmacyName", "Centrale")
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+			mux.HandleFunc("GET /check", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "%d %s", web.PharmacyID(r.Context()), web.PharmacyName(r.Context()))
+			})
+			srv := httptest.NewServer(sm.LoadAndSave(web.RecheckBranch(sm, checker)(web.LoadUser(sm)(mux))))
+			defer srv.Close()
+
+			client := noFollowClient()
+			setupResp, err := client.Get(srv.URL + "/setup-session")
+			if err != nil {
+				t.Fatalf("setting up session: %v", err)
+			}
+			setupResp.Body.Close()
+			checker.checked = nil
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/check", nil)
+			for _, c := range setupResp.Cookies() {
+				req.AddCookie(c)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("requesting page: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantActive != "" && string(body) != tt.wantActive {
+				t.Errorf("active pharmacy = %q, want %q", body, tt.wantActive)
+			}
+			if len(checker.checked) != tt.wantChecks {
+				t.Errorf("checked %v, want %d checks", checker.checked, tt.wantChecks)
+			}
+		})
+	}
+}
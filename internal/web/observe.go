@@ -0,0 +1,178 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/logging"
+	"github.com/giorgiovilardo/pharmarecall/internal/metrics"
+	"github.com/giorgiovilardo/pharmarecall/internal/tracing"
+)
+
+// RequestIDHeader carries the request ID. One set by the proxy in front is
+// kept, so its logs and ours agree; the response always echoes it.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs taken from the client.
+const maxRequestIDLength = 64
+
+// requestInfo collects who made a request as the chain finds out, for the
+// line LogRequests writes once it is served.
+type requestInfo struct {
+	userID     int64
+	pharmacyID int64
+	patientID  int64
+}
+
+// LogRequests assigns every request an ID, or keeps a well-formed one from
+// RequestIDHeader, and adds it to the context so records logged with the
+// context carry it. Once served, it logs the method, path, status, duration
+// and the user or patient who made the request. Probes log at debug level.
+func LogRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = rand.Text()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		info := &requestInfo{}
+		ctx := logging.With(r.Context(), slog.String("request_id", id))
+		ctx = context.WithValue(ctx, ctxKeyRequestInfo, info)
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", loggedPath(r.URL.Path, metrics.RouteOf(ctx))),
+			slog.Int("status", sw.status),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if info.userID != 0 {
+			attrs = append(attrs, slog.Int64("user_id", info.userID), slog.Int64("pharmacy_id", info.pharmacyID))
+		}
+		if info.patientID != 0 {
+			attrs = append(attrs, slog.Int64("patient_id", info.patientID), slog.Int64("pharmacy_id", info.pharmacyID))
+		}
+		level := slog.LevelInfo
+		switch {
+		case sw.status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case isProbe(r.URL.Path):
+			level = slog.LevelDebug
+		}
+		slog.LogAttrs(ctx, level, "request", attrs...)
+	})
+}
+
+// identifyUser records the signed-in staff member for the request log and
+// every record logged further down the chain.
+func identifyUser(ctx context.Context, userID, pharmacyID int64) context.Context {
+	if info, ok := ctx.Value(ctxKeyRequestInfo).(*requestInfo); ok {
+		info.userID, info.pharmacyID = userID, pharmacyID
+	}
+	return logging.With(ctx, slog.Int64("user_id", userID), slog.Int64("pharmacy_id", pharmacyID))
+}
+
+// identifyPatient is identifyUser for the patient portal.
+func identifyPatient(ctx context.Context, patientID, pharmacyID int64) context.Context {
+	if info, ok := ctx.Value(ctxKeyRequestInfo).(*requestInfo); ok {
+		info.patientID, info.pharmacyID = patientID, pharmacyID
+	}
+	return logging.With(ctx, slog.Int64("patient_id", patientID), slog.Int64("pharmacy_id", pharmacyID))
+}
+
+// validRequestID accepts short IDs of letters, digits, dashes, dots and
+// underscores, so a client cannot forge log lines through the header.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// loggedPath keeps secrets out of the logs: login and password reset links
+// carry a token in the path, so those requests are logged by route pattern.
+func loggedPath(path, route string) string {
+	if strings.Contains(route, "{token}") {
+		_, pattern, _ := strings.Cut(route, " ")
+		return pattern
+	}
+	return path
+}
+
+func isProbe(path string) bool {
+	return path == "/healthz" || path == "/readyz" || path == "/metrics"
+}
+
+// TraceRequests starts a server span for every request, continuing the
+// trace of a W3C traceparent header. The span is named after the route
+// pattern once known. With tracing disabled the spans are no-ops.
+func TraceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("http.request.method", r.Method)),
+		)
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		route := metrics.RouteOf(ctx)
+		if route != "" {
+			span.SetName(route)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		span.SetAttributes(
+			attribute.String("url.path", loggedPath(r.URL.Path, route)),
+			attribute.Int("http.response.status_code", sw.status),
+		)
+		if sw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(sw.status))
+		}
+	})
+}
+
+// statusWriter remembers the status code written.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusWriter) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusWriter) Write(p []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the original writer.
+func (s *statusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
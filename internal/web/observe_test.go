@@ -0,0 +1,158 @@
+package web_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/logging"
+	"github.com/giorgiovilardo/pharmarecall/internal/metrics"
+	"github.com/giorgiovilardo/pharmarecall/internal/web"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// captureLogs sends the default logger's JSON records to the returned
+// buffer until the test ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(logging.New(&buf, logging.FormatJSON))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+// records decodes one JSON record per line.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("log line is not JSON: %v\n%s", err, line)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestLogRequestsTagsRecordsWithRequestAndUser(t *testing.T) {
+	buf := captureLogs(t)
+	sm := scs.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /setup-session", func(w http.ResponseWriter, r *http.Request) {
+		sm.Put(r.Context(), "userID", int64(5))
+		sm.Put(r.Context(), "pharmacyID", int64(7))
+	})
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		slog.ErrorContext(r.Context(), "listing patients", "error", "boom")
+		http.Error(w, "internal", http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(web.LogRequests(sm.LoadAndSave(web.LoadUser(sm)(mux))))
+	defer srv.Close()
+
+	setup, err := http.Get(srv.URL + "/setup-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup.Body.Close()
+	buf.Reset()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/fail", nil)
+	req.Header.Set(web.RequestIDHeader, "proxy-id-123")
+	for _, c := range setup.Cookies() {
+		req.AddCookie(c)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got := resp.Header.Get(web.RequestIDHeader); got != "proxy-id-123" {
+		t.Errorf("response request ID = %q, want the proxy's", got)
+	}
+	recs := records(t, buf)
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want the handler's and the request line:\n%s", len(recs), buf.String())
+	}
+	for _, rec := range recs {
+		if rec["request_id"] != "proxy-id-123" || rec["user_id"] != float64(5) || rec["pharmacy_id"] != float64(7) {
+			t.Errorf("record %q lacks the request and user: %v", rec["msg"], rec)
+		}
+	}
+	line := recs[1]
+	if line["msg"] != "request" || line["level"] != "ERROR" || line["method"] != "GET" || line["path"] != "/fail" || line["status"] != float64(500) {
+		t.Errorf("request line = %v", line)
+	}
+}
+
+func TestLogRequestsReplacesMalformedRequestID(t *testing.T) {
+	captureLogs(t)
+	h := web.LogRequests(http.HandlerFunc(noopHandler))
+
+	for _, id := range []string{"", "forged\nlevel=INFO", strings.Repeat("a", 65)} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(web.RequestIDHeader, id)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got := rec.Header().Get(web.RequestIDHeader); got == "" || got == id {
+			t.Errorf("request ID %q: response ID = %q, want a fresh one", id, got)
+		}
+	}
+}
+
+func TestLogRequestsHidesTokensInPaths(t *testing.T) {
+	buf := captureLogs(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /reset-password/{token}", noopHandler)
+	h := metrics.Instrument(web.LogRequests(metrics.Route(mux)))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reset-password/s3cret", nil))
+
+	if strings.Contains(buf.String(), "s3cret") {
+		t.Errorf("token logged: %s", buf.String())
+	}
+	if recs := records(t, buf); len(recs) != 1 || recs[0]["path"] != "/reset-password/{token}" {
+		t.Errorf("records = %v", recs)
+	}
+}
+
+func TestTraceRequestsContinuesTraceAndNamesSpanByRoute(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /patients/{id}", noopHandler)
+	h := metrics.Instrument(web.TraceRequests(metrics.Route(mux)))
+
+	req := httptest.NewRequest("GET", "/patients/42", nil)
+	req.Header.Set("traceparent", "00-0102030405060708090a0b0c0d0e0f10-0102030405060708-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d spans, want 1", len(ended))
+	}
+	s := ended[0]
+	if s.Name() != "GET /patients/{id}" {
+		t.Errorf("span name = %q", s.Name())
+	}
+	if got := s.SpanContext().TraceID().String(); got != "0102030405060708090a0b0c0d0e0f10" {
+		t.Errorf("trace ID = %s, want the caller's", got)
+	}
+}
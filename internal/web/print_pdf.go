@@ -0,0 +1,244 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giorgiovilardo/pharmarecall/internal/barcode"
+	"github.com/giorgiovilardo/pharmarecall/internal/depletion"
+	"github.com/giorgiovilardo/pharmarecall/internal/i18n"
+	"github.com/giorgiovilardo/pharmarecall/internal/order"
+	"github.com/giorgiovilardo/pharmarecall/internal/pdf"
+	"github.com/giorgiovilardo/pharmarecall/internal/pharmacy"
+)
+
+// labelLayouts maps the pharmacy label layout setting to its physical sheet.
+var labelLayouts = map[string]pdf.LabelLayout{
+	pharmacy.LabelLayoutA4x14:  pdf.LayoutA4x14,
+	pharmacy.LabelLayoutRoll62: pdf.LayoutRoll62,
+}
+
+// ptToMM converts a font size in points to millimetres.
+const ptToMM = 25.4 / 72
+
+// PrintLabelsPDF renders one label per entry on the given label layout,
+// mirroring PrintLabelsPage. Unknown layouts fall back to A4. logo is the
+// pharmacy's JPEG logo, nil for none.
+func PrintLabelsPDF(w io.Writer, entries []order.DashboardEntry, refs map[int64]string, layoutName string, logo []byte) error {
+	layout, ok := labelLayouts[layoutName]
+	if !ok {
+		layout = pdf.LayoutA4x14
+	}
+
+	var logoImg *pdf.Image
+	if logo != nil {
+		img, err := pdf.NewJPEG(logo)
+		if err != nil {
+			return fmt.Errorf("reading logo: %w", err)
+		}
+		logoImg = img
+	}
+
+	doc := pdf.New(layout.PageWidth, layout.PageHeight)
+	for i, entry := range entries {
+		slot := i % layout.PerPage()
+		if slot == 0 {
+			doc.AddPage()
+		}
+		x, y := layout.Position(slot)
+		if err := drawLabel(doc, x, y, layout.LabelWidth, layout.LabelHeight, entry, refs[entry.OrderID], logoImg); err != nil {
+			return fmt.Errorf("drawing label for order %d: %w", entry.OrderID, err)
+		}
+	}
+
+	if _, err := doc.WriteTo(w); err != nil {
+		return fmt.Errorf("writing labels pdf: %w", err)
+	}
+	return nil
+}
+
+func drawLabel(doc *pdf.Document, x, y, width, height float64, entry order.DashboardEntry, ref string, logo *pdf.Image) error {
+	const (
+		pad     = 3.0
+		refSize = 6.0
+	)
+	inner := width - 2*pad
+	cy := y + pad
+
+	barHeight := min(10, height/4)
+	refBaseline := y + height - pad
+	barTop := refBaseline - refSize*ptToMM - 0.5 - barHeight
+	textBottom := y + height - pad
+	if ref != "" {
+		textBottom = barTop - 0.5
+	}
+
+	// The logo sits in the top-right corner; lines beside it are shortened.
+	var logoWidth, logoBottom float64
+	if logo != nil {
+		logoHeight := min(8, height/5)
+		logoWidth = min(logoHeight*logo.AspectRatio(), inner/3)
+		logoHeight = logoWidth / logo.AspectRatio()
+		doc.Image(logo, x+width-pad-logoWidth, y+pad, logoWidth, logoHeight)
+		logoBottom = y + pad + logoHeight
+	}
+
+	// line draws the next text line, dropping it if it would run into the barcode.
+	line := func(font pdf.Font, size float64, s string) {
+		if s == "" || cy+size*ptToMM > textBottom {
+			return
+		}
+		maxWidth := inner
+		if cy < logoBottom {
+			maxWidth -= logoWidth + 1
+		}
+		cy += size * ptToMM
+		doc.Text(x+pad, cy, font, size, pdf.Truncate(s, font, size, maxWidth))
+		cy += 1
+	}
+
+	line(pdf.HelveticaBold, 10, entry.FirstName+" "+entry.LastName)
+	line(pdf.Helvetica, 9, entry.MedicationName)
+	if entry.Fulfillment == "shipping" {
+		line(pdf.HelveticaBold, 8, "Spedizione")
+		line(pdf.Helvetica, 8, entry.DeliveryAddress.Line1())
+		line(pdf.Helvetica, 8, entry.DeliveryAddress.Line2())
+		line(pdf.Helvetica, 7, entry.DeliveryAddress.Notes)
+	} else {
+		if entry.PickupAt != nil && entry.PickupConfirmed {
+			line(pdf.HelveticaBold, 8, "Ritiro "+pickupTime(i18n.Default, *entry.PickupAt))
+		} else {
+			line(pdf.HelveticaBold, 8, "Ritiro")
+		}
+		var contacts []string
+		for _, c := range []string{entry.Phone, entry.Email} {
+			if c != "" {
+				contacts = append(contacts, c)
+			}
+		}
+		line(pdf.Helvetica, 8, strings.Join(contacts, " · "))
+	}
+
+	if ref == "" {
+		return nil
+	}
+
+	modules, err := barcode.Code128Modules(ref)
+	if err != nil {
+		return err
+	}
+
+	moduleWidth := min(0.33, inner/float64(len(modules)))
+	for start := 0; start < len(modules); {
+		end := start
+		for end < len(modules) && modules[end] == modules[start] {
+			end++
+		}
+		if modules[start] {
+			doc.Rect(x+pad+float64(start)*moduleWidth, barTop, float64(end-start)*moduleWidth, barHeight)
+		}
+		start = end
+	}
+	doc.Text(x+pad, refBaseline, pdf.Helvetica, refSize, ref)
+	return nil
+}
+
+// dashboardColumn is a column of the printed order list.
+type dashboardColumn struct {
+	title string
+	width float64
+	value func(order.DashboardEntry, time.Time) string
+}
+
+var dashboardColumns = []dashboardColumn{
+	{"Paziente", 45, func(e order.DashboardEntry, _ time.Time) string { return e.FirstName + " " + e.LastName }},
+	{"Farmaco", 45, func(e order.DashboardEntry, _ time.Time) string { return e.MedicationName }},
+	{"Unità/conf.", 16, func(e order.DashboardEntry, _ time.Time) string { return strconv.Itoa(e.UnitsPerBox) }},
+	{"Esaurimento", 21, func(e order.DashboardEntry, _ time.Time) string { return i18n.Default.Date(e.EstimatedDepletionDate) }},
+	{"Stato presc.", 22, func(e order.DashboardEntry, now time.Time) string {
+		return prescriptionStatusLabel(e.PrepareStatus(now))
+	}},
+	{"Consegna", 19, func(e order.DashboardEntry, _ time.Time) string {
+		if e.Fulfillment == "pickup" {
+			return "Ritiro"
+		}
+		return "Spedizione"
+	}},
+	{"Stato ordine", 22, func(e order.DashboardEntry, _ time.Time) string {
+		return i18n.Default.T("order_status." + e.OrderStatus)
+	}},
+}
+
+// prescriptionStatusLabel returns the Italian label for a depletion status,
+// matching orderPrescriptionStatusBadge.
+func prescriptionStatusLabel(status string) string {
+	switch status {
+	case depletion.StatusApproaching:
+		return "in esaurimento"
+	case depletion.StatusDepleted:
+		return "esaurito"
+	default:
+		return status
+	}
+}
+
+// PrintDashboardPDF renders the order list on A4 pages, mirroring PrintDashboardPage.
+func PrintDashboardPDF(w io.Writer, entries []order.DashboardEntry, now time.Time, pharmacyName string) error {
+	const (
+		pageWidth  = 210.0
+		pageHeight = 297.0
+		margin     = 10.0
+		rowHeight  = 6.0
+		fontSize   = 8.0
+	)
+
+	doc := pdf.New(pageWidth, pageHeight)
+	doc.AddPage()
+
+	y := margin + 14*ptToMM
+	doc.Text(margin, y, pdf.HelveticaBold, 14, pharmacyName)
+	y += 6
+	doc.Text(margin, y, pdf.Helvetica, 9, "Stampato il: "+fmtNow(now))
+	y += 8
+
+	if len(entries) == 0 {
+		doc.Text(margin, y, pdf.Helvetica, 10, "Nessun ordine da stampare.")
+		if _, err := doc.WriteTo(w); err != nil {
+			return fmt.Errorf("writing dashboard pdf: %w", err)
+		}
+		return nil
+	}
+
+	header := func() {
+		x := margin
+		for _, c := range dashboardColumns {
+			doc.Text(x, y, pdf.HelveticaBold, fontSize, c.title)
+			x += c.width
+		}
+		doc.Line(margin, y+1.5, pageWidth-margin, y+1.5, 0.3)
+		y += rowHeight
+	}
+
+	header()
+	for _, entry := range entries {
+		if y > pageHeight-margin {
+			doc.AddPage()
+			y = margin + fontSize*ptToMM
+			header()
+		}
+		x := margin
+		for _, c := range dashboardColumns {
+			doc.Text(x, y, pdf.Helvetica, fontSize, pdf.Truncate(c.value(entry, now), pdf.Helvetica, fontSize, c.width-2))
+			x += c.width
+		}
+		y += rowHeight
+	}
+
+	if _, err := doc.WriteTo(w); err != nil {
+		return fmt.Errorf("writing dashboard pdf: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package web
 import (
 	"net/http"
 
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/giorgiovilardo/pharmarecall/static"
 )
 
@@ -15,13 +16,56 @@ type AdminHandlers struct {
 	UpdatePharmacy  http.HandlerFunc
 	AddPersonnel    http.HandlerFunc
 	CreatePersonnel http.HandlerFunc
+	RevokeSessions  http.HandlerFunc
+	SSOSettings     http.HandlerFunc
+	SaveSSOSettings http.HandlerFunc
+	Personnel       PersonnelHandlers
 }
 
-// OwnerHandlers groups all owner-only handler funcs.
+// OwnerHandlers groups the handler funcs of owner areas: personnel, roles,
+// statistics and branches.
 type OwnerHandlers struct {
 	PersonnelList   http.HandlerFunc
 	AddPersonnel    http.HandlerFunc
 	CreatePersonnel http.HandlerFunc
+	Analytics       http.HandlerFunc
+	Group           http.HandlerFunc
+	SwitchBranch    http.HandlerFunc
+	Personnel       PersonnelHandlers
+	Roles           RoleHandlers
+	Settings        SettingsHandlers
+}
+
+// SettingsHandlers groups the handler funcs of the owner's pharmacy settings.
+type SettingsHandlers struct {
+	Page       http.HandlerFunc
+	Update     http.HandlerFunc
+	Logo       http.HandlerFunc
+	UploadLogo http.HandlerFunc
+	RemoveLogo http.HandlerFunc
+}
+
+// RoleHandlers groups the handler funcs of the pharmacy's custom roles.
+type RoleHandlers struct {
+	List   http.HandlerFunc
+	New    http.HandlerFunc
+	Create http.HandlerFunc
+	Edit   http.HandlerFunc
+	Update http.HandlerFunc
+	Delete http.HandlerFunc
+}
+
+// PersonnelHandlers groups the lifecycle handlers of one personnel member,
+// mounted for owners and admins alike.
+type PersonnelHandlers struct {
+	Member         http.HandlerFunc
+	Deactivate     http.HandlerFunc
+	Reactivate     http.HandlerFunc
+	Unlock         http.HandlerFunc
+	ChangeRole     http.HandlerFunc
+	ResetPassword  http.HandlerFunc
+	ResetTwoFactor http.HandlerFunc
+	Remove         http.HandlerFunc
 }
 
 // PatientHandlers groups all patient handler funcs (owner + personnel).
@@ -32,6 +76,8 @@ type PatientHandlers struct {
 	Detail       http.HandlerFunc
 	Update       http.HandlerFunc
 	SetConsensus http.HandlerFunc
+	TransferPage http.HandlerFunc
+	Transfer     http.HandlerFunc
 }
 
 // PrescriptionHandlers groups all prescription handler funcs.
@@ -41,6 +87,7 @@ type PrescriptionHandlers struct {
 	Edit         http.HandlerFunc
 	Update       http.HandlerFunc
 	RecordRefill http.HandlerFunc
+	ReportStock  http.HandlerFunc
 }
 
 // OrderHandlers groups all order/dashboard handler funcs.
@@ -50,6 +97,37 @@ type OrderHandlers struct {
 	PrintDashboard   http.HandlerFunc
 	PrintLabel       http.HandlerFunc
 	PrintBatchLabels http.HandlerFunc
+	ScanPage         http.HandlerFunc
+	ScanPost         http.HandlerFunc
+}
+
+// ShippingHandlers groups all shipping batch handler funcs.
+type ShippingHandlers struct {
+	List          http.HandlerFunc
+	CreateBatch   http.HandlerFunc
+	Batch         http.HandlerFunc
+	Manifest      http.HandlerFunc
+	Export        http.HandlerFunc
+	SetTracking   http.HandlerFunc
+	Ship          http.HandlerFunc
+	MarkDelivered http.HandlerFunc
+}
+
+// PickupHandlers groups all pickup scheduling handler funcs.
+type PickupHandlers struct {
+	Day          http.HandlerFunc
+	Settings     http.HandlerFunc
+	SaveSettings http.HandlerFunc
+	AssignPage   http.HandlerFunc
+	Assign       http.HandlerFunc
+}
+
+// CalendarHandlers groups all closure calendar handler funcs (owner only).
+type CalendarHandlers struct {
+	Page          http.HandlerFunc
+	SaveSettings  http.HandlerFunc
+	AddClosure    http.HandlerFunc
+	DeleteClosure http.HandlerFunc
 }
 
 // NotificationHandlers groups all notification handler funcs.
@@ -59,78 +137,256 @@ type NotificationHandlers struct {
 	MarkAllRead http.HandlerFunc
 }
 
+// PortalHandlers groups all patient portal handler funcs.
+type PortalHandlers struct {
+	LoginPage    http.HandlerFunc
+	LoginPost    http.HandlerFunc
+	CodePost     http.HandlerFunc
+	LinkPage     http.HandlerFunc
+	LinkPost     http.HandlerFunc
+	Logout       http.HandlerFunc
+	Home         http.HandlerFunc
+	Confirm      http.HandlerFunc
+	PostponePage http.HandlerFunc
+	Postpone     http.HandlerFunc
+	ReportStock  http.HandlerFunc
+}
+
+// TwoFactorHandlers groups the account's 2FA settings handler funcs.
+type TwoFactorHandlers struct {
+	Settings      http.HandlerFunc
+	Enable        http.HandlerFunc
+	Disable       http.HandlerFunc
+	RecoveryCodes http.HandlerFunc
+}
+
+// SessionHandlers groups the handler funcs for the user's own sessions.
+type SessionHandlers struct {
+	List         http.HandlerFunc
+	Revoke       http.HandlerFunc
+	RevokeOthers http.HandlerFunc
+}
+
 // Handlers groups all handler funcs for routing.
 type Handlers struct {
 	LoginPage      http.HandlerFunc
 	LoginPost      http.HandlerFunc
+	LoginCodePage  http.HandlerFunc
+	LoginCodePost  http.HandlerFunc
+	SSOLogin       http.HandlerFunc
+	SSOCallback    http.HandlerFunc
 	Logout         http.HandlerFunc
 	ChangePassPage http.HandlerFunc
 	ChangePassPost http.HandlerFunc
+	ForgotPassPage http.HandlerFunc
+	ForgotPassPost http.HandlerFunc
+	ResetPassPage  http.HandlerFunc
+	ResetPassPost  http.HandlerFunc
+	Account        http.HandlerFunc
+	SetLocale      http.HandlerFunc
+	TwoFactor      TwoFactorHandlers
+	Sessions       SessionHandlers
 	Admin          AdminHandlers
 	Owner          OwnerHandlers
 	Patient        PatientHandlers
 	Prescription   PrescriptionHandlers
 	Order          OrderHandlers
+	Shipping       ShippingHandlers
+	Pickup         PickupHandlers
+	Calendar       CalendarHandlers
 	Notification   NotificationHandlers
 }
 
 // NewRouter builds the ServeMux with all routes. Handlers are constructed
 // by the caller (main or tests) and passed in ready to use.
-// Middleware (sessions, CORS) is applied by the caller.
+// Middleware (sessions, CORS) is applied by the caller. Staff routes are
+// guarded by the permission they need rather than by role.
 func NewRouter(h Handlers) *http.ServeMux {
 	mux := http.NewServeMux()
+	admin := RequirePermission(permission.ManagePharmacies)
 	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(static.Files)))
 	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
 		HealthPage().Render(r.Context(), w)
 	})
 	mux.HandleFunc("GET /login", h.LoginPage)
 	mux.HandleFunc("POST /login", h.LoginPost)
+	mux.HandleFunc("GET /login/2fa", h.LoginCodePage)
+	mux.HandleFunc("POST /login/2fa", h.LoginCodePost)
+	mux.HandleFunc("GET /login/sso/{id}", h.SSOLogin)
+	mux.HandleFunc("GET /login/sso/callback", h.SSOCallback)
 	mux.HandleFunc("POST /logout", h.Logout)
 	mux.HandleFunc("GET /change-password", h.ChangePassPage)
 	mux.HandleFunc("POST /change-password", h.ChangePassPost)
+	mux.HandleFunc("GET /forgot-password", h.ForgotPassPage)
+	mux.HandleFunc("POST /forgot-password", h.ForgotPassPost)
+	mux.HandleFunc("GET /reset-password/{token}", h.ResetPassPage)
+	mux.HandleFunc("POST /reset-password/{token}", h.ResetPassPost)
+
+	// Own account: profile with recent logins, two-factor settings, sessions — any authenticated user
+	mux.Handle("GET /account", RequireAuth(http.HandlerFunc(h.Account)))
+	mux.Handle("POST /account/locale", RequireAuth(http.HandlerFunc(h.SetLocale)))
+	mux.Handle("GET /account/2fa", RequireAuth(http.HandlerFunc(h.TwoFactor.Settings)))
+	mux.Handle("POST /account/2fa/enable", RequireAuth(http.HandlerFunc(h.TwoFactor.Enable)))
+	mux.Handle("POST /account/2fa/disable", RequireAuth(http.HandlerFunc(h.TwoFactor.Disable)))
+	mux.Handle("POST /account/2fa/recovery-codes", RequireAuth(http.HandlerFunc(h.TwoFactor.RecoveryCodes)))
+	mux.Handle("GET /account/sessions", RequireAuth(http.HandlerFunc(h.Sessions.List)))
+	mux.Handle("POST /account/sessions/{id}/revoke", RequireAuth(http.HandlerFunc(h.Sessions.Revoke)))
+	mux.Handle("POST /account/sessions/revoke-others", RequireAuth(http.HandlerFunc(h.Sessions.RevokeOthers)))
+
+	// Dashboard — landing page of staff who can see orders
+	mux.Handle("GET /dashboard", can(permission.ViewOrders, h.Order.Dashboard))
+	mux.Handle("GET /dashboard/print", can(permission.ViewOrders, h.Order.PrintDashboard))
+	mux.Handle("GET /dashboard/labels", can(permission.ViewOrders, h.Order.PrintBatchLabels))
+
+	// Order routes — scanning a label advances the order
+	mux.Handle("POST /orders/{id}/advance", can(permission.AdvanceOrders, h.Order.AdvanceStatus))
+	mux.Handle("GET /orders/{id}/label", can(permission.ViewOrders, h.Order.PrintLabel))
+	mux.Handle("GET /scan", can(permission.AdvanceOrders, h.Order.ScanPage))
+	mux.Handle("POST /scan", can(permission.AdvanceOrders, h.Order.ScanPost))
+
+	// Shipping routes — drivers see batches and mark deliveries
+	mux.Handle("GET /shipping", can(permission.ViewShipping, h.Shipping.List))
+	mux.Handle("POST /shipping/batches", can(permission.ManageShipping, h.Shipping.CreateBatch))
+	mux.Handle("GET /shipping/batches/{id}", can(permission.ViewShipping, h.Shipping.Batch))
+	mux.Handle("GET /shipping/batches/{id}/manifest", can(permission.ViewShipping, h.Shipping.Manifest))
+	mux.Handle("GET /shipping/batches/{id}/export.csv", can(permission.ViewShipping, h.Shipping.Export))
+	mux.Handle("POST /shipping/batches/{id}/tracking", can(permission.ManageShipping, h.Shipping.SetTracking))
+	mux.Handle("POST /shipping/batches/{id}/ship", can(permission.ManageShipping, h.Shipping.Ship))
+	mux.Handle("POST /shipping/batches/{id}/orders/{orderID}/delivered", can(permission.DeliverShipments, h.Shipping.MarkDelivered))
 
-	// Dashboard — pharmacy staff landing page (order dashboard)
-	mux.Handle("GET /dashboard", RequirePharmacyStaff(http.HandlerFunc(h.Order.Dashboard)))
-	mux.Handle("GET /dashboard/print", RequirePharmacyStaff(http.HandlerFunc(h.Order.PrintDashboard)))
-	mux.Handle("GET /dashboard/labels", RequirePharmacyStaff(http.HandlerFunc(h.Order.PrintBatchLabels)))
-
-	// Order routes — RequirePharmacyStaff middleware
-	mux.Handle("POST /orders/{id}/advance", RequirePharmacyStaff(http.HandlerFunc(h.Order.AdvanceStatus)))
-	mux.Handle("GET /orders/{id}/label", RequirePharmacyStaff(http.HandlerFunc(h.Order.PrintLabel)))
-
-	// Notification routes — RequirePharmacyStaff middleware
-	mux.Handle("GET /notifications", RequirePharmacyStaff(http.HandlerFunc(h.Notification.List)))
-	mux.Handle("POST /notifications/{id}/read", RequirePharmacyStaff(http.HandlerFunc(h.Notification.MarkRead)))
-	mux.Handle("POST /notifications/read-all", RequirePharmacyStaff(http.HandlerFunc(h.Notification.MarkAllRead)))
-
-	// Admin routes — RequireAdmin middleware applied per-handler
-	mux.Handle("GET /admin", RequireAdmin(http.HandlerFunc(h.Admin.Dashboard)))
-	mux.Handle("GET /admin/pharmacies/new", RequireAdmin(http.HandlerFunc(h.Admin.NewPharmacy)))
-	mux.Handle("POST /admin/pharmacies", RequireAdmin(http.HandlerFunc(h.Admin.CreatePharmacy)))
-	mux.Handle("GET /admin/pharmacies/{id}", RequireAdmin(http.HandlerFunc(h.Admin.PharmacyDetail)))
-	mux.Handle("POST /admin/pharmacies/{id}", RequireAdmin(http.HandlerFunc(h.Admin.UpdatePharmacy)))
-	mux.Handle("GET /admin/pharmacies/{id}/personnel/new", RequireAdmin(http.HandlerFunc(h.Admin.AddPersonnel)))
-	mux.Handle("POST /admin/pharmacies/{id}/personnel", RequireAdmin(http.HandlerFunc(h.Admin.CreatePersonnel)))
-
-	// Owner routes — RequireOwner middleware applied per-handler
-	mux.Handle("GET /personnel", RequireOwner(http.HandlerFunc(h.Owner.PersonnelList)))
-	mux.Handle("GET /personnel/new", RequireOwner(http.HandlerFunc(h.Owner.AddPersonnel)))
-	mux.Handle("POST /personnel", RequireOwner(http.HandlerFunc(h.Owner.CreatePersonnel)))
-
-	// Patient routes — RequirePharmacyStaff middleware (owner + personnel)
-	mux.Handle("GET /patients", RequirePharmacyStaff(http.HandlerFunc(h.Patient.List)))
-	mux.Handle("GET /patients/new", RequirePharmacyStaff(http.HandlerFunc(h.Patient.New)))
-	mux.Handle("POST /patients", RequirePharmacyStaff(http.HandlerFunc(h.Patient.Create)))
-	mux.Handle("GET /patients/{id}", RequirePharmacyStaff(http.HandlerFunc(h.Patient.Detail)))
-	mux.Handle("POST /patients/{id}", RequirePharmacyStaff(http.HandlerFunc(h.Patient.Update)))
-	mux.Handle("POST /patients/{id}/consensus", RequirePharmacyStaff(http.HandlerFunc(h.Patient.SetConsensus)))
-
-	// Prescription routes — RequirePharmacyStaff middleware
-	mux.Handle("GET /patients/{id}/prescriptions/new", RequirePharmacyStaff(http.HandlerFunc(h.Prescription.New)))
-	mux.Handle("POST /patients/{id}/prescriptions", RequirePharmacyStaff(http.HandlerFunc(h.Prescription.Create)))
-	mux.Handle("GET /patients/{id}/prescriptions/{rxid}/edit", RequirePharmacyStaff(http.HandlerFunc(h.Prescription.Edit)))
-	mux.Handle("POST /patients/{id}/prescriptions/{rxid}", RequirePharmacyStaff(http.HandlerFunc(h.Prescription.Update)))
-	mux.Handle("POST /patients/{id}/prescriptions/{rxid}/refill", RequirePharmacyStaff(http.HandlerFunc(h.Prescription.RecordRefill)))
+	// Pickup routes — staff see and book slots, schedule managers set opening hours
+	mux.Handle("GET /pickup", can(permission.ViewOrders, h.Pickup.Day))
+	mux.Handle("GET /pickup/settings", can(permission.ManageSchedule, h.Pickup.Settings))
+	mux.Handle("POST /pickup/settings", can(permission.ManageSchedule, h.Pickup.SaveSettings))
+	mux.Handle("GET /orders/{id}/pickup", can(permission.AssignPickups, h.Pickup.AssignPage))
+	mux.Handle("POST /orders/{id}/pickup", can(permission.AssignPickups, h.Pickup.Assign))
+
+	// Closure calendar routes
+	mux.Handle("GET /calendar", can(permission.ManageSchedule, h.Calendar.Page))
+	mux.Handle("POST /calendar", can(permission.ManageSchedule, h.Calendar.SaveSettings))
+	mux.Handle("POST /calendar/closures", can(permission.ManageSchedule, h.Calendar.AddClosure))
+	mux.Handle("POST /calendar/closures/{id}/delete", can(permission.ManageSchedule, h.Calendar.DeleteClosure))
+
+	// Notification routes
+	mux.Handle("GET /notifications", can(permission.ViewOrders, h.Notification.List))
+	mux.Handle("POST /notifications/{id}/read", can(permission.ViewOrders, h.Notification.MarkRead))
+	mux.Handle("POST /notifications/read-all", can(permission.ViewOrders, h.Notification.MarkAllRead))
+
+	// Admin routes
+	mux.Handle("GET /admin", admin(http.HandlerFunc(h.Admin.Dashboard)))
+	mux.Handle("GET /admin/pharmacies/new", admin(http.HandlerFunc(h.Admin.NewPharmacy)))
+	mux.Handle("POST /admin/pharmacies", admin(http.HandlerFunc(h.Admin.CreatePharmacy)))
+	mux.Handle("GET /admin/pharmacies/{id}", admin(http.HandlerFunc(h.Admin.PharmacyDetail)))
+	mux.Handle("POST /admin/pharmacies/{id}", admin(http.HandlerFunc(h.Admin.UpdatePharmacy)))
+	mux.Handle("GET /admin/pharmacies/{id}/personnel/new", admin(http.HandlerFunc(h.Admin.AddPersonnel)))
+	mux.Handle("POST /admin/pharmacies/{id}/personnel", admin(http.HandlerFunc(h.Admin.CreatePersonnel)))
+	mux.Handle("POST /admin/pharmacies/{id}/sessions/revoke", admin(http.HandlerFunc(h.Admin.RevokeSessions)))
+	mux.Handle("GET /admin/pharmacies/{id}/sso", admin(http.HandlerFunc(h.Admin.SSOSettings)))
+	mux.Handle("POST /admin/pharmacies/{id}/sso", admin(http.HandlerFunc(h.Admin.SaveSSOSettings)))
+	mountPersonnel(mux, "/admin/pharmacies/{id}/personnel", admin, h.Admin.Personnel)
+
+	// Owner routes — personnel, custom roles, statistics, branches, settings
+	mux.Handle("GET /personnel", can(permission.ManagePersonnel, h.Owner.PersonnelList))
+	mux.Handle("GET /personnel/new", can(permission.ManagePersonnel, h.Owner.AddPersonnel))
+	mux.Handle("POST /personnel", can(permission.ManagePersonnel, h.Owner.CreatePersonnel))
+	mountPersonnel(mux, "/personnel", RequirePermission(permission.ManagePersonnel), h.Owner.Personnel)
+	mux.Handle("GET /roles", can(permission.ManageRoles, h.Owner.Roles.List))
+	mux.Handle("GET /roles/new", can(permission.ManageRoles, h.Owner.Roles.New))
+	mux.Handle("POST /roles", can(permission.ManageRoles, h.Owner.Roles.Create))
+	mux.Handle("GET /roles/{id}", can(permission.ManageRoles, h.Owner.Roles.Edit))
+	mux.Handle("POST /roles/{id}", can(permission.ManageRoles, h.Owner.Roles.Update))
+	mux.Handle("POST /roles/{id}/delete", can(permission.ManageRoles, h.Owner.Roles.Delete))
+	mux.Handle("GET /analytics", can(permission.ViewAnalytics, h.Owner.Analytics))
+	mux.Handle("GET /group", can(permission.SwitchBranch, h.Owner.Group))
+	mux.Handle("POST /group/switch", can(permission.SwitchBranch, h.Owner.SwitchBranch))
+	mux.Handle("GET /settings", can(permission.ManageSettings, h.Owner.Settings.Page))
+	mux.Handle("POST /settings", can(permission.ManageSettings, h.Owner.Settings.Update))
+	mux.Handle("POST /settings/logo", can(permission.ManageSettings, h.Owner.Settings.UploadLogo))
+	mux.Handle("POST /settings/logo/delete", can(permission.ManageSettings, h.Owner.Settings.RemoveLogo))
+	// The logo is printed on labels by anyone who sees orders
+	mux.Handle("GET /settings/logo", can(permission.ViewOrders, h.Owner.Settings.Logo))
+
+	// Patient routes
+	mux.Handle("GET /patients", can(permission.ViewPatients, h.Patient.List))
+	mux.Handle("GET /patients/new", can(permission.EditPatients, h.Patient.New))
+	mux.Handle("POST /patients", can(permission.EditPatients, h.Patient.Create))
+	mux.Handle("GET /patients/{id}", can(permission.ViewPatients, h.Patient.Detail))
+	mux.Handle("POST /patients/{id}", can(permission.EditPatients, h.Patient.Update))
+	mux.Handle("POST /patients/{id}/consensus", can(permission.EditPatients, h.Patient.SetConsensus))
+	mux.Handle("GET /patients/{id}/transfer", can(permission.TransferPatients, h.Patient.TransferPage))
+	mux.Handle("POST /patients/{id}/transfer", can(permission.TransferPatients, h.Patient.Transfer))
+
+	// Prescription routes
+	mux.Handle("GET /patients/{id}/prescriptions/new", can(permission.EditPrescriptions, h.Prescription.New))
+	mux.Handle("POST /patients/{id}/prescriptions", can(permission.EditPrescriptions, h.Prescription.Create))
+	mux.Handle("GET /patients/{id}/prescriptions/{rxid}/edit", can(permission.EditPrescriptions, h.Prescription.Edit))
+	mux.Handle("POST /patients/{id}/prescriptions/{rxid}", can(permission.EditPrescriptions, h.Prescription.Update))
+	mux.Handle("POST /patients/{id}/prescriptions/{rxid}/refill", can(permission.RecordRefills, h.Prescription.RecordRefill))
+	mux.Handle("POST /patients/{id}/prescriptions/{rxid}/stock", can(permission.RecordRefills, h.Prescription.ReportStock))
+
+	return mux
+}
+
+// NewPortalRouter builds the ServeMux of the patient portal. Every route
+// lives under /portal/ and is guarded by RequirePatient except the login steps.
+func NewPortalRouter(h PortalHandlers) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /portal/login", h.LoginPage)
+	mux.HandleFunc("POST /portal/login", h.LoginPost)
+	mux.HandleFunc("POST /portal/code", h.CodePost)
+	mux.HandleFunc("GET /portal/login/{token}", h.LinkPage)
+	mux.HandleFunc("POST /portal/login/{token}", h.LinkPost)
+	mux.HandleFunc("POST /portal/logout", h.Logout)
+
+	mux.Handle("GET /portal/{$}", RequirePatient(http.HandlerFunc(h.Home)))
+	mux.Handle("POST /portal/orders/{id}/confirm", RequirePatient(http.HandlerFunc(h.Confirm)))
+	mux.Handle("GET /portal/orders/{id}/postpone", RequirePatient(http.HandlerFunc(h.PostponePage)))
+	mux.Handle("POST /portal/orders/{id}/postpone", RequirePatient(http.HandlerFunc(h.Postpone)))
+	mux.Handle("POST /portal/prescriptions/{id}/stock", RequirePatient(http.HandlerFunc(h.ReportStock)))
 
 	return mux
 }
+
+// ProbeHandlers groups the endpoints called by machines, served without
+// sessions: orchestrators, monitoring and the SMS gateway. Metrics and
+// Replies are nil when no token is configured for them.
+type ProbeHandlers struct {
+	Healthz http.HandlerFunc
+	Readyz  http.HandlerFunc
+	Metrics http.Handler
+	Replies http.Handler
+}
+
+// Mount routes /portal/ to the patient portal chain, the probes to their
+// handlers and everything else to the staff chain. Each chain loads only its
+// own session; probes load none.
+func Mount(staff, portal http.Handler, probes ProbeHandlers) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/portal/", portal)
+	mux.Handle("/", staff)
+	mux.HandleFunc("GET /healthz", probes.Healthz)
+	mux.HandleFunc("GET /readyz", probes.Readyz)
+	if probes.Metrics != nil {
+		mux.Handle("GET /metrics", probes.Metrics)
+	}
+	if probes.Replies != nil {
+		mux.Handle("POST /inbound/sms", probes.Replies)
+	}
+	return mux
+}
+
+// can guards h with RequirePermission(p).
+func can(p permission.Permission, h http.HandlerFunc) http.Handler {
+	return RequirePermission(p)(h)
+}
+
+func mountPersonnel(mux *http.ServeMux, base string, guard func(http.Handler) http.Handler, h PersonnelHandlers) {
+	mux.Handle("GET "+base+"/{uid}", guard(http.HandlerFunc(h.Member)))
+	mux.Handle("POST "+base+"/{uid}/deactivate", guard(http.HandlerFunc(h.Deactivate)))
+	mux.Handle("POST "+base+"/{uid}/reactivate", guard(http.HandlerFunc(h.Reactivate)))
+	mux.Handle("POST "+base+"/{uid}/unlock", guard(http.HandlerFunc(h.Unlock)))
+	mux.Handle("POST "+base+"/{uid}/role", guard(http.HandlerFunc(h.ChangeRole)))
+	mux.Handle("POST "+base+"/{uid}/password", guard(http.HandlerFunc(h.ResetPassword)))
+	mux.Handle("POST "+base+"/{uid}/2fa/reset", guard(http.HandlerFunc(h.ResetTwoFactor)))
+	mux.Handle("POST "+base+"/{uid}/delete", guard(http.HandlerFunc(h.Remove)))
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/giorgiovilardo/pharmarecall/internal/permission"
 	"github.com/giorgiovilardo/pharmarecall/internal/web"
 )
 
@@ -19,8 +20,17 @@ func newTestStack() http.Handler {
 		LoginPage:      noopHandler,
 		LoginPost:      noopHandler,
 		Logout:         noopHandler,
+		Account:        noopHandler,
+		LoginCodePage:  noopHandler,
+		LoginCodePost:  noopHandler,
+		SSOLogin:       noopHandler,
+		SSOCallback:    noopHandler,
 		ChangePassPage: noopHandler,
 		ChangePassPost: noopHandler,
+		ForgotPassPage: noopHandler,
+		ForgotPassPost: noopHandler,
+		ResetPassPage:  noopHandler,
+		ResetPassPost:  noopHandler,
 		Admin: web.AdminHandlers{
 			Dashboard:       noopHandler,
 			NewPharmacy:     noopHandler,
@@ -74,6 +84,26 @@ func TestHealthCheck(t *testing.T) {
 	}
 }
 
+func TestProbesBypassSessions(t *testing.T) {
+	refuse := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no", http.StatusTeapot)
+	})
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	srv := httptest.NewServer(web.Mount(refuse, refuse, web.ProbeHandlers{Healthz: ok, Readyz: ok}))
+	defer srv.Close()
+
+	for path, want := range map[string]int{"/healthz": 200, "/readyz": 200, "/metrics": http.StatusTeapot} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("requesting %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s status = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
+
 func TestStaticFileServing(t *testing.T) {
 	srv := httptest.NewServer(newTestStack())
 	defer srv.Close()
@@ -88,3 +118,74 @@ func TestStaticFileServing(t *testing.T) {
 		t.Errorf("GET /static/custom.css status = %d, want 200", resp.StatusCode)
 	}
 }
+
+func TestPortalAndStaffSessionsAreIsolated(t *testing.T) {
+	staffSM := scs.New()
+	patientSM := scs.New()
+	patientSM.Cookie.Name = "pharmarecall_patient"
+
+	staffMux := http.NewServeMux()
+	staffMux.Handle("GET /dashboard", web.RequirePermission(permission.ViewOrders)(http.HandlerFunc(noopHandler)))
+	staffMux.HandleFunc("GET /setup-staff", func(w http.ResponseWriter, r *http.Request) {
+		staffSM.Put(r.Context(), "userID", int64(1))
+		staffSM.Put(r.Context(), "role", "owner")
+		staffSM.Put(r.Context(), "pharmacyID", int64(7))
+	})
+	portalMux := web.NewPortalRouter(web.PortalHandlers{
+		LoginPage:    noopHandler,
+		LoginPost:    noopHandler,
+		CodePost:     noopHandler,
+		LinkPage:     noopHandler,
+		LinkPost:     noopHandler,
+		Logout:       noopHandler,
+		Home:         noopHandler,
+		Confirm:      noopHandler,
+		PostponePage: noopHandler,
+		Postpone:     noopHandler,
+		ReportStock:  noopHandler,
+	})
+	portalMux.HandleFunc("GET /portal/setup-patient", func(w http.ResponseWriter, r *http.Request) {
+		patientSM.Put(r.Context(), "patientID", int64(3))
+		patientSM.Put(r.Context(), "pharmacyID", int64(7))
+	})
+	srv := httptest.NewServer(web.Mount(
+		staffSM.LoadAndSave(web.LoadUser(staffSM)(staffMux)),
+		patientSM.LoadAndSave(web.LoadPatient(patientSM)(portalMux)),
+		web.ProbeHandlers{Healthz: noopHandler, Readyz: noopHandler},
+	))
+	defer srv.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	cookiesFrom := func(path string) []*http.Cookie {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("requesting %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.Cookies()
+	}
+	get := func(path string, cookies []*http.Cookie) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("requesting %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := get("/portal/", cookiesFrom("/setup-staff")); resp.StatusCode != http.StatusSeeOther {
+		t.Errorf("portal with staff session: status = %d, want redirect to login", resp.StatusCode)
+	}
+	if resp := get("/dashboard", cookiesFrom("/portal/setup-patient")); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("dashboard with patient session: status = %d, want 403", resp.StatusCode)
+	}
+	if resp := get("/portal/", cookiesFrom("/portal/setup-patient")); resp.StatusCode != http.StatusOK {
+		t.Errorf("portal with patient session: status = %d, want 200", resp.StatusCode)
+	}
+}